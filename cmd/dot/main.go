@@ -2,12 +2,35 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/urfave/cli/v3"
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/diff"
+	"github.com/yourusername/dot/internal/discover"
+	"github.com/yourusername/dot/internal/doctor"
 	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/exporter"
+	"github.com/yourusername/dot/internal/importer"
+	"github.com/yourusername/dot/internal/journal"
 	"github.com/yourusername/dot/internal/linker"
+	"github.com/yourusername/dot/internal/lint"
+	"github.com/yourusername/dot/internal/pager"
+	"github.com/yourusername/dot/internal/presets"
+	"github.com/yourusername/dot/internal/secrets"
+	"github.com/yourusername/dot/internal/settings"
+	"github.com/yourusername/dot/internal/shell"
+	"github.com/yourusername/dot/internal/term"
+	"github.com/yourusername/dot/internal/utils"
+	"github.com/yourusername/dot/internal/xdg"
 )
 
 // Version information (injected by GoReleaser)
@@ -22,26 +45,436 @@ func main() {
 		fmt.Printf("version=%s commit=%s date=%s\n", version, commit, date)
 	}
 	app := &cli.Command{
-		Name:  "dot",
-		Usage: "Manage dotfiles with profiles",
+		Name:                  "dot",
+		Usage:                 "Manage dotfiles with profiles",
+		EnableShellCompletion: true,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "no-pager",
+				Usage: "Do not pipe long output through $PAGER",
+			},
+			&cli.BoolFlag{
+				Name:  "ascii",
+				Usage: "Use plain text markers instead of emoji (default when the locale is not UTF-8)",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				Usage:   "Path to an alternate global config file (default: $XDG_CONFIG_HOME/dot/config.toml)",
+				Sources: cli.EnvVars(settings.EnvVar),
+			},
+			&cli.StringFlag{
+				Name:    "home",
+				Aliases: []string{"target-root"},
+				Usage:   "Directory that ~ stands for in mapping targets, e.g. to fill an OS image or another user's home (default: your home directory)",
+				Sources: cli.EnvVars("DOT_HOME"),
+			},
+			&cli.BoolFlag{
+				Name:    "no-auto-host",
+				Usage:   "Do not include the [host:<hostname>] profile of this machine automatically",
+				Sources: cli.EnvVars("DOT_NO_AUTO_HOST"),
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output of list, check, link, and status: text, or json for scripts",
+				Value: outputText,
+			},
+		},
+		Before: func(ctx context.Context, c *cli.Command) (context.Context, error) {
+			if output := c.String("output"); output != outputText && output != outputJSON {
+				return ctx, fmt.Errorf("invalid --output %q, expected %q or %q", output, outputText, outputJSON)
+			}
+			term.ASCII = c.Bool("ascii") || !term.UTF8Locale()
+			// Hooks and other dot processes started from here see the same config
+			if path := c.String("config"); path != "" {
+				os.Setenv(settings.EnvVar, path)
+			}
+			if home := c.String("home"); home != "" {
+				abs, err := filepath.Abs(utils.ExpandPath(home))
+				if err != nil {
+					return ctx, fmt.Errorf("invalid --home %s: %w", home, err)
+				}
+				utils.TargetHome = abs
+				os.Setenv("DOT_HOME", abs)
+			}
+			if c.Bool("no-auto-host") {
+				config.AutoHost = false
+				os.Setenv("DOT_NO_AUTO_HOST", "true")
+			}
+			return ctx, loadGlobalConfig()
+		},
 		Commands: []*cli.Command{
+			addCmd(),
+			adoptCmd(),
+			backupsCmd(),
+			bundleCmd(),
 			checkCmd(),
 			cleanCmd(),
 			cloneCmd(),
+			decryptCmd(),
+			diffCmd(),
+			discoverCmd(),
+			disableCmd(),
+			doctorCmd(),
+			enableCmd(),
+			encryptCmd(),
+			exportCmd(),
+			importCmd(),
+			initCmd(),
+			keysCmd(),
 			linkCmd(),
+			lintCmd(),
 			listCmd(),
+			logCmd(),
 			openCmd(),
+			pushCmd(),
+			remoteCmd(),
+			reportCmd(),
 			rootCmd(),
+			shellIntegrationCmd(),
+			showCmd(),
+			statusCmd(),
+			syncCmd(),
+			undoCmd(),
+			unlinkCmd(),
 			updateCmd(),
+			watchCmd(),
+			whichCmd(),
 		},
 	}
 
 	if err := app.Run(context.Background(), os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var interrupted *linker.InterruptedError
+		if errors.As(err, &interrupted) {
+			os.Exit(interrupted.ExitCode())
+		}
+		// Like EX_TEMPFAIL, tells a scheduled run that trying again later may succeed
+		var network *dotfiles.NetworkError
+		if errors.As(err, &network) {
+			os.Exit(75)
+		}
 		os.Exit(1)
 	}
 }
 
+// Values of the global --output flag
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// jsonOutput reports whether --output json asks for JSON on stdout
+// Commands with an --output flag of their own, like report, shadow the global one
+func jsonOutput(c *cli.Command) bool {
+	return c.Root().String("output") == outputJSON
+}
+
+// defaultProfiles are the profiles of the global config that commands use when
+// --profile is not given
+var defaultProfiles []string
+
+// loadGlobalConfig moves dot's files to their current base directories, along with the
+// journal's paths into them, and applies the global config, which is read after the
+// move so a config.toml moved on this run counts
+func loadGlobalConfig() error {
+	moves, err := xdg.Migrate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, move := range moves {
+		if err := journal.Relocate(move.From, move.To); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to update the paths in the journal: %v\n", err)
+		}
+	}
+	return applySettings()
+}
+
+// applySettings applies the defaults of the global config: the dotfiles directory when
+// $DOT_DIR is not set, the color preference, the backup directory, and the profiles
+// A config that cannot be read fails the run, as every command would act on it
+func applySettings() error {
+	// Backups go to the store in the data directory unless the global config says otherwise
+	if dir, err := xdg.Data.Path("backups"); err == nil {
+		linker.BackupDir = dir
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.DotfilesDir != "" && os.Getenv("DOT_DIR") == "" {
+		dir, err := filepath.Abs(utils.ExpandPath(cfg.DotfilesDir))
+		if err != nil {
+			return fmt.Errorf("invalid dotfiles_dir %s: %w", cfg.DotfilesDir, err)
+		}
+		os.Setenv("DOT_DIR", dir)
+	}
+	if cfg.Color != "" {
+		term.ColorMode = cfg.Color
+	}
+	if cfg.AdjacentBackups {
+		linker.BackupDir = ""
+	} else if cfg.BackupDir != "" {
+		dir, err := filepath.Abs(utils.ExpandPath(cfg.BackupDir))
+		if err != nil {
+			return fmt.Errorf("invalid backup_dir %s: %w", cfg.BackupDir, err)
+		}
+		linker.BackupDir = dir
+	}
+	defaultProfiles = cfg.Profiles
+	return nil
+}
+
+// profilesOf returns the profiles of the --profile flag, or those of the global config
+// when the flag is not given and defaults to general
+func profilesOf(c *cli.Command) []string {
+	if !c.IsSet("profile") && c.String("profile") == "general" && len(defaultProfiles) > 0 {
+		return defaultProfiles
+	}
+	return linker.ParseProfiles(c.String("profile"))
+}
+
+// paged wraps an action so that its output is piped through the pager when it does not
+// fit on the terminal, unless --no-pager was given
+func paged(action cli.ActionFunc) cli.ActionFunc {
+	return func(ctx context.Context, c *cli.Command) error {
+		if c.Bool("no-pager") {
+			return action(ctx, c)
+		}
+
+		p := pager.Start()
+		err := action(ctx, c)
+		if stopErr := p.Stop(); err == nil {
+			err = stopErr
+		}
+		return err
+	}
+}
+
+// complete returns shell completion that offers the words returned by words, or the
+// flags of the command while a flag is being typed
+func complete(words func(c *cli.Command) []string) cli.ShellCompleteFunc {
+	return func(ctx context.Context, c *cli.Command) {
+		if args := os.Args; len(args) > 1 && strings.HasPrefix(args[len(args)-2], "-") {
+			cli.DefaultCompleteWithFlags(ctx, c)
+			return
+		}
+		for _, word := range words(c) {
+			fmt.Fprintln(c.Root().Writer, word)
+		}
+	}
+}
+
+// sshFlags are the flags of the commands that talk to remotes over SSH
+func sshFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "ssh-key",
+			Usage: "Private key to use for SSH remotes instead of the one git would pick",
+		},
+		&cli.StringFlag{
+			Name:  "ssh-command",
+			Usage: "Command git runs for SSH remotes, like GIT_SSH_COMMAND",
+		},
+	}
+}
+
+// reportFlag is the flag of the commands that can write a JSON report of their run
+func reportFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "report",
+		Usage: "Write a JSON report of the run, with the result of every mapping, to `FILE`",
+	}
+}
+
+// failFastFlag is the --fail-fast flag of the commands that change links
+func failFastFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "fail-fast",
+		Usage: "Stop at the first mapping that fails and roll back what the run changed",
+	}
+}
+
+// jobsFlag is the --jobs flag of the commands that process mappings concurrently
+func jobsFlag() cli.Flag {
+	return &cli.IntFlag{
+		Name:    "jobs",
+		Aliases: []string{"j"},
+		Usage:   "How many mappings to process at once, or 0 for one per CPU",
+		Value:   1,
+	}
+}
+
+// jobsOf returns the number of mappings --jobs processes at once
+func jobsOf(c *cli.Command) int {
+	if jobs := c.Int("jobs"); jobs > 0 {
+		return jobs
+	}
+	return runtime.NumCPU()
+}
+
+// sortFlag is the --sort flag of the commands that show every mapping
+func sortFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:  "sort",
+		Usage: "Order of the mappings: target, by target path, or source, by source in the repository",
+		Value: linker.SortTarget,
+	}
+}
+
+// setSSH passes the ssh flags on to git
+func setSSH(c *cli.Command) {
+	dotfiles.SSHKey = c.String("ssh-key")
+	dotfiles.SSHCommand = c.String("ssh-command")
+}
+
+func addCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "add",
+		Usage: "Add mappings for a common tool from a bundled preset",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "preset",
+				Usage: "Name of the preset to add (e.g. nvim, tmux, zsh, git)",
+			},
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Profile to add the mappings to",
+				Value: "general",
+			},
+			&cli.BoolFlag{
+				Name:  "list",
+				Usage: "List the available presets",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			if c.Bool("list") {
+				all, err := presets.Load()
+				if err != nil {
+					return err
+				}
+				for _, name := range presets.Names(all) {
+					fmt.Printf("%-12s %s\n", name, all[name].Description)
+				}
+				return nil
+			}
+
+			if c.String("preset") == "" {
+				return fmt.Errorf("--preset is required (use --list to see available presets)")
+			}
+			return presets.Add(c.String("preset"), c.String("profile"))
+		},
+	}
+}
+
+func adoptCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "adopt",
+		Usage:     "Move existing files into the repository, map them, and link them back into place",
+		ArgsUsage: "<target>... <source|dir/>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Profile to add the mappings to",
+				Value: "general",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			args := c.Args().Slice()
+			if len(args) < 2 {
+				return fmt.Errorf("at least one target and a source or directory in the repository are required")
+			}
+			return linker.AdoptFiles(args[:len(args)-1], args[len(args)-1], c.String("profile"))
+		},
+	}
+}
+
+func backupsCmd() *cli.Command {
+	profileFlag := func() cli.Flag {
+		return &cli.StringFlag{
+			Name:  "profile",
+			Usage: "Comma-separated list of profiles whose backups to handle, or \"all\" (default: profiles of the global config, or general)",
+			Value: "general",
+		}
+	}
+	flags := func() []cli.Flag {
+		return []cli.Flag{
+			profileFlag(),
+			&cli.DurationFlag{
+				Name:  "older-than",
+				Usage: "Only handle backups made at least this long ago, e.g. 720h",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "Handle every backup without asking",
+			},
+		}
+	}
+	options := func(c *cli.Command) linker.BackupOptions {
+		return linker.BackupOptions{OlderThan: c.Duration("older-than"), Yes: c.Bool("yes")}
+	}
+
+	return &cli.Command{
+		Name:  "backups",
+		Usage: "List, delete, or restore the backups link made of managed targets, in the backup store or as .bak files next to them",
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List backups with their age and whether they differ from the source, newest first",
+				Flags: []cli.Flag{profileFlag()},
+				Action: paged(func(_ context.Context, c *cli.Command) error {
+					return linker.ListBackups(profilesOf(c))
+				}),
+			},
+			{
+				Name:  "prune",
+				Usage: "Delete backups",
+				Flags: flags(),
+				Action: func(_ context.Context, c *cli.Command) error {
+					return linker.PruneBackups(profilesOf(c), options(c))
+				},
+			},
+			{
+				Name:      "restore",
+				Usage:     "Move the latest backups back in place of their links",
+				ArgsUsage: "[target...]",
+				Flags:     flags(),
+				ShellComplete: complete(func(c *cli.Command) []string {
+					return linker.Completions(profilesOf(c))
+				}),
+				Action: func(_ context.Context, c *cli.Command) error {
+					return linker.RestoreBackups(profilesOf(c), c.Args().Slice(), options(c))
+				},
+			},
+		},
+	}
+}
+
+func bundleCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "bundle",
+		Usage: "Generate a standalone shell script that creates the links of the specified profile(s) without dot",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Comma-separated list of profiles to bundle (default: profiles of the global config, or general)",
+				Value: "general",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output file, relative to the dotfiles directory (use - for stdout)",
+				Value:   "install.sh",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			profiles := profilesOf(c)
+			return exporter.Export(profiles, exporter.Shell, c.String("output"))
+		},
+	}
+}
+
 func checkCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "check",
@@ -49,14 +482,44 @@ func checkCmd() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "profile",
-				Usage: "Comma-separated list of profiles to check (default: general)",
+				Usage: "Comma-separated list of profiles to check, or \"all\" (default: profiles of the global config, or general)",
 				Value: "general",
 			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format: text, or annotations for GitHub Actions",
+				Value: linker.FormatText,
+			},
+			reportFlag(),
+			jobsFlag(),
+			sortFlag(),
+			&cli.BoolFlag{
+				Name:  "backups",
+				Usage: "List the backups of managed targets instead, like dot backups list",
+			},
 		},
-		Action: func(_ context.Context, c *cli.Command) error {
-			profiles := linker.ParseProfiles(c.String("profile"))
-			return linker.Check(profiles)
-		},
+		Action: paged(func(_ context.Context, c *cli.Command) error {
+			if c.Bool("backups") {
+				return linker.ListBackups(profilesOf(c))
+			}
+
+			format := c.String("format")
+			if format != linker.FormatText && format != linker.FormatAnnotations {
+				return fmt.Errorf("invalid format %q, expected %q or %q", format, linker.FormatText, linker.FormatAnnotations)
+			}
+			if format == linker.FormatAnnotations && jsonOutput(c) {
+				return fmt.Errorf("--format annotations cannot be combined with --output json")
+			}
+
+			profiles := profilesOf(c)
+			return linker.CheckWithOptions(profiles, linker.CheckOptions{
+				Format: format,
+				Report: c.String("report"),
+				JSON:   jsonOutput(c),
+				Jobs:   jobsOf(c),
+				Sort:   c.String("sort"),
+			})
+		}),
 	}
 }
 
@@ -67,13 +530,34 @@ func cleanCmd() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "profile",
-				Usage: "Comma-separated list of profiles to clean (default: general)",
+				Usage: "Comma-separated list of profiles to clean, or \"all\" (default: profiles of the global config, or general)",
 				Value: "general",
 			},
+			&cli.BoolFlag{
+				Name:  "all-profiles",
+				Usage: "Clean the links of every profile in .mappings",
+			},
+			&cli.BoolFlag{
+				Name:    "interactive",
+				Aliases: []string{"i"},
+				Usage:   "List the managed links and remove only the ones picked, after a preview",
+			},
+			&cli.BoolFlag{
+				Name:  "restore-backups",
+				Usage: "Move the latest backups that link made back into place after removing the links",
+			},
+			failFastFlag(),
 		},
 		Action: func(_ context.Context, c *cli.Command) error {
-			profiles := linker.ParseProfiles(c.String("profile"))
-			return linker.Clean(profiles)
+			profiles := profilesOf(c)
+			if c.Bool("all-profiles") {
+				profiles = []string{config.AllProfiles}
+			}
+			return linker.CleanWithOptions(profiles, linker.CleanOptions{
+				FailFast:       c.Bool("fail-fast"),
+				Interactive:    c.Bool("interactive"),
+				RestoreBackups: c.Bool("restore-backups"),
+			})
 		},
 	}
 }
@@ -83,35 +567,529 @@ func cloneCmd() *cli.Command {
 		Name:      "clone",
 		Usage:     "Clone a dotfiles repository from a remote URL to ~/.dotfiles",
 		ArgsUsage: "<repository-url>",
+		Flags:     sshFlags(),
 		Action: func(_ context.Context, c *cli.Command) error {
 			if c.Args().Len() != 1 {
 				return fmt.Errorf("exactly one argument (repository URL) is required")
 			}
+			setSSH(c)
 			return dotfiles.Clone(c.Args().First())
 		},
 	}
 }
 
+func decryptCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "decrypt",
+		Usage:     "Write the plaintext of .age or .gpg files next to them, readable only by you",
+		ArgsUsage: "<file>...",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "stdout",
+				Usage: "Print the plaintext instead of writing it to a file",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			if c.Args().Len() == 0 {
+				return fmt.Errorf("at least one file is required")
+			}
+			return secrets.Decrypt(c.Args().Slice(), secrets.DecryptOptions{Stdout: c.Bool("stdout")})
+		},
+	}
+}
+
+func diffCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "diff",
+		Usage: "Show how targets that are real files or edited copies differ from their sources",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Comma-separated list of profiles to compare, or \"all\" (default: profiles of the global config, or general)",
+				Value: "general",
+			},
+			&cli.IntFlag{
+				Name:    "unified",
+				Aliases: []string{"U"},
+				Usage:   "Number of unchanged lines shown around each change",
+				Value:   diff.DefaultContext,
+			},
+			&cli.BoolFlag{
+				Name:  "color",
+				Usage: "Color the diff and highlight the changed words",
+			},
+		},
+		Action: paged(func(_ context.Context, c *cli.Command) error {
+			return linker.Diff(profilesOf(c), linker.DiffOptions{
+				Context: c.Int("unified"),
+				Color:   c.Bool("color"),
+			})
+		}),
+	}
+}
+
+func discoverCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "discover",
+		Usage: "Find well-known dotfiles in the home directory that are not managed yet and offer to adopt them",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Profile to add adopted files to",
+				Value: "general",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "Adopt every unmanaged dotfile without prompting",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			return discover.Run(os.Stdin, c.String("profile"), c.Bool("yes"))
+		},
+	}
+}
+
+func disableCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "disable",
+		Usage:     "Switch off a profile or source on this machine, without editing .mappings",
+		ArgsUsage: "<profile|source>",
+		ShellComplete: complete(func(_ *cli.Command) []string {
+			return linker.DisableCompletions()
+		}),
+		Action: func(_ context.Context, c *cli.Command) error {
+			if c.Args().Len() != 1 {
+				return fmt.Errorf("exactly one argument (profile or source) is required")
+			}
+			return linker.Disable(c.Args().First())
+		},
+	}
+}
+
+func doctorCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "doctor",
+		Usage: "Probe the capabilities dot relies on and suggest how to fix what is missing",
+		Action: func(_ context.Context, _ *cli.Command) error {
+			return doctor.Run(os.Stdout)
+		},
+	}
+}
+
+func enableCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "enable",
+		Usage:     "Switch a profile or source disabled on this machine back on",
+		ArgsUsage: "<profile|source>",
+		ShellComplete: complete(func(_ *cli.Command) []string {
+			return linker.EnableCompletions()
+		}),
+		Action: func(_ context.Context, c *cli.Command) error {
+			if c.Args().Len() != 1 {
+				return fmt.Errorf("exactly one argument (profile or source) is required")
+			}
+			return linker.Enable(c.Args().First())
+		},
+	}
+}
+
+func encryptCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "encrypt",
+		Usage:     "Write an encrypted copy of files next to them, to the repository's age recipients or with gpg",
+		ArgsUsage: "<file>...",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "gpg",
+				Usage: "Encrypt with gpg to a .gpg file instead of with age",
+			},
+			&cli.StringSliceFlag{
+				Name:  "recipient",
+				Usage: "GnuPG key to encrypt to with --gpg (default: your default key)",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			if c.Args().Len() == 0 {
+				return fmt.Errorf("at least one file is required")
+			}
+			return secrets.Encrypt(c.Args().Slice(), secrets.EncryptOptions{
+				GPG:        c.Bool("gpg"),
+				Recipients: c.StringSlice("recipient"),
+			})
+		},
+	}
+}
+
+func exportCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Generate configuration for other dotfiles tools from the specified profile(s)",
+		Commands: []*cli.Command{
+			{
+				Name:  "dotbot",
+				Usage: "Generate a dotbot install.conf.yaml with equivalent link directives",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "profile",
+						Usage: "Comma-separated list of profiles to export (default: profiles of the global config, or general)",
+						Value: "general",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Output file, relative to the dotfiles directory (use - for stdout)",
+						Value:   "install.conf.yaml",
+					},
+				},
+				Action: func(_ context.Context, c *cli.Command) error {
+					profiles := profilesOf(c)
+					return exporter.Export(profiles, exporter.Dotbot, c.String("output"))
+				},
+			},
+		},
+	}
+}
+
+func importCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "Convert dotfiles managed by another tool into a dot repository",
+		Commands: []*cli.Command{
+			{
+				Name:      "bare",
+				Usage:     "Turn a bare repository with $HOME as its work tree into a dot repository, keeping its git history",
+				ArgsUsage: "<git-dir>",
+				Action: func(_ context.Context, c *cli.Command) error {
+					if c.Args().Len() != 1 {
+						return fmt.Errorf("exactly one argument (bare repository, e.g. ~/.cfg) is required")
+					}
+					homeDir, err := os.UserHomeDir()
+					if err != nil {
+						return fmt.Errorf("failed to get user home directory: %w", err)
+					}
+					dotfilesDir, err := dotfiles.GetDotfilesDir()
+					if err != nil {
+						return err
+					}
+
+					result, err := importer.Bare(homeDir, utils.ExpandPath(c.Args().First()), dotfilesDir)
+					if err != nil {
+						return err
+					}
+					importer.PrintSummary(result, dotfilesDir)
+					return nil
+				},
+			},
+			{
+				Name:      "homesick",
+				Aliases:   []string{"homeshick"},
+				Usage:     "Generate .mappings for a homesick/homeshick castle in place, keeping its git history",
+				ArgsUsage: "<castle-dir>",
+				Action: func(_ context.Context, c *cli.Command) error {
+					if c.Args().Len() != 1 {
+						return fmt.Errorf("exactly one argument (castle directory) is required")
+					}
+					castleDir := c.Args().First()
+
+					result, err := importer.Homesick(castleDir)
+					if err != nil {
+						return err
+					}
+					importer.PrintSummary(result, castleDir)
+					fmt.Fprintf(os.Stderr, "Set DOT_DIR=%s (or move the castle to ~/.dotfiles) to manage it with dot\n", castleDir)
+					return nil
+				},
+			},
+			{
+				Name:      "stow",
+				Usage:     "Generate .mappings for the packages of a GNU stow directory, in place or moved into the dotfiles directory",
+				ArgsUsage: "<stow-dir>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "target",
+						Usage: "Directory the packages are stowed into, like stow --target (default: parent of the stow directory)",
+					},
+					&cli.BoolFlag{
+						Name:  "dotfiles",
+						Usage: "Turn a dot- prefix into a dot in targets, like stow --dotfiles",
+					},
+					&cli.BoolFlag{
+						Name:  "move",
+						Usage: "Move the packages into the dotfiles directory instead of using the stow directory as it",
+					},
+				},
+				Action: func(_ context.Context, c *cli.Command) error {
+					if c.Args().Len() != 1 {
+						return fmt.Errorf("exactly one argument (stow directory) is required")
+					}
+					stowDir, err := filepath.Abs(utils.ExpandPath(c.Args().First()))
+					if err != nil {
+						return fmt.Errorf("invalid stow directory %s: %w", c.Args().First(), err)
+					}
+					targetDir := filepath.Dir(stowDir)
+					if target := c.String("target"); target != "" {
+						if targetDir, err = filepath.Abs(utils.ExpandPath(target)); err != nil {
+							return fmt.Errorf("invalid --target %s: %w", target, err)
+						}
+					}
+					dotfilesDir, err := dotfiles.GetDotfilesDir()
+					if err != nil {
+						return err
+					}
+
+					opts := importer.StowOptions{Dotfiles: c.Bool("dotfiles"), Move: c.Bool("move")}
+					result, err := importer.Stow(stowDir, targetDir, dotfilesDir, opts)
+					if err != nil {
+						return err
+					}
+					if !opts.Move {
+						dotfilesDir = stowDir
+					}
+					importer.PrintSummary(result, dotfilesDir)
+					if !opts.Move {
+						fmt.Fprintf(os.Stderr, "Set DOT_DIR=%s (or move the stow directory to ~/.dotfiles) to manage it with dot\n", stowDir)
+					}
+					fmt.Fprintln(os.Stderr, "'dot link' replaces the links that stow made with its own")
+					return nil
+				},
+			},
+			{
+				Name:  "yadm",
+				Usage: "Import files tracked by yadm, deriving profiles from ##alternate conditions",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "repo",
+						Usage: "Path to the yadm repository (default: $XDG_DATA_HOME/yadm/repo.git)",
+					},
+				},
+				Action: func(_ context.Context, c *cli.Command) error {
+					homeDir, err := os.UserHomeDir()
+					if err != nil {
+						return fmt.Errorf("failed to get user home directory: %w", err)
+					}
+					dotfilesDir, err := dotfiles.GetDotfilesDir()
+					if err != nil {
+						return err
+					}
+
+					repoDir := c.String("repo")
+					if repoDir == "" {
+						repoDir = importer.YadmRepoPath(homeDir)
+					}
+
+					result, err := importer.Yadm(homeDir, repoDir, dotfilesDir)
+					if err != nil {
+						return err
+					}
+					importer.PrintSummary(result, dotfilesDir)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func initCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "init",
+		Usage: "Create a new dotfiles repository with a starter .mappings file",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "discover",
+				Usage: "Then scan the home directory for well-known dotfiles and offer to adopt them",
+			},
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Profile to add adopted files to",
+				Value: "general",
+			},
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "With --discover, adopt every unmanaged dotfile without prompting",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			if err := dotfiles.Init(); err != nil {
+				return err
+			}
+			if !c.Bool("discover") {
+				fmt.Fprintln(os.Stderr, "Run 'dot discover' to adopt the dotfiles already in your home directory")
+				return nil
+			}
+			return discover.Run(os.Stdin, c.String("profile"), c.Bool("yes"))
+		},
+	}
+}
+
+func keysCmd() *cli.Command {
+	nameFlag := &cli.StringFlag{
+		Name:  "name",
+		Usage: "Name of the machine or person the recipient belongs to (default: this machine's hostname)",
+	}
+	return &cli.Command{
+		Name:  "keys",
+		Usage: "Manage the age identities of this machine and the recipients secrets are encrypted to",
+		Commands: []*cli.Command{
+			{
+				Name:  "generate",
+				Usage: "Create an age identity for this machine and add its recipient to the repository",
+				Flags: []cli.Flag{nameFlag},
+				Action: func(_ context.Context, c *cli.Command) error {
+					return secrets.Generate(c.String("name"))
+				},
+			},
+			{
+				Name:      "add",
+				Usage:     "Add recipients to the repository, or import an identity with --identity",
+				ArgsUsage: "<recipient>...",
+				Flags: []cli.Flag{
+					nameFlag,
+					&cli.StringFlag{
+						Name:  "identity",
+						Usage: "Import the age identity file `FILE` as this machine's and add its recipient",
+					},
+				},
+				Action: func(_ context.Context, c *cli.Command) error {
+					if identity := c.String("identity"); identity != "" {
+						if c.Args().Len() > 0 {
+							return fmt.Errorf("recipients cannot be given with --identity")
+						}
+						return secrets.Import(identity, c.String("name"))
+					}
+					if c.Args().Len() == 0 {
+						return fmt.Errorf("at least one recipient is required")
+					}
+					return secrets.AddRecipients(c.Args().Slice(), c.String("name"))
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List the repository's recipients and this machine's identities",
+				Action: func(_ context.Context, _ *cli.Command) error {
+					return secrets.List()
+				},
+			},
+			{
+				Name:  "export",
+				Usage: "Print the recipients of this machine's identities, or the identities with --secret",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "secret",
+						Usage: "Print the private identities, to import them on another machine",
+					},
+				},
+				Action: func(_ context.Context, c *cli.Command) error {
+					return secrets.Export(c.Bool("secret"))
+				},
+			},
+		},
+	}
+}
+
 func linkCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "link",
 		Usage: "Create symbolic links in the home directory based on the .mappings file for the specified profile(s)",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:  "profile",
-				Usage: "Comma-separated list of profiles to link (default: general)",
+				Usage: "Comma-separated list of profiles to link (default: profiles of the global config, or general)",
 				Value: "general",
 			},
+		}, linkFlags("Simulate link creation without performing I/O operations")...),
+		Action: func(_ context.Context, c *cli.Command) error {
+			opts, err := linkOptionsOf(c)
+			if err != nil {
+				return err
+			}
+			opts.JSON = jsonOutput(c)
+			return linker.LinkWithOptions(profilesOf(c), opts)
+		},
+	}
+}
+
+// linkFlags are the flags of link that sync shares, with dryRun describing --dry-run
+func linkFlags(dryRun string) []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "dry-run",
+			Aliases: []string{"n"},
+			Usage:   dryRun,
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "Fail without linking anything if a source file is missing",
+		},
+		&cli.BoolFlag{
+			Name:  "ignore-missing-sources",
+			Usage: "Skip missing source files without a warning",
+		},
+		&cli.StringFlag{
+			Name:  "on-conflict",
+			Usage: "What to do with a file or another link at a target: backup, skip, overwrite, adopt, or prompt (default: on_conflict from the global config, or backup)",
+		},
+		reportFlag(),
+		&cli.BoolFlag{
+			Name:  "timings",
+			Usage: "Print how long each phase of the run and the slowest mappings took",
+		},
+		failFastFlag(),
+		&cli.BoolFlag{
+			Name:  "no-hooks",
+			Usage: "Skip the pre_link, on_change, and post_link hooks",
+		},
+		jobsFlag(),
+		sortFlag(),
+	}
+}
+
+// linkOptionsOf returns the link options that the flags of linkFlags and the global
+// config give
+func linkOptionsOf(c *cli.Command) (linker.LinkOptions, error) {
+	ignoreMissing := c.Bool("ignore-missing-sources")
+	if ignoreMissing && c.Bool("strict") {
+		return linker.LinkOptions{}, fmt.Errorf("--strict and --ignore-missing-sources cannot be used together")
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		return linker.LinkOptions{}, err
+	}
+
+	onConflict := c.String("on-conflict")
+	if onConflict == "" {
+		onConflict = cfg.OnConflict
+	}
+
+	return linker.LinkOptions{
+		DryRun:        c.Bool("dry-run"),
+		Strict:        c.Bool("strict") || (cfg.Strict && !ignoreMissing),
+		IgnoreMissing: ignoreMissing,
+		Report:        c.String("report"),
+		OnConflict:    onConflict,
+		Timings:       c.Bool("timings"),
+		FailFast:      c.Bool("fail-fast"),
+		NoHooks:       c.Bool("no-hooks"),
+		Jobs:          jobsOf(c),
+		Sort:          c.String("sort"),
+	}, nil
+}
+
+func lintCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "lint",
+		Usage:     "Check the dotfiles repository for broken symlinks, CRLF shell configs, and other hygiene problems",
+		ArgsUsage: "[file...]",
+		Flags: []cli.Flag{
 			&cli.BoolFlag{
-				Name:    "dry-run",
-				Aliases: []string{"n"},
-				Usage:   "Simulate link creation without performing I/O operations",
+				Name:  "strict",
+				Usage: "Fail on warnings too, not only on errors",
 			},
 		},
 		Action: func(_ context.Context, c *cli.Command) error {
-			profiles := linker.ParseProfiles(c.String("profile"))
-			dryRun := c.Bool("dry-run")
-			return linker.Link(profiles, dryRun)
+			return lint.Run(os.Stdout, c.Args().Slice(), c.Bool("strict"))
 		},
 	}
 }
@@ -123,13 +1101,75 @@ func listCmd() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "profile",
-				Usage: "Comma-separated list of profiles to list (default: general)",
+				Usage: "Comma-separated list of profiles to list, or \"all\" (default: profiles of the global config, or general)",
 				Value: "general",
 			},
+			&cli.BoolFlag{
+				Name:  "unmanaged",
+				Usage: "Also show top-level dotfiles in the home directory that are not managed",
+			},
+			&cli.BoolFlag{
+				Name:  "porcelain",
+				Usage: "Print tab-separated lines in a stable format for scripts",
+			},
+			&cli.BoolFlag{
+				Name:    "long",
+				Aliases: []string{"l"},
+				Usage:   "Also show the size and modification time of each source and when it was last linked",
+			},
+			sortFlag(),
+		},
+		Action: paged(func(_ context.Context, c *cli.Command) error {
+			profiles := profilesOf(c)
+			porcelain := c.Bool("porcelain")
+			if jsonOutput(c) && (porcelain || c.Bool("unmanaged")) {
+				return fmt.Errorf("--output json cannot be combined with --porcelain or --unmanaged")
+			}
+			opts := linker.ListOptions{Porcelain: porcelain, Long: c.Bool("long"), JSON: jsonOutput(c), Sort: c.String("sort")}
+			if err := linker.ListWithOptions(profiles, opts); err != nil {
+				return err
+			}
+			if c.Bool("unmanaged") {
+				return discover.ListUnmanaged(porcelain)
+			}
+			return nil
+		}),
+	}
+}
+
+func reportCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "report",
+		Usage: "Render the mappings of the specified profile(s) as a Markdown or HTML table",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Comma-separated list of profiles to report",
+				Value: config.AllProfiles,
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Table format: markdown or html",
+				Value: "markdown",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output file, relative to the dotfiles directory (use - for stdout)",
+				Value:   "-",
+			},
 		},
 		Action: func(_ context.Context, c *cli.Command) error {
-			profiles := linker.ParseProfiles(c.String("profile"))
-			return linker.List(profiles)
+			var format exporter.ReportFormat
+			switch c.String("format") {
+			case "markdown", "md":
+				format = exporter.MarkdownReport
+			case "html":
+				format = exporter.HTMLReport
+			default:
+				return fmt.Errorf("invalid --format %q: use markdown or html", c.String("format"))
+			}
+			return exporter.Report(profilesOf(c), format, c.String("output"))
 		},
 	}
 }
@@ -144,16 +1184,342 @@ func rootCmd() *cli.Command {
 	}
 }
 
+func shellIntegrationCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "shell-integration",
+		Usage: "Print shell functions such as dotcd, to be evaluated by the shell config",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "shell",
+				Usage: "Shell to print the functions for: bash, zsh, or fish (default: from $SHELL)",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			script, err := shell.Integration(c.String("shell"))
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		},
+	}
+}
+
+func showCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "show",
+		Usage:     "Print the content of the source a target is linked to",
+		ArgsUsage: "<target|source>",
+		ShellComplete: complete(func(c *cli.Command) []string {
+			return linker.Completions(profilesOf(c))
+		}),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Comma-separated list of profiles to look the target up in",
+				Value: config.AllProfiles,
+			},
+			&cli.BoolFlag{
+				Name:    "line-numbers",
+				Aliases: []string{"n"},
+				Usage:   "Number the lines",
+			},
+			&cli.BoolFlag{
+				Name:  "highlight",
+				Usage: "Color comments, section headers, and keys",
+			},
+			&cli.BoolFlag{
+				Name:  "rendered",
+				Usage: "Render a template or decrypt a secret now and print what link would write",
+			},
+			&cli.BoolFlag{
+				Name:  "mask",
+				Usage: "With --rendered, hide the values of a decrypted secret",
+			},
+		},
+		Action: paged(func(_ context.Context, c *cli.Command) error {
+			if c.Args().Len() != 1 {
+				return fmt.Errorf("exactly one argument (target or source) is required")
+			}
+			if c.Bool("mask") && !c.Bool("rendered") {
+				return fmt.Errorf("--mask requires --rendered")
+			}
+			return linker.Show(profilesOf(c), c.Args().First(), linker.ShowOptions{
+				LineNumbers: c.Bool("line-numbers"),
+				Highlight:   c.Bool("highlight"),
+				Rendered:    c.Bool("rendered"),
+				Mask:        c.Bool("mask"),
+			})
+		}),
+	}
+}
+
+func statusCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "status",
+		Usage: "Show the git state of the dotfiles repository and how many links of each profile are healthy",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Comma-separated list of profiles to summarize, or \"all\" (default: profiles of the global config, or general)",
+				Value: "general",
+			},
+		},
+		Action: paged(func(_ context.Context, c *cli.Command) error {
+			profiles := profilesOf(c)
+			return linker.Status(profiles, linker.StatusOptions{JSON: jsonOutput(c)})
+		}),
+	}
+}
+
+func whichCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "which",
+		Usage:     "Print the path of the source a target is linked to",
+		ArgsUsage: "<target|source>",
+		ShellComplete: complete(func(c *cli.Command) []string {
+			return linker.Completions(profilesOf(c))
+		}),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Comma-separated list of profiles to look the target up in",
+				Value: config.AllProfiles,
+			},
+			&cli.BoolFlag{
+				Name:  "dir",
+				Usage: "Print the directory holding the source instead",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			if c.Args().Len() != 1 {
+				return fmt.Errorf("exactly one argument (target or source) is required")
+			}
+			return linker.Which(profilesOf(c), c.Args().First(), c.Bool("dir"))
+		},
+	}
+}
+
+func logCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "log",
+		Usage: "Show the history of runs that changed the filesystem",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "limit",
+				Aliases: []string{"n"},
+				Usage:   "Number of runs to show, 0 for all",
+				Value:   20,
+			},
+			&cli.BoolFlag{
+				Name:    "verbose",
+				Aliases: []string{"v"},
+				Usage:   "Show every change made by each run",
+			},
+		},
+		Action: paged(func(_ context.Context, c *cli.Command) error {
+			return journal.Log(os.Stdout, c.Int("limit"), c.Bool("verbose"))
+		}),
+	}
+}
+
+func pushCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "push",
+		Usage: "Push the dotfiles repository to every configured remote",
+		Flags: sshFlags(),
+		Action: func(_ context.Context, c *cli.Command) error {
+			setSSH(c)
+			return dotfiles.Push()
+		},
+	}
+}
+
+func remoteCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "remote",
+		Usage: "Manage the git remotes the dotfiles repository is pushed to",
+		Commands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Add a remote",
+				ArgsUsage: "<name> <url>",
+				Action: func(_ context.Context, c *cli.Command) error {
+					if c.Args().Len() != 2 {
+						return fmt.Errorf("exactly two arguments (remote name and URL) are required")
+					}
+					return dotfiles.AddRemote(c.Args().Get(0), c.Args().Get(1))
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List remotes",
+				Action: func(_ context.Context, _ *cli.Command) error {
+					return dotfiles.PrintRemotes()
+				},
+			},
+			{
+				Name:      "remove",
+				Aliases:   []string{"rm"},
+				Usage:     "Remove a remote",
+				ArgsUsage: "<name>",
+				Action: func(_ context.Context, c *cli.Command) error {
+					if c.Args().Len() != 1 {
+						return fmt.Errorf("exactly one argument (remote name) is required")
+					}
+					return dotfiles.RemoveRemote(c.Args().First())
+				},
+			},
+		},
+	}
+}
+
+func syncCmd() *cli.Command {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  "profile",
+			Usage: "Comma-separated list of profiles to sync (default: profiles of the global config, or general)",
+			Value: "general",
+		},
+		&cli.BoolFlag{
+			Name:  "adopt-changes",
+			Usage: "Copy edits made to copied files back into their sources in the repository first",
+		},
+		&cli.BoolFlag{
+			Name:  "commit",
+			Usage: "Commit the adopted sources to the dotfiles repository",
+		},
+		&cli.BoolFlag{
+			Name:  "push",
+			Usage: "Push the repository to every remote once linked without failures, like dot push",
+		},
+	}
+	flags = append(flags, linkFlags("Show what would be adopted and linked without pulling or changing anything")...)
+
+	return &cli.Command{
+		Name:  "sync",
+		Usage: "Pull the dotfiles repository, link the profile(s), and summarize what changed",
+		Flags: append(flags, sshFlags()...),
+		Action: func(_ context.Context, c *cli.Command) error {
+			setSSH(c)
+			opts, err := linkOptionsOf(c)
+			if err != nil {
+				return err
+			}
+
+			profiles := profilesOf(c)
+			if c.Bool("adopt-changes") {
+				if err := linker.AdoptChanges(profiles, linker.AdoptOptions{
+					DryRun: opts.DryRun,
+					Commit: c.Bool("commit"),
+				}); err != nil {
+					return err
+				}
+			}
+
+			return linker.Sync(profiles, linker.SyncOptions{LinkOptions: opts, Push: c.Bool("push")})
+		},
+	}
+}
+
+func undoCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "undo",
+		Usage: "Revert the most recent link or clean run",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "steps",
+				Usage: "Number of runs to revert, most recent first",
+				Value: 1,
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			return linker.Undo(c.Int("steps"))
+		},
+	}
+}
+
+func unlinkCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "unlink",
+		Usage:     "Remove the links of single mappings, leaving the rest of the profile linked",
+		ArgsUsage: "<target|source>...",
+		ShellComplete: complete(func(c *cli.Command) []string {
+			return linker.Completions(profilesOf(c))
+		}),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Comma-separated list of profiles to look the mappings up in",
+				Value: config.AllProfiles,
+			},
+			&cli.BoolFlag{
+				Name:  "restore-backup",
+				Usage: "Move the latest backup that link made back into place",
+			},
+		},
+		Action: func(_ context.Context, c *cli.Command) error {
+			if c.Args().Len() == 0 {
+				return fmt.Errorf("at least one target or source is required")
+			}
+			return linker.Unlink(profilesOf(c), c.Args().Slice(), linker.UnlinkOptions{
+				RestoreBackups: c.Bool("restore-backup"),
+			})
+		},
+	}
+}
+
 func updateCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "update",
 		Usage: "Update the dotfiles repository by running git pull",
-		Action: func(_ context.Context, _ *cli.Command) error {
+		Flags: sshFlags(),
+		Action: func(_ context.Context, c *cli.Command) error {
+			setSSH(c)
 			return dotfiles.Update()
 		},
 	}
 }
 
+func watchCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "watch",
+		Usage: "Keep watching the links of the specified profile(s) and warn when one is replaced by a file",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Comma-separated list of profiles to watch (default: profiles of the global config, or general)",
+				Value: "general",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "Time between two looks at the links",
+				Value: 5 * time.Second,
+			},
+			&cli.StringFlag{
+				Name:  "on-replace",
+				Usage: "What to do with a link replaced by a file: warn, relink (back up the file and link again), or adopt (move the file into the repository and link again)",
+				Value: linker.OnReplaceWarn,
+			},
+			&cli.BoolFlag{
+				Name:  "link",
+				Usage: "Also watch the dotfiles repository and link the mappings its changes affect, or all of them when .mappings changes",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			profiles := profilesOf(c)
+			return linker.Watch(ctx, profiles, linker.WatchOptions{
+				Interval:  c.Duration("interval"),
+				OnReplace: c.String("on-replace"),
+				Link:      c.Bool("link"),
+			})
+		},
+	}
+}
+
 func openCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "open",
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadGlobalConfig(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("DOT_CONFIG", "")
+	t.Setenv("DOT_DIR", "")
+
+	t.Run("Reads a config moved on the same run", func(t *testing.T) {
+		legacyDir := filepath.Join(homeDir, ".config", "dot")
+		os.MkdirAll(legacyDir, 0755)
+		os.WriteFile(filepath.Join(legacyDir, "config.toml"), []byte("dotfiles_dir = \"~/mydots\"\n"), 0644)
+		configHome := filepath.Join(homeDir, "config")
+		t.Setenv("XDG_CONFIG_HOME", configHome)
+
+		if err := loadGlobalConfig(); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(configHome, "dot", "config.toml")); err != nil {
+			t.Errorf("Expected the config to be moved: %v", err)
+		}
+		expected := filepath.Join(homeDir, "mydots")
+		if dir := os.Getenv("DOT_DIR"); dir != expected {
+			t.Errorf("Expected DOT_DIR %s, got %s", expected, dir)
+		}
+	})
+
+	t.Run("Fails on a malformed config", func(t *testing.T) {
+		configHome := filepath.Join(homeDir, "broken")
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("color = \n"), 0644)
+		t.Setenv("XDG_CONFIG_HOME", configHome)
+
+		if err := loadGlobalConfig(); err == nil {
+			t.Error("Expected an error for a malformed config")
+		}
+	})
+}
@@ -4,5 +4,8 @@ go 1.24.0
 
 require (
 	github.com/BurntSushi/toml v1.5.0
+	github.com/fsnotify/fsnotify v1.10.1
 	github.com/urfave/cli/v3 v3.3.8
 )
+
+require golang.org/x/sys v0.13.0 // indirect
@@ -3,85 +3,621 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/settings"
+	"github.com/yourusername/dot/internal/utils"
 )
 
 // Profile represents a mapping of source paths to target paths
 type Profile map[string]string
 
+// Entry holds the options of a mapping declared as an inline table, e.g.
+// "vscode/settings.json" = { targets = { darwin = "~/Library/...", linux = "~/.config/..." } }
+type Entry struct {
+	Target  string            `toml:"target"`
+	Targets map[string]string `toml:"targets"`
+	// OnChange is a shell command run by link after the mapping's link was created or replaced
+	OnChange string `toml:"on_change"`
+	// OnRemove is a shell command run by clean after the mapping's link was removed
+	OnRemove string `toml:"on_remove"`
+	// IgnoreMissing marks a source that only exists on some machines, so link skips it silently
+	IgnoreMissing bool `toml:"ignore_missing"`
+	// HookTimeout, HookOnFailure, and HookOutput override the [hooks] defaults of the
+	// global config for the entry's hooks
+	HookTimeout   string `toml:"hook_timeout"`
+	HookOnFailure string `toml:"hook_on_failure"`
+	HookOutput    string `toml:"hook_output"`
+	// Priority overrides the priority of the entry's profile, see Config.Priority
+	Priority *int `toml:"priority"`
+	// When is a condition such as "command-exists nvim" that must hold on this machine
+	// for the entry to be mapped, see evalWhen
+	When string `toml:"when"`
+	// OS and Arch restrict the entry to the listed runtime.GOOS and runtime.GOARCH values,
+	// e.g. os = ["darwin"]; an empty list allows any
+	OS   []string `toml:"os"`
+	Arch []string `toml:"arch"`
+	// After lists sources, as written in the same profile, that link applies before this entry
+	After []string `toml:"after"`
+	// Description says what the entry is for, for dot report
+	Description string `toml:"description"`
+	// EOL sets the line endings of the text files that link copies for the entry: "lf",
+	// "crlf", or "native" for those of the OS; copies keep the source's line endings if unset
+	EOL string `toml:"eol"`
+	// Mode is how link puts the source in place: ModeAuto, ModeLink, or ModeCopy
+	Mode string `toml:"mode"`
+	// Delims replaces the {{ and }} delimiters of the entry's template, e.g.
+	// delims = ["[[", "]]"] for a file whose own syntax uses braces
+	Delims []string `toml:"delims"`
+	// Tree holds the options of an entry whose directory is linked file by file
+	Tree
+}
+
+// Tree holds the options of an entry whose source ends in /**, so that the files beneath
+// its directory are linked one by one
+type Tree struct {
+	// MaxDepth is how many levels of directories link descends into, 1 linking only the
+	// files directly in the directory; directories at the limit are linked as a whole,
+	// and 0 has no limit
+	MaxDepth int `toml:"max_depth"`
+	// FollowSymlinks makes link descend into symlinked directories instead of linking
+	// them as a whole; a link back to a directory it is in is still linked as a whole
+	FollowSymlinks bool `toml:"follow_symlinks"`
+	// Ignore lists gitignore-style patterns of files and directories beneath the
+	// directory not to link, in addition to those in the dotfiles directory's .dotignore;
+	// a pattern with a slash is relative to the directory
+	Ignore []string `toml:"ignore"`
+}
+
+// How link puts the source of an entry in place
+const (
+	// ModeAuto links the source, or copies it where the target directory does not
+	// support symlinks; it is the default
+	ModeAuto = "auto"
+	// ModeLink only links the source, failing where symlinks are not supported
+	ModeLink = "link"
+	// ModeCopy always copies the source, tracking the copy like those made by ModeAuto
+	ModeCopy = "copy"
+)
+
+// Hooks returns the entry's hook options
+func (e Entry) Hooks() settings.Hooks {
+	return settings.Hooks{Timeout: e.HookTimeout, OnFailure: e.HookOnFailure, Output: e.HookOutput}
+}
+
+// runsOn reports whether the entry's os and arch lists allow the given platform
+func (e Entry) runsOn(goos, goarch string) bool {
+	return (len(e.OS) == 0 || slices.Contains(e.OS, goos)) && (len(e.Arch) == 0 || slices.Contains(e.Arch, goarch))
+}
+
+// TargetFor returns the entry's target on the given OS, or "" if it has none there
+// An OS-specific target takes precedence over the default target
+func (e Entry) TargetFor(goos string) string {
+	if target, exists := e.Targets[goos]; exists {
+		return target
+	}
+	return e.Target
+}
+
+// SourceRootKey is the top-level .mappings key naming the directory sources are relative to
+const SourceRootKey = "source_root"
+
+// SourceRootsKey is the top-level .mappings table giving profiles their own source root,
+// e.g. [source_roots] work = "work-overlay"
+const SourceRootsKey = "source_roots"
+
+// PrioritiesKey is the top-level .mappings table ranking profiles, e.g. [priorities] work = 10
+const PrioritiesKey = "priorities"
+
+// VarsKey is the top-level .mappings table of custom variables for templates, e.g.
+// [vars] email = "me@example.com"
+const VarsKey = "vars"
+
+// DelimsKey is the top-level .mappings key replacing the {{ and }} delimiters of every
+// template, e.g. template_delims = ["[[", "]]"]
+const DelimsKey = "template_delims"
+
+// RemotesKey is the top-level .mappings table of git remotes that dot push adds to the
+// repository, e.g. [remotes] mirror = "ssh://git@git.example.com/me/dotfiles.git"
+const RemotesKey = "remotes"
+
 // Config represents the entire .mappings configuration
+// Sources in Profiles and Entries are relative to the dotfiles directory, even where
+// .mappings declares them relative to its source_root
 type Config struct {
-	Profiles map[string]Profile
+	// SourceRoot is the directory of the repository that .mappings sources are relative
+	// to, e.g. "home"; "" is the repository itself
+	SourceRoot string
+	// SourceRoots replaces SourceRoot for the sources of the profiles it lists
+	SourceRoots map[string]string
+	Profiles    map[string]Profile
+	// Priorities ranks profiles for targets that several selected profiles map; a profile
+	// that is not listed has priority 0
+	Priorities map[string]int
+	// Entries holds the table-form entries of each profile, keyed by profile then source
+	Entries map[string]map[string]Entry
+	// Vars holds the custom variables that templates see as .Vars
+	Vars map[string]interface{}
+	// TemplateDelims holds the template delimiters of the entries without delims of their
+	// own; none keeps {{ and }}
+	TemplateDelims []string
+	// Hooks holds the pre_link and post_link hooks of each profile that declares them
+	Hooks map[string]ProfileHooks
+	// Remotes holds the git remotes of the repository by name
+	Remotes map[string]string
+	// TeamPrefix is the path of the team repository relative to the dotfiles directory,
+	// which the sources it contributes start with; "" without a team repository
+	TeamPrefix string
 }
 
 // ParseConfig reads and parses the .mappings file from the dotfiles directory
 func ParseConfig(dotfilesDir string) (*Config, error) {
-	mappingsPath := filepath.Join(dotfilesDir, ".mappings")
+	return ParseConfigFS(fsys.OS{}, dotfilesDir)
+}
+
+// ParseConfigFS reads and parses the .mappings file from the dotfiles directory on the given filesystem
+// The .mappings files of git submodules are merged beneath it, see mergeSubmodules, and
+// that of the team repository beneath those, see mergeTeam
+func ParseConfigFS(f fsys.FS, dotfilesDir string) (*Config, error) {
+	config, err := parseMappings(f, dotfilesDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.mergeSubmodules(f, dotfilesDir); err != nil {
+		return nil, err
+	}
+	if err := config.mergeTeam(f, dotfilesDir); err != nil {
+		return nil, err
+	}
+
+	if _, exists := config.Profiles[AllProfiles]; exists {
+		return nil, fmt.Errorf("failed to parse .mappings file: profile name [%s] is reserved", AllProfiles)
+	}
+
+	// Validate that [general] profile exists
+	if _, exists := config.Profiles["general"]; !exists {
+		return nil, fmt.Errorf("[general] profile is required but not found in .mappings")
+	}
+
+	for name := range config.Priorities {
+		if _, exists := config.Profiles[name]; !exists {
+			return nil, fmt.Errorf("failed to parse .mappings file: [%s] ranks profile [%s], which is not defined", PrioritiesKey, name)
+		}
+	}
+	for name := range config.SourceRoots {
+		if _, exists := config.Profiles[name]; !exists {
+			return nil, fmt.Errorf("failed to parse .mappings file: [%s] sets the root of profile [%s], which is not defined", SourceRootsKey, name)
+		}
+	}
+	if err := config.validateHooks(); err != nil {
+		return nil, err
+	}
+	for name, entries := range config.Entries {
+		for source, entry := range entries {
+			for _, after := range entry.After {
+				if !config.declares(config.RepoSource(name, after)) {
+					return nil, fmt.Errorf("failed to parse .mappings file: [%s] %s: after %q, which is not mapped", name, source, after)
+				}
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// declares reports whether any profile maps source, on this machine or another
+func (c *Config) declares(source string) bool {
+	for name, profile := range c.Profiles {
+		if _, exists := profile[source]; exists {
+			return true
+		}
+		if _, exists := c.Entries[name][source]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMappings parses the .mappings file in dir on its own, with the fragments in its
+// .mappings.d directory merged over it, see mergeFragments
+func parseMappings(f fsys.FS, dir string) (*Config, error) {
+	mappingsPath := filepath.Join(dir, ".mappings")
 
 	// Check if .mappings file exists
-	if _, err := os.Stat(mappingsPath); os.IsNotExist(err) {
+	data, err := f.ReadFile(mappingsPath)
+	if os.IsNotExist(err) {
 		return nil, fmt.Errorf(".mappings file not found at %s", mappingsPath)
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .mappings file: %w", err)
+	}
 
-	var config Config
-	if _, err := toml.DecodeFile(mappingsPath, &config.Profiles); err != nil {
-		return nil, fmt.Errorf("failed to parse .mappings file: %w", err)
+	config, err := decodeMappings(data, ".mappings file")
+	if err != nil {
+		return nil, err
+	}
+	if err := config.mergeFragments(f, dir); err != nil {
+		return nil, err
 	}
+	return config, nil
+}
 
-	// Validate that [general] profile exists
-	if config.Profiles == nil {
-		config.Profiles = make(map[string]Profile)
+// decodeMappings decodes the content of a .mappings file or fragment, name naming it in errors
+func decodeMappings(data []byte, name string) (*Config, error) {
+	var raw map[string]toml.Primitive
+	md, err := toml.Decode(string(quoteHostHeaders(data)), &raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
 	}
 
-	if _, exists := config.Profiles["general"]; !exists {
-		return nil, fmt.Errorf("[general] profile is required but not found in .mappings")
+	config := Config{
+		SourceRoots: make(map[string]string),
+		Profiles:    make(map[string]Profile),
+		Priorities:  make(map[string]int),
+		Entries:     make(map[string]map[string]Entry),
+		Vars:        make(map[string]interface{}),
+		Hooks:       make(map[string]ProfileHooks),
+		Remotes:     make(map[string]string),
+	}
+
+	if primitive, exists := raw[SourceRootKey]; exists {
+		if err := config.parseSourceRoot(md, primitive); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		delete(raw, SourceRootKey)
+	}
+	if primitive, exists := raw[DelimsKey]; exists {
+		if err := md.PrimitiveDecode(primitive, &config.TemplateDelims); err != nil || !validDelims(config.TemplateDelims) {
+			return nil, fmt.Errorf("failed to parse %s: %s must be a left and a right delimiter, e.g. [\"[[\", \"]]\"]", name, DelimsKey)
+		}
+		delete(raw, DelimsKey)
+	}
+	if primitive, exists := raw[SourceRootsKey]; exists && md.Type(SourceRootsKey) == "Hash" {
+		if err := config.parseSourceRoots(md, primitive); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		delete(raw, SourceRootsKey)
+	}
+	if primitive, exists := raw[PrioritiesKey]; exists && md.Type(PrioritiesKey) == "Hash" {
+		if err := md.PrimitiveDecode(primitive, &config.Priorities); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: [%s] must rank profiles by number: %w", name, PrioritiesKey, err)
+		}
+		delete(raw, PrioritiesKey)
+	}
+	if primitive, exists := raw[VarsKey]; exists && md.Type(VarsKey) == "Hash" {
+		if err := md.PrimitiveDecode(primitive, &config.Vars); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: [%s]: %w", name, VarsKey, err)
+		}
+		delete(raw, VarsKey)
+	}
+	if primitive, exists := raw[RemotesKey]; exists && md.Type(RemotesKey) == "Hash" {
+		if err := md.PrimitiveDecode(primitive, &config.Remotes); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: [%s] must map names to URLs: %w", name, RemotesKey, err)
+		}
+		delete(raw, RemotesKey)
+	}
+	if primitive, exists := raw[HooksKey]; exists {
+		if err := md.PrimitiveDecode(primitive, &config.Hooks); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: [%s] must hold a table of hooks per profile: %w", name, HooksKey, err)
+		}
+		delete(raw, HooksKey)
+	}
+
+	for profile, primitive := range raw {
+		// A profile with only [profile.<os>] subsections is an implicit table without a type
+		if t := md.Type(profile); t != "Hash" && t != "" {
+			return nil, fmt.Errorf("failed to parse %s: top-level key %q must be a [profile] table", name, profile)
+		}
+		if err := config.parseProfile(md, profile, primitive); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("failed to parse %s: unknown option %q", name, undecoded[0].String())
 	}
 
 	return &config, nil
 }
 
+// parseSourceRoot decodes the source_root key, which must name a directory inside the repository
+func (c *Config) parseSourceRoot(md toml.MetaData, primitive toml.Primitive) error {
+	var root string
+	if md.Type(SourceRootKey) != "String" {
+		return fmt.Errorf("%s must be a path, got %s", SourceRootKey, strings.ToLower(md.Type(SourceRootKey)))
+	}
+	if err := md.PrimitiveDecode(primitive, &root); err != nil {
+		return fmt.Errorf("%s: %w", SourceRootKey, err)
+	}
+
+	root, err := cleanRoot(SourceRootKey, root)
+	c.SourceRoot = root
+	return err
+}
+
+// parseSourceRoots decodes the [source_roots] table, whose values must name directories
+// inside the repository like source_root
+func (c *Config) parseSourceRoots(md toml.MetaData, primitive toml.Primitive) error {
+	var roots map[string]string
+	if err := md.PrimitiveDecode(primitive, &roots); err != nil {
+		return fmt.Errorf("[%s] must map profiles to paths: %w", SourceRootsKey, err)
+	}
+
+	for name, root := range roots {
+		root, err := cleanRoot(fmt.Sprintf("[%s] %s", SourceRootsKey, name), root)
+		if err != nil {
+			return err
+		}
+		c.SourceRoots[name] = root
+	}
+	return nil
+}
+
+// cleanRoot returns a source root in clean slash form, "" for the repository itself
+// key names the setting in the error when root is outside the repository
+func cleanRoot(key, root string) (string, error) {
+	root = path.Clean(filepath.ToSlash(root))
+	if path.IsAbs(root) || filepath.IsAbs(root) || root == ".." || strings.HasPrefix(root, "../") {
+		return "", fmt.Errorf("%s %q must be a directory inside the repository", key, root)
+	}
+	if root == "." {
+		return "", nil
+	}
+	return root, nil
+}
+
+// Priority returns the priority of a profile's mapping of source: the entry's own
+// priority if it sets one, else the priority of the profile
+// When several selected profiles map the same target, the mapping with the highest
+// priority wins, and among equal priorities the profile selected last
+func (c *Config) Priority(profile, source string) int {
+	if priority := c.Entries[profile][source].Priority; priority != nil {
+		return *priority
+	}
+	return c.Priorities[profile]
+}
+
+// ProfileRoot returns the source root of a profile: its entry in [source_roots], or else
+// the source_root of .mappings
+func (c *Config) ProfileRoot(profile string) string {
+	if root, exists := c.SourceRoots[profile]; exists {
+		return root
+	}
+	return c.SourceRoot
+}
+
+// RepoSource returns a source as a profile declares it in .mappings relative to the
+// dotfiles directory
+func (c *Config) RepoSource(profile, src string) string {
+	root := c.ProfileRoot(profile)
+	if root == "" {
+		return src
+	}
+	return path.Join(root, src)
+}
+
+// MappingsSource returns a source relative to the dotfiles directory as a profile declares
+// it in .mappings, relative to the profile's source root; ok is false when the source lies
+// outside that root
+func (c *Config) MappingsSource(profile, source string) (src string, ok bool) {
+	root := c.ProfileRoot(profile)
+	if root == "" {
+		return source, true
+	}
+	return strings.CutPrefix(source, root+"/")
+}
+
+// parseProfile decodes a single profile table, resolving table-form entries for the current OS
+// Entries without a target for the current OS, whose os or arch excludes this machine,
+// or whose when condition does not hold on it are left out of the profile, and so are the
+// entries of [profile.<os>] subsections for other operating systems
+func (c *Config) parseProfile(md toml.MetaData, name string, primitive toml.Primitive) error {
+	profile := make(Profile)
+	if err := c.parseEntries(md, name, []string{name}, primitive, profile, true); err != nil {
+		return err
+	}
+	c.Profiles[name] = profile
+	return nil
+}
+
+// parseEntries decodes the entries of the profile table at key, or of a [profile.<os>]
+// subsection of it, into profile
+// The entries are only added to profile and Entries if applies is set; those of another
+// OS's subsection are checked all the same, so mistakes show on every machine
+func (c *Config) parseEntries(md toml.MetaData, name string, key []string, primitive toml.Primitive, profile Profile, applies bool) error {
+	section := strings.Join(key, ".")
+	var raw map[string]toml.Primitive
+	if err := md.PrimitiveDecode(primitive, &raw); err != nil {
+		return fmt.Errorf("profile [%s]: %w", section, err)
+	}
+
+	// mapTarget maps source to target; a subsection's mapping replaces the profile's
+	// mapping of the same target
+	mapTarget := func(source, target string) {
+		if len(key) > 1 {
+			for other, existing := range profile {
+				if existing == target && other != source {
+					delete(profile, other)
+				}
+			}
+		}
+		profile[source] = target
+	}
+
+	var subsections []string
+	for src, value := range raw {
+		source := c.RepoSource(name, src)
+		path := append(slices.Clone(key), src)
+		switch md.Type(path...) {
+		case "String":
+			var target string
+			if err := md.PrimitiveDecode(value, &target); err != nil {
+				return fmt.Errorf("[%s] %s: %w", section, src, err)
+			}
+			if applies {
+				mapTarget(source, target)
+			}
+		case "Hash":
+			if len(key) == 1 && !md.IsDefined(append(path, "target")...) && !md.IsDefined(append(path, "targets")...) && isGOOS(src) {
+				subsections = append(subsections, src)
+				continue
+			}
+			var entry Entry
+			if err := md.PrimitiveDecode(value, &entry); err != nil {
+				return fmt.Errorf("[%s] %s: %w", section, src, err)
+			}
+			if entry.Target == "" && len(entry.Targets) == 0 {
+				return fmt.Errorf("[%s] %s: mapping table requires target or targets", section, src)
+			}
+			if err := entry.Hooks().Validate(); err != nil {
+				return fmt.Errorf("[%s] %s: %w", section, src, err)
+			}
+			if !utils.ValidEOL(entry.EOL) {
+				return fmt.Errorf("[%s] %s: eol must be lf, crlf, or native, got %q", section, src, entry.EOL)
+			}
+			switch entry.Mode {
+			case "", ModeAuto, ModeLink, ModeCopy:
+			default:
+				return fmt.Errorf("[%s] %s: mode must be %s, %s, or %s, got %q", section, src, ModeAuto, ModeLink, ModeCopy, entry.Mode)
+			}
+			if entry.MaxDepth < 0 {
+				return fmt.Errorf("[%s] %s: max_depth must be at least 0, got %d", section, src, entry.MaxDepth)
+			}
+			if entry.Delims != nil && !validDelims(entry.Delims) {
+				return fmt.Errorf("[%s] %s: delims must be a left and a right delimiter, e.g. [\"[[\", \"]]\"]", section, src)
+			}
+			for _, pattern := range entry.Ignore {
+				if !validPattern(pattern) {
+					return fmt.Errorf("[%s] %s: invalid ignore pattern %q", section, src, pattern)
+				}
+			}
+			for _, goos := range entry.OS {
+				if !isGOOS(goos) {
+					return fmt.Errorf("[%s] %s: unknown os %q, expected a Go GOOS value such as darwin, linux, or windows", section, src, goos)
+				}
+			}
+			for _, goarch := range entry.Arch {
+				if !isGOARCH(goarch) {
+					return fmt.Errorf("[%s] %s: unknown arch %q, expected a Go GOARCH value such as amd64 or arm64", section, src, goarch)
+				}
+			}
+
+			entryApplies := applies && entry.runsOn(runtime.GOOS, runtime.GOARCH)
+			if entry.When != "" {
+				ok, err := evalWhen(entry.When)
+				if err != nil {
+					return fmt.Errorf("[%s] %s: when: %w", section, src, err)
+				}
+				entryApplies = entryApplies && ok
+			}
+			if !applies {
+				continue
+			}
+			if c.Entries[name] == nil {
+				c.Entries[name] = make(map[string]Entry)
+			}
+			c.Entries[name][source] = entry
+			if target := entry.TargetFor(runtime.GOOS); target != "" && entryApplies {
+				mapTarget(source, target)
+			}
+		default:
+			return fmt.Errorf("[%s] %s: expected a target path or a table, got %s", section, src, strings.ToLower(md.Type(path...)))
+		}
+	}
+
+	// The mappings of this OS's subsection replace those of the profile for the same source
+	slices.Sort(subsections)
+	for _, goos := range subsections {
+		if err := c.parseEntries(md, name, append(slices.Clone(key), goos), raw[goos], profile, applies && goos == runtime.GOOS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validDelims reports whether delims holds a left and a right template delimiter
+func validDelims(delims []string) bool {
+	return len(delims) == 2 && delims[0] != "" && delims[1] != ""
+}
+
+// validPattern reports whether pattern, a line of a gitignore-style list, is well formed
+func validPattern(pattern string) bool {
+	_, err := path.Match(strings.TrimPrefix(pattern, "!"), "")
+	return err == nil
+}
+
 // GetProfiles returns the profiles for the given profile names
 // If no profiles are specified, returns [general] profile
-// Later profiles override earlier ones when they map to the same target
+// Later profiles override earlier ones when they map to the same target, unless the
+// earlier mapping has a higher priority, see Priority
 func (c *Config) GetProfiles(profileNames []string) (Profile, error) {
+	result, _, err := c.merge(profileNames)
+	return result, err
+}
+
+// merge merges the given profiles like GetProfiles, also returning the profile each
+// source of the result was taken from
+func (c *Config) merge(profileNames []string) (Profile, map[string]string, error) {
 	if len(profileNames) == 0 {
 		profileNames = []string{"general"}
 	}
-
-	result := make(Profile)
-	targetToSource := make(map[string]string) // track target -> source mapping for precedence
-
-	// Start with [general] as base (lowest precedence)
-	if general, exists := c.Profiles["general"]; exists {
-		for src, target := range general {
-			result[src] = target
-			targetToSource[target] = src
-		}
+	profileNames, err := c.ExpandProfiles(profileNames)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Apply other profiles in order (last one wins for same target)
+	// Start with [general] as base (lowest precedence), then apply other profiles in
+	// order (last one wins for same target, unless outranked)
+	chain := []string{"general"}
+	size := len(c.Profiles["general"])
 	for _, profileName := range profileNames {
-		if profileName == "general" {
-			continue // Already applied above
+		if profileName != "general" {
+			chain = append(chain, profileName)
+			size += len(c.Profiles[profileName])
 		}
+	}
+
+	// A single pass keeps the source that currently wins each target next to the result,
+	// so that neither needs to be searched when a later profile overrides a target
+	winners := make(map[string]string, size) // target -> source that wins it
+	result := make(Profile, size)            // source -> target it wins
+	origins := make(map[string]string, size) // source -> profile it was taken from
 
+	for _, profileName := range chain {
 		profile, exists := c.Profiles[profileName]
 		if !exists {
-			return nil, fmt.Errorf("profile [%s] not found in .mappings", profileName)
+			if profileName == "general" {
+				continue
+			}
+			return nil, nil, fmt.Errorf("profile [%s] not found in .mappings", profileName)
 		}
 
 		for src, target := range profile {
-			// If this target already exists from a previous profile, remove the old mapping
-			if oldSrc, exists := targetToSource[target]; exists {
-				delete(result, oldSrc)
+			// A target mapped by a previous profile goes to this mapping, unless the
+			// previous one has a higher priority
+			if old, claimed := winners[target]; claimed {
+				if c.Priority(origins[old], old) > c.Priority(profileName, src) {
+					continue
+				}
+				delete(result, old)
+				delete(origins, old)
+			}
+			// A source mapped to another target by a previous profile gives that one up
+			if previous, mapped := result[src]; mapped {
+				delete(winners, previous)
 			}
 
+			winners[target] = src
 			result[src] = target
-			targetToSource[target] = src
+			origins[src] = profileName
 		}
 	}
 
-	return result, nil
+	return result, origins, nil
 }
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -147,6 +150,445 @@ func TestParseConfig(t *testing.T) {
 	})
 }
 
+func TestParseConfigTableEntries(t *testing.T) {
+	t.Run("OS-specific targets resolve for the current OS", func(t *testing.T) {
+		content := `[general]
+"vim/.vimrc" = "~/.vimrc"
+"vscode/settings.json" = { target = "~/default.json", targets = { ` + runtime.GOOS + ` = "~/current.json", plan9 = "~/plan9.json" } }
+"only/other.conf" = { targets = { plan9 = "~/other.conf" } }
+"fallback.conf" = { target = "~/fallback.conf", targets = { plan9 = "~/plan9.conf" } }`
+
+		tempDir := createTempMappings(t, content)
+		config, err := ParseConfig(tempDir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		general := config.Profiles["general"]
+		if general["vscode/settings.json"] != "~/current.json" {
+			t.Errorf("Expected current OS target, got %s", general["vscode/settings.json"])
+		}
+		if general["fallback.conf"] != "~/fallback.conf" {
+			t.Errorf("Expected default target, got %s", general["fallback.conf"])
+		}
+		if _, exists := general["only/other.conf"]; exists {
+			t.Error("Expected entry without a target for the current OS to be omitted")
+		}
+		if _, exists := config.Entries["general"]["only/other.conf"]; !exists {
+			t.Error("Expected table entry to be recorded in Entries")
+		}
+	})
+
+	t.Run("Table without target should error", func(t *testing.T) {
+		content := `[general]
+"vim/.vimrc" = {}`
+
+		tempDir := createTempMappings(t, content)
+		_, err := ParseConfig(tempDir)
+		if err == nil || !strings.Contains(err.Error(), "requires target or targets") {
+			t.Errorf("Expected missing target error, got: %v", err)
+		}
+	})
+
+	t.Run("Unknown option should error", func(t *testing.T) {
+		content := `[general]
+"vim/.vimrc" = { target = "~/.vimrc", bogus = true }`
+
+		tempDir := createTempMappings(t, content)
+		_, err := ParseConfig(tempDir)
+		if err == nil || !strings.Contains(err.Error(), "unknown option") {
+			t.Errorf("Expected unknown option error, got: %v", err)
+		}
+	})
+
+	t.Run("Invalid hook option should error", func(t *testing.T) {
+		content := `[general]
+"vim/.vimrc" = { target = "~/.vimrc", on_change = "true", hook_on_failure = "ignore" }`
+
+		tempDir := createTempMappings(t, content)
+		_, err := ParseConfig(tempDir)
+		if err == nil || !strings.Contains(err.Error(), "[general] vim/.vimrc: invalid hook on_failure") {
+			t.Errorf("Expected hook option error, got: %v", err)
+		}
+	})
+
+	t.Run("Non-string target should error", func(t *testing.T) {
+		content := `[general]
+"vim/.vimrc" = 42`
+
+		tempDir := createTempMappings(t, content)
+		_, err := ParseConfig(tempDir)
+		if err == nil || !strings.Contains(err.Error(), "expected a target path or a table") {
+			t.Errorf("Expected type error, got: %v", err)
+		}
+	})
+}
+
+func TestSourceRoot(t *testing.T) {
+	t.Run("Sources are resolved below the source root", func(t *testing.T) {
+		content := `source_root = "home/"
+
+[general]
+"zsh/.zshrc" = "~/.zshrc"
+"nvim" = { target = "~/.config/nvim", ignore_missing = true }`
+
+		config, err := ParseConfig(createTempMappings(t, content))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if config.SourceRoot != "home" {
+			t.Errorf("Expected source root home, got %q", config.SourceRoot)
+		}
+		if config.Profiles["general"]["home/zsh/.zshrc"] != "~/.zshrc" {
+			t.Errorf("Expected source relative to the repository, got %v", config.Profiles["general"])
+		}
+		if !config.Entries["general"]["home/nvim"].IgnoreMissing {
+			t.Errorf("Expected entry under the rooted source, got %v", config.Entries["general"])
+		}
+	})
+
+	t.Run("Source root must stay inside the repository", func(t *testing.T) {
+		for _, root := range []string{"../elsewhere", "/home/user/dotfiles"} {
+			_, err := ParseConfig(createTempMappings(t, "source_root = \""+root+"\"\n[general]\n"))
+			if err == nil || !strings.Contains(err.Error(), "inside the repository") {
+				t.Errorf("Expected error for %s, got %v", root, err)
+			}
+		}
+	})
+
+	t.Run("Profiles can have their own source root", func(t *testing.T) {
+		content := `source_root = "home"
+
+[source_roots]
+work = "work-overlay/"
+
+[general]
+".zshrc" = "~/.zshrc"
+
+[work]
+".zshrc" = "~/.zshrc"
+".npmrc" = { target = "~/.npmrc", ignore_missing = true }`
+
+		config, err := ParseConfig(createTempMappings(t, content))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if config.Profiles["general"]["home/.zshrc"] != "~/.zshrc" {
+			t.Errorf("Expected general under the source root, got %v", config.Profiles["general"])
+		}
+		if config.Profiles["work"]["work-overlay/.zshrc"] != "~/.zshrc" {
+			t.Errorf("Expected work under its own root, got %v", config.Profiles["work"])
+		}
+		if !config.Entries["work"]["work-overlay/.npmrc"].IgnoreMissing {
+			t.Errorf("Expected entry under the profile's root, got %v", config.Entries["work"])
+		}
+		if src, ok := config.MappingsSource("work", "work-overlay/.zshrc"); !ok || src != ".zshrc" {
+			t.Errorf("Expected .zshrc relative to the profile's root, got %q", src)
+		}
+	})
+
+	t.Run("Profile source roots must stay inside the repository", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "[source_roots]\nwork = \"../work\"\n\n[general]\n[work]\n"))
+		if err == nil || !strings.Contains(err.Error(), "inside the repository") {
+			t.Errorf("Expected error, got %v", err)
+		}
+	})
+
+	t.Run("Profile source roots need a defined profile", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "[source_roots]\nwork = \"work\"\n\n[general]\n"))
+		if err == nil || !strings.Contains(err.Error(), "sets the root of profile [work]") {
+			t.Errorf("Expected undefined profile error, got %v", err)
+		}
+	})
+
+	t.Run("Source root must be a string", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "source_root = 1\n[general]\n"))
+		if err == nil || !strings.Contains(err.Error(), "source_root must be a path") {
+			t.Errorf("Expected type error, got %v", err)
+		}
+	})
+}
+
+func TestPriorities(t *testing.T) {
+	content := `[priorities]
+laptop = 10
+
+[general]
+".gitconfig" = "~/.gitconfig"
+".zshrc" = { target = "~/.zshrc", priority = 20 }
+
+[laptop]
+".gitconfig-laptop" = "~/.gitconfig"
+
+[work]
+".gitconfig-work" = "~/.gitconfig"
+".zshrc-work" = "~/.zshrc"`
+
+	config, err := ParseConfig(createTempMappings(t, content))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	t.Run("Priorities are parsed", func(t *testing.T) {
+		if config.Priority("laptop", ".gitconfig-laptop") != 10 {
+			t.Errorf("Expected laptop priority 10, got %d", config.Priority("laptop", ".gitconfig-laptop"))
+		}
+		if config.Priority("general", ".zshrc") != 20 {
+			t.Errorf("Expected entry priority 20, got %d", config.Priority("general", ".zshrc"))
+		}
+		if config.Priority("work", ".zshrc-work") != 0 {
+			t.Errorf("Expected default priority 0, got %d", config.Priority("work", ".zshrc-work"))
+		}
+	})
+
+	t.Run("Higher priority wins over later profiles", func(t *testing.T) {
+		profile, err := config.GetProfiles([]string{"laptop", "work"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if profile[".gitconfig-laptop"] != "~/.gitconfig" {
+			t.Errorf("Expected laptop's .gitconfig to win, got %v", profile)
+		}
+		if _, exists := profile[".gitconfig-work"]; exists {
+			t.Errorf("Expected work's .gitconfig to be overridden, got %v", profile)
+		}
+		if profile[".zshrc"] != "~/.zshrc" {
+			t.Errorf("Expected the entry priority to keep general's .zshrc, got %v", profile)
+		}
+	})
+
+	t.Run("Equal priorities fall back to profile order", func(t *testing.T) {
+		profile, _ := config.GetProfiles([]string{"work"})
+		if profile[".gitconfig-work"] != "~/.gitconfig" {
+			t.Errorf("Expected work's .gitconfig to win, got %v", profile)
+		}
+	})
+
+	t.Run("Unknown profiles cannot be ranked", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "[priorities]\nhome = 1\n\n[general]\n"))
+		if err == nil || !strings.Contains(err.Error(), "ranks profile [home]") {
+			t.Errorf("Expected unknown profile error, got %v", err)
+		}
+	})
+
+	t.Run("Priorities must be numbers", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "[priorities]\nwork = \"high\"\n\n[general]\n[work]\n"))
+		if err == nil || !strings.Contains(err.Error(), "must rank profiles by number") {
+			t.Errorf("Expected type error, got %v", err)
+		}
+	})
+}
+
+func TestVars(t *testing.T) {
+	t.Run("Vars are parsed and are not a profile", func(t *testing.T) {
+		config, err := ParseConfig(createTempMappings(t, "[vars]\nemail = \"me@example.com\"\nwork = true\n\n[general]\n"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if config.Vars["email"] != "me@example.com" || config.Vars["work"] != true {
+			t.Errorf("Expected the vars, got %v", config.Vars)
+		}
+		if _, exists := config.Profiles[VarsKey]; exists {
+			t.Error("Expected [vars] not to be a profile")
+		}
+	})
+
+	t.Run("Fragments override vars", func(t *testing.T) {
+		dir := createTempMappings(t, "[vars]\nemail = \"me@example.com\"\n\n[general]\n")
+		os.MkdirAll(filepath.Join(dir, FragmentsDir), 0755)
+		os.WriteFile(filepath.Join(dir, FragmentsDir, "work.toml"), []byte("[vars]\nemail = \"me@work.com\"\n"), 0644)
+
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if config.Vars["email"] != "me@work.com" {
+			t.Errorf("Expected the fragment's email, got %v", config.Vars["email"])
+		}
+	})
+}
+
+func TestRemotes(t *testing.T) {
+	t.Run("Remotes are parsed and are not a profile", func(t *testing.T) {
+		config, err := ParseConfig(createTempMappings(t, "[remotes]\nmirror = \"ssh://git@git.example.com/me/dotfiles.git\"\n\n[general]\n"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if url := config.Remotes["mirror"]; url != "ssh://git@git.example.com/me/dotfiles.git" {
+			t.Errorf("Expected the mirror URL, got %q", url)
+		}
+		if _, exists := config.Profiles[RemotesKey]; exists {
+			t.Error("Expected [remotes] not to be a profile")
+		}
+	})
+
+	t.Run("Remotes must be URLs", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "[remotes]\nmirror = 1\n\n[general]\n"))
+		if err == nil || !strings.Contains(err.Error(), "must map names to URLs") {
+			t.Errorf("Expected type error, got %v", err)
+		}
+	})
+}
+
+func TestProfileHooks(t *testing.T) {
+	t.Run("Hooks are parsed per profile", func(t *testing.T) {
+		config, err := ParseConfig(createTempMappings(t, "[hooks.general]\npost_link = \"fc-cache -f\"\ntimeout = \"30s\"\n\n[general]\n"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if hooks := config.Hooks["general"]; hooks.PostLink != "fc-cache -f" || hooks.Hooks().Timeout != "30s" {
+			t.Errorf("Expected the general hooks, got %+v", hooks)
+		}
+		if _, exists := config.Profiles[HooksKey]; exists {
+			t.Error("Expected [hooks] not to be a profile")
+		}
+	})
+
+	t.Run("Hooks of undefined profiles are an error", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "[hooks.work]\npre_link = \"true\"\n\n[general]\n"))
+		if err == nil || !strings.Contains(err.Error(), "[hooks.work] sets hooks of a profile that is not defined") {
+			t.Errorf("Expected undefined profile error, got %v", err)
+		}
+	})
+
+	t.Run("Hook options are validated", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "[hooks.general]\non_failure = \"ignore\"\n\n[general]\n"))
+		if err == nil || !strings.Contains(err.Error(), "invalid hook on_failure") {
+			t.Errorf("Expected invalid option error, got %v", err)
+		}
+	})
+
+	t.Run("Unknown hook keys are an error", func(t *testing.T) {
+		_, err := ParseConfig(createTempMappings(t, "[hooks.general]\npost_lnk = \"true\"\n\n[general]\n"))
+		if err == nil || !strings.Contains(err.Error(), "unknown option") {
+			t.Errorf("Expected unknown option error, got %v", err)
+		}
+	})
+}
+
+func TestAfter(t *testing.T) {
+	t.Run("Dependencies are parsed", func(t *testing.T) {
+		content := `[general]
+"zsh/.zshenv" = "~/.zshenv"
+"zsh/.zshrc" = { target = "~/.zshrc", after = ["zsh/.zshenv"] }
+
+[work]
+"zsh/work.zsh" = { target = "~/.zsh/work.zsh", after = ["zsh/.zshrc", "ssh"] }
+"ssh" = { target = "~/.ssh/config", when = "env DOT_TEST_UNSET" }`
+
+		config, err := ParseConfig(createTempMappings(t, content))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		after := config.Entries["general"]["zsh/.zshrc"].After
+		if len(after) != 1 || after[0] != "zsh/.zshenv" {
+			t.Errorf("Expected [zsh/.zshenv], got %v", after)
+		}
+	})
+
+	t.Run("Dependencies must be mapped", func(t *testing.T) {
+		content := `[general]
+"zsh/.zshrc" = { target = "~/.zshrc", after = ["zsh/.zprofile"] }`
+
+		_, err := ParseConfig(createTempMappings(t, content))
+		if err == nil || !strings.Contains(err.Error(), `[general] zsh/.zshrc: after "zsh/.zprofile", which is not mapped`) {
+			t.Errorf("Expected unmapped dependency error, got %v", err)
+		}
+	})
+}
+
+func TestEOL(t *testing.T) {
+	content := `[general]
+"scripts/setup.sh" = { target = "~/setup.sh", eol = "lf" }`
+	config, err := ParseConfig(createTempMappings(t, content))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if eol := config.Entries["general"]["scripts/setup.sh"].EOL; eol != "lf" {
+		t.Errorf("Expected lf, got %q", eol)
+	}
+
+	content = `[general]
+"scripts/setup.sh" = { target = "~/setup.sh", eol = "cr" }`
+	if _, err := ParseConfig(createTempMappings(t, content)); err == nil || !strings.Contains(err.Error(), "eol must be lf, crlf, or native") {
+		t.Errorf("Expected invalid eol error, got %v", err)
+	}
+}
+
+func TestMode(t *testing.T) {
+	content := `[general]
+"zsh/.zshrc" = { target = "~/.zshrc", mode = "copy" }`
+	config, err := ParseConfig(createTempMappings(t, content))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if mode := config.Entries["general"]["zsh/.zshrc"].Mode; mode != ModeCopy {
+		t.Errorf("Expected copy, got %q", mode)
+	}
+
+	content = `[general]
+"zsh/.zshrc" = { target = "~/.zshrc", mode = "hardlink" }`
+	if _, err := ParseConfig(createTempMappings(t, content)); err == nil || !strings.Contains(err.Error(), "mode must be auto, link, or copy") {
+		t.Errorf("Expected invalid mode error, got %v", err)
+	}
+}
+
+func TestTree(t *testing.T) {
+	content := `[general]
+"nvim/**" = { target = "~/.config/nvim/", max_depth = 2, follow_symlinks = true }`
+	config, err := ParseConfig(createTempMappings(t, content))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	mappings, _ := config.Select([]string{"general"})
+	if len(mappings) != 1 || mappings[0].Tree.MaxDepth != 2 || !mappings[0].Tree.FollowSymlinks {
+		t.Errorf("Expected the selected mapping to carry the tree options, got %+v", mappings)
+	}
+
+	content = `[general]
+"nvim/**" = { target = "~/.config/nvim/", max_depth = -1 }`
+	if _, err := ParseConfig(createTempMappings(t, content)); err == nil || !strings.Contains(err.Error(), "max_depth must be at least 0") {
+		t.Errorf("Expected invalid max_depth error, got %v", err)
+	}
+
+	content = `[general]
+"nvim/**" = { target = "~/.config/nvim/", ignore = ["[cache"] }`
+	if _, err := ParseConfig(createTempMappings(t, content)); err == nil || !strings.Contains(err.Error(), "invalid ignore pattern") {
+		t.Errorf("Expected invalid ignore pattern error, got %v", err)
+	}
+}
+
+func TestTemplateDelims(t *testing.T) {
+	content := `template_delims = ["<%", "%>"]
+
+[general]
+"git/.gitconfig.tmpl" = { target = "~/.gitconfig", delims = ["[[", "]]"] }
+"zsh/.zshrc.tmpl" = "~/.zshrc"`
+	config, err := ParseConfig(createTempMappings(t, content))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	mappings, _ := config.Select([]string{"general"})
+	for _, m := range mappings {
+		expected := []string{"<%", "%>"}
+		if m.Source == "git/.gitconfig.tmpl" {
+			expected = []string{"[[", "]]"}
+		}
+		if !slices.Equal(m.Delims, expected) {
+			t.Errorf("Expected delimiters %v for %s, got %v", expected, m.Source, m.Delims)
+		}
+	}
+
+	for _, content := range []string{
+		"template_delims = [\"<%\"]\n\n[general]\n",
+		"[general]\n\"a.tmpl\" = { target = \"~/a\", delims = [\"\", \"]]\"] }",
+	} {
+		if _, err := ParseConfig(createTempMappings(t, content)); err == nil || !strings.Contains(err.Error(), "a left and a right delimiter") {
+			t.Errorf("Expected invalid delimiters error, got %v", err)
+		}
+	}
+}
+
 func TestGetProfiles(t *testing.T) {
 	// Setup test configuration
 	content := `[general]
@@ -184,6 +626,23 @@ func TestGetProfiles(t *testing.T) {
 		}
 	})
 
+	t.Run("Source remapped by a later profile gives up its earlier target", func(t *testing.T) {
+		remapped := &Config{Profiles: map[string]Profile{
+			"general": {"vim/.vimrc": "~/.vimrc", "nvim/init.vim": "~/.config/nvim/init.vim"},
+			"legacy":  {"vim/.vimrc": "~/.config/nvim/init.vim"},
+			"classic": {"vim/.vimrc": "~/.vimrc"},
+		}}
+
+		result, err := remapped.GetProfiles([]string{"legacy", "classic"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		// [legacy] took init.vim from nvim/init.vim, then [classic] moved vim/.vimrc back
+		if len(result) != 1 || result["vim/.vimrc"] != "~/.vimrc" {
+			t.Errorf("Expected only vim/.vimrc -> ~/.vimrc, got %v", result)
+		}
+	})
+
 	t.Run("Default to general when nil profiles specified", func(t *testing.T) {
 		result, err := config.GetProfiles(nil)
 		if err != nil {
@@ -419,3 +878,49 @@ func BenchmarkGetProfiles(b *testing.B) {
 		}
 	}
 }
+
+// largeConfig returns a config with n [general] mappings, a [work] profile that
+// overrides every fifth target, and a higher ranked [laptop] profile that overrides
+// every tenth, as glob or tree expansion of a large repository produces
+func largeConfig(n int) *Config {
+	config := &Config{
+		Profiles:   map[string]Profile{"general": {}, "work": {}, "laptop": {}},
+		Priorities: map[string]int{"laptop": 10},
+		Entries:    make(map[string]map[string]Entry),
+	}
+	for i := 0; i < n; i++ {
+		target := fmt.Sprintf("~/.config/app%d/config", i)
+		config.Profiles["general"][fmt.Sprintf("app%d/config", i)] = target
+		if i%5 == 0 {
+			config.Profiles["work"][fmt.Sprintf("work/app%d/config", i)] = target
+		}
+		if i%10 == 0 {
+			config.Profiles["laptop"][fmt.Sprintf("laptop/app%d/config", i)] = target
+		}
+	}
+	return config
+}
+
+func BenchmarkGetProfiles10k(b *testing.B) {
+	config := largeConfig(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := config.GetProfiles([]string{"laptop", "work"}); err != nil {
+			b.Fatalf("GetProfiles failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSelect10k(b *testing.B) {
+	config := largeConfig(10000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := config.Select([]string{"laptop", "work"}); err != nil {
+			b.Fatalf("Select failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+// FragmentsDir is the directory next to .mappings whose *.toml files are merged over it,
+// so scripts and presets can add or remove the mappings of one tool as a file of its own
+const FragmentsDir = ".mappings.d"
+
+// mergeFragments merges the *.toml files of dir's .mappings.d directory over the
+// configuration in lexical order of their names, e.g. 10-git.toml before 20-zsh.toml
+// A fragment's mapping replaces any earlier mapping of the same profile with the same
+// source or target, so later fragments override earlier ones and all of them override
+// .mappings; a fragment's priorities, vars, and profile hooks likewise replace earlier ones
+// A fragment's source_root and [source_roots] apply to its own sources only
+func (c *Config) mergeFragments(f fsys.FS, dir string) error {
+	entries, err := f.ReadDir(filepath.Join(dir, FragmentsDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", FragmentsDir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".toml") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rel := FragmentsDir + "/" + name
+		data, err := f.ReadFile(filepath.Join(dir, FragmentsDir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		fragment, err := decodeMappings(data, rel)
+		if err != nil {
+			return err
+		}
+		c.mergeOver(fragment)
+	}
+	return nil
+}
+
+// mergeOver adds the mappings of fragment to c, replacing those of the same profile that
+// map the same source or target
+// Entries that do not apply on this machine still replace the source's earlier mapping
+func (c *Config) mergeOver(fragment *Config) {
+	for name, priority := range fragment.Priorities {
+		c.Priorities[name] = priority
+	}
+	for name, value := range fragment.Vars {
+		c.Vars[name] = value
+	}
+	if fragment.TemplateDelims != nil {
+		c.TemplateDelims = fragment.TemplateDelims
+	}
+	for name, hooks := range fragment.Hooks {
+		c.Hooks[name] = hooks
+	}
+	for name, url := range fragment.Remotes {
+		c.Remotes[name] = url
+	}
+
+	names := make(map[string]bool)
+	for name := range fragment.Profiles {
+		names[name] = true
+	}
+	for name := range fragment.Entries {
+		names[name] = true
+	}
+	for name := range names {
+		merged := c.Profiles[name]
+		if merged == nil {
+			merged = make(Profile)
+			c.Profiles[name] = merged
+		}
+
+		sources := make(map[string]bool)
+		for source := range fragment.Profiles[name] {
+			sources[source] = true
+		}
+		for source := range fragment.Entries[name] {
+			sources[source] = true
+		}
+		for source := range sources {
+			c.unmap(name, source)
+			target, mapped := fragment.Profiles[name][source]
+			if !mapped {
+				continue
+			}
+			for other, existing := range merged {
+				if existing == target {
+					c.unmap(name, other)
+				}
+			}
+		}
+
+		for source := range sources {
+			if target, mapped := fragment.Profiles[name][source]; mapped {
+				merged[source] = target
+			}
+			if entry, exists := fragment.Entries[name][source]; exists {
+				if c.Entries[name] == nil {
+					c.Entries[name] = make(map[string]Entry)
+				}
+				c.Entries[name][source] = entry
+			}
+		}
+	}
+}
+
+// unmap removes a profile's mapping of source, along with its entry
+func (c *Config) unmap(profile, source string) {
+	delete(c.Profiles[profile], source)
+	delete(c.Entries[profile], source)
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFragments(t *testing.T) {
+	// setup creates a dotfiles repository with the given fragments in .mappings.d
+	setup := func(t *testing.T, mappings string, fragments map[string]string) string {
+		dir := createTempMappings(t, mappings)
+		os.MkdirAll(filepath.Join(dir, FragmentsDir), 0755)
+		for name, content := range fragments {
+			os.WriteFile(filepath.Join(dir, FragmentsDir, name), []byte(content), 0644)
+		}
+		return dir
+	}
+
+	t.Run("Fragments add mappings and profiles", func(t *testing.T) {
+		dir := setup(t, "[general]\n\"zsh/.zshrc\" = \"~/.zshrc\"\n", map[string]string{
+			"git.toml":  "[general]\n\"git/.gitconfig\" = \"~/.gitconfig\"\n",
+			"work.toml": "[work]\n\"ssh/config\" = { target = \"~/.ssh/config\", on_change = \"echo changed\" }\n",
+			"notes.txt": "not a fragment",
+		})
+
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		general := config.Profiles["general"]
+		if general["zsh/.zshrc"] != "~/.zshrc" || general["git/.gitconfig"] != "~/.gitconfig" {
+			t.Errorf("Expected the .mappings and fragment mappings, got %v", general)
+		}
+		if entry := config.Entries["work"]["ssh/config"]; entry.OnChange != "echo changed" {
+			t.Errorf("Expected the fragment's entry options, got %+v", entry)
+		}
+	})
+
+	t.Run("Later fragments override earlier ones and .mappings", func(t *testing.T) {
+		dir := setup(t, `[general]
+"zsh/.zshrc" = { target = "~/.zshrc", on_change = "echo zsh" }
+"vim/.vimrc" = "~/.vimrc"
+`, map[string]string{
+			"20-zsh.toml": "[general]\n\"zsh/.zshrc\" = \"~/.zshrc-20\"\n",
+			"10-zsh.toml": "[general]\n\"zsh/.zshrc\" = \"~/.zshrc-10\"\n",
+			"30-vim.toml": "[general]\n\"nvim/init.vim\" = \"~/.vimrc\"\n",
+		})
+
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		general := config.Profiles["general"]
+		if general["zsh/.zshrc"] != "~/.zshrc-20" {
+			t.Errorf("Expected 20-zsh.toml to win, got %v", general)
+		}
+		if _, exists := config.Entries["general"]["zsh/.zshrc"]; exists {
+			t.Error("Expected the replaced entry's options to be dropped")
+		}
+		if _, exists := general["vim/.vimrc"]; exists || general["nvim/init.vim"] != "~/.vimrc" {
+			t.Errorf("Expected the fragment to take over ~/.vimrc, got %v", general)
+		}
+	})
+
+	t.Run("Fragment sources are relative to its own source_root", func(t *testing.T) {
+		dir := setup(t, "source_root = \"home\"\n\n[general]\n\"zshrc\" = \"~/.zshrc\"\n", map[string]string{
+			"git.toml": "[general]\n\"git/.gitconfig\" = \"~/.gitconfig\"\n",
+		})
+
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		general := config.Profiles["general"]
+		if general["home/zshrc"] != "~/.zshrc" || general["git/.gitconfig"] != "~/.gitconfig" {
+			t.Errorf("Expected each file's own source root, got %v", general)
+		}
+	})
+
+	t.Run("Invalid fragment is named in the error", func(t *testing.T) {
+		dir := setup(t, "[general]\n\"zsh/.zshrc\" = \"~/.zshrc\"\n", map[string]string{
+			"broken.toml": "[general\n",
+		})
+
+		_, err := ParseConfig(dir)
+		if err == nil || !strings.Contains(err.Error(), ".mappings.d/broken.toml") {
+			t.Errorf("Expected an error naming the fragment, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/yourusername/dot/internal/settings"
+)
+
+// HooksKey is the top-level .mappings table of profile hooks, e.g.
+// [hooks.general] post_link = "fc-cache -f"
+const HooksKey = "hooks"
+
+// ProfileHooks holds the shell commands that link runs around linking a profile
+type ProfileHooks struct {
+	// PreLink runs before any mapping is linked
+	PreLink string `toml:"pre_link"`
+	// PostLink runs after every mapping was linked and the on_change hooks have run
+	PostLink string `toml:"post_link"`
+	// Timeout, OnFailure, and Output override the [hooks] defaults of the global config
+	Timeout   string `toml:"timeout"`
+	OnFailure string `toml:"on_failure"`
+	Output    string `toml:"output"`
+}
+
+// Hooks returns the options for running the profile's hooks
+func (h ProfileHooks) Hooks() settings.Hooks {
+	return settings.Hooks{Timeout: h.Timeout, OnFailure: h.OnFailure, Output: h.Output}
+}
+
+// validateHooks checks that every profile with hooks is defined and its options are known
+func (c *Config) validateHooks() error {
+	for name, hooks := range c.Hooks {
+		if _, exists := c.Profiles[name]; !exists {
+			return fmt.Errorf("failed to parse .mappings file: [%s.%s] sets hooks of a profile that is not defined", HooksKey, name)
+		}
+		if err := hooks.Hooks().Validate(); err != nil {
+			return fmt.Errorf("failed to parse .mappings file: [%s.%s]: %w", HooksKey, name, err)
+		}
+	}
+	return nil
+}
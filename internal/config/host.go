@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// HostProfilePrefix starts the name of a profile that is included automatically on the
+// machine it names, e.g. [host:laptop]
+const HostProfilePrefix = "host:"
+
+// AutoHost includes the [host:<hostname>] profile of the current machine whenever
+// .mappings defines it; dot --no-auto-host turns it off
+var AutoHost = true
+
+// hostname returns the short, lower-cased hostname of the current machine, as alternates
+// match it
+var hostname = func() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return strings.ToLower(name)
+}
+
+// hostProfile returns the name of the current machine's host profile, or "" when it is
+// turned off or the hostname is unknown
+func hostProfile() string {
+	if !AutoHost {
+		return ""
+	}
+	if host := hostname(); host != "" {
+		return HostProfilePrefix + host
+	}
+	return ""
+}
+
+// hostHeader matches a [host:name] or [host:name.<os>] table header, which TOML only
+// accepts with the name quoted
+var hostHeader = regexp.MustCompile(`(?m)^(\s*\[\s*)(` + HostProfilePrefix + `[A-Za-z0-9_-]+)(\s*[.\]])`)
+
+// quoteHostHeaders quotes the names of [host:name] table headers, so they can be written
+// as plainly as any other profile
+func quoteHostHeaders(data []byte) []byte {
+	return hostHeader.ReplaceAll(data, []byte(`$1"$2"$3`))
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestHostProfiles(t *testing.T) {
+	originalHostname := hostname
+	originalAutoHost := AutoHost
+	defer func() { hostname, AutoHost = originalHostname, originalAutoHost }()
+	hostname = func() string { return "laptop" }
+
+	content := `[general]
+"git/.gitconfig" = "~/.gitconfig"
+
+[host:laptop]
+"git/.gitconfig-laptop" = "~/.gitconfig"
+
+[host:laptop.` + runtime.GOOS + `]
+"ssh/config-laptop" = "~/.ssh/config"
+
+["host:desktop"]
+"git/.gitconfig-desktop" = "~/.gitconfig"
+
+[work]
+"git/.gitconfig-work" = "~/.gitconfig"
+`
+	cfg, err := ParseConfig(createTempMappings(t, content))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	t.Run("Unquoted and quoted headers define host profiles", func(t *testing.T) {
+		if target := cfg.Profiles["host:laptop"]["ssh/config-laptop"]; target != "~/.ssh/config" {
+			t.Errorf("Expected the OS subsection to be merged into [host:laptop], got %q", target)
+		}
+		if _, exists := cfg.Profiles["host:desktop"]; !exists {
+			t.Errorf("Expected [host:desktop] to be defined")
+		}
+	})
+
+	t.Run("This machine's profile is included automatically", func(t *testing.T) {
+		AutoHost = true
+		names, err := cfg.ExpandProfiles([]string{"general"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.Join(names, ",") != "host:laptop,general" {
+			t.Errorf("Expected host:laptop,general, got %v", names)
+		}
+
+		profile, err := cfg.GetProfiles([]string{"general"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, exists := profile["git/.gitconfig-laptop"]; !exists {
+			t.Errorf("Expected [host:laptop] to override [general], got %v", profile)
+		}
+	})
+
+	t.Run("Selected profiles override it", func(t *testing.T) {
+		AutoHost = true
+		profile, err := cfg.GetProfiles([]string{"general", "work"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, exists := profile["git/.gitconfig-work"]; !exists {
+			t.Errorf("Expected [work] to override [host:laptop], got %v", profile)
+		}
+	})
+
+	t.Run("Hostname matches regardless of case", func(t *testing.T) {
+		AutoHost = true
+		hostname = func() string { return "desktop" }
+		defer func() { hostname = func() string { return "laptop" } }()
+
+		upper, err := ParseConfig(createTempMappings(t, "[general]\n\n[host:Desktop]\n\"a\" = \"~/a\"\n"))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		names, err := upper.ExpandProfiles([]string{"general"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.Join(names, ",") != "host:Desktop,general" {
+			t.Errorf("Expected host:Desktop,general, got %v", names)
+		}
+	})
+
+	t.Run("No auto host leaves it out", func(t *testing.T) {
+		AutoHost = false
+		names, err := cfg.ExpandProfiles([]string{"general"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.Join(names, ",") != "general" {
+			t.Errorf("Expected general, got %v", names)
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/wsl"
+)
+
+// AllProfiles is the pseudo-profile that selects every profile in .mappings
+const AllProfiles = "all"
+
+// Mapping is a single source -> target entry together with the profile it comes from
+type Mapping struct {
+	Source  string
+	Target  string
+	Profile string
+	// Team is set when the source lies in the read-only team repository
+	Team bool
+	// Tree holds the entry's options for linking its directory file by file
+	Tree Tree
+	// Delims are the left and right delimiters of the source's template, or none for
+	// {{ and }}
+	Delims []string
+}
+
+// ExpandProfiles replaces the "all" pseudo-profile with every profile in the
+// configuration, [general] first and the rest in alphabetical order, and glob patterns
+// such as "work*" with the profiles they match, in alphabetical order
+// Profiles for the current machine, such as [wsl] and [host:<hostname>], are included
+// ahead of the others
+// A pattern that matches no profile is an error; a profile selected twice is kept once
+func (c *Config) ExpandProfiles(profileNames []string) ([]string, error) {
+	if IsAll(profileNames) {
+		return append([]string{"general"}, c.sortedProfileNames("*", "general")...), nil
+	}
+
+	var expanded []string
+	seen := make(map[string]bool)
+	for _, name := range profileNames {
+		matches := []string{name}
+		if isPattern(name) {
+			if _, err := path.Match(name, ""); err != nil {
+				return nil, fmt.Errorf("invalid profile pattern %q: %w", name, err)
+			}
+			matches = c.sortedProfileNames(name, "")
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("profile pattern %q matches no profiles in .mappings", name)
+			}
+		}
+
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				expanded = append(expanded, match)
+			}
+		}
+	}
+
+	// Profiles for the current machine come first, so the selected profiles override them
+	var automatic []string
+	for _, name := range automaticProfiles() {
+		if defined, exists := c.profileNamed(name); exists && !seen[defined] {
+			seen[defined] = true
+			automatic = append(automatic, defined)
+		}
+	}
+
+	return append(automatic, expanded...), nil
+}
+
+// automaticProfiles returns the profiles that are included whenever the current machine
+// calls for them and .mappings defines them, like [wsl] under the Windows Subsystem for Linux
+// and [host:<hostname>] on the machine it names, which comes last so it overrides [wsl]
+var automaticProfiles = func() []string {
+	var names []string
+	if wsl.Detect() {
+		names = append(names, wsl.Profile)
+	}
+	if host := hostProfile(); host != "" {
+		names = append(names, host)
+	}
+	return names
+}
+
+// profileNamed returns the name of the defined profile called name, ignoring case so
+// that [host:MyLaptop] matches the lower-cased hostname
+func (c *Config) profileNamed(name string) (string, bool) {
+	if _, exists := c.Profiles[name]; exists {
+		return name, true
+	}
+	for _, defined := range c.sortedProfileNames("*", "") {
+		if strings.EqualFold(defined, name) {
+			return defined, true
+		}
+	}
+	return "", false
+}
+
+// sortedProfileNames returns the sorted names of the profiles matching pattern, except skip
+func (c *Config) sortedProfileNames(pattern, skip string) []string {
+	var names []string
+	for name := range c.Profiles {
+		if matched, _ := path.Match(pattern, name); matched && name != skip {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isPattern reports whether a profile name contains glob metacharacters
+func isPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// IsAll reports whether the profile names include the "all" pseudo-profile
+func IsAll(profileNames []string) bool {
+	for _, name := range profileNames {
+		if name == AllProfiles {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the mappings of the given profiles
+// Normally profiles are merged like GetProfiles, later or higher-priority profiles
+// overriding the others
+// With the "all" pseudo-profile every target of every profile is selected instead, so a
+// source mapped to different targets in different profiles yields all of them
+func (c *Config) Select(profileNames []string) ([]Mapping, error) {
+	if IsAll(profileNames) {
+		return c.selectAll(), nil
+	}
+
+	profileNames, err := c.ExpandProfiles(profileNames)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, origins, err := c.merge(profileNames)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make([]Mapping, 0, len(profile))
+	for source, target := range profile {
+		mappings = append(mappings, c.mapping(origins[source], source, target))
+	}
+
+	return mappings, nil
+}
+
+// mapping returns the Mapping of source to target in profile, with the options of its entry
+func (c *Config) mapping(profile, source, target string) Mapping {
+	entry := c.Entries[profile][source]
+	m := Mapping{Source: source, Target: target, Profile: profile, Team: c.FromTeam(source), Tree: entry.Tree, Delims: entry.Delims}
+	if m.Delims == nil {
+		m.Delims = c.TemplateDelims
+	}
+	return m
+}
+
+// selectAll returns one mapping per distinct target across every profile
+// When profiles map different sources to the same target, the later profile wins unless
+// the earlier mapping has a higher priority
+func (c *Config) selectAll() []Mapping {
+	byTarget := make(map[string]Mapping)
+	var order []string
+
+	names, _ := c.ExpandProfiles([]string{AllProfiles})
+	for _, name := range names {
+		profile := c.Profiles[name]
+		sources := make([]string, 0, len(profile))
+		for source := range profile {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+
+		for _, source := range sources {
+			target := profile[source]
+			previous, exists := byTarget[target]
+			if !exists {
+				order = append(order, target)
+			} else if c.Priority(previous.Profile, previous.Source) > c.Priority(name, source) {
+				continue
+			}
+			byTarget[target] = c.mapping(name, source, target)
+		}
+	}
+
+	mappings := make([]Mapping, 0, len(order))
+	for _, target := range order {
+		mappings = append(mappings, byTarget[target])
+	}
+	return mappings
+}
+
+// Conflict is a target that several selected profiles map different sources to
+type Conflict struct {
+	Target     string
+	Winner     Mapping   // the mapping applied: the highest priority, then the highest-precedence profile
+	Overridden []Mapping // the mappings it replaces, highest precedence first
+}
+
+// String describes how the conflict is resolved, e.g.
+// "~/.gitconfig: work/.gitconfig-work overrides general/git/.gitconfig"
+func (c Conflict) String() string {
+	overridden := make([]string, len(c.Overridden))
+	for i, m := range c.Overridden {
+		overridden[i] = m.Profile + "/" + m.Source
+	}
+	return fmt.Sprintf("%s: %s/%s overrides %s", c.Target, c.Winner.Profile, c.Winner.Source, strings.Join(overridden, ", "))
+}
+
+// Conflicts returns the targets that the given profiles map different sources to,
+// sorted by target, describing which mapping precedence selects
+func (c *Config) Conflicts(profileNames []string) ([]Conflict, error) {
+	if len(profileNames) == 0 {
+		profileNames = []string{"general"}
+	}
+	names, err := c.ExpandProfiles(profileNames)
+	if err != nil {
+		return nil, err
+	}
+
+	// Precedence order: [general] first, then the others in the order given
+	chain := []string{"general"}
+	for _, name := range names {
+		if name != "general" {
+			chain = append(chain, name)
+		}
+	}
+
+	byTarget := make(map[string][]Mapping)
+	for _, name := range chain {
+		profile, exists := c.Profiles[name]
+		if !exists {
+			return nil, fmt.Errorf("profile [%s] not found in .mappings", name)
+		}
+		sources := make([]string, 0, len(profile))
+		for source := range profile {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+
+		for _, source := range sources {
+			target := profile[source]
+			byTarget[target] = append(byTarget[target], Mapping{Source: source, Target: target, Profile: name})
+		}
+	}
+
+	var conflicts []Conflict
+	for target, mappings := range byTarget {
+		won := len(mappings) - 1
+		for i := won - 1; i >= 0; i-- {
+			if c.Priority(mappings[i].Profile, mappings[i].Source) > c.Priority(mappings[won].Profile, mappings[won].Source) {
+				won = i
+			}
+		}
+		winner := mappings[won]
+
+		var overridden []Mapping
+		for i := len(mappings) - 1; i >= 0; i-- {
+			if i != won && mappings[i].Source != winner.Source {
+				overridden = append(overridden, mappings[i])
+			}
+		}
+		if len(overridden) > 0 {
+			conflicts = append(conflicts, Conflict{Target: target, Winner: winner, Overridden: overridden})
+		}
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Target < conflicts[j].Target })
+	return conflicts, nil
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	cfg := &Config{
+		Profiles: map[string]Profile{
+			"general": {"vim/.vimrc": "~/.vimrc", "git/.gitconfig": "~/.gitconfig"},
+			"work":    {"git/.gitconfig-work": "~/.gitconfig", "vim/.vimrc": "~/.vimrc-work"},
+			"laptop":  {"tmux/.tmux.conf": "~/.tmux.conf"},
+		},
+	}
+
+	describe := func(mappings []Mapping) string {
+		var parts []string
+		for _, m := range mappings {
+			parts = append(parts, m.Profile+":"+m.Source+"="+m.Target)
+		}
+		sort.Strings(parts)
+		return strings.Join(parts, " ")
+	}
+
+	t.Run("Merges profiles by precedence", func(t *testing.T) {
+		mappings, err := cfg.Select([]string{"general", "work"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := "work:git/.gitconfig-work=~/.gitconfig work:vim/.vimrc=~/.vimrc-work"
+		if describe(mappings) != expected {
+			t.Errorf("Expected %s, got %s", expected, describe(mappings))
+		}
+	})
+
+	t.Run("All selects every target of every profile", func(t *testing.T) {
+		mappings, err := cfg.Select([]string{AllProfiles})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := "general:vim/.vimrc=~/.vimrc laptop:tmux/.tmux.conf=~/.tmux.conf work:git/.gitconfig-work=~/.gitconfig work:vim/.vimrc=~/.vimrc-work"
+		if describe(mappings) != expected {
+			t.Errorf("Expected %s, got %s", expected, describe(mappings))
+		}
+	})
+
+	t.Run("Glob pattern attributes mappings to matched profiles", func(t *testing.T) {
+		mappings, err := cfg.Select([]string{"general", "wor*"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := "work:git/.gitconfig-work=~/.gitconfig work:vim/.vimrc=~/.vimrc-work"
+		if describe(mappings) != expected {
+			t.Errorf("Expected %s, got %s", expected, describe(mappings))
+		}
+	})
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		if _, err := cfg.Select([]string{"missing"}); err == nil {
+			t.Error("Expected error for unknown profile")
+		}
+	})
+}
+
+func TestExpandProfiles(t *testing.T) {
+	cfg := &Config{Profiles: map[string]Profile{"general": {}, "work": {}, "work-vpn": {}, "work-k8s": {}, "laptop": {}}}
+
+	tests := []struct {
+		name     string
+		input    []string
+		expected string
+	}{
+		{"All", []string{"all"}, "general,laptop,work,work-k8s,work-vpn"},
+		{"Plain names unchanged", []string{"general", "work"}, "general,work"},
+		{"Glob matches sorted", []string{"general", "work*"}, "general,work,work-k8s,work-vpn"},
+		{"Duplicates kept once", []string{"work", "work*"}, "work,work-k8s,work-vpn"},
+		{"Character class", []string{"work-[kv]*"}, "work-k8s,work-vpn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := cfg.ExpandProfiles(tt.input)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if strings.Join(result, ",") != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, strings.Join(result, ","))
+			}
+		})
+	}
+
+	t.Run("Pattern without matches", func(t *testing.T) {
+		_, err := cfg.ExpandProfiles([]string{"home*"})
+		if err == nil || !strings.Contains(err.Error(), "matches no profiles") {
+			t.Errorf("Expected no match error, got %v", err)
+		}
+	})
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		if _, err := cfg.ExpandProfiles([]string{"work["}); err == nil {
+			t.Error("Expected error for invalid pattern")
+		}
+	})
+}
+
+func TestReservedAllProfile(t *testing.T) {
+	dotfilesDir := t.TempDir()
+	content := "[general]\n[all]\n\"vim/.vimrc\" = \"~/.vimrc\"\n"
+	if err := os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write .mappings: %v", err)
+	}
+
+	_, err := ParseConfig(dotfilesDir)
+	if err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("Expected reserved profile error, got %v", err)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	cfg := &Config{
+		Profiles: map[string]Profile{
+			"general": {"git/.gitconfig": "~/.gitconfig", "vim/.vimrc": "~/.vimrc"},
+			"work":    {".gitconfig-work": "~/.gitconfig", "vim/.vimrc": "~/.vimrc"},
+			"laptop":  {"git/.gitconfig-laptop": "~/.gitconfig"},
+		},
+	}
+
+	t.Run("Reports overridden sources", func(t *testing.T) {
+		conflicts, err := cfg.Conflicts([]string{"general", "work"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(conflicts) != 1 {
+			t.Fatalf("Expected 1 conflict, got %d", len(conflicts))
+		}
+
+		expected := "~/.gitconfig: work/.gitconfig-work overrides general/git/.gitconfig"
+		if conflicts[0].String() != expected {
+			t.Errorf("Expected %q, got %q", expected, conflicts[0].String())
+		}
+	})
+
+	t.Run("Lists every overridden source by precedence", func(t *testing.T) {
+		conflicts, err := cfg.Conflicts([]string{"work", "laptop"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := "~/.gitconfig: laptop/git/.gitconfig-laptop overrides work/.gitconfig-work, general/git/.gitconfig"
+		if len(conflicts) != 1 || conflicts[0].String() != expected {
+			t.Errorf("Expected %q, got %v", expected, conflicts)
+		}
+	})
+
+	t.Run("Priority outranks precedence", func(t *testing.T) {
+		ranked := &Config{Profiles: cfg.Profiles, Priorities: map[string]int{"work": 1}}
+		conflicts, _ := ranked.Conflicts([]string{"work", "laptop"})
+
+		expected := "~/.gitconfig: work/.gitconfig-work overrides laptop/git/.gitconfig-laptop, general/git/.gitconfig"
+		if len(conflicts) != 1 || conflicts[0].String() != expected {
+			t.Errorf("Expected %q, got %v", expected, conflicts)
+		}
+
+		mappings, _ := ranked.Select([]string{"work", "laptop"})
+		for _, m := range mappings {
+			if m.Target == "~/.gitconfig" && m.Profile != "work" {
+				t.Errorf("Expected work's .gitconfig to be selected, got %v", m)
+			}
+		}
+	})
+
+	t.Run("Same source in several profiles is not a conflict", func(t *testing.T) {
+		conflicts, _ := cfg.Conflicts([]string{"general"})
+		if len(conflicts) != 0 {
+			t.Errorf("Expected no conflicts, got %v", conflicts)
+		}
+	})
+}
+
+func TestAutomaticProfiles(t *testing.T) {
+	original := automaticProfiles
+	defer func() { automaticProfiles = original }()
+	automaticProfiles = func() []string { return []string{"wsl"} }
+
+	cfg := &Config{
+		Profiles: map[string]Profile{
+			"general": {"git/.gitconfig": "~/.gitconfig"},
+			"wsl":     {"git/.gitconfig-wsl": "~/.gitconfig", "wsl/wsl.conf": "/etc/wsl.conf"},
+			"work":    {"git/.gitconfig-work": "~/.gitconfig"},
+		},
+	}
+
+	t.Run("Defined profile is included ahead of the selected ones", func(t *testing.T) {
+		names, err := cfg.ExpandProfiles([]string{"general", "work"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.Join(names, ",") != "wsl,general,work" {
+			t.Errorf("Expected wsl,general,work, got %v", names)
+		}
+	})
+
+	t.Run("Selected profiles override it", func(t *testing.T) {
+		profile, err := cfg.GetProfiles([]string{"general", "work"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, exists := profile["git/.gitconfig-work"]; !exists {
+			t.Errorf("Expected work to win ~/.gitconfig, got %v", profile)
+		}
+		if _, exists := profile["wsl/wsl.conf"]; !exists {
+			t.Errorf("Expected wsl mappings to be included, got %v", profile)
+		}
+	})
+
+	t.Run("Selecting it explicitly keeps one copy", func(t *testing.T) {
+		names, err := cfg.ExpandProfiles([]string{"general", "wsl"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.Join(names, ",") != "general,wsl" {
+			t.Errorf("Expected general,wsl, got %v", names)
+		}
+	})
+
+	t.Run("Undefined profile is not included", func(t *testing.T) {
+		cfg := &Config{Profiles: map[string]Profile{"general": {"vim/.vimrc": "~/.vimrc"}}}
+		names, err := cfg.ExpandProfiles([]string{"general"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.Join(names, ",") != "general" {
+			t.Errorf("Expected general, got %v", names)
+		}
+	})
+}
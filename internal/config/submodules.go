@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+// mergeSubmodules merges the .mappings files of the git submodules of dir beneath the
+// configuration, so a shared repository such as a team base can contribute its own profiles
+// Submodule sources are prefixed with the submodule's path, and a submodule mapping is left
+// out when the same profile already maps its source or target, so the including repository
+// always wins
+// Submodules without a .mappings file, e.g. ones not checked out yet, are skipped
+func (c *Config) mergeSubmodules(f fsys.FS, dir string) error {
+	paths, err := submodulePaths(f, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, submodule := range paths {
+		subDir := filepath.Join(dir, filepath.FromSlash(submodule))
+		if _, err := f.Stat(filepath.Join(subDir, ".mappings")); os.IsNotExist(err) {
+			continue
+		}
+
+		sub, err := parseMappings(f, subDir)
+		if err != nil {
+			return fmt.Errorf("submodule %s: %w", submodule, err)
+		}
+		if err := sub.mergeSubmodules(f, subDir); err != nil {
+			return fmt.Errorf("submodule %s: %w", submodule, err)
+		}
+		c.mergeBeneath(sub, submodule)
+	}
+	return nil
+}
+
+// mergeBeneath adds the mappings of sub, whose sources are relative to prefix, to the
+// profiles of c that do not already map the same source or target
+// Priorities and hooks of sub apply to the profiles c does not rank or hook itself, and
+// its vars to the names c does not set; its template delimiters stay with its own entries,
+// and its remotes, which are those of another repository, are left out
+func (c *Config) mergeBeneath(sub *Config, prefix string) {
+	for name, priority := range sub.Priorities {
+		if _, ranked := c.Priorities[name]; !ranked {
+			c.Priorities[name] = priority
+		}
+	}
+	for name, hooks := range sub.Hooks {
+		if _, hooked := c.Hooks[name]; !hooked {
+			c.Hooks[name] = hooks
+		}
+	}
+	for name, value := range sub.Vars {
+		if _, set := c.Vars[name]; !set {
+			c.Vars[name] = value
+		}
+	}
+	for name, profile := range sub.Profiles {
+		merged := c.Profiles[name]
+		if merged == nil {
+			merged = make(Profile, len(profile))
+			c.Profiles[name] = merged
+		}
+		targets := make(map[string]bool, len(merged))
+		for _, target := range merged {
+			targets[target] = true
+		}
+
+		for src, target := range profile {
+			source := path.Join(prefix, src)
+			if _, exists := merged[source]; exists || targets[target] {
+				continue
+			}
+			merged[source] = target
+			targets[target] = true
+			entry, exists := sub.Entries[name][src]
+			if entry.Delims == nil && sub.TemplateDelims != nil {
+				entry.Delims, exists = sub.TemplateDelims, true
+			}
+			if exists {
+				if c.Entries[name] == nil {
+					c.Entries[name] = make(map[string]Entry)
+				}
+				c.Entries[name][source] = entry
+			}
+		}
+	}
+}
+
+// submodulePaths returns the paths of the submodules declared in dir's .gitmodules file,
+// sorted so that earlier submodules take precedence in a stable order
+func submodulePaths(f fsys.FS, dir string) ([]string, error) {
+	data, err := f.ReadFile(filepath.Join(dir, ".gitmodules"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .gitmodules: %w", err)
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if found && strings.TrimSpace(key) == "path" {
+			paths = append(paths, strings.Trim(strings.TrimSpace(value), `"`))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
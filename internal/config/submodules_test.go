@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubmodules(t *testing.T) {
+	// setup creates a dotfiles repository with a team submodule holding teamMappings
+	setup := func(t *testing.T, mappings, teamMappings string) string {
+		dir := createTempMappings(t, mappings)
+		os.WriteFile(filepath.Join(dir, ".gitmodules"), []byte("[submodule \"team\"]\n\tpath = team\n\turl = git@example.com:team/base.git\n"), 0644)
+		os.MkdirAll(filepath.Join(dir, "team"), 0755)
+		if teamMappings != "" {
+			os.WriteFile(filepath.Join(dir, "team", ".mappings"), []byte(teamMappings), 0644)
+		}
+		return dir
+	}
+
+	t.Run("Submodule mappings are merged beneath", func(t *testing.T) {
+		dir := setup(t, `[general]
+"zsh/.zshrc" = "~/.zshrc"
+"git/.gitconfig" = "~/.gitconfig"
+`, `[general]
+"git/.gitconfig" = "~/.gitconfig"
+"tmux/.tmux.conf" = "~/.tmux.conf"
+"zsh/.zshrc" = "~/.zshrc-team"
+
+[work]
+"ssh/config" = { target = "~/.ssh/config", on_change = "echo changed" }
+`)
+
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		general := config.Profiles["general"]
+		if general["team/tmux/.tmux.conf"] != "~/.tmux.conf" {
+			t.Errorf("Expected the team's tmux mapping, got %v", general)
+		}
+		if _, exists := general["team/git/.gitconfig"]; exists {
+			t.Error("Expected the repository's ~/.gitconfig to win over the team's")
+		}
+		if general["team/zsh/.zshrc"] != "~/.zshrc-team" {
+			t.Errorf("Expected the team's other zshrc target to be kept, got %v", general)
+		}
+		if entry := config.Entries["work"]["team/ssh/config"]; entry.OnChange != "echo changed" {
+			t.Errorf("Expected the team's entry options under the prefixed source, got %+v", entry)
+		}
+	})
+
+	t.Run("Submodule that is not checked out is skipped", func(t *testing.T) {
+		dir := setup(t, "[general]\n\"zsh/.zshrc\" = \"~/.zshrc\"\n", "")
+
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(config.Profiles["general"]) != 1 {
+			t.Errorf("Expected only the repository's mapping, got %v", config.Profiles["general"])
+		}
+	})
+
+	t.Run("Invalid submodule mappings name the submodule", func(t *testing.T) {
+		dir := setup(t, "[general]\n", "[general]\n\"a\" = 1\n")
+
+		_, err := ParseConfig(dir)
+		if err == nil || !strings.Contains(err.Error(), "submodule team") {
+			t.Errorf("Expected submodule error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/settings"
+)
+
+// mergeTeam merges the .mappings file of the team repository set in the global config
+// beneath the configuration, like a submodule's, so the personal repository's mappings
+// override the team's; a team repository that is not checked out yet is skipped
+// Team sources are relative to the dotfiles directory like all others, and so lead out
+// of it when the team repository is checked out elsewhere
+func (c *Config) mergeTeam(f fsys.FS, dotfilesDir string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	if !cfg.Team.Enabled() {
+		return nil
+	}
+	teamDir, err := cfg.Team.Directory()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Stat(filepath.Join(teamDir, ".mappings")); os.IsNotExist(err) {
+		return nil
+	}
+
+	team, err := parseMappings(f, teamDir)
+	if err != nil {
+		return fmt.Errorf("team repository: %w", err)
+	}
+	if err := team.mergeSubmodules(f, teamDir); err != nil {
+		return fmt.Errorf("team repository: %w", err)
+	}
+
+	prefix, err := filepath.Rel(dotfilesDir, teamDir)
+	if err != nil {
+		return fmt.Errorf("team repository %s: %w", teamDir, err)
+	}
+	c.TeamPrefix = filepath.ToSlash(prefix)
+	c.mergeBeneath(team, c.TeamPrefix)
+	return nil
+}
+
+// FromTeam reports whether source, relative to the dotfiles directory, lies in the
+// read-only team repository
+func (c *Config) FromTeam(source string) bool {
+	return c.TeamPrefix != "" && strings.HasPrefix(source, c.TeamPrefix+"/")
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTeam(t *testing.T) {
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+
+	// setup creates a dotfiles repository and a team repository next to it, named in the
+	// global config
+	setup := func(t *testing.T, mappings, teamMappings string) (dir, teamDir string) {
+		dir = createTempMappings(t, mappings)
+		teamDir = filepath.Join(t.TempDir(), "team")
+		os.MkdirAll(teamDir, 0755)
+		if teamMappings != "" {
+			os.WriteFile(filepath.Join(teamDir, ".mappings"), []byte(teamMappings), 0644)
+		}
+
+		configHome := t.TempDir()
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("[team]\ndir = \""+filepath.ToSlash(teamDir)+"\"\n"), 0644)
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+		return dir, teamDir
+	}
+
+	t.Run("Personal mappings override the team's", func(t *testing.T) {
+		dir, teamDir := setup(t, `[general]
+"zsh/.zshrc" = "~/.zshrc"
+`, `[general]
+"zsh/.zshrc" = "~/.zshrc"
+"git/.gitconfig" = "~/.gitconfig"
+`)
+
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		mappings, err := config.Select([]string{"general"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		bySource := make(map[string]Mapping)
+		for _, m := range mappings {
+			bySource[filepath.Base(m.Source)] = m
+		}
+		if m := bySource[".zshrc"]; m.Source != "zsh/.zshrc" || m.Team {
+			t.Errorf("Expected the personal ~/.zshrc to win, got %+v", m)
+		}
+		gitconfig := bySource[".gitconfig"]
+		if !gitconfig.Team {
+			t.Errorf("Expected ~/.gitconfig to come from the team repository, got %+v", gitconfig)
+		}
+		if result := filepath.Join(dir, filepath.FromSlash(gitconfig.Source)); result != filepath.Join(teamDir, "git", ".gitconfig") {
+			t.Errorf("Expected the team source to resolve into the team repository, got %s", result)
+		}
+	})
+
+	t.Run("Team repository that is not checked out is skipped", func(t *testing.T) {
+		dir, _ := setup(t, "[general]\n\"zsh/.zshrc\" = \"~/.zshrc\"\n", "")
+
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(config.Profiles["general"]) != 1 || config.TeamPrefix != "" {
+			t.Errorf("Expected only the repository's mapping, got %v", config.Profiles["general"])
+		}
+	})
+}
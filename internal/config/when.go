@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// lookPath finds commands for the command-exists predicate; tests replace it
+var lookPath = exec.LookPath
+
+// predicates are the conditions a when expression can test, each taking one argument
+var predicates = map[string]func(arg string) bool{
+	// command-exists nvim: the command is in $PATH
+	"command-exists": func(name string) bool {
+		_, err := lookPath(name)
+		return err == nil
+	},
+	// env WORK_LAPTOP: the variable is set and not empty; env SHELL=/bin/zsh: it has the value
+	"env": func(arg string) bool {
+		if name, value, found := strings.Cut(arg, "="); found {
+			return os.Getenv(name) == value
+		}
+		return os.Getenv(arg) != ""
+	},
+	// file-exists ~/.config/work: the file or directory exists
+	"file-exists": func(path string) bool {
+		_, err := os.Stat(utils.ExpandPath(path))
+		return err == nil
+	},
+	// os darwin: dot runs on the given GOOS
+	"os": func(goos string) bool {
+		return runtime.GOOS == goos
+	},
+}
+
+// knownGOOS and knownGOARCH are the values runtime.GOOS and runtime.GOARCH can take, so
+// a typo in os, arch, or a [profile.<os>] subsection is caught on every machine
+var (
+	knownGOOS = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js",
+		"linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows"}
+	knownGOARCH = []string{"386", "amd64", "arm", "arm64", "loong64", "mips", "mips64", "mips64le",
+		"mipsle", "ppc64", "ppc64le", "riscv64", "s390x", "wasm"}
+)
+
+// isGOOS reports whether name is a runtime.GOOS value
+func isGOOS(name string) bool {
+	return slices.Contains(knownGOOS, name)
+}
+
+// isGOARCH reports whether name is a runtime.GOARCH value
+func isGOARCH(name string) bool {
+	return slices.Contains(knownGOARCH, name)
+}
+
+// evalWhen evaluates the when expression of an entry, e.g.
+// "command-exists nvim && !env MINIMAL", where || binds weaker than &&
+// An expression that does not parse is an error, whatever machine it is evaluated on
+func evalWhen(expr string) (bool, error) {
+	result := false
+	for _, alternative := range strings.Split(expr, "||") {
+		all := true
+		for _, clause := range strings.Split(alternative, "&&") {
+			ok, err := evalClause(strings.TrimSpace(clause))
+			if err != nil {
+				return false, err
+			}
+			all = all && ok
+		}
+		result = result || all
+	}
+	return result, nil
+}
+
+// evalClause evaluates a single predicate, negated by a leading !
+func evalClause(clause string) (bool, error) {
+	negate := false
+	if rest, found := strings.CutPrefix(clause, "!"); found {
+		negate, clause = true, strings.TrimSpace(rest)
+	}
+
+	fields := strings.Fields(clause)
+	if len(fields) == 0 {
+		return false, fmt.Errorf("empty condition")
+	}
+	predicate, known := predicates[fields[0]]
+	if !known {
+		return false, fmt.Errorf("unknown condition %q, expected command-exists, env, file-exists, or os", fields[0])
+	}
+	if len(fields) != 2 {
+		return false, fmt.Errorf("%s takes one argument, got %d", fields[0], len(fields)-1)
+	}
+	return predicate(fields[1]) != negate, nil
+}
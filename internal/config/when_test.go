@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEvalWhen(t *testing.T) {
+	originalLookPath := lookPath
+	defer func() { lookPath = originalLookPath }()
+	lookPath = func(name string) (string, error) {
+		if name == "nvim" {
+			return "/usr/bin/nvim", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	os.Setenv("DOT_TEST_WHEN", "work")
+	defer os.Unsetenv("DOT_TEST_WHEN")
+
+	tests := map[string]bool{
+		"command-exists nvim":                         true,
+		"command-exists emacs":                        false,
+		"!command-exists emacs":                       true,
+		"env DOT_TEST_WHEN":                           true,
+		"env DOT_TEST_WHEN=work":                      true,
+		"env DOT_TEST_WHEN=home":                      false,
+		"env DOT_TEST_UNSET":                          false,
+		"os " + runtime.GOOS:                          true,
+		"command-exists nvim && env DOT_TEST_UNSET":   false,
+		"command-exists emacs || command-exists nvim": true,
+		"command-exists emacs || os " + runtime.GOOS:  true,
+		"command-exists nvim && ! env DOT_TEST_UNSET": true,
+		"file-exists " + os.TempDir():                 true,
+		"file-exists /nonexistent/dot-test/when":      false,
+	}
+
+	for expr, expected := range tests {
+		result, err := evalWhen(expr)
+		if err != nil {
+			t.Errorf("evalWhen(%q) failed: %v", expr, err)
+			continue
+		}
+		if result != expected {
+			t.Errorf("evalWhen(%q) = %v, want %v", expr, result, expected)
+		}
+	}
+
+	for _, expr := range []string{"installed nvim", "command-exists", "env A B", "command-exists nvim &&"} {
+		if _, err := evalWhen(expr); err == nil {
+			t.Errorf("Expected evalWhen(%q) to fail", expr)
+		}
+	}
+}
+
+func TestWhenEntries(t *testing.T) {
+	originalLookPath := lookPath
+	defer func() { lookPath = originalLookPath }()
+	lookPath = func(name string) (string, error) {
+		if name == "nvim" {
+			return "/usr/bin/nvim", nil
+		}
+		return "", errors.New("not found")
+	}
+
+	t.Run("Entries whose condition fails are left out", func(t *testing.T) {
+		content := `[general]
+"nvim" = { target = "~/.config/nvim", when = "command-exists nvim" }
+"emacs.d" = { target = "~/.emacs.d", when = "command-exists emacs" }`
+
+		config, err := ParseConfig(createTempMappings(t, content))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if config.Profiles["general"]["nvim"] != "~/.config/nvim" {
+			t.Errorf("Expected nvim to be mapped, got %v", config.Profiles["general"])
+		}
+		if _, exists := config.Profiles["general"]["emacs.d"]; exists {
+			t.Errorf("Expected emacs.d to be left out, got %v", config.Profiles["general"])
+		}
+	})
+
+	t.Run("Invalid conditions are errors", func(t *testing.T) {
+		content := "[general]\n\"nvim\" = { target = \"~/.config/nvim\", when = \"installed nvim\" }\n"
+		_, err := ParseConfig(createTempMappings(t, content))
+		if err == nil || !strings.Contains(err.Error(), "[general] nvim: when: unknown condition") {
+			t.Errorf("Expected unknown condition error, got %v", err)
+		}
+	})
+}
+
+func TestPlatformEntries(t *testing.T) {
+	other := "windows"
+	if runtime.GOOS == other {
+		other = "linux"
+	}
+
+	t.Run("os and arch restrict entries", func(t *testing.T) {
+		content := `[general]
+"here" = { target = "~/.here", os = ["` + runtime.GOOS + `"] }
+"there" = { target = "~/.there", os = ["` + other + `"] }
+"this-arch" = { target = "~/.this-arch", os = ["` + other + `", "` + runtime.GOOS + `"], arch = ["` + runtime.GOARCH + `"] }
+"wasm" = { target = "~/.wasm", arch = ["wasm"] }`
+
+		config, err := ParseConfig(createTempMappings(t, content))
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		general := config.Profiles["general"]
+		if general["here"] != "~/.here" || general["this-arch"] != "~/.this-arch" {
+			t.Errorf("Expected the entries for this machine to be mapped, got %v", general)
+		}
+		if _, exists := general["there"]; exists {
+			t.Errorf("Expected the entry for %s to be left out, got %v", other, general)
+		}
+		if _, exists := general["wasm"]; exists && runtime.GOARCH != "wasm" {
+			t.Errorf("Expected the wasm entry to be left out, got %v", general)
+		}
+	})
+
+	t.Run("OS subsections", func(t *testing.T) {
+		content := `[general]
+"shell/.profile" = "~/.profile"
+"git/.gitconfig" = "~/.gitconfig"
+
+[general.` + runtime.GOOS + `]
+"git/.gitconfig-here" = "~/.gitconfig"
+"here" = { target = "~/.here", description = "only here" }
+
+[general.` + other + `]
+"there" = "~/.there"`
+
+		dir := createTempMappings(t, content)
+		config, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		general := config.Profiles["general"]
+		if general["shell/.profile"] != "~/.profile" || general["here"] != "~/.here" {
+			t.Errorf("Expected the profile and this OS's subsection to be mapped, got %v", general)
+		}
+		if _, exists := general["there"]; exists {
+			t.Errorf("Expected the subsection for %s to be left out, got %v", other, general)
+		}
+		if config.Entries["general"]["here"].Description != "only here" {
+			t.Errorf("Expected the entry of the subsection, got %+v", config.Entries["general"])
+		}
+
+		mappings, err := config.Select([]string{"general"})
+		if err != nil {
+			t.Fatalf("Select failed: %v", err)
+		}
+		for _, m := range mappings {
+			if m.Target == "~/.gitconfig" && m.Source != "git/.gitconfig-here" {
+				t.Errorf("Expected the subsection to win for ~/.gitconfig, got %s", m.Source)
+			}
+		}
+
+		data, _ := os.ReadFile(dir + "/.mappings")
+		if line := MappingLine(data, "general", "here"); line != 7 {
+			t.Errorf("Expected the entry of the subsection on line 7, got %d", line)
+		}
+	})
+
+	t.Run("Unknown platforms are errors", func(t *testing.T) {
+		for _, content := range []string{
+			`[general]
+"a" = { target = "~/.a", os = ["macos"] }`,
+			`[general]
+"a" = { target = "~/.a", arch = ["x86_64"] }`,
+			`[general.` + other + `]
+"a" = { target = "~/.a", os = ["macos"] }`,
+		} {
+			if _, err := ParseConfig(createTempMappings(t, content)); err == nil || !strings.Contains(err.Error(), "unknown") {
+				t.Errorf("Expected an unknown platform error for %q, got %v", content, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Encode writes profiles in .mappings TOML format
+// [general] is written first, remaining profiles follow in alphabetical order
+func Encode(w io.Writer, profiles map[string]Profile) error {
+	bw := bufio.NewWriter(w)
+
+	for i, name := range sortedProfileNames(profiles) {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "[%s]\n", quoteKey(name))
+
+		profile := profiles[name]
+		sources := make([]string, 0, len(profile))
+		for src := range profile {
+			sources = append(sources, src)
+		}
+		sort.Strings(sources)
+
+		for _, src := range sources {
+			fmt.Fprintf(bw, "%s = %s\n", quoteString(src), quoteString(profile[src]))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteConfig writes profiles to the .mappings file in the dotfiles directory
+// Refuses to overwrite an existing .mappings file
+func WriteConfig(dotfilesDir string, profiles map[string]Profile) error {
+	mappingsPath := filepath.Join(dotfilesDir, ".mappings")
+
+	file, err := os.OpenFile(mappingsPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf(".mappings file already exists at %s", mappingsPath)
+		}
+		return fmt.Errorf("failed to create .mappings file: %w", err)
+	}
+	defer file.Close()
+
+	if err := Encode(file, profiles); err != nil {
+		return fmt.Errorf("failed to write .mappings file: %w", err)
+	}
+
+	return nil
+}
+
+// sortedProfileNames returns profile names with general first and the rest sorted
+func sortedProfileNames(profiles map[string]Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		if name != "general" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if _, exists := profiles["general"]; exists {
+		names = append([]string{"general"}, names...)
+	}
+
+	return names
+}
+
+// quoteKey returns a bare TOML key when possible, otherwise a quoted one
+func quoteKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for _, r := range key {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return quoteString(key)
+		}
+	}
+	return key
+}
+
+// quoteString returns s as a TOML basic string
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// AddMapping inserts a source -> target entry into the given profile of the .mappings file
+// source is relative to the dotfiles directory and must lie within its source_root
+// The file is edited in place so existing comments and formatting are preserved;
+// the profile section is appended if it does not exist yet
+func AddMapping(dotfilesDir, profile, source, target string) error {
+	return AddEntry(dotfilesDir, profile, source, Entry{Target: target})
+}
+
+// AddEntry inserts source into the given profile like AddMapping, with the target and
+// per-OS targets of entry; an entry with targets is written as an inline table, e.g.
+// "alacritty.toml" = { target = "~/.config/alacritty.toml", targets = { windows = "..." } }
+func AddEntry(dotfilesDir, profile, source string, entry Entry) error {
+	mappingsPath := filepath.Join(dotfilesDir, ".mappings")
+
+	if profile == PrioritiesKey || profile == SourceRootsKey || profile == VarsKey || profile == HooksKey || profile == RemotesKey {
+		return fmt.Errorf("[%s] is not a profile and cannot hold mappings", profile)
+	}
+
+	cfg, err := ParseConfig(dotfilesDir)
+	if err != nil {
+		return err
+	}
+	if existing, exists := cfg.Profiles[profile][source]; exists {
+		return fmt.Errorf("%s is already mapped to %s in [%s]", source, existing, profile)
+	}
+	if _, exists := cfg.Entries[profile][source]; exists {
+		return fmt.Errorf("%s is already mapped in [%s]", source, profile)
+	}
+	src, ok := cfg.MappingsSource(profile, source)
+	if !ok {
+		return fmt.Errorf("%s is outside the source root %s of [%s]", source, cfg.ProfileRoot(profile), profile)
+	}
+
+	data, err := os.ReadFile(mappingsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read .mappings file: %w", err)
+	}
+
+	line := fmt.Sprintf("%s = %s", quoteString(src), formatTargets(entry))
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+
+	insertAt := -1
+	inProfile := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			if inProfile {
+				break
+			}
+			inProfile = isProfileHeader(trimmed, profile)
+			if inProfile {
+				insertAt = i + 1
+			}
+			continue
+		}
+		if inProfile && trimmed != "" {
+			insertAt = i + 1
+		}
+	}
+
+	if insertAt < 0 {
+		lines = append(lines, "", fmt.Sprintf("[%s]", quoteKey(profile)), line)
+	} else {
+		lines = append(lines[:insertAt], append([]string{line}, lines[insertAt:]...)...)
+	}
+
+	if err := os.WriteFile(mappingsPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write .mappings file: %w", err)
+	}
+
+	return nil
+}
+
+// formatTargets returns the value of a .mappings line mapping to the targets of entry: the
+// target as a string, or an inline table if there are per-OS targets
+func formatTargets(entry Entry) string {
+	if len(entry.Targets) == 0 {
+		return quoteString(entry.Target)
+	}
+
+	var fields []string
+	if entry.Target != "" {
+		fields = append(fields, "target = "+quoteString(entry.Target))
+	}
+	goos := make([]string, 0, len(entry.Targets))
+	for name := range entry.Targets {
+		goos = append(goos, name)
+	}
+	sort.Strings(goos)
+	targets := make([]string, len(goos))
+	for i, name := range goos {
+		targets[i] = fmt.Sprintf("%s = %s", quoteKey(name), quoteString(entry.Targets[name]))
+	}
+	fields = append(fields, "targets = { "+strings.Join(targets, ", ")+" }")
+	return "{ " + strings.Join(fields, ", ") + " }"
+}
+
+// isProfileHeader reports whether a trimmed line is the [profile] table header
+func isProfileHeader(line, profile string) bool {
+	if strings.HasPrefix(line, "[[") {
+		return false
+	}
+
+	header := line[1:]
+	if end := strings.Index(header, "]"); end >= 0 {
+		header = header[:end]
+	}
+	header = strings.TrimSpace(header)
+
+	return header == profile || header == quoteString(profile) || header == "'"+profile+"'"
+}
+
+// MappingLine returns the 1-based line of .mappings data that declares src in the given
+// profile, or in its subsection for this OS, or 0 if it is not found; src is the key as
+// written, relative to the source root
+func MappingLine(data []byte, profile, src string) int {
+	keys := []string{quoteString(src), "'" + src + "'", src}
+	inProfile := false
+	line := 0
+	for i, text := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(text)
+		if strings.HasPrefix(trimmed, "[") {
+			inProfile = isProfileHeader(trimmed, profile) || isProfileHeader(trimmed, profile+"."+runtime.GOOS)
+			continue
+		}
+		if !inProfile {
+			continue
+		}
+		for _, key := range keys {
+			// A later declaration in the subsection is the one that applies
+			if rest, found := strings.CutPrefix(trimmed, key); found && strings.HasPrefix(strings.TrimSpace(rest), "=") {
+				line = i + 1
+			}
+		}
+	}
+	return line
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	t.Run("General first and round trips", func(t *testing.T) {
+		profiles := map[string]Profile{
+			"work":    {"git/.gitconfig-work": "~/.gitconfig"},
+			"general": {"vim/.vimrc": "~/.vimrc", `odd "name"`: `~/odd\path`},
+			"my.host": {"zsh/.zshrc": "~/.zshrc"},
+		}
+
+		var buf bytes.Buffer
+		if err := Encode(&buf, profiles); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		output := buf.String()
+
+		if !strings.HasPrefix(output, "[general]\n") {
+			t.Errorf("Expected [general] first, got: %s", output)
+		}
+		if !strings.Contains(output, `["my.host"]`) {
+			t.Errorf("Expected dotted profile name to be quoted, got: %s", output)
+		}
+
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, ".mappings"), buf.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to write .mappings: %v", err)
+		}
+		cfg, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected encoded output to parse, got: %v", err)
+		}
+		if cfg.Profiles["general"][`odd "name"`] != `~/odd\path` {
+			t.Errorf("Expected escaped entry to round trip, got %v", cfg.Profiles["general"])
+		}
+		if cfg.Profiles["my.host"]["zsh/.zshrc"] != "~/.zshrc" {
+			t.Errorf("Expected my.host profile to round trip, got %v", cfg.Profiles["my.host"])
+		}
+	})
+}
+
+func TestWriteConfig(t *testing.T) {
+	t.Run("Refuses to overwrite", func(t *testing.T) {
+		dir := createTempMappings(t, "[general]\n")
+		err := WriteConfig(dir, map[string]Profile{"general": {}})
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected already exists error, got: %v", err)
+		}
+	})
+}
+
+func TestAddMapping(t *testing.T) {
+	t.Run("Inserts into existing profile preserving comments", func(t *testing.T) {
+		content := `# my dotfiles
+[general]
+"vim/.vimrc" = "~/.vimrc" # editor
+
+[work]
+"git/.gitconfig-work" = "~/.gitconfig"
+`
+		dir := createTempMappings(t, content)
+
+		if err := AddMapping(dir, "general", "zsh/.zshrc", "~/.zshrc"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		data, _ := os.ReadFile(filepath.Join(dir, ".mappings"))
+		expected := `# my dotfiles
+[general]
+"vim/.vimrc" = "~/.vimrc" # editor
+"zsh/.zshrc" = "~/.zshrc"
+
+[work]
+"git/.gitconfig-work" = "~/.gitconfig"
+`
+		if string(data) != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+		}
+	})
+
+	t.Run("Appends missing profile", func(t *testing.T) {
+		dir := createTempMappings(t, "[general]\n\"vim/.vimrc\" = \"~/.vimrc\"\n")
+
+		if err := AddMapping(dir, "laptop", "zsh/.zshrc", "~/.zshrc"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		cfg, err := ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected valid config, got: %v", err)
+		}
+		if cfg.Profiles["laptop"]["zsh/.zshrc"] != "~/.zshrc" {
+			t.Errorf("Expected new profile entry, got %v", cfg.Profiles["laptop"])
+		}
+	})
+
+	t.Run("Rejects duplicate source", func(t *testing.T) {
+		dir := createTempMappings(t, "[general]\n\"vim/.vimrc\" = \"~/.vimrc\"\n")
+
+		if err := AddMapping(dir, "general", "vim/.vimrc", "~/.vimrc"); err == nil {
+			t.Error("Expected error for duplicate source")
+		}
+	})
+
+	t.Run("Writes sources relative to the source root", func(t *testing.T) {
+		dir := createTempMappings(t, "source_root = \"home\"\n\n[general]\n\"vim/.vimrc\" = \"~/.vimrc\"\n")
+
+		if err := AddMapping(dir, "general", "home/zsh/.zshrc", "~/.zshrc"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		data, _ := os.ReadFile(filepath.Join(dir, ".mappings"))
+		if !strings.Contains(string(data), "\n\"zsh/.zshrc\" = \"~/.zshrc\"\n") {
+			t.Errorf("Expected the source without the root, got:\n%s", data)
+		}
+
+		if err := AddMapping(dir, "general", "scripts/install.sh", "~/install.sh"); err == nil || !strings.Contains(err.Error(), "outside the source root") {
+			t.Errorf("Expected source root error, got %v", err)
+		}
+	})
+}
+
+func TestAddEntry(t *testing.T) {
+	dir := createTempMappings(t, "[general]\n\"vim/.vimrc\" = \"~/.vimrc\"\n")
+
+	entry := Entry{Target: "~/.config/alacritty.toml", Targets: map[string]string{"windows": "~/AppData/alacritty.toml", "darwin": "~/Library/alacritty.toml"}}
+	if err := AddEntry(dir, "general", "alacritty.toml", entry); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, ".mappings"))
+	expected := `"alacritty.toml" = { target = "~/.config/alacritty.toml", targets = { darwin = "~/Library/alacritty.toml", windows = "~/AppData/alacritty.toml" } }`
+	if !strings.Contains(string(data), expected+"\n") {
+		t.Errorf("Expected the inline table %s, got:\n%s", expected, data)
+	}
+	cfg, err := ParseConfig(dir)
+	if err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+	if cfg.Entries["general"]["alacritty.toml"].Targets["windows"] != "~/AppData/alacritty.toml" {
+		t.Errorf("Expected the windows target to be kept, got %+v", cfg.Entries["general"]["alacritty.toml"])
+	}
+}
+
+func TestMappingLine(t *testing.T) {
+	data := []byte(`source_root = "home"
+
+[general]
+"vim/.vimrc" = "~/.vimrc"
+'zsh/.zshrc'="~/.zshrc"
+
+[work]
+"vim/.vimrc" = "~/.vimrc-work"
+`)
+
+	tests := []struct {
+		profile, src string
+		expected     int
+	}{
+		{"general", "vim/.vimrc", 4},
+		{"general", "zsh/.zshrc", 5},
+		{"work", "vim/.vimrc", 8},
+		{"work", "zsh/.zshrc", 0},
+	}
+	for _, test := range tests {
+		if line := MappingLine(data, test.profile, test.src); line != test.expected {
+			t.Errorf("Expected [%s] %s on line %d, got %d", test.profile, test.src, test.expected, line)
+		}
+	}
+}
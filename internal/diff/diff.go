@@ -0,0 +1,406 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Kind is the type of an edit
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Edit is one element of an edit script: a line or word kept, removed, or added
+type Edit struct {
+	Kind Kind
+	Text string
+}
+
+// Options controls how Unified renders a diff
+type Options struct {
+	// Context is the number of unchanged lines shown around each change
+	Context int
+	// Color renders the diff with ANSI colors
+	Color bool
+	// Words highlights the changed words of modified lines; it only has an effect with Color
+	Words bool
+}
+
+// DefaultContext is the number of context lines used by diff and patch
+const DefaultContext = 3
+
+const (
+	bold      = "\033[1m"
+	reverse   = "\033[7m"
+	noReverse = "\033[27m"
+)
+
+// Compute returns the shortest edit script turning a into b, using Myers' algorithm
+func Compute(a, b []string) []Edit {
+	// Common prefix and suffix are cut off first, so the quadratic part only
+	// sees the region that actually changed
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Equal, line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Equal, line})
+	}
+
+	return edits
+}
+
+// myers finds the shortest edit script by exploring diagonals, keeping the furthest
+// reaching point of every diagonal per edit distance so the path can be traced back
+func myers(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	max := n + m
+	offset := max
+	v := make([]int, 2*max+2)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, offset)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backtrack walks the trace from the end of both sequences back to the start
+func backtrack(trace [][]int, a, b []string, offset int) []Edit {
+	x, y := len(a), len(b)
+	var reversed []Edit
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, Edit{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, Edit{Insert, b[y-1]})
+			} else {
+				reversed = append(reversed, Edit{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	edits := make([]Edit, len(reversed))
+	for i, edit := range reversed {
+		edits[len(reversed)-1-i] = edit
+	}
+	return edits
+}
+
+// hunk is a group of changes with their surrounding context
+type hunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+	edits              []Edit
+}
+
+// hunks groups an edit script into hunks; changes separated by at most twice the
+// context are merged into one hunk
+func hunks(edits []Edit, context int) []hunk {
+	// positions[i] holds the old and new line index before edit i
+	positions := make([][2]int, len(edits)+1)
+	oldLine, newLine := 0, 0
+	for i, edit := range edits {
+		positions[i] = [2]int{oldLine, newLine}
+		if edit.Kind != Insert {
+			oldLine++
+		}
+		if edit.Kind != Delete {
+			newLine++
+		}
+	}
+	positions[len(edits)] = [2]int{oldLine, newLine}
+
+	var result []hunk
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == Equal {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		end := i
+		for {
+			for end < len(edits) && edits[end].Kind != Equal {
+				end++
+			}
+			next := end
+			for next < len(edits) && edits[next].Kind == Equal {
+				next++
+			}
+			if next < len(edits) && next-end <= 2*context {
+				end = next
+				continue
+			}
+			end += context
+			if end > len(edits) {
+				end = len(edits)
+			}
+			break
+		}
+
+		result = append(result, hunk{
+			oldStart: positions[start][0],
+			oldLines: positions[end][0] - positions[start][0],
+			newStart: positions[start][1],
+			newLines: positions[end][1] - positions[start][1],
+			edits:    edits[start:end],
+		})
+		i = end
+	}
+
+	return result
+}
+
+// Unified writes a unified diff turning oldText into newText to w, labelled with oldName
+// and newName; nothing is written when the texts are equal
+func Unified(w io.Writer, oldName, newName, oldText, newText string, opts Options) error {
+	if oldText == newText {
+		return nil
+	}
+
+	if isBinary(oldText) || isBinary(newText) {
+		_, err := fmt.Fprintf(w, "Binary files %s and %s differ\n", oldName, newName)
+		return err
+	}
+
+	var buf bytes.Buffer
+	r := renderer{w: &buf, opts: opts}
+
+	r.line(bold, "--- "+oldName+"\n")
+	r.line(bold, "+++ "+newName+"\n")
+	for _, h := range hunks(Compute(splitLines(oldText), splitLines(newText)), opts.Context) {
+		r.line(utils.Cyan, fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldLines), hunkRange(h.newStart, h.newLines)))
+		r.hunk(h.edits)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// hunkRange formats a hunk's line range; an empty range refers to the line before it
+func hunkRange(start, lines int) string {
+	switch lines {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, lines)
+}
+
+// renderer writes the lines of a diff, coloring them when enabled
+type renderer struct {
+	w    io.Writer
+	opts Options
+}
+
+// line writes a line in the given color
+func (r renderer) line(color, text string) {
+	if r.opts.Color {
+		text = color + strings.TrimSuffix(text, "\n") + utils.Reset + "\n"
+	}
+	io.WriteString(r.w, text)
+}
+
+// hunk writes the edits of a hunk; each block of changes is written as its removed
+// lines followed by its added lines
+func (r renderer) hunk(edits []Edit) {
+	for i := 0; i < len(edits); {
+		if edits[i].Kind == Equal {
+			r.content(" ", "", edits[i].Text)
+			i++
+			continue
+		}
+
+		var deleted, inserted []string
+		for ; i < len(edits) && edits[i].Kind != Equal; i++ {
+			if edits[i].Kind == Delete {
+				deleted = append(deleted, edits[i].Text)
+			} else {
+				inserted = append(inserted, edits[i].Text)
+			}
+		}
+
+		// Lines are only compared word by word when each removed line has an added counterpart
+		if r.opts.Color && r.opts.Words && len(deleted) == len(inserted) {
+			for j := range deleted {
+				r.content("-", utils.Red, highlight(deleted[j], inserted[j], Delete))
+			}
+			for j := range inserted {
+				r.content("+", utils.Green, highlight(deleted[j], inserted[j], Insert))
+			}
+			continue
+		}
+
+		for _, line := range deleted {
+			r.content("-", utils.Red, line)
+		}
+		for _, line := range inserted {
+			r.content("+", utils.Green, line)
+		}
+	}
+}
+
+// content writes a line of the compared files with its prefix, marking a missing final newline
+func (r renderer) content(prefix, color, text string) {
+	missingNewline := !strings.HasSuffix(text, "\n")
+	text = prefix + strings.TrimSuffix(text, "\n")
+
+	if r.opts.Color && color != "" {
+		text = color + text + utils.Reset
+	}
+	io.WriteString(r.w, text+"\n")
+
+	if missingNewline {
+		io.WriteString(r.w, "\\ No newline at end of file\n")
+	}
+}
+
+// highlight returns the side of a modified line selected by kind, with the words that
+// differ from the other side shown in reverse video
+// Lines that share no words are returned unchanged, since highlighting all of them adds nothing
+func highlight(oldLine, newLine string, kind Kind) string {
+	side := newLine
+	if kind == Delete {
+		side = oldLine
+	}
+
+	edits := Compute(splitWords(strings.TrimSuffix(oldLine, "\n")), splitWords(strings.TrimSuffix(newLine, "\n")))
+	shared := false
+	for _, edit := range edits {
+		if edit.Kind == Equal && strings.TrimSpace(edit.Text) != "" {
+			shared = true
+			break
+		}
+	}
+	if !shared {
+		return side
+	}
+
+	var b strings.Builder
+	for _, edit := range edits {
+		switch edit.Kind {
+		case Equal:
+			b.WriteString(edit.Text)
+		case kind:
+			b.WriteString(reverse + edit.Text + noReverse)
+		}
+	}
+	if strings.HasSuffix(side, "\n") {
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// splitLines splits text into lines that keep their newline; a final line without
+// one is kept as is
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// splitWords splits a line into runs of word characters, runs of whitespace, and
+// single punctuation characters
+func splitWords(line string) []string {
+	var words []string
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); {
+		j := i + 1
+		switch {
+		case isWordRune(runes[i]):
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+		case unicode.IsSpace(runes[i]):
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+		}
+		words = append(words, string(runes[i:j]))
+		i = j
+	}
+
+	return words
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isBinary reports whether text looks like binary data, as git does by looking for a NUL byte
+func isBinary(text string) bool {
+	return strings.IndexByte(text, 0) >= 0
+}
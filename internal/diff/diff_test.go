@@ -0,0 +1,158 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	apply := func(edits []Edit) (string, string) {
+		var a, b strings.Builder
+		for _, edit := range edits {
+			if edit.Kind != Insert {
+				a.WriteString(edit.Text)
+			}
+			if edit.Kind != Delete {
+				b.WriteString(edit.Text)
+			}
+		}
+		return a.String(), b.String()
+	}
+
+	cases := []struct {
+		name    string
+		a, b    string
+		changes int
+	}{
+		{"Equal", "abc", "abc", 0},
+		{"Empty to text", "", "abc", 3},
+		{"Text to empty", "abc", "", 3},
+		{"Insertion in the middle", "abd", "abcd", 1},
+		{"Replacement", "abcabba", "cbabac", 5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			edits := Compute(strings.Split(tc.a, ""), strings.Split(tc.b, ""))
+			a, b := apply(edits)
+			if a != tc.a || b != tc.b {
+				t.Errorf("Expected edits to rebuild %q and %q, got %q and %q", tc.a, tc.b, a, b)
+			}
+
+			changes := 0
+			for _, edit := range edits {
+				if edit.Kind != Equal {
+					changes++
+				}
+			}
+			if changes != tc.changes {
+				t.Errorf("Expected %d changes, got %d", tc.changes, changes)
+			}
+		})
+	}
+}
+
+func TestUnified(t *testing.T) {
+	render := func(oldText, newText string, opts Options) string {
+		var buf bytes.Buffer
+		if err := Unified(&buf, "a/file", "b/file", oldText, newText, opts); err != nil {
+			t.Fatalf("Unified failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	t.Run("Equal texts produce no output", func(t *testing.T) {
+		if output := render("same\n", "same\n", Options{Context: DefaultContext}); output != "" {
+			t.Errorf("Expected no output, got: %q", output)
+		}
+	})
+
+	t.Run("Single change with context", func(t *testing.T) {
+		output := render("a\nb\nc\nd\ne\nf\ng\n", "a\nb\nc\nD\ne\nf\ng\n", Options{Context: 2})
+		expected := `--- a/file
++++ b/file
+@@ -2,5 +2,5 @@
+ b
+ c
+-d
++D
+ e
+ f
+`
+		if output != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+		}
+	})
+
+	t.Run("Distant changes produce separate hunks", func(t *testing.T) {
+		oldText := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+		newText := "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n"
+		output := render(oldText, newText, Options{Context: 1})
+		if count := strings.Count(output, "@@ -"); count != 2 {
+			t.Errorf("Expected 2 hunks, got %d:\n%s", count, output)
+		}
+
+		output = render(oldText, newText, Options{Context: 4})
+		if count := strings.Count(output, "@@ -"); count != 1 {
+			t.Errorf("Expected 1 merged hunk, got %d:\n%s", count, output)
+		}
+	})
+
+	t.Run("New file", func(t *testing.T) {
+		output := render("", "a\nb\n", Options{Context: DefaultContext})
+		if !strings.Contains(output, "@@ -0,0 +1,2 @@\n+a\n+b\n") {
+			t.Errorf("Expected hunk adding both lines, got:\n%s", output)
+		}
+	})
+
+	t.Run("Missing newline at end of file", func(t *testing.T) {
+		output := render("a\nb\n", "a\nb", Options{Context: DefaultContext})
+		expected := "-b\n+b\n\\ No newline at end of file\n"
+		if !strings.HasSuffix(output, expected) {
+			t.Errorf("Expected output to end with %q, got:\n%s", expected, output)
+		}
+	})
+
+	t.Run("Binary files", func(t *testing.T) {
+		output := render("a\x00b", "a\x00c", Options{Context: DefaultContext})
+		if output != "Binary files a/file and b/file differ\n" {
+			t.Errorf("Expected binary notice, got: %q", output)
+		}
+	})
+
+	t.Run("Color", func(t *testing.T) {
+		output := render("a\n", "b\n", Options{Context: DefaultContext, Color: true})
+		if !strings.Contains(output, "\033[31m-a\033[0m\n") {
+			t.Errorf("Expected red removed line, got: %q", output)
+		}
+		if !strings.Contains(output, "\033[32m+b\033[0m\n") {
+			t.Errorf("Expected green added line, got: %q", output)
+		}
+	})
+
+	t.Run("Word highlighting", func(t *testing.T) {
+		output := render("color = blue\n", "color = green\n", Options{Context: DefaultContext, Color: true, Words: true})
+		if !strings.Contains(output, "-color = "+reverse+"blue"+noReverse) {
+			t.Errorf("Expected changed word highlighted on removed line, got: %q", output)
+		}
+		if !strings.Contains(output, "+color = "+reverse+"green"+noReverse) {
+			t.Errorf("Expected changed word highlighted on added line, got: %q", output)
+		}
+	})
+
+	t.Run("Word highlighting skips unrelated lines", func(t *testing.T) {
+		output := render("alpha\n", "beta\n", Options{Context: DefaultContext, Color: true, Words: true})
+		if strings.Contains(output, reverse) {
+			t.Errorf("Expected no highlighting for lines sharing no words, got: %q", output)
+		}
+	})
+}
+
+func TestSplitWords(t *testing.T) {
+	words := splitWords("set  foo_bar=1;")
+	expected := []string{"set", "  ", "foo_bar", "=", "1", ";"}
+	if strings.Join(words, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v, got %v", expected, words)
+	}
+}
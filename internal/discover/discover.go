@@ -0,0 +1,163 @@
+package discover
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/linker"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Candidate is a well-known dotfile and where it is stored in the repository when adopted
+type Candidate struct {
+	Path   string // relative to the home directory
+	Source string // relative to the dotfiles directory
+}
+
+// Candidates lists well-known configuration files and directories
+var Candidates = []Candidate{
+	{".bash_profile", "bash/.bash_profile"},
+	{".bashrc", "bash/.bashrc"},
+	{".profile", "sh/.profile"},
+	{".inputrc", "readline/.inputrc"},
+	{".zshenv", "zsh/.zshenv"},
+	{".zprofile", "zsh/.zprofile"},
+	{".zshrc", "zsh/.zshrc"},
+	{".config/fish", "fish"},
+	{".config/starship.toml", "starship/starship.toml"},
+	{".vimrc", "vim/.vimrc"},
+	{".vim", "vim/.vim"},
+	{".config/nvim", "nvim"},
+	{".config/helix", "helix"},
+	{".emacs.d", "emacs/.emacs.d"},
+	{".editorconfig", "editorconfig/.editorconfig"},
+	{".gitconfig", "git/.gitconfig"},
+	{".gitignore_global", "git/.gitignore_global"},
+	{".config/git", "git/config"},
+	{".tmux.conf", "tmux/.tmux.conf"},
+	{".config/tmux", "tmux/config"},
+	{".config/alacritty", "alacritty"},
+	{".config/kitty", "kitty"},
+	{".wezterm.lua", "wezterm/.wezterm.lua"},
+	{".ssh/config", "ssh/config"},
+	{".curlrc", "curl/.curlrc"},
+	{".npmrc", "npm/.npmrc"},
+	{".psqlrc", "psql/.psqlrc"},
+}
+
+// Unmanaged returns the candidates present in the home directory that are not yet
+// managed by any profile in the configuration
+func Unmanaged(homeDir, dotfilesDir string, cfg *config.Config) []Candidate {
+	managed := make(map[string]bool)
+	for _, profile := range cfg.Profiles {
+		for _, target := range profile {
+			managed[filepath.Clean(utils.ExpandPath(target))] = true
+		}
+	}
+
+	var unmanaged []Candidate
+	for _, candidate := range Candidates {
+		path := filepath.Join(homeDir, filepath.FromSlash(candidate.Path))
+
+		if _, err := os.Lstat(path); err != nil {
+			continue
+		}
+		if isManaged(path, dotfilesDir, managed) {
+			continue
+		}
+
+		unmanaged = append(unmanaged, candidate)
+	}
+
+	return unmanaged
+}
+
+// isManaged reports whether path, or one of its parents, is a mapping target
+// or a symlink into the dotfiles directory
+func isManaged(path, dotfilesDir string, managed map[string]bool) bool {
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if managed[dir] {
+			return true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	if linkTarget, err := utils.ReadSymlink(path); err == nil {
+		return isWithin(linkTarget, dotfilesDir)
+	}
+
+	return isWithin(path, dotfilesDir)
+}
+
+// isWithin reports whether path is dir or located below it
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Run scans the home directory for unmanaged dotfiles and offers to adopt each one
+// into the given profile, reading answers from in
+// When yes is set every candidate is adopted without prompting
+func Run(in io.Reader, profile string, yes bool) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfig(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	candidates := Unmanaged(homeDir, dotfilesDir, cfg)
+	if len(candidates) == 0 {
+		fmt.Fprintln(os.Stderr, "No unmanaged dotfiles found")
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Found %d unmanaged dotfile(s)\n", len(candidates))
+
+	reader := bufio.NewReader(in)
+	adopted := 0
+
+	for _, candidate := range candidates {
+		candidate.Source = cfg.RepoSource(profile, candidate.Source)
+		if !yes {
+			fmt.Fprintf(os.Stderr, "Adopt ~/%s as %s into [%s]? [y/N/q] ", candidate.Path, candidate.Source, profile)
+			answer, err := reader.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+
+			if answer == "q" {
+				break
+			}
+			if answer != "y" && answer != "yes" {
+				if err != nil {
+					break
+				}
+				continue
+			}
+		}
+
+		if err := linker.Adopt(filepath.Join(homeDir, candidate.Path), candidate.Source, profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error adopting ~/%s: %v\n", candidate.Path, err)
+			continue
+		}
+		adopted++
+	}
+
+	fmt.Fprintf(os.Stderr, "Adopted %d dotfile(s)\n", adopted)
+	return nil
+}
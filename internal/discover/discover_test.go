@@ -0,0 +1,116 @@
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func setupDiscoverEnvironment(t *testing.T) (string, string) {
+	tempDir := t.TempDir()
+	homeDir := filepath.Join(tempDir, "home")
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+
+	originalHome := os.Getenv("HOME")
+	originalDotDir := os.Getenv("DOT_DIR")
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+		if originalDotDir != "" {
+			os.Setenv("DOT_DIR", originalDotDir)
+		} else {
+			os.Unsetenv("DOT_DIR")
+		}
+	})
+	os.Setenv("HOME", homeDir)
+	os.Setenv("DOT_DIR", dotfilesDir)
+
+	for _, dir := range []string{dotfilesDir, filepath.Join(homeDir, ".config", "nvim")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	for _, file := range []string{".zshrc", ".tmux.conf", ".config/nvim/init.lua"} {
+		if err := os.WriteFile(filepath.Join(homeDir, file), []byte("config"), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+	}
+
+	mappings := "[general]\n\"tmux/.tmux.conf\" = \"~/.tmux.conf\"\n"
+	if err := os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644); err != nil {
+		t.Fatalf("Failed to create .mappings: %v", err)
+	}
+
+	return homeDir, dotfilesDir
+}
+
+func TestUnmanaged(t *testing.T) {
+	t.Run("Skips managed and missing candidates", func(t *testing.T) {
+		homeDir, dotfilesDir := setupDiscoverEnvironment(t)
+
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Failed to parse config: %v", err)
+		}
+
+		var paths []string
+		for _, candidate := range Unmanaged(homeDir, dotfilesDir, cfg) {
+			paths = append(paths, candidate.Path)
+		}
+
+		if strings.Join(paths, ",") != ".zshrc,.config/nvim" {
+			t.Errorf("Expected .zshrc and .config/nvim, got %v", paths)
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	t.Run("Adopts accepted candidates only", func(t *testing.T) {
+		homeDir, dotfilesDir := setupDiscoverEnvironment(t)
+
+		// Accept .zshrc, decline .config/nvim
+		if err := Run(strings.NewReader("y\nn\n"), "general", false); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(dotfilesDir, "zsh", ".zshrc")); err != nil {
+			t.Errorf("Expected .zshrc to be moved into the repository: %v", err)
+		}
+		linkTarget, err := os.Readlink(filepath.Join(homeDir, ".zshrc"))
+		if err != nil || linkTarget != filepath.Join(dotfilesDir, "zsh", ".zshrc") {
+			t.Errorf("Expected ~/.zshrc to link into the repository, got %s (%v)", linkTarget, err)
+		}
+		if _, err := os.Readlink(filepath.Join(homeDir, ".config", "nvim")); err == nil {
+			t.Error("Expected declined candidate to be left alone")
+		}
+
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Failed to parse config: %v", err)
+		}
+		if cfg.Profiles["general"]["zsh/.zshrc"] != "~/.zshrc" {
+			t.Errorf("Expected zsh/.zshrc mapping to be added, got %v", cfg.Profiles["general"])
+		}
+		if cfg.Profiles["general"]["tmux/.tmux.conf"] != "~/.tmux.conf" {
+			t.Errorf("Expected existing mapping to be preserved, got %v", cfg.Profiles["general"])
+		}
+	})
+
+	t.Run("Adopts everything with yes", func(t *testing.T) {
+		_, dotfilesDir := setupDiscoverEnvironment(t)
+
+		if err := Run(strings.NewReader(""), "laptop", true); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Failed to parse config: %v", err)
+		}
+		if len(cfg.Profiles["laptop"]) != 2 {
+			t.Errorf("Expected 2 mappings in [laptop], got %v", cfg.Profiles["laptop"])
+		}
+	})
+}
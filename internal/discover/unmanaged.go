@@ -0,0 +1,206 @@
+package discover
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/term"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// IgnoreFile lists extra top-level names, one glob pattern per line, that list --unmanaged
+// should not report; it lives in the dotfiles directory
+const IgnoreFile = ".unmanagedignore"
+
+// DefaultIgnore are top-level entries of the home directory that hold caches, history,
+// or machine state rather than configuration
+var DefaultIgnore = []string{
+	".CFUserTextEncoding",
+	".DS_Store",
+	".Trash",
+	".Xauthority",
+	".ICEauthority",
+	".bash_history",
+	".bash_sessions",
+	".cache",
+	".cargo",
+	".dbus",
+	".docker",
+	".gradle",
+	".lesshst",
+	".local",
+	".m2",
+	".node_repl_history",
+	".npm",
+	".pki",
+	".python_history",
+	".rustup",
+	".sudo_as_admin_successful",
+	".viminfo",
+	".vscode-server",
+	".wget-hsts",
+	".zcompdump*",
+	".zsh_history",
+	".zsh_sessions",
+	"*.swp",
+}
+
+// Entry is a top-level dotfile or directory in the home directory that is not managed
+type Entry struct {
+	Name      string
+	Dir       bool
+	Partially bool // a directory containing some managed paths
+}
+
+// LoadIgnore returns the default ignore patterns plus those in the dotfiles directory's IgnoreFile
+func LoadIgnore(dotfilesDir string) ([]string, error) {
+	patterns := append([]string{}, DefaultIgnore...)
+
+	file, err := os.Open(filepath.Join(dotfilesDir, IgnoreFile))
+	if os.IsNotExist(err) {
+		return patterns, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", IgnoreFile, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.TrimSuffix(line, "/"))
+	}
+
+	return patterns, scanner.Err()
+}
+
+// UnmanagedEntries returns the top-level dotfiles and directories of homeDir that are
+// neither managed by any profile nor matched by an ignore pattern, sorted by name
+func UnmanagedEntries(homeDir, dotfilesDir string, cfg *config.Config, ignore []string) ([]Entry, error) {
+	dirEntries, err := os.ReadDir(homeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read home directory: %w", err)
+	}
+
+	managed := make(map[string]bool)
+	for _, profile := range cfg.Profiles {
+		for _, target := range profile {
+			managed[filepath.Clean(utils.ExpandPath(target))] = true
+		}
+	}
+
+	var entries []Entry
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if !strings.HasPrefix(name, ".") || isIgnored(name, ignore) {
+			continue
+		}
+
+		path := filepath.Join(homeDir, name)
+		if isManaged(path, dotfilesDir, managed) {
+			continue
+		}
+
+		entry := Entry{Name: name, Dir: dirEntry.IsDir()}
+		if entry.Dir {
+			entry.Partially = containsManaged(path, managed)
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// isIgnored reports whether name matches any of the ignore patterns
+func isIgnored(name string, ignore []string) bool {
+	for _, pattern := range ignore {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// containsManaged reports whether any managed target lies below dir
+func containsManaged(dir string, managed map[string]bool) bool {
+	for target := range managed {
+		if target != dir && isWithin(target, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListUnmanaged prints the unmanaged top-level dotfiles of the home directory
+// Porcelain prints one tab-separated line per entry, "unmanaged" or "partially-managed"
+// followed by its absolute path, in a format that stays stable for scripts
+func ListUnmanaged(porcelain bool) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfig(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	ignore, err := LoadIgnore(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	entries, err := UnmanagedEntries(homeDir, dotfilesDir, cfg, ignore)
+	if err != nil {
+		return err
+	}
+
+	if porcelain {
+		for _, entry := range entries {
+			state := "unmanaged"
+			if entry.Partially {
+				state = "partially-managed"
+			}
+			fmt.Printf("%s\t%s\n", state, filepath.Join(homeDir, entry.Name))
+		}
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Unmanaged dotfiles in ~:")
+	fmt.Fprintln(os.Stderr)
+
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No unmanaged dotfiles found.")
+		return nil
+	}
+
+	for _, entry := range entries {
+		name := "~/" + entry.Name
+		if entry.Dir {
+			name += "/"
+		}
+		if entry.Partially {
+			fmt.Printf("%s %s (partially managed)\n", term.Added, name)
+		} else {
+			fmt.Printf("%s %s\n", term.Added, name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package discover
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestUnmanagedEntries(t *testing.T) {
+	t.Run("Lists unmanaged top-level dotfiles", func(t *testing.T) {
+		homeDir, dotfilesDir := setupDiscoverEnvironment(t)
+		os.MkdirAll(filepath.Join(homeDir, ".cache"), 0755)
+		os.MkdirAll(filepath.Join(homeDir, "Documents"), 0755)
+		os.WriteFile(filepath.Join(homeDir, ".zcompdump-box"), nil, 0644)
+		os.WriteFile(filepath.Join(homeDir, ".secret-tool"), nil, 0644)
+		os.Symlink(dotfilesDir, filepath.Join(homeDir, ".dotfiles"))
+
+		os.WriteFile(filepath.Join(dotfilesDir, IgnoreFile), []byte("# local noise\n.secret-*\n"), 0644)
+		mappings := "[general]\n\"tmux/.tmux.conf\" = \"~/.tmux.conf\"\n\"nvim\" = \"~/.config/nvim\"\n"
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Failed to parse config: %v", err)
+		}
+		ignore, err := LoadIgnore(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Failed to load ignore patterns: %v", err)
+		}
+
+		entries, err := UnmanagedEntries(homeDir, dotfilesDir, cfg, ignore)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+		if strings.Join(names, ",") != ".config,.zshrc" {
+			t.Fatalf("Expected .config and .zshrc, got %v", names)
+		}
+		if !entries[0].Dir || !entries[0].Partially {
+			t.Errorf("Expected .config to be a partially managed directory, got %+v", entries[0])
+		}
+		if entries[1].Dir || entries[1].Partially {
+			t.Errorf("Expected .zshrc to be an unmanaged file, got %+v", entries[1])
+		}
+	})
+
+	t.Run("Missing ignore file uses defaults", func(t *testing.T) {
+		ignore, err := LoadIgnore(t.TempDir())
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(ignore) != len(DefaultIgnore) {
+			t.Errorf("Expected %d default patterns, got %d", len(DefaultIgnore), len(ignore))
+		}
+	})
+}
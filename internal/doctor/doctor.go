@@ -0,0 +1,221 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/term"
+)
+
+// Status is the outcome of a check
+type Status int
+
+const (
+	Pass Status = iota
+	Warn        // dot works, but not as well as it could
+	Fail        // dot cannot do part of its job
+)
+
+// Result is the outcome of a check, with a hint on how to fix it unless it passed
+type Result struct {
+	Name   string
+	Status Status
+	Detail string
+	Hint   string
+}
+
+// check probes one capability dot relies on; it may return several results, e.g. one per remote
+type check func() []Result
+
+// checks are run in order by Run
+var checks = []check{
+	checkSymlinks,
+	checkHome,
+	checkDotfilesDir,
+	checkGit,
+	checkMappings,
+	checkTargets,
+	checkSources,
+	checkRemotes,
+	checkEditor,
+	checkLocale,
+}
+
+// Run runs every check, printing one line per result followed by its hint, and fails
+// if any check failed
+func Run(w io.Writer) error {
+	failed := 0
+	for _, check := range checks {
+		for _, result := range check() {
+			printResult(w, result)
+			if result.Status == Fail {
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// printResult prints a result behind its status marker
+func printResult(w io.Writer, r Result) {
+	symbol := term.OK
+	switch r.Status {
+	case Warn:
+		symbol = term.Warning
+	case Fail:
+		symbol = term.Error
+	}
+
+	fmt.Fprintf(w, "%s %s: %s\n", symbol, r.Name, r.Detail)
+	if r.Status != Pass && r.Hint != "" {
+		fmt.Fprintf(w, "   hint: %s\n", r.Hint)
+	}
+}
+
+// checkSymlinks creates and removes a symlink in the home directory, where dot creates them
+func checkSymlinks() []Result {
+	name := "Symlinks"
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return []Result{{name, Fail, err.Error(), "set $HOME to your home directory"}}
+	}
+	return []Result{probeSymlink(name, homeDir)}
+}
+
+// probeSymlink reports whether symlinks can be created in dir
+func probeSymlink(name, dir string) Result {
+	link := filepath.Join(dir, fmt.Sprintf(".dot-doctor-%d", os.Getpid()))
+	err := os.Symlink(filepath.Join(dir, ".dot-doctor-target"), link)
+	if err == nil {
+		os.Remove(link)
+		return Result{name, Pass, "can be created in " + dir, ""}
+	}
+
+	if errors.Is(err, os.ErrPermission) || errors.Is(err, errors.ErrUnsupported) || runtime.GOOS == "windows" {
+		hint := "dot copies files instead of linking them, so edits in the repository need another dot link"
+		if runtime.GOOS == "windows" {
+			hint = "enable Developer Mode in Settings > For developers to allow symlinks; until then " + hint
+		}
+		return Result{name, Warn, fmt.Sprintf("cannot be created in %s: %v", dir, err), hint}
+	}
+	return Result{name, Fail, fmt.Sprintf("cannot be created in %s: %v", dir, err), "check that " + dir + " exists and is writable"}
+}
+
+// checkDotfilesDir checks that the dotfiles repository exists and can be written to
+func checkDotfilesDir() []Result {
+	name := "Dotfiles directory"
+	dir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return []Result{{name, Fail, err.Error(), "set $DOT_DIR to your dotfiles repository"}}
+	}
+	return []Result{probeWritable(name, dir)}
+}
+
+// probeWritable reports whether a file can be created in dir
+func probeWritable(name, dir string) Result {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return Result{name, Fail, dir + " does not exist", "run dot clone <repository-url>, or set $DOT_DIR to your dotfiles repository"}
+	}
+
+	if err := writable(dir); err != nil {
+		return Result{name, Fail, fmt.Sprintf("%s is not writable: %v", dir, err), "fix the permissions of " + dir + ", dot writes adopted files and .mappings there"}
+	}
+	return Result{name, Pass, dir + " is writable", ""}
+}
+
+// checkHome checks that files can be created in the home directory, where dot links
+// targets and backs up what was there
+func checkHome() []Result {
+	name := "Home directory"
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return []Result{{name, Fail, err.Error(), "set $HOME to your home directory"}}
+	}
+	if err := writable(homeDir); err != nil {
+		return []Result{{name, Fail, fmt.Sprintf("%s is not writable: %v", homeDir, err), "fix the permissions of " + homeDir + ", or link into another directory with --home"}}
+	}
+	return []Result{{name, Pass, homeDir + " is writable", ""}}
+}
+
+// writable creates and removes a file in dir
+func writable(dir string) error {
+	file, err := os.CreateTemp(dir, ".dot-doctor-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// checkGit checks that git is installed
+func checkGit() []Result {
+	name := "Git"
+	out, err := exec.Command("git", "--version").Output()
+	if err != nil {
+		return []Result{{name, Fail, "git was not found in $PATH", "install git, dot uses it to clone, update, and push your dotfiles"}}
+	}
+	return []Result{{name, Pass, strings.TrimPrefix(strings.TrimSpace(string(out)), "git version "), ""}}
+}
+
+// checkRemotes checks that every remote of the dotfiles repository can be read with the
+// credentials git has
+func checkRemotes() []Result {
+	name := "Remote"
+	remotes, err := dotfiles.ListRemotes()
+	if err != nil {
+		return []Result{{name, Warn, err.Error(), "remotes can be checked once the dotfiles repository exists"}}
+	}
+	if len(remotes) == 0 {
+		return []Result{{name, Warn, "no remote configured", "add one with dot remote add <name> <url> to back up your dotfiles"}}
+	}
+
+	var results []Result
+	for _, remote := range remotes {
+		name := "Remote " + remote.Name
+		if err := dotfiles.ProbeRemote(remote.URL); err != nil {
+			results = append(results, Result{name, Fail, fmt.Sprintf("%s cannot be reached: %v", remote.URL, err),
+				"check the URL and your credentials: an SSH key loaded in ssh-agent or set with --ssh-key or ssh_key in [network], or a git credential helper for HTTPS"})
+			continue
+		}
+		results = append(results, Result{name, Pass, remote.URL + " is reachable", ""})
+	}
+	return results
+}
+
+// checkEditor checks that $VISUAL or $EDITOR names an installed editor
+func checkEditor() []Result {
+	name := "Editor"
+	variable, editor := "VISUAL", os.Getenv("VISUAL")
+	if editor == "" {
+		variable, editor = "EDITOR", os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		return []Result{{name, Warn, "$EDITOR is not set", "set it in your shell profile, e.g. export EDITOR=vim"}}
+	}
+
+	command := strings.Fields(editor)[0]
+	if _, err := exec.LookPath(command); err != nil {
+		return []Result{{name, Warn, fmt.Sprintf("$%s is %q, which was not found", variable, editor), "install it or point $" + variable + " to an installed editor"}}
+	}
+	return []Result{{name, Pass, fmt.Sprintf("$%s is %s", variable, editor), ""}}
+}
+
+// checkLocale checks that the locale uses UTF-8, which dot needs to show emoji markers
+func checkLocale() []Result {
+	name := "Locale"
+	if !term.UTF8Locale() {
+		return []Result{{name, Warn, "not UTF-8, so dot prints plain text markers instead of emoji", "set LANG to a UTF-8 locale, e.g. export LANG=en_US.UTF-8"}}
+	}
+	return []Result{{name, Pass, "UTF-8, emoji markers are shown", ""}}
+}
@@ -0,0 +1,88 @@
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/term"
+)
+
+func TestRun(t *testing.T) {
+	originalChecks := checks
+	defer func() {
+		checks = originalChecks
+		term.ASCII = false
+	}()
+	term.ASCII = true
+
+	checks = []check{
+		func() []Result { return []Result{{"Git", Pass, "2.45.0", "install git"}} },
+		func() []Result { return []Result{{"Editor", Warn, "$EDITOR is not set", "export EDITOR=vim"}} },
+		func() []Result {
+			return []Result{{"Remote origin", Fail, "unreachable", "check credentials"}, {"Remote mirror", Fail, "unreachable", ""}}
+		},
+	}
+
+	var buf bytes.Buffer
+	err := Run(&buf)
+
+	expected := "OK Git: 2.45.0\n" +
+		"WARN Editor: $EDITOR is not set\n   hint: export EDITOR=vim\n" +
+		"ERR Remote origin: unreachable\n   hint: check credentials\n" +
+		"ERR Remote mirror: unreachable\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+	if err == nil || err.Error() != "2 check(s) failed" {
+		t.Errorf("Expected 2 failed checks, got %v", err)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	t.Run("Symlinks in a writable directory", func(t *testing.T) {
+		dir := t.TempDir()
+		result := probeSymlink("Symlinks", dir)
+		if runtime.GOOS != "windows" && result.Status != Pass {
+			t.Errorf("Expected symlinks to be supported, got %+v", result)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("Expected the probe to clean up, found %v", entries)
+		}
+	})
+
+	t.Run("Writable dotfiles directory", func(t *testing.T) {
+		dir := t.TempDir()
+		if result := probeWritable("Dotfiles directory", dir); result.Status != Pass {
+			t.Errorf("Expected pass, got %+v", result)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("Expected the probe to clean up, found %v", entries)
+		}
+	})
+
+	t.Run("Missing dotfiles directory", func(t *testing.T) {
+		result := probeWritable("Dotfiles directory", filepath.Join(t.TempDir(), "missing"))
+		if result.Status != Fail || !strings.Contains(result.Hint, "dot clone") {
+			t.Errorf("Expected failure with a clone hint, got %+v", result)
+		}
+	})
+
+	t.Run("Editor that is not installed", func(t *testing.T) {
+		originalVisual, originalEditor := os.Getenv("VISUAL"), os.Getenv("EDITOR")
+		defer func() {
+			os.Setenv("VISUAL", originalVisual)
+			os.Setenv("EDITOR", originalEditor)
+		}()
+		os.Setenv("VISUAL", "")
+		os.Setenv("EDITOR", "no-such-editor --wait")
+
+		results := checkEditor()
+		if results[0].Status != Warn || !strings.Contains(results[0].Detail, "no-such-editor") {
+			t.Errorf("Expected a warning about the editor, got %+v", results[0])
+		}
+	})
+}
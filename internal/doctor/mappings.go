@@ -0,0 +1,121 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/linker"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// maxListed is how many sources or targets a result names before summarizing the rest
+const maxListed = 3
+
+// loadConfig parses the .mappings file of the dotfiles repository
+func loadConfig() (*config.Config, string, error) {
+	dir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, err := config.ParseConfig(dir)
+	return cfg, dir, err
+}
+
+// checkMappings checks that .mappings parses, which includes that every profile its
+// [priorities], [source_roots], and hooks refer to is defined
+func checkMappings() []Result {
+	name := ".mappings"
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return []Result{{name, Fail, err.Error(), "fix the error in .mappings; dot lint checks the rest of the repository"}}
+	}
+
+	mappings := 0
+	for _, profile := range cfg.Profiles {
+		mappings += len(profile)
+	}
+	return []Result{{name, Pass, fmt.Sprintf("%d profile(s) with %d mapping(s), every profile referred to is defined", len(cfg.Profiles), mappings), ""}}
+}
+
+// checkTargets checks that no profile maps two sources to the same target, of which
+// only one can be linked
+// Profiles that map the same target override each other on purpose and are left alone
+func checkTargets() []Result {
+	name := "Targets"
+	cfg, _, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+
+	var collisions []string
+	for _, profile := range sortedKeys(cfg.Profiles) {
+		sourcesOf := make(map[string][]string)
+		for source, target := range cfg.Profiles[profile] {
+			target = utils.ExpandTarget(target)
+			sourcesOf[target] = append(sourcesOf[target], source)
+		}
+		for _, target := range sortedKeys(sourcesOf) {
+			if sources := sourcesOf[target]; len(sources) > 1 {
+				sort.Strings(sources)
+				collisions = append(collisions, fmt.Sprintf("[%s] maps %s to %s", profile, strings.Join(sources, " and "), utils.ContractTarget(target)))
+			}
+		}
+	}
+	if len(collisions) > 0 {
+		return []Result{{name, Fail, summarize(collisions), "keep one mapping per target in each profile, or move the others to a profile of their own"}}
+	}
+	return []Result{{name, Pass, "no profile maps two sources to the same target", ""}}
+}
+
+// checkSources checks that the source of every mapping exists, as a file, a directory,
+// or an alternate for this machine; sources marked ignore_missing are expected to be absent
+func checkSources() []Result {
+	name := "Sources"
+	cfg, dir, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var missing []string
+	for _, profile := range sortedKeys(cfg.Profiles) {
+		for _, source := range sortedKeys(cfg.Profiles[profile]) {
+			if seen[source] || cfg.Entries[profile][source].IgnoreMissing {
+				continue
+			}
+			seen[source] = true
+			path := filepath.Join(dir, filepath.FromSlash(strings.TrimSuffix(source, "/**")))
+			if _, err := os.Stat(linker.ResolveAlternate(path)); err != nil {
+				missing = append(missing, source)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return []Result{{name, Warn, fmt.Sprintf("%d missing: %s", len(missing), summarize(missing)),
+			"add them to the repository, set ignore_missing = true on entries only some machines have, or remove their mappings"}}
+	}
+	return []Result{{name, Pass, fmt.Sprintf("all %d exist", len(seen)), ""}}
+}
+
+// summarize joins the first few items and counts the rest
+func summarize(items []string) string {
+	if len(items) <= maxListed {
+		return strings.Join(items, ", ")
+	}
+	return fmt.Sprintf("%s, and %d more", strings.Join(items[:maxListed], ", "), len(items)-maxListed)
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,72 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMappingChecks(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	defer func() {
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+	}()
+	os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	// setup writes .mappings and the given sources to a new dotfiles directory
+	setup := func(t *testing.T, mappings string, sources ...string) {
+		dir := t.TempDir()
+		os.Setenv("DOT_DIR", dir)
+		os.WriteFile(filepath.Join(dir, ".mappings"), []byte(mappings), 0644)
+		for _, source := range sources {
+			os.MkdirAll(filepath.Dir(filepath.Join(dir, source)), 0755)
+			os.WriteFile(filepath.Join(dir, source), []byte("x\n"), 0644)
+		}
+	}
+
+	t.Run("A healthy repository passes", func(t *testing.T) {
+		setup(t, "[general]\n\"zshrc\" = \"~/.zshrc\"\n\"gitconfig\" = \"~/.gitconfig\"\n\"nvim/**\" = \"~/.config/nvim\"\n"+
+			"\"work.zsh\" = { target = \"~/.work.zsh\", ignore_missing = true }\n",
+			"zshrc", "gitconfig##default", "nvim/init.lua")
+
+		for _, results := range [][]Result{checkMappings(), checkTargets(), checkSources()} {
+			if len(results) != 1 || results[0].Status != Pass {
+				t.Errorf("Expected the check to pass, got %+v", results)
+			}
+		}
+	})
+
+	t.Run("A parse error fails only the first check", func(t *testing.T) {
+		setup(t, "[general]\n\"zshrc\" = \"~/.zshrc\"\n\n[priorities]\nwork = 10\n", "zshrc")
+
+		results := checkMappings()
+		if len(results) != 1 || results[0].Status != Fail || !strings.Contains(results[0].Detail, "[work]") {
+			t.Errorf("Expected the undefined profile to fail the check, got %+v", results)
+		}
+		if results := append(checkTargets(), checkSources()...); len(results) != 0 {
+			t.Errorf("Expected the other checks to be left out, got %+v", results)
+		}
+	})
+
+	t.Run("Two sources of a profile mapped to one target fail", func(t *testing.T) {
+		setup(t, "[general]\n\"zshrc\" = \"~/.zshrc\"\n\"zsh/zshrc\" = \"~/.zshrc\"\n\n[work]\n\"work/zshrc\" = \"~/.zshrc\"\n",
+			"zshrc", "zsh/zshrc", "work/zshrc")
+
+		results := checkTargets()
+		if len(results) != 1 || results[0].Status != Fail || results[0].Detail != "[general] maps zsh/zshrc and zshrc to ~/.zshrc" {
+			t.Errorf("Expected the collision in [general] only, got %+v", results)
+		}
+	})
+
+	t.Run("Missing sources warn", func(t *testing.T) {
+		setup(t, "[general]\n\"a\" = \"~/.a\"\n\"b\" = \"~/.b\"\n\"c\" = \"~/.c\"\n\"d\" = \"~/.d\"\n\"e\" = \"~/.e\"\n", "a")
+
+		results := checkSources()
+		if len(results) != 1 || results[0].Status != Warn || results[0].Detail != "4 missing: b, c, d, and 1 more" {
+			t.Errorf("Expected a warning about the missing sources, got %+v", results)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package dotfiles
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/settings"
+)
+
+// checkRemote fails when url is outside the allowed_remotes of the global config
+func checkRemote(url string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	return remoteAllowed(cfg, url)
+}
+
+// remoteAllowed fails when url is outside the allowed_remotes of cfg
+func remoteAllowed(cfg *settings.Settings, url string) error {
+	if !cfg.RemoteAllowed(url) {
+		return fmt.Errorf("remote URL %s is not allowed, allowed_remotes in the global config permits only %s", url, strings.Join(cfg.AllowedRemotes, ", "))
+	}
+	return nil
+}
+
+// checkUpstream fails when the remote that the current branch of the repository in dir
+// pulls from is outside the allowed_remotes of the global config
+// A branch without an upstream passes, since git has nothing to pull then
+func checkUpstream(dir string) error {
+	cfg, err := settings.Load()
+	if err != nil || len(cfg.AllowedRemotes) == 0 {
+		return err
+	}
+
+	branch, err := gitOutput(dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil
+	}
+	remote, err := gitOutput(dir, "config", "branch."+branch+".remote")
+	if err != nil {
+		return nil
+	}
+	url, err := gitOutput(dir, "remote", "get-url", remote)
+	if err != nil {
+		return nil
+	}
+	return remoteAllowed(cfg, url)
+}
+
+// updateSubmodules checks out the submodules of the repository in dir at the commits it
+// records, and theirs in turn, once allowed_remotes permits the URL of every one
+// Submodules contribute mappings and hooks, so one outside the allowlist fails the
+// update before anything is fetched from it
+func updateSubmodules(dir string) error {
+	if _, err := FS.Stat(filepath.Join(dir, ".gitmodules")); err != nil {
+		return nil
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+
+	// init copies the URLs of .gitmodules into the repository's config, resolving relative
+	// ones against its remote, so the URLs checked are those git fetches from
+	if err := runGit(dir, "submodule", "init"); err != nil {
+		return err
+	}
+	urls, err := gitConfigValues(dir, `^submodule\..*\.url$`)
+	if err != nil {
+		return err
+	}
+	for name, url := range urls {
+		if err := remoteAllowed(cfg, url); err != nil {
+			return fmt.Errorf("submodule %s: %w", strings.TrimSuffix(strings.TrimPrefix(name, "submodule."), ".url"), err)
+		}
+	}
+
+	if err := runNetworkGit(dir, "submodule", "update", "--init"); err != nil {
+		return err
+	}
+	paths, err := gitConfigValues(dir, `^submodule\..*\.path$`, "-f", ".gitmodules")
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := updateSubmodules(filepath.Join(dir, filepath.FromSlash(path))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitConfigValues returns the git config keys of the repository in dir that match
+// pattern with their values, reading the file given by args if any; no match is no error
+func gitConfigValues(dir, pattern string, args ...string) (map[string]string, error) {
+	cmd := exec.Command("git", append(append([]string{"config"}, args...), "--get-regexp", pattern)...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if exit, ok := err.(*exec.ExitError); ok && exit.ExitCode() == 1 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git config: %w", err)
+	}
+
+	values := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if key, value, found := strings.Cut(line, " "); found {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
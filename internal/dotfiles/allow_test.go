@@ -0,0 +1,111 @@
+package dotfiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/settings"
+)
+
+func TestAllowedRemotes(t *testing.T) {
+	// allow restricts remotes to the repositories below tempDir/allowed
+	allow := func(t *testing.T, tempDir string) string {
+		allowed := filepath.ToSlash(filepath.Join(tempDir, "allowed"))
+		cfg, _ := settings.Load()
+		cfg.AllowedRemotes = []string{allowed + "/*"}
+		if err := settings.Save(cfg); err != nil {
+			t.Fatalf("Failed to save settings: %v", err)
+		}
+		return allowed
+	}
+
+	t.Run("Add refuses a URL outside the allowlist", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		allowed := allow(t, tempDir)
+
+		err := AddRemote("github", filepath.ToSlash(filepath.Join(tempDir, "other.git")))
+		if err == nil || !strings.Contains(err.Error(), "is not allowed") {
+			t.Errorf("Expected the URL to be refused, got %v", err)
+		}
+		if err := AddRemote("mirror", allowed+"/mirror.git"); err != nil {
+			t.Errorf("Expected the allowed URL to be added, got %v", err)
+		}
+	})
+
+	t.Run("Push skips remotes outside the allowlist", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		cfg, _ := settings.Load()
+		cfg.Remotes = map[string]string{"rogue": filepath.ToSlash(filepath.Join(tempDir, "rogue.git"))}
+		settings.Save(cfg)
+		allow(t, tempDir)
+
+		err := Push()
+		if err == nil || !strings.Contains(err.Error(), "rogue") {
+			t.Errorf("Expected the push to rogue to fail, got %v", err)
+		}
+		if remotes, _ := gitRemotes(filepath.Join(tempDir, "dotfiles")); remotes["rogue"] != "" {
+			t.Error("Expected the rogue remote not to be added to the repository")
+		}
+	})
+
+	t.Run("Clone refuses a URL outside the allowlist", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		allow(t, tempDir)
+		os.Setenv("DOT_DIR", filepath.Join(tempDir, "clone"))
+
+		err := Clone(filepath.Join(tempDir, "dotfiles"))
+		if err == nil || !strings.Contains(err.Error(), "is not allowed") {
+			t.Errorf("Expected the clone to be refused, got %v", err)
+		}
+	})
+
+	t.Run("Update refuses an upstream outside the allowlist", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		upstream := filepath.Join(tempDir, "dotfiles")
+		clone := filepath.Join(tempDir, "clone")
+		testGit(t, "", "clone", "-q", upstream, clone)
+		os.Setenv("DOT_DIR", clone)
+		allow(t, tempDir)
+
+		err := Update()
+		if err == nil || !strings.Contains(err.Error(), "is not allowed") {
+			t.Errorf("Expected the update to be refused, got %v", err)
+		}
+	})
+	t.Run("Clone refuses a submodule outside the allowlist", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		allowed := allow(t, tempDir)
+		// Local submodules are only cloned where the file protocol is permitted
+		t.Setenv("GIT_CONFIG_COUNT", "1")
+		t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+		t.Setenv("GIT_CONFIG_VALUE_0", "always")
+
+		// repo includes a submodule it may, and then one from outside the allowlist
+		repo := filepath.Join(filepath.FromSlash(allowed), "repo")
+		testGit(t, "", "clone", "-q", filepath.Join(tempDir, "dotfiles"), filepath.Join(filepath.FromSlash(allowed), "shared"))
+		testGit(t, "", "clone", "-q", filepath.Join(tempDir, "dotfiles"), repo)
+		testGit(t, repo, "submodule", "add", "-q", allowed+"/shared", "shared")
+		testGit(t, repo, "commit", "-q", "-m", "Add shared")
+
+		os.Setenv("DOT_DIR", filepath.Join(tempDir, "clone"))
+		if err := Clone(repo); err != nil {
+			t.Fatalf("Expected the allowed submodule to be cloned, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, "clone", "shared", ".mappings")); err != nil {
+			t.Errorf("Expected the allowed submodule to be checked out: %v", err)
+		}
+
+		testGit(t, repo, "submodule", "add", "-q", filepath.ToSlash(filepath.Join(tempDir, "dotfiles")), "rogue")
+		testGit(t, repo, "commit", "-q", "-m", "Add rogue")
+		os.Setenv("DOT_DIR", filepath.Join(tempDir, "clone2"))
+		err := Clone(repo)
+		if err == nil || !strings.Contains(err.Error(), "submodule rogue") || !strings.Contains(err.Error(), "is not allowed") {
+			t.Errorf("Expected the rogue submodule to be refused, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, "clone2", "rogue", ".mappings")); err == nil {
+			t.Error("Expected the rogue submodule not to be checked out")
+		}
+	})
+}
@@ -1,12 +1,22 @@
 package dotfiles
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/yourusername/dot/internal/fsys"
 )
 
+// FS is the filesystem the dotfiles directory is inspected and set up on; the git
+// commands run on it act on the real filesystem regardless
+// Tests replace it with an in-memory filesystem
+var FS fsys.FS = fsys.OS{}
+
 // GetDotfilesDir returns the dotfiles directory path
 // Uses $DOT_DIR environment variable if set, otherwise defaults to ~/.dotfiles
 func GetDotfilesDir() (string, error) {
@@ -22,7 +32,8 @@ func GetDotfilesDir() (string, error) {
 	return filepath.Join(homeDir, ".dotfiles"), nil
 }
 
-// Clone clones a repository to the dotfiles directory
+// Clone clones a repository to the dotfiles directory, and the team repository if one
+// is configured and not checked out yet
 func Clone(repoURL string) error {
 	dotfilesDir, err := GetDotfilesDir()
 	if err != nil {
@@ -30,9 +41,9 @@ func Clone(repoURL string) error {
 	}
 
 	// Check if destination exists and is non-empty
-	if stat, err := os.Stat(dotfilesDir); err == nil {
+	if stat, err := FS.Stat(dotfilesDir); err == nil {
 		if stat.IsDir() {
-			entries, err := os.ReadDir(dotfilesDir)
+			entries, err := FS.ReadDir(dotfilesDir)
 			if err != nil {
 				return fmt.Errorf("failed to read dotfiles directory: %w", err)
 			}
@@ -44,22 +55,28 @@ func Clone(repoURL string) error {
 		}
 	}
 
-	// Execute git clone command
-	cmd := exec.Command("git", "clone", repoURL, dotfilesDir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if err := checkRemote(repoURL); err != nil {
+		return err
+	}
 
-	if err := cmd.Run(); err != nil {
+	if err := runNetworkGit("", "clone", repoURL, dotfilesDir); err != nil {
 		return fmt.Errorf("failed to clone repository: %w", err)
 	}
+	if err := verifiedClone(dotfilesDir); err != nil {
+		return err
+	}
+	// Submodules are checked out too, since they may contribute mappings
+	if err := updateSubmodules(dotfilesDir); err != nil {
+		return fmt.Errorf("failed to update submodules: %w", err)
+	}
 
 	// Validate that .mappings file exists
 	mappingsPath := filepath.Join(dotfilesDir, ".mappings")
-	if _, err := os.Stat(mappingsPath); os.IsNotExist(err) {
+	if _, err := FS.Stat(mappingsPath); os.IsNotExist(err) {
 		return fmt.Errorf("cloned repository does not contain a .mappings file")
 	}
 
-	return nil
+	return updateTeam()
 }
 
 // PrintRoot prints the dotfiles directory path
@@ -73,7 +90,8 @@ func PrintRoot() error {
 	return nil
 }
 
-// Update changes to the dotfiles directory and runs git pull
+// Update changes to the dotfiles directory and runs git pull, then updates its submodules
+// and the team repository, if one is configured
 func Update() error {
 	dotfilesDir, err := GetDotfilesDir()
 	if err != nil {
@@ -81,21 +99,22 @@ func Update() error {
 	}
 
 	// Check if the dotfiles directory exists
-	if _, err := os.Stat(dotfilesDir); os.IsNotExist(err) {
+	if _, err := FS.Stat(dotfilesDir); os.IsNotExist(err) {
 		return fmt.Errorf("dotfiles directory %s does not exist", dotfilesDir)
 	}
 
 	// Execute git pull command in the dotfiles directory
-	cmd := exec.Command("git", "pull")
-	cmd.Dir = dotfilesDir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := pull(dotfilesDir, false); err != nil {
 		return fmt.Errorf("failed to update dotfiles repository: %w", err)
 	}
 
-	return nil
+	// Bring submodules, which may contribute mappings, to the commits the pull recorded,
+	// checking out any that were added
+	if err := updateSubmodules(dotfilesDir); err != nil {
+		return fmt.Errorf("failed to update submodules: %w", err)
+	}
+
+	return updateTeam()
 }
 
 // Open opens the dotfiles directory in the system file manager
@@ -106,37 +125,23 @@ func Open() error {
 	}
 
 	// Check if the dotfiles directory exists
-	if _, err := os.Stat(dotfilesDir); os.IsNotExist(err) {
+	if _, err := FS.Stat(dotfilesDir); os.IsNotExist(err) {
 		return fmt.Errorf("dotfiles directory %s does not exist", dotfilesDir)
 	}
 
-	// Determine the command based on the operating system
-	// Try different commands in order of likelihood
-	var cmd *exec.Cmd
+	// Try the file managers of this OS in order of likelihood
+	managers := fileManagers(runtime.GOOS)
 	var cmdErr error
-
-	// Try macOS first
-	if _, err := exec.LookPath("open"); err == nil {
-		cmd = exec.Command("open", dotfilesDir)
-		cmdErr = cmd.Run()
-		if cmdErr == nil {
-			return nil
+	for _, manager := range managers {
+		if _, err := exec.LookPath(manager); err != nil {
+			continue
 		}
-	}
-
-	// Try Linux/Unix with xdg-open
-	if _, err := exec.LookPath("xdg-open"); err == nil {
-		cmd = exec.Command("xdg-open", dotfilesDir)
-		cmdErr = cmd.Run()
-		if cmdErr == nil {
+		cmdErr = exec.Command(manager, managerPath(manager, dotfilesDir)).Run()
+		var exitErr *exec.ExitError
+		if manager == "explorer" && errors.As(cmdErr, &exitErr) {
+			// explorer exits with 1 even when it opened the window
 			return nil
 		}
-	}
-
-	// Try Windows
-	if _, err := exec.LookPath("explorer"); err == nil {
-		cmd = exec.Command("explorer", dotfilesDir)
-		cmdErr = cmd.Run()
 		if cmdErr == nil {
 			return nil
 		}
@@ -146,5 +151,24 @@ func Open() error {
 		return fmt.Errorf("failed to open dotfiles directory: %w", cmdErr)
 	}
 
-	return fmt.Errorf("no suitable file manager command found (tried: open, xdg-open, explorer)")
+	return fmt.Errorf("no suitable file manager command found (tried: %s)", strings.Join(managers, ", "))
+}
+
+// fileManagers returns the commands that may open a directory on goos, in the order
+// they are tried
+// explorer comes first on Windows, where it is always present
+func fileManagers(goos string) []string {
+	if goos == "windows" {
+		return []string{"explorer", "open", "xdg-open"}
+	}
+	return []string{"open", "xdg-open", "explorer"}
+}
+
+// managerPath returns dir as the file manager expects it; explorer only understands
+// backslashes and opens the Documents folder for a path with forward slashes
+func managerPath(manager, dir string) string {
+	if manager == "explorer" {
+		return strings.ReplaceAll(dir, "/", `\`)
+	}
+	return dir
 }
@@ -5,8 +5,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
 )
 
 func TestGetDotfilesDir(t *testing.T) {
@@ -161,6 +164,24 @@ func TestClone(t *testing.T) {
 		}
 	})
 
+	t.Run("Clone checks the destination on FS", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("in-memory paths are POSIX style")
+		}
+		originalFS := FS
+		defer func() { FS = originalFS }()
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles", 0755)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+		os.Setenv("DOT_DIR", "/dotfiles")
+
+		err := Clone("https://example.com/repo.git")
+		if err == nil || !strings.Contains(err.Error(), "already exists and is non-empty") {
+			t.Errorf("Expected error about non-empty directory, got: %v", err)
+		}
+	})
+
 	t.Run("Clone succeeds when destination doesn't exist", func(t *testing.T) {
 		tempDir := t.TempDir()
 		dotfilesDir := filepath.Join(tempDir, "nonexistent")
@@ -532,3 +553,28 @@ func TestOpen(t *testing.T) {
 		}
 	})
 }
+
+func TestFileManagers(t *testing.T) {
+	t.Run("Windows tries explorer first", func(t *testing.T) {
+		managers := fileManagers("windows")
+		if managers[0] != "explorer" {
+			t.Errorf("Expected explorer first, got %v", managers)
+		}
+	})
+
+	t.Run("Other systems try open and xdg-open first", func(t *testing.T) {
+		managers := fileManagers("linux")
+		if managers[0] != "open" || managers[1] != "xdg-open" {
+			t.Errorf("Expected open and xdg-open first, got %v", managers)
+		}
+	})
+
+	t.Run("Explorer gets backslashes", func(t *testing.T) {
+		if path := managerPath("explorer", "C:/Users/me/.dotfiles"); path != `C:\Users\me\.dotfiles` {
+			t.Errorf("Expected 'C:\\Users\\me\\.dotfiles', got '%s'", path)
+		}
+		if path := managerPath("xdg-open", "/home/me/.dotfiles"); path != "/home/me/.dotfiles" {
+			t.Errorf("Expected '/home/me/.dotfiles', got '%s'", path)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package dotfiles
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// starterMappings is the .mappings file a new repository starts with
+const starterMappings = `# Each profile maps sources in this repository to targets on the machine, e.g.
+#   "zsh/.zshrc" = "~/.zshrc"
+# Link them with: dot link --profile general
+
+[general]
+`
+
+// Init creates the dotfiles directory as a new git repository with a starter .mappings
+// file, so dot can be used without a repository to clone
+// A directory that is already a git repository is kept, but one that already has a
+// .mappings file is refused
+func Init() error {
+	dotfilesDir, err := GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	mappingsPath := filepath.Join(dotfilesDir, ".mappings")
+	if utils.FileExistsFS(FS, mappingsPath) {
+		return fmt.Errorf("dotfiles directory %s already contains a .mappings file", dotfilesDir)
+	}
+	if err := FS.MkdirAll(dotfilesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create dotfiles directory: %w", err)
+	}
+
+	if _, err := FS.Stat(filepath.Join(dotfilesDir, ".git")); os.IsNotExist(err) {
+		if err := runGit(dotfilesDir, "init", "--quiet"); err != nil {
+			return fmt.Errorf("failed to initialize git repository: %w", err)
+		}
+	}
+
+	if err := FS.WriteFile(mappingsPath, []byte(starterMappings), 0644); err != nil {
+		return fmt.Errorf("failed to write .mappings file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Initialized dotfiles repository in %s\n", dotfilesDir)
+	return nil
+}
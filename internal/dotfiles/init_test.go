@@ -0,0 +1,53 @@
+package dotfiles
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestInit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	t.Run("Creates a repository with a starter .mappings", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "dotfiles")
+		os.Setenv("DOT_DIR", dir)
+
+		if err := Init(); err != nil {
+			t.Fatalf("Init failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+			t.Errorf("Expected a git repository, got %v", err)
+		}
+		cfg, err := config.ParseConfig(dir)
+		if err != nil {
+			t.Fatalf("Expected the starter .mappings to parse, got %v", err)
+		}
+		if _, exists := cfg.Profiles["general"]; !exists {
+			t.Error("Expected a [general] profile")
+		}
+	})
+
+	t.Run("Refuses a directory with a .mappings file", func(t *testing.T) {
+		dir := t.TempDir()
+		os.Setenv("DOT_DIR", dir)
+		os.WriteFile(filepath.Join(dir, ".mappings"), []byte("[work]\n"), 0644)
+
+		err := Init()
+		if err == nil || !strings.Contains(err.Error(), "already contains a .mappings file") {
+			t.Errorf("Expected the existing .mappings to be refused, got %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(dir, ".mappings")); string(data) != "[work]\n" {
+			t.Errorf("Expected .mappings to be left alone, got '%s'", data)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package dotfiles
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/yourusername/dot/internal/settings"
+)
+
+const (
+	// defaultNetworkTimeout stops a clone, pull, or push that stalls
+	defaultNetworkTimeout = 5 * time.Minute
+	// defaultRetries is how often an operation that failed with a network error is retried
+	defaultRetries = 2
+	// firstBackoff is the wait before the first retry; it doubles for every further retry
+	firstBackoff = 2 * time.Second
+)
+
+// sleep waits between retries; tests replace it to run without delay
+var sleep = time.Sleep
+
+// NetworkError is a git operation that could not reach its remote: it timed out, or git
+// reported a connection failure on every attempt
+// Unlike other failures it is likely to go away by itself, so scheduled runs can retry later
+type NetworkError struct {
+	Op       string // the git subcommand, e.g. "pull"
+	Attempts int
+	Err      error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("network error: git %s failed after %d attempt(s): %v", e.Op, e.Attempts, e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// transientErrors are messages of git and ssh that mean the remote could not be reached
+var transientErrors = []string{
+	"could not resolve host",
+	"temporary failure in name resolution",
+	"connection timed out",
+	"connection refused",
+	"connection reset",
+	"operation timed out",
+	"network is unreachable",
+	"the remote end hung up unexpectedly",
+	"early eof",
+	"rpc failed",
+	"ssh: connect to host",
+	"gnutls recv error",
+	"ssl_read",
+}
+
+// isTransient reports whether git's error output describes a network failure
+func isTransient(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, message := range transientErrors {
+		if strings.Contains(stderr, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// networkPolicy is how git operations that talk to remotes are run
+type networkPolicy struct {
+	timeout    time.Duration
+	retries    int
+	sshCommand string
+}
+
+// loadNetworkPolicy reads the policy from the [network] settings and the ssh flags
+func loadNetworkPolicy() (networkPolicy, error) {
+	cfg, err := settings.Load()
+	if err != nil {
+		return networkPolicy{}, err
+	}
+
+	policy := networkPolicy{timeout: defaultNetworkTimeout, retries: defaultRetries}
+	if cfg.Network.Timeout != "" {
+		policy.timeout, _ = time.ParseDuration(cfg.Network.Timeout)
+	}
+	if cfg.Network.Retries != nil {
+		policy.retries = *cfg.Network.Retries
+	}
+	if policy.sshCommand, err = sshCommand(cfg.Network); err != nil {
+		return networkPolicy{}, err
+	}
+	return policy, nil
+}
+
+// runNetworkGit runs a git command that talks to a remote in dir, showing its output
+// An attempt that times out or fails with a network error is retried with exponential
+// backoff; when every attempt failed that way a *NetworkError is returned
+func runNetworkGit(dir string, args ...string) error {
+	policy, err := loadNetworkPolicy()
+	if err != nil {
+		return err
+	}
+
+	backoff := firstBackoff
+	for attempt := 1; ; attempt++ {
+		transient, err := runGitAttempt(dir, policy, args)
+		if !transient {
+			return err
+		}
+		if attempt > policy.retries {
+			return &NetworkError{Op: args[0], Attempts: attempt, Err: err}
+		}
+
+		fmt.Fprintf(os.Stderr, "Warning: git %s failed (%v), retrying in %s\n", args[0], err, backoff)
+		sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// runGitAttempt runs git once, stopping it after the policy's timeout when that is positive
+// transient reports whether the attempt failed in a way that is worth retrying
+func runGitAttempt(dir string, policy networkPolicy, args []string) (transient bool, err error) {
+	ctx := context.Background()
+	if policy.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.timeout)
+		defer cancel()
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	if policy.sshCommand != "" {
+		cmd.Env = append(os.Environ(), "GIT_SSH_COMMAND="+policy.sshCommand)
+	}
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	// ssh started by git may keep stderr open after git was stopped
+	cmd.WaitDelay = time.Second
+
+	err = cmd.Run()
+	switch {
+	case err == nil:
+		return false, nil
+	case ctx.Err() == context.DeadlineExceeded:
+		return true, fmt.Errorf("timed out after %s", policy.timeout)
+	}
+	return isTransient(stderr.String()), err
+}
+
+// probeTimeout bounds ProbeRemote, which must not hold up a diagnosis for long
+const probeTimeout = 20 * time.Second
+
+// ProbeRemote checks that the remote at url can be reached and read with the credentials
+// git has, without prompting for any; the error holds git's explanation
+func ProbeRemote(url string) error {
+	policy, err := loadNetworkPolicy()
+	if err != nil {
+		return err
+	}
+	timeout := probeTimeout
+	if policy.timeout > 0 && policy.timeout < timeout {
+		timeout = policy.timeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--exit-code", url, "HEAD")
+	// A missing credential fails instead of waiting for input
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	if policy.sshCommand != "" {
+		cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND="+policy.sshCommand+" -o BatchMode=yes")
+	}
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s", timeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			lines := strings.Split(message, "\n")
+			return fmt.Errorf("%s", strings.TrimSpace(lines[len(lines)-1]))
+		}
+		return err
+	}
+	return nil
+}
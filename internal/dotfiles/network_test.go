@@ -0,0 +1,138 @@
+package dotfiles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := map[string]bool{
+		"fatal: unable to access 'https://github.com/me/dotfiles.git/': Could not resolve host: github.com": true,
+		"ssh: connect to host github.com port 22: Connection timed out":                                     true,
+		"fatal: the remote end hung up unexpectedly":                                                        true,
+		"remote: Repository not found.\nfatal: repository 'https://github.com/me/missing.git/' not found":   false,
+		"git@github.com: Permission denied (publickey).":                                                    false,
+	}
+
+	for stderr, expected := range tests {
+		if result := isTransient(stderr); result != expected {
+			t.Errorf("isTransient(%q) = %v, want %v", stderr, result, expected)
+		}
+	}
+}
+
+func TestRunNetworkGit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake git is a shell script")
+	}
+
+	originalPath := os.Getenv("PATH")
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	originalSleep := sleep
+	defer func() {
+		os.Setenv("PATH", originalPath)
+		os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+		sleep = originalSleep
+	}()
+
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	// setup installs a fake git running script, which may count its runs in $CALLS
+	setup := func(t *testing.T, script, config string) string {
+		binDir := t.TempDir()
+		os.WriteFile(filepath.Join(binDir, "git"), []byte("#!/bin/sh\necho run >> \"$CALLS\"\n"+script), 0755)
+		os.Setenv("PATH", binDir+string(os.PathListSeparator)+originalPath)
+
+		configHome := t.TempDir()
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte(config), 0644)
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+
+		calls := filepath.Join(t.TempDir(), "calls")
+		os.Setenv("CALLS", calls)
+		t.Cleanup(func() { os.Unsetenv("CALLS") })
+		waits = nil
+		return calls
+	}
+
+	countCalls := func(calls string) int {
+		data, _ := os.ReadFile(calls)
+		return strings.Count(string(data), "run")
+	}
+
+	t.Run("Network failures are retried with backoff", func(t *testing.T) {
+		calls := setup(t, "echo 'fatal: Could not resolve host: github.com' >&2\nexit 128\n", "")
+
+		err := runNetworkGit(t.TempDir(), "pull")
+
+		var networkErr *NetworkError
+		if !errors.As(err, &networkErr) {
+			t.Fatalf("Expected NetworkError, got %v", err)
+		}
+		if networkErr.Attempts != 3 || countCalls(calls) != 3 {
+			t.Errorf("Expected 3 attempts, got %d (%d runs)", networkErr.Attempts, countCalls(calls))
+		}
+		if len(waits) != 2 || waits[1] != 2*waits[0] {
+			t.Errorf("Expected two doubling waits, got %v", waits)
+		}
+	})
+
+	t.Run("Other failures are not retried", func(t *testing.T) {
+		calls := setup(t, "echo 'fatal: repository not found' >&2\nexit 128\n", "")
+
+		err := runNetworkGit(t.TempDir(), "pull")
+
+		var networkErr *NetworkError
+		if err == nil || errors.As(err, &networkErr) {
+			t.Errorf("Expected a plain error, got %v", err)
+		}
+		if countCalls(calls) != 1 {
+			t.Errorf("Expected 1 attempt, got %d", countCalls(calls))
+		}
+	})
+
+	t.Run("Stalled operations time out", func(t *testing.T) {
+		calls := setup(t, "exec sleep 10\n", "[network]\ntimeout = \"100ms\"\nretries = 0\n")
+
+		start := time.Now()
+		err := runNetworkGit(t.TempDir(), "pull")
+
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected git to be stopped, took %s", elapsed)
+		}
+		if err == nil || !strings.Contains(err.Error(), "network error: git pull failed after 1 attempt(s): timed out after 100ms") {
+			t.Errorf("Expected timeout error, got %v", err)
+		}
+		if countCalls(calls) != 1 {
+			t.Errorf("Expected 1 attempt, got %d", countCalls(calls))
+		}
+	})
+
+	t.Run("SSH command reaches git", func(t *testing.T) {
+		calls := setup(t, "echo \"$GIT_SSH_COMMAND\" >> \"$CALLS\"\n", "[network]\nssh_command = \"ssh -p 2222\"\n")
+
+		if err := runNetworkGit(t.TempDir(), "pull"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if data, _ := os.ReadFile(calls); !strings.Contains(string(data), "ssh -p 2222") {
+			t.Errorf("Expected GIT_SSH_COMMAND to be set, got %q", data)
+		}
+	})
+
+	t.Run("Success needs one attempt", func(t *testing.T) {
+		calls := setup(t, "exit 0\n", "")
+
+		if err := runNetworkGit(t.TempDir(), "pull"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if countCalls(calls) != 1 {
+			t.Errorf("Expected 1 attempt, got %d", countCalls(calls))
+		}
+	})
+}
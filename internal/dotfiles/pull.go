@@ -0,0 +1,46 @@
+package dotfiles
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pull updates the dotfiles repository like Update and returns the files the update
+// brought in, with the status letter of git diff --name-status: A, M, or D
+// A repository without commits before the pull reports no changes
+func Pull() ([]Change, error) {
+	dotfilesDir, err := GetDotfilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	before, _ := gitOutput(dotfilesDir, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err := Update(); err != nil {
+		return nil, err
+	}
+	after, err := gitOutput(dotfilesDir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD of %s: %w", dotfilesDir, err)
+	}
+	if before == "" || before == after {
+		return []Change{}, nil
+	}
+
+	output, err := gitOutput(dotfilesDir, "-c", "core.quotePath=false", "diff", "--name-status", "--no-renames", before, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pulled changes: %w", err)
+	}
+	return parseNameStatus(output), nil
+}
+
+// parseNameStatus parses the output of git diff --name-status --no-renames
+func parseNameStatus(output string) []Change {
+	changes := []Change{}
+	for _, line := range strings.Split(output, "\n") {
+		status, path, found := strings.Cut(line, "\t")
+		if found {
+			changes = append(changes, Change{Status: status, Path: path})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,67 @@
+package dotfiles
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestPull(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	defer func() {
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+	}()
+
+	tempDir := t.TempDir()
+	upstream := filepath.Join(tempDir, "upstream")
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	os.Setenv("DOT_DIR", dotfilesDir)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+
+	os.MkdirAll(upstream, 0755)
+	os.WriteFile(filepath.Join(upstream, ".mappings"), []byte("[general]\n"), 0644)
+	os.WriteFile(filepath.Join(upstream, "vimrc"), []byte("vim"), 0644)
+	testGit(t, upstream, "init", "-q")
+	testGit(t, upstream, "add", ".")
+	testGit(t, upstream, "commit", "-q", "-m", "initial")
+	testGit(t, tempDir, "clone", "-q", upstream, dotfilesDir)
+
+	t.Run("Nothing to pull reports no changes", func(t *testing.T) {
+		changes, err := Pull()
+		if err != nil {
+			t.Fatalf("Pull failed: %v", err)
+		}
+		if len(changes) != 0 {
+			t.Errorf("Expected no changes, got %v", changes)
+		}
+	})
+
+	t.Run("Pulled files are reported with their status", func(t *testing.T) {
+		os.WriteFile(filepath.Join(upstream, ".mappings"), []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n"), 0644)
+		os.WriteFile(filepath.Join(upstream, "zshrc"), []byte("zsh"), 0644)
+		os.Remove(filepath.Join(upstream, "vimrc"))
+		testGit(t, upstream, "add", "-A")
+		testGit(t, upstream, "commit", "-q", "-m", "add zshrc")
+
+		changes, err := Pull()
+		if err != nil {
+			t.Fatalf("Pull failed: %v", err)
+		}
+		expected := []Change{{Status: "M", Path: ".mappings"}, {Status: "D", Path: "vimrc"}, {Status: "A", Path: "zshrc"}}
+		if len(changes) != len(expected) {
+			t.Fatalf("Expected %v, got %v", expected, changes)
+		}
+		for i := range expected {
+			if changes[i] != expected[i] {
+				t.Errorf("Expected %v, got %v", expected[i], changes[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,332 @@
+package dotfiles
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/settings"
+)
+
+// Remote is a git remote of the dotfiles repository
+type Remote struct {
+	Name       string
+	URL        string
+	Configured bool // declared in the global config
+	Mapped     bool // declared in the [remotes] table of the repository's .mappings
+	InRepo     bool // present in the repository's git config
+}
+
+// AddRemote adds a git remote to the dotfiles repository and records it in the global
+// config, so it is restored on other machines the next time dot pushes
+func AddRemote(name, url string) error {
+	dotfilesDir, err := existingDotfilesDir()
+	if err != nil {
+		return err
+	}
+	if err := checkRemote(url); err != nil {
+		return err
+	}
+
+	remotes, err := gitRemotes(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	if existing, exists := remotes[name]; exists {
+		if existing != url {
+			return fmt.Errorf("remote %s already exists with URL %s", name, existing)
+		}
+	} else if err := runGit(dotfilesDir, "remote", "add", name, url); err != nil {
+		return fmt.Errorf("failed to add remote %s: %w", name, err)
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.Remotes == nil {
+		cfg.Remotes = make(map[string]string)
+	}
+	cfg.Remotes[name] = url
+	if err := settings.Save(cfg); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Added remote %s: %s\n", name, url)
+	return nil
+}
+
+// RemoveRemote removes a remote from the dotfiles repository and the global config
+func RemoveRemote(name string) error {
+	dotfilesDir, err := existingDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	remotes, err := gitRemotes(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+
+	mapped, err := mappedRemotes(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	_, inRepo := remotes[name]
+	_, configured := cfg.Remotes[name]
+	_, inMappings := mapped[name]
+	if !inRepo && !configured {
+		if inMappings {
+			return fmt.Errorf("remote %s is declared in the [%s] table of .mappings, remove it from there", name, config.RemotesKey)
+		}
+		return fmt.Errorf("remote %s does not exist", name)
+	}
+
+	if inRepo {
+		if err := runGit(dotfilesDir, "remote", "remove", name); err != nil {
+			return fmt.Errorf("failed to remove remote %s: %w", name, err)
+		}
+	}
+	if configured {
+		delete(cfg.Remotes, name)
+		if err := settings.Save(cfg); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Removed remote %s\n", name)
+	if inMappings {
+		fmt.Fprintf(os.Stderr, "Warning: remote %s is still declared in .mappings and is added back on the next push\n", name)
+	}
+	return nil
+}
+
+// ListRemotes returns the remotes of the repository, the global config, and the
+// repository's .mappings, sorted by name
+// A remote named in several places has the URL of the repository, then that of the
+// global config
+func ListRemotes() ([]Remote, error) {
+	dotfilesDir, err := existingDotfilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	remotes, err := gitRemotes(dotfilesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*Remote)
+	for name, url := range remotes {
+		byName[name] = &Remote{Name: name, URL: url, InRepo: true}
+	}
+	for name, url := range cfg.Remotes {
+		if remote, exists := byName[name]; exists {
+			remote.Configured = true
+			continue
+		}
+		byName[name] = &Remote{Name: name, URL: url, Configured: true}
+	}
+	mapped, err := mappedRemotes(dotfilesDir)
+	if err != nil {
+		return nil, err
+	}
+	for name, url := range mapped {
+		if remote, exists := byName[name]; exists {
+			remote.Mapped = true
+			continue
+		}
+		byName[name] = &Remote{Name: name, URL: url, Mapped: true}
+	}
+
+	result := make([]Remote, 0, len(byName))
+	for _, remote := range byName {
+		result = append(result, *remote)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+
+	return result, nil
+}
+
+// PrintRemotes prints the remotes of the dotfiles repository
+func PrintRemotes() error {
+	remotes, err := ListRemotes()
+	if err != nil {
+		return err
+	}
+
+	if len(remotes) == 0 {
+		fmt.Fprintln(os.Stderr, "No remotes configured")
+		return nil
+	}
+
+	for _, remote := range remotes {
+		note := ""
+		if !remote.InRepo {
+			note = " (added on next push)"
+		}
+		fmt.Printf("%-12s %s%s\n", remote.Name, remote.URL, note)
+	}
+
+	return nil
+}
+
+// Push pushes the current branch of the dotfiles repository to every remote
+// Remotes declared in the global config or .mappings but missing from the repository are
+// added first, and remotes outside allowed_remotes fail without being pushed to
+// A failing remote does not stop the others from being pushed
+func Push() error {
+	remotes, err := ListRemotes()
+	if err != nil {
+		return err
+	}
+	if len(remotes) == 0 {
+		return fmt.Errorf("no remotes configured (add one with 'dot remote add <name> <url>')")
+	}
+
+	dotfilesDir, err := GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	// The run fails with a network error when every remote that failed could not be reached
+	var networkErr *NetworkError
+	networkOnly := true
+	for _, remote := range remotes {
+		if err := checkRemote(remote.URL); err != nil {
+			fmt.Fprintf(os.Stderr, "Error pushing to %s: %v\n", remote.Name, err)
+			failed = append(failed, remote.Name)
+			networkOnly = false
+			continue
+		}
+		if !remote.InRepo {
+			if err := runGit(dotfilesDir, "remote", "add", remote.Name, remote.URL); err != nil {
+				fmt.Fprintf(os.Stderr, "Error adding remote %s: %v\n", remote.Name, err)
+				failed = append(failed, remote.Name)
+				networkOnly = false
+				continue
+			}
+		}
+
+		fmt.Fprintf(os.Stderr, "Pushing to %s (%s)\n", remote.Name, remote.URL)
+		if err := runNetworkGit(dotfilesDir, "push", remote.Name, "HEAD"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error pushing to %s: %v\n", remote.Name, err)
+			failed = append(failed, remote.Name)
+			if !errors.As(err, &networkErr) {
+				networkOnly = false
+			}
+		}
+	}
+
+	if len(failed) > 0 && networkOnly && networkErr != nil {
+		return fmt.Errorf("failed to push to %d remote(s): %s: %w", len(failed), strings.Join(failed, ", "), networkErr)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to push to %d remote(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// mappedRemotes returns the remotes declared in the .mappings of the repository in dir,
+// none if it has no .mappings
+func mappedRemotes(dir string) (map[string]string, error) {
+	if _, err := FS.Stat(filepath.Join(dir, ".mappings")); os.IsNotExist(err) {
+		return nil, nil
+	}
+	cfg, err := config.ParseConfigFS(FS, dir)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Remotes, nil
+}
+
+// existingDotfilesDir returns the dotfiles directory, failing if it does not exist
+func existingDotfilesDir() (string, error) {
+	dotfilesDir, err := GetDotfilesDir()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := FS.Stat(dotfilesDir); os.IsNotExist(err) {
+		return "", fmt.Errorf("dotfiles directory %s does not exist", dotfilesDir)
+	}
+
+	return dotfilesDir, nil
+}
+
+// gitRemotes returns the remotes of the repository in dir keyed by name
+func gitRemotes(dir string) (map[string]string, error) {
+	cmd := exec.Command("git", "remote", "-v")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list remotes: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	remotes := make(map[string]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "(fetch)" {
+			remotes[fields[0]] = fields[1]
+		}
+	}
+
+	return remotes, nil
+}
+
+// runGit runs a git command in dir, returning its error output on failure
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("%s", message)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Commit records the current content of paths in the dotfiles repository as a commit,
+// leaving other changes in the working tree alone
+func Commit(paths []string, message string) error {
+	dotfilesDir, err := existingDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	if err := runGit(dotfilesDir, append([]string{"add", "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if err := runGit(dotfilesDir, append([]string{"commit", "-m", message, "--"}, paths...)...); err != nil {
+		return fmt.Errorf("failed to commit changes: %w", err)
+	}
+	return nil
+}
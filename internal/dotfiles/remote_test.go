@@ -0,0 +1,180 @@
+package dotfiles
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/settings"
+)
+
+// setupRemoteEnvironment creates a dotfiles repository with one commit and a separate config home
+func setupRemoteEnvironment(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	t.Cleanup(func() {
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+	})
+	os.Setenv("DOT_DIR", dotfilesDir)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(tempDir, "config"))
+
+	if err := os.MkdirAll(dotfilesDir, 0755); err != nil {
+		t.Fatalf("Failed to create dotfiles directory: %v", err)
+	}
+	os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte("[general]\n"), 0644)
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	} {
+		if err := runGit(dotfilesDir, args...); err != nil {
+			t.Fatalf("git %v failed: %v", args, err)
+		}
+	}
+
+	return tempDir
+}
+
+// createBareRepo creates an empty bare repository to push to
+func createBareRepo(t *testing.T, path string) {
+	t.Helper()
+	if err := exec.Command("git", "init", "-q", "--bare", path).Run(); err != nil {
+		t.Fatalf("Failed to create bare repository: %v", err)
+	}
+}
+
+func TestRemotes(t *testing.T) {
+	t.Run("Add records remote in repository and global config", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		url := filepath.Join(tempDir, "github.git")
+
+		if err := AddRemote("github", url); err != nil {
+			t.Fatalf("AddRemote failed: %v", err)
+		}
+
+		remotes, err := ListRemotes()
+		if err != nil {
+			t.Fatalf("ListRemotes failed: %v", err)
+		}
+		if len(remotes) != 1 || remotes[0].URL != url || !remotes[0].InRepo || !remotes[0].Configured {
+			t.Errorf("Expected configured github remote, got %+v", remotes)
+		}
+	})
+
+	t.Run("Add refuses a different URL for an existing remote", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		AddRemote("github", filepath.Join(tempDir, "a.git"))
+
+		err := AddRemote("github", filepath.Join(tempDir, "b.git"))
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected already exists error, got %v", err)
+		}
+	})
+
+	t.Run("Remove deletes remote everywhere", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		AddRemote("mirror", filepath.Join(tempDir, "mirror.git"))
+
+		if err := RemoveRemote("mirror"); err != nil {
+			t.Fatalf("RemoveRemote failed: %v", err)
+		}
+
+		remotes, _ := ListRemotes()
+		if len(remotes) != 0 {
+			t.Errorf("Expected no remotes, got %+v", remotes)
+		}
+		if err := RemoveRemote("mirror"); err == nil {
+			t.Error("Expected error removing unknown remote")
+		}
+	})
+
+	t.Run("Push sends to every remote and restores configured ones", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		github := filepath.Join(tempDir, "github.git")
+		mirror := filepath.Join(tempDir, "mirror.git")
+		createBareRepo(t, github)
+		createBareRepo(t, mirror)
+
+		AddRemote("github", github)
+		// A remote known only from the global config, as on a freshly cloned machine
+		cfg, _ := settings.Load()
+		cfg.Remotes["mirror"] = mirror
+		settings.Save(cfg)
+
+		if err := Push(); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+
+		for _, repo := range []string{github, mirror} {
+			output, err := exec.Command("git", "--git-dir", repo, "log", "--oneline").Output()
+			if err != nil || !strings.Contains(string(output), "initial") {
+				t.Errorf("Expected commit in %s, got %s (%v)", repo, output, err)
+			}
+		}
+	})
+
+	t.Run("Push adds remotes declared in .mappings", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		mirror := filepath.Join(tempDir, "mirror.git")
+		createBareRepo(t, mirror)
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte("[remotes]\nmirror = \""+filepath.ToSlash(mirror)+"\"\n\n[general]\n"), 0644)
+
+		remotes, err := ListRemotes()
+		if err != nil {
+			t.Fatalf("ListRemotes failed: %v", err)
+		}
+		if len(remotes) != 1 || !remotes[0].Mapped || remotes[0].InRepo {
+			t.Errorf("Expected the mirror declared in .mappings, got %+v", remotes)
+		}
+		if err := Push(); err != nil {
+			t.Fatalf("Push failed: %v", err)
+		}
+		if output, _ := exec.Command("git", "--git-dir", mirror, "log", "--oneline").Output(); !strings.Contains(string(output), "initial") {
+			t.Error("Expected the mirror to be pushed")
+		}
+		if err := RemoveRemote("mirror"); err != nil {
+			t.Errorf("Expected the added remote to be removed, got %v", err)
+		}
+		if err := RemoveRemote("mirror"); err == nil || !strings.Contains(err.Error(), ".mappings") {
+			t.Errorf("Expected an error pointing at .mappings, got %v", err)
+		}
+	})
+
+	t.Run("Push reports failing remotes after trying all", func(t *testing.T) {
+		tempDir := setupRemoteEnvironment(t)
+		good := filepath.Join(tempDir, "good.git")
+		createBareRepo(t, good)
+
+		AddRemote("broken", filepath.Join(tempDir, "missing.git"))
+		AddRemote("good", good)
+
+		err := Push()
+		if err == nil || !strings.Contains(err.Error(), "broken") {
+			t.Errorf("Expected error naming the broken remote, got %v", err)
+		}
+		if output, _ := exec.Command("git", "--git-dir", good, "log", "--oneline").Output(); !strings.Contains(string(output), "initial") {
+			t.Error("Expected the good remote to be pushed despite the failure")
+		}
+	})
+
+	t.Run("Push without remotes", func(t *testing.T) {
+		setupRemoteEnvironment(t)
+
+		if err := Push(); err == nil || !strings.Contains(err.Error(), "no remotes") {
+			t.Errorf("Expected no remotes error, got %v", err)
+		}
+	})
+}
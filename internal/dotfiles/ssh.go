@@ -0,0 +1,47 @@
+package dotfiles
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/settings"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// SSHKey and SSHCommand are set from the --ssh-key and --ssh-command flags; they take
+// precedence over ssh_key and ssh_command in the [network] settings
+var (
+	SSHKey     string
+	SSHCommand string
+)
+
+// sshCommand returns the GIT_SSH_COMMAND for clone, pull, and push, or "" to leave the
+// environment and git config to decide which key is used
+func sshCommand(cfg settings.Network) (string, error) {
+	key, command := cfg.SSHKey, cfg.SSHCommand
+	if SSHKey != "" || SSHCommand != "" {
+		key, command = SSHKey, SSHCommand
+	}
+	if key != "" && command != "" {
+		return "", fmt.Errorf("--ssh-key and --ssh-command cannot be used together")
+	}
+	if command != "" || key == "" {
+		return command, nil
+	}
+
+	path, err := filepath.Abs(utils.ExpandPath(key))
+	if err != nil {
+		return "", err
+	}
+	if _, err := FS.Stat(path); err != nil {
+		return "", fmt.Errorf("ssh key: %w", err)
+	}
+	// Only the given key is offered, so an agent holding other keys cannot pick the wrong account
+	return "ssh -i " + shellQuote(filepath.ToSlash(path)) + " -o IdentitiesOnly=yes", nil
+}
+
+// shellQuote quotes s for the shell git runs GIT_SSH_COMMAND with
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,69 @@
+package dotfiles
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/settings"
+)
+
+func TestSSHCommand(t *testing.T) {
+	defer func() {
+		SSHKey, SSHCommand = "", ""
+	}()
+
+	key := filepath.Join(t.TempDir(), "id_work")
+	os.WriteFile(key, []byte("key"), 0600)
+
+	t.Run("Nothing configured leaves git alone", func(t *testing.T) {
+		command, err := sshCommand(settings.Network{})
+		if err != nil || command != "" {
+			t.Errorf("Expected no command, got %q, %v", command, err)
+		}
+	})
+
+	t.Run("Key from settings", func(t *testing.T) {
+		command, err := sshCommand(settings.Network{SSHKey: key})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := "ssh -i '" + filepath.ToSlash(key) + "' -o IdentitiesOnly=yes"
+		if command != expected {
+			t.Errorf("Expected %q, got %q", expected, command)
+		}
+	})
+
+	t.Run("Flags override settings", func(t *testing.T) {
+		SSHCommand = "ssh -F ~/.ssh/work_config"
+		defer func() { SSHCommand = "" }()
+
+		command, err := sshCommand(settings.Network{SSHKey: key})
+		if err != nil || command != SSHCommand {
+			t.Errorf("Expected %q, got %q, %v", SSHCommand, command, err)
+		}
+	})
+
+	t.Run("Missing key is an error", func(t *testing.T) {
+		_, err := sshCommand(settings.Network{SSHKey: filepath.Join(t.TempDir(), "missing")})
+		if err == nil || !strings.Contains(err.Error(), "ssh key") {
+			t.Errorf("Expected missing key error, got %v", err)
+		}
+	})
+
+	t.Run("Key and command together are an error", func(t *testing.T) {
+		SSHKey, SSHCommand = key, "ssh"
+		defer func() { SSHKey, SSHCommand = "", "" }()
+
+		if _, err := sshCommand(settings.Network{}); err == nil {
+			t.Error("Expected an error for both --ssh-key and --ssh-command")
+		}
+	})
+}
+
+func TestShellQuote(t *testing.T) {
+	if quoted := shellQuote("/home/o'neil/.ssh/id"); quoted != `'/home/o'\''neil/.ssh/id'` {
+		t.Errorf("Expected escaped quote, got %s", quoted)
+	}
+}
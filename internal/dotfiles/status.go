@@ -0,0 +1,120 @@
+package dotfiles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RepoStatus is the git state of the dotfiles repository
+type RepoStatus struct {
+	Dir      string   `json:"dir"`
+	Branch   string   `json:"branch"`             // empty when HEAD is detached
+	Upstream string   `json:"upstream,omitempty"` // e.g. origin/main, empty without one
+	Ahead    int      `json:"ahead"`              // commits not pushed to the upstream
+	Behind   int      `json:"behind"`             // commits of the upstream not pulled yet
+	Changes  []Change `json:"changes"`
+}
+
+// Change is a file of the working tree that differs from HEAD
+type Change struct {
+	// Status is the two-letter code of git status --short, e.g. " M" or "??"
+	Status string `json:"status"`
+	Path   string `json:"path"`
+}
+
+// Dirty reports whether the working tree has changes that are not committed
+func (s *RepoStatus) Dirty() bool {
+	return len(s.Changes) > 0
+}
+
+// Status returns the git state of the dotfiles repository: its branch, how far it is
+// ahead of or behind its upstream as of the last fetch, and its uncommitted changes
+func Status() (*RepoStatus, error) {
+	dotfilesDir, err := existingDotfilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := gitOutput(dotfilesDir, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status of %s: %w", dotfilesDir, err)
+	}
+	status, err := parseStatus(output)
+	if err != nil {
+		return nil, err
+	}
+	status.Dir = dotfilesDir
+	return status, nil
+}
+
+// parseStatus parses the output of git status --porcelain=v2 --branch
+func parseStatus(output string) (*RepoStatus, error) {
+	status := &RepoStatus{Changes: []Change{}}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "#":
+			if err := parseBranchHeader(status, fields[1:]); err != nil {
+				return nil, err
+			}
+		case "1":
+			// 1 XY sub mH mI mW hH hI path
+			if parts := strings.SplitN(line, " ", 9); len(parts) == 9 {
+				status.Changes = append(status.Changes, Change{Status: shortStatus(parts[1]), Path: parts[8]})
+			}
+		case "2":
+			// 2 XY sub mH mI mW hH hI Xscore path<TAB>origPath
+			if parts := strings.SplitN(line, " ", 10); len(parts) == 10 {
+				path, _, _ := strings.Cut(parts[9], "\t")
+				status.Changes = append(status.Changes, Change{Status: shortStatus(parts[1]), Path: path})
+			}
+		case "u":
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			if parts := strings.SplitN(line, " ", 11); len(parts) == 11 {
+				status.Changes = append(status.Changes, Change{Status: parts[1], Path: parts[10]})
+			}
+		case "?":
+			status.Changes = append(status.Changes, Change{Status: "??", Path: strings.TrimPrefix(line, "? ")})
+		}
+	}
+	return status, nil
+}
+
+// parseBranchHeader reads a "# branch.<key> <value>" line into status
+func parseBranchHeader(status *RepoStatus, fields []string) error {
+	if len(fields) < 2 {
+		return nil
+	}
+	switch fields[0] {
+	case "branch.head":
+		if fields[1] != "(detached)" {
+			status.Branch = fields[1]
+		}
+	case "branch.upstream":
+		status.Upstream = fields[1]
+	case "branch.ab":
+		if len(fields) != 3 {
+			return fmt.Errorf("failed to parse git status: unexpected branch.ab %q", strings.Join(fields[1:], " "))
+		}
+		ahead, err := strconv.Atoi(strings.TrimPrefix(fields[1], "+"))
+		if err != nil {
+			return fmt.Errorf("failed to parse git status: %w", err)
+		}
+		behind, err := strconv.Atoi(strings.TrimPrefix(fields[2], "-"))
+		if err != nil {
+			return fmt.Errorf("failed to parse git status: %w", err)
+		}
+		status.Ahead, status.Behind = ahead, behind
+	}
+	return nil
+}
+
+// shortStatus turns the XY code of porcelain v2, which marks an unchanged side with a
+// dot, into the one of git status --short
+func shortStatus(xy string) string {
+	return strings.ReplaceAll(xy, ".", " ")
+}
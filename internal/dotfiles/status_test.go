@@ -0,0 +1,82 @@
+package dotfiles
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	output := `# branch.oid 1234567890abcdef
+# branch.head main
+# branch.upstream origin/main
+# branch.ab +2 -1
+1 .M N... 100644 100644 100644 abc abc zsh/.zshrc
+1 A. N... 000000 100644 100644 000 abc git/config with space
+2 R. N... 100644 100644 100644 abc abc R100 nvim/init.lua	vim/init.lua
+u UU N... 100644 100644 100644 100644 a b c tmux.conf
+? notes.txt`
+
+	status, err := parseStatus(output)
+	if err != nil {
+		t.Fatalf("parseStatus failed: %v", err)
+	}
+	if status.Branch != "main" || status.Upstream != "origin/main" || status.Ahead != 2 || status.Behind != 1 {
+		t.Errorf("Expected main tracking origin/main ahead 2 and behind 1, got %+v", status)
+	}
+
+	expected := []Change{
+		{Status: " M", Path: "zsh/.zshrc"},
+		{Status: "A ", Path: "git/config with space"},
+		{Status: "R ", Path: "nvim/init.lua"},
+		{Status: "UU", Path: "tmux.conf"},
+		{Status: "??", Path: "notes.txt"},
+	}
+	if len(status.Changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), status.Changes)
+	}
+	for i, change := range expected {
+		if status.Changes[i] != change {
+			t.Errorf("Expected %+v, got %+v", change, status.Changes[i])
+		}
+	}
+
+	t.Run("Detached HEAD without upstream", func(t *testing.T) {
+		status, err := parseStatus("# branch.oid abc\n# branch.head (detached)\n")
+		if err != nil {
+			t.Fatalf("parseStatus failed: %v", err)
+		}
+		if status.Branch != "" || status.Upstream != "" || status.Dirty() {
+			t.Errorf("Expected a clean detached HEAD, got %+v", status)
+		}
+	})
+}
+
+func TestStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	dir := t.TempDir()
+	os.Setenv("DOT_DIR", dir)
+	testGit(t, dir, "init", "-q", "-b", "main")
+	os.WriteFile(filepath.Join(dir, ".mappings"), []byte("[general]\n"), 0644)
+	testGit(t, dir, "add", ".mappings")
+	testGit(t, dir, "commit", "-q", "--no-gpg-sign", "-m", "init")
+	os.WriteFile(filepath.Join(dir, ".mappings"), []byte("[general]\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+
+	status, err := Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.Dir != dir || status.Branch != "main" || status.Upstream != "" {
+		t.Errorf("Expected branch main without upstream, got %+v", status)
+	}
+	if len(status.Changes) != 1 || status.Changes[0] != (Change{Status: " M", Path: ".mappings"}) {
+		t.Errorf("Expected the modified .mappings, got %+v", status.Changes)
+	}
+}
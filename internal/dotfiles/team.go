@@ -0,0 +1,57 @@
+package dotfiles
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/dot/internal/settings"
+)
+
+// updateTeam brings the team repository set in the global config up to date, cloning it
+// when it is not checked out yet; nothing happens without a team repository
+// The team repository is read-only, so it is only fast-forwarded, never merged or pushed
+func updateTeam() error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	if !cfg.Team.Enabled() {
+		return nil
+	}
+	teamDir, err := cfg.Team.Directory()
+	if err != nil {
+		return err
+	}
+
+	if _, err := FS.Stat(teamDir); os.IsNotExist(err) {
+		if cfg.Team.URL == "" {
+			return fmt.Errorf("team repository %s does not exist and [team] sets no url to clone it from", teamDir)
+		}
+		if err := remoteAllowed(cfg, cfg.Team.URL); err != nil {
+			return err
+		}
+		if err := FS.MkdirAll(filepath.Dir(teamDir), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(teamDir), err)
+		}
+		if err := runNetworkGit("", "clone", cfg.Team.URL, teamDir); err != nil {
+			return fmt.Errorf("failed to clone team repository: %w", err)
+		}
+		if err := verifiedClone(teamDir); err != nil {
+			return err
+		}
+		if err := updateSubmodules(teamDir); err != nil {
+			return fmt.Errorf("failed to update team submodules: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Cloned team repository into %s\n", teamDir)
+		return nil
+	}
+
+	if err := pull(teamDir, true); err != nil {
+		return fmt.Errorf("failed to update team repository: %w", err)
+	}
+	if err := updateSubmodules(teamDir); err != nil {
+		return fmt.Errorf("failed to update team submodules: %w", err)
+	}
+	return nil
+}
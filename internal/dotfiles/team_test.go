@@ -0,0 +1,85 @@
+package dotfiles
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateTeam(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+
+	// setup creates an upstream team repository and a global config naming it
+	setup := func(t *testing.T, team string) (upstream, teamDir string) {
+		tempDir := t.TempDir()
+		upstream = filepath.Join(tempDir, "upstream")
+		teamDir = filepath.Join(tempDir, "data", "team")
+		os.MkdirAll(upstream, 0755)
+		os.WriteFile(filepath.Join(upstream, ".mappings"), []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n"), 0644)
+		testGit(t, upstream, "init", "-q")
+		testGit(t, upstream, "add", ".mappings")
+		testGit(t, upstream, "commit", "-q", "-m", "initial")
+
+		configHome := filepath.Join(tempDir, "config")
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		team = strings.NewReplacer("UPSTREAM", filepath.ToSlash(upstream), "TEAM_DIR", filepath.ToSlash(teamDir)).Replace(team)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte(team), 0644)
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+		return upstream, teamDir
+	}
+
+	t.Run("Clones the team repository, then fast-forwards it", func(t *testing.T) {
+		upstream, teamDir := setup(t, "[team]\nurl = \"UPSTREAM\"\ndir = \"TEAM_DIR\"\n")
+
+		if err := updateTeam(); err != nil {
+			t.Fatalf("updateTeam failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(teamDir, ".mappings")); err != nil {
+			t.Fatalf("Expected the team repository to be cloned: %v", err)
+		}
+
+		os.WriteFile(filepath.Join(upstream, "zshrc"), []byte("zsh"), 0644)
+		testGit(t, upstream, "add", "zshrc")
+		testGit(t, upstream, "commit", "-q", "-m", "add zshrc")
+		if err := updateTeam(); err != nil {
+			t.Fatalf("updateTeam failed: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(teamDir, "zshrc")); string(data) != "zsh" {
+			t.Errorf("Expected the new file to be pulled, got '%s'", data)
+		}
+	})
+
+	t.Run("A missing checkout without url is an error", func(t *testing.T) {
+		setup(t, "[team]\ndir = \"TEAM_DIR/missing\"\n")
+
+		err := updateTeam()
+		if err == nil || !strings.Contains(err.Error(), "sets no url") {
+			t.Errorf("Expected an error about the missing url, got %v", err)
+		}
+	})
+
+	t.Run("Nothing happens without a team repository", func(t *testing.T) {
+		setup(t, "")
+
+		if err := updateTeam(); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+}
+
+// testGit runs git in dir as a test author
+func testGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
@@ -0,0 +1,125 @@
+package dotfiles
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/yourusername/dot/internal/settings"
+)
+
+// verifySignatures reports whether verify_signatures is set in the global config
+func verifySignatures() (bool, error) {
+	cfg, err := settings.Load()
+	if err != nil {
+		return false, err
+	}
+	return cfg.VerifySignatures, nil
+}
+
+// verifyRevision checks that the commit rev of the repository in dir carries a good
+// GPG or SSH signature, or that a tag pointing at it does
+// Which keys are trusted is up to git: the GPG keyring, or gpg.ssh.allowedSignersFile
+func verifyRevision(dir, rev string) error {
+	commit, err := gitOutput(dir, "rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", rev, err)
+	}
+	if runGit(dir, "verify-commit", commit) == nil {
+		return nil
+	}
+
+	tags, _ := gitOutput(dir, "tag", "--points-at", commit)
+	for _, tag := range strings.Fields(tags) {
+		if runGit(dir, "verify-tag", tag) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("commit %.12s of %s has no good signature, and no tag pointing at it has one", commit, dir)
+}
+
+// gitOutput runs git in dir and returns what it printed, trimmed
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// VerifyCheckout refuses the dotfiles repository in dir, or the team repository if one is
+// checked out, when its HEAD is not signed and verify_signatures is set in the global
+// config; otherwise it does nothing
+// Link calls it before changing anything, since a repository's hooks run on the machine
+func VerifyCheckout(dir string) error {
+	cfg, err := settings.Load()
+	if err != nil || !cfg.VerifySignatures {
+		return err
+	}
+
+	dirs := []string{dir}
+	if cfg.Team.Enabled() {
+		teamDir, err := cfg.Team.Directory()
+		if err != nil {
+			return err
+		}
+		if _, err := FS.Stat(teamDir); err == nil {
+			dirs = append(dirs, teamDir)
+		}
+	}
+	for _, dir := range dirs {
+		if err := verifyRevision(dir, "HEAD"); err != nil {
+			return fmt.Errorf("refusing to use unverified repository (verify_signatures is set): %w", err)
+		}
+	}
+	return nil
+}
+
+// verifiedClone verifies a repository just cloned into dir when verify_signatures is set,
+// deleting the clone if its HEAD is not signed so that nothing can be linked from it
+func verifiedClone(dir string) error {
+	verify, err := verifySignatures()
+	if err != nil || !verify {
+		return err
+	}
+	if err := verifyRevision(dir, "HEAD"); err != nil {
+		FS.RemoveAll(dir)
+		return fmt.Errorf("refusing unverified clone, which was removed: %w", err)
+	}
+	return nil
+}
+
+// pull updates the repository in dir from its upstream branch, if allowed_remotes
+// permits its URL
+// With verify_signatures set, the fetched commit is verified before the branch is
+// fast-forwarded to it, leaving the checkout untouched if it is not signed; merges are
+// refused then, since a merge commit made here would carry no signature
+func pull(dir string, ffOnly bool) error {
+	if err := checkUpstream(dir); err != nil {
+		return err
+	}
+	verify, err := verifySignatures()
+	if err != nil {
+		return err
+	}
+	if !verify {
+		args := []string{"pull"}
+		if ffOnly {
+			args = append(args, "--ff-only")
+		}
+		return runNetworkGit(dir, args...)
+	}
+
+	if err := runNetworkGit(dir, "fetch"); err != nil {
+		return err
+	}
+	if err := verifyRevision(dir, "@{upstream}"); err != nil {
+		return fmt.Errorf("refusing to update to unverified commit (verify_signatures is set): %w", err)
+	}
+	return runGit(dir, "merge", "--ff-only", "@{upstream}")
+}
@@ -0,0 +1,129 @@
+package dotfiles
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifySignatures(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	originalGitConfig := os.Getenv("GIT_CONFIG_GLOBAL")
+	defer func() {
+		os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+		os.Setenv("GIT_CONFIG_GLOBAL", originalGitConfig)
+	}()
+
+	// git trusts commits signed with an SSH key made for the test
+	tempDir := t.TempDir()
+	key := filepath.Join(tempDir, "key")
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "test", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("ssh-keygen failed: %v\n%s", err, output)
+	}
+	public, _ := os.ReadFile(key + ".pub")
+	allowed := filepath.Join(tempDir, "allowed_signers")
+	os.WriteFile(allowed, []byte("test@example.com "+string(public)), 0644)
+	gitConfig := filepath.Join(tempDir, "gitconfig")
+	os.WriteFile(gitConfig, []byte("[gpg]\n\tformat = ssh\n[gpg \"ssh\"]\n\tallowedSignersFile = "+filepath.ToSlash(allowed)+"\n[user]\n\tsigningkey = "+filepath.ToSlash(key)+"\n"), 0644)
+	os.Setenv("GIT_CONFIG_GLOBAL", gitConfig)
+
+	// setVerify writes a global dot config with verify_signatures set as given
+	setVerify := func(t *testing.T, verify bool) {
+		configHome := t.TempDir()
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		if verify {
+			os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("verify_signatures = true\n"), 0644)
+		}
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+	}
+
+	// commitFile commits a file to the repository in dir, signed if sign is set
+	commitFile := func(t *testing.T, dir, name string, sign bool) {
+		os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		testGit(t, dir, "add", name)
+		if sign {
+			testGit(t, dir, "commit", "-q", "-S", "-m", name)
+		} else {
+			testGit(t, dir, "commit", "-q", "--no-gpg-sign", "-m", name)
+		}
+	}
+
+	newRepo := func(t *testing.T, sign bool) string {
+		dir := filepath.Join(t.TempDir(), "repo")
+		os.MkdirAll(dir, 0755)
+		testGit(t, dir, "init", "-q")
+		commitFile(t, dir, ".mappings", sign)
+		return dir
+	}
+
+	t.Run("Unsigned checkouts are refused", func(t *testing.T) {
+		setVerify(t, true)
+		dir := newRepo(t, false)
+
+		err := VerifyCheckout(dir)
+		if err == nil || !strings.Contains(err.Error(), "refusing to use unverified repository") {
+			t.Errorf("Expected the checkout to be refused, got %v", err)
+		}
+	})
+
+	t.Run("Unsigned checkouts pass without verify_signatures", func(t *testing.T) {
+		setVerify(t, false)
+		dir := newRepo(t, false)
+
+		if err := VerifyCheckout(dir); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Signed commits pass", func(t *testing.T) {
+		setVerify(t, true)
+		dir := newRepo(t, true)
+
+		if err := VerifyCheckout(dir); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("A signed tag vouches for an unsigned commit", func(t *testing.T) {
+		setVerify(t, true)
+		dir := newRepo(t, false)
+		testGit(t, dir, "tag", "-s", "-m", "release", "v1")
+
+		if err := VerifyCheckout(dir); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Update leaves the checkout alone when the fetched commit is unsigned", func(t *testing.T) {
+		setVerify(t, true)
+		upstream := newRepo(t, true)
+		dir := filepath.Join(t.TempDir(), "clone")
+		testGit(t, "", "clone", "-q", upstream, dir)
+
+		commitFile(t, upstream, "unsigned", false)
+		err := pull(dir, false)
+		if err == nil || !strings.Contains(err.Error(), "refusing to update to unverified commit") {
+			t.Errorf("Expected the update to be refused, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "unsigned")); !os.IsNotExist(err) {
+			t.Error("Expected the unsigned commit not to be checked out")
+		}
+
+		commitFile(t, upstream, "signed", true)
+		if err := pull(dir, false); err != nil {
+			t.Fatalf("Expected the signed commit to be pulled, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "signed")); err != nil {
+			t.Errorf("Expected the signed commit to be checked out: %v", err)
+		}
+	})
+}
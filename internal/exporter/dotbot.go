@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+// Dotbot writes a dotbot install.conf.yaml with link directives equivalent to the profile
+// Entries are sorted by target so the generated file is stable across runs
+func Dotbot(w io.Writer, profile config.Profile) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# Generated by dot export dotbot")
+	fmt.Fprintln(bw, "- defaults:")
+	fmt.Fprintln(bw, "    link:")
+	fmt.Fprintln(bw, "      relink: true")
+	fmt.Fprintln(bw, "      create: true")
+	fmt.Fprintln(bw)
+	fmt.Fprintln(bw, "- clean: ['~']")
+
+	if len(profile) > 0 {
+		fmt.Fprintln(bw)
+		fmt.Fprintln(bw, "- link:")
+
+		targets := make(map[string]string, len(profile))
+		for src, target := range profile {
+			targets[target] = src
+		}
+
+		for _, target := range sortedKeys(targets) {
+			fmt.Fprintf(bw, "    %s: %s\n", strconv.Quote(target), strconv.Quote(targets[target]))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestDotbot(t *testing.T) {
+	t.Run("Link directives sorted by target", func(t *testing.T) {
+		profile := config.Profile{
+			"zsh/.zshrc": "~/.zshrc",
+			"vim/.vimrc": "~/.vimrc",
+		}
+
+		var buf bytes.Buffer
+		if err := Dotbot(&buf, profile); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		output := buf.String()
+
+		vimIndex := strings.Index(output, `"~/.vimrc": "vim/.vimrc"`)
+		zshIndex := strings.Index(output, `"~/.zshrc": "zsh/.zshrc"`)
+		if vimIndex < 0 || zshIndex < 0 {
+			t.Fatalf("Expected link directives, got: %s", output)
+		}
+		if vimIndex > zshIndex {
+			t.Errorf("Expected directives sorted by target, got: %s", output)
+		}
+		if !strings.Contains(output, "relink: true") {
+			t.Errorf("Expected link defaults, got: %s", output)
+		}
+	})
+
+	t.Run("Empty profile has no link section", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := Dotbot(&buf, config.Profile{}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if strings.Contains(buf.String(), "- link:") {
+			t.Errorf("Expected no link section, got: %s", buf.String())
+		}
+	})
+}
+
+func TestExport(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer func() {
+		if originalDotDir != "" {
+			os.Setenv("DOT_DIR", originalDotDir)
+		} else {
+			os.Unsetenv("DOT_DIR")
+		}
+	}()
+
+	t.Run("Writes file relative to dotfiles directory", func(t *testing.T) {
+		dotfilesDir := t.TempDir()
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		mappings := "[general]\n\"vim/.vimrc\" = \"~/.vimrc\"\n\n[work]\n\"git/.gitconfig-work\" = \"~/.gitconfig\"\n"
+		if err := os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644); err != nil {
+			t.Fatalf("Failed to create .mappings: %v", err)
+		}
+
+		if err := Export([]string{"general", "work"}, Dotbot, "install.conf.yaml"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dotfilesDir, "install.conf.yaml"))
+		if err != nil {
+			t.Fatalf("Expected install.conf.yaml to be written: %v", err)
+		}
+		if !strings.Contains(string(data), `"~/.gitconfig": "git/.gitconfig-work"`) {
+			t.Errorf("Expected work mapping in output, got: %s", data)
+		}
+	})
+}
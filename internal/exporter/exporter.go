@@ -0,0 +1,63 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+)
+
+// Format renders a resolved profile in another tool's configuration format
+type Format func(w io.Writer, profile config.Profile) error
+
+// Export renders the resolved profiles with the given format
+// The output is written to the output path, relative paths resolve against the dotfiles
+// directory, and "-" writes to stdout
+func Export(profiles []string, format Format, output string) error {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfig(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	profileMap, err := cfg.GetProfiles(profiles)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(dotfilesDir, output, func(w io.Writer) error {
+		return format(w, profileMap)
+	})
+}
+
+// writeOutput writes with write to the output path, where relative paths resolve against
+// the dotfiles directory and "-" writes to stdout
+func writeOutput(dotfilesDir, output string, write func(w io.Writer) error) error {
+	if output == "-" {
+		return write(os.Stdout)
+	}
+
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(dotfilesDir, output)
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", output, err)
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", output)
+	return nil
+}
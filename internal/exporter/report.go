@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+)
+
+// ReportRow is one mapping as dot report lists it
+type ReportRow struct {
+	Target      string
+	Source      string // as .mappings declares it
+	Mode        string // how link puts the source in place
+	Profile     string
+	Description string
+}
+
+// ReportFormat renders the rows of a mappings report
+type ReportFormat func(w io.Writer, rows []ReportRow) error
+
+// reportHeader names the columns of a mappings report
+var reportHeader = []string{"Target", "Source", "Mode", "Profile", "Description"}
+
+// Report renders the mappings of the given profiles as a table, for sharing the setup with
+// teammates or embedding it in a wiki; output works like it does for Export
+func Report(profiles []string, format ReportFormat, output string) error {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfig(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	rows, err := reportRows(cfg, profiles)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(dotfilesDir, output, func(w io.Writer) error {
+		return format(w, rows)
+	})
+}
+
+// reportRows returns the rows for the mappings of the given profiles, sorted by target
+// and then profile
+func reportRows(cfg *config.Config, profiles []string) ([]ReportRow, error) {
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]ReportRow, 0, len(selected))
+	for _, m := range selected {
+		source, ok := cfg.MappingsSource(m.Profile, m.Source)
+		if !ok {
+			source = m.Source
+		}
+		entry := cfg.Entries[m.Profile][m.Source]
+		mode := entry.Mode
+		if mode == "" {
+			mode = config.ModeAuto
+		}
+		rows = append(rows, ReportRow{
+			Target:      m.Target,
+			Source:      source,
+			Mode:        mode,
+			Profile:     m.Profile,
+			Description: entry.Description,
+		})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Target != rows[j].Target {
+			return rows[i].Target < rows[j].Target
+		}
+		return rows[i].Profile < rows[j].Profile
+	})
+	return rows, nil
+}
+
+// MarkdownReport writes the rows as a Markdown table, with paths as code
+func MarkdownReport(w io.Writer, rows []ReportRow) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "| %s |\n", strings.Join(reportHeader, " | "))
+	fmt.Fprintf(bw, "|%s\n", strings.Repeat(" --- |", len(reportHeader)))
+	for _, r := range rows {
+		fmt.Fprintf(bw, "| %s | %s | %s | %s | %s |\n",
+			markdownCode(r.Target), markdownCode(r.Source), markdownCell(r.Mode), markdownCell(r.Profile), markdownCell(r.Description))
+	}
+
+	return bw.Flush()
+}
+
+// markdownCell escapes text for a Markdown table cell
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(text, "\n", " ")
+}
+
+// markdownCode formats a path as code in a Markdown table cell
+func markdownCode(path string) string {
+	return "`" + strings.ReplaceAll(markdownCell(path), "`", "'") + "`"
+}
+
+// HTMLReport writes the rows as an HTML table that can be embedded in a page
+func HTMLReport(w io.Writer, rows []ReportRow) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "<table>")
+	fmt.Fprintln(bw, "  <thead>")
+	fmt.Fprint(bw, "    <tr>")
+	for _, column := range reportHeader {
+		fmt.Fprintf(bw, "<th>%s</th>", column)
+	}
+	fmt.Fprintln(bw, "</tr>")
+	fmt.Fprintln(bw, "  </thead>")
+	fmt.Fprintln(bw, "  <tbody>")
+	for _, r := range rows {
+		fmt.Fprintf(bw, "    <tr><td><code>%s</code></td><td><code>%s</code></td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(r.Target), html.EscapeString(r.Source), html.EscapeString(r.Mode), html.EscapeString(r.Profile), html.EscapeString(r.Description))
+	}
+	fmt.Fprintln(bw, "  </tbody>")
+	fmt.Fprintln(bw, "</table>")
+
+	return bw.Flush()
+}
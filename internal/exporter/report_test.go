@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestReport(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	dotfilesDir := t.TempDir()
+	os.Setenv("DOT_DIR", dotfilesDir)
+	mappings := "[general]\n\"zsh/.zshrc\" = { target = \"~/.zshrc\", description = \"Shell | prompt\", mode = \"copy\" }\n\"vim/.vimrc\" = \"~/.vimrc\"\n\n[work]\n\"git/.gitconfig-work\" = { target = \"~/.gitconfig\", description = \"<work> identity\" }\n"
+	if err := os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644); err != nil {
+		t.Fatalf("Failed to create .mappings: %v", err)
+	}
+
+	t.Run("Markdown sorted by target", func(t *testing.T) {
+		if err := Report([]string{config.AllProfiles}, MarkdownReport, "report.md"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(dotfilesDir, "report.md"))
+		if err != nil {
+			t.Fatalf("Expected report.md to be written: %v", err)
+		}
+
+		expected := "| Target | Source | Mode | Profile | Description |\n" +
+			"| --- | --- | --- | --- | --- |\n" +
+			"| `~/.gitconfig` | `git/.gitconfig-work` | auto | work | <work> identity |\n" +
+			"| `~/.vimrc` | `vim/.vimrc` | auto | general |  |\n" +
+			"| `~/.zshrc` | `zsh/.zshrc` | copy | general | Shell \\| prompt |\n"
+		if string(data) != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, data)
+		}
+	})
+
+	t.Run("HTML escapes cells", func(t *testing.T) {
+		var buf bytes.Buffer
+		rows := []ReportRow{{Target: "~/.gitconfig", Source: "git/.gitconfig-work", Mode: "link", Profile: "work", Description: "<work> identity"}}
+		if err := HTMLReport(&buf, rows); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if !strings.Contains(buf.String(), "<td><code>~/.gitconfig</code></td><td><code>git/.gitconfig-work</code></td><td>link</td><td>work</td><td>&lt;work&gt; identity</td>") {
+			t.Errorf("Expected an escaped row, got: %s", buf.String())
+		}
+	})
+}
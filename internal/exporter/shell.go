@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+// shellPrelude defines the link function the generated script calls once per mapping
+// It links like dot link: a correct link is left alone, another link is replaced, and a
+// file or directory in the way is moved to <target>.bak, replacing an earlier backup
+const shellPrelude = `#!/bin/sh
+# Generated by dot bundle: recreates the links of your dotfiles without dot
+# Run it from anywhere: sh install.sh
+# Sources are taken from $DOT_DIR, by default the directory of this script
+set -eu
+
+DOT_DIR="${DOT_DIR:-$(cd "$(dirname "$0")" && pwd)}"
+
+link() {
+	src="$DOT_DIR/$1"
+	dst="$2"
+
+	if [ ! -e "$src" ] && [ ! -L "$src" ]; then
+		echo "Warning: Source file does not exist: $src" >&2
+		return 0
+	fi
+
+	if [ -L "$dst" ]; then
+		if [ "$(readlink "$dst")" = "$src" ]; then
+			return 0
+		fi
+		echo "Overriding: $dst (was pointing to $(readlink "$dst"))" >&2
+		rm "$dst"
+	elif [ -e "$dst" ]; then
+		rm -rf "$dst.bak"
+		mv "$dst" "$dst.bak"
+		echo "Backed up: $dst -> $dst.bak" >&2
+	fi
+
+	mkdir -p "$(dirname "$dst")"
+	ln -s "$src" "$dst"
+	echo "Created: $dst -> $src" >&2
+}
+`
+
+// Shell writes a standalone POSIX shell script that creates the links of the profile,
+// for machines where dot itself cannot be installed
+// Entries are sorted by target so the generated script is stable across runs
+func Shell(w io.Writer, profile config.Profile) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, shellPrelude)
+
+	if len(profile) > 0 {
+		fmt.Fprintln(bw)
+
+		targets := make(map[string]string, len(profile))
+		for src, target := range profile {
+			targets[target] = src
+		}
+
+		for _, target := range sortedKeys(targets) {
+			fmt.Fprintf(bw, "link %s %s\n", shellQuote(targets[target]), shellTarget(target))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// shellTarget returns a target path as a shell word, with a leading ~ left to the shell
+// as $HOME so that the script works for any user
+func shellTarget(target string) string {
+	if target == "~" {
+		return `"$HOME"`
+	}
+	if rest, found := strings.CutPrefix(target, "~/"); found {
+		return `"$HOME"/` + shellQuote(rest)
+	}
+	return shellQuote(target)
+}
+
+// shellQuote quotes s as a single shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestShell(t *testing.T) {
+	t.Run("Link calls sorted by target", func(t *testing.T) {
+		profile := config.Profile{
+			"zsh/.zshrc":     "~/.zshrc",
+			"vim/it's.vimrc": "~/.vimrc",
+		}
+
+		var buf bytes.Buffer
+		if err := Shell(&buf, profile); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		output := buf.String()
+
+		expected := "link 'vim/it'\\''s.vimrc' \"$HOME\"/'.vimrc'\nlink 'zsh/.zshrc' \"$HOME\"/'.zshrc'\n"
+		if !strings.HasSuffix(output, expected) {
+			t.Errorf("Expected link calls:\n%s\ngot:\n%s", expected, output)
+		}
+		if !strings.HasPrefix(output, "#!/bin/sh\n") {
+			t.Errorf("Expected a shebang, got: %s", output)
+		}
+	})
+
+	t.Run("Script creates links and backups", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("requires a POSIX shell")
+		}
+
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.MkdirAll(filepath.Join(dotfilesDir, "zsh"), 0755)
+		os.MkdirAll(homeDir, 0755)
+		os.WriteFile(filepath.Join(dotfilesDir, "zsh", ".zshrc"), []byte("zsh"), 0644)
+		os.WriteFile(filepath.Join(dotfilesDir, "gitconfig"), []byte("git"), 0644)
+		os.WriteFile(filepath.Join(homeDir, ".gitconfig"), []byte("old"), 0644)
+
+		var buf bytes.Buffer
+		Shell(&buf, config.Profile{
+			"zsh/.zshrc": "~/.config/zsh/.zshrc",
+			"gitconfig":  "~/.gitconfig",
+			"missing":    "~/.missing",
+		})
+		script := filepath.Join(dotfilesDir, "install.sh")
+		os.WriteFile(script, buf.Bytes(), 0644)
+
+		for range 2 {
+			cmd := exec.Command("sh", script)
+			cmd.Env = append(os.Environ(), "HOME="+homeDir, "DOT_DIR=")
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("Script failed: %v\n%s", err, output)
+			}
+		}
+
+		for target, source := range map[string]string{".config/zsh/.zshrc": "zsh/.zshrc", ".gitconfig": "gitconfig"} {
+			link, err := os.Readlink(filepath.Join(homeDir, target))
+			if err != nil || link != filepath.Join(dotfilesDir, source) {
+				t.Errorf("Expected %s to link to %s, got %q, %v", target, source, link, err)
+			}
+		}
+		if data, _ := os.ReadFile(filepath.Join(homeDir, ".gitconfig.bak")); string(data) != "old" {
+			t.Errorf("Expected the old file to be backed up, got %q", data)
+		}
+		if _, err := os.Lstat(filepath.Join(homeDir, ".missing")); err == nil {
+			t.Error("Expected no link for a missing source")
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package fsys
+
+import (
+	"io/fs"
+	"os"
+)
+
+// FS is the filesystem used by dot for every read and write
+// It mirrors the subset of the os package dot relies on, so operations can run
+// against the real filesystem, an in-memory one in tests, or an overlay for dry runs
+type FS interface {
+	Lstat(name string) (fs.FileInfo, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Readlink(name string) (string, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	MkdirAll(path string, perm fs.FileMode) error
+	Symlink(oldname, newname string) error
+	Remove(name string) error
+	RemoveAll(path string) error
+	Rename(oldpath, newpath string) error
+}
+
+// OS is the FS backed by the real filesystem
+type OS struct{}
+
+// Lstat calls os.Lstat
+func (OS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+
+// Stat calls os.Stat
+func (OS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+// ReadDir calls os.ReadDir
+func (OS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+// Readlink calls os.Readlink
+func (OS) Readlink(name string) (string, error) { return os.Readlink(name) }
+
+// ReadFile calls os.ReadFile
+func (OS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+// WriteFile calls os.WriteFile
+func (OS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// MkdirAll calls os.MkdirAll
+func (OS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+
+// Symlink calls os.Symlink
+func (OS) Symlink(oldname, newname string) error { return os.Symlink(oldname, newname) }
+
+// Remove calls os.Remove
+func (OS) Remove(name string) error { return os.Remove(name) }
+
+// RemoveAll calls os.RemoveAll
+func (OS) RemoveAll(path string) error { return os.RemoveAll(path) }
+
+// Rename calls os.Rename
+func (OS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
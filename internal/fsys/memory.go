@@ -0,0 +1,362 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSymlinkHops bounds symlink resolution, like the kernel's ELOOP limit
+const maxSymlinkHops = 40
+
+var (
+	errNotDir   = errors.New("not a directory")
+	errIsDir    = errors.New("is a directory")
+	errNotEmpty = errors.New("directory not empty")
+	errLoop     = errors.New("too many levels of symbolic links")
+)
+
+// memNode is a file, directory, or symlink in a Memory filesystem
+type memNode struct {
+	mode     fs.FileMode
+	data     []byte
+	target   string
+	modTime  time.Time
+	children map[string]*memNode
+}
+
+// Memory is an in-memory FS for tests; paths are absolute and use the host separator
+type Memory struct {
+	mu   sync.Mutex
+	root *memNode
+}
+
+// NewMemory returns an empty in-memory filesystem containing only the root directory
+func NewMemory() *Memory {
+	return &Memory{root: newDirNode(0755)}
+}
+
+func newDirNode(perm fs.FileMode) *memNode {
+	return &memNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now(), children: make(map[string]*memNode)}
+}
+
+// split returns the cleaned components of an absolute path
+func split(name string) []string {
+	name = filepath.ToSlash(filepath.Clean(strings.TrimPrefix(name, filepath.VolumeName(name))))
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// walk resolves name to its parent directory and final component, following symlinks
+// in every intermediate component, and in the final component when followLast is set
+func (m *Memory) walk(name string, followLast bool) (parent *memNode, base string, node *memNode, err error) {
+	parts := split(name)
+	if len(parts) == 0 {
+		return nil, "", m.root, nil
+	}
+
+	hops := 0
+	stack := []*memNode{m.root}
+
+	for i := 0; i < len(parts); i++ {
+		part := parts[i]
+		dir := stack[len(stack)-1]
+
+		if part == ".." {
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		}
+
+		if !dir.mode.IsDir() {
+			return nil, "", nil, errNotDir
+		}
+
+		child := dir.children[part]
+		last := i == len(parts)-1
+
+		if child != nil && child.mode&fs.ModeSymlink != 0 && (!last || followLast) {
+			hops++
+			if hops > maxSymlinkHops {
+				return nil, "", nil, errLoop
+			}
+			// Replace the symlink component with its target and resolve again
+			rest := append(split(child.target), parts[i+1:]...)
+			if !filepath.IsAbs(child.target) {
+				rest = append(append([]string{}, parts[:i]...), append(split(child.target), parts[i+1:]...)...)
+			}
+			parts = rest
+			stack = []*memNode{m.root}
+			i = -1
+			continue
+		}
+
+		if last {
+			return dir, part, child, nil
+		}
+		if child == nil {
+			return nil, "", nil, fs.ErrNotExist
+		}
+		stack = append(stack, child)
+	}
+
+	// Path resolved to a directory reached through ".." or a symlink
+	return nil, "", stack[len(stack)-1], nil
+}
+
+// lookup resolves name to an existing node
+func (m *Memory) lookup(op, name string, followLast bool) (*memNode, error) {
+	_, _, node, err := m.walk(name, followLast)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if node == nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return node, nil
+}
+
+// parentDir resolves the directory that would contain name
+func (m *Memory) parentDir(op, name string) (*memNode, string, error) {
+	parent, base, _, err := m.walk(name, false)
+	if err != nil {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if parent == nil {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return parent, base, nil
+}
+
+// Lstat returns file info without following a final symlink
+func (m *Memory) Lstat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo(filepath.Base(name), node), nil
+}
+
+// Stat returns file info, following symlinks
+func (m *Memory) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo(filepath.Base(name), node), nil
+}
+
+// ReadDir returns the entries of a directory sorted by name
+func (m *Memory) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(node.children))
+	for childName, child := range node.children {
+		entries = append(entries, fs.FileInfoToDirEntry(fileInfo(childName, child)))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return entries, nil
+}
+
+// Readlink returns the target of a symlink
+func (m *Memory) Readlink(name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("readlink", name, false)
+	if err != nil {
+		return "", err
+	}
+	if node.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return node.target, nil
+}
+
+// ReadFile returns the content of a file, following symlinks
+func (m *Memory) ReadFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+// WriteFile creates or truncates a file, following symlinks
+func (m *Memory) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, base, node, err := m.walk(name, true)
+	if err != nil {
+		return &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if node != nil {
+		if node.mode.IsDir() {
+			return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+		}
+		node.data = append([]byte(nil), data...)
+		node.modTime = time.Now()
+		return nil
+	}
+	if parent == nil {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	parent.children[base] = &memNode{mode: perm.Perm(), data: append([]byte(nil), data...), modTime: time.Now()}
+	return nil
+}
+
+// MkdirAll creates a directory and any missing parents
+func (m *Memory) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parts := split(path)
+	for i := range parts {
+		prefix := string(filepath.Separator) + filepath.Join(parts[:i+1]...)
+
+		parent, base, node, err := m.walk(prefix, true)
+		if err != nil {
+			return &fs.PathError{Op: "mkdir", Path: path, Err: err}
+		}
+		if node != nil {
+			if !node.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: prefix, Err: errNotDir}
+			}
+			continue
+		}
+		parent.children[base] = newDirNode(perm)
+	}
+
+	return nil
+}
+
+// Symlink creates newname as a symlink to oldname
+func (m *Memory) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, base, err := m.parentDir("symlink", newname)
+	if err != nil {
+		return err
+	}
+	if _, exists := parent.children[base]; exists {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrExist}
+	}
+
+	parent.children[base] = &memNode{mode: fs.ModeSymlink | 0777, target: oldname, modTime: time.Now()}
+	return nil
+}
+
+// Remove removes a file, symlink, or empty directory
+func (m *Memory) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, base, err := m.parentDir("remove", name)
+	if err != nil {
+		return err
+	}
+	node := parent.children[base]
+	if node == nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if node.mode.IsDir() && len(node.children) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+
+	delete(parent.children, base)
+	return nil
+}
+
+// RemoveAll removes a path and everything below it; a missing path is not an error
+func (m *Memory) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, base, _, err := m.walk(path, false)
+	if err != nil || parent == nil {
+		return nil
+	}
+
+	delete(parent.children, base)
+	return nil
+}
+
+// Rename moves a node, replacing a non-directory at the destination
+func (m *Memory) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldParent, oldBase, err := m.parentDir("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	node := oldParent.children[oldBase]
+	if node == nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+
+	newParent, newBase, err := m.parentDir("rename", newpath)
+	if err != nil {
+		return err
+	}
+	if existing := newParent.children[newBase]; existing != nil && existing.mode.IsDir() && len(existing.children) > 0 {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errNotEmpty}
+	}
+
+	delete(oldParent.children, oldBase)
+	newParent.children[newBase] = node
+	return nil
+}
+
+// memFileInfo implements fs.FileInfo for memory nodes
+type memFileInfo struct {
+	name string
+	node *memNode
+}
+
+func fileInfo(name string, node *memNode) fs.FileInfo {
+	return memFileInfo{name: name, node: node}
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return int64(len(i.node.data)) }
+func (i memFileInfo) Mode() fs.FileMode  { return i.node.mode }
+func (i memFileInfo) ModTime() time.Time { return i.node.modTime }
+func (i memFileInfo) IsDir() bool        { return i.node.mode.IsDir() }
+func (i memFileInfo) Sys() any           { return nil }
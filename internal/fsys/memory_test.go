@@ -0,0 +1,163 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	t.Run("WriteFile and ReadFile round trip", func(t *testing.T) {
+		m := NewMemory()
+		if err := m.MkdirAll("/home/user", 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := m.WriteFile("/home/user/.zshrc", []byte("zsh"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		data, err := m.ReadFile("/home/user/.zshrc")
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if string(data) != "zsh" {
+			t.Errorf("Expected 'zsh', got '%s'", data)
+		}
+	})
+
+	t.Run("WriteFile requires parent directory", func(t *testing.T) {
+		m := NewMemory()
+		err := m.WriteFile("/missing/file", nil, 0644)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected ErrNotExist, got %v", err)
+		}
+	})
+
+	t.Run("Symlinks are followed by Stat but not Lstat", func(t *testing.T) {
+		m := NewMemory()
+		m.MkdirAll("/dotfiles", 0755)
+		m.MkdirAll("/home", 0755)
+		m.WriteFile("/dotfiles/vimrc", []byte("vim"), 0644)
+		if err := m.Symlink("/dotfiles/vimrc", "/home/.vimrc"); err != nil {
+			t.Fatalf("Symlink failed: %v", err)
+		}
+
+		info, err := m.Lstat("/home/.vimrc")
+		if err != nil {
+			t.Fatalf("Lstat failed: %v", err)
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			t.Error("Expected Lstat to report a symlink")
+		}
+
+		info, err = m.Stat("/home/.vimrc")
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if !info.Mode().IsRegular() {
+			t.Error("Expected Stat to report a regular file")
+		}
+
+		target, err := m.Readlink("/home/.vimrc")
+		if err != nil || target != "/dotfiles/vimrc" {
+			t.Errorf("Expected /dotfiles/vimrc, got %s (%v)", target, err)
+		}
+	})
+
+	t.Run("Symlinked directories resolve intermediate components", func(t *testing.T) {
+		m := NewMemory()
+		m.MkdirAll("/dotfiles/nvim", 0755)
+		m.WriteFile("/dotfiles/nvim/init.lua", []byte("lua"), 0644)
+		m.MkdirAll("/home/.config", 0755)
+		m.Symlink("../../dotfiles/nvim", "/home/.config/nvim")
+
+		data, err := m.ReadFile("/home/.config/nvim/init.lua")
+		if err != nil {
+			t.Fatalf("ReadFile through symlink failed: %v", err)
+		}
+		if string(data) != "lua" {
+			t.Errorf("Expected 'lua', got '%s'", data)
+		}
+	})
+
+	t.Run("Broken symlink", func(t *testing.T) {
+		m := NewMemory()
+		m.Symlink("/nowhere", "/link")
+
+		if _, err := m.Lstat("/link"); err != nil {
+			t.Errorf("Expected Lstat to succeed, got %v", err)
+		}
+		if _, err := m.Stat("/link"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected ErrNotExist, got %v", err)
+		}
+	})
+
+	t.Run("Symlink loop", func(t *testing.T) {
+		m := NewMemory()
+		m.Symlink("/b", "/a")
+		m.Symlink("/a", "/b")
+
+		if _, err := m.Stat("/a"); err == nil {
+			t.Error("Expected error for symlink loop")
+		}
+	})
+
+	t.Run("Symlink does not replace existing entries", func(t *testing.T) {
+		m := NewMemory()
+		m.WriteFile("/file", nil, 0644)
+		if err := m.Symlink("/other", "/file"); !errors.Is(err, fs.ErrExist) {
+			t.Errorf("Expected ErrExist, got %v", err)
+		}
+	})
+
+	t.Run("Remove refuses non-empty directories", func(t *testing.T) {
+		m := NewMemory()
+		m.MkdirAll("/dir/sub", 0755)
+
+		if err := m.Remove("/dir"); err == nil {
+			t.Error("Expected error removing non-empty directory")
+		}
+		if err := m.RemoveAll("/dir"); err != nil {
+			t.Errorf("RemoveAll failed: %v", err)
+		}
+		if _, err := m.Lstat("/dir"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected directory to be removed, got %v", err)
+		}
+	})
+
+	t.Run("Rename moves nodes", func(t *testing.T) {
+		m := NewMemory()
+		m.MkdirAll("/a", 0755)
+		m.MkdirAll("/b", 0755)
+		m.WriteFile("/a/file", []byte("data"), 0644)
+
+		if err := m.Rename("/a/file", "/b/file"); err != nil {
+			t.Fatalf("Rename failed: %v", err)
+		}
+		if _, err := m.Lstat("/a/file"); !errors.Is(err, fs.ErrNotExist) {
+			t.Error("Expected old path to be gone")
+		}
+		if data, _ := m.ReadFile("/b/file"); string(data) != "data" {
+			t.Errorf("Expected 'data', got '%s'", data)
+		}
+	})
+
+	t.Run("ReadDir lists sorted entries", func(t *testing.T) {
+		m := NewMemory()
+		m.MkdirAll("/dir", 0755)
+		m.WriteFile("/dir/b", nil, 0644)
+		m.WriteFile("/dir/a", nil, 0644)
+		m.Symlink("/dir/a", "/dir/c")
+
+		entries, err := m.ReadDir("/dir")
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		if len(entries) != 3 || entries[0].Name() != "a" || entries[2].Name() != "c" {
+			t.Fatalf("Unexpected entries: %v", entries)
+		}
+		if entries[2].Type()&fs.ModeSymlink == 0 {
+			t.Error("Expected c to be a symlink")
+		}
+	})
+}
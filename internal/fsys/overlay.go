@@ -0,0 +1,497 @@
+package fsys
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// overlayNode is a path created or replaced in an Overlay
+// A node with an origin mirrors a path of the base filesystem that was renamed into place
+type overlayNode struct {
+	mode    fs.FileMode
+	data    []byte
+	target  string
+	origin  string
+	modTime time.Time
+}
+
+// Overlay is a copy-on-write view of a base filesystem
+// Reads fall through to the base, while every write is recorded in memory, so a whole
+// run can be simulated against the real filesystem without modifying it
+type Overlay struct {
+	mu       sync.Mutex
+	base     FS
+	nodes    map[string]*overlayNode // path -> node, nil marks a removed path
+	children map[string]map[string]bool
+}
+
+// NewOverlay returns an overlay on top of base with no changes recorded
+func NewOverlay(base FS) *Overlay {
+	return &Overlay{
+		base:     base,
+		nodes:    make(map[string]*overlayNode),
+		children: make(map[string]map[string]bool),
+	}
+}
+
+// record stores node at path, which must be clean
+func (o *Overlay) record(path string, node *overlayNode) {
+	o.nodes[path] = node
+
+	parent := filepath.Dir(path)
+	if o.children[parent] == nil {
+		o.children[parent] = make(map[string]bool)
+	}
+	o.children[parent][filepath.Base(path)] = true
+}
+
+// drop forgets every recorded node strictly below path and returns them keyed by relative path
+func (o *Overlay) drop(path string) map[string]*overlayNode {
+	dropped := make(map[string]*overlayNode)
+	prefix := path + string(filepath.Separator)
+
+	for name, node := range o.nodes {
+		if strings.HasPrefix(name, prefix) {
+			dropped[strings.TrimPrefix(name, prefix)] = node
+			delete(o.nodes, name)
+		}
+	}
+	for dir := range o.children {
+		if dir == path || strings.HasPrefix(dir, prefix) {
+			delete(o.children, dir)
+		}
+	}
+
+	return dropped
+}
+
+// lookup resolves path without following a final symlink
+// It returns the recorded node for path, or the base path that backs it
+func (o *Overlay) lookup(path string) (*overlayNode, string, error) {
+	node, basePath, _, err := o.lookupPath(path)
+	return node, basePath, err
+}
+
+// lookupPath is lookup that also returns path with symlinks in its parent components resolved
+func (o *Overlay) lookupPath(path string) (*overlayNode, string, string, error) {
+	path = filepath.Clean(path)
+
+	for hops := 0; hops <= maxSymlinkHops; hops++ {
+		if node, recorded := o.nodes[path]; recorded {
+			if node == nil {
+				return nil, "", "", fs.ErrNotExist
+			}
+			return node, "", path, nil
+		}
+
+		rewritten := false
+		for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+			node, recorded := o.nodes[dir]
+			if recorded {
+				rel, _ := filepath.Rel(dir, path)
+				switch {
+				case node == nil:
+					return nil, "", "", fs.ErrNotExist
+				case node.mode&fs.ModeSymlink != 0:
+					target := node.target
+					if !filepath.IsAbs(target) {
+						target = filepath.Join(filepath.Dir(dir), target)
+					}
+					path = filepath.Join(target, rel)
+					rewritten = true
+				case node.origin != "":
+					return nil, filepath.Join(node.origin, rel), path, nil
+				case node.mode.IsDir():
+					return nil, "", "", fs.ErrNotExist
+				default:
+					return nil, "", "", errNotDir
+				}
+				break
+			}
+			if dir == filepath.Dir(dir) {
+				break
+			}
+		}
+
+		if !rewritten {
+			return nil, path, path, nil
+		}
+	}
+
+	return nil, "", "", errLoop
+}
+
+// lstat returns file info for path without following a final symlink
+func (o *Overlay) lstat(op, name string) (fs.FileInfo, error) {
+	node, basePath, err := o.lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if node == nil {
+		info, err := o.base.Lstat(basePath)
+		if err != nil {
+			return nil, err
+		}
+		return renamedInfo{FileInfo: info, name: filepath.Base(name)}, nil
+	}
+	if node.origin != "" {
+		info, err := o.base.Lstat(node.origin)
+		if err != nil {
+			return nil, err
+		}
+		return renamedInfo{FileInfo: info, name: filepath.Base(name)}, nil
+	}
+	return overlayInfo{name: filepath.Base(name), node: node}, nil
+}
+
+// resolve follows symlinks in the final component of name
+func (o *Overlay) resolve(op, name string) (string, fs.FileInfo, error) {
+	path := filepath.Clean(name)
+
+	for hops := 0; hops <= maxSymlinkHops; hops++ {
+		info, err := o.lstat(op, path)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, _, resolved, err := o.lookupPath(path); err == nil {
+			path = resolved
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return path, info, nil
+		}
+
+		target, err := o.readlink(path)
+		if err != nil {
+			return "", nil, err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = filepath.Clean(target)
+	}
+
+	return "", nil, &fs.PathError{Op: op, Path: name, Err: errLoop}
+}
+
+// readlink returns the target of the symlink at path
+func (o *Overlay) readlink(path string) (string, error) {
+	node, basePath, err := o.lookup(path)
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: path, Err: err}
+	}
+	if node == nil {
+		return o.base.Readlink(basePath)
+	}
+	if node.origin != "" {
+		return o.base.Readlink(node.origin)
+	}
+	if node.mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: path, Err: fs.ErrInvalid}
+	}
+	return node.target, nil
+}
+
+// readDir returns the merged entries of a directory
+func (o *Overlay) readDir(name string) ([]fs.DirEntry, error) {
+	path, info, err := o.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	merged := make(map[string]fs.DirEntry)
+
+	node, basePath, _ := o.lookup(path)
+	if node != nil && node.origin != "" {
+		basePath = node.origin
+	}
+	if basePath != "" {
+		entries, err := o.base.ReadDir(basePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if removed, recorded := o.nodes[filepath.Join(path, entry.Name())]; recorded && removed == nil {
+				continue
+			}
+			merged[entry.Name()] = entry
+		}
+	}
+
+	for childName := range o.children[path] {
+		child := o.nodes[filepath.Join(path, childName)]
+		if child == nil {
+			delete(merged, childName)
+			continue
+		}
+		info, err := o.lstat("readdir", filepath.Join(path, childName))
+		if err != nil {
+			continue
+		}
+		merged[childName] = fs.FileInfoToDirEntry(info)
+	}
+
+	entries := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	return entries, nil
+}
+
+// canonical returns path with every symlink in its parent directories resolved, so that
+// changes made through a symlinked directory are recorded where they would really land
+func (o *Overlay) canonical(path string) string {
+	path = filepath.Clean(path)
+	dir := filepath.Dir(path)
+	if dir == path {
+		return path
+	}
+	if resolved, _, err := o.resolve("lstat", dir); err == nil {
+		return filepath.Join(resolved, filepath.Base(path))
+	}
+	return path
+}
+
+// requireParent returns an error unless the parent of path is an existing directory
+func (o *Overlay) requireParent(op, path string) error {
+	_, info, err := o.resolve(op, filepath.Dir(path))
+	if err != nil {
+		return &fs.PathError{Op: op, Path: path, Err: fs.ErrNotExist}
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: op, Path: path, Err: errNotDir}
+	}
+	return nil
+}
+
+// Lstat returns file info without following a final symlink
+func (o *Overlay) Lstat(name string) (fs.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.lstat("lstat", name)
+}
+
+// Stat returns file info, following symlinks
+func (o *Overlay) Stat(name string) (fs.FileInfo, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, info, err := o.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return renamedInfo{FileInfo: info, name: filepath.Base(name)}, nil
+}
+
+// ReadDir returns the entries of a directory sorted by name
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.readDir(name)
+}
+
+// Readlink returns the target of a symlink
+func (o *Overlay) Readlink(name string) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.readlink(filepath.Clean(name))
+}
+
+// ReadFile returns the content of a file, following symlinks
+func (o *Overlay) ReadFile(name string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path, info, err := o.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+
+	node, basePath, _ := o.lookup(path)
+	switch {
+	case node == nil:
+		return o.base.ReadFile(basePath)
+	case node.origin != "":
+		return o.base.ReadFile(node.origin)
+	default:
+		return append([]byte(nil), node.data...), nil
+	}
+}
+
+// WriteFile records a new file content, following symlinks
+func (o *Overlay) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path := o.canonical(name)
+	if resolved, info, err := o.resolve("open", name); err == nil {
+		if info.IsDir() {
+			return &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+		}
+		path = resolved
+		perm = info.Mode().Perm()
+	} else if err := o.requireParent("open", path); err != nil {
+		return err
+	}
+
+	o.record(path, &overlayNode{mode: perm.Perm(), data: append([]byte(nil), data...), modTime: time.Now()})
+	return nil
+}
+
+// MkdirAll records a directory and any missing parents
+func (o *Overlay) MkdirAll(path string, perm fs.FileMode) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path = filepath.Clean(path)
+
+	var missing []string
+	for dir := path; ; dir = filepath.Dir(dir) {
+		_, info, err := o.resolve("mkdir", dir)
+		if err == nil {
+			if !info.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: errNotDir}
+			}
+			break
+		}
+		missing = append(missing, dir)
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		o.record(missing[i], &overlayNode{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()})
+	}
+	return nil
+}
+
+// Symlink records newname as a symlink to oldname
+func (o *Overlay) Symlink(oldname, newname string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path := o.canonical(newname)
+	if _, err := o.lstat("symlink", path); err == nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrExist}
+	}
+	if err := o.requireParent("symlink", path); err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+
+	o.drop(path)
+	o.record(path, &overlayNode{mode: fs.ModeSymlink | 0777, target: oldname, modTime: time.Now()})
+	return nil
+}
+
+// Remove records the removal of a file, symlink, or empty directory
+func (o *Overlay) Remove(name string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path := o.canonical(name)
+	info, err := o.lstat("remove", path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := o.readDir(path)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+		}
+	}
+
+	o.drop(path)
+	o.record(path, nil)
+	return nil
+}
+
+// RemoveAll records the removal of a path and everything below it
+func (o *Overlay) RemoveAll(path string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path = o.canonical(path)
+	if _, err := o.lstat("remove", path); err != nil {
+		return nil
+	}
+
+	o.drop(path)
+	o.record(path, nil)
+	return nil
+}
+
+// Rename records moving a path, replacing a non-directory at the destination
+func (o *Overlay) Rename(oldpath, newpath string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	oldpath, newpath = o.canonical(oldpath), o.canonical(newpath)
+
+	node, basePath, err := o.lookup(oldpath)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	if node == nil {
+		if _, err := o.base.Lstat(basePath); err != nil {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+		}
+		node = &overlayNode{origin: basePath}
+	}
+	if err := o.requireParent("rename", newpath); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if info, err := o.lstat("rename", newpath); err == nil && info.IsDir() {
+		if entries, _ := o.readDir(newpath); len(entries) > 0 {
+			return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errNotEmpty}
+		}
+	}
+
+	moved := o.drop(oldpath)
+	o.record(oldpath, nil)
+	o.drop(newpath)
+	o.record(newpath, node)
+	for rel, child := range moved {
+		o.record(filepath.Join(newpath, rel), child)
+	}
+	return nil
+}
+
+// overlayInfo implements fs.FileInfo for recorded nodes
+type overlayInfo struct {
+	name string
+	node *overlayNode
+}
+
+func (i overlayInfo) Name() string       { return i.name }
+func (i overlayInfo) Size() int64        { return int64(len(i.node.data)) }
+func (i overlayInfo) Mode() fs.FileMode  { return i.node.mode }
+func (i overlayInfo) ModTime() time.Time { return i.node.modTime }
+func (i overlayInfo) IsDir() bool        { return i.node.mode.IsDir() }
+func (i overlayInfo) Sys() any           { return nil }
+
+// renamedInfo reports base file info under the name it has in the overlay
+type renamedInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (i renamedInfo) Name() string { return i.name }
@@ -0,0 +1,110 @@
+package fsys
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	newBase := func() *Memory {
+		base := NewMemory()
+		base.MkdirAll("/home/.config", 0755)
+		base.MkdirAll("/dotfiles/nvim", 0755)
+		base.WriteFile("/home/.zshrc", []byte("old"), 0644)
+		base.WriteFile("/dotfiles/nvim/init.lua", []byte("lua"), 0644)
+		return base
+	}
+
+	t.Run("Reads fall through to base", func(t *testing.T) {
+		o := NewOverlay(newBase())
+
+		data, err := o.ReadFile("/home/.zshrc")
+		if err != nil || string(data) != "old" {
+			t.Errorf("Expected 'old', got '%s' (%v)", data, err)
+		}
+	})
+
+	t.Run("Writes do not reach base", func(t *testing.T) {
+		base := newBase()
+		o := NewOverlay(base)
+
+		if err := o.MkdirAll("/home/.local/bin", 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := o.Symlink("/dotfiles/nvim", "/home/.local/bin/nvim"); err != nil {
+			t.Fatalf("Symlink failed: %v", err)
+		}
+		if err := o.Remove("/home/.zshrc"); err != nil {
+			t.Fatalf("Remove failed: %v", err)
+		}
+
+		if _, err := o.Lstat("/home/.zshrc"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected removed file to be hidden, got %v", err)
+		}
+		if _, err := base.Lstat("/home/.zshrc"); err != nil {
+			t.Errorf("Expected base file to remain, got %v", err)
+		}
+		if _, err := base.Lstat("/home/.local"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected base to have no new directory, got %v", err)
+		}
+	})
+
+	t.Run("ReadDir merges base and overlay entries", func(t *testing.T) {
+		o := NewOverlay(newBase())
+		o.WriteFile("/home/.bashrc", nil, 0644)
+		o.Remove("/home/.zshrc")
+
+		entries, err := o.ReadDir("/home")
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		if len(names) != 2 || names[0] != ".bashrc" || names[1] != ".config" {
+			t.Errorf("Expected [.bashrc .config], got %v", names)
+		}
+	})
+
+	t.Run("Rename keeps base content under the new name", func(t *testing.T) {
+		o := NewOverlay(newBase())
+
+		if err := o.Rename("/dotfiles/nvim", "/dotfiles/nvim.bak"); err != nil {
+			t.Fatalf("Rename failed: %v", err)
+		}
+
+		data, err := o.ReadFile("/dotfiles/nvim.bak/init.lua")
+		if err != nil || string(data) != "lua" {
+			t.Errorf("Expected 'lua', got '%s' (%v)", data, err)
+		}
+		if _, err := o.Lstat("/dotfiles/nvim/init.lua"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected old path to be gone, got %v", err)
+		}
+	})
+
+	t.Run("Paths through overlay symlinks resolve", func(t *testing.T) {
+		o := NewOverlay(newBase())
+		o.Symlink("/dotfiles/nvim", "/home/.config/nvim")
+
+		data, err := o.ReadFile("/home/.config/nvim/init.lua")
+		if err != nil || string(data) != "lua" {
+			t.Errorf("Expected 'lua', got '%s' (%v)", data, err)
+		}
+
+		if err := o.Symlink("/dotfiles/lazy.lua", "/home/.config/nvim/lazy.lua"); err != nil {
+			t.Fatalf("Symlink through link failed: %v", err)
+		}
+		if target, err := o.Readlink("/dotfiles/nvim/lazy.lua"); err != nil || target != "/dotfiles/lazy.lua" {
+			t.Errorf("Expected link inside linked directory, got %s (%v)", target, err)
+		}
+	})
+
+	t.Run("Symlink requires an existing parent", func(t *testing.T) {
+		o := NewOverlay(newBase())
+		if err := o.Symlink("/dotfiles/nvim", "/missing/nvim"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Expected ErrNotExist, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Bare converts a bare repository whose work tree is the home directory, the "dotfiles
+// alias" setup of git --git-dir=~/.cfg --work-tree=$HOME, into a normal checkout in the
+// dotfiles directory that keeps its history and origin remote
+// Every tracked file is mapped into [general] at the same path under ~; the checkout holds
+// the files as they are in the home directory, so uncommitted edits show up as changes
+func Bare(homeDir, gitDir, dotfilesDir string) (*Result, error) {
+	if out, err := git("--git-dir", gitDir, "rev-parse", "--is-bare-repository"); err != nil || strings.TrimSpace(out) != "true" {
+		return nil, fmt.Errorf("%s is not a bare git repository", gitDir)
+	}
+	if entries, err := os.ReadDir(dotfilesDir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("dotfiles directory %s already exists and is not empty", dotfilesDir)
+	}
+
+	files, err := trackedFiles(gitDir, homeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := git("clone", "--quiet", gitDir, dotfilesDir); err != nil {
+		return nil, fmt.Errorf("failed to clone %s: %w", gitDir, err)
+	}
+	// The checkout pushes to where the bare repository did, not to the bare repository
+	if url, err := git("--git-dir", gitDir, "remote", "get-url", "origin"); err == nil {
+		if _, err := git("-C", dotfilesDir, "remote", "set-url", "origin", strings.TrimSpace(url)); err != nil {
+			return nil, fmt.Errorf("failed to set the origin remote: %w", err)
+		}
+	}
+
+	result := newResult()
+	for _, file := range files {
+		if file == ".mappings" {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s (dot keeps its own .mappings there)", file))
+			continue
+		}
+
+		src := filepath.Join(homeDir, filepath.FromSlash(file))
+		if utils.FileExists(src) {
+			if err := utils.CopyFile(src, filepath.Join(dotfilesDir, filepath.FromSlash(file))); err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%v)", file, err))
+				continue
+			}
+		}
+
+		result.add("general", file, "~/"+file)
+		result.Copied++
+	}
+
+	if err := config.WriteConfig(dotfilesDir, result.Profiles); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// trackedFiles lists the files a repository tracks, relative to its work tree
+func trackedFiles(gitDir, workTree string) ([]string, error) {
+	output, err := git("--git-dir", gitDir, "--work-tree", workTree, "ls-files", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked files: %w", err)
+	}
+
+	var files []string
+	for _, file := range strings.Split(output, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// git runs a git command and returns its output, or its error output on failure
+func git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s", message)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestBare(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	// A bare repository in ~/.cfg tracks two files, one of them edited since the commit
+	setup := func(t *testing.T) (homeDir, gitDir, dotfilesDir string) {
+		tempDir := t.TempDir()
+		homeDir = filepath.Join(tempDir, "home")
+		gitDir = filepath.Join(homeDir, ".cfg")
+		dotfilesDir = filepath.Join(tempDir, "dotfiles")
+
+		// run runs git against the bare repository with the home directory as its work tree
+		run := func(args ...string) {
+			cmd := exec.Command("git", append([]string{"--git-dir", gitDir, "--work-tree", homeDir}, args...)...)
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, output)
+			}
+		}
+
+		os.MkdirAll(filepath.Join(homeDir, ".config", "nvim"), 0755)
+		os.WriteFile(filepath.Join(homeDir, ".bashrc"), []byte("bash"), 0644)
+		os.WriteFile(filepath.Join(homeDir, ".config", "nvim", "init.lua"), []byte("lua"), 0644)
+		os.WriteFile(filepath.Join(homeDir, "notes.txt"), []byte("untracked"), 0644)
+		if err := exec.Command("git", "init", "-q", "--bare", gitDir).Run(); err != nil {
+			t.Fatalf("Failed to create bare repository: %v", err)
+		}
+		run("remote", "add", "origin", "git@example.com:me/dotfiles.git")
+		run("add", ".bashrc", ".config/nvim/init.lua")
+		run("commit", "-q", "-m", "initial")
+		os.WriteFile(filepath.Join(homeDir, ".bashrc"), []byte("bash edited"), 0644)
+		return homeDir, gitDir, dotfilesDir
+	}
+
+	t.Run("Materializes a checkout with mappings", func(t *testing.T) {
+		homeDir, gitDir, dotfilesDir := setup(t)
+
+		result, err := Bare(homeDir, gitDir, dotfilesDir)
+		if err != nil {
+			t.Fatalf("Bare failed: %v", err)
+		}
+		if result.Copied != 2 {
+			t.Errorf("Expected 2 files, got %d", result.Copied)
+		}
+
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Expected generated .mappings to parse, got: %v", err)
+		}
+		expected := config.Profile{".bashrc": "~/.bashrc", ".config/nvim/init.lua": "~/.config/nvim/init.lua"}
+		if len(cfg.Profiles["general"]) != len(expected) {
+			t.Errorf("Expected %v, got %v", expected, cfg.Profiles["general"])
+		}
+		for source, target := range expected {
+			if cfg.Profiles["general"][source] != target {
+				t.Errorf("Expected %s to map to %s, got %v", source, target, cfg.Profiles["general"])
+			}
+		}
+
+		if data, _ := os.ReadFile(filepath.Join(dotfilesDir, ".bashrc")); string(data) != "bash edited" {
+			t.Errorf("Expected the edited .bashrc, got '%s'", data)
+		}
+		if log, _ := exec.Command("git", "-C", dotfilesDir, "log", "--oneline").Output(); !strings.Contains(string(log), "initial") {
+			t.Errorf("Expected the history to be kept, got %s", log)
+		}
+		if url, _ := exec.Command("git", "-C", dotfilesDir, "remote", "get-url", "origin").Output(); strings.TrimSpace(string(url)) != "git@example.com:me/dotfiles.git" {
+			t.Errorf("Expected the origin of the bare repository, got %s", url)
+		}
+	})
+
+	t.Run("Refuses a non-empty dotfiles directory", func(t *testing.T) {
+		homeDir, gitDir, dotfilesDir := setup(t)
+		os.MkdirAll(dotfilesDir, 0755)
+		os.WriteFile(filepath.Join(dotfilesDir, "README.md"), []byte("readme"), 0644)
+
+		if _, err := Bare(homeDir, gitDir, dotfilesDir); err == nil || !strings.Contains(err.Error(), "not empty") {
+			t.Errorf("Expected a non-empty directory error, got %v", err)
+		}
+	})
+
+	t.Run("Refuses a work tree repository", func(t *testing.T) {
+		dir := t.TempDir()
+		exec.Command("git", "init", "-q", dir).Run()
+
+		if _, err := Bare(dir, filepath.Join(dir, ".git"), filepath.Join(dir, "dotfiles")); err == nil || !strings.Contains(err.Error(), "not a bare git repository") {
+			t.Errorf("Expected a not bare error, got %v", err)
+		}
+	})
+}
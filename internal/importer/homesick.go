@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+// Homesick generates a .mappings file for a homesick/homeshick castle in place
+// Entries under the castle's home/ directory are mapped to the same path under ~,
+// so the castle keeps its git history and can be used directly as the dotfiles directory
+// Directories listed in .homesick_subdir have their children mapped instead of the directory itself
+func Homesick(castleDir string) (*Result, error) {
+	homeDir := filepath.Join(castleDir, "home")
+	if stat, err := os.Stat(homeDir); err != nil || !stat.IsDir() {
+		return nil, fmt.Errorf("%s is not a homesick castle (missing home/ directory)", castleDir)
+	}
+
+	if err := ensureEmptyRepo(castleDir); err != nil {
+		return nil, err
+	}
+
+	subdirs, err := readHomesickSubdirs(castleDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newResult()
+	if err := addHomesickEntries(result, homeDir, "", subdirs); err != nil {
+		return nil, err
+	}
+
+	if err := config.WriteConfig(castleDir, result.Profiles); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// addHomesickEntries maps the entries of home/<rel>, descending into configured subdirs
+func addHomesickEntries(result *Result, homeDir, rel string, subdirs map[string]bool) error {
+	entries, err := os.ReadDir(filepath.Join(homeDir, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Errorf("failed to read castle directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		entryRel := path.Join(rel, entry.Name())
+
+		if entry.IsDir() && subdirs[entryRel] {
+			if err := addHomesickEntries(result, homeDir, entryRel, subdirs); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result.add("general", "home/"+entryRel, "~/"+entryRel)
+	}
+
+	return nil
+}
+
+// readHomesickSubdirs reads the castle's .homesick_subdir file, if any
+func readHomesickSubdirs(castleDir string) (map[string]bool, error) {
+	subdirs := make(map[string]bool)
+
+	file, err := os.Open(filepath.Join(castleDir, ".homesick_subdir"))
+	if os.IsNotExist(err) {
+		return subdirs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .homesick_subdir: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.Trim(strings.TrimSpace(scanner.Text()), "/")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Every parent of a listed subdir is traversed as well
+		parts := strings.Split(line, "/")
+		for i := range parts {
+			subdirs[strings.Join(parts[:i+1], "/")] = true
+		}
+	}
+
+	return subdirs, scanner.Err()
+}
@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestHomesick(t *testing.T) {
+	t.Run("Maps home entries in place", func(t *testing.T) {
+		castleDir := t.TempDir()
+		files := []string{
+			"home/.vimrc",
+			"home/.config/nvim/init.lua",
+			"home/.config/git/config",
+			"home/.bin/tool",
+		}
+		for _, file := range files {
+			path := filepath.Join(castleDir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+				t.Fatalf("Failed to create %s: %v", file, err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(castleDir, ".homesick_subdir"), []byte(".config\n"), 0644); err != nil {
+			t.Fatalf("Failed to create .homesick_subdir: %v", err)
+		}
+
+		result, err := Homesick(castleDir)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result.Copied != 0 {
+			t.Errorf("Expected entries mapped in place not to count as copied, got %d", result.Copied)
+		}
+
+		cfg, err := config.ParseConfig(castleDir)
+		if err != nil {
+			t.Fatalf("Expected generated .mappings to parse, got: %v", err)
+		}
+		general := cfg.Profiles["general"]
+
+		expected := map[string]string{
+			"home/.vimrc":       "~/.vimrc",
+			"home/.bin":         "~/.bin",
+			"home/.config/nvim": "~/.config/nvim",
+			"home/.config/git":  "~/.config/git",
+		}
+		if len(general) != len(expected) {
+			t.Errorf("Expected %d mappings, got %v", len(expected), general)
+		}
+		for src, target := range expected {
+			if general[src] != target {
+				t.Errorf("Expected %s -> %s, got %s", src, target, general[src])
+			}
+		}
+
+		// Source files must stay in place
+		if _, err := os.Stat(filepath.Join(castleDir, "home", ".vimrc")); err != nil {
+			t.Errorf("Expected castle files to remain in place: %v", err)
+		}
+	})
+
+	t.Run("Error when home directory is missing", func(t *testing.T) {
+		if _, err := Homesick(t.TempDir()); err == nil {
+			t.Error("Expected error for directory without home/")
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Result describes the outcome of an import
+type Result struct {
+	Profiles map[string]config.Profile
+	// Copied and Moved count the files copied or moved into the dotfiles directory; an
+	// import that maps files in place has neither
+	Copied  int
+	Moved   int
+	Skipped []string
+}
+
+// newResult returns an empty Result with a [general] profile
+func newResult() *Result {
+	return &Result{
+		Profiles: map[string]config.Profile{"general": {}},
+	}
+}
+
+// add registers a source -> target mapping under the given profile
+func (r *Result) add(profile, source, target string) {
+	if _, exists := r.Profiles[profile]; !exists {
+		r.Profiles[profile] = make(config.Profile)
+	}
+	r.Profiles[profile][source] = target
+}
+
+// ensureEmptyRepo verifies that the dotfiles directory has no .mappings file yet
+// and creates the directory if needed
+func ensureEmptyRepo(dotfilesDir string) error {
+	if utils.FileExists(filepath.Join(dotfilesDir, ".mappings")) {
+		return fmt.Errorf("dotfiles directory %s already contains a .mappings file", dotfilesDir)
+	}
+
+	if err := os.MkdirAll(dotfilesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create dotfiles directory: %w", err)
+	}
+
+	return nil
+}
+
+// PrintSummary prints the profiles and counts produced by an import
+func PrintSummary(result *Result, dotfilesDir string) {
+	for _, name := range profileNames(result.Profiles) {
+		fmt.Fprintf(os.Stderr, "[%s] %d mapping(s)\n", name, len(result.Profiles[name]))
+	}
+	for _, skipped := range result.Skipped {
+		utils.FprintfColor(os.Stderr, "yellow", "Warning: Skipped %s\n", skipped)
+	}
+	switch {
+	case result.Copied > 0:
+		utils.FprintfColor(os.Stderr, "green", "Imported %d file(s) into %s\n", result.Copied, dotfilesDir)
+	case result.Moved > 0:
+		utils.FprintfColor(os.Stderr, "green", "Moved %d file(s) into %s\n", result.Moved, dotfilesDir)
+	default:
+		utils.FprintfColor(os.Stderr, "green", "Mapped the files in place in %s\n", dotfilesDir)
+	}
+	fmt.Fprintln(os.Stderr, "Run 'dot link --dry-run' to preview the resulting links")
+}
+
+// profileNames returns profile names with general first and the rest sorted
+func profileNames(profiles map[string]config.Profile) []string {
+	names := []string{"general"}
+	rest := make([]string, 0, len(profiles))
+	for name := range profiles {
+		if name != "general" {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
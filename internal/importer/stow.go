@@ -0,0 +1,313 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// stowIgnoreFile is the file of a stow package listing what stow leaves alone
+const stowIgnoreFile = ".stow-local-ignore"
+
+// stowDefaultIgnore is what stow ignores when neither the package nor the user has an
+// ignore list of their own
+var stowDefaultIgnore = []string{
+	`RCS`, `.+,v`, `CVS`, `\.\#.+`, `\.cvsignore`, `\.svn`, `_darcs`, `\.hg`,
+	`\.git`, `\.gitignore`, `\.gitmodules`, `.+~`, `\#.*\#`,
+	`^/README.*`, `^/LICENSE.*`, `^/COPYING`,
+}
+
+// StowOptions configures how a stow directory is imported
+type StowOptions struct {
+	// Dotfiles turns a dot- prefix of a name into a dot in its target, like stow --dotfiles
+	Dotfiles bool
+	// Move moves the packages into the dotfiles directory instead of generating
+	// .mappings in the stow directory in place
+	Move bool
+}
+
+// Stow generates a .mappings file for the packages of a GNU stow directory, each of
+// whose top-level directories mirrors targetDir
+// Like stow's tree folding, a directory of a package is mapped as a whole unless it is
+// a real directory in targetDir already or more than one package has it, in which case
+// its entries are mapped one by one
+// All packages go into [general]; without opts.Move the stow directory keeps its git
+// history and becomes the dotfiles directory, with it the packages are moved to dotfilesDir
+func Stow(stowDir, targetDir, dotfilesDir string, opts StowOptions) (*Result, error) {
+	entries, err := os.ReadDir(stowDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stow directory: %w", err)
+	}
+
+	repoDir := stowDir
+	if opts.Move {
+		repoDir = dotfilesDir
+	}
+	if err := ensureEmptyRepo(repoDir); err != nil {
+		return nil, err
+	}
+
+	var packages []*stowPackage
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		pkg, err := readStowPackage(filepath.Join(stowDir, entry.Name()), opts.Dotfiles)
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, pkg)
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("%s is not a stow directory (no package directories)", stowDir)
+	}
+
+	// Directories that several packages have are shared, so stow never folds them
+	owners := make(map[string]int)
+	for _, pkg := range packages {
+		for dir := range pkg.dirs {
+			owners[dir]++
+		}
+	}
+
+	result := newResult()
+	for _, pkg := range packages {
+		for _, file := range pkg.mappings(targetDir, owners) {
+			result.add("general", pkg.name+"/"+file.source, utils.ContractTarget(filepath.Join(targetDir, filepath.FromSlash(file.target))))
+		}
+		result.Skipped = append(result.Skipped, pkg.ignored...)
+	}
+
+	if opts.Move && !utils.SamePath(stowDir, dotfilesDir) {
+		moved, err := moveStowPackages(packages, dotfilesDir)
+		if err != nil {
+			return nil, err
+		}
+		result.Moved = moved
+		if err := config.WriteConfig(repoDir, result.Profiles); err != nil {
+			restoreStowPackages(packages, dotfilesDir)
+			return nil, err
+		}
+		return result, nil
+	}
+
+	if err := config.WriteConfig(repoDir, result.Profiles); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// moveStowPackages moves the packages into dotfilesDir and returns how many files they
+// hold; if one cannot be moved, those moved before it are moved back, so a failed import
+// leaves the stow directory as it was
+func moveStowPackages(packages []*stowPackage, dotfilesDir string) (int, error) {
+	files := 0
+	for i, pkg := range packages {
+		count := countFiles(pkg.dir)
+		if err := utils.MovePath(pkg.dir, filepath.Join(dotfilesDir, pkg.name)); err != nil {
+			restoreStowPackages(packages[:i], dotfilesDir)
+			return 0, fmt.Errorf("failed to move package %s: %w", pkg.name, err)
+		}
+		files += count
+	}
+	return files, nil
+}
+
+// restoreStowPackages moves packages that moveStowPackages moved back to the stow directory
+func restoreStowPackages(packages []*stowPackage, dotfilesDir string) {
+	for i := len(packages) - 1; i >= 0; i-- {
+		pkg := packages[i]
+		if err := utils.MovePath(filepath.Join(dotfilesDir, pkg.name), pkg.dir); err != nil {
+			utils.FprintfColor(os.Stderr, "red", "Error moving package %s back to %s: %v\n", pkg.name, pkg.dir, err)
+		}
+	}
+}
+
+// countFiles returns how many files, other than directories, lie beneath dir
+func countFiles(dir string) int {
+	count := 0
+	filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+// stowPackage is a package directory of a stow directory
+type stowPackage struct {
+	name    string
+	dir     string
+	entries []stowEntry     // every file and directory of the package, parents first
+	dirs    map[string]bool // the target paths of the package's directories
+	ignored []string        // the paths the ignore list left out, for the summary
+}
+
+// stowEntry is a file or directory of a stow package
+type stowEntry struct {
+	source string // the path in the package, with slashes
+	target string // the path in the target directory, with slashes
+	dir    bool
+}
+
+// readStowPackage lists the entries of a stow package that its ignore list lets through
+func readStowPackage(dir string, dotfiles bool) (*stowPackage, error) {
+	ignore, err := readStowIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &stowPackage{name: filepath.Base(dir), dir: dir, dirs: make(map[string]bool)}
+	var walk func(source, target string) error
+	walk = func(source, target string) error {
+		entries, err := os.ReadDir(filepath.Join(dir, filepath.FromSlash(source)))
+		if err != nil {
+			return fmt.Errorf("failed to read package %s: %w", pkg.name, err)
+		}
+		for _, entry := range entries {
+			entrySource := path.Join(source, entry.Name())
+			if entrySource == stowIgnoreFile {
+				continue
+			}
+			if ignore.matches(entrySource) {
+				pkg.ignored = append(pkg.ignored, fmt.Sprintf("%s/%s (ignored like stow does)", pkg.name, entrySource))
+				continue
+			}
+
+			name := entry.Name()
+			if dotfiles && strings.HasPrefix(name, "dot-") {
+				name = "." + strings.TrimPrefix(name, "dot-")
+			}
+			entryTarget := path.Join(target, name)
+			pkg.entries = append(pkg.entries, stowEntry{source: entrySource, target: entryTarget, dir: entry.IsDir()})
+			if entry.IsDir() {
+				pkg.dirs[entryTarget] = true
+				if err := walk(entrySource, entryTarget); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk("", ""); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
+
+// mappings returns the entries of the package to map, folding every directory that
+// is neither shared with another package nor a real directory in targetDir
+func (p *stowPackage) mappings(targetDir string, owners map[string]int) []stowEntry {
+	var mapped []stowEntry
+	folded := make(map[string]bool)
+	for _, entry := range p.entries {
+		if folded[path.Dir(entry.target)] {
+			if entry.dir {
+				folded[entry.target] = true
+			}
+			continue
+		}
+		if entry.dir && (owners[entry.target] > 1 || isRealDir(filepath.Join(targetDir, filepath.FromSlash(entry.target)))) {
+			continue
+		}
+		if entry.dir {
+			folded[entry.target] = true
+		}
+		mapped = append(mapped, entry)
+	}
+	sort.Slice(mapped, func(i, j int) bool { return mapped[i].source < mapped[j].source })
+	return mapped
+}
+
+// isRealDir reports whether path is a directory and not a link to one, like those
+// stow folds a package's directory into
+func isRealDir(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir()
+}
+
+// stowIgnore is the ignore list of a stow package: patterns with a slash match the
+// path in the package, given with a leading slash, the others match any name
+type stowIgnore struct {
+	paths []*regexp.Regexp
+	names []*regexp.Regexp
+}
+
+// readStowIgnore reads the ignore list of the package in dir: its .stow-local-ignore,
+// or else ~/.stow-global-ignore, or else stow's default list
+func readStowIgnore(dir string) (*stowIgnore, error) {
+	lines := stowDefaultIgnore
+	for _, file := range []string{filepath.Join(dir, stowIgnoreFile), utils.ExpandPath("~/.stow-global-ignore")} {
+		read, err := readIgnoreLines(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		lines = read
+		break
+	}
+
+	ignore := &stowIgnore{}
+	for _, line := range lines {
+		// Like stow, patterns match whole names or paths
+		re, err := regexp.Compile("^(?:" + strings.TrimPrefix(line, "^") + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid stow ignore pattern %q in %s: %w", line, filepath.Base(dir), err)
+		}
+		if strings.Contains(line, "/") {
+			ignore.paths = append(ignore.paths, re)
+		} else {
+			ignore.names = append(ignore.names, re)
+		}
+	}
+	return ignore, nil
+}
+
+// readIgnoreLines reads the patterns of an ignore file, leaving out blank lines and
+// # comments
+func readIgnoreLines(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// matches reports whether the ignore list leaves out the entry at source, a path in
+// the package with slashes
+func (i *stowIgnore) matches(source string) bool {
+	for _, re := range i.paths {
+		if re.MatchString("/" + source) {
+			return true
+		}
+	}
+	for _, re := range i.names {
+		if re.MatchString(path.Base(source)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestStow(t *testing.T) {
+	originalHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", originalHome)
+
+	// setup creates a stow directory and the home directory its packages are stowed into
+	setup := func(t *testing.T) (stowDir, homeDir string) {
+		homeDir = t.TempDir()
+		os.Setenv("HOME", homeDir)
+		stowDir = filepath.Join(homeDir, "dotfiles")
+		files := []string{
+			"vim/.vimrc",
+			"nvim/.config/nvim/init.lua",
+			"git/.config/git/config",
+			"zsh/dot-zshrc",
+			"zsh/README.md",
+			"scripts/.local/bin/tool",
+		}
+		for _, file := range files {
+			path := filepath.Join(stowDir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+				t.Fatalf("Failed to create %s: %v", file, err)
+			}
+		}
+		// Like any home directory, it has a ~/.local/bin of its own
+		if err := os.MkdirAll(filepath.Join(homeDir, ".local", "bin"), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		return stowDir, homeDir
+	}
+
+	expected := map[string]string{
+		"vim/.vimrc":              "~/.vimrc",
+		"nvim/.config/nvim":       "~/.config/nvim",
+		"git/.config/git":         "~/.config/git",
+		"zsh/dot-zshrc":           "~/.zshrc",
+		"scripts/.local/bin/tool": "~/.local/bin/tool",
+	}
+	verify := func(t *testing.T, dotfilesDir string) {
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Expected generated .mappings to parse, got: %v", err)
+		}
+		general := cfg.Profiles["general"]
+		if len(general) != len(expected) {
+			t.Errorf("Expected %d mappings, got %v", len(expected), general)
+		}
+		for src, target := range expected {
+			if general[src] != target {
+				t.Errorf("Expected %s -> %s, got %s", src, target, general[src])
+			}
+		}
+	}
+
+	t.Run("Maps packages in place, folding directories like stow", func(t *testing.T) {
+		stowDir, homeDir := setup(t)
+
+		result, err := Stow(stowDir, homeDir, filepath.Join(homeDir, ".dotfiles"), StowOptions{Dotfiles: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		verify(t, stowDir)
+
+		if len(result.Skipped) != 1 {
+			t.Errorf("Expected README.md to be skipped, got %v", result.Skipped)
+		}
+		if result.Copied != 0 || result.Moved != 0 {
+			t.Errorf("Expected no files to be copied or moved, got %d and %d", result.Copied, result.Moved)
+		}
+		if _, err := os.Stat(filepath.Join(stowDir, "vim", ".vimrc")); err != nil {
+			t.Errorf("Expected package files to remain in place: %v", err)
+		}
+	})
+
+	t.Run("Moves packages into the dotfiles directory", func(t *testing.T) {
+		stowDir, homeDir := setup(t)
+		dotfilesDir := filepath.Join(homeDir, ".dotfiles")
+
+		result, err := Stow(stowDir, homeDir, dotfilesDir, StowOptions{Dotfiles: true, Move: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		verify(t, dotfilesDir)
+
+		// README.md is moved along with its package, though it is not mapped
+		if result.Moved != 6 {
+			t.Errorf("Expected 6 moved files, got %d", result.Moved)
+		}
+
+		if _, err := os.Stat(filepath.Join(dotfilesDir, "nvim", ".config", "nvim", "init.lua")); err != nil {
+			t.Errorf("Expected packages to be moved: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(stowDir, "nvim")); !os.IsNotExist(err) {
+			t.Errorf("Expected packages to leave the stow directory, got %v", err)
+		}
+	})
+
+	t.Run("A package that cannot be moved moves the others back", func(t *testing.T) {
+		stowDir, homeDir := setup(t)
+		dotfilesDir := filepath.Join(homeDir, ".dotfiles")
+		// zsh, the last package, collides with a file that is already there
+		if err := os.MkdirAll(dotfilesDir, 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dotfilesDir, "zsh"), []byte("file"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+
+		if _, err := Stow(stowDir, homeDir, dotfilesDir, StowOptions{Move: true}); err == nil {
+			t.Fatal("Expected the move to fail")
+		}
+		for _, pkg := range []string{"git", "nvim", "scripts", "vim", "zsh"} {
+			if _, err := os.Stat(filepath.Join(stowDir, pkg)); err != nil {
+				t.Errorf("Expected package %s to be back in the stow directory: %v", pkg, err)
+			}
+			if pkg != "zsh" {
+				if _, err := os.Stat(filepath.Join(dotfilesDir, pkg)); !os.IsNotExist(err) {
+					t.Errorf("Expected package %s to leave the dotfiles directory, got %v", pkg, err)
+				}
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dotfilesDir, ".mappings")); !os.IsNotExist(err) {
+			t.Errorf("Expected no .mappings, got %v", err)
+		}
+	})
+
+	t.Run("A package's ignore list replaces the default one", func(t *testing.T) {
+		stowDir, homeDir := setup(t)
+		if err := os.WriteFile(filepath.Join(stowDir, "vim", ".stow-local-ignore"), []byte("# Not for stow\n\\.vimrc\n"), 0644); err != nil {
+			t.Fatalf("Failed to create .stow-local-ignore: %v", err)
+		}
+
+		if _, err := Stow(stowDir, homeDir, filepath.Join(homeDir, ".dotfiles"), StowOptions{}); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		cfg, err := config.ParseConfig(stowDir)
+		if err != nil {
+			t.Fatalf("Expected generated .mappings to parse, got: %v", err)
+		}
+		general := cfg.Profiles["general"]
+		if _, mapped := general["vim/.vimrc"]; mapped {
+			t.Error("Expected .vimrc to be ignored")
+		}
+		if general["zsh/dot-zshrc"] != "~/dot-zshrc" {
+			t.Errorf("Expected dot- prefixes to be kept without Dotfiles, got %v", general)
+		}
+	})
+
+	t.Run("Error when there are no packages", func(t *testing.T) {
+		if _, err := Stow(t.TempDir(), t.TempDir(), t.TempDir(), StowOptions{}); err == nil {
+			t.Error("Expected error for directory without packages")
+		}
+	})
+}
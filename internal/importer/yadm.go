@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// yadmAttributes maps yadm alternate attribute names (long and short) to canonical names
+var yadmAttributes = map[string]string{
+	"arch": "arch", "a": "arch",
+	"class": "class", "c": "class",
+	"distro": "distro", "d": "distro",
+	"distro_family": "distro_family", "f": "distro_family",
+	"hostname": "hostname", "h": "hostname",
+	"os": "os", "o": "os",
+	"user": "user", "u": "user",
+	"extension": "extension", "e": "extension",
+}
+
+// YadmRepoPath returns the default location of the yadm repository
+// yadm stores its repo under $XDG_DATA_HOME/yadm/repo.git, falling back to ~/.config/yadm/repo.git
+func YadmRepoPath(homeDir string) string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+
+	repo := filepath.Join(dataHome, "yadm", "repo.git")
+	if !utils.FileExists(repo) {
+		legacy := filepath.Join(homeDir, ".config", "yadm", "repo.git")
+		if utils.FileExists(legacy) {
+			return legacy
+		}
+	}
+
+	return repo
+}
+
+// Yadm imports files tracked by a yadm repository into the dotfiles directory
+func Yadm(homeDir, repoDir, dotfilesDir string) (*Result, error) {
+	if !utils.FileExists(repoDir) {
+		return nil, fmt.Errorf("yadm repository not found at %s", repoDir)
+	}
+
+	files, err := trackedFiles(repoDir, homeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return ImportYadmFiles(homeDir, dotfilesDir, files)
+}
+
+// ImportYadmFiles copies the given home-relative yadm tracked files into the dotfiles
+// directory and writes a .mappings file with profiles derived from alternate conditions
+func ImportYadmFiles(homeDir, dotfilesDir string, files []string) (*Result, error) {
+	if err := ensureEmptyRepo(dotfilesDir); err != nil {
+		return nil, err
+	}
+
+	result := newResult()
+
+	for _, file := range files {
+		target, profile, err := ParseYadmPath(file)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%v)", file, err))
+			continue
+		}
+
+		src := filepath.Join(homeDir, file)
+		dst := filepath.Join(dotfilesDir, file)
+		if err := utils.CopyFile(src, dst); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%v)", file, err))
+			continue
+		}
+
+		result.add(profile, filepath.ToSlash(file), "~/"+filepath.ToSlash(target))
+		result.Copied++
+	}
+
+	if err := config.WriteConfig(dotfilesDir, result.Profiles); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ParseYadmPath strips yadm alternate suffixes (file##os.Darwin,hostname.box) from every
+// path component and returns the plain target path and the profile derived from the conditions
+// Paths without conditions, or marked ##default, belong to the general profile
+func ParseYadmPath(path string) (string, string, error) {
+	components := strings.Split(filepath.ToSlash(path), "/")
+	var profileParts []string
+
+	for i, component := range components {
+		base, conditions, found := strings.Cut(component, "##")
+		if !found {
+			continue
+		}
+		if base == "" {
+			return "", "", fmt.Errorf("invalid alternate name %q", component)
+		}
+		components[i] = base
+
+		for _, condition := range strings.Split(conditions, ",") {
+			part, err := yadmProfilePart(condition)
+			if err != nil {
+				return "", "", err
+			}
+			if part != "" {
+				profileParts = append(profileParts, part)
+			}
+		}
+	}
+
+	profile := "general"
+	if len(profileParts) > 0 {
+		profile = strings.Join(profileParts, "-")
+	}
+
+	return filepath.FromSlash(strings.Join(components, "/")), profile, nil
+}
+
+// yadmProfilePart converts a single yadm condition into a profile name fragment
+func yadmProfilePart(condition string) (string, error) {
+	condition = strings.TrimSpace(condition)
+
+	switch condition {
+	case "", "default":
+		return "", nil
+	case "template", "t":
+		return "", fmt.Errorf("yadm templates are not supported")
+	}
+
+	if strings.HasPrefix(condition, "~") {
+		return "", fmt.Errorf("negated condition %q is not supported", condition)
+	}
+
+	name, value, _ := strings.Cut(condition, ".")
+	attribute, known := yadmAttributes[name]
+	if !known {
+		if name == "template" || name == "t" {
+			return "", fmt.Errorf("yadm templates are not supported")
+		}
+		return "", fmt.Errorf("unknown alternate condition %q", condition)
+	}
+	if value == "" {
+		return "", fmt.Errorf("alternate condition %q has no value", condition)
+	}
+
+	value = strings.ToLower(value)
+	switch attribute {
+	case "extension":
+		return "", nil
+	case "hostname":
+		return "host-" + value, nil
+	case "user":
+		return "user-" + value, nil
+	default:
+		return value, nil
+	}
+}
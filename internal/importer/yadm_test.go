@@ -0,0 +1,114 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestParseYadmPath(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		expectedTarget  string
+		expectedProfile string
+		expectError     bool
+	}{
+		{"Plain file", ".bashrc", ".bashrc", "general", false},
+		{"Default alternate", ".bashrc##default", ".bashrc", "general", false},
+		{"OS alternate", ".bashrc##os.Darwin", ".bashrc", "darwin", false},
+		{"Short attribute names", ".gitconfig##c.Work", ".gitconfig", "work", false},
+		{"Hostname alternate", ".vimrc##hostname.box", ".vimrc", "host-box", false},
+		{"Combined conditions", ".zshrc##os.Linux,class.Work", ".zshrc", "linux-work", false},
+		{"Directory alternate", ".config/app##os.Linux/conf", ".config/app/conf", "linux", false},
+		{"Extension is ignored", "script##os.Linux,e.sh", "script", "linux", false},
+		{"Template is unsupported", ".gitconfig##template", "", "", true},
+		{"Unknown condition", ".gitconfig##bogus.x", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, profile, err := ParseYadmPath(tt.input)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if target != filepath.FromSlash(tt.expectedTarget) {
+				t.Errorf("Expected target %s, got %s", tt.expectedTarget, target)
+			}
+			if profile != tt.expectedProfile {
+				t.Errorf("Expected profile %s, got %s", tt.expectedProfile, profile)
+			}
+		})
+	}
+}
+
+func TestImportYadmFiles(t *testing.T) {
+	t.Run("Copies files and writes profiles", func(t *testing.T) {
+		tempDir := t.TempDir()
+		homeDir := filepath.Join(tempDir, "home")
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+
+		files := map[string]string{
+			".bashrc":             "bash",
+			".gitconfig##default": "git",
+			".gitconfig##c.Work":  "git work",
+			".vimrc##template":    "tmpl",
+		}
+		for name, content := range files {
+			path := filepath.Join(homeDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatalf("Failed to create directory: %v", err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+
+		result, err := ImportYadmFiles(homeDir, dotfilesDir, []string{".bashrc", ".gitconfig##default", ".gitconfig##c.Work", ".vimrc##template"})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if result.Copied != 3 {
+			t.Errorf("Expected 3 copied files, got %d", result.Copied)
+		}
+		if len(result.Skipped) != 1 {
+			t.Errorf("Expected template to be skipped, got %v", result.Skipped)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dotfilesDir, ".gitconfig##c.Work"))
+		if err != nil || string(data) != "git work" {
+			t.Errorf("Expected alternate to be copied, got %q (%v)", data, err)
+		}
+
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Expected generated .mappings to parse, got: %v", err)
+		}
+		if cfg.Profiles["general"][".gitconfig##default"] != "~/.gitconfig" {
+			t.Errorf("Expected default alternate in general, got %v", cfg.Profiles["general"])
+		}
+		if cfg.Profiles["work"][".gitconfig##c.Work"] != "~/.gitconfig" {
+			t.Errorf("Expected class alternate in work, got %v", cfg.Profiles["work"])
+		}
+	})
+
+	t.Run("Refuses to overwrite existing .mappings", func(t *testing.T) {
+		tempDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(tempDir, ".mappings"), []byte("[general]\n"), 0644); err != nil {
+			t.Fatalf("Failed to create .mappings: %v", err)
+		}
+
+		if _, err := ImportYadmFiles(tempDir, tempDir, nil); err == nil {
+			t.Error("Expected error when .mappings already exists")
+		}
+	})
+}
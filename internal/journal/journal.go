@@ -0,0 +1,234 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/dot/internal/xdg"
+)
+
+// Operations recorded in the journal
+const (
+	OpCreateLink = "create_link" // a symlink was created at Path pointing to Target
+	OpRemoveLink = "remove_link" // the symlink at Path pointing to Target was removed
+	OpBackup     = "backup"      // the file at Path was moved to the backup Target
+	OpMkdir      = "mkdir"       // the directory at Path was created
+	OpRestore    = "restore"     // the backup Target was moved back to Path
+	OpRmdir      = "rmdir"       // the empty directory at Path was removed
+	OpMove       = "move"        // the file at Path was moved to Target, into the repository when adopted
+	OpMap        = "map"         // the mapping Path -> Target was added to .mappings
+	OpCopy       = "copy"        // the source Target was copied to Path because a symlink was not permitted
+	OpRemoveCopy = "remove_copy" // the copy of the source Target at Path was removed
+	OpAdoptCopy  = "adopt_copy"  // the edits to the copy at Path were copied back to its source Target
+	OpDelete     = "delete"      // the backup at Path was deleted for good
+)
+
+// undoableCommands are the runs whose actions undo knows how to revert
+// prune is not among them: the backups it deletes are gone
+var undoableCommands = map[string]bool{
+	"link":   true,
+	"clean":  true,
+	"unlink": true,
+}
+
+// Action is a single filesystem change made by a run
+type Action struct {
+	Op     string    `json:"op"`
+	Path   string    `json:"path"`
+	Target string    `json:"target,omitempty"`
+	Time   time.Time `json:"time,omitzero"`
+}
+
+// NewAction returns an action stamped with the current time
+func NewAction(op, path, target string) Action {
+	return Action{Op: op, Path: path, Target: target, Time: time.Now()}
+}
+
+// Entry is one mutating run of dot
+type Entry struct {
+	ID       int       `json:"id"`
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command"`
+	Profiles []string  `json:"profiles,omitempty"`
+	Actions  []Action  `json:"actions"`
+	Undoes   []int     `json:"undoes,omitempty"`
+}
+
+// Path returns the location of the journal file
+// It lives under $XDG_STATE_HOME/dot, falling back to ~/.local/state/dot
+func Path() (string, error) {
+	return xdg.State.Path("history.jsonl")
+}
+
+// Load returns all journal entries, oldest first
+// A missing journal is not an error
+func Load() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse journal line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Append assigns the next ID and timestamp to entry and appends it to the journal
+func Append(entry Entry) (Entry, error) {
+	entries, err := Load()
+	if err != nil {
+		return entry, err
+	}
+
+	entry.ID = 1
+	if len(entries) > 0 {
+		entry.ID = entries[len(entries)-1].ID + 1
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	path, err := Path()
+	if err != nil {
+		return entry, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return entry, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return entry, fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return entry, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return entry, fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	return entry, nil
+}
+
+// Relocate rewrites the paths of the journal's actions that lie in from, a file or
+// directory of dot's own that was moved to to, such as the backup store, so that undo
+// finds them at their new place
+func Relocate(from, to string) error {
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	relocate := func(path string) string {
+		if path == from {
+			changed = true
+			return to
+		}
+		if rest, found := strings.CutPrefix(path, from+string(filepath.Separator)); found {
+			changed = true
+			return filepath.Join(to, rest)
+		}
+		return path
+	}
+	for i := range entries {
+		for j := range entries[i].Actions {
+			action := &entries[i].Actions[j]
+			action.Path, action.Target = relocate(action.Path), relocate(action.Target)
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode journal entry: %w", err)
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Record appends a mutating run to the journal
+// Runs without actions are not recorded, and a journal that cannot be written only
+// produces a warning, since the run itself already happened
+func Record(command string, profiles []string, actions []Action) {
+	if len(actions) == 0 {
+		return
+	}
+	if _, err := Append(Entry{Command: command, Profiles: profiles, Actions: actions}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record %s in journal: %v\n", command, err)
+	}
+}
+
+// Undoable returns the link, clean, and unlink entries that can still be undone, most recent first
+// Runs that were already undone are excluded
+func Undoable(entries []Entry) []Entry {
+	undone := make(map[int]bool)
+	for _, entry := range entries {
+		for _, id := range entry.Undoes {
+			undone[id] = true
+		}
+	}
+
+	var result []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if !undoableCommands[entry.Command] || undone[entry.ID] || len(entry.Actions) == 0 {
+			continue
+		}
+		result = append(result, entry)
+	}
+
+	return result
+}
@@ -0,0 +1,186 @@
+package journal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer os.Setenv("XDG_STATE_HOME", originalStateHome)
+
+	t.Run("Uses XDG_STATE_HOME", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", "/tmp/state")
+
+		path, err := Path()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := filepath.Join("/tmp/state", "dot", "history.jsonl")
+		if path != expected {
+			t.Errorf("Expected %s, got %s", expected, path)
+		}
+	})
+
+	t.Run("Falls back to ~/.local/state", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", "")
+		homeDir, _ := os.UserHomeDir()
+
+		path, err := Path()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := filepath.Join(homeDir, ".local", "state", "dot", "history.jsonl")
+		if path != expected {
+			t.Errorf("Expected %s, got %s", expected, path)
+		}
+	})
+}
+
+func TestAppendAndLoad(t *testing.T) {
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer os.Setenv("XDG_STATE_HOME", originalStateHome)
+
+	t.Run("Missing journal is empty", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+		entries, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("Expected no entries, got %d", len(entries))
+		}
+	})
+
+	t.Run("Entries get increasing IDs", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+		first, err := Append(Entry{Command: "link", Actions: []Action{{Op: OpCreateLink, Path: "/home/.zshrc", Target: "/dotfiles/zshrc"}}})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		second, err := Append(Entry{Command: "clean", Actions: []Action{{Op: OpRemoveLink, Path: "/home/.zshrc", Target: "/dotfiles/zshrc"}}})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		if first.ID != 1 || second.ID != 2 {
+			t.Errorf("Expected IDs 1 and 2, got %d and %d", first.ID, second.ID)
+		}
+
+		entries, err := Load()
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d", len(entries))
+		}
+		if entries[1].Command != "clean" || entries[1].Actions[0].Target != "/dotfiles/zshrc" {
+			t.Errorf("Unexpected entry: %+v", entries[1])
+		}
+		if entries[0].Time.IsZero() {
+			t.Error("Expected entry time to be set")
+		}
+	})
+}
+
+func TestRelocate(t *testing.T) {
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer os.Setenv("XDG_STATE_HOME", originalStateHome)
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	oldStore := filepath.Join("/home", "me", ".local", "share", "dot", "backups")
+	newStore := filepath.Join("/data", "dot", "backups")
+	backup := filepath.Join(oldStore, "20240102-150405", "home", "me", ".zshrc")
+	Append(Entry{Command: "link", Actions: []Action{
+		{Op: OpBackup, Path: "/home/me/.zshrc", Target: backup},
+		{Op: OpCreateLink, Path: "/home/me/.zshrc", Target: oldStore + "-elsewhere"},
+	}})
+
+	if err := Relocate(oldStore, newStore); err != nil {
+		t.Fatalf("Relocate failed: %v", err)
+	}
+	entries, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	t.Run("Paths in the moved directory are rewritten", func(t *testing.T) {
+		expected := filepath.Join(newStore, "20240102-150405", "home", "me", ".zshrc")
+		if got := entries[0].Actions[0].Target; got != expected {
+			t.Errorf("Expected %s, got %s", expected, got)
+		}
+		if got := entries[0].Actions[0].Path; got != "/home/me/.zshrc" {
+			t.Errorf("Expected /home/me/.zshrc, got %s", got)
+		}
+	})
+
+	t.Run("Paths merely sharing a prefix are kept", func(t *testing.T) {
+		if got := entries[0].Actions[1].Target; got != oldStore+"-elsewhere" {
+			t.Errorf("Expected %s, got %s", oldStore+"-elsewhere", got)
+		}
+	})
+}
+
+func TestUndoable(t *testing.T) {
+	action := []Action{{Op: OpCreateLink, Path: "/a", Target: "/b"}}
+	entries := []Entry{
+		{ID: 1, Command: "link", Actions: action},
+		{ID: 2, Command: "link", Actions: action},
+		{ID: 3, Command: "clean", Actions: action},
+		{ID: 4, Command: "undo", Actions: action, Undoes: []int{3}},
+		{ID: 5, Command: "link"},
+	}
+
+	undoable := Undoable(entries)
+	if len(undoable) != 2 {
+		t.Fatalf("Expected 2 undoable entries, got %d", len(undoable))
+	}
+	if undoable[0].ID != 2 || undoable[1].ID != 1 {
+		t.Errorf("Expected entries 2 and 1, got %d and %d", undoable[0].ID, undoable[1].ID)
+	}
+}
+
+func TestLog(t *testing.T) {
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer os.Setenv("XDG_STATE_HOME", originalStateHome)
+
+	t.Run("Empty history", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+		var buf bytes.Buffer
+		if err := Log(&buf, 0, false); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+		if !strings.Contains(buf.String(), "No history recorded yet") {
+			t.Errorf("Expected empty history message, got: %s", buf.String())
+		}
+	})
+
+	t.Run("Newest first with limit and actions", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+		Record("link", []string{"general", "work"}, []Action{NewAction(OpCreateLink, "/home/.zshrc", "/dotfiles/zshrc")})
+		Record("clean", []string{"general"}, []Action{NewAction(OpRemoveLink, "/home/.zshrc", "/dotfiles/zshrc")})
+		Record("link", nil, nil)
+
+		var buf bytes.Buffer
+		if err := Log(&buf, 1, true); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+		output := buf.String()
+
+		if !strings.HasPrefix(output, "#2") || strings.Contains(output, "#1 ") {
+			t.Errorf("Expected only the newest entry, got: %s", output)
+		}
+		if !strings.Contains(output, "clean") || !strings.Contains(output, "[general]") {
+			t.Errorf("Expected command and profiles, got: %s", output)
+		}
+		if !strings.Contains(output, "unlinked   /home/.zshrc (was -> /dotfiles/zshrc)") {
+			t.Errorf("Expected action details, got: %s", output)
+		}
+	})
+}
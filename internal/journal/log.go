@@ -0,0 +1,87 @@
+package journal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Log prints the most recent journal entries, newest first
+// A limit of 0 prints every entry; verbose also prints each entry's actions
+func Log(w io.Writer, limit int, verbose bool) error {
+	entries, err := Load()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No history recorded yet")
+		return nil
+	}
+
+	printed := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		if limit > 0 && printed == limit {
+			break
+		}
+		printEntry(w, entries[i], verbose)
+		printed++
+	}
+
+	return nil
+}
+
+// printEntry prints a one-line summary of an entry, followed by its actions when verbose
+func printEntry(w io.Writer, entry Entry, verbose bool) {
+	summary := fmt.Sprintf("#%-4d %s  %-6s", entry.ID, entry.Time.Local().Format("2006-01-02 15:04:05"), entry.Command)
+	if len(entry.Profiles) > 0 {
+		summary += fmt.Sprintf("  [%s]", strings.Join(entry.Profiles, ","))
+	}
+	if len(entry.Undoes) > 0 {
+		ids := make([]string, len(entry.Undoes))
+		for i, id := range entry.Undoes {
+			ids[i] = fmt.Sprintf("#%d", id)
+		}
+		summary += "  undid " + strings.Join(ids, ", ")
+	}
+	summary += fmt.Sprintf("  %d change(s)", len(entry.Actions))
+	fmt.Fprintln(w, summary)
+
+	if !verbose {
+		return
+	}
+	for _, action := range entry.Actions {
+		fmt.Fprintf(w, "      %s\n", Describe(action))
+	}
+}
+
+// Describe returns a human readable description of an action
+func Describe(action Action) string {
+	switch action.Op {
+	case OpCreateLink:
+		return fmt.Sprintf("linked     %s -> %s", action.Path, action.Target)
+	case OpRemoveLink:
+		return fmt.Sprintf("unlinked   %s (was -> %s)", action.Path, action.Target)
+	case OpBackup:
+		return fmt.Sprintf("backed up  %s -> %s", action.Path, action.Target)
+	case OpRestore:
+		return fmt.Sprintf("restored   %s -> %s", action.Target, action.Path)
+	case OpMkdir:
+		return fmt.Sprintf("created    %s", action.Path)
+	case OpRmdir:
+		return fmt.Sprintf("removed    %s", action.Path)
+	case OpMove:
+		return fmt.Sprintf("moved      %s -> %s", action.Path, action.Target)
+	case OpMap:
+		return fmt.Sprintf("mapped     %s -> %s", action.Path, action.Target)
+	case OpCopy:
+		return fmt.Sprintf("copied     %s <- %s", action.Path, action.Target)
+	case OpRemoveCopy:
+		return fmt.Sprintf("removed    %s (copy of %s)", action.Path, action.Target)
+	case OpAdoptCopy:
+		return fmt.Sprintf("adopted    %s -> %s", action.Path, action.Target)
+	case OpDelete:
+		return fmt.Sprintf("deleted    %s", action.Path)
+	}
+	return fmt.Sprintf("%-10s %s %s", action.Op, action.Path, action.Target)
+}
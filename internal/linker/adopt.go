@@ -0,0 +1,55 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// AdoptFiles adopts existing files or directories such as ~/.zshrc into the dotfiles
+// repository under the given profile, see Adopt
+// dest is written like a .mappings source; it names a directory to move the targets
+// into, keeping their names, when it ends with a slash, already is a directory of the
+// repository, or more than one target is given, and otherwise the source of the only target
+// Every target is tried; the error counts the ones that could not be adopted
+func AdoptFiles(targets []string, dest, profile string) error {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	intoDir := strings.HasSuffix(dest, "/") || len(targets) > 1
+	dest = cfg.RepoSource(profile, path.Clean(filepath.ToSlash(dest)))
+	if dest == ".." || strings.HasPrefix(dest, "../") {
+		return fmt.Errorf("%s is outside the dotfiles directory", dest)
+	}
+	if info, err := FS.Stat(filepath.Join(dotfilesDir, filepath.FromSlash(dest))); err == nil && info.IsDir() {
+		intoDir = true
+	}
+
+	failed := 0
+	for _, target := range targets {
+		source := dest
+		if intoDir {
+			source = path.Join(dest, filepath.Base(utils.ExpandTarget(target)))
+		}
+		if err := Adopt(target, filepath.FromSlash(source), profile); err != nil {
+			utils.FprintfColor(os.Stderr, "red", "Error adopting %s: %v\n", target, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to adopt %d of %d file(s)", failed, len(targets))
+	}
+	return nil
+}
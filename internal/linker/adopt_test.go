@@ -0,0 +1,111 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestAdoptFiles(t *testing.T) {
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+	FS = fsys.OS{}
+
+	// setup creates a home directory with .zshrc and .zshenv and an empty repository
+	setup := func(t *testing.T, mappings string) (string, string) {
+		tempDir := t.TempDir()
+		homeDir := filepath.Join(tempDir, "home")
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		os.Setenv("HOME", homeDir)
+		os.Setenv("DOT_DIR", dotfilesDir)
+		os.MkdirAll(homeDir, 0755)
+		os.MkdirAll(dotfilesDir, 0755)
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+		os.WriteFile(filepath.Join(homeDir, ".zshrc"), []byte("zshrc"), 0644)
+		os.WriteFile(filepath.Join(homeDir, ".zshenv"), []byte("zshenv"), 0644)
+		return homeDir, dotfilesDir
+	}
+
+	// adopted reports whether target links to source and source is mapped in profile
+	adopted := func(t *testing.T, dotfilesDir, profile, target, source string) {
+		t.Helper()
+		if link, err := os.Readlink(target); err != nil || link != filepath.Join(dotfilesDir, source) {
+			t.Errorf("Expected %s to link to %s, got %s (%v)", target, source, link, err)
+		}
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Failed to parse config: %v", err)
+		}
+		if _, exists := cfg.Profiles[profile][filepath.ToSlash(source)]; !exists {
+			t.Errorf("Expected %s in [%s], got %v", source, profile, cfg.Profiles[profile])
+		}
+	}
+
+	t.Run("Directory destination keeps the file name", func(t *testing.T) {
+		homeDir, dotfilesDir := setup(t, "[general]\n")
+
+		captureOutput(t, func() {
+			if err := AdoptFiles([]string{"~/.zshrc"}, "zsh/", "work"); err != nil {
+				t.Fatalf("AdoptFiles failed: %v", err)
+			}
+		})
+		adopted(t, dotfilesDir, "work", filepath.Join(homeDir, ".zshrc"), "zsh/.zshrc")
+	})
+
+	t.Run("Source destination renames the file", func(t *testing.T) {
+		homeDir, dotfilesDir := setup(t, "[general]\n")
+
+		captureOutput(t, func() {
+			if err := AdoptFiles([]string{"~/.zshrc"}, "zsh/rc", "general"); err != nil {
+				t.Fatalf("AdoptFiles failed: %v", err)
+			}
+		})
+		adopted(t, dotfilesDir, "general", filepath.Join(homeDir, ".zshrc"), "zsh/rc")
+	})
+
+	t.Run("Several targets go into the directory", func(t *testing.T) {
+		homeDir, dotfilesDir := setup(t, "source_root = \"home\"\n\n[general]\n")
+
+		captureOutput(t, func() {
+			if err := AdoptFiles([]string{"~/.zshrc", "~/.zshenv"}, "zsh", "general"); err != nil {
+				t.Fatalf("AdoptFiles failed: %v", err)
+			}
+		})
+		adopted(t, dotfilesDir, "general", filepath.Join(homeDir, ".zshrc"), "home/zsh/.zshrc")
+		adopted(t, dotfilesDir, "general", filepath.Join(homeDir, ".zshenv"), "home/zsh/.zshenv")
+	})
+
+	t.Run("Failures are counted and the rest adopted", func(t *testing.T) {
+		homeDir, dotfilesDir := setup(t, "[general]\n")
+
+		var err error
+		output := captureOutput(t, func() {
+			err = AdoptFiles([]string{"~/.missing", "~/.zshrc"}, "zsh/", "general")
+		})
+		if err == nil || err.Error() != "failed to adopt 1 of 2 file(s)" {
+			t.Errorf("Expected one failure, got %v", err)
+		}
+		if !strings.Contains(output, "Error adopting ~/.missing") {
+			t.Errorf("Expected the failure to be reported, got: %s", output)
+		}
+		adopted(t, dotfilesDir, "general", filepath.Join(homeDir, ".zshrc"), "zsh/.zshrc")
+	})
+
+	t.Run("Destination outside the repository is refused", func(t *testing.T) {
+		setup(t, "[general]\n")
+
+		if err := AdoptFiles([]string{"~/.zshrc"}, "../elsewhere/", "general"); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// hostname returns the short, lower-cased hostname of the current machine
+var hostname = func() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return strings.ToLower(name)
+}
+
+// ResolveAlternate returns the sibling alternate of sourcePath that best matches the
+// current machine, or sourcePath itself when no alternate matches
+// Alternates are named <source>##<conditions> where conditions are comma-separated
+// os.<GOOS> or host.<hostname> entries that must all match; host matches win over os matches
+// A <source>##default alternate is used when nothing matches and the plain source is absent
+func ResolveAlternate(sourcePath string) string {
+	return newDirCache(FS).resolveAlternate(sourcePath)
+}
+
+// alternateScore scores how well alternate conditions match the current machine
+// Returns 0 when any condition does not match
+func alternateScore(conditions, host string) int {
+	score := 0
+
+	for _, condition := range strings.Split(conditions, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(condition), ".")
+		value = strings.ToLower(value)
+
+		switch name {
+		case "os", "o":
+			if value != runtime.GOOS {
+				return 0
+			}
+			score++
+		case "host", "hostname", "h":
+			if value == "" || value != host {
+				return 0
+			}
+			score += 2
+		default:
+			return 0
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,113 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestResolveAlternate(t *testing.T) {
+	originalHostname := hostname
+	defer func() { hostname = originalHostname }()
+	hostname = func() string { return "box" }
+
+	createFiles := func(t *testing.T, dir string, names ...string) {
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+				t.Fatalf("Failed to create %s: %v", name, err)
+			}
+		}
+	}
+
+	t.Run("No alternates returns source", func(t *testing.T) {
+		dir := t.TempDir()
+		createFiles(t, dir, ".gitconfig")
+
+		source := filepath.Join(dir, ".gitconfig")
+		if result := ResolveAlternate(source); result != source {
+			t.Errorf("Expected %s, got %s", source, result)
+		}
+	})
+
+	t.Run("OS alternate is selected", func(t *testing.T) {
+		dir := t.TempDir()
+		createFiles(t, dir, ".gitconfig", ".gitconfig##os."+runtime.GOOS, ".gitconfig##os.plan9")
+
+		result := ResolveAlternate(filepath.Join(dir, ".gitconfig"))
+		if result != filepath.Join(dir, ".gitconfig##os."+runtime.GOOS) {
+			t.Errorf("Expected OS alternate, got %s", result)
+		}
+	})
+
+	t.Run("Host alternate wins over OS alternate", func(t *testing.T) {
+		dir := t.TempDir()
+		createFiles(t, dir, ".gitconfig", ".gitconfig##os."+runtime.GOOS, ".gitconfig##host.box", ".gitconfig##host.other")
+
+		result := ResolveAlternate(filepath.Join(dir, ".gitconfig"))
+		if result != filepath.Join(dir, ".gitconfig##host.box") {
+			t.Errorf("Expected host alternate, got %s", result)
+		}
+	})
+
+	t.Run("All conditions must match", func(t *testing.T) {
+		dir := t.TempDir()
+		createFiles(t, dir, ".gitconfig", ".gitconfig##os.plan9,host.box")
+
+		source := filepath.Join(dir, ".gitconfig")
+		if result := ResolveAlternate(source); result != source {
+			t.Errorf("Expected plain source, got %s", result)
+		}
+	})
+
+	t.Run("Default alternate used when source is absent", func(t *testing.T) {
+		dir := t.TempDir()
+		createFiles(t, dir, ".gitconfig##default", ".gitconfig##os.plan9")
+
+		result := ResolveAlternate(filepath.Join(dir, ".gitconfig"))
+		if result != filepath.Join(dir, ".gitconfig##default") {
+			t.Errorf("Expected default alternate, got %s", result)
+		}
+	})
+}
+
+func TestLinkWithAlternates(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer func() {
+		if originalDotDir != "" {
+			os.Setenv("DOT_DIR", originalDotDir)
+		} else {
+			os.Unsetenv("DOT_DIR")
+		}
+	}()
+
+	t.Run("Links the matching alternate", func(t *testing.T) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		setupTestEnvironment(t, dotfilesDir, homeDir)
+		alternate := filepath.Join(dotfilesDir, "vim", ".vimrc##os."+runtime.GOOS)
+		if err := os.WriteFile(alternate, []byte("alternate"), 0644); err != nil {
+			t.Fatalf("Failed to create alternate: %v", err)
+		}
+
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+
+		linkTarget, err := os.Readlink(filepath.Join(homeDir, ".vimrc"))
+		if err != nil || linkTarget != alternate {
+			t.Errorf("Expected link to alternate %s, got %s (%v)", alternate, linkTarget, err)
+		}
+
+		captureOutput(t, func() {
+			if err := Check([]string{"general"}); err != nil {
+				t.Errorf("Expected check to accept alternate link, got: %v", err)
+			}
+		})
+	})
+}
@@ -0,0 +1,46 @@
+package linker
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+// Output formats of check
+const (
+	FormatText        = "text"
+	FormatAnnotations = "annotations"
+)
+
+// printAnnotations writes each issue as a GitHub Actions workflow command, e.g.
+// "::warning file=.mappings,line=3,title=Missing link::Missing link: /home/me/.zshrc",
+// so that broken mappings show up inline on the .mappings line that declares them
+func printAnnotations(w io.Writer, dotfilesDir string, cfg *config.Config, broken []mapping, issues []string) {
+	data, _ := FS.ReadFile(filepath.Join(dotfilesDir, ".mappings"))
+
+	for i, m := range broken {
+		properties := "file=.mappings"
+		if src, ok := cfg.MappingsSource(m.profile, m.source); ok {
+			if line := config.MappingLine(data, m.profile, src); line > 0 {
+				properties += fmt.Sprintf(",line=%d", line)
+			}
+		}
+		title, _, _ := strings.Cut(issues[i], ":")
+		properties += ",title=" + escapeProperty(title)
+
+		fmt.Fprintf(w, "::warning %s::%s\n", properties, escapeData(issues[i]))
+	}
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
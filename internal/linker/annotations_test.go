@@ -0,0 +1,51 @@
+package linker
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestPrintAnnotations(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	defer func() { FS = originalFS }()
+	memory := fsys.NewMemory()
+	FS = memory
+	memory.MkdirAll("/dotfiles", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zsh/.zshrc\" = \"~/.zshrc\"\n\n[work]\n\"git/config, work\" = \"~/.gitconfig\"\n"), 0644)
+
+	cfg := &config.Config{}
+	broken := []mapping{
+		{source: "zsh/.zshrc", profile: "general"},
+		{source: "git/config, work", profile: "work"},
+		{source: "vim/.vimrc", profile: "general"},
+	}
+	issues := []string{
+		"Missing link: /home/user/.zshrc",
+		"Incorrect link: /home/user/.gitconfig -> /elsewhere (expected: /dotfiles/git/config, work)",
+		"Not a symlink: /home/user/100%\n",
+	}
+
+	var buf bytes.Buffer
+	printAnnotations(&buf, "/dotfiles", cfg, broken, issues)
+
+	expected := "::warning file=.mappings,line=2,title=Missing link::Missing link: /home/user/.zshrc\n" +
+		"::warning file=.mappings,line=5,title=Incorrect link::Incorrect link: /home/user/.gitconfig -> /elsewhere (expected: /dotfiles/git/config, work)\n" +
+		"::warning file=.mappings,title=Not a symlink::Not a symlink: /home/user/100%25%0A\n"
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestEscapeProperty(t *testing.T) {
+	if escaped := escapeProperty("a:b,c%"); escaped != "a%3Ab%2Cc%25" {
+		t.Errorf("Expected escaped property, got %s", escaped)
+	}
+}
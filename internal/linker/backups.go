@@ -0,0 +1,410 @@
+package linker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/table"
+	"github.com/yourusername/dot/internal/term"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// How a backup compares with the source of its mapping
+const (
+	backupSame     = "same as source"
+	backupDiffers  = "differs from source"
+	backupNoSource = "source missing"
+)
+
+// now returns the current time; tests replace it
+var now = time.Now
+
+// backupStamp is the layout of the names of the directories in the backup store, one for
+// each run of link that made backups; a run in the same second as an earlier one adds a
+// counter, e.g. 20240102-150405-2
+const backupStamp = "20060102-150405"
+
+// BackupDir is the backup store that link moves files out of the way to, each beneath
+// a directory for the run that made it and then its absolute path, e.g.
+// <BackupDir>/20240102-150405/home/me/.zshrc, so earlier backups of a target are kept;
+// "" leaves them next to their target as <target>.bak
+// The CLI sets it to backups in the XDG data directory, or the backup_dir option of the
+// global config, unless adjacent_backups is set
+var BackupDir string
+
+// newBackupDir returns the directory of the backup store for a run of link starting
+// now, one that no earlier run used, or "" to leave backups next to their target
+func newBackupDir(f fsys.FS) string {
+	if BackupDir == "" {
+		return ""
+	}
+	stamp := now().UTC().Format(backupStamp)
+	dir := filepath.Join(BackupDir, stamp)
+	for n := 2; ; n++ {
+		if _, err := f.Lstat(dir); err != nil {
+			return dir
+		}
+		dir = filepath.Join(BackupDir, fmt.Sprintf("%s-%d", stamp, n))
+	}
+}
+
+// newBackupPath returns where the file at target is moved out of the way to by a run
+// backing up to dir, see newBackupDir
+func newBackupPath(dir, target string) string {
+	if dir == "" {
+		return target + ".bak"
+	}
+	return filepath.Join(dir, storePath(target))
+}
+
+// storePath returns target relative to a directory of the backup store, with the drive
+// of a Windows path as its first element, e.g. C/Users/me/.gitconfig
+func storePath(target string) string {
+	volume := filepath.VolumeName(target)
+	return filepath.Join(strings.TrimSuffix(volume, ":"), target[len(volume):])
+}
+
+// backupStore returns the directories of the backup store and when they were made,
+// newest first
+func backupStore(f fsys.FS) []storeDir {
+	if BackupDir == "" {
+		return nil
+	}
+	entries, err := f.ReadDir(BackupDir)
+	if err != nil {
+		return nil
+	}
+	var dirs []storeDir
+	for _, entry := range entries {
+		name := entry.Name()
+		if len(name) < len(backupStamp) || !entry.IsDir() {
+			continue
+		}
+		made, err := time.Parse(backupStamp, name[:len(backupStamp)])
+		if err != nil {
+			continue
+		}
+		dirs = append(dirs, storeDir{path: filepath.Join(BackupDir, entry.Name()), made: made})
+	}
+	// Newest first, and the later runs of a second before the earlier ones
+	slices.SortFunc(dirs, func(a, b storeDir) int {
+		if c := b.made.Compare(a.made); c != 0 {
+			return c
+		}
+		if c := len(b.path) - len(a.path); c != 0 {
+			return c
+		}
+		return strings.Compare(b.path, a.path)
+	})
+	return dirs
+}
+
+// storeDir is a directory of the backup store, or a backup in one
+type storeDir struct {
+	path string
+	made time.Time
+}
+
+// backupsOf returns the backups that link made of target, newest first: those in the
+// directories of the backup store, then <target>.bak, made with adjacent backups or
+// before the store was used
+// The time a backup of the store was made is that of its directory, zero for <target>.bak
+func backupsOf(f fsys.FS, store []storeDir, target string) []storeDir {
+	var backups []storeDir
+	for _, dir := range store {
+		path := filepath.Join(dir.path, storePath(target))
+		if _, err := f.Lstat(path); err == nil {
+			backups = append(backups, storeDir{path: path, made: dir.made})
+		}
+	}
+	if _, err := f.Lstat(target + ".bak"); err == nil {
+		backups = append(backups, storeDir{path: target + ".bak"})
+	}
+	return backups
+}
+
+// removeEmptyDirs removes the directories of the backup store that path was in, from
+// the innermost up to the store's own directory, as long as they are empty
+func removeEmptyDirs(f fsys.FS, path string) {
+	if BackupDir == "" {
+		return
+	}
+	for dir := filepath.Dir(path); within(dir, BackupDir); dir = filepath.Dir(dir) {
+		if f.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// backupFile is a backup that link made of a managed target, see newBackupPath
+type backupFile struct {
+	m       mapping
+	path    string
+	latest  bool      // no later backup of the target was made
+	made    time.Time // when link made the backup, or when it was last modified if neither the store nor the journal tells
+	content string    // how the backup compares with the source, e.g. backupSame
+}
+
+// BackupOptions configures pruning and restoring backups
+type BackupOptions struct {
+	// OlderThan leaves backups alone that were made less than this long ago
+	OlderThan time.Duration
+	// Yes acts on every backup without asking
+	Yes bool
+	// Input is where answers are read from, os.Stdin if nil
+	Input io.Reader
+}
+
+// findBackups returns the backups of the targets of the given profiles, in target order
+// and newest first
+func findBackups(profiles []string) ([]backupFile, error) {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	made := backupTimes()
+	store := backupStore(FS)
+	var backups []backupFile
+	for _, m := range resolveMappings(newDirCache(FS), dotfilesDir, selected) {
+		for i, backup := range backupsOf(FS, store, m.targetPath) {
+			info, err := FS.Lstat(backup.path)
+			if err != nil {
+				continue
+			}
+
+			b := backupFile{m: m, path: backup.path, latest: i == 0, made: info.ModTime(), content: compareBackup(backup.path, m.sourcePath)}
+			if !backup.made.IsZero() {
+				b.made = backup.made
+			} else if t, recorded := made[backup.path]; recorded {
+				b.made = t
+			}
+			backups = append(backups, b)
+		}
+	}
+	return backups, nil
+}
+
+// backupTimes returns when the journal last recorded each backup being made
+func backupTimes() map[string]time.Time {
+	made := make(map[string]time.Time)
+	entries, _ := journal.Load()
+	for _, entry := range entries {
+		for _, action := range entry.Actions {
+			if action.Op == journal.OpBackup {
+				made[action.Target] = entry.Time
+			}
+		}
+	}
+	return made
+}
+
+// compareBackup tells how the backup at path compares with source
+func compareBackup(path, source string) string {
+	sourceHash, err := manifest.Hash(FS, source)
+	if err != nil {
+		return backupNoSource
+	}
+	if backupHash, err := manifest.Hash(FS, path); err != nil || backupHash != sourceHash {
+		return backupDiffers
+	}
+	return backupSame
+}
+
+// age returns how long ago the backup was made
+func (b backupFile) age() time.Duration {
+	return now().Sub(b.made)
+}
+
+// formatAge describes an age in the largest whole unit, e.g. "3d" or "5h"
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	}
+	return "<1h"
+}
+
+// ListBackups lists the backups that link made of the targets of the given profiles,
+// with their age and how they compare with the current source
+func ListBackups(profiles []string) error {
+	backups, err := findBackups(profiles)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		fmt.Fprintln(os.Stderr, "No backups of managed targets")
+		return nil
+	}
+
+	t := table.New("BACKUP", "AGE", "CONTENT", "PROFILE")
+	t.Truncatable(0)
+	for _, b := range backups {
+		t.Append(b.path, formatAge(b.age()), b.content, b.m.profile)
+	}
+	if err := t.Render(os.Stdout, term.Width()); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "\nRun dot backups prune to delete backups, or dot backups restore to put them back in place")
+	return nil
+}
+
+// PruneBackups deletes the backups of the targets of the given profiles, asking for
+// each one unless opts.Yes is set
+func PruneBackups(profiles []string, opts BackupOptions) error {
+	backups, err := findBackups(profiles)
+	if err != nil {
+		return err
+	}
+
+	in := bufio.NewReader(inputOrStdin(opts.Input))
+	var actions []journal.Action
+	for _, b := range backups {
+		if b.age() < opts.OlderThan {
+			continue
+		}
+		if !opts.Yes {
+			answer, quit := ask(in, "Delete %s (%s old, %s)? [y/N/q] ", b.path, formatAge(b.age()), b.content)
+			if quit {
+				break
+			}
+			if !answer {
+				continue
+			}
+		}
+
+		if err := FS.RemoveAll(b.path); err != nil {
+			utils.FprintfColor(os.Stderr, "red", "Error deleting %s: %v\n", b.path, err)
+			continue
+		}
+		removeEmptyDirs(FS, b.path)
+		actions = append(actions, journal.NewAction(journal.OpDelete, b.path, ""))
+		fmt.Fprintf(os.Stderr, "Deleted backup: %s\n", b.path)
+	}
+
+	// The run shows in dot log, but undo cannot bring deleted backups back
+	journal.Record("prune", profiles, actions)
+	fmt.Fprintf(os.Stderr, "Deleted %d backup(s)\n", len(actions))
+	return nil
+}
+
+// RestoreBackups moves the latest backups back in place of the links of the given
+// profiles, asking for each one unless opts.Yes is set
+// With targets given, only their backups are restored
+// A target that is not the mapping's link, e.g. a file written since, is left alone
+func RestoreBackups(profiles []string, targets []string, opts BackupOptions) error {
+	backups, err := findBackups(profiles)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		wanted[filepath.Clean(utils.ExpandTarget(target))] = true
+	}
+
+	protected, err := loadProtection()
+	if err != nil {
+		return err
+	}
+
+	in := bufio.NewReader(inputOrStdin(opts.Input))
+	var actions []journal.Action
+	for _, b := range backups {
+		if !b.latest || len(wanted) > 0 && !wanted[b.m.targetPath] {
+			continue
+		}
+		if b.age() < opts.OlderThan {
+			continue
+		}
+		if refusal := protected.refusal(b.m.targetPath, "restore a backup to"); refusal != "" {
+			utils.FprintfColor(os.Stderr, "red", "%s\n", refusal)
+			continue
+		}
+		if !opts.Yes {
+			answer, quit := ask(in, "Restore %s (%s old, %s) to %s? [y/N/q] ", b.path, formatAge(b.age()), b.content, b.m.targetPath)
+			if quit {
+				break
+			}
+			if !answer {
+				continue
+			}
+		}
+		actions = append(actions, restoreBackup(b)...)
+	}
+
+	journal.Record("restore", profiles, actions)
+	return nil
+}
+
+// restoreBackup replaces the link at a backup's target with the backup and returns the
+// actions that did so
+func restoreBackup(b backupFile) []journal.Action {
+	var actions []journal.Action
+	target := b.m.targetPath
+
+	if info, err := FS.Lstat(target); err == nil {
+		linkTarget, err := FS.Readlink(target)
+		if info.Mode()&os.ModeSymlink == 0 || err != nil || !utils.SamePath(linkTarget, b.m.sourcePath) {
+			fmt.Fprintf(os.Stderr, "Skipped (not the managed link): %s\n", target)
+			return nil
+		}
+		if err := FS.Remove(target); err != nil {
+			utils.FprintfColor(os.Stderr, "red", "Error removing %s: %v\n", target, err)
+			return nil
+		}
+		actions = append(actions, journal.NewAction(journal.OpRemoveLink, target, linkTarget))
+	}
+
+	if err := utils.MovePathFS(FS, b.path, target); err != nil {
+		utils.FprintfColor(os.Stderr, "red", "Error restoring backup %s: %v\n", b.path, err)
+		return actions
+	}
+	removeEmptyDirs(FS, b.path)
+	utils.FprintfColor(os.Stderr, "blue", "Restored backup: %s -> %s\n", b.path, target)
+	return append(actions, journal.NewAction(journal.OpRestore, target, b.path))
+}
+
+// inputOrStdin returns in, or os.Stdin if it is nil
+func inputOrStdin(in io.Reader) io.Reader {
+	if in == nil {
+		return os.Stdin
+	}
+	return in
+}
+
+// ask prints a yes/no/quit question to stderr and reads the answer; no answer is no
+func ask(in *bufio.Reader, format string, args ...interface{}) (yes, quit bool) {
+	fmt.Fprintf(os.Stderr, format, args...)
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, false
+	case "q", "quit":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,264 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/journal"
+)
+
+func TestBackups(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalNow := now
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		now = originalNow
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	// Link backs up a stale .zshrc and a .gitconfig identical to its source
+	setup := func(t *testing.T) *fsys.Memory {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"gitconfig\" = \"~/.gitconfig\"\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+		memory.WriteFile("/dotfiles/gitconfig", []byte("git"), 0644)
+		memory.WriteFile("/dotfiles/vimrc", []byte("vim"), 0644)
+		memory.WriteFile("/home/user/.zshrc", []byte("old zsh"), 0644)
+		memory.WriteFile("/home/user/.gitconfig", []byte("git"), 0644)
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		return memory
+	}
+
+	t.Run("Check lists backups with age and content", func(t *testing.T) {
+		setup(t)
+		now = func() time.Time { return time.Now().Add(72 * time.Hour) }
+		defer func() { now = originalNow }()
+
+		output := captureOutput(t, func() {
+			if err := ListBackups([]string{"general"}); err != nil {
+				t.Fatalf("ListBackups failed: %v", err)
+			}
+		})
+		for _, expected := range []string{"/home/user/.zshrc.bak", "differs from source", "/home/user/.gitconfig.bak", "same as source", "3d"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected %q in the listing, got: %s", expected, output)
+			}
+		}
+		if strings.Contains(output, ".vimrc.bak") {
+			t.Errorf("Expected no backup of .vimrc, got: %s", output)
+		}
+	})
+
+	t.Run("Prune asks for each backup", func(t *testing.T) {
+		memory := setup(t)
+
+		captureOutput(t, func() {
+			// .gitconfig.bak comes first in target order
+			if err := PruneBackups([]string{"general"}, BackupOptions{Input: strings.NewReader("n\ny\n")}); err != nil {
+				t.Fatalf("PruneBackups failed: %v", err)
+			}
+		})
+		if _, err := memory.Lstat("/home/user/.gitconfig.bak"); err != nil {
+			t.Errorf("Expected .gitconfig.bak to be kept: %v", err)
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc.bak"); !os.IsNotExist(err) {
+			t.Errorf("Expected .zshrc.bak to be deleted, got %v", err)
+		}
+
+		entries, _ := journal.Load()
+		last := entries[len(entries)-1]
+		if last.Command != "prune" || len(last.Actions) != 1 || last.Actions[0].Path != "/home/user/.zshrc.bak" {
+			t.Errorf("Expected the prune run to be recorded, got %+v", last)
+		}
+		if undoable := journal.Undoable(entries); len(undoable) > 0 && undoable[0].Command == "prune" {
+			t.Error("Expected the prune run not to be undoable")
+		}
+	})
+
+	t.Run("Prune leaves recent backups alone", func(t *testing.T) {
+		memory := setup(t)
+
+		captureOutput(t, func() {
+			if err := PruneBackups([]string{"general"}, BackupOptions{OlderThan: time.Hour, Yes: true}); err != nil {
+				t.Fatalf("PruneBackups failed: %v", err)
+			}
+		})
+		if _, err := memory.Lstat("/home/user/.zshrc.bak"); err != nil {
+			t.Errorf("Expected the recent .zshrc.bak to be kept: %v", err)
+		}
+	})
+
+	t.Run("Restore puts backups back in place of their links", func(t *testing.T) {
+		memory := setup(t)
+		// .gitconfig was replaced by a file since it was linked
+		memory.Remove("/home/user/.gitconfig")
+		memory.WriteFile("/home/user/.gitconfig", []byte("new git"), 0644)
+
+		output := captureOutput(t, func() {
+			if err := RestoreBackups([]string{"general"}, nil, BackupOptions{Yes: true}); err != nil {
+				t.Fatalf("RestoreBackups failed: %v", err)
+			}
+		})
+		if data, _ := memory.ReadFile("/home/user/.zshrc"); string(data) != "old zsh" {
+			t.Errorf("Expected .zshrc to be restored, got '%s'", data)
+		}
+		if !strings.Contains(output, "Skipped (not the managed link): /home/user/.gitconfig") {
+			t.Errorf("Expected .gitconfig to be skipped, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/home/user/.gitconfig"); string(data) != "new git" {
+			t.Errorf("Expected .gitconfig to be kept, got '%s'", data)
+		}
+	})
+
+	t.Run("Restore only the given targets", func(t *testing.T) {
+		memory := setup(t)
+
+		captureOutput(t, func() {
+			if err := RestoreBackups([]string{"general"}, []string{"~/.gitconfig"}, BackupOptions{Yes: true}); err != nil {
+				t.Fatalf("RestoreBackups failed: %v", err)
+			}
+		})
+		if _, err := memory.Readlink("/home/user/.zshrc"); err != nil {
+			t.Errorf("Expected .zshrc to stay linked: %v", err)
+		}
+		if _, err := memory.Readlink("/home/user/.gitconfig"); err == nil {
+			t.Error("Expected .gitconfig to be restored")
+		}
+	})
+
+	t.Run("The backup store keeps every backup by when it was made", func(t *testing.T) {
+		originalBackupDir := BackupDir
+		defer func() {
+			BackupDir = originalBackupDir
+			now = originalNow
+		}()
+		BackupDir = "/backups"
+		made := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+		now = func() time.Time { return made }
+		memory := setup(t)
+
+		// A file written over the link later is backed up again, next to the first backup
+		now = func() time.Time { return made.Add(time.Hour) }
+		memory.Remove("/home/user/.zshrc")
+		memory.WriteFile("/home/user/.zshrc", []byte("newer zsh"), 0644)
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+
+		if data, _ := memory.ReadFile("/backups/20240102-150405/home/user/.zshrc"); string(data) != "old zsh" {
+			t.Errorf("Expected the first .zshrc in the backup store, got '%s'", data)
+		}
+		if data, _ := memory.ReadFile("/backups/20240102-160405/home/user/.zshrc"); string(data) != "newer zsh" {
+			t.Errorf("Expected the second .zshrc in the backup store, got '%s'", data)
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc.bak"); !os.IsNotExist(err) {
+			t.Errorf("Expected no .zshrc.bak, got %v", err)
+		}
+
+		output := captureOutput(t, func() {
+			if err := ListBackups([]string{"general"}); err != nil {
+				t.Fatalf("ListBackups failed: %v", err)
+			}
+		})
+		first := strings.Index(output, "/backups/20240102-160405/home/user/.zshrc")
+		second := strings.Index(output, "/backups/20240102-150405/home/user/.zshrc")
+		if first < 0 || second < first {
+			t.Errorf("Expected both backups of .zshrc, newest first, got: %s", output)
+		}
+
+		captureOutput(t, func() {
+			if err := RestoreBackups([]string{"general"}, []string{"~/.zshrc"}, BackupOptions{Yes: true}); err != nil {
+				t.Fatalf("RestoreBackups failed: %v", err)
+			}
+		})
+		if data, _ := memory.ReadFile("/home/user/.zshrc"); string(data) != "newer zsh" {
+			t.Errorf("Expected the latest .zshrc to be restored, got '%s'", data)
+		}
+		if _, err := memory.Lstat("/backups/20240102-160405"); !os.IsNotExist(err) {
+			t.Errorf("Expected the emptied directory of the store to be removed, got %v", err)
+		}
+		if data, _ := memory.ReadFile("/backups/20240102-150405/home/user/.zshrc"); string(data) != "old zsh" {
+			t.Errorf("Expected the earlier backup to be kept, got '%s'", data)
+		}
+	})
+
+	t.Run("Each run backs up to a directory of its own", func(t *testing.T) {
+		originalBackupDir := BackupDir
+		defer func() {
+			BackupDir = originalBackupDir
+			now = originalNow
+		}()
+		BackupDir = "/backups"
+		made := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+		// The clock moves on between the backups of one run
+		tick := made
+		now = func() time.Time {
+			tick = tick.Add(700 * time.Millisecond)
+			return tick
+		}
+		memory := setup(t)
+
+		for _, path := range []string{"/backups/20240102-150405/home/user/.zshrc", "/backups/20240102-150405/home/user/.gitconfig"} {
+			if _, err := memory.Lstat(path); err != nil {
+				t.Errorf("Expected %s in the directory of the run: %v", path, err)
+			}
+		}
+
+		// A second run within the same second does not overwrite the first one's backups
+		now = func() time.Time { return made }
+		memory.Remove("/home/user/.zshrc")
+		memory.WriteFile("/home/user/.zshrc", []byte("newer zsh"), 0644)
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if data, _ := memory.ReadFile("/backups/20240102-150405/home/user/.zshrc"); string(data) != "old zsh" {
+			t.Errorf("Expected the first backup to be kept, got '%s'", data)
+		}
+		if data, _ := memory.ReadFile("/backups/20240102-150405-2/home/user/.zshrc"); string(data) != "newer zsh" {
+			t.Errorf("Expected the second run to back up next to the first, got '%s'", data)
+		}
+		if store := backupStore(memory); len(store) != 2 || !strings.HasSuffix(store[0].path, "-2") {
+			t.Errorf("Expected the later run of the second first, got %v", store)
+		}
+	})
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Minute: "<1h",
+		5 * time.Hour:    "5h",
+		50 * time.Hour:   "2d",
+	}
+	for d, expected := range tests {
+		if result := formatAge(d); result != expected {
+			t.Errorf("Expected %v to format as %s, got %s", d, expected, result)
+		}
+	}
+}
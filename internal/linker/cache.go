@@ -0,0 +1,263 @@
+package linker
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+// dirListing is the cached content of a single directory
+type dirListing struct {
+	entries    map[string]fs.FileMode // name -> file type bits
+	alternates map[string][]string    // base name -> names of its ##alternates
+}
+
+// dirCache memoizes directory listings so that large mapping sets cost one ReadDir
+// per directory instead of several Lstat/Stat calls per entry
+type dirCache struct {
+	mu   sync.Mutex
+	fs   fsys.FS
+	dirs map[string]*dirListing
+
+	noSymlinks map[string]bool // directories where creating a symlink was not permitted
+
+	lookups atomic.Int64 // nanoseconds spent in ReadDir, Lstat, and Stat calls
+}
+
+// newDirCache returns an empty directory cache reading from f
+func newDirCache(f fsys.FS) *dirCache {
+	return &dirCache{fs: f, dirs: make(map[string]*dirListing), noSymlinks: make(map[string]bool)}
+}
+
+// symlinksUnsupported reports whether creating a symlink in dir was already refused
+func (c *dirCache) symlinksUnsupported(dir string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.noSymlinks[dir]
+}
+
+// unsupportSymlinks records that dir does not permit symlinks, so that later mappings
+// there are copied without trying to link them first
+func (c *dirCache) unsupportSymlinks(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noSymlinks[dir] = true
+}
+
+// listing returns the cached listing of dir, reading it on first use
+// Returns nil if the directory cannot be read
+// set and remove change its entries while other mappings are linked, so they are only
+// read through entry and entries; its alternates never change
+func (c *dirCache) listing(dir string) *dirListing {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.listingLocked(dir)
+}
+
+// entry returns the file type of name in the cached listing of dir and whether it
+// exists there; listed is false if dir cannot be read
+func (c *dirCache) entry(dir, name string) (mode fs.FileMode, exists, listed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	listing := c.listingLocked(dir)
+	if listing == nil {
+		return 0, false, false
+	}
+	mode, exists = listing.entries[name]
+	return mode, exists, true
+}
+
+// entries returns a copy of the cached entries of dir, or false if dir cannot be read
+func (c *dirCache) entries(dir string) (map[string]fs.FileMode, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	listing := c.listingLocked(dir)
+	if listing == nil {
+		return nil, false
+	}
+	entries := make(map[string]fs.FileMode, len(listing.entries))
+	for name, mode := range listing.entries {
+		entries[name] = mode
+	}
+	return entries, true
+}
+
+// listingLocked implements listing; c.mu must be held
+func (c *dirCache) listingLocked(dir string) *dirListing {
+	if listing, cached := c.dirs[dir]; cached {
+		return listing
+	}
+
+	start := time.Now()
+	entries, err := c.fs.ReadDir(dir)
+	c.timeLookup(start)
+	if err != nil {
+		c.dirs[dir] = nil
+		return nil
+	}
+
+	listing := &dirListing{
+		entries:    make(map[string]fs.FileMode, len(entries)),
+		alternates: make(map[string][]string),
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		listing.entries[name] = entry.Type()
+		if base, _, found := strings.Cut(name, "##"); found {
+			listing.alternates[base] = append(listing.alternates[base], name)
+		}
+	}
+
+	c.dirs[dir] = listing
+	return listing
+}
+
+// lstat returns the file type of path without following symlinks
+// Only the type bits of the returned mode are set
+func (c *dirCache) lstat(path string) (fs.FileMode, error) {
+	dir, name := filepath.Split(path)
+	mode, exists, listed := c.entry(filepath.Clean(dir), name)
+	if !listed {
+		start := time.Now()
+		stat, err := c.fs.Lstat(path)
+		c.timeLookup(start)
+		if err != nil {
+			return 0, err
+		}
+		return stat.Mode().Type(), nil
+	}
+
+	if !exists {
+		return 0, &fs.PathError{Op: "lstat", Path: path, Err: fs.ErrNotExist}
+	}
+	return mode, nil
+}
+
+// exists reports whether path exists, following symlinks
+func (c *dirCache) exists(path string) bool {
+	mode, err := c.lstat(path)
+	if err != nil {
+		return false
+	}
+	if mode&fs.ModeSymlink != 0 {
+		start := time.Now()
+		_, err := c.fs.Stat(path)
+		c.timeLookup(start)
+		return err == nil
+	}
+	return true
+}
+
+// timeLookup adds the time since start to the lookup time
+func (c *dirCache) timeLookup(start time.Time) {
+	c.lookups.Add(int64(time.Since(start)))
+}
+
+// lookupTime returns the time spent reading directories and stat-ing files so far
+func (c *dirCache) lookupTime() time.Duration {
+	return time.Duration(c.lookups.Load())
+}
+
+// set records that path now exists with the given file type
+func (c *dirCache) set(path string, mode fs.FileMode) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir, name := filepath.Split(path)
+	if listing := c.dirs[filepath.Clean(dir)]; listing != nil {
+		listing.entries[name] = mode.Type()
+	}
+}
+
+// remove records that path no longer exists, dropping any listings below it
+func (c *dirCache) remove(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir, name := filepath.Split(path)
+	if listing := c.dirs[filepath.Clean(dir)]; listing != nil {
+		delete(listing.entries, name)
+	}
+
+	prefix := path + string(filepath.Separator)
+	for cached := range c.dirs {
+		if cached == path || strings.HasPrefix(cached, prefix) {
+			delete(c.dirs, cached)
+		}
+	}
+}
+
+// forget drops the cached listing of dir so it is read again on next use
+func (c *dirCache) forget(dir string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.dirs, dir)
+}
+
+// mkdirAll creates dir and any missing parents, dropping the cached listings they change
+// Returns the directories it created, outermost first
+func (c *dirCache) mkdirAll(dir string, perm fs.FileMode) ([]string, error) {
+	missing := missingDirs(c.fs, dir)
+	if err := c.fs.MkdirAll(dir, perm); err != nil {
+		return nil, err
+	}
+	c.created(missing)
+	return missing, nil
+}
+
+// created drops the cached listings of dirs, directories just created outermost first as
+// missingDirs returns them, and of the directory holding the outermost one, any of which
+// may have been read while they did not exist
+func (c *dirCache) created(dirs []string) {
+	if len(dirs) == 0 {
+		return
+	}
+	c.forget(filepath.Dir(dirs[0]))
+	for _, dir := range dirs {
+		c.forget(dir)
+	}
+}
+
+// resolveAlternate implements ResolveAlternate using the cached listing of the source directory
+func (c *dirCache) resolveAlternate(sourcePath string) string {
+	dir, base := filepath.Split(sourcePath)
+	listing := c.listing(filepath.Clean(dir))
+	if listing == nil || len(listing.alternates[base]) == 0 {
+		return sourcePath
+	}
+
+	host := hostname()
+	best := sourcePath
+	bestScore := 0
+	fallback := ""
+
+	for _, name := range listing.alternates[base] {
+		conditions := strings.TrimPrefix(name, base+"##")
+		if conditions == "default" {
+			fallback = filepath.Join(dir, name)
+			continue
+		}
+
+		score := alternateScore(conditions, host)
+		if score > bestScore {
+			best = filepath.Join(dir, name)
+			bestScore = score
+		}
+	}
+
+	if bestScore == 0 && fallback != "" {
+		if _, exists, _ := c.entry(filepath.Clean(dir), base); !exists {
+			return fallback
+		}
+	}
+
+	return best
+}
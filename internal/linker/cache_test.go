@@ -0,0 +1,217 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDirCache(t *testing.T) {
+	t.Run("Lstat reports type and missing entries", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "file")
+		link := filepath.Join(dir, "link")
+		if err := os.WriteFile(file, []byte("content"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := os.Symlink(file, link); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+
+		cache := newDirCache(FS)
+
+		if mode, err := cache.lstat(file); err != nil || !mode.IsRegular() {
+			t.Errorf("Expected regular file, got %v (%v)", mode, err)
+		}
+		if mode, err := cache.lstat(link); err != nil || mode&os.ModeSymlink == 0 {
+			t.Errorf("Expected symlink, got %v (%v)", mode, err)
+		}
+		if _, err := cache.lstat(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+			t.Errorf("Expected not exist error, got %v", err)
+		}
+		if _, err := cache.lstat(filepath.Join(dir, "nodir", "file")); !os.IsNotExist(err) {
+			t.Errorf("Expected not exist error for missing directory, got %v", err)
+		}
+	})
+
+	t.Run("Exists follows symlinks", func(t *testing.T) {
+		dir := t.TempDir()
+		dangling := filepath.Join(dir, "dangling")
+		if err := os.Symlink(filepath.Join(dir, "missing"), dangling); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+
+		cache := newDirCache(FS)
+		if cache.exists(dangling) {
+			t.Error("Expected dangling symlink to not exist")
+		}
+	})
+
+	t.Run("Set and remove update cached listings", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "entry")
+
+		cache := newDirCache(FS)
+		if _, err := cache.lstat(path); err == nil {
+			t.Fatal("Expected entry to be missing")
+		}
+
+		cache.set(path, os.ModeSymlink)
+		if mode, err := cache.lstat(path); err != nil || mode&os.ModeSymlink == 0 {
+			t.Errorf("Expected recorded symlink, got %v (%v)", mode, err)
+		}
+
+		cache.remove(path)
+		if _, err := cache.lstat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected removed entry to be missing, got %v", err)
+		}
+	})
+
+	t.Run("MkdirAll drops the listings of every directory it creates", func(t *testing.T) {
+		dir := t.TempDir()
+		nested := filepath.Join(dir, "a", "b", "c")
+
+		cache := newDirCache(FS)
+		for d := nested; d != filepath.Dir(dir); d = filepath.Dir(d) {
+			cache.listing(d)
+		}
+
+		created, err := cache.mkdirAll(nested, 0755)
+		if err != nil {
+			t.Fatalf("Failed to create %s: %v", nested, err)
+		}
+		if len(created) != 3 {
+			t.Errorf("Expected 3 created directories, got %v", created)
+		}
+		for d := nested; d != dir; d = filepath.Dir(d) {
+			if mode, err := cache.lstat(d); err != nil || !mode.IsDir() {
+				t.Errorf("Expected %s to be a directory, got %v (%v)", d, mode, err)
+			}
+			if cache.listing(d) == nil {
+				t.Errorf("Expected %s to be listed", d)
+			}
+		}
+	})
+
+	t.Run("Lookups and updates of one listing can run at the same time", func(t *testing.T) {
+		dir := t.TempDir()
+		cache := newDirCache(FS)
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 1000 {
+					path := filepath.Join(dir, fmt.Sprintf("entry%d-%d", i, j))
+					cache.set(path, os.ModeSymlink)
+					cache.lstat(filepath.Join(dir, fmt.Sprintf("entry%d-%d", (i+1)%8, j)))
+					cache.remove(path)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	// Siblings are linked at the same time, so their lookups and updates of the listing
+	// of their directory must not race; run with -race to be sure
+	t.Run("Sibling targets linked concurrently share the listing safely", func(t *testing.T) {
+		dotfilesDir, homeDir := setupLargeEnvironment(t, 200)
+		t.Setenv("DOT_DIR", dotfilesDir)
+		// Files in the way are backed up next to their target, into the same listing
+		for i := 0; i < 200; i += 3 {
+			os.WriteFile(filepath.Join(homeDir, "files", fmt.Sprintf("file%05d", i)), []byte("local"), 0644)
+		}
+
+		captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{Jobs: 8}); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+			if err := CheckWithOptions([]string{"general"}, CheckOptions{Jobs: 8}); err != nil {
+				t.Errorf("Expected every link to be correct, got: %v", err)
+			}
+		})
+	})
+}
+
+// setupLargeEnvironment creates a dotfiles repository with n mappings in one profile
+func setupLargeEnvironment(b testing.TB, n int) (string, string) {
+	tempDir := b.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	homeDir := filepath.Join(tempDir, "home")
+
+	for _, dir := range []string{filepath.Join(dotfilesDir, "files"), filepath.Join(homeDir, "files")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+
+	var mappings strings.Builder
+	mappings.WriteString("[general]\n")
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("file%05d", i)
+		if err := os.WriteFile(filepath.Join(dotfilesDir, "files", name), nil, 0644); err != nil {
+			b.Fatalf("Failed to create source: %v", err)
+		}
+		fmt.Fprintf(&mappings, "\"files/%s\" = %q\n", name, filepath.Join(homeDir, "files", name))
+	}
+
+	if err := os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings.String()), 0644); err != nil {
+		b.Fatalf("Failed to write .mappings: %v", err)
+	}
+
+	return dotfilesDir, homeDir
+}
+
+// silenceOutput redirects stdout and stderr to the null device for the rest of the benchmark
+func silenceOutput(b *testing.B) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatalf("Failed to open %s: %v", os.DevNull, err)
+	}
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = devNull, devNull
+	b.Cleanup(func() {
+		os.Stdout, os.Stderr = oldStdout, oldStderr
+		devNull.Close()
+	})
+}
+
+func BenchmarkCheck10k(b *testing.B) {
+	dotfilesDir, _ := setupLargeEnvironment(b, 10000)
+	b.Setenv("DOT_DIR", dotfilesDir)
+	silenceOutput(b)
+
+	if err := Link([]string{"general"}, false); err != nil {
+		b.Fatalf("Link failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Check([]string{"general"}); err != nil {
+			b.Fatalf("Check failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkLink10k measures re-running link over an up-to-date home directory,
+// where every entry is inspected but no link needs to be created
+func BenchmarkLink10k(b *testing.B) {
+	dotfilesDir, _ := setupLargeEnvironment(b, 10000)
+	b.Setenv("DOT_DIR", dotfilesDir)
+	silenceOutput(b)
+
+	if err := Link([]string{"general"}, false); err != nil {
+		b.Fatalf("Link failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Link([]string{"general"}, false); err != nil {
+			b.Fatalf("Link failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package linker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// caseCollision is a mapping whose target differs from an earlier mapping's only by case,
+// so that on a case-insensitive filesystem both link the same file
+type caseCollision struct {
+	m     mapping
+	first mapping
+}
+
+// String describes the collision as check reports it
+func (c caseCollision) String() string {
+	return fmt.Sprintf("Case collision: %s and %s are the same file on this filesystem ([%s] %s, [%s] %s)",
+		c.first.targetPath, c.m.targetPath, c.first.profile, c.first.source, c.m.profile, c.m.source)
+}
+
+// caseCollisions returns the mappings whose targets collide with an earlier target when
+// the filesystem ignores case, or nothing where it does not
+func caseCollisions(mappings []mapping) []caseCollision {
+	if !utils.CaseInsensitive {
+		return nil
+	}
+
+	var collisions []caseCollision
+	seen := make(map[string]mapping, len(mappings))
+	for _, m := range mappings {
+		key := strings.ToLower(m.targetPath)
+		first, found := seen[key]
+		if !found {
+			seen[key] = m
+			continue
+		}
+		// The same target reached twice is a precedence conflict, not a case collision
+		if first.targetPath != m.targetPath {
+			collisions = append(collisions, caseCollision{m: m, first: first})
+		}
+	}
+	return collisions
+}
+
+// printCaseCollisions warns about targets that collide on a case-insensitive filesystem,
+// which would otherwise overwrite each other's link on every run
+func printCaseCollisions(mappings []mapping) {
+	collisions := caseCollisions(mappings)
+	if len(collisions) == 0 {
+		return
+	}
+
+	Output.Messagef("yellow", "Targets differing only by case, which this filesystem treats as one file:\n")
+	for _, c := range collisions {
+		Output.Messagef("yellow", "  %s and %s\n", c.first.targetPath, c.m.targetPath)
+	}
+	Output.Messagef("", "\n")
+}
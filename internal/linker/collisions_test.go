@@ -0,0 +1,94 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+func TestCaseCollisions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalCaseInsensitive := utils.CaseInsensitive
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		utils.CaseInsensitive = originalCaseInsensitive
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	setup := func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"Config\" = \"~/Config\"\n\"zshrc\" = \"~/.zshrc\"\n\n[work]\n\"config\" = \"~/config\"\n"), 0644)
+		memory.WriteFile("/dotfiles/Config", []byte("general"), 0644)
+		memory.WriteFile("/dotfiles/config", []byte("work"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+	}
+
+	t.Run("Check reports targets differing only by case", func(t *testing.T) {
+		setup(t)
+		utils.CaseInsensitive = true
+
+		var err error
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general", "work"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+			err = Check([]string{"general", "work"})
+		})
+		if err == nil || !strings.Contains(err.Error(), "found 1 issue(s)") {
+			t.Errorf("Expected one issue, got %v", err)
+		}
+		if !strings.Contains(output, "Case collision: /home/user/Config and /home/user/config") {
+			t.Errorf("Expected the collision to be reported, got: %s", output)
+		}
+	})
+
+	t.Run("Link warns about targets differing only by case", func(t *testing.T) {
+		setup(t)
+		utils.CaseInsensitive = true
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general", "work"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "/home/user/Config and /home/user/config") {
+			t.Errorf("Expected a warning about the collision, got: %s", output)
+		}
+	})
+
+	t.Run("Case-sensitive filesystems keep both targets", func(t *testing.T) {
+		setup(t)
+		utils.CaseInsensitive = false
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general", "work"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+			if err := Check([]string{"general", "work"}); err != nil {
+				t.Errorf("Expected no issues, got %v", err)
+			}
+		})
+		if strings.Contains(output, "Case collision") {
+			t.Errorf("Expected no collision, got: %s", output)
+		}
+	})
+}
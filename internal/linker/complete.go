@@ -0,0 +1,65 @@
+package linker
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Completions returns what shell completion offers for commands that take a target or
+// source: the targets of the given profiles, written with ~ as in .mappings, the sources
+// as .mappings declares them, and the targets the manifest records as copies
+// A broken .mappings or manifest yields what could still be read, as completion should
+// never fail loudly
+func Completions(profiles []string) []string {
+	var words []string
+	if cfg, err := loadConfig(); err == nil {
+		if selected, err := cfg.Select(profiles); err == nil {
+			for _, m := range selected {
+				words = append(words, utils.ContractTarget(utils.ExpandTarget(m.Target)))
+				if declared, ok := cfg.MappingsSource(m.Profile, m.Source); ok {
+					words = append(words, declared)
+				}
+			}
+		}
+	}
+	if man, err := manifest.Load(); err == nil {
+		for target := range man.Copies {
+			words = append(words, utils.ContractTarget(target))
+		}
+	}
+
+	sort.Strings(words)
+	return slices.Compact(words)
+}
+
+// DisableCompletions returns the profiles and sources that dot disable accepts
+func DisableCompletions() []string {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil
+	}
+
+	var words []string
+	for profile, mappings := range cfg.Profiles {
+		words = append(words, profile)
+		for source := range mappings {
+			if declared, ok := cfg.MappingsSource(profile, source); ok {
+				words = append(words, declared)
+			}
+		}
+	}
+	sort.Strings(words)
+	return slices.Compact(words)
+}
+
+// EnableCompletions returns the profiles and sources disabled on this machine
+func EnableCompletions() []string {
+	man, err := manifest.Load()
+	if err != nil {
+		return nil
+	}
+	return man.Disabled
+}
@@ -0,0 +1,62 @@
+package linker
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/manifest"
+)
+
+func TestCompletions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	memory := fsys.NewMemory()
+	FS = memory
+	memory.MkdirAll("/dotfiles", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("source_root = \"home\"\n[general]\n\"zshrc\" = \"~/.zshrc\"\n[work]\n\"gitconfig\" = \"~/.gitconfig\"\n"), 0644)
+
+	man := &manifest.Manifest{
+		Copies:   map[string]manifest.Copy{"/home/user/.vimrc": {Source: "/dotfiles/vimrc"}},
+		Disabled: []string{"work"},
+	}
+	if err := man.Save(); err != nil {
+		t.Fatalf("Failed to save manifest: %v", err)
+	}
+
+	t.Run("Targets and sources", func(t *testing.T) {
+		expected := []string{"gitconfig", "zshrc", "~/.gitconfig", "~/.vimrc", "~/.zshrc"}
+		if result := Completions([]string{config.AllProfiles}); !reflect.DeepEqual(result, expected) {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+	})
+
+	t.Run("Disable and enable", func(t *testing.T) {
+		expected := []string{"general", "gitconfig", "work", "zshrc"}
+		if result := DisableCompletions(); !reflect.DeepEqual(result, expected) {
+			t.Errorf("Expected %v, got %v", expected, result)
+		}
+		if result := EnableCompletions(); !reflect.DeepEqual(result, []string{"work"}) {
+			t.Errorf("Expected [work], got %v", result)
+		}
+	})
+}
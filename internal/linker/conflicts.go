@@ -0,0 +1,92 @@
+package linker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// What link does with a file, directory, or other link in the way of a mapping's target
+const (
+	OnConflictBackup    = "backup"    // move files to the backup store or <target>.bak and replace other links
+	OnConflictSkip      = "skip"      // leave the target alone
+	OnConflictOverwrite = "overwrite" // delete what is in the way without a backup
+	OnConflictAdopt     = "adopt"     // move the file into the repository in place of its source
+	OnConflictPrompt    = "prompt"    // ask for each target which of the above to do
+)
+
+// OnConflictPolicies lists the valid values of LinkOptions.OnConflict
+var OnConflictPolicies = []string{OnConflictBackup, OnConflictSkip, OnConflictOverwrite, OnConflictAdopt, OnConflictPrompt}
+
+// validOnConflict reports whether policy is one of OnConflictPolicies
+func validOnConflict(policy string) bool {
+	return slices.Contains(OnConflictPolicies, policy)
+}
+
+// conflictResolver decides what happens to each target in the way of a link
+// A nil resolver backs up, as link always did, and leaves the default protected paths alone
+type conflictResolver struct {
+	policy    string
+	in        *bufio.Reader // answers read when the policy is prompt
+	protected protection    // paths that are never backed up, replaced, or deleted
+	backups   string        // the directory of the backup store the run backs up to, see newBackupDir
+	intr      *interruption // aborts a prompt when a signal arrives, set once the run catches them
+}
+
+// newConflictResolver returns a resolver for policy, reading prompt answers from in
+func newConflictResolver(policy string, in io.Reader, protected protection) *conflictResolver {
+	if policy == "" {
+		policy = OnConflictBackup
+	}
+	if in == nil {
+		in = os.Stdin
+	}
+	return &conflictResolver{policy: policy, in: bufio.NewReader(in), protected: protected, backups: newBackupDir(FS)}
+}
+
+// backupDir returns the directory of the backup store the run backs up to, or "" to
+// back up next to the target
+func (r *conflictResolver) backupDir() string {
+	if r == nil {
+		return newBackupDir(FS)
+	}
+	return r.backups
+}
+
+// protection returns the paths the resolver never lets link change
+func (r *conflictResolver) protection() protection {
+	if r == nil {
+		return newProtection(nil)
+	}
+	return r.protected
+}
+
+// resolve returns the policy to apply to target, in the way as described by what, asking
+// for it when the policy is prompt; an unanswered or interrupted prompt skips the target
+func (r *conflictResolver) resolve(target, what string) string {
+	if r == nil {
+		return OnConflictBackup
+	}
+	if r.policy != OnConflictPrompt {
+		return r.policy
+	}
+
+	fmt.Fprintf(os.Stderr, "%s is %s: [b]ack up, [s]kip, [o]verwrite, or [a]dopt it? [b/S/o/a] ", target, what)
+	answer, ok := r.intr.readLine(r.in)
+	if !ok {
+		fmt.Fprintln(os.Stderr)
+		return OnConflictSkip
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "b", OnConflictBackup:
+		return OnConflictBackup
+	case "o", OnConflictOverwrite:
+		return OnConflictOverwrite
+	case "a", OnConflictAdopt:
+		return OnConflictAdopt
+	}
+	return OnConflictSkip
+}
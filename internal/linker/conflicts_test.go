@@ -0,0 +1,150 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestOnConflict(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	// setup puts a file at ~/.zshrc and a link to elsewhere at ~/.vimrc
+	setup := func() *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+		memory.WriteFile("/dotfiles/vimrc", []byte("vim"), 0644)
+		memory.WriteFile("/home/user/.zshrc", []byte("local"), 0644)
+		memory.Symlink("/elsewhere/vimrc", "/home/user/.vimrc")
+		return memory
+	}
+	link := func(opts LinkOptions) string {
+		return captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, opts); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+	}
+
+	t.Run("Skip leaves conflicts alone", func(t *testing.T) {
+		memory := setup()
+		output := link(LinkOptions{OnConflict: OnConflictSkip})
+
+		if !strings.Contains(output, "Skipped (conflict): /home/user/.zshrc (a file is in the way)") {
+			t.Errorf("Expected file to be skipped, got: %s", output)
+		}
+		if !strings.Contains(output, "Skipped (conflict): /home/user/.vimrc (points to /elsewhere/vimrc)") {
+			t.Errorf("Expected link to be skipped, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/home/user/.zshrc"); string(data) != "local" {
+			t.Errorf("Expected 'local', got '%s'", data)
+		}
+	})
+
+	t.Run("Overwrite deletes without a backup", func(t *testing.T) {
+		memory := setup()
+		output := link(LinkOptions{OnConflict: OnConflictOverwrite})
+
+		if !strings.Contains(output, "Overwriting: /home/user/.zshrc") {
+			t.Errorf("Expected file to be overwritten, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc.bak"); err == nil {
+			t.Error("Expected no backup")
+		}
+		if target, _ := memory.Readlink("/home/user/.zshrc"); target != "/dotfiles/zshrc" {
+			t.Errorf("Expected .zshrc to be linked, got %q", target)
+		}
+	})
+
+	t.Run("Adopt moves the file into the repository", func(t *testing.T) {
+		memory := setup()
+		output := link(LinkOptions{OnConflict: OnConflictAdopt})
+
+		if !strings.Contains(output, "Adopted: /home/user/.zshrc -> /dotfiles/zshrc") {
+			t.Errorf("Expected file to be adopted, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/dotfiles/zshrc"); string(data) != "local" {
+			t.Errorf("Expected 'local', got '%s'", data)
+		}
+		if target, _ := memory.Readlink("/home/user/.vimrc"); target != "/dotfiles/vimrc" {
+			t.Errorf("Expected .vimrc to be linked, got %q", target)
+		}
+	})
+
+	t.Run("Adopt leaves sources in the team repository alone", func(t *testing.T) {
+		originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+		defer os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+		configHome := t.TempDir()
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("[team]\ndir = \"/team\"\n"), 0644)
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+
+		memory := setup()
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+		memory.MkdirAll("/team", 0755)
+		memory.WriteFile("/team/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n"), 0644)
+		memory.WriteFile("/team/zshrc", []byte("team zsh"), 0644)
+		output := link(LinkOptions{OnConflict: OnConflictAdopt})
+
+		if !strings.Contains(output, "Skipped (source is in the read-only team repository): /home/user/.zshrc") {
+			t.Errorf("Expected the team source to be left alone, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/team/zshrc"); string(data) != "team zsh" {
+			t.Errorf("Expected 'team zsh', got '%s'", data)
+		}
+	})
+
+	t.Run("Prompt asks for each conflict", func(t *testing.T) {
+		memory := setup()
+		// .vimrc sorts first: skip it, then back up .zshrc
+		output := link(LinkOptions{OnConflict: OnConflictPrompt, Input: strings.NewReader("s\nb\n")})
+
+		if !strings.Contains(output, "/home/user/.vimrc is a link to /elsewhere/vimrc: [b]ack up, [s]kip") {
+			t.Errorf("Expected a prompt for .vimrc, got: %s", output)
+		}
+		if target, _ := memory.Readlink("/home/user/.vimrc"); target != "/elsewhere/vimrc" {
+			t.Errorf("Expected .vimrc to be left alone, got %q", target)
+		}
+		if data, _ := memory.ReadFile("/home/user/.zshrc.bak"); string(data) != "local" {
+			t.Errorf("Expected .zshrc to be backed up, got '%s'", data)
+		}
+	})
+
+	t.Run("Unanswered prompts skip", func(t *testing.T) {
+		memory := setup()
+		link(LinkOptions{OnConflict: OnConflictPrompt, Input: strings.NewReader("")})
+
+		if data, _ := memory.ReadFile("/home/user/.zshrc"); string(data) != "local" {
+			t.Errorf("Expected 'local', got '%s'", data)
+		}
+	})
+
+	t.Run("Unknown policies are rejected", func(t *testing.T) {
+		setup()
+		err := LinkWithOptions([]string{"general"}, LinkOptions{OnConflict: "replace"})
+		if err == nil || !strings.Contains(err.Error(), "invalid --on-conflict") {
+			t.Errorf("Expected invalid policy error, got %v", err)
+		}
+	})
+}
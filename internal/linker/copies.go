@@ -0,0 +1,178 @@
+package linker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// errorPrivilegeNotHeld is returned by Windows when symlinks need Developer Mode or elevation
+const errorPrivilegeNotHeld = syscall.Errno(1314)
+
+// symlinkNotPermitted reports whether a failed symlink creation means symlinks cannot be
+// created at all, as on Windows without Developer Mode or on FAT and some network filesystems
+func symlinkNotPermitted(err error) bool {
+	if errors.Is(err, os.ErrPermission) || errors.Is(err, errors.ErrUnsupported) {
+		return true
+	}
+	var errno syscall.Errno
+	return runtime.GOOS == "windows" && errors.As(err, &errno) && errno == errorPrivilegeNotHeld
+}
+
+// markCopies attaches the manifest's record to every mapping whose target holds a copy
+// of the mapping's source, and the mode and line endings of its entry
+func markCopies(cfg *config.Config, mappings []mapping) error {
+	man, err := manifest.Load()
+	if err != nil {
+		return err
+	}
+
+	for i, m := range mappings {
+		entry := cfg.Entries[m.profile][m.source]
+		mappings[i].eol, mappings[i].mode = entry.EOL, entry.Mode
+		if c, exists := man.Copies[m.targetPath]; exists && utils.SamePath(c.Source, m.sourcePath) {
+			mappings[i].copied = &c
+		}
+	}
+	return nil
+}
+
+// copyState is how a copied target relates to its source and to what dot wrote
+type copyState int
+
+const (
+	copyCurrent  copyState = iota // the target matches the source
+	copyStale                     // the source changed, the target is as dot left it
+	copyEdited                    // the target was edited, the source is as dot copied it
+	copyDiverged                  // both the target and the source changed since dot copied it
+)
+
+// compareCopy returns the state of a mapping's copied target
+func compareCopy(cache *dirCache, m mapping) (copyState, error) {
+	targetHash, err := manifest.Hash(cache.fs, m.targetPath)
+	if err != nil {
+		return copyCurrent, err
+	}
+	// The copy dot made has the line endings of the entry, not those of the source
+	sourceHash, err := manifest.HashEOL(cache.fs, m.sourcePath, m.eol)
+	if err != nil {
+		return copyCurrent, err
+	}
+
+	switch {
+	case targetHash == sourceHash:
+		return copyCurrent, nil
+	case targetHash == m.copied.Hash:
+		return copyStale, nil
+	case sourceHash == m.copied.Hash:
+		return copyEdited, nil
+	}
+	return copyDiverged, nil
+}
+
+// describeCopy returns the check issue for a copied target in the given state, with what
+// to do about it, or "" for a current copy
+func describeCopy(m mapping, state copyState) string {
+	switch state {
+	case copyStale:
+		return fmt.Sprintf("Copy out of date: %s (the source changed; run dot link to update the copy)", m.targetPath)
+	case copyEdited:
+		return fmt.Sprintf("Copy edited locally: %s (run dot sync --adopt-changes to copy your edits to %s, or delete the copy and run dot link to discard them)", m.targetPath, m.sourcePath)
+	case copyDiverged:
+		return fmt.Sprintf("Copy edited locally and source changed: %s (merge your edits into %s, then delete the copy and run dot link)", m.targetPath, m.sourcePath)
+	}
+	return ""
+}
+
+// refreshCopy brings a copied target up to date before it is linked again
+// It returns true when the mapping needs nothing more: the copy is current, or it was
+// edited and is left alone; a stale copy is removed so it can be recreated, as is a
+// current one whose entry asks for a link
+func refreshCopy(cache *dirCache, m mapping, dryRun bool, protected protection, out *output) bool {
+	state, err := compareCopy(cache, m)
+	if err != nil {
+		out.errorf("Error comparing %s with %s: %v\n", m.targetPath, m.sourcePath, err)
+		return true
+	}
+
+	relink := m.mode == config.ModeLink
+	switch state {
+	case copyCurrent:
+		if !relink {
+			return true
+		}
+	case copyEdited, copyDiverged:
+		out.printfColor("yellow", "Skipped (copy edited since it was made): %s\n", m.targetPath)
+		return true
+	}
+	if refuseProtected(protected, m.targetPath, "update", out) {
+		return true
+	}
+
+	if err := cache.fs.RemoveAll(m.targetPath); err != nil {
+		out.errorf("Error removing outdated copy %s: %v\n", m.targetPath, err)
+		return true
+	}
+	cache.remove(m.targetPath)
+	out.record(journal.OpRemoveCopy, m.targetPath, m.sourcePath)
+	if relink {
+		out.printf("%s: %s\n", verb(dryRun, "Replacing copy with link", "Would replace copy with link"), m.targetPath)
+	} else {
+		out.printf("%s: %s\n", verb(dryRun, "Updating copy", "Would update copy"), m.targetPath)
+	}
+	return false
+}
+
+// copyMapping copies a mapping's source to its target in place of a symlink; reason says
+// why it is copied when its entry did not ask for a copy
+func copyMapping(cache *dirCache, m mapping, dryRun bool, reason string, out *output) {
+	err := utils.CopyTreeFS(cache.fs, m.sourcePath, m.targetPath)
+	if err == nil {
+		err = utils.ConvertTreeEOLFS(cache.fs, m.targetPath, m.eol)
+	}
+	if err != nil {
+		cache.fs.RemoveAll(m.targetPath)
+		out.errorf("Error copying %s to %s: %v\n", m.sourcePath, m.targetPath, err)
+		return
+	}
+
+	mode, _ := cache.lstat(m.sourcePath)
+	cache.set(m.targetPath, mode)
+	out.record(journal.OpCopy, m.targetPath, m.sourcePath)
+	if reason != "" {
+		out.printfColor("yellow", "%s (%s): %s <- %s\n", verb(dryRun, "Copied", "Would copy"), reason, m.targetPath, m.sourcePath)
+		return
+	}
+	out.printfColor("green", "%s: %s <- %s\n", verb(dryRun, "Copied", "Would copy"), m.targetPath, m.sourcePath)
+}
+
+// cleanCopy removes a copied target unless it was edited since it was made or is protected
+func cleanCopy(cache *dirCache, m mapping, protected protection, out *output) {
+	state, err := compareCopy(cache, m)
+	if err != nil {
+		out.errorf("Error comparing %s with %s: %v\n", m.targetPath, m.sourcePath, err)
+		return
+	}
+	if state == copyEdited || state == copyDiverged {
+		out.printfColor("yellow", "Skipped (copy edited since it was made): %s\n", m.targetPath)
+		return
+	}
+	if refuseProtected(protected, m.targetPath, "remove", out) {
+		return
+	}
+
+	if err := cache.fs.RemoveAll(m.targetPath); err != nil {
+		out.errorf("Error removing %s: %v\n", m.targetPath, err)
+		return
+	}
+	cache.remove(m.targetPath)
+	out.record(journal.OpRemoveCopy, m.targetPath, m.sourcePath)
+	out.printf("Removed copy: %s\n", m.targetPath)
+}
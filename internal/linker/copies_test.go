@@ -0,0 +1,437 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+// noSymlinks is a filesystem that refuses to create symlinks, like Windows without
+// Developer Mode
+type noSymlinks struct {
+	*fsys.Memory
+}
+
+func (noSymlinks) Symlink(oldname, newname string) error {
+	return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: syscall.EPERM}
+}
+
+func TestSymlinkNotPermitted(t *testing.T) {
+	if !symlinkNotPermitted(&os.LinkError{Op: "symlink", Err: syscall.EPERM}) {
+		t.Error("Expected EPERM to mean symlinks are not permitted")
+	}
+	if symlinkNotPermitted(&os.LinkError{Op: "symlink", Err: syscall.ENOENT}) {
+		t.Error("Expected ENOENT not to mean symlinks are not permitted")
+	}
+	if symlinkNotPermitted(nil) {
+		t.Error("Expected nil not to mean symlinks are not permitted")
+	}
+}
+
+func TestCopyFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+
+	memory := fsys.NewMemory()
+	FS = noSymlinks{memory}
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	memory.MkdirAll("/dotfiles/nvim", 0755)
+	memory.MkdirAll("/home/user", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"nvim\" = \"~/.config/nvim\"\n"), 0644)
+	memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+	memory.WriteFile("/dotfiles/nvim/init.lua", []byte("lua"), 0644)
+
+	t.Run("Sources are copied", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Copied (symlinks not permitted): /home/user/.zshrc <- /dotfiles/zshrc") {
+			t.Errorf("Expected copy message, got: %s", output)
+		}
+		if strings.Contains(output, "Error creating link") {
+			t.Errorf("Expected no link errors, got: %s", output)
+		}
+
+		for path, expected := range map[string]string{
+			"/home/user/.zshrc":                "zsh",
+			"/home/user/.config/nvim/init.lua": "lua",
+		} {
+			data, err := memory.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Expected copy at %s: %v", path, err)
+			}
+			if string(data) != expected {
+				t.Errorf("Expected '%s', got '%s'", expected, data)
+			}
+		}
+	})
+
+	t.Run("Check compares copies with their source", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := Check([]string{"general"}); err != nil {
+				t.Errorf("Check failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "All links are correct") {
+			t.Errorf("Expected all links correct, got: %s", output)
+		}
+
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh updated"), 0644)
+		output = captureOutput(t, func() {
+			if err := Check([]string{"general"}); err == nil {
+				t.Error("Expected check to fail for an outdated copy")
+			}
+		})
+		if !strings.Contains(output, "Copy out of date: /home/user/.zshrc") {
+			t.Errorf("Expected outdated copy, got: %s", output)
+		}
+	})
+
+	t.Run("List shows copies", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := ListWithOptions([]string{"general"}, ListOptions{Porcelain: true}); err != nil {
+				t.Errorf("List failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "copied\t/home/user/.config/nvim") {
+			t.Errorf("Expected nvim to be listed as copied, got: %s", output)
+		}
+		if !strings.Contains(output, "copy-outdated\t/home/user/.zshrc") {
+			t.Errorf("Expected .zshrc to be listed as outdated, got: %s", output)
+		}
+	})
+
+	t.Run("Link updates outdated copies", func(t *testing.T) {
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		data, _ := memory.ReadFile("/home/user/.zshrc")
+		if string(data) != "zsh updated" {
+			t.Errorf("Expected 'zsh updated', got '%s'", data)
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc.bak"); err == nil {
+			t.Error("Expected no backup of a copy dot made")
+		}
+	})
+
+	t.Run("Link keeps edited copies", func(t *testing.T) {
+		memory.WriteFile("/home/user/.zshrc", []byte("edited"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh again"), 0644)
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Skipped (copy edited since it was made): /home/user/.zshrc") {
+			t.Errorf("Expected edited copy to be skipped, got: %s", output)
+		}
+		data, _ := memory.ReadFile("/home/user/.zshrc")
+		if string(data) != "edited" {
+			t.Errorf("Expected 'edited', got '%s'", data)
+		}
+	})
+
+	t.Run("Check tells edited copies from stale ones", func(t *testing.T) {
+		// .zshrc was edited and its source changed too, so it has diverged
+		output := captureOutput(t, func() { Check([]string{"general"}) })
+		if !strings.Contains(output, "Copy edited locally and source changed: /home/user/.zshrc") {
+			t.Errorf("Expected diverged copy, got: %s", output)
+		}
+
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh updated"), 0644)
+		output = captureOutput(t, func() { Check([]string{"general"}) })
+		if !strings.Contains(output, "Copy edited locally: /home/user/.zshrc (run dot sync --adopt-changes to copy your edits to /dotfiles/zshrc") {
+			t.Errorf("Expected edited copy, got: %s", output)
+		}
+
+		output = captureOutput(t, func() {
+			ListWithOptions([]string{"general"}, ListOptions{Porcelain: true})
+		})
+		if !strings.Contains(output, "copy-edited\t/home/user/.zshrc") {
+			t.Errorf("Expected .zshrc to be listed as edited, got: %s", output)
+		}
+	})
+
+	t.Run("Clean removes unedited copies", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := Clean([]string{"general"}); err != nil {
+				t.Fatalf("Clean failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Removed copy: /home/user/.config/nvim") {
+			t.Errorf("Expected nvim copy to be removed, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.config/nvim"); err == nil {
+			t.Error("Expected nvim copy to be gone")
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc"); err != nil {
+			t.Error("Expected edited .zshrc copy to be kept")
+		}
+	})
+
+	t.Run("Sync adopts edited copies", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := AdoptChanges([]string{"general"}, AdoptOptions{DryRun: true}); err != nil {
+				t.Fatalf("AdoptChanges failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Would adopt edits: /home/user/.zshrc -> /dotfiles/zshrc") {
+			t.Errorf("Expected edits to be adopted in a dry run, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/dotfiles/zshrc"); string(data) != "zsh updated" {
+			t.Errorf("Expected a dry run to leave the source alone, got '%s'", data)
+		}
+
+		output = captureOutput(t, func() {
+			if err := AdoptChanges([]string{"general"}, AdoptOptions{}); err != nil {
+				t.Fatalf("AdoptChanges failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Adopted edits: /home/user/.zshrc -> /dotfiles/zshrc") {
+			t.Errorf("Expected edits to be adopted, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/dotfiles/zshrc"); string(data) != "edited" {
+			t.Errorf("Expected 'edited', got '%s'", data)
+		}
+
+		// The copy now matches what dot recorded, so check has nothing to report
+		output = captureOutput(t, func() { Check([]string{"general"}) })
+		if strings.Contains(output, "/home/user/.zshrc") {
+			t.Errorf("Expected the adopted copy to be current, got: %s", output)
+		}
+	})
+
+	t.Run("Sync skips diverged copies", func(t *testing.T) {
+		memory.WriteFile("/home/user/.zshrc", []byte("edited again"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("upstream"), 0644)
+
+		output := captureOutput(t, func() {
+			if err := AdoptChanges([]string{"general"}, AdoptOptions{}); err != nil {
+				t.Fatalf("AdoptChanges failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Skipped (source changed too, merge the edits by hand): /home/user/.zshrc") {
+			t.Errorf("Expected diverged copy to be skipped, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/dotfiles/zshrc"); string(data) != "upstream" {
+			t.Errorf("Expected 'upstream', got '%s'", data)
+		}
+	})
+}
+
+func TestCopyEOL(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+
+	memory := fsys.NewMemory()
+	FS = noSymlinks{memory}
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	memory.MkdirAll("/dotfiles", 0755)
+	memory.MkdirAll("/home/user", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"profile.ps1\" = { target = \"~/profile.ps1\", eol = \"crlf\" }\n\"script.sh\" = { target = \"~/script.sh\", eol = \"lf\" }\n"), 0644)
+	memory.WriteFile("/dotfiles/profile.ps1", []byte("a\nb\n"), 0644)
+	memory.WriteFile("/dotfiles/script.sh", []byte("a\r\nb\r\n"), 0644)
+
+	t.Run("Copies get the line endings of their entry", func(t *testing.T) {
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		for path, expected := range map[string]string{
+			"/home/user/profile.ps1": "a\r\nb\r\n",
+			"/home/user/script.sh":   "a\nb\n",
+		} {
+			if data, _ := memory.ReadFile(path); string(data) != expected {
+				t.Errorf("Expected %q in %s, got %q", expected, path, data)
+			}
+		}
+	})
+
+	t.Run("Converted copies are current", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := Check([]string{"general"}); err != nil {
+				t.Errorf("Check failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "All links are correct") {
+			t.Errorf("Expected all links correct, got: %s", output)
+		}
+	})
+
+	t.Run("Adopted edits keep LF in the repository", func(t *testing.T) {
+		memory.WriteFile("/home/user/profile.ps1", []byte("a\r\nb\r\nc\r\n"), 0644)
+		captureOutput(t, func() {
+			if err := AdoptChanges([]string{"general"}, AdoptOptions{}); err != nil {
+				t.Fatalf("AdoptChanges failed: %v", err)
+			}
+		})
+		if data, _ := memory.ReadFile("/dotfiles/profile.ps1"); string(data) != "a\nb\nc\n" {
+			t.Errorf("Expected the edits with LF line endings, got %q", data)
+		}
+
+		output := captureOutput(t, func() { Check([]string{"general"}) })
+		if strings.Contains(output, "profile.ps1") {
+			t.Errorf("Expected the adopted copy to be current, got: %s", output)
+		}
+	})
+}
+
+// countingNoSymlinks refuses symlinks like noSymlinks and counts the attempts
+type countingNoSymlinks struct {
+	noSymlinks
+	attempts *int
+}
+
+func (c countingNoSymlinks) Symlink(oldname, newname string) error {
+	*c.attempts++
+	return c.noSymlinks.Symlink(oldname, newname)
+}
+
+func TestMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	setup := func(t *testing.T, mappings string) *fsys.Memory {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte(mappings), 0644)
+		for _, name := range []string{"aliases", "vimrc", "zshrc"} {
+			memory.WriteFile("/dotfiles/"+name, []byte(name), 0644)
+		}
+		return memory
+	}
+
+	t.Run("Auto tries one symlink per directory", func(t *testing.T) {
+		memory := setup(t, "[general]\n\"aliases\" = \"~/.aliases\"\n\"vimrc\" = \"~/.vimrc\"\n\"zshrc\" = { target = \"~/.zshrc\", mode = \"auto\" }\n")
+		attempts := 0
+		FS = countingNoSymlinks{noSymlinks{memory}, &attempts}
+
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if attempts != 1 {
+			t.Errorf("Expected 1 symlink attempt, got %d", attempts)
+		}
+		if data, _ := memory.ReadFile("/home/user/.zshrc"); string(data) != "zshrc" {
+			t.Errorf("Expected .zshrc to be copied, got '%s'", data)
+		}
+	})
+
+	t.Run("Copy replaces the link with a tracked copy", func(t *testing.T) {
+		memory := setup(t, "[general]\n\"zshrc\" = { target = \"~/.zshrc\", mode = \"copy\" }\n")
+		memory.Symlink("/dotfiles/zshrc", "/home/user/.zshrc")
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Replacing link with copy: /home/user/.zshrc") || !strings.Contains(output, "Copied: /home/user/.zshrc <- /dotfiles/zshrc") {
+			t.Errorf("Expected the link to be replaced, got: %s", output)
+		}
+		if data, err := memory.ReadFile("/home/user/.zshrc"); err != nil || string(data) != "zshrc" {
+			t.Errorf("Expected a copy, got '%s' (%v)", data, err)
+		}
+
+		memory.WriteFile("/dotfiles/zshrc", []byte("updated"), 0644)
+		output = captureOutput(t, func() { Check([]string{"general"}) })
+		if !strings.Contains(output, "Copy out of date: /home/user/.zshrc") {
+			t.Errorf("Expected the copy to be tracked, got: %s", output)
+		}
+	})
+
+	t.Run("Link fails where symlinks are not permitted", func(t *testing.T) {
+		memory := setup(t, "[general]\n\"zshrc\" = { target = \"~/.zshrc\", mode = \"link\" }\n")
+		FS = noSymlinks{memory}
+
+		var err error
+		captureOutput(t, func() {
+			err = Link([]string{"general"}, false)
+		})
+		if err == nil || !strings.Contains(err.Error(), "symlinks are not permitted in /home/user and the mapping's mode is link") {
+			t.Errorf("Expected a link error, got %v", err)
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc"); !os.IsNotExist(err) {
+			t.Errorf("Expected no copy, got %v", err)
+		}
+	})
+
+	t.Run("Link replaces a copy once symlinks work", func(t *testing.T) {
+		memory := setup(t, "[general]\n\"zshrc\" = \"~/.zshrc\"\n")
+		FS = noSymlinks{memory}
+		captureOutput(t, func() { Link([]string{"general"}, false) })
+
+		FS = memory
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = { target = \"~/.zshrc\", mode = \"link\" }\n"), 0644)
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Replacing copy with link: /home/user/.zshrc") {
+			t.Errorf("Expected the copy to be replaced, got: %s", output)
+		}
+		if target, _ := memory.Readlink("/home/user/.zshrc"); target != "/dotfiles/zshrc" {
+			t.Errorf("Expected a link, got %q", target)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package linker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/diff"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// DiffOptions controls how Diff prints the differences
+type DiffOptions struct {
+	// Context is the number of unchanged lines shown around each change
+	Context int
+	// Color renders the diffs with ANSI colors and highlights the changed words
+	Color bool
+}
+
+// Diff prints a unified diff from the source of each mapping to what is at its target,
+// for targets that are real files rather than links to their source and for copies that
+// differ from it, to decide whether a stray file can be overwritten
+// Directories are compared file by file
+func Diff(profiles []string, opts DiffOptions) error {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return err
+	}
+
+	cache := newDirCache(FS)
+	mappings := resolveMappings(cache, dotfilesDir, selected)
+	if err := markCopies(cfg, mappings); err != nil {
+		return err
+	}
+
+	differ := false
+	for _, m := range mappings {
+		switch state, _ := listState(cache, m); state {
+		case stateNotSymlink, stateCopyEdited, stateCopyOutdated:
+		default:
+			continue
+		}
+		changed, err := diffPaths(os.Stdout, FS, m.sourcePath, m.targetPath, m.eol, opts)
+		if err != nil {
+			return err
+		}
+		differ = differ || changed
+	}
+	if !differ {
+		fmt.Fprintln(os.Stderr, "No targets differ from their sources")
+	}
+	return nil
+}
+
+// diffPaths writes the differences from source to target to w, file by file for
+// directories, and reports whether there were any
+// The source is compared with the line endings eol that copies of it get, if any
+func diffPaths(w io.Writer, f fsys.FS, source, target, eol string, opts DiffOptions) (bool, error) {
+	sourceInfo, sourceErr := f.Stat(source)
+	targetInfo, err := f.Lstat(target)
+	if err != nil {
+		return false, err
+	}
+	if sourceErr == nil && sourceInfo.IsDir() != targetInfo.IsDir() {
+		kind := map[bool]string{true: "a directory", false: "a file"}
+		_, err := fmt.Fprintf(w, "%s is %s while %s is %s\n", source, kind[sourceInfo.IsDir()], target, kind[targetInfo.IsDir()])
+		return true, err
+	}
+	if !targetInfo.IsDir() {
+		return diffFiles(w, f, source, target, eol, opts)
+	}
+
+	// A file on one side only is diffed against nothing
+	names := make(map[string]bool)
+	for _, name := range treeFiles(f, source, "") {
+		names[name] = true
+	}
+	for _, name := range treeFiles(f, target, "") {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	differ := false
+	for _, name := range sorted {
+		rel := filepath.FromSlash(name)
+		changed, err := diffFiles(w, f, filepath.Join(source, rel), filepath.Join(target, rel), eol, opts)
+		if err != nil {
+			return differ, err
+		}
+		differ = differ || changed
+	}
+	return differ, nil
+}
+
+// diffFiles writes the differences from the file source to the file target to w, either
+// of which may be missing, and reports whether there were any
+func diffFiles(w io.Writer, f fsys.FS, source, target, eol string, opts DiffOptions) (bool, error) {
+	oldName, newName := source, target
+	oldText, err := f.ReadFile(source)
+	if os.IsNotExist(err) {
+		oldName = "/dev/null"
+	} else if err != nil {
+		return false, err
+	} else if eol != "" {
+		oldText = utils.ConvertEOL(oldText, eol)
+	}
+	newText, err := f.ReadFile(target)
+	if os.IsNotExist(err) {
+		newName = "/dev/null"
+	} else if err != nil {
+		return false, err
+	}
+
+	if string(oldText) == string(newText) {
+		return false, nil
+	}
+	return true, diff.Unified(w, oldName, newName, string(oldText), string(newText), diff.Options{
+		Context: opts.Context,
+		Color:   opts.Color,
+		Words:   opts.Color,
+	})
+}
+
+// treeFiles returns the files beneath dir, with slash-separated paths prefixed by prefix
+func treeFiles(f fsys.FS, dir, prefix string) []string {
+	entries, err := f.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			files = append(files, treeFiles(f, filepath.Join(dir, entry.Name()), prefix+entry.Name()+"/")...)
+			continue
+		}
+		files = append(files, prefix+entry.Name())
+	}
+	return files
+}
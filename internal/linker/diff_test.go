@@ -0,0 +1,92 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/diff"
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestDiff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	setup := func() *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles/nvim", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"gitconfig\" = \"~/.gitconfig\"\n\"nvim\" = \"~/.config/nvim\"\n"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("export EDITOR=vim\nalias ll='ls -l'\n"), 0644)
+		memory.WriteFile("/dotfiles/gitconfig", []byte("[user]\n"), 0644)
+		memory.WriteFile("/dotfiles/nvim/init.lua", []byte("vim.o.number = true\n"), 0644)
+		memory.Symlink("/dotfiles/gitconfig", "/home/user/.gitconfig")
+		return memory
+	}
+	run := func(t *testing.T) (string, string) {
+		var err error
+		stdout := captureStdout(t, func() {
+			err = Diff([]string{"general"}, DiffOptions{Context: diff.DefaultContext})
+		})
+		if err != nil {
+			t.Fatalf("Diff failed: %v", err)
+		}
+		all := captureOutput(t, func() {
+			Diff([]string{"general"}, DiffOptions{Context: diff.DefaultContext})
+		})
+		return string(stdout), all
+	}
+
+	t.Run("A real file is diffed against its source", func(t *testing.T) {
+		memory := setup()
+		memory.WriteFile("/home/user/.zshrc", []byte("export EDITOR=nvim\nalias ll='ls -l'\n"), 0644)
+
+		stdout, _ := run(t)
+		expected := "--- /dotfiles/zshrc\n+++ /home/user/.zshrc\n@@ -1,2 +1,2 @@\n-export EDITOR=vim\n+export EDITOR=nvim\n alias ll='ls -l'\n"
+		if stdout != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, stdout)
+		}
+	})
+
+	t.Run("A real directory is diffed file by file", func(t *testing.T) {
+		memory := setup()
+		memory.MkdirAll("/home/user/.config/nvim", 0755)
+		memory.WriteFile("/home/user/.config/nvim/init.lua", []byte("vim.o.number = true\n"), 0644)
+		memory.WriteFile("/home/user/.config/nvim/extra.lua", []byte("-- mine\n"), 0644)
+
+		stdout, _ := run(t)
+		if !strings.Contains(stdout, "--- /dev/null\n+++ /home/user/.config/nvim/extra.lua\n") {
+			t.Errorf("Expected the file only at the target to be added, got:\n%s", stdout)
+		}
+		if strings.Contains(stdout, "init.lua") {
+			t.Errorf("Expected identical files to be left out, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("Links and missing targets are left out", func(t *testing.T) {
+		setup()
+
+		stdout, all := run(t)
+		if stdout != "" {
+			t.Errorf("Expected no diff, got:\n%s", stdout)
+		}
+		if !strings.Contains(all, "No targets differ from their sources") {
+			t.Errorf("Expected a note that nothing differs, got: %s", all)
+		}
+	})
+}
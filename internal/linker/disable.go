@@ -0,0 +1,129 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Disable switches off a profile or a single source on this machine, so that link,
+// check, and watch leave it alone without editing the shared .mappings
+// The setting is kept in the manifest; links that already exist stay until cleaned
+func Disable(name string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if !profileOrSource(cfg, name) {
+		return fmt.Errorf("%s is neither a profile nor a mapped source", name)
+	}
+
+	man, err := manifest.Load()
+	if err != nil {
+		return err
+	}
+	if !man.Disable(name) {
+		fmt.Fprintf(os.Stderr, "Already disabled: %s\n", name)
+		return nil
+	}
+	if err := man.Save(); err != nil {
+		return err
+	}
+
+	utils.FprintfColor(os.Stderr, "yellow", "Disabled on this machine: %s\n", name)
+	if _, isProfile := cfg.Profiles[name]; isProfile {
+		fmt.Fprintf(os.Stderr, "Links it already created stay; run dot clean --profile %s to remove them\n", name)
+	}
+	return nil
+}
+
+// Enable switches a profile or source disabled by Disable back on
+func Enable(name string) error {
+	man, err := manifest.Load()
+	if err != nil {
+		return err
+	}
+	if !man.Enable(name) {
+		return fmt.Errorf("%s is not disabled", name)
+	}
+	if err := man.Save(); err != nil {
+		return err
+	}
+
+	utils.FprintfColor(os.Stderr, "green", "Enabled on this machine: %s\n", name)
+	return nil
+}
+
+// loadConfig parses the .mappings file of the dotfiles directory
+func loadConfig() (*config.Config, error) {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseConfigFS(FS, dotfilesDir)
+}
+
+// profileOrSource reports whether name is a profile, or a source mapped by any profile,
+// either relative to the dotfiles directory or as the profile declares it
+func profileOrSource(cfg *config.Config, name string) bool {
+	if _, exists := cfg.Profiles[name]; exists {
+		return true
+	}
+	for profile, mappings := range cfg.Profiles {
+		for source := range mappings {
+			if disables(cfg, name, profile, source) {
+				return true
+			}
+		}
+		for source := range cfg.Entries[profile] {
+			if disables(cfg, name, profile, source) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// disables reports whether the disabled name covers source of profile
+func disables(cfg *config.Config, name, profile, source string) bool {
+	if name == profile || name == source {
+		return true
+	}
+	declared, ok := cfg.MappingsSource(profile, source)
+	return ok && name == declared
+}
+
+// withoutDisabled splits the selected mappings into those enabled on this machine and
+// those a profile or source disabled on it
+func withoutDisabled(cfg *config.Config, selected []config.Mapping) (enabled, disabled []config.Mapping, err error) {
+	man, err := manifest.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(man.Disabled) == 0 {
+		return selected, nil, nil
+	}
+
+	for _, m := range selected {
+		if slices.ContainsFunc(man.Disabled, func(name string) bool {
+			return disables(cfg, name, m.Profile, m.Source)
+		}) {
+			disabled = append(disabled, m)
+		} else {
+			enabled = append(enabled, m)
+		}
+	}
+	return enabled, disabled, nil
+}
+
+// printDisabled says which targets a run leaves alone because they are disabled
+func printDisabled(disabled []config.Mapping) {
+	for _, m := range disabled {
+		Output.Messagef("", "Skipped (disabled): %s\n", utils.ExpandTarget(m.Target))
+	}
+}
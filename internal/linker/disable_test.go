@@ -0,0 +1,93 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestDisable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+
+	memory := fsys.NewMemory()
+	FS = memory
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	memory.MkdirAll("/dotfiles/vim", 0755)
+	memory.MkdirAll("/home/user", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"vim/.vimrc\" = \"~/.vimrc\"\n\"zshrc\" = \"~/.zshrc\"\n\n[gui]\n\"alacritty.toml\" = \"~/.alacritty.toml\"\n"), 0644)
+	memory.WriteFile("/dotfiles/vim/.vimrc", []byte("vim"), 0644)
+	memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+	memory.WriteFile("/dotfiles/alacritty.toml", []byte("gui"), 0644)
+
+	t.Run("Only profiles and mapped sources can be disabled", func(t *testing.T) {
+		err := Disable("emacs")
+		if err == nil || !strings.Contains(err.Error(), "neither a profile nor a mapped source") {
+			t.Errorf("Expected unknown name error, got %v", err)
+		}
+	})
+
+	t.Run("Link leaves disabled profiles and sources alone", func(t *testing.T) {
+		captureOutput(t, func() {
+			if err := Disable("gui"); err != nil {
+				t.Fatalf("Disable failed: %v", err)
+			}
+			if err := Disable("vim/.vimrc"); err != nil {
+				t.Fatalf("Disable failed: %v", err)
+			}
+		})
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general", "gui"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Skipped (disabled): /home/user/.vimrc") || !strings.Contains(output, "Skipped (disabled): /home/user/.alacritty.toml") {
+			t.Errorf("Expected disabled mappings to be skipped, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.vimrc"); !os.IsNotExist(err) {
+			t.Errorf("Expected .vimrc not to be linked, got %v", err)
+		}
+		if _, err := memory.Lstat("/home/user/.alacritty.toml"); !os.IsNotExist(err) {
+			t.Errorf("Expected .alacritty.toml not to be linked, got %v", err)
+		}
+		if target, _ := memory.Readlink("/home/user/.zshrc"); target != "/dotfiles/zshrc" {
+			t.Errorf("Expected .zshrc to be linked, got %q", target)
+		}
+	})
+
+	t.Run("Enabled mappings are linked again", func(t *testing.T) {
+		captureOutput(t, func() {
+			if err := Enable("vim/.vimrc"); err != nil {
+				t.Fatalf("Enable failed: %v", err)
+			}
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if target, _ := memory.Readlink("/home/user/.vimrc"); target != "/dotfiles/vim/.vimrc" {
+			t.Errorf("Expected .vimrc to be linked, got %q", target)
+		}
+		if err := Enable("vim/.vimrc"); err == nil {
+			t.Error("Expected enabling a source that is not disabled to fail")
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package linker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+)
+
+// failedMappings summarizes the errors of the mappings a run failed to apply, or returns
+// nil when none failed
+func failedMappings(failed int, errors []string) error {
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d mapping(s) failed:\n  %s", failed, strings.Join(errors, "\n  "))
+}
+
+// failFast stops a run at the first mapping that fails, for provisioning runs that must
+// either apply every mapping or leave the machine as it was
+// A nil failFast never stops a run
+type failFast struct {
+	mu      sync.Mutex
+	failed  bool
+	skipped int // the mappings not processed after the failure
+}
+
+// newFailFast returns a failFast for a run, or nil when the run carries on after errors
+func newFailFast(enabled bool) *failFast {
+	if !enabled {
+		return nil
+	}
+	return &failFast{}
+}
+
+// stopped reports whether an earlier mapping failed, counting the mapping it is asked
+// for as skipped and saying so in its output
+func (f *failFast) stopped(m mapping, out *output) bool {
+	if f == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.failed {
+		return false
+	}
+	f.skipped++
+	out.printf("Skipped (an earlier mapping failed): %s\n", m.targetPath)
+	return true
+}
+
+// observe notes whether a mapping failed
+func (f *failFast) observe(out *output) {
+	if f != nil && out.failed {
+		f.mu.Lock()
+		f.failed = true
+		f.mu.Unlock()
+	}
+}
+
+// rollback reverts the changes of the run, newest first, once a mapping failed, and
+// returns the error that says so; it returns nil when nothing failed
+// A dry run changed nothing, so nothing is reverted
+func (f *failFast) rollback(actions []journal.Action, dryRun bool) error {
+	if f == nil || !f.failed {
+		return nil
+	}
+	if dryRun {
+		return fmt.Errorf("stopped at the first failed mapping (--fail-fast), %d mapping(s) not processed", f.skipped)
+	}
+
+	// Copies made by the run are only removed while they hold what the run wrote
+	man := &manifest.Manifest{Copies: make(map[string]manifest.Copy)}
+	man.Apply(FS, actions)
+
+	// The run only made changes protection allowed, so all of them are reverted
+	reverted := 0
+	for i := len(actions) - 1; i >= 0; i-- {
+		if _, ok := undoAction(man, nil, actions[i]); ok {
+			reverted++
+		}
+	}
+	return fmt.Errorf("stopped at the first failed mapping (--fail-fast), %d change(s) rolled back, %d mapping(s) not processed", reverted, f.skipped)
+}
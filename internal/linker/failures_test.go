@@ -0,0 +1,101 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestFailures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	// The protected authorized_keys fails to link between the other two mappings
+	setup := func() *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles/ssh", 0755)
+		memory.MkdirAll("/home/user/.ssh", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"aliases\" = \"~/.aliases\"\n\"ssh/authorized_keys\" = \"~/.ssh/authorized_keys\"\n\"zshrc\" = \"~/.zshrc\"\n"), 0644)
+		memory.WriteFile("/dotfiles/aliases", []byte("aliases"), 0644)
+		memory.WriteFile("/dotfiles/ssh/authorized_keys", []byte("ssh-ed25519 new"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+		memory.WriteFile("/home/user/.ssh/authorized_keys", []byte("ssh-ed25519 current"), 0644)
+		memory.WriteFile("/home/user/.aliases", []byte("local aliases"), 0644)
+		return memory
+	}
+
+	t.Run("Failures are summarized after every mapping was processed", func(t *testing.T) {
+		memory := setup()
+
+		var err error
+		captureOutput(t, func() {
+			err = LinkWithOptions([]string{"general"}, LinkOptions{OnConflict: OnConflictOverwrite})
+		})
+		if err == nil || !strings.Contains(err.Error(), "1 mapping(s) failed:\n  Refusing to replace protected path: /home/user/.ssh/authorized_keys") {
+			t.Errorf("Expected a summary of the failure, got %v", err)
+		}
+		if target, _ := memory.Readlink("/home/user/.zshrc"); target != "/dotfiles/zshrc" {
+			t.Errorf("Expected .zshrc to be linked after the failure, got %q", target)
+		}
+	})
+
+	t.Run("Fail fast stops and rolls back", func(t *testing.T) {
+		memory := setup()
+
+		var err error
+		output := captureOutput(t, func() {
+			err = LinkWithOptions([]string{"general"}, LinkOptions{FailFast: true})
+		})
+		if err == nil || !strings.Contains(err.Error(), "stopped at the first failed mapping (--fail-fast), 2 change(s) rolled back, 1 mapping(s) not processed") {
+			t.Errorf("Expected fail fast error, got %v", err)
+		}
+		if !strings.Contains(output, "Skipped (an earlier mapping failed): /home/user/.zshrc") {
+			t.Errorf("Expected .zshrc to be skipped, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc"); !os.IsNotExist(err) {
+			t.Errorf("Expected .zshrc not to be linked, got %v", err)
+		}
+		// The link replacing .aliases is removed and the backup put back
+		if data, _ := memory.ReadFile("/home/user/.aliases"); string(data) != "local aliases" {
+			t.Errorf("Expected .aliases to be restored, got '%s'", data)
+		}
+		if _, err := memory.Lstat("/home/user/.aliases.bak"); !os.IsNotExist(err) {
+			t.Errorf("Expected no backup to remain, got %v", err)
+		}
+	})
+
+	t.Run("Fail fast clean restores removed links", func(t *testing.T) {
+		memory := setup()
+		memory.Remove("/home/user/.aliases")
+		memory.Symlink("/dotfiles/aliases", "/home/user/.aliases")
+		memory.Remove("/home/user/.ssh/authorized_keys")
+		memory.Symlink("/dotfiles/ssh/authorized_keys", "/home/user/.ssh/authorized_keys")
+
+		var err error
+		captureOutput(t, func() {
+			err = CleanWithOptions([]string{"general"}, CleanOptions{FailFast: true})
+		})
+		if err == nil || !strings.Contains(err.Error(), "1 change(s) rolled back") {
+			t.Errorf("Expected fail fast error, got %v", err)
+		}
+		if target, _ := memory.Readlink("/home/user/.aliases"); target != "/dotfiles/aliases" {
+			t.Errorf("Expected the .aliases link to be restored, got %q", target)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package linker
+
+import (
+	"errors"
+
+	"github.com/yourusername/dot/internal/journal"
+)
+
+// linkFallback links target to source with a link of another kind where symlinks are
+// not permitted, returning what it made, e.g. "junction"
+// errors.ErrUnsupported means the platform has no such link for source, so it is copied
+var linkFallback = platformLink
+
+// fallbackMapping stands in for the symlink a mapping's directory refused: a junction or
+// hard link where the platform has one, or else a copy
+// Such links are tracked like copies, so check and clean treat them alike; since they
+// share the source's content they stay current until an editor replaces the file
+func fallbackMapping(cache *dirCache, m mapping, dryRun bool, out *output) {
+	const reason = "symlinks not permitted"
+	// A hard link cannot have line endings of its own
+	if m.eol == "" {
+		mode, _ := cache.lstat(m.sourcePath)
+		kind, err := linkFallback(cache.fs, m.sourcePath, m.targetPath, mode.IsDir())
+		if err == nil {
+			cache.set(m.targetPath, mode)
+			out.record(journal.OpCopy, m.targetPath, m.sourcePath)
+			out.printfColor("yellow", "Linked with %s (%s): %s -> %s\n", kind, reason, m.targetPath, m.sourcePath)
+			return
+		}
+		if !errors.Is(err, errors.ErrUnsupported) {
+			out.printfColor("yellow", "Warning: failed to create %s for %s, copying instead: %v\n", kind, m.targetPath, err)
+		}
+	}
+	copyMapping(cache, m, dryRun, reason, out)
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package linker
+
+import (
+	"errors"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+// platformLink has nothing to offer where symlinks are refused outside Windows, as on
+// FAT and some network filesystems, which lack hard links as well
+func platformLink(f fsys.FS, source, target string, dir bool) (string, error) {
+	return "", errors.ErrUnsupported
+}
@@ -0,0 +1,113 @@
+package linker
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+func TestLinkFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalFallback := linkFallback
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		linkFallback = originalFallback
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	// Files get a stand-in for a hard link; directories have no fallback
+	linkFallback = func(f fsys.FS, source, target string, dir bool) (string, error) {
+		if dir {
+			return "", errors.ErrUnsupported
+		}
+		return "hard link", utils.CopyTreeFS(f, source, target)
+	}
+
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	setup := func(mappings string) *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = noSymlinks{memory}
+		memory.MkdirAll("/dotfiles/nvim", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte(mappings), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+		memory.WriteFile("/dotfiles/nvim/init.lua", []byte("lua"), 0644)
+		return memory
+	}
+
+	t.Run("Files are linked with the fallback", func(t *testing.T) {
+		setup("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"nvim\" = \"~/.config/nvim\"\n")
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Linked with hard link (symlinks not permitted): /home/user/.zshrc -> /dotfiles/zshrc") {
+			t.Errorf("Expected a hard link message, got: %s", output)
+		}
+		if !strings.Contains(output, "Copied (symlinks not permitted): /home/user/.config/nvim <- /dotfiles/nvim") {
+			t.Errorf("Expected the directory to be copied, got: %s", output)
+		}
+	})
+
+	t.Run("Fallback links are tracked like copies", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := Check([]string{"general"}); err != nil {
+				t.Errorf("Check failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "All links are correct") {
+			t.Errorf("Expected all links correct, got: %s", output)
+		}
+	})
+
+	t.Run("Entries with line endings are copied", func(t *testing.T) {
+		setup("[general]\n\"zshrc\" = { target = \"~/.zshrc\", eol = \"crlf\" }\n")
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Copied (symlinks not permitted): /home/user/.zshrc <- /dotfiles/zshrc") {
+			t.Errorf("Expected a copy, got: %s", output)
+		}
+	})
+
+	t.Run("Failed fallbacks are copied with a warning", func(t *testing.T) {
+		memory := setup("[general]\n\"zshrc\" = \"~/.zshrc\"\n")
+		linkFallback = func(f fsys.FS, source, target string, dir bool) (string, error) {
+			return "hard link", errors.New("not the same device")
+		}
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Warning: failed to create hard link for /home/user/.zshrc, copying instead: not the same device") {
+			t.Errorf("Expected a warning, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/home/user/.zshrc"); string(data) != "zsh" {
+			t.Errorf("Expected .zshrc to be copied, got '%s'", data)
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package linker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+// platformLink creates an NTFS junction for a directory and a hard link for a file,
+// neither of which needs the privilege symlinks do
+// Junctions need an absolute source and hard links one on the same volume, so either
+// may fail and leave the source to be copied
+// Only the real filesystem has them; dry runs show the copy that would be made instead
+func platformLink(f fsys.FS, source, target string, dir bool) (string, error) {
+	if _, real := f.(fsys.OS); !real {
+		return "", errors.ErrUnsupported
+	}
+	if !dir {
+		return "hard link", os.Link(source, target)
+	}
+
+	output, err := exec.Command("cmd", "/c", "mklink", "/J", target, source).CombinedOutput()
+	if err != nil {
+		return "junction", fmt.Errorf("mklink: %s", strings.TrimSpace(string(output)))
+	}
+	return "junction", nil
+}
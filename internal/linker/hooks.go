@@ -0,0 +1,206 @@
+package linker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/settings"
+	"github.com/yourusername/dot/internal/wsl"
+)
+
+// defaultHookTimeout stops hooks that hang, unless the global config or the entry says otherwise
+const defaultHookTimeout = 2 * time.Minute
+
+// hook is a shell command triggered by one or more mappings during a run
+type hook struct {
+	command string
+	targets []string
+	policy  hookPolicy
+	profile string // the profile whose pre_link or post_link hook it is, "" for entry hooks
+}
+
+// hookPolicy is how a hook is run
+type hookPolicy struct {
+	timeout time.Duration // 0 lets the hook run as long as it takes
+	abort   bool          // a failing hook fails the run and skips the hooks after it
+	capture bool          // output is only shown when the hook fails
+}
+
+// loadHookPolicy returns the policy from the [hooks] defaults of the global config
+func loadHookPolicy() (hookPolicy, error) {
+	cfg, err := settings.Load()
+	if err != nil {
+		return hookPolicy{}, err
+	}
+	return hookPolicy{timeout: defaultHookTimeout}.with(cfg.Hooks), nil
+}
+
+// with returns the policy with the options that are set overriding it
+// The options are validated when the config is parsed
+func (p hookPolicy) with(options settings.Hooks) hookPolicy {
+	if options.Timeout != "" {
+		p.timeout, _ = time.ParseDuration(options.Timeout)
+	}
+	if options.OnFailure != "" {
+		p.abort = options.OnFailure == "abort"
+	}
+	if options.Output != "" {
+		p.capture = options.Output == "capture"
+	}
+	return p
+}
+
+// collectHooks returns the hooks triggered by the given journal actions, in the order they were
+// first triggered; each distinct command appears once, with every target that triggered it
+// ops selects the actions that trigger a hook and command picks the hook from a mapping's entry
+// A hook is run with policy, overridden by the options of the entry that first triggered it
+func collectHooks(cfg *config.Config, mappings []mapping, actions []journal.Action, ops []string, policy hookPolicy, command func(config.Entry) string) []hook {
+	byTarget := make(map[string]mapping, len(mappings))
+	for _, m := range mappings {
+		byTarget[m.targetPath] = m
+	}
+
+	var hooks []hook
+	index := make(map[string]int)
+
+	for _, action := range actions {
+		if !slices.Contains(ops, action.Op) {
+			continue
+		}
+		m, mapped := byTarget[action.Path]
+		if !mapped {
+			continue
+		}
+		entry := cfg.Entries[m.profile][m.source]
+		cmd := command(entry)
+		if cmd == "" {
+			continue
+		}
+
+		if i, seen := index[cmd]; seen {
+			hooks[i].targets = append(hooks[i].targets, action.Path)
+			continue
+		}
+		index[cmd] = len(hooks)
+		hooks = append(hooks, hook{command: cmd, targets: []string{action.Path}, policy: policy.with(entry.Hooks())})
+	}
+
+	return hooks
+}
+
+// profileHooks returns the hooks that command picks from the profiles selected by names,
+// in the order they are selected; profiles disabled on this machine have none
+// A profile's hook gets the targets of its mappings and runs with policy, overridden by
+// the options of the profile's hooks
+func profileHooks(cfg *config.Config, names []string, mappings []mapping, policy hookPolicy, command func(config.ProfileHooks) string) ([]hook, error) {
+	profiles, err := cfg.ExpandProfiles(names)
+	if err != nil {
+		return nil, err
+	}
+	man, err := manifest.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var hooks []hook
+	for _, profile := range profiles {
+		options := cfg.Hooks[profile]
+		cmd := command(options)
+		if cmd == "" || slices.Contains(man.Disabled, profile) {
+			continue
+		}
+		var targets []string
+		for _, m := range mappings {
+			if m.profile == profile {
+				targets = append(targets, m.targetPath)
+			}
+		}
+		hooks = append(hooks, hook{command: cmd, targets: targets, policy: policy.with(options.Hooks()), profile: profile})
+	}
+	return hooks, nil
+}
+
+// runHooks runs each hook once in the dotfiles directory
+// The targets that triggered a hook are passed newline-separated in $DOT_TARGETS, the
+// profile of a profile hook in $DOT_PROFILE, and under WSL the Windows home directory
+// in $WINHOME
+// A failing hook is reported but does not fail the run, unless its policy aborts
+func runHooks(name, dotfilesDir string, hooks []hook, dryRun bool) error {
+	for _, h := range hooks {
+		if dryRun {
+			Output.Messagef("", "Would run %s hook: %s\n", name, h.command)
+			continue
+		}
+
+		Output.Messagef("", "Running %s hook: %s\n", name, h.command)
+		if err := runHook(h, dotfilesDir); err != nil {
+			if h.policy.abort {
+				return fmt.Errorf("%s hook %q failed: %w", name, h.command, err)
+			}
+			Output.Messagef("", "Warning: %s hook %q failed: %v\n", name, h.command, err)
+		}
+	}
+	return nil
+}
+
+// runHook runs a single hook, stopping it once its timeout passed
+func runHook(h hook, dotfilesDir string) error {
+	ctx := context.Background()
+	if h.policy.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.policy.timeout)
+		defer cancel()
+	}
+
+	cmd := shellCommand(ctx, h.command)
+	cmd.Dir = dotfilesDir
+	cmd.Env = append(os.Environ(), "DOT_TARGETS="+strings.Join(h.targets, "\n"))
+	if h.profile != "" {
+		cmd.Env = append(cmd.Env, "DOT_PROFILE="+h.profile)
+	}
+	if wsl.Detect() {
+		if home, err := wsl.WindowsHome(); err == nil {
+			cmd.Env = append(cmd.Env, wsl.HomeVar+"="+home)
+		}
+	}
+	killProcessGroup(cmd)
+	// Processes the hook started in the background may hold its output open; do not
+	// wait for them once the hook itself is done
+	cmd.WaitDelay = time.Second
+
+	var captured bytes.Buffer
+	if h.policy.capture {
+		cmd.Stdout = &captured
+		cmd.Stderr = &captured
+	} else {
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+	}
+
+	err := cmd.Run()
+	if err != nil {
+		os.Stderr.Write(captured.Bytes())
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", h.policy.timeout)
+	}
+	return err
+}
+
+// shellCommand returns a command running script with the platform shell
+func shellCommand(ctx context.Context, script string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", script)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", script)
+}
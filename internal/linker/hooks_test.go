@@ -0,0 +1,315 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test use POSIX shell syntax")
+	}
+
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	setup := func(t *testing.T) (string, string) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		os.MkdirAll(filepath.Join(dotfilesDir, "fonts"), 0755)
+		os.MkdirAll(homeDir, 0755)
+		for _, name := range []string{"a.ttf", "b.ttf"} {
+			os.WriteFile(filepath.Join(dotfilesDir, "fonts", name), nil, 0644)
+		}
+		os.WriteFile(filepath.Join(dotfilesDir, "zshrc"), nil, 0644)
+
+		mappings := `[general]
+"fonts/a.ttf" = { target = "` + filepath.Join(homeDir, "a.ttf") + `", on_change = "echo changed >> hooks.log", on_remove = "echo removed >> hooks.log" }
+"fonts/b.ttf" = { target = "` + filepath.Join(homeDir, "b.ttf") + `", on_change = "echo changed >> hooks.log", on_remove = "echo removed >> hooks.log" }
+"zshrc" = "` + filepath.Join(homeDir, ".zshrc") + `"
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+		return dotfilesDir, homeDir
+	}
+
+	readLog := func(dotfilesDir string) string {
+		data, _ := os.ReadFile(filepath.Join(dotfilesDir, "hooks.log"))
+		return strings.TrimSpace(string(data))
+	}
+
+	t.Run("on_change runs once per distinct command", func(t *testing.T) {
+		dotfilesDir, _ := setup(t)
+
+		captureOutput(t, func() {
+			Link([]string{"general"}, false)
+		})
+		if log := readLog(dotfilesDir); log != "changed" {
+			t.Errorf("Expected hook to run once, got %q", log)
+		}
+
+		// Nothing changes on the second run, so the hook does not run again
+		captureOutput(t, func() {
+			Link([]string{"general"}, false)
+		})
+		if log := readLog(dotfilesDir); log != "changed" {
+			t.Errorf("Expected no hook for unchanged links, got %q", log)
+		}
+	})
+
+	t.Run("on_remove runs only for removed links", func(t *testing.T) {
+		dotfilesDir, homeDir := setup(t)
+
+		captureOutput(t, func() {
+			Clean([]string{"general"})
+		})
+		if log := readLog(dotfilesDir); log != "" {
+			t.Errorf("Expected no hook when nothing was removed, got %q", log)
+		}
+
+		os.Symlink(filepath.Join(dotfilesDir, "fonts", "a.ttf"), filepath.Join(homeDir, "a.ttf"))
+		captureOutput(t, func() {
+			Clean([]string{"general"})
+		})
+		if log := readLog(dotfilesDir); log != "removed" {
+			t.Errorf("Expected on_remove hook, got %q", log)
+		}
+	})
+
+	t.Run("Hook receives triggering targets", func(t *testing.T) {
+		dotfilesDir, homeDir := setup(t)
+		mappings := `[general]
+"zshrc" = { target = "` + filepath.Join(homeDir, ".zshrc") + `", on_change = "printf '%s' \"$DOT_TARGETS\" > targets.txt" }
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+		captureOutput(t, func() {
+			Link([]string{"general"}, false)
+		})
+
+		data, _ := os.ReadFile(filepath.Join(dotfilesDir, "targets.txt"))
+		if string(data) != filepath.Join(homeDir, ".zshrc") {
+			t.Errorf("Expected target in DOT_TARGETS, got %q", data)
+		}
+	})
+
+	t.Run("Dry run only reports hooks", func(t *testing.T) {
+		dotfilesDir, _ := setup(t)
+
+		output := captureOutput(t, func() {
+			Link([]string{"general"}, true)
+		})
+
+		if log := readLog(dotfilesDir); log != "" {
+			t.Errorf("Expected no hook to run, got %q", log)
+		}
+		if !strings.Contains(output, "Would run on_change hook: echo changed >> hooks.log") {
+			t.Errorf("Expected dry-run hook message, got: %s", output)
+		}
+	})
+
+	t.Run("Failing hook does not fail the run", func(t *testing.T) {
+		dotfilesDir, homeDir := setup(t)
+		mappings := `[general]
+"zshrc" = { target = "` + filepath.Join(homeDir, ".zshrc") + `", on_change = "exit 3" }
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+		var err error
+		output := captureOutput(t, func() {
+			err = Link([]string{"general"}, false)
+		})
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if !strings.Contains(output, "Warning: on_change hook") {
+			t.Errorf("Expected hook warning, got: %s", output)
+		}
+	})
+
+	t.Run("Hook is stopped after its timeout", func(t *testing.T) {
+		dotfilesDir, homeDir := setup(t)
+		mappings := `[general]
+"zshrc" = { target = "` + filepath.Join(homeDir, ".zshrc") + `", on_change = "sleep 10", hook_timeout = "100ms" }
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+		start := time.Now()
+		output := captureOutput(t, func() {
+			Link([]string{"general"}, false)
+		})
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the hook to be stopped, took %s", elapsed)
+		}
+		if !strings.Contains(output, "timed out after 100ms") {
+			t.Errorf("Expected timeout warning, got: %s", output)
+		}
+	})
+
+	t.Run("Aborting hook fails the run", func(t *testing.T) {
+		dotfilesDir, homeDir := setup(t)
+		mappings := `[general]
+"zshrc" = { target = "` + filepath.Join(homeDir, ".zshrc") + `", on_change = "exit 3", hook_on_failure = "abort" }
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+		var err error
+		captureOutput(t, func() {
+			err = Link([]string{"general"}, false)
+		})
+		if err == nil || !strings.Contains(err.Error(), `on_change hook "exit 3" failed`) {
+			t.Errorf("Expected hook failure error, got %v", err)
+		}
+	})
+
+	t.Run("Captured output is only shown on failure", func(t *testing.T) {
+		dotfilesDir, homeDir := setup(t)
+		mappings := `[general]
+"zshrc" = { target = "` + filepath.Join(homeDir, ".zshrc") + `", on_change = "echo quiet", on_remove = "echo loud; exit 1", hook_output = "capture" }
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+		output := captureOutput(t, func() {
+			Link([]string{"general"}, false)
+		})
+		if strings.Contains(output, "\nquiet\n") {
+			t.Errorf("Expected output of a successful hook to be hidden, got: %s", output)
+		}
+
+		output = captureOutput(t, func() {
+			Clean([]string{"general"})
+		})
+		if !strings.Contains(output, "\nloud\n") {
+			t.Errorf("Expected output of a failing hook to be shown, got: %s", output)
+		}
+	})
+
+	t.Run("Global config sets the defaults", func(t *testing.T) {
+		originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+		defer os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+		configHome := t.TempDir()
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("[hooks]\non_failure = \"abort\"\n"), 0644)
+
+		dotfilesDir, homeDir := setup(t)
+		mappings := `[general]
+"zshrc" = { target = "` + filepath.Join(homeDir, ".zshrc") + `", on_change = "exit 3" }
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+		var err error
+		captureOutput(t, func() {
+			err = Link([]string{"general"}, false)
+		})
+		if err == nil {
+			t.Error("Expected the global on_failure to fail the run")
+		}
+	})
+}
+
+func TestProfileHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test use POSIX shell syntax")
+	}
+
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	// setup maps .zshrc with an on_change hook, and logs the profile hooks and the state
+	// of the link when they run
+	setup := func(t *testing.T, hooks string) (string, string) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+		os.MkdirAll(dotfilesDir, 0755)
+		os.MkdirAll(homeDir, 0755)
+		os.WriteFile(filepath.Join(dotfilesDir, "zshrc"), nil, 0644)
+
+		zshrc := filepath.Join(homeDir, ".zshrc")
+		mappings := hooks + `
+[general]
+"zshrc" = { target = "` + zshrc + `", on_change = "echo on_change >> hooks.log" }
+
+[work]
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+		return dotfilesDir, zshrc
+	}
+	readLog := func(dotfilesDir string) string {
+		data, _ := os.ReadFile(filepath.Join(dotfilesDir, "hooks.log"))
+		return strings.TrimSpace(string(data))
+	}
+
+	t.Run("pre_link runs before linking and post_link after on_change", func(t *testing.T) {
+		dotfilesDir, _ := setup(t, `[hooks.general]
+pre_link = "test -e $DOT_TARGETS && echo pre linked >> hooks.log || echo pre $DOT_PROFILE >> hooks.log"
+post_link = "test -L $DOT_TARGETS && echo post linked >> hooks.log"
+`)
+
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if log := readLog(dotfilesDir); log != "pre general\non_change\npost linked" {
+			t.Errorf("Expected pre_link, on_change, and post_link in order, got %q", log)
+		}
+	})
+
+	t.Run("Hooks of profiles that are not selected do not run", func(t *testing.T) {
+		dotfilesDir, _ := setup(t, "[hooks.work]\npost_link = \"echo work >> hooks.log\"\n")
+
+		captureOutput(t, func() {
+			Link([]string{"general"}, false)
+		})
+		if log := readLog(dotfilesDir); log != "on_change" {
+			t.Errorf("Expected only the on_change hook, got %q", log)
+		}
+
+		captureOutput(t, func() {
+			Link([]string{"general", "work"}, false)
+		})
+		if log := readLog(dotfilesDir); log != "on_change\nwork" {
+			t.Errorf("Expected the work hook to run, got %q", log)
+		}
+	})
+
+	t.Run("Aborting pre_link hook links nothing", func(t *testing.T) {
+		_, zshrc := setup(t, "[hooks.general]\npre_link = \"exit 3\"\non_failure = \"abort\"\n")
+
+		var err error
+		captureOutput(t, func() {
+			err = Link([]string{"general"}, false)
+		})
+		if err == nil || !strings.Contains(err.Error(), `pre_link hook "exit 3" failed`) {
+			t.Errorf("Expected hook failure error, got %v", err)
+		}
+		if _, err := os.Lstat(zshrc); !os.IsNotExist(err) {
+			t.Errorf("Expected nothing to be linked, got %v", err)
+		}
+	})
+
+	t.Run("NoHooks skips every hook", func(t *testing.T) {
+		dotfilesDir, zshrc := setup(t, "[hooks.general]\npre_link = \"echo pre >> hooks.log\"\npost_link = \"echo post >> hooks.log\"\n")
+
+		captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{NoHooks: true}); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if log := readLog(dotfilesDir); log != "" {
+			t.Errorf("Expected no hook to run, got %q", log)
+		}
+		if _, err := os.Readlink(zshrc); err != nil {
+			t.Errorf("Expected .zshrc to be linked: %v", err)
+		}
+	})
+}
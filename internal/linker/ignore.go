@@ -0,0 +1,106 @@
+package linker
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFile lists gitignore-style patterns, relative to the dotfiles directory, of
+// files and directories that entries ending in /** do not link
+const ignoreFile = ".dotignore"
+
+// ignorePattern is a line of a gitignore-style list
+type ignorePattern struct {
+	segments []string // the pattern split at slashes, ** matching any number of them
+	anchored bool     // matched against the whole path rather than the name
+	dirOnly  bool     // only matches directories, written with a trailing slash
+	negate   bool     // includes again what an earlier pattern ignored, written with a leading !
+}
+
+// ignoreList is a list of patterns in which the last one matching a path decides
+type ignoreList []ignorePattern
+
+// parseIgnore parses gitignore-style lines; patterns with a slash are relative to base,
+// a directory relative to the dotfiles directory, and lines that are blank or start
+// with # are skipped
+func parseIgnore(lines []string, base string) ignoreList {
+	var list ignoreList
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var p ignorePattern
+		line, p.negate = strings.CutPrefix(line, "!")
+		line, p.dirOnly = strings.CutSuffix(line, "/")
+		if strings.Contains(line, "/") {
+			p.anchored = true
+			line = path.Join(base, strings.TrimPrefix(line, "/"))
+		}
+		if line == "" || line == "." {
+			continue
+		}
+		p.segments = strings.Split(line, "/")
+		list = append(list, p)
+	}
+	return list
+}
+
+// loadIgnore returns the patterns of the dotfiles directory's ignoreFile, none if it
+// does not exist
+func (c *dirCache) loadIgnore(dotfilesDir string) ignoreList {
+	data, err := c.fs.ReadFile(filepath.Join(dotfilesDir, ignoreFile))
+	if err != nil {
+		return nil
+	}
+	return parseIgnore(strings.Split(string(data), "\n"), "")
+}
+
+// ignored reports whether the file or directory at rel, a slash-separated path
+// relative to the dotfiles directory, is to be left out
+func (l ignoreList) ignored(rel string, dir bool) bool {
+	segments := strings.Split(rel, "/")
+	ignored := false
+	for _, p := range l {
+		if p.dirOnly && !dir {
+			continue
+		}
+		var matched bool
+		if p.anchored {
+			matched = matchSegments(p.segments, segments)
+		} else {
+			matched, _ = path.Match(p.segments[0], segments[len(segments)-1])
+		}
+		if matched {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments reports whether the segments of a path match those of a pattern, in
+// which ** matches any number of segments, or at least one at the end of the pattern
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return len(segments) > 0
+		}
+		for i := range len(segments) + 1 {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
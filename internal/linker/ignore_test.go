@@ -0,0 +1,49 @@
+package linker
+
+import "testing"
+
+func TestIgnoreList(t *testing.T) {
+	list := parseIgnore([]string{
+		"# comment",
+		"*.swp",
+		"cache/",
+		"/build",
+		"docs/**/*.pdf",
+		"!keep.swp",
+	}, "nvim")
+
+	tests := []struct {
+		path    string
+		dir     bool
+		ignored bool
+	}{
+		{"nvim/.init.lua.swp", false, true},
+		{"nvim/lua/deep/x.swp", false, true},
+		{"nvim/keep.swp", false, false},
+		{"nvim/cache", true, true},
+		{"nvim/lua/cache", true, true},
+		{"nvim/cache", false, false},
+		{"nvim/build", true, true},
+		{"nvim/lua/build", true, false},
+		{"nvim/docs/a.pdf", false, true},
+		{"nvim/docs/a/b/c.pdf", false, true},
+		{"nvim/init.lua", false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if ignored := list.ignored(test.path, test.dir); ignored != test.ignored {
+				t.Errorf("Expected ignored %v, got %v", test.ignored, ignored)
+			}
+		})
+	}
+
+	t.Run("A trailing ** matches only what is inside", func(t *testing.T) {
+		list := parseIgnore([]string{"vendor/**"}, "")
+		if list.ignored("vendor", true) {
+			t.Errorf("Expected the directory itself not to be ignored")
+		}
+		if !list.ignored("vendor/lib.lua", false) {
+			t.Errorf("Expected a file inside to be ignored")
+		}
+	})
+}
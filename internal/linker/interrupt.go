@@ -0,0 +1,143 @@
+package linker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+)
+
+// InterruptedError is returned by a run that SIGINT or SIGTERM stopped before it processed
+// every mapping; what it completed is kept and recorded in the journal
+type InterruptedError struct {
+	Signal    os.Signal
+	Remaining int // the mappings that were not processed
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("interrupted by %s, %d mapping(s) were not processed", e.Signal, e.Remaining)
+}
+
+// ExitCode returns the exit status for the signal, 128 plus its number as shells report it
+func (e *InterruptedError) ExitCode() int {
+	if s, ok := e.Signal.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 130
+}
+
+// interruption holds off SIGINT and SIGTERM while a run changes the filesystem, so the run
+// stops between mappings instead of in the middle of one
+type interruption struct {
+	signals  chan os.Signal
+	mu       sync.Mutex
+	received os.Signal
+	skipped  int
+}
+
+// catchInterrupts starts holding off signals until stop is called
+func catchInterrupts() *interruption {
+	i := &interruption{signals: make(chan os.Signal, 1)}
+	signal.Notify(i.signals, os.Interrupt, syscall.SIGTERM)
+	return i
+}
+
+// stop restores the default handling of signals
+func (i *interruption) stop() {
+	signal.Stop(i.signals)
+}
+
+// interrupted reports whether a signal arrived, counting the mapping it is asked for as skipped
+func (i *interruption) interrupted() bool {
+	if i.poll() {
+		i.mu.Lock()
+		i.skipped++
+		i.mu.Unlock()
+		return true
+	}
+	return false
+}
+
+// poll reports whether a signal arrived so far
+func (i *interruption) poll() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.received == nil {
+		select {
+		case s := <-i.signals:
+			i.received = s
+		default:
+		}
+	}
+	return i.received != nil
+}
+
+// readLine reads a line from in, giving up as soon as a signal arrives so a prompt does not
+// hold off an interrupt until it is answered; ok is false if a signal came first
+func (i *interruption) readLine(in *bufio.Reader) (line string, ok bool) {
+	if i == nil {
+		line, _ = in.ReadString('\n')
+		return line, true
+	}
+	if i.poll() {
+		return "", false
+	}
+
+	// The read is left behind if a signal wins, which is harmless as the run stops
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := in.ReadString('\n')
+		lines <- line
+	}()
+	select {
+	case line = <-lines:
+		return line, true
+	case s := <-i.signals:
+		i.mu.Lock()
+		i.received = s
+		i.mu.Unlock()
+		return "", false
+	}
+}
+
+// err returns the error for an interrupted run, or nil
+func (i *interruption) err() error {
+	if i.received == nil {
+		return nil
+	}
+	return &InterruptedError{Signal: i.received, Remaining: i.skipped}
+}
+
+// rollback reverts the changes of a mapping that a signal caught half done: a target that
+// was backed up but not replaced by a link or copy is put back, so no target is left missing
+func (i *interruption) rollback(out *output) {
+	if !i.poll() || !incomplete(out.actions) {
+		return
+	}
+
+	for j := len(out.actions) - 1; j >= 0; j-- {
+		undoAction(&manifest.Manifest{}, nil, out.actions[j])
+	}
+	out.actions = nil
+	out.interrupted = true
+}
+
+// incomplete reports whether the actions of a mapping moved its target away without
+// putting a link or copy in its place
+func incomplete(actions []journal.Action) bool {
+	backedUp := false
+	for _, action := range actions {
+		switch action.Op {
+		case journal.OpBackup, journal.OpRemoveLink, journal.OpRemoveCopy, journal.OpMove:
+			backedUp = true
+		case journal.OpCreateLink, journal.OpCopy:
+			return false
+		}
+	}
+	return backedUp
+}
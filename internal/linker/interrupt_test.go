@@ -0,0 +1,150 @@
+package linker
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/journal"
+)
+
+func TestInterruption(t *testing.T) {
+	t.Run("Stops at the first mapping after the signal", func(t *testing.T) {
+		intr := &interruption{signals: make(chan os.Signal, 1)}
+		if intr.interrupted() {
+			t.Fatal("Expected no interruption before a signal")
+		}
+
+		intr.signals <- os.Interrupt
+		for range 3 {
+			if !intr.interrupted() {
+				t.Fatal("Expected interruption after a signal")
+			}
+		}
+
+		var interrupted *InterruptedError
+		if !errors.As(intr.err(), &interrupted) {
+			t.Fatalf("Expected InterruptedError, got %v", intr.err())
+		}
+		if interrupted.Remaining != 3 {
+			t.Errorf("Expected 3 remaining mappings, got %d", interrupted.Remaining)
+		}
+		if interrupted.ExitCode() != 130 {
+			t.Errorf("Expected exit code 130, got %d", interrupted.ExitCode())
+		}
+	})
+
+	t.Run("No signal is no error", func(t *testing.T) {
+		intr := &interruption{signals: make(chan os.Signal, 1)}
+		if err := intr.err(); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("A signal aborts a pending prompt", func(t *testing.T) {
+		intr := &interruption{signals: make(chan os.Signal, 1)}
+		in, w := io.Pipe()
+		defer w.Close()
+		r := &conflictResolver{policy: OnConflictPrompt, in: bufio.NewReader(in), intr: intr}
+
+		resolved := make(chan string)
+		go func() {
+			resolved <- r.resolve("/home/user/.zshrc", "a file in the way")
+		}()
+		intr.signals <- os.Interrupt
+
+		select {
+		case policy := <-resolved:
+			if policy != OnConflictSkip {
+				t.Errorf("Expected %s, got %s", OnConflictSkip, policy)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the prompt to give up on the signal")
+		}
+		if intr.err() == nil {
+			t.Error("Expected the run to be interrupted")
+		}
+		if policy := r.resolve("/home/user/.bashrc", "a file in the way"); policy != OnConflictSkip {
+			t.Errorf("Expected no further prompt after the signal, got %s", policy)
+		}
+	})
+
+	t.Run("SIGTERM has its own exit code", func(t *testing.T) {
+		err := &InterruptedError{Signal: syscall.SIGTERM}
+		if err.ExitCode() != 143 {
+			t.Errorf("Expected exit code 143, got %d", err.ExitCode())
+		}
+	})
+}
+
+func TestRollback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	defer func() { FS = originalFS }()
+
+	setup := func() *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/home/user/.zshrc.bak", []byte("old"), 0644)
+		return memory
+	}
+	backedUp := []journal.Action{journal.NewAction(journal.OpBackup, "/home/user/.zshrc", "/home/user/.zshrc.bak")}
+
+	t.Run("Half done mapping is put back", func(t *testing.T) {
+		memory := setup()
+		intr := &interruption{signals: make(chan os.Signal, 1)}
+		intr.signals <- os.Interrupt
+		out := &output{actions: backedUp}
+
+		captureOutput(t, func() {
+			intr.rollback(out)
+		})
+
+		data, err := memory.ReadFile("/home/user/.zshrc")
+		if err != nil || string(data) != "old" {
+			t.Errorf("Expected the backup to be restored, got %q, %v", data, err)
+		}
+		if len(out.actions) != 0 {
+			t.Errorf("Expected reverted actions to be dropped, got %v", out.actions)
+		}
+	})
+
+	t.Run("Completed mapping is kept", func(t *testing.T) {
+		memory := setup()
+		memory.Symlink("/dotfiles/zshrc", "/home/user/.zshrc")
+		intr := &interruption{signals: make(chan os.Signal, 1)}
+		intr.signals <- os.Interrupt
+		out := &output{actions: append(backedUp, journal.NewAction(journal.OpCreateLink, "/home/user/.zshrc", "/dotfiles/zshrc"))}
+
+		intr.rollback(out)
+
+		if link, err := memory.Readlink("/home/user/.zshrc"); err != nil || link != "/dotfiles/zshrc" {
+			t.Errorf("Expected the link to be kept, got %q, %v", link, err)
+		}
+		if len(out.actions) != 2 {
+			t.Errorf("Expected the actions to be kept, got %v", out.actions)
+		}
+	})
+
+	t.Run("Nothing is rolled back without a signal", func(t *testing.T) {
+		memory := setup()
+		intr := &interruption{signals: make(chan os.Signal, 1)}
+		out := &output{actions: backedUp}
+
+		intr.rollback(out)
+
+		if _, err := memory.Lstat("/home/user/.zshrc"); err == nil {
+			t.Error("Expected the backup to stay in place")
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package linker
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ListRecord is a mapping as list prints it with --output json
+type ListRecord struct {
+	State      string `json:"state"`
+	Target     string `json:"target"`
+	Source     string `json:"source"`      // relative to the dotfiles directory, as .mappings resolves it
+	SourcePath string `json:"source_path"` // absolute, with alternates resolved
+	Profile    string `json:"profile"`
+	Note       string `json:"note,omitempty"`
+	// Size, Modified, and Linked are only set with ListOptions.Long, and Modified and
+	// Linked only when known
+	Size     *int64     `json:"size,omitempty"`
+	Modified *time.Time `json:"modified,omitempty"`
+	Linked   *time.Time `json:"linked,omitempty"`
+}
+
+// writeListJSON writes the mappings to w as a JSON array of ListRecord
+func writeListJSON(w io.Writer, cache *dirCache, mappings []mapping, long bool, linked map[string]time.Time) error {
+	records := make([]ListRecord, 0, len(mappings))
+	for _, m := range mappings {
+		state, note := listState(cache, m)
+		record := ListRecord{
+			State:      string(state),
+			Target:     m.targetPath,
+			Source:     m.source,
+			SourcePath: m.sourcePath,
+			Profile:    m.profile,
+			Note:       note,
+		}
+		if long {
+			d := detailsOf(m, linked)
+			record.Size = &d.size
+			if !d.modified.IsZero() {
+				record.Modified = &d.modified
+			}
+			if !d.linked.IsZero() {
+				record.Linked = &d.linked
+			}
+		}
+		records = append(records, record)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
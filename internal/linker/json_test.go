@@ -0,0 +1,101 @@
+package linker
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// captureStdout returns what fn writes to stdout, discarding what it writes to stderr
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	devNull, _ := os.Open(os.DevNull)
+	defer devNull.Close()
+	os.Stdout, os.Stderr = w, devNull
+
+	done := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.Bytes()
+	}()
+
+	fn()
+
+	w.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	return <-done
+}
+
+func TestJSONOutput(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	homeDir := filepath.Join(tempDir, "home")
+	os.Setenv("DOT_DIR", dotfilesDir)
+	setupTestEnvironment(t, dotfilesDir, homeDir)
+
+	t.Run("List prints a record per mapping", func(t *testing.T) {
+		data := captureStdout(t, func() {
+			if err := ListWithOptions([]string{"general"}, ListOptions{JSON: true, Long: true}); err != nil {
+				t.Errorf("List failed: %v", err)
+			}
+		})
+
+		var records []ListRecord
+		if err := json.Unmarshal(data, &records); err != nil {
+			t.Fatalf("Expected valid JSON, got %v: %s", err, data)
+		}
+		if len(records) != 1 {
+			t.Fatalf("Expected 1 record, got %d", len(records))
+		}
+		r := records[0]
+		if r.State != string(stateNotLinked) || r.Source != "vim/.vimrc" || r.Target != filepath.Join(homeDir, ".vimrc") {
+			t.Errorf("Expected the unlinked .vimrc, got %+v", r)
+		}
+		if r.Size == nil || *r.Size != int64(len("\" vim config")) || r.Modified == nil || r.Linked != nil {
+			t.Errorf("Expected the size and modification time only, got %+v", r)
+		}
+	})
+
+	t.Run("Link and check print their report", func(t *testing.T) {
+		data := captureStdout(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{JSON: true}); err != nil {
+				t.Errorf("Link failed: %v", err)
+			}
+		})
+		var report Report
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("Expected valid JSON, got %v: %s", err, data)
+		}
+		if report.Command != "link" || len(report.Mappings) != 1 || report.Mappings[0].Result != ActionChanged {
+			t.Errorf("Expected the .vimrc to be reported changed, got %+v", report)
+		}
+		if len(report.Mappings[0].Actions) != 1 {
+			t.Errorf("Expected the action that linked it, got %+v", report.Mappings[0].Actions)
+		}
+
+		data = captureStdout(t, func() {
+			if err := CheckWithOptions([]string{"general"}, CheckOptions{JSON: true}); err != nil {
+				t.Errorf("Check failed: %v", err)
+			}
+		})
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("Expected valid JSON, got %v: %s", err, data)
+		}
+		if report.Command != "check" || len(report.Mappings) != 1 || report.Mappings[0].Result != ActionOK {
+			t.Errorf("Expected the .vimrc to be reported ok, got %+v", report)
+		}
+	})
+}
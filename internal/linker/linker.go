@@ -1,73 +1,142 @@
 package linker
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/yourusername/dot/internal/config"
 	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/table"
+	"github.com/yourusername/dot/internal/term"
 	"github.com/yourusername/dot/internal/utils"
 )
 
+// FS is the filesystem the linker reads and writes
+// Tests replace it with an in-memory filesystem
+var FS fsys.FS = fsys.OS{}
+
+// CheckOptions controls how Check reports the links
+type CheckOptions struct {
+	// Format is FormatText to describe issues for people, or FormatAnnotations to print
+	// them as GitHub Actions annotations on stdout
+	Format string
+	// Report is a file to write a JSON report of the run to, see Report
+	Report string
+	// JSON prints the report of the run to stdout, see Report
+	JSON bool
+	// Jobs is how many mappings are checked at once; 0 and 1 check them one at a time
+	Jobs int
+	// Sort is one of SortOrders, the order the mappings are reported in; empty is SortTarget
+	Sort string
+}
+
 // Check verifies that symbolic links exist and point to correct source files
 func Check(profiles []string) error {
+	return CheckWithOptions(profiles, CheckOptions{})
+}
+
+// CheckWithOptions verifies that symbolic links exist and point to correct source files
+func CheckWithOptions(profiles []string, opts CheckOptions) (err error) {
+	rep := newReport(opts.Report != "" || opts.JSON, "check", profiles, nil)
+	defer func() { err = rep.write(opts.Report, opts.JSON, err) }()
+
+	if err := validSort(opts.Sort); err != nil {
+		return err
+	}
+
 	dotfilesDir, err := dotfiles.GetDotfilesDir()
 	if err != nil {
 		return err
 	}
 
-	cfg, err := config.ParseConfig(dotfilesDir)
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
 	if err != nil {
 		return err
 	}
 
-	profileMap, err := cfg.GetProfiles(profiles)
+	selected, err := cfg.Select(profiles)
 	if err != nil {
 		return err
 	}
+	printConflicts(cfg, profiles)
+	selected, disabled, err := withoutDisabled(cfg, selected)
+	if err != nil {
+		return err
+	}
+	printDisabled(disabled)
 
 	var issues []string
+	var broken []mapping
+	cache := newDirCache(FS)
+	mappings := resolveMappings(cache, dotfilesDir, selected)
+	markIgnoreMissing(cfg, mappings, false)
+	if err := markCopies(cfg, mappings); err != nil {
+		return err
+	}
 
-	for source, target := range profileMap {
-		targetPath := utils.ExpandPath(target)
-		sourcePath := filepath.Join(dotfilesDir, source)
+	collided := make(map[string]caseCollision)
+	for _, c := range caseCollisions(mappings) {
+		collided[c.m.targetPath] = c
+	}
+	// Which of two colliding targets comes first does not depend on the order they are reported in
+	sortMappings(mappings, opts.Sort)
 
-		// Check if target exists
-		stat, err := os.Lstat(targetPath)
-		if os.IsNotExist(err) {
-			issues = append(issues, fmt.Sprintf("Missing link: %s", targetPath))
-			continue
+	// Checking changes nothing, so every mapping can be checked at the same time as any other
+	results := make([]checkResult, len(mappings))
+	parallel(len(mappings), opts.Jobs, func(i int) {
+		m := mappings[i]
+		start := time.Now()
+		defer func() { results[i].duration = time.Since(start) }()
+		// A source that is expected to be absent on this machine has nothing to link
+		if m.ignoreMissing && !cache.exists(m.sourcePath) {
+			results[i].skipped = true
+			return
 		}
-		if err != nil {
-			issues = append(issues, fmt.Sprintf("Error checking %s: %v", targetPath, err))
-			continue
+		// Two targets that are one file on this filesystem cannot both be linked correctly
+		issue := checkMapping(cache, m)
+		if issue == "" && m.template != "" {
+			issue = staleTemplate(cache, dotfilesDir, m, cfg.Vars)
 		}
-
-		// Check if target is a symbolic link
-		if stat.Mode()&os.ModeSymlink == 0 {
-			issues = append(issues, fmt.Sprintf("Not a symlink: %s", targetPath))
-			continue
+		if issue == "" && m.encrypted != "" {
+			issue = staleSecret(cache, m)
 		}
-
-		// Check if link points to correct source
-		linkTarget, err := os.Readlink(targetPath)
-		if err != nil {
-			issues = append(issues, fmt.Sprintf("Error reading link %s: %v", targetPath, err))
-			continue
+		if c, found := collided[m.targetPath]; found {
+			issue = c.String()
 		}
+		results[i].issue = issue
+	})
 
-		if linkTarget != sourcePath {
-			issues = append(issues, fmt.Sprintf("Incorrect link: %s -> %s (expected: %s)", targetPath, linkTarget, sourcePath))
+	for i, m := range mappings {
+		switch r := results[i]; {
+		case r.skipped:
+			settle(rep, m, ActionSkipped, "", r.duration)
+		case r.issue != "":
+			issues = append(issues, r.issue)
+			broken = append(broken, m)
+			settle(rep, m, ActionIssue, r.issue, r.duration)
+		default:
+			settle(rep, m, ActionOK, "", r.duration)
 		}
 	}
 
 	if len(issues) == 0 {
-		fmt.Println("All links are correct")
+		Output.Messagef("", "All links are correct\n")
 	} else {
-		for _, issue := range issues {
-			fmt.Fprintf(os.Stderr, "%s\n", issue)
+		if opts.Format == FormatAnnotations {
+			printAnnotations(os.Stdout, dotfilesDir, cfg, broken, issues)
+		} else {
+			for _, issue := range issues {
+				Output.Messagef("", "%s\n", issue)
+			}
 		}
 		return fmt.Errorf("found %d issue(s)", len(issues))
 	}
@@ -75,133 +144,639 @@ func Check(profiles []string) error {
 	return nil
 }
 
+// checkResult is the outcome of checking a mapping
+type checkResult struct {
+	issue    string // what is wrong with the link, "" if nothing is
+	skipped  bool   // the source is missing where it may be
+	duration time.Duration
+}
+
+// checkMapping returns a description of what is wrong with a mapping's link, or "" if it is correct
+func checkMapping(cache *dirCache, m mapping) string {
+	// Check if target exists
+	mode, err := cache.lstat(m.targetPath)
+	if os.IsNotExist(err) {
+		return fmt.Sprintf("Missing link: %s", m.targetPath)
+	}
+	if err != nil {
+		return fmt.Sprintf("Error checking %s: %v", m.targetPath, err)
+	}
+
+	// A copy made in place of a symlink is correct while it matches its source
+	if mode&os.ModeSymlink == 0 && m.copied != nil {
+		state, err := compareCopy(cache, m)
+		if err != nil {
+			return fmt.Sprintf("Error comparing %s with %s: %v", m.targetPath, m.sourcePath, err)
+		}
+		return describeCopy(m, state)
+	}
+
+	// Check if target is a symbolic link
+	if mode&os.ModeSymlink == 0 {
+		return fmt.Sprintf("Not a symlink: %s", m.targetPath)
+	}
+
+	// Check if link points to correct source
+	linkTarget, err := cache.fs.Readlink(m.targetPath)
+	if err != nil {
+		return fmt.Sprintf("Error reading link %s: %v", m.targetPath, err)
+	}
+
+	if !utils.SamePath(linkTarget, m.sourcePath) {
+		return fmt.Sprintf("Incorrect link: %s -> %s (expected: %s)", m.targetPath, linkTarget, m.sourcePath)
+	}
+
+	return ""
+}
+
+// CleanOptions configures a clean run
+type CleanOptions struct {
+	// FailFast stops at the first mapping that fails and restores what was removed
+	FailFast bool
+	// Interactive lists the links and removes only those picked, after a preview
+	Interactive bool
+	// Input is where picks are read from when Interactive is set, os.Stdin if nil
+	Input io.Reader
+	// RestoreBackups moves the backup that link made back into place once the link
+	// is removed, so cleaning leaves the machine as it was before linking
+	RestoreBackups bool
+}
+
 // Clean removes all registered symbolic links
 func Clean(profiles []string) error {
+	return CleanWithOptions(profiles, CleanOptions{})
+}
+
+// CleanWithOptions removes all registered symbolic links
+// A mapping that fails does not stop the others unless opts.FailFast is set; the run
+// returns an error summarizing every failure
+func CleanWithOptions(profiles []string, opts CleanOptions) error {
 	dotfilesDir, err := dotfiles.GetDotfilesDir()
 	if err != nil {
 		return err
 	}
 
-	cfg, err := config.ParseConfig(dotfilesDir)
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
 	if err != nil {
 		return err
 	}
 
-	profileMap, err := cfg.GetProfiles(profiles)
+	selected, err := cfg.Select(profiles)
 	if err != nil {
 		return err
 	}
 
-	for _, target := range profileMap {
-		targetPath := utils.ExpandPath(target)
+	policy, err := loadHookPolicy()
+	if err != nil {
+		return err
+	}
+	protected, err := loadProtection()
+	if err != nil {
+		return err
+	}
 
-		// Check if target exists and is a symlink
-		stat, err := os.Lstat(targetPath)
-		if os.IsNotExist(err) {
-			fmt.Printf("Skipped (not found): %s\n", targetPath)
-			continue
-		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error checking %s: %v\n", targetPath, err)
-			continue
+	cache := newDirCache(FS)
+	mappings := resolveMappings(cache, dotfilesDir, selected)
+	if err := markCopies(cfg, mappings); err != nil {
+		return err
+	}
+	if opts.Interactive {
+		if mappings = pickMappings(cache, dotfilesDir, mappings, bufio.NewReader(inputOrStdin(opts.Input))); len(mappings) == 0 {
+			return nil
 		}
+	}
 
-		if stat.Mode()&os.ModeSymlink == 0 {
-			fmt.Printf("Skipped (not a symlink): %s\n", targetPath)
-			continue
+	// A signal stops the run between mappings; what was removed so far is recorded
+	intr := catchInterrupts()
+	defer intr.stop()
+	ff := newFailFast(opts.FailFast)
+	actions, failed := forEachMapping(mappings, nil, nil, nil, func(m mapping, out *output) {
+		if intr.interrupted() || ff.stopped(m, out) {
+			return
 		}
-
-		// Remove the symlink
-		if err := os.Remove(targetPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error removing %s: %v\n", targetPath, err)
-		} else {
-			fmt.Printf("Removed: %s\n", targetPath)
+		cleanMapping(cache, m, protected, out)
+		if opts.RestoreBackups && !out.failed {
+			restoreBackupOf(cache, m, protected, out)
 		}
+		ff.observe(out)
+	})
+	// A run stopped at its first failure is rolled back, so there is nothing to record
+	if err := ff.rollback(actions, false); err != nil {
+		return errors.Join(failed, err)
+	}
+	journal.Record("clean", profiles, actions)
+	manifest.Record(FS, actions)
+	if err := intr.err(); err != nil {
+		return err
 	}
 
-	return nil
+	// on_remove hooks only run for links and copies that were actually removed
+	return errors.Join(failed, runHooks("on_remove", dotfilesDir, collectHooks(cfg, mappings, actions, []string{journal.OpRemoveLink, journal.OpRemoveCopy}, policy, func(e config.Entry) string {
+		return e.OnRemove
+	}), false))
+}
+
+// cleanMapping removes the symlink at a mapping's target, unless it is protected
+func cleanMapping(cache *dirCache, m mapping, protected protection, out *output) {
+	// Check if target exists and is a symlink
+	mode, err := cache.lstat(m.targetPath)
+	if os.IsNotExist(err) {
+		out.printf("Skipped (not found): %s\n", m.targetPath)
+		return
+	}
+	if err != nil {
+		out.errorf("Error checking %s: %v\n", m.targetPath, err)
+		return
+	}
+
+	if mode&os.ModeSymlink == 0 && m.copied != nil {
+		cleanCopy(cache, m, protected, out)
+		return
+	}
+
+	if mode&os.ModeSymlink == 0 {
+		out.printf("Skipped (not a symlink): %s\n", m.targetPath)
+		return
+	}
+	if refuseProtected(protected, m.targetPath, "remove", out) {
+		return
+	}
+
+	linkTarget, err := cache.fs.Readlink(m.targetPath)
+	if err != nil {
+		out.errorf("Error reading link %s: %v\n", m.targetPath, err)
+		return
+	}
+
+	// Remove the symlink
+	if err := cache.fs.Remove(m.targetPath); err != nil {
+		out.errorf("Error removing %s: %v\n", m.targetPath, err)
+	} else {
+		cache.remove(m.targetPath)
+		out.record(journal.OpRemoveLink, m.targetPath, linkTarget)
+		out.printf("Removed: %s\n", m.targetPath)
+	}
+}
+
+// LinkOptions controls how Link processes the mappings
+type LinkOptions struct {
+	// DryRun reports what would change without touching the filesystem
+	DryRun bool
+	// Strict turns a missing source into an error; nothing is linked in that case
+	Strict bool
+	// IgnoreMissing skips missing sources without a warning, as if every entry set ignore_missing
+	IgnoreMissing bool
+	// Report is a file to write a JSON report of the run to, see Report
+	Report string
+	// JSON prints the report of the run to stdout, see Report
+	JSON bool
+	// OnConflict is one of OnConflictPolicies, what to do with a file or another link
+	// at a target; empty backs up
+	OnConflict string
+	// Input is where answers are read from when OnConflict is prompt, os.Stdin if nil
+	Input io.Reader
+	// Timings prints how long each phase of the run and the slowest mappings took
+	Timings bool
+	// FailFast stops at the first mapping that fails and rolls back what the run changed
+	FailFast bool
+	// NoHooks skips the pre_link and post_link hooks of profiles and the on_change hooks
+	// of entries
+	NoHooks bool
+	// Jobs is how many mappings are linked at once; 0 and 1 link them one at a time
+	// Mappings that depend on each other are still linked in order, see chainMappings,
+	// and the prompt policy always asks about one target at a time
+	Jobs int
+	// Sort is one of SortOrders, the order the mappings are reported in; empty is
+	// SortTarget, the order they are linked in unless after puts one before another
+	Sort string
+
+	// changed limits the run to the mappings whose source is one of these paths, lies
+	// beneath one, or contains one, see affectedBy; nil links every mapping
+	changed []string
 }
 
 // Link creates symbolic links based on the .mappings file
 func Link(profiles []string, dryRun bool) error {
+	return LinkWithOptions(profiles, LinkOptions{DryRun: dryRun})
+}
+
+// LinkWithOptions creates symbolic links based on the .mappings file
+// A mapping that fails does not stop the others unless opts.FailFast is set; the run
+// returns an error summarizing every failure
+func LinkWithOptions(profiles []string, opts LinkOptions) error {
+	return runLink(newLinkReport(opts.Report != "" || opts.JSON, profiles, opts), profiles, opts)
+}
+
+// newLinkReport starts the report of a link run, or returns nil when enabled is false
+func newLinkReport(enabled bool, profiles []string, opts LinkOptions) *Report {
+	return newReport(enabled, "link", profiles, map[string]bool{
+		"dry_run":        opts.DryRun,
+		"strict":         opts.Strict,
+		"ignore_missing": opts.IgnoreMissing,
+	})
+}
+
+// runLink implements LinkWithOptions, recording the outcome of every mapping in rep
+// unless it is nil; rep is only written out where opts asks for a report
+func runLink(rep *Report, profiles []string, opts LinkOptions) (err error) {
+	dryRun := opts.DryRun
+	// The report includes the timings of a run even without --timings
+	tm := newTimings(opts.Timings || rep != nil)
+	tm.begin(phaseConfig)
+	defer func() {
+		tm.end()
+		if opts.Timings {
+			tm.print()
+		}
+		rep.addTimings(tm)
+		err = rep.write(opts.Report, opts.JSON, err)
+	}()
+
+	if opts.OnConflict != "" && !validOnConflict(opts.OnConflict) {
+		return fmt.Errorf("invalid --on-conflict %q, must be one of: %s", opts.OnConflict, strings.Join(OnConflictPolicies, ", "))
+	}
+	if err := validSort(opts.Sort); err != nil {
+		return err
+	}
+
 	dotfilesDir, err := dotfiles.GetDotfilesDir()
 	if err != nil {
 		return err
 	}
+	if err := dotfiles.VerifyCheckout(dotfilesDir); err != nil {
+		return err
+	}
 
-	cfg, err := config.ParseConfig(dotfilesDir)
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
 	if err != nil {
 		return err
 	}
 
-	profileMap, err := cfg.GetProfiles(profiles)
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return err
+	}
+	printConflicts(cfg, profiles)
+	selected, disabled, err := withoutDisabled(cfg, selected)
 	if err != nil {
 		return err
 	}
+	printDisabled(disabled)
 
-	for source, target := range profileMap {
-		targetPath := utils.ExpandPath(target)
-		sourcePath := filepath.Join(dotfilesDir, source)
+	policy, err := loadHookPolicy()
+	if err != nil {
+		return err
+	}
+	protected, err := loadProtection()
+	if err != nil {
+		return err
+	}
+	conflicts := newConflictResolver(opts.OnConflict, opts.Input, protected)
 
-		// Check if source file exists
-		if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
-			utils.FprintfColor(os.Stderr, "yellow", "Warning: Source file does not exist: %s\n", sourcePath)
-			continue
+	// A dry run performs the same steps against an overlay of the filesystem, so that
+	// directory creation, backups, and mappings that depend on earlier ones are
+	// reported exactly as a real run would carry them out
+	target := FS
+	if dryRun {
+		target = fsys.NewOverlay(FS)
+	}
+
+	tm.begin(phaseResolve)
+	cache := newDirCache(target)
+	tm.watch(cache)
+	mappings, err := orderMappings(cfg, resolveMappings(cache, dotfilesDir, selected))
+	if err != nil {
+		return err
+	}
+	if opts.changed != nil {
+		if mappings = affectedBy(cache.fs, dotfilesDir, mappings, opts.changed); len(mappings) == 0 {
+			return nil
+		}
+	}
+	markIgnoreMissing(cfg, mappings, opts.IgnoreMissing)
+	if err := markCopies(cfg, mappings); err != nil {
+		return err
+	}
+	printCaseCollisions(mappings)
+
+	// In strict mode every source must exist before anything is changed, so a
+	// provisioning run never leaves the machine half linked
+	if opts.Strict {
+		var missing int
+		for _, m := range mappings {
+			// Templates and encrypted files exist, or they would be the source, and are
+			// rendered or decrypted while linking
+			if !m.ignoreMissing && m.template == "" && m.encrypted == "" && !cache.exists(m.sourcePath) {
+				Output.Messagef("red", "Error: Source file does not exist: %s\n", m.sourcePath)
+				settle(rep, m, ActionFailed, "Error: Source file does not exist: "+m.sourcePath, 0)
+				missing++
+			}
+		}
+		if missing > 0 {
+			return fmt.Errorf("%d source file(s) missing, nothing was linked", missing)
+		}
+	}
+
+	// pre_link hooks run once the run is known to go ahead, before anything is linked
+	tm.begin(phasePreLink)
+	if !opts.NoHooks {
+		pre, err := profileHooks(cfg, profiles, mappings, policy, func(h config.ProfileHooks) string {
+			return h.PreLink
+		})
+		if err != nil {
+			return err
+		}
+		if err := runHooks("pre_link", dotfilesDir, pre, dryRun); err != nil {
+			return err
+		}
+	}
+
+	// A signal stops the run between mappings; a mapping it caught half done is rolled
+	// back and what was completed so far is recorded
+	tm.begin(phaseLink)
+	intr := catchInterrupts()
+	defer intr.stop()
+	conflicts.intr = intr
+	ff := newFailFast(opts.FailFast)
+	jobs := opts.Jobs
+	if conflicts.policy == OnConflictPrompt {
+		jobs = 1
+	}
+	actions, failed := forEachMapping(mappings, newSchedule(cfg, mappings, jobs, opts.Sort), rep, tm, func(m mapping, out *output) {
+		if intr.interrupted() {
+			out.interrupted = true
+			return
+		}
+		if ff.stopped(m, out) {
+			return
+		}
+		ready := true
+		switch {
+		case m.template != "":
+			ready = renderMapping(cache, dotfilesDir, m, cfg.Vars, dryRun, out)
+		case m.encrypted != "":
+			ready = decryptMapping(cache, m, dryRun, out)
+		}
+		if ready {
+			linkMapping(cache, m, dryRun, conflicts, out)
 		}
+		if !dryRun {
+			intr.rollback(out)
+		}
+		ff.observe(out)
+	})
+	// A run stopped at its first failure is rolled back, so there is nothing to record
+	if err := ff.rollback(actions, dryRun); err != nil {
+		return errors.Join(failed, err)
+	}
+	if !dryRun {
+		journal.Record("link", profiles, actions)
+		manifest.Record(FS, actions)
+	}
+	if err := intr.err(); err != nil {
+		return err
+	}
 
-		// Handle existing target
-		if stat, err := os.Lstat(targetPath); err == nil {
-			if stat.Mode()&os.ModeSymlink != 0 {
-				// Target is a symlink
-				linkTarget, err := os.Readlink(targetPath)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error reading existing link %s: %v\n", targetPath, err)
-					continue
-				}
-
-				if linkTarget == sourcePath {
-					continue
-				} else {
-					// Remove existing symlink to override it
-					if !dryRun {
-						if err := os.Remove(targetPath); err != nil {
-							fmt.Fprintf(os.Stderr, "Error removing existing link %s: %v\n", targetPath, err)
-							continue
-						}
-					}
-					fmt.Printf("Overriding: %s (was pointing to %s)\n", targetPath, linkTarget)
-				}
+	// on_change hooks only run for links and copies that were created or replaced, and
+	// post_link hooks after them
+	tm.begin(phaseHooks)
+	if opts.NoHooks {
+		return failed
+	}
+	if err := runHooks("on_change", dotfilesDir, collectHooks(cfg, mappings, actions, []string{journal.OpCreateLink, journal.OpCopy}, policy, func(e config.Entry) string {
+		return e.OnChange
+	}), dryRun); err != nil {
+		return errors.Join(failed, err)
+	}
+	post, err := profileHooks(cfg, profiles, mappings, policy, func(h config.ProfileHooks) string {
+		return h.PostLink
+	})
+	if err != nil {
+		return errors.Join(failed, err)
+	}
+	return errors.Join(failed, runHooks("post_link", dotfilesDir, post, dryRun))
+}
+
+// linkMapping creates the symlink for a single mapping, handling what is in the way as
+// conflicts decides
+// In dry-run mode cache reads from an overlay, so every step is still carried out but only reported
+func linkMapping(cache *dirCache, m mapping, dryRun bool, conflicts *conflictResolver, out *output) {
+	targetPath, sourcePath := m.targetPath, m.sourcePath
+
+	// Check if source file exists
+	if !cache.exists(sourcePath) {
+		if !m.ignoreMissing {
+			out.printfColor("yellow", "Warning: Source file does not exist: %s\n", sourcePath)
+		}
+		return
+	}
+
+	// Handle existing target
+	if mode, err := cache.lstat(targetPath); err == nil {
+		if mode&os.ModeSymlink == 0 && m.copied != nil {
+			// Target is a copy made in place of a symlink, replace it only if outdated
+			if refreshCopy(cache, m, dryRun, conflicts.protection(), out) {
+				return
+			}
+		} else if mode&os.ModeSymlink != 0 {
+			// Target is a symlink
+			linkTarget, err := cache.fs.Readlink(targetPath)
+			if err != nil {
+				out.errorf("Error reading existing link %s: %v\n", targetPath, err)
+				return
+			}
+
+			linked := utils.SamePath(linkTarget, sourcePath)
+			if linked && m.mode != config.ModeCopy {
+				return
+			}
+
+			if refuseProtected(conflicts.protection(), targetPath, "replace", out) {
+				return
+			}
+			if !linked && conflicts.resolve(targetPath, "a link to "+linkTarget) == OnConflictSkip {
+				out.printfColor("yellow", "Skipped (conflict): %s (points to %s)\n", targetPath, linkTarget)
+				return
+			}
+
+			// Remove existing symlink to override it
+			if err := cache.fs.Remove(targetPath); err != nil {
+				out.errorf("Error removing existing link %s: %v\n", targetPath, err)
+				return
+			}
+			cache.remove(targetPath)
+			out.record(journal.OpRemoveLink, targetPath, linkTarget)
+			if linked {
+				out.printf("%s: %s\n", verb(dryRun, "Replacing link with copy", "Would replace link with copy"), targetPath)
 			} else {
-				// Target is a file or directory, back it up
-				if !dryRun {
-					if err := utils.BackupFile(targetPath); err != nil {
-						fmt.Fprintf(os.Stderr, "Error backing up %s: %v\n", targetPath, err)
-						continue
-					}
-				}
-				utils.PrintfColor("blue", "Backed up: %s -> %s.bak\n", targetPath, targetPath)
+				out.printf("%s: %s (was pointing to %s)\n", verb(dryRun, "Overriding", "Would override"), targetPath, linkTarget)
 			}
+		} else if refuseProtected(conflicts.protection(), targetPath, "replace", out) {
+			return
+		} else if !resolveTarget(cache, m, mode, conflicts.resolve(targetPath, "a file in the way"), conflicts.backupDir(), dryRun, out) {
+			return
 		}
+	}
 
-		// Create the symlink
+	// Ensure target directory exists
+	if cache.listing(filepath.Dir(targetPath)) == nil {
+		missing, err := cache.mkdirAll(filepath.Dir(targetPath), 0755)
+		if err != nil {
+			out.errorf("Error creating directory for %s: %v\n", targetPath, err)
+			return
+		}
+		for _, dir := range missing {
+			out.record(journal.OpMkdir, dir, "")
+		}
 		if dryRun {
-			fmt.Printf("Would create: %s -> %s\n", targetPath, sourcePath)
+			out.printf("Would create directory: %s\n", filepath.Dir(targetPath))
+		}
+	}
+
+	// A directory that refused one symlink refuses them all, so later targets there fall
+	// back straight away
+	dir := filepath.Dir(targetPath)
+	if m.mode == config.ModeCopy {
+		copyMapping(cache, m, dryRun, "", out)
+		return
+	}
+	if m.mode != config.ModeLink && cache.symlinksUnsupported(dir) {
+		fallbackMapping(cache, m, dryRun, out)
+		return
+	}
+
+	if err := cache.fs.Symlink(sourcePath, targetPath); symlinkNotPermitted(err) {
+		cache.unsupportSymlinks(dir)
+		if m.mode == config.ModeLink {
+			out.errorf("Error creating link %s -> %s: symlinks are not permitted in %s and the mapping's mode is link\n", targetPath, sourcePath, dir)
+			return
+		}
+		fallbackMapping(cache, m, dryRun, out)
+	} else if err != nil {
+		out.errorf("Error creating link %s -> %s: %v\n", targetPath, sourcePath, err)
+	} else {
+		cache.set(targetPath, os.ModeSymlink)
+		out.record(journal.OpCreateLink, targetPath, sourcePath)
+		if dryRun {
+			out.printf("Would create: %s -> %s\n", targetPath, sourcePath)
 		} else {
-			// Ensure target directory exists
-			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				fmt.Fprintf(os.Stderr, "Error creating directory for %s: %v\n", targetPath, err)
-				continue
-			}
+			out.printfColor("green", "Created: %s -> %s\n", targetPath, sourcePath)
+		}
+	}
+}
 
-			if err := os.Symlink(sourcePath, targetPath); err != nil {
-				fmt.Fprintf(os.Stderr, "Error creating link %s -> %s: %v\n", targetPath, sourcePath, err)
-			} else {
-				utils.PrintfColor("green", "Created: %s -> %s\n", targetPath, sourcePath)
+// resolveTarget clears a file or directory of the given mode out of the way of a mapping's
+// link according to policy, backing files up to backups, see newBackupDir
+// Returns false if the target was left in place, so the mapping cannot be linked
+func resolveTarget(cache *dirCache, m mapping, mode os.FileMode, policy, backups string, dryRun bool, out *output) bool {
+	targetPath, sourcePath := m.targetPath, m.sourcePath
+
+	switch policy {
+	case OnConflictSkip:
+		out.printfColor("yellow", "Skipped (conflict): %s (a file is in the way)\n", targetPath)
+		return false
+
+	case OnConflictOverwrite:
+		if err := cache.fs.RemoveAll(targetPath); err != nil {
+			out.errorf("Error removing %s: %v\n", targetPath, err)
+			return false
+		}
+		cache.remove(targetPath)
+		out.printfColor("yellow", "%s: %s\n", verb(dryRun, "Overwriting", "Would overwrite"), targetPath)
+		return true
+
+	case OnConflictAdopt:
+		if m.team {
+			out.printfColor("yellow", "Skipped (source is in the read-only team repository): %s\n", targetPath)
+			return false
+		}
+		if m.template != "" {
+			out.printfColor("yellow", "Skipped (source is rendered from %s, edit the template instead): %s\n", m.template, targetPath)
+			return false
+		}
+		if m.encrypted != "" {
+			out.printfColor("yellow", "Skipped (source is decrypted from %s, encrypt the file with dot encrypt instead): %s\n", m.encrypted, targetPath)
+			return false
+		}
+		// The file replaces the source, whose previous version is backed up so that undo
+		// and rollbacks can put it back, committed or not
+		if sourceMode, err := cache.lstat(sourcePath); err == nil {
+			if !backUp(cache, sourcePath, sourceMode, backups, dryRun, out) {
+				return false
 			}
 		}
+		if err := utils.MovePathFS(cache.fs, targetPath, sourcePath); err != nil {
+			out.errorf("Error moving %s to %s: %v\n", targetPath, sourcePath, err)
+			return false
+		}
+		cache.remove(sourcePath)
+		cache.remove(targetPath)
+		cache.set(sourcePath, mode)
+		out.record(journal.OpMove, targetPath, sourcePath)
+		out.printfColor("green", "%s: %s -> %s\n", verb(dryRun, "Adopted", "Would adopt"), targetPath, sourcePath)
+		return true
 	}
 
-	return nil
+	return backUp(cache, targetPath, mode, backups, dryRun, out)
+}
+
+// backUp moves the file or directory of the given mode at path to a new backup in
+// backups, see newBackupDir
+// Returns false if it could not be backed up and was left in place
+func backUp(cache *dirCache, path string, mode os.FileMode, backups string, dryRun bool, out *output) bool {
+	backup := newBackupPath(backups, path)
+	replacing := cache.exists(backup)
+	missing := missingDirs(cache.fs, filepath.Dir(backup))
+	if err := utils.BackupFileToFS(cache.fs, path, backup); err != nil {
+		out.errorf("Error backing up %s: %v\n", path, err)
+		return false
+	}
+	cache.created(missing)
+	cache.remove(backup)
+	cache.remove(path)
+	cache.set(backup, mode)
+	out.record(journal.OpBackup, path, backup)
+
+	note := ""
+	if replacing {
+		note = " (replaced previous backup)"
+	}
+	out.printfColor("blue", "%s: %s -> %s%s\n", verb(dryRun, "Backed up", "Would back up"), path, backup, note)
+	return true
+}
+
+// missingDirs returns dir and those of its parents that do not exist yet, outermost first
+func missingDirs(f fsys.FS, dir string) []string {
+	var missing []string
+	for ; dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, err := f.Lstat(dir); err == nil {
+			break
+		}
+		missing = append([]string{dir}, missing...)
+	}
+	return missing
+}
+
+// verb returns the message prefix for an action, depending on whether it is only simulated
+func verb(dryRun bool, done, simulated string) string {
+	if dryRun {
+		return simulated
+	}
+	return done
+}
+
+// printConflicts reports targets that several selected profiles map, and which mapping wins
+func printConflicts(cfg *config.Config, profiles []string) {
+	conflicts, err := cfg.Conflicts(profiles)
+	if err != nil || len(conflicts) == 0 {
+		return
+	}
+
+	Output.Messagef("yellow", "Overridden by profile precedence:\n")
+	for _, conflict := range conflicts {
+		Output.Messagef("yellow", "  %s\n", conflict)
+	}
+	Output.Messagef("", "\n")
 }
 
 // ParseProfiles parses a comma-separated list of profile names
@@ -218,63 +793,284 @@ func ParseProfiles(profileStr string) []string {
 	return profiles
 }
 
+// linkState is the state of a mapping's link as reported by list
+type linkState string
+
+const (
+	stateLinked        linkState = "linked"
+	stateNotLinked     linkState = "not-linked"
+	stateNotSymlink    linkState = "not-symlink"
+	stateUnreadable    linkState = "unreadable"
+	stateWrongLink     linkState = "wrong-link"
+	stateSourceMissing linkState = "source-missing"
+	stateCopied        linkState = "copied"
+	stateCopyOutdated  linkState = "copy-outdated"
+	stateCopyEdited    linkState = "copy-edited"
+)
+
+// label returns the state for people, behind its status marker
+func (s linkState) label() string {
+	symbol := term.Error
+	switch s {
+	case stateLinked, stateCopied:
+		symbol = term.OK
+	case stateSourceMissing:
+		symbol = term.Warning
+	}
+	label := strings.ReplaceAll(string(s), "-", " ")
+	if s == stateNotSymlink {
+		label = "not a symlink"
+	}
+	return symbol.String() + " " + label
+}
+
+// ListOptions controls how List prints the links
+type ListOptions struct {
+	// Porcelain prints one tab-separated line per mapping, with state, target, source,
+	// profile, and note, in a format that stays stable for scripts
+	Porcelain bool
+	// Long adds the size and modification time of each source and when its target was
+	// last linked
+	Long bool
+	// JSON prints the mappings as a JSON array of ListRecord instead
+	JSON bool
+	// Sort is one of SortOrders, the order the mappings are listed in; empty is SortTarget
+	Sort string
+}
+
 // List shows all symbolic links that are currently set based on the profiles
 func List(profiles []string) error {
+	return ListWithOptions(profiles, ListOptions{})
+}
+
+// ListWithOptions shows all symbolic links that are currently set based on the profiles
+// The links are printed to stdout and everything else to stderr
+func ListWithOptions(profiles []string, opts ListOptions) error {
+	if err := validSort(opts.Sort); err != nil {
+		return err
+	}
+	cache, dotfilesDir, mappings, err := listMappings(profiles)
+	if err != nil {
+		return err
+	}
+	sortMappings(mappings, opts.Sort)
+
+	var linked map[string]time.Time
+	if opts.Long {
+		linked = loadLinkTimes()
+	}
+
+	if opts.JSON {
+		return writeListJSON(os.Stdout, cache, mappings, opts.Long, linked)
+	}
+	if opts.Porcelain {
+		for _, m := range mappings {
+			state, note := listState(cache, m)
+			line := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", state, m.targetPath, m.sourcePath, m.profile, note)
+			// The long fields follow the note, so scripts reading the first five keep working
+			if opts.Long {
+				d := detailsOf(m, linked)
+				line += fmt.Sprintf("\t%d\t%s\t%s", d.size, porcelainTime(d.modified), porcelainTime(d.linked))
+			}
+			fmt.Println(line)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Dotfiles links for profile(s): %s\n", strings.Join(profiles, ", "))
+	fmt.Fprintln(os.Stderr)
+
+	if len(mappings) == 0 {
+		fmt.Fprintln(os.Stderr, "No dotfile mappings found in the specified profile(s).")
+		return nil
+	}
+
+	links := table.New("STATUS", "TARGET", "SOURCE", "PROFILE", "NOTE")
+	links.Truncatable(1, 2, 4)
+	if opts.Long {
+		links = table.New("STATUS", "TARGET", "SOURCE", "PROFILE", "SIZE", "MODIFIED", "LINKED", "NOTE")
+		links.Truncatable(1, 2, 7)
+	}
+	for _, m := range mappings {
+		state, note := listState(cache, m)
+		source, err := filepath.Rel(dotfilesDir, m.sourcePath)
+		if err != nil {
+			source = m.sourcePath
+		}
+		if state == stateWrongLink {
+			note = "points to " + utils.ContractTarget(note)
+		}
+		if opts.Long {
+			d := detailsOf(m, linked)
+			size := "-"
+			if !d.modified.IsZero() {
+				size = formatSize(d.size)
+			}
+			links.Append(state.label(), utils.ContractTarget(m.targetPath), source, m.profile, size, formatTime(d.modified), formatTime(d.linked), note)
+			continue
+		}
+		links.Append(state.label(), utils.ContractTarget(m.targetPath), source, m.profile, note)
+	}
+
+	return links.Render(os.Stdout, term.Width())
+}
+
+// ListResults returns the state of the link of every mapping of the profiles, as List
+// shows it, with the path a wrong link points to or why a link is unreadable as message
+func ListResults(profiles []string) ([]LinkResult, error) {
+	cache, _, mappings, err := listMappings(profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]LinkResult, len(mappings))
+	for i, m := range mappings {
+		state, note := listState(cache, m)
+		var messages []string
+		if note != "" {
+			messages = []string{note}
+		}
+		results[i] = newResult(m, string(state), messages, nil)
+	}
+	return results, nil
+}
+
+// listMappings returns the mappings of the profiles that list shows, with the cache they
+// were resolved through and the dotfiles directory
+func listMappings(profiles []string) (*dirCache, string, []mapping, error) {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	cache := newDirCache(FS)
+	mappings := resolveMappings(cache, dotfilesDir, selected)
+	if err := markCopies(cfg, mappings); err != nil {
+		return nil, "", nil, err
+	}
+	return cache, dotfilesDir, mappings, nil
+}
+
+// listState returns the state of a single mapping's link, with the path a wrong link
+// points to or the error that made the link unreadable
+func listState(cache *dirCache, m mapping) (linkState, string) {
+	// Check if target exists and what type it is
+	mode, err := cache.lstat(m.targetPath)
+	if err != nil {
+		return stateNotLinked, ""
+	}
+
+	if mode&os.ModeSymlink == 0 && m.copied != nil {
+		if !cache.exists(m.sourcePath) {
+			return stateSourceMissing, ""
+		}
+		state, err := compareCopy(cache, m)
+		if err != nil {
+			return stateUnreadable, err.Error()
+		}
+		switch state {
+		case copyStale:
+			return stateCopyOutdated, ""
+		case copyEdited:
+			return stateCopyEdited, ""
+		case copyDiverged:
+			return stateCopyEdited, "source changed too"
+		}
+		return stateCopied, ""
+	}
+
+	if mode&os.ModeSymlink == 0 {
+		return stateNotSymlink, ""
+	}
+
+	// Target is a symlink
+	linkTarget, err := cache.fs.Readlink(m.targetPath)
+	if err != nil {
+		return stateUnreadable, err.Error()
+	}
+	if !utils.SamePath(linkTarget, m.sourcePath) {
+		return stateWrongLink, linkTarget
+	}
+
+	// Check if source actually exists
+	if !cache.exists(m.sourcePath) {
+		return stateSourceMissing, ""
+	}
+	return stateLinked, ""
+}
+
+// Adopt moves an existing file or directory into the dotfiles repository, registers it
+// in the .mappings file under the given profile and links it back into place
+func Adopt(target, source, profile string) error {
+	return AdoptEntry(target, source, profile, config.Entry{})
+}
+
+// AdoptEntry adopts target like Adopt, registering it with the targets of entry, e.g.
+// those of other OSes as well; an entry without targets maps to the adopted path
+func AdoptEntry(target, source, profile string, entry config.Entry) error {
 	dotfilesDir, err := dotfiles.GetDotfilesDir()
 	if err != nil {
 		return err
 	}
 
-	cfg, err := config.ParseConfig(dotfilesDir)
+	targetPath, err := filepath.Abs(utils.ExpandTarget(target))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", target, err)
+	}
+	sourcePath := filepath.Join(dotfilesDir, source)
+
+	p, err := loadProtection()
 	if err != nil {
 		return err
 	}
+	if protected := p.covers(targetPath); protected != "" {
+		return fmt.Errorf("cannot adopt %s: it would touch protected path %s", targetPath, protected)
+	}
 
-	profileMap, err := cfg.GetProfiles(profiles)
+	isLink, err := utils.IsSymlinkFS(FS, targetPath)
 	if err != nil {
+		return fmt.Errorf("cannot adopt %s: %w", targetPath, err)
+	}
+	if isLink {
+		return fmt.Errorf("cannot adopt %s: already a symlink", targetPath)
+	}
+	if _, err := FS.Lstat(sourcePath); err == nil {
+		return fmt.Errorf("cannot adopt %s: %s already exists in the repository", targetPath, source)
+	}
+
+	if err := utils.MovePathFS(FS, targetPath, sourcePath); err != nil {
 		return err
 	}
+	actions := []journal.Action{journal.NewAction(journal.OpMove, targetPath, sourcePath)}
 
-	fmt.Printf("Dotfiles links for profile(s): %s\n", strings.Join(profiles, ", "))
-	fmt.Println()
-
-	linksFound := false
-
-	for source, target := range profileMap {
-		targetPath := utils.ExpandPath(target)
-		sourcePath := filepath.Join(dotfilesDir, source)
-
-		// Check if target exists and what type it is
-		if stat, err := os.Lstat(targetPath); err == nil {
-			if stat.Mode()&os.ModeSymlink != 0 {
-				// Target is a symlink
-				linkTarget, err := os.Readlink(targetPath)
-				if err != nil { //nolint:gocritic
-					fmt.Printf("❌ %s -> ??? (error reading link: %v)\n", targetPath, err)
-				} else if linkTarget == sourcePath {
-					// Check if source actually exists
-					if utils.FileExists(sourcePath) {
-						fmt.Printf("✅ %s -> %s\n", targetPath, sourcePath)
-					} else {
-						fmt.Printf("⚠️  %s -> %s (source missing)\n", targetPath, sourcePath)
-					}
-				} else {
-					fmt.Printf("❌ %s -> %s (expected: %s)\n", targetPath, linkTarget, sourcePath)
-				}
-				linksFound = true
-			} else {
-				fmt.Printf("❌ %s (exists but not a symlink)\n", targetPath)
-				linksFound = true
-			}
-		} else {
-			fmt.Printf("❌ %s (not linked)\n", targetPath)
-			linksFound = true
+	if entry.Target == "" && len(entry.Targets) == 0 {
+		entry.Target = utils.ContractTarget(targetPath)
+	}
+	if err := config.AddEntry(dotfilesDir, profile, filepath.ToSlash(source), entry); err != nil {
+		if restoreErr := utils.MovePathFS(FS, sourcePath, targetPath); restoreErr != nil {
+			fmt.Fprintf(os.Stderr, "Error restoring %s: %v\n", targetPath, restoreErr)
 		}
+		return err
 	}
+	actions = append(actions, journal.NewAction(journal.OpMap, filepath.ToSlash(source), utils.ContractTarget(targetPath)))
 
-	if !linksFound {
-		fmt.Println("No dotfile mappings found in the specified profile(s).")
+	if err := FS.Symlink(sourcePath, targetPath); err != nil {
+		journal.Record("adopt", []string{profile}, actions)
+		return fmt.Errorf("error creating link %s -> %s: %w", targetPath, sourcePath, err)
 	}
+	actions = append(actions, journal.NewAction(journal.OpCreateLink, targetPath, sourcePath))
+	journal.Record("adopt", []string{profile}, actions)
 
+	utils.FprintfColor(os.Stderr, "green", "Adopted: %s -> %s\n", targetPath, sourcePath)
 	return nil
 }
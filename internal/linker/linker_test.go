@@ -5,8 +5,12 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
 )
 
 func TestParseProfiles(t *testing.T) {
@@ -253,14 +257,14 @@ func TestClean(t *testing.T) {
 		}
 
 		// Capture output
-		oldStdout := os.Stdout
+		oldStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		err := Clean([]string{"general"})
 
 		w.Close()
-		os.Stdout = oldStdout
+		os.Stderr = oldStderr
 
 		var buf bytes.Buffer
 		io.Copy(&buf, r)
@@ -289,14 +293,14 @@ func TestClean(t *testing.T) {
 		setupTestEnvironment(t, dotfilesDir, homeDir)
 
 		// Capture output
-		oldStdout := os.Stdout
+		oldStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		err := Clean([]string{"general"})
 
 		w.Close()
-		os.Stdout = oldStdout
+		os.Stderr = oldStderr
 
 		var buf bytes.Buffer
 		io.Copy(&buf, r)
@@ -326,14 +330,14 @@ func TestClean(t *testing.T) {
 		}
 
 		// Capture output
-		oldStdout := os.Stdout
+		oldStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		err := Clean([]string{"general"})
 
 		w.Close()
-		os.Stdout = oldStdout
+		os.Stderr = oldStderr
 
 		var buf bytes.Buffer
 		io.Copy(&buf, r)
@@ -351,6 +355,36 @@ func TestClean(t *testing.T) {
 			t.Error("Expected regular file to remain")
 		}
 	})
+
+	t.Run("Restore backups after removing links", func(t *testing.T) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		setupTestEnvironment(t, dotfilesDir, homeDir)
+
+		targetPath := filepath.Join(homeDir, ".vimrc")
+		os.WriteFile(targetPath+".bak", []byte("original"), 0644)
+		if err := os.Symlink(filepath.Join(dotfilesDir, "vim/.vimrc"), targetPath); err != nil {
+			t.Fatalf("Failed to create test symlink: %v", err)
+		}
+
+		output := captureOutput(t, func() {
+			if err := CleanWithOptions([]string{"general"}, CleanOptions{RestoreBackups: true}); err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Restored backup: "+targetPath+".bak -> "+targetPath) {
+			t.Errorf("Expected restored message, got: %s", output)
+		}
+		if data, _ := os.ReadFile(targetPath); string(data) != "original" {
+			t.Errorf("Expected the backup in place, got '%s'", data)
+		}
+		if _, err := os.Lstat(targetPath + ".bak"); !os.IsNotExist(err) {
+			t.Error("Expected the backup to be moved")
+		}
+	})
 }
 
 func TestLink(t *testing.T) {
@@ -374,14 +408,14 @@ func TestLink(t *testing.T) {
 		setupTestEnvironment(t, dotfilesDir, homeDir)
 
 		// Capture output
-		oldStdout := os.Stdout
+		oldStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		err := Link([]string{"general"}, false)
 
 		w.Close()
-		os.Stdout = oldStdout
+		os.Stderr = oldStderr
 
 		var buf bytes.Buffer
 		io.Copy(&buf, r)
@@ -418,14 +452,14 @@ func TestLink(t *testing.T) {
 		}
 
 		// Capture output
-		oldStdout := os.Stdout
+		oldStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		err := Link([]string{"general"}, false)
 
 		w.Close()
-		os.Stdout = oldStdout
+		os.Stderr = oldStderr
 
 		var buf bytes.Buffer
 		io.Copy(&buf, r)
@@ -487,14 +521,14 @@ func TestLink(t *testing.T) {
 		}
 
 		// Capture output
-		oldStdout := os.Stdout
+		oldStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		err := Link([]string{"general"}, false)
 
 		w.Close()
-		os.Stdout = oldStdout
+		os.Stderr = oldStderr
 
 		var buf bytes.Buffer
 		io.Copy(&buf, r)
@@ -524,14 +558,14 @@ func TestLink(t *testing.T) {
 		setupTestEnvironment(t, dotfilesDir, homeDir)
 
 		// Capture output
-		oldStdout := os.Stdout
+		oldStderr := os.Stderr
 		r, w, _ := os.Pipe()
-		os.Stdout = w
+		os.Stderr = w
 
 		err := Link([]string{"general"}, true)
 
 		w.Close()
-		os.Stdout = oldStdout
+		os.Stderr = oldStderr
 
 		var buf bytes.Buffer
 		io.Copy(&buf, r)
@@ -550,6 +584,77 @@ func TestLink(t *testing.T) {
 			t.Error("Expected no symlink to be created in dry-run mode")
 		}
 	})
+
+	t.Run("Dry-run reports exactly what a real run does", func(t *testing.T) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		os.MkdirAll(filepath.Join(dotfilesDir, "nvim"), 0755)
+		os.MkdirAll(homeDir, 0755)
+		os.WriteFile(filepath.Join(dotfilesDir, "zshrc"), []byte("zsh"), 0644)
+		os.WriteFile(filepath.Join(dotfilesDir, "nvim", "init.lua"), []byte("lua"), 0644)
+		os.WriteFile(filepath.Join(dotfilesDir, "lazy.lua"), []byte("lazy"), 0644)
+
+		// An existing file with a stale backup, a missing parent directory, and a
+		// mapping that lives inside the directory linked by an earlier one
+		os.WriteFile(filepath.Join(homeDir, ".zshrc"), []byte("old"), 0644)
+		os.WriteFile(filepath.Join(homeDir, ".zshrc.bak"), []byte("older"), 0644)
+
+		mappings := `[general]
+"zshrc" = "` + filepath.Join(homeDir, ".zshrc") + `"
+"nvim" = "` + filepath.Join(homeDir, ".config", "nvim") + `"
+"lazy.lua" = "` + filepath.Join(homeDir, ".config", "nvim", "lazy.lua") + `"
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+		dryRun := captureOutput(t, func() {
+			if err := Link([]string{"general"}, true); err != nil {
+				t.Fatalf("Dry run failed: %v", err)
+			}
+		})
+
+		if data, _ := os.ReadFile(filepath.Join(homeDir, ".zshrc.bak")); string(data) != "older" {
+			t.Fatal("Expected dry run to leave the existing backup untouched")
+		}
+		if _, err := os.Lstat(filepath.Join(homeDir, ".config")); !os.IsNotExist(err) {
+			t.Fatal("Expected dry run not to create directories")
+		}
+
+		realRun := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+
+		normalize := func(output string) string {
+			output = regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(output, "")
+			var lines []string
+			for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+				if strings.HasPrefix(line, "Would create directory:") {
+					continue
+				}
+				line = strings.Replace(line, "Would create:", "Created:", 1)
+				line = strings.Replace(line, "Would back up:", "Backed up:", 1)
+				line = strings.Replace(line, "Would override:", "Overriding:", 1)
+				lines = append(lines, line)
+			}
+			return strings.Join(lines, "\n")
+		}
+
+		if normalize(dryRun) != normalize(realRun) {
+			t.Errorf("Expected dry run to match real run\ndry run:\n%s\nreal run:\n%s", dryRun, realRun)
+		}
+		for _, expected := range []string{"Would create directory: " + filepath.Join(homeDir, ".config"), "replaced previous backup"} {
+			if !strings.Contains(dryRun, expected) {
+				t.Errorf("Expected dry run output to contain %q, got: %s", expected, dryRun)
+			}
+		}
+		if strings.Contains(dryRun, "Warning") || strings.Contains(dryRun, "Error") {
+			t.Errorf("Expected no warnings in dry run, got: %s", dryRun)
+		}
+	})
 }
 
 // Test error handling scenarios
@@ -609,6 +714,91 @@ func TestLinkErrorHandling(t *testing.T) {
 		}
 	})
 
+	t.Run("Strict mode fails on missing source files", func(t *testing.T) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		os.MkdirAll(dotfilesDir, 0755)
+		os.MkdirAll(homeDir, 0755)
+		os.WriteFile(filepath.Join(dotfilesDir, ".zshrc"), []byte("zsh"), 0644)
+
+		mappingsContent := `[general]
+".zshrc" = "` + filepath.Join(homeDir, ".zshrc") + `"
+"vim/.vimrc" = "` + filepath.Join(homeDir, ".vimrc") + `"`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappingsContent), 0644)
+
+		var err error
+		output := captureOutput(t, func() {
+			err = LinkWithOptions([]string{"general"}, LinkOptions{Strict: true})
+		})
+
+		if err == nil {
+			t.Fatal("Expected error for missing source in strict mode")
+		}
+		if !strings.Contains(err.Error(), "1 source file(s) missing") {
+			t.Errorf("Expected missing source count in error, got: %v", err)
+		}
+		if !strings.Contains(output, filepath.Join(dotfilesDir, "vim", ".vimrc")) {
+			t.Errorf("Expected missing source to be reported, got: %s", output)
+		}
+		if _, err := os.Lstat(filepath.Join(homeDir, ".zshrc")); !os.IsNotExist(err) {
+			t.Error("Expected nothing to be linked in strict mode")
+		}
+	})
+
+	t.Run("Ignore missing sources", func(t *testing.T) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		os.MkdirAll(dotfilesDir, 0755)
+		os.MkdirAll(homeDir, 0755)
+
+		mappingsContent := `[general]
+"vim/.vimrc" = "` + filepath.Join(homeDir, ".vimrc") + `"
+"work/.npmrc" = { target = "` + filepath.Join(homeDir, ".npmrc") + `", ignore_missing = true }`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappingsContent), 0644)
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+		if strings.Contains(output, ".npmrc") {
+			t.Errorf("Expected no warning for ignore_missing entry, got: %s", output)
+		}
+		if !strings.Contains(output, "Warning: Source file does not exist:") {
+			t.Errorf("Expected warning for other missing source, got: %s", output)
+		}
+
+		output = captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{IgnoreMissing: true}); err != nil {
+				t.Errorf("Expected no error, got: %v", err)
+			}
+		})
+		if strings.Contains(output, "Warning") {
+			t.Errorf("Expected no warnings with IgnoreMissing, got: %s", output)
+		}
+
+		var err error
+		captureOutput(t, func() {
+			err = LinkWithOptions([]string{"general"}, LinkOptions{Strict: true})
+		})
+		if err == nil || !strings.Contains(err.Error(), "1 source file(s) missing") {
+			t.Errorf("Expected strict mode to skip the ignore_missing entry, got: %v", err)
+		}
+
+		output = captureOutput(t, func() {
+			Check([]string{"general"})
+		})
+		if strings.Contains(output, ".npmrc") {
+			t.Errorf("Expected check to skip the ignore_missing entry, got: %s", output)
+		}
+	})
+
 	t.Run("Handle invalid .mappings file", func(t *testing.T) {
 		tempDir := t.TempDir()
 		dotfilesDir := filepath.Join(tempDir, "dotfiles")
@@ -754,6 +944,35 @@ func setupTestEnvironment(t *testing.T, dotfilesDir, homeDir string) {
 	}
 }
 
+// captureOutput runs fn with stdout and stderr redirected and returns what was written
+func captureOutput(t *testing.T, fn func()) string {
+	t.Helper()
+
+	oldStdout := os.Stdout
+	oldStderr := os.Stderr
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+	os.Stderr = w
+
+	done := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.String()
+	}()
+
+	fn()
+
+	w.Close()
+	os.Stdout = oldStdout
+	os.Stderr = oldStderr
+
+	return <-done
+}
+
 func TestList(t *testing.T) {
 	// Save original DOT_DIR
 	originalDotDir := os.Getenv("DOT_DIR")
@@ -837,7 +1056,7 @@ func TestList(t *testing.T) {
 		if !strings.Contains(output, "❌") {
 			t.Errorf("Expected error indicator, got: %s", output)
 		}
-		if !strings.Contains(output, "(not linked)") {
+		if !strings.Contains(output, "not linked") {
 			t.Errorf("Expected 'not linked' message, got: %s", output)
 		}
 	})
@@ -881,8 +1100,8 @@ func TestList(t *testing.T) {
 		if !strings.Contains(output, "❌") {
 			t.Errorf("Expected error indicator, got: %s", output)
 		}
-		if !strings.Contains(output, "(expected:") {
-			t.Errorf("Expected 'expected:' message, got: %s", output)
+		if !strings.Contains(output, "points to") {
+			t.Errorf("Expected 'points to' message, got: %s", output)
 		}
 	})
 
@@ -937,7 +1156,7 @@ func TestList(t *testing.T) {
 		if !strings.Contains(output, "⚠️") {
 			t.Errorf("Expected warning indicator, got: %s", output)
 		}
-		if !strings.Contains(output, "(source missing)") {
+		if !strings.Contains(output, "source missing") {
 			t.Errorf("Expected 'source missing' message, got: %s", output)
 		}
 	})
@@ -977,8 +1196,8 @@ func TestList(t *testing.T) {
 		if !strings.Contains(output, "❌") {
 			t.Errorf("Expected error indicator, got: %s", output)
 		}
-		if !strings.Contains(output, "(exists but not a symlink)") {
-			t.Errorf("Expected 'exists but not a symlink' message, got: %s", output)
+		if !strings.Contains(output, "not a symlink") {
+			t.Errorf("Expected 'not a symlink' message, got: %s", output)
 		}
 	})
 
@@ -1014,19 +1233,10 @@ func TestList(t *testing.T) {
 		os.Setenv("HOME", homeDir)
 		defer os.Setenv("HOME", oldHome)
 
-		// Capture stdout
-		oldStdout := os.Stdout
-		r, w, _ := os.Pipe()
-		os.Stdout = w
-
-		err := List([]string{"general", "work"})
-
-		w.Close()
-		os.Stdout = oldStdout
-
-		var buf bytes.Buffer
-		io.Copy(&buf, r)
-		output := buf.String()
+		var err error
+		output := captureOutput(t, func() {
+			err = List([]string{"general", "work"})
+		})
 
 		if err != nil {
 			t.Errorf("Expected no error, got: %v", err)
@@ -1042,3 +1252,206 @@ func TestList(t *testing.T) {
 		}
 	})
 }
+
+func TestLinkInMemory(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+
+	memory := fsys.NewMemory()
+	FS = memory
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	memory.MkdirAll("/dotfiles/nvim", 0755)
+	memory.MkdirAll("/home/user", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"nvim\" = \"~/.config/nvim\"\n"), 0644)
+	memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+	memory.WriteFile("/home/user/.zshrc", []byte("old"), 0644)
+
+	captureOutput(t, func() {
+		if err := Link([]string{"general"}, false); err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+	})
+
+	t.Run("Links are created", func(t *testing.T) {
+		for target, source := range map[string]string{
+			"/home/user/.zshrc":       "/dotfiles/zshrc",
+			"/home/user/.config/nvim": "/dotfiles/nvim",
+		} {
+			link, err := memory.Readlink(target)
+			if err != nil {
+				t.Fatalf("Expected symlink at %s: %v", target, err)
+			}
+			if link != source {
+				t.Errorf("Expected %s, got %s", source, link)
+			}
+		}
+	})
+
+	t.Run("Existing file is backed up", func(t *testing.T) {
+		data, err := memory.ReadFile("/home/user/.zshrc.bak")
+		if err != nil {
+			t.Fatalf("Expected backup: %v", err)
+		}
+		if string(data) != "old" {
+			t.Errorf("Expected 'old', got '%s'", data)
+		}
+	})
+
+	t.Run("Check passes", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := Check([]string{"general"}); err != nil {
+				t.Errorf("Check failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "All links are correct") {
+			t.Errorf("Expected all links correct, got: %s", output)
+		}
+	})
+
+	t.Run("Real filesystem is untouched", func(t *testing.T) {
+		if _, err := os.Lstat("/dotfiles/.mappings"); err == nil {
+			t.Error("Expected no file on the real filesystem")
+		}
+	})
+}
+
+func TestAllProfiles(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	homeDir := filepath.Join(tempDir, "home")
+	os.Setenv("DOT_DIR", dotfilesDir)
+
+	os.MkdirAll(dotfilesDir, 0755)
+	os.MkdirAll(homeDir, 0755)
+	os.WriteFile(filepath.Join(dotfilesDir, "vimrc"), nil, 0644)
+	mappings := `[general]
+"vimrc" = "` + filepath.Join(homeDir, ".vimrc") + `"
+
+[work]
+"vimrc" = "` + filepath.Join(homeDir, ".vimrc-work") + `"
+`
+	os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+	captureOutput(t, func() {
+		if err := Link([]string{"all"}, false); err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+	})
+
+	for _, name := range []string{".vimrc", ".vimrc-work"} {
+		if _, err := os.Readlink(filepath.Join(homeDir, name)); err != nil {
+			t.Errorf("Expected %s to be linked: %v", name, err)
+		}
+	}
+
+	captureOutput(t, func() {
+		if err := Clean([]string{"all"}); err != nil {
+			t.Fatalf("Clean failed: %v", err)
+		}
+	})
+
+	for _, name := range []string{".vimrc", ".vimrc-work"} {
+		if _, err := os.Lstat(filepath.Join(homeDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+}
+
+func TestConflictReport(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	homeDir := filepath.Join(tempDir, "home")
+	os.Setenv("DOT_DIR", dotfilesDir)
+
+	os.MkdirAll(filepath.Join(dotfilesDir, "git"), 0755)
+	os.MkdirAll(homeDir, 0755)
+	os.WriteFile(filepath.Join(dotfilesDir, "git", ".gitconfig"), nil, 0644)
+	os.WriteFile(filepath.Join(dotfilesDir, ".gitconfig-work"), nil, 0644)
+	target := filepath.Join(homeDir, ".gitconfig")
+	mappings := `[general]
+"git/.gitconfig" = "` + target + `"
+
+[work]
+".gitconfig-work" = "` + target + `"
+`
+	os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+	expected := target + ": work/.gitconfig-work overrides general/git/.gitconfig"
+
+	output := captureOutput(t, func() {
+		Link([]string{"general", "work"}, false)
+	})
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected conflict report in link output, got: %s", output)
+	}
+
+	output = captureOutput(t, func() {
+		Check([]string{"general", "work"})
+	})
+	if !strings.Contains(output, expected) {
+		t.Errorf("Expected conflict report in check output, got: %s", output)
+	}
+
+	output = captureOutput(t, func() {
+		Check([]string{"general"})
+	})
+	if strings.Contains(output, "overrides") {
+		t.Errorf("Expected no conflict report for a single profile, got: %s", output)
+	}
+}
+
+func TestListPorcelain(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	homeDir := filepath.Join(tempDir, "home")
+	os.Setenv("DOT_DIR", dotfilesDir)
+
+	setupTestEnvironment(t, dotfilesDir, homeDir)
+	sourcePath := filepath.Join(dotfilesDir, "vim/.vimrc")
+	targetPath := filepath.Join(homeDir, ".vimrc")
+	if err := os.Symlink(sourcePath, targetPath); err != nil {
+		t.Fatalf("Failed to create test symlink: %v", err)
+	}
+
+	// Only stdout is captured: porcelain output must not depend on what goes to stderr
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := ListWithOptions([]string{"general"}, ListOptions{Porcelain: true})
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	expected := "linked\t" + targetPath + "\t" + sourcePath + "\tgeneral\t\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
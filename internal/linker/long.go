@@ -0,0 +1,95 @@
+package linker
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/yourusername/dot/internal/manifest"
+)
+
+// sourceDetails is what list --long shows about a mapping beyond the state of its link
+type sourceDetails struct {
+	size     int64     // bytes in the source, summed over every file below a directory
+	modified time.Time // when the source, or the newest file below it, was last modified
+	linked   time.Time // when dot last linked or copied the target, zero if unknown
+}
+
+// loadLinkTimes returns when dot last linked each target as the manifest records it
+// A manifest that cannot be read only leaves the times unknown
+func loadLinkTimes() map[string]time.Time {
+	man, err := manifest.Load()
+	if err != nil {
+		return nil
+	}
+	return man.Linked
+}
+
+// detailsOf returns the size and modification time of a mapping's source and when its
+// target was last linked; a missing source has neither size nor time
+func detailsOf(m mapping, linked map[string]time.Time) sourceDetails {
+	d := sourceDetails{linked: linked[m.targetPath]}
+	d.size, d.modified, _ = treeStats(m.sourcePath)
+	return d
+}
+
+// treeStats returns the total size of the file or directory tree at path and the latest
+// modification time in it; symlinks are followed, as linking exposes what they point to
+func treeStats(path string) (int64, time.Time, error) {
+	info, err := FS.Stat(path)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if !info.IsDir() {
+		return info.Size(), info.ModTime(), nil
+	}
+
+	size, modified := int64(0), info.ModTime()
+	entries, err := FS.ReadDir(path)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	for _, entry := range entries {
+		s, t, err := treeStats(filepath.Join(path, entry.Name()))
+		if err != nil {
+			continue
+		}
+		size += s
+		if t.After(modified) {
+			modified = t
+		}
+	}
+	return size, modified, nil
+}
+
+// formatSize describes a number of bytes in the largest binary unit, e.g. "512B" or "1.5M"
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, suffix := float64(n)/unit, "K"
+	for _, next := range []string{"M", "G", "T"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return fmt.Sprintf("%.1f%s", value, suffix)
+}
+
+// formatTime shows a time to the minute in the local zone, or "-" for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// porcelainTime shows a time for scripts, or "" for the zero time
+func porcelainTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
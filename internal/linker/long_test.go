@@ -0,0 +1,96 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestListLong(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	memory := fsys.NewMemory()
+	FS = memory
+	memory.MkdirAll("/dotfiles/nvim", 0755)
+	memory.MkdirAll("/home/user", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"nvim\" = \"~/.config/nvim\"\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+	memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+	memory.WriteFile("/dotfiles/nvim/init.lua", []byte(strings.Repeat("x", 2048)), 0644)
+	memory.WriteFile("/dotfiles/nvim/lazy.lua", []byte(strings.Repeat("x", 1024)), 0644)
+	captureOutput(t, func() {
+		if err := Link([]string{"general"}, false); err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+	})
+
+	t.Run("Table shows size, modification, and link time", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := ListWithOptions([]string{"general"}, ListOptions{Long: true}); err != nil {
+				t.Fatalf("ListWithOptions failed: %v", err)
+			}
+		})
+		for _, expected := range []string{"SIZE", "MODIFIED", "LINKED", "3B", "3.0K"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected %q in the listing, got: %s", expected, output)
+			}
+		}
+	})
+
+	t.Run("Porcelain appends the fields after the note", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			if err := ListWithOptions([]string{"general"}, ListOptions{Porcelain: true, Long: true}); err != nil {
+				t.Fatalf("ListWithOptions failed: %v", err)
+			}
+		})
+		var zshrc, vimrc []string
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Split(line, "\t")
+			if len(fields) > 1 && fields[1] == "/home/user/.zshrc" {
+				zshrc = fields
+			}
+			if len(fields) > 1 && fields[1] == "/home/user/.vimrc" {
+				vimrc = fields
+			}
+		}
+		if len(zshrc) != 8 || zshrc[5] != "3" || zshrc[6] == "" || zshrc[7] == "" {
+			t.Errorf("Expected size, modification, and link time for .zshrc, got %q", zshrc)
+		}
+		// The missing source of .vimrc was never linked
+		if len(vimrc) != 8 || vimrc[5] != "0" || vimrc[6] != "" || vimrc[7] != "" {
+			t.Errorf("Expected no details for .vimrc, got %q", vimrc)
+		}
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:                    "512B",
+		1536:                   "1.5K",
+		5 * 1024 * 1024:        "5.0M",
+		3 * 1024 * 1024 * 1024: "3.0G",
+	}
+	for n, expected := range tests {
+		if result := formatSize(n); result != expected {
+			t.Errorf("Expected %d to format as %s, got %s", n, expected, result)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package linker
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain keeps the journal written by mutating runs out of the real state directory,
+// and the user's global config out of the tests
+func TestMain(m *testing.M) {
+	stateDir, err := os.MkdirTemp("", "dot-state")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_STATE_HOME", stateDir)
+	os.Setenv("XDG_CONFIG_HOME", stateDir)
+
+	code := m.Run()
+
+	os.RemoveAll(stateDir)
+	os.Exit(code)
+}
@@ -0,0 +1,177 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// orderMappings orders mappings so that each one comes after the mappings its entry
+// lists in after, keeping the target order wherever dependencies allow it
+// Dependencies that are not among the mappings, e.g. from a profile that is not selected,
+// are ignored; mappings that depend on each other are an error
+func orderMappings(cfg *config.Config, mappings []mapping) ([]mapping, error) {
+	deps := afterDependencies(cfg, mappings)   // the mappings each mapping comes after
+	dependents := make([][]int, len(mappings)) // the reverse of deps
+	pending := make([]int, len(mappings))      // the number of deps not ordered yet
+	for i := range mappings {
+		for _, j := range deps[i] {
+			dependents[j] = append(dependents[j], i)
+			pending[i]++
+		}
+	}
+
+	// Take the first mapping in target order whose dependencies are all ordered
+	var ready []int
+	for i := range mappings {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	ordered := make([]mapping, 0, len(mappings))
+	done := make([]bool, len(mappings))
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, mappings[i])
+		done[i] = true
+
+		for _, k := range dependents[i] {
+			if pending[k]--; pending[k] == 0 {
+				ready = append(ready, k)
+				sort.Ints(ready)
+			}
+		}
+	}
+
+	if len(ordered) < len(mappings) {
+		return nil, fmt.Errorf("mappings depend on each other: %s", describeCycle(mappings, deps, done))
+	}
+	return ordered, nil
+}
+
+// afterDependencies returns the indexes of the mappings that each mapping's entry lists
+// in after
+func afterDependencies(cfg *config.Config, mappings []mapping) [][]int {
+	bySource := make(map[string][]int, len(mappings))
+	for i, m := range mappings {
+		bySource[m.source] = append(bySource[m.source], i)
+	}
+
+	deps := make([][]int, len(mappings))
+	for i, m := range mappings {
+		for _, after := range cfg.Entries[m.profile][m.source].After {
+			deps[i] = append(deps[i], bySource[cfg.RepoSource(m.profile, after)]...)
+		}
+	}
+	return deps
+}
+
+// chainMappings groups the indexes of mappings, which are in link order, into chains
+// whose mappings must be processed one after another: those ordered by after, and those
+// whose targets or sources are the same path or one inside the other, e.g. a link to a
+// directory and a link into it, or a link whose source is reached through another link
+// Each chain keeps link order, and the chains are in the order of their first mappings
+func chainMappings(cfg *config.Config, mappings []mapping) [][]int {
+	parent := make([]int, len(mappings))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	union := func(i, j int) {
+		parent[find(i)] = find(j)
+	}
+
+	for i, deps := range afterDependencies(cfg, mappings) {
+		for _, j := range deps {
+			union(i, j)
+		}
+	}
+
+	// Sorted by key, the paths inside a path follow it directly, so each path only needs
+	// to be joined with the nearest of the paths it is inside
+	type ownedPath struct {
+		key   string
+		owner int
+	}
+	paths := make([]ownedPath, 0, 2*len(mappings))
+	for i, m := range mappings {
+		paths = append(paths, ownedPath{pathKey(m.targetPath), i}, ownedPath{pathKey(m.sourcePath), i})
+	}
+	sort.Slice(paths, func(a, b int) bool { return paths[a].key < paths[b].key })
+	var outer []ownedPath
+	for _, p := range paths {
+		for len(outer) > 0 && !keyInside(p.key, outer[len(outer)-1].key) {
+			outer = outer[:len(outer)-1]
+		}
+		if len(outer) > 0 {
+			union(p.owner, outer[len(outer)-1].owner)
+		}
+		outer = append(outer, p)
+	}
+
+	var chains [][]int
+	chainOf := make(map[int]int)
+	for i := range mappings {
+		root := find(i)
+		c, found := chainOf[root]
+		if !found {
+			c = len(chains)
+			chainOf[root] = c
+			chains = append(chains, nil)
+		}
+		chains[c] = append(chains[c], i)
+	}
+	return chains
+}
+
+// pathKey returns a key for path that sorts the paths inside it right after it, by
+// turning separators into the lowest byte, folding case where the filesystem does
+func pathKey(path string) string {
+	if utils.CaseInsensitive {
+		path = strings.ToLower(path)
+	}
+	return strings.ReplaceAll(path, string(os.PathSeparator), "\x00")
+}
+
+// keyInside reports whether the path of key is the path of dir or inside it
+func keyInside(key, dir string) bool {
+	return key == dir || strings.HasPrefix(key, dir+"\x00")
+}
+
+// describeCycle follows the dependencies of the mappings that could not be ordered from
+// the first of them until one repeats, e.g. "a after b after a"
+func describeCycle(mappings []mapping, deps [][]int, done []bool) string {
+	start := 0
+	for done[start] {
+		start++
+	}
+
+	var path []string
+	seen := make(map[int]int)
+	for i := start; ; {
+		if at, repeated := seen[i]; repeated {
+			return strings.Join(append(path[at:], mappings[i].source), " after ")
+		}
+		seen[i] = len(path)
+		path = append(path, mappings[i].source)
+
+		// Every mapping left over depends on another one left over
+		for _, j := range deps[i] {
+			if !done[j] {
+				i = j
+				break
+			}
+		}
+	}
+}
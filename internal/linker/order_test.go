@@ -0,0 +1,115 @@
+package linker
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestOrderMappings(t *testing.T) {
+	sources := func(mappings []mapping) string {
+		var names []string
+		for _, m := range mappings {
+			names = append(names, m.source)
+		}
+		return strings.Join(names, " ")
+	}
+	// resolveMappings sorts by target, which orders the sources alphabetically here
+	mappings := []mapping{
+		{source: "git", profile: "general"},
+		{source: "nvim", profile: "general"},
+		{source: "zsh/.zshenv", profile: "general"},
+		{source: "zsh/.zshrc", profile: "general"},
+	}
+
+	t.Run("Mappings without dependencies keep their order", func(t *testing.T) {
+		cfg := &config.Config{}
+		ordered, err := orderMappings(cfg, mappings)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result := sources(ordered); result != "git nvim zsh/.zshenv zsh/.zshrc" {
+			t.Errorf("Expected target order, got %s", result)
+		}
+	})
+
+	t.Run("Dependencies come first", func(t *testing.T) {
+		cfg := &config.Config{Entries: map[string]map[string]config.Entry{
+			"general": {
+				"git":  {After: []string{"zsh/.zshrc"}},
+				"nvim": {After: []string{"zsh/.zshenv", "python"}},
+			},
+		}}
+		ordered, err := orderMappings(cfg, mappings)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result := sources(ordered); result != "zsh/.zshenv nvim zsh/.zshrc git" {
+			t.Errorf("Expected dependencies first, got %s", result)
+		}
+	})
+
+	t.Run("Dependencies are relative to the source root", func(t *testing.T) {
+		cfg := &config.Config{
+			SourceRoots: map[string]string{"work": "work"},
+			Entries: map[string]map[string]config.Entry{
+				"work": {"work/gitconfig": {After: []string{"ssh"}}},
+			},
+		}
+		ordered, err := orderMappings(cfg, []mapping{
+			{source: "work/gitconfig", profile: "work"},
+			{source: "work/ssh", profile: "work"},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result := sources(ordered); result != "work/ssh work/gitconfig" {
+			t.Errorf("Expected work/ssh first, got %s", result)
+		}
+	})
+
+	t.Run("Cycles are errors", func(t *testing.T) {
+		cfg := &config.Config{Entries: map[string]map[string]config.Entry{
+			"general": {
+				"git":         {After: []string{"zsh/.zshrc"}},
+				"zsh/.zshrc":  {After: []string{"zsh/.zshenv"}},
+				"zsh/.zshenv": {After: []string{"git"}},
+			},
+		}}
+		_, err := orderMappings(cfg, mappings)
+		if err == nil || !strings.Contains(err.Error(), "mappings depend on each other: git after zsh/.zshrc after zsh/.zshenv after git") {
+			t.Errorf("Expected cycle error, got %v", err)
+		}
+	})
+}
+
+func TestChainMappings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths are POSIX style")
+	}
+
+	mappings := []mapping{
+		{source: "bin", sourcePath: "/dotfiles/bin", targetPath: "/home/user/.bin", profile: "general"},
+		{source: "config", sourcePath: "/dotfiles/config", targetPath: "/home/user/.config", profile: "general"},
+		{source: "nvim", sourcePath: "/dotfiles/nvim", targetPath: "/home/user/.config/nvim", profile: "general"},
+		{source: "gitconfig", sourcePath: "/dotfiles/gitconfig", targetPath: "/home/user/.gitconfig", profile: "general"},
+		{source: "tool", sourcePath: "/home/user/.bin/tool", targetPath: "/home/user/.tool", profile: "general"},
+		{source: "zshrc", sourcePath: "/dotfiles/zshrc", targetPath: "/home/user/.zshrc", profile: "general"},
+		{source: "zshrc.local", sourcePath: "/dotfiles/zshrc.local", targetPath: "/home/user/.zshrc.local", profile: "general"},
+	}
+	cfg := &config.Config{Entries: map[string]map[string]config.Entry{
+		"general": {"zshrc.local": {After: []string{"gitconfig"}}},
+	}}
+
+	chains := chainMappings(cfg, mappings)
+
+	// A link into a linked directory, a source reached through another link, and an
+	// after dependency each keep their mappings together and in order
+	expected := [][]int{{0, 4}, {1, 2}, {3, 6}, {5}}
+	if !reflect.DeepEqual(chains, expected) {
+		t.Errorf("Expected chains %v, got %v", expected, chains)
+	}
+}
@@ -0,0 +1,308 @@
+package linker
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/secrets"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// mapping is a single resolved source -> target entry of the selected profiles
+type mapping struct {
+	source     string   // source path relative to the dotfiles directory
+	sourcePath string   // absolute source path, with alternates resolved
+	targetPath string   // absolute target path
+	profile    string   // profile the entry was taken from
+	team       bool     // the source lies in the read-only team repository
+	template   string   // the template sourcePath is rendered from, see renderMapping; "" for other sources
+	encrypted  string   // the encrypted file sourcePath is decrypted from, see decryptMapping; "" for other sources
+	delims     []string // the left and right delimiters of template, none for {{ and }}
+
+	ignoreMissing bool           // a missing source is expected and skipped silently
+	copied        *manifest.Copy // the target holds a copy of the source, made when symlinks were not permitted
+	eol           string         // the line endings copies of the source get, see config.Entry.EOL
+	mode          string         // how link puts the source in place, see config.Entry.Mode
+}
+
+// resolveMappings resolves the selected entries into mappings sorted by target path,
+// so every command processes and reports entries in a stable order
+// The source of a template is the output it is rendered to, that of an encrypted file the
+// plaintext it is decrypted to, and a recursive entry
+// becomes a mapping for each file beneath its directory, see recursiveSuffix
+func resolveMappings(cache *dirCache, dotfilesDir string, selected []config.Mapping) []mapping {
+	// A template or encrypted file that does not exist stays the source, which is reported missing
+	rendered, renderedErr := renderedDir()
+	decrypted, decryptedErr := decryptedDir()
+	// resolve maps source, a file of the entry relative to the dotfiles directory, to target
+	resolve := func(entry config.Mapping, source, target string) mapping {
+		m := mapping{
+			source:     entry.Source,
+			sourcePath: cache.resolveAlternate(filepath.Join(dotfilesDir, source)),
+			targetPath: target,
+			profile:    entry.Profile,
+			team:       entry.Team,
+			delims:     entry.Delims,
+		}
+		if strings.HasSuffix(source, templateExt) && renderedErr == nil && cache.exists(m.sourcePath) {
+			m.template, m.sourcePath = m.sourcePath, renderedPath(rendered, source)
+		} else if backend, ok := secrets.BackendOf(source); ok && decryptedErr == nil && cache.exists(m.sourcePath) {
+			m.encrypted, m.sourcePath = m.sourcePath, outputPath(decrypted, strings.TrimSuffix(path.Clean(source), backend.Ext()))
+		}
+		return m
+	}
+
+	var dotignore ignoreList
+	dotignoreLoaded := false
+
+	mappings := make([]mapping, 0, len(selected))
+	for _, entry := range selected {
+		target := utils.ExpandTarget(entry.Target)
+		dir, recursive := strings.CutSuffix(entry.Source, recursiveSuffix)
+		if !recursive {
+			mappings = append(mappings, resolve(entry, entry.Source, target))
+			continue
+		}
+
+		if !dotignoreLoaded {
+			dotignore, dotignoreLoaded = cache.loadIgnore(dotfilesDir), true
+		}
+		ignore := append(dotignore[:len(dotignore):len(dotignore)], parseIgnore(entry.Tree.Ignore, dir)...)
+		files, ok := cache.files(filepath.Join(dotfilesDir, dir), dir, entry.Tree, ignore)
+		if !ok {
+			// A missing directory is reported like any missing source
+			mappings = append(mappings, resolve(entry, dir, target))
+			continue
+		}
+		for _, file := range files {
+			m := resolve(entry, path.Join(dir, file), filepath.Join(target, filepath.FromSlash(file)))
+			if m.template != "" || m.encrypted != "" {
+				m.targetPath = strings.TrimSuffix(m.targetPath, path.Ext(file))
+			} else if !cache.exists(m.sourcePath) {
+				// Only ##alternates for other machines exist
+				continue
+			}
+			mappings = append(mappings, m)
+		}
+	}
+
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].targetPath < mappings[j].targetPath
+	})
+
+	return mappings
+}
+
+// The orders commands can show mappings in
+const (
+	SortTarget = "target" // by target path, the order resolveMappings returns
+	SortSource = "source" // by source in the dotfiles directory, then by target path
+)
+
+// SortOrders lists the valid values of the Sort options
+var SortOrders = []string{SortTarget, SortSource}
+
+// validSort returns an error unless by is one of SortOrders, or empty for SortTarget
+func validSort(by string) error {
+	if by != "" && !slices.Contains(SortOrders, by) {
+		return fmt.Errorf("invalid --sort %q, must be one of: %s", by, strings.Join(SortOrders, ", "))
+	}
+	return nil
+}
+
+// sortMappings puts mappings, sorted by target path, in the order by shows them
+func sortMappings(mappings []mapping, by string) {
+	if by == SortSource {
+		sort.SliceStable(mappings, func(i, j int) bool {
+			return mappings[i].source < mappings[j].source
+		})
+	}
+}
+
+// sortOrder returns the indexes of mappings in the order by shows them, or nil to show
+// them in the order they are in
+func sortOrder(mappings []mapping, by string) []int {
+	if by != SortSource {
+		return nil
+	}
+	order := make([]int, len(mappings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return mappings[order[a]].source < mappings[order[b]].source
+	})
+	return order
+}
+
+// markIgnoreMissing flags the mappings whose missing source is expected, either because
+// their entry sets ignore_missing or because all is true
+func markIgnoreMissing(cfg *config.Config, mappings []mapping, all bool) {
+	for i, m := range mappings {
+		mappings[i].ignoreMissing = all || cfg.Entries[m.profile][m.source].IgnoreMissing
+	}
+}
+
+// output buffers the progress messages and journal actions produced while processing one
+// mapping so they can be shown in a stable order, without interleaving, once processing
+// is done; the dot command prints them to stderr, leaving stdout to the data it prints
+type output struct {
+	lines   []string
+	actions []journal.Action
+
+	messages    []string // the lines without color, for the run report
+	failed      bool     // an error message was printed
+	errors      []string // the error messages without color, for the summary of the run
+	interrupted bool     // a signal stopped the run before the mapping was finished
+}
+
+// record buffers a filesystem change for the journal
+func (o *output) record(op, path, target string) {
+	o.actions = append(o.actions, journal.NewAction(op, path, target))
+}
+
+// printf buffers a message
+func (o *output) printf(format string, args ...interface{}) {
+	o.lines = append(o.lines, fmt.Sprintf(format, args...))
+	o.messages = append(o.messages, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+// printfColor buffers a colored message
+func (o *output) printfColor(colorChoice string, format string, args ...interface{}) {
+	o.lines = append(o.lines, utils.SprintfColor(colorChoice, format, args...))
+	o.messages = append(o.messages, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+// errorf buffers a message about a step of the mapping that failed
+func (o *output) errorf(format string, args ...interface{}) {
+	o.printf(format, args...)
+	o.failed = true
+	o.errors = append(o.errors, o.messages[len(o.messages)-1])
+}
+
+// schedule lets forEachMapping process mappings concurrently or show them in another
+// order than they are processed in
+type schedule struct {
+	jobs   int     // how many mappings are processed at once
+	chains [][]int // the mappings to process one after another, see chainMappings
+	shown  []int   // the order the mappings are shown in, see sortOrder; nil for their order
+}
+
+// newSchedule returns the schedule for processing mappings up to jobs at a time and
+// showing them in the order sortBy asks for, or nil to process and show them one at a
+// time in order
+func newSchedule(cfg *config.Config, mappings []mapping, jobs int, sortBy string) *schedule {
+	shown := sortOrder(mappings, sortBy)
+	if jobs <= 1 && shown == nil {
+		return nil
+	}
+	if jobs <= 1 {
+		all := make([]int, len(mappings))
+		for i := range all {
+			all[i] = i
+		}
+		return &schedule{jobs: 1, chains: [][]int{all}, shown: shown}
+	}
+	return &schedule{jobs: jobs, chains: chainMappings(cfg, mappings), shown: shown}
+}
+
+// parallel calls fn with every index below n, up to jobs of them at once, and returns
+// once all calls returned; with jobs of 1 or less it calls fn in index order
+func parallel(n, jobs int, fn func(i int)) {
+	if jobs <= 1 {
+		for i := range n {
+			fn(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(jobs, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// forEachMapping runs fn for every mapping with its own output buffer and shows the
+// buffers through Output in mapping order, or the order s shows them in, keeping output
+// deterministic however fn is scheduled
+// Mappings are processed one at a time in order unless s says otherwise, in which case
+// fn must be safe to call concurrently
+// Each mapping's outcome is added to rep and its duration to tm, unless they are nil; the
+// lookups of a mapping are only told apart from those of others when one mapping is
+// processed at a time
+// Returns the recorded journal actions in mapping order, and an error summarizing the
+// mappings that failed
+func forEachMapping(mappings []mapping, s *schedule, rep *Report, tm *timings, fn func(m mapping, out *output)) ([]journal.Action, error) {
+	outputs := make([]output, len(mappings))
+	durations := make([]time.Duration, len(mappings))
+	lookups := make([]time.Duration, len(mappings))
+	run := func(i int) {
+		start, looked := time.Now(), tm.lookupTime()
+		fn(mappings[i], &outputs[i])
+		durations[i] = time.Since(start)
+		if s == nil || s.jobs <= 1 {
+			lookups[i] = tm.lookupTime() - looked
+		}
+	}
+
+	if s == nil {
+		for i := range mappings {
+			run(i)
+		}
+	} else {
+		parallel(len(s.chains), s.jobs, func(c int) {
+			for _, i := range s.chains[c] {
+				run(i)
+			}
+		})
+	}
+
+	shown := make([]int, len(mappings))
+	for i := range shown {
+		shown[i] = i
+	}
+	if s != nil && s.shown != nil {
+		shown = s.shown
+	}
+
+	var errors []string
+	failed := 0
+	for _, i := range shown {
+		m := mappings[i]
+		rep.addOutput(m, &outputs[i], durations[i], lookups[i])
+		tm.mapping(m, durations[i], lookups[i])
+		Output.Result(outputs[i].result(m), outputs[i].lines)
+		if outputs[i].failed {
+			errors = append(errors, outputs[i].errors...)
+			failed++
+		}
+	}
+
+	// The journal replays actions in the order they were taken, whatever order they were shown in
+	var actions []journal.Action
+	for i := range outputs {
+		actions = append(actions, outputs[i].actions...)
+	}
+	return actions, failedMappings(failed, errors)
+}
@@ -0,0 +1,255 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/journal"
+)
+
+func TestResolveMappings(t *testing.T) {
+	t.Run("Sorted by target path", func(t *testing.T) {
+		dotfilesDir := t.TempDir()
+		selected := []config.Mapping{
+			{Source: "zsh/.zshrc", Target: "/home/user/.zshrc", Profile: "general"},
+			{Source: "vim/.vimrc", Target: "/home/user/.vimrc", Profile: "general"},
+			{Source: "git/.gitconfig", Target: "/home/user/.gitconfig", Profile: "work"},
+		}
+
+		mappings := resolveMappings(newDirCache(FS), dotfilesDir, selected)
+
+		var targets []string
+		for _, m := range mappings {
+			targets = append(targets, m.targetPath)
+		}
+		expected := "/home/user/.gitconfig,/home/user/.vimrc,/home/user/.zshrc"
+		if strings.Join(targets, ",") != expected {
+			t.Errorf("Expected %s, got %v", expected, targets)
+		}
+		if mappings[0].sourcePath != filepath.Join(dotfilesDir, "git/.gitconfig") {
+			t.Errorf("Expected absolute source path, got %s", mappings[0].sourcePath)
+		}
+		if mappings[0].profile != "work" {
+			t.Errorf("Expected profile work, got %s", mappings[0].profile)
+		}
+	})
+}
+
+func TestSortMappings(t *testing.T) {
+	mappings := []mapping{
+		{source: "zsh/.zshrc", targetPath: "/home/user/.a"},
+		{source: "vim/", targetPath: "/home/user/.vim/b"},
+		{source: "vim/", targetPath: "/home/user/.vim/c"},
+		{source: "git/.gitconfig", targetPath: "/home/user/.z"},
+	}
+	targets := func(mappings []mapping) string {
+		var targets []string
+		for _, m := range mappings {
+			targets = append(targets, m.targetPath)
+		}
+		return strings.Join(targets, ",")
+	}
+
+	t.Run("Target keeps the order of resolveMappings", func(t *testing.T) {
+		sorted := slices.Clone(mappings)
+		sortMappings(sorted, SortTarget)
+		if targets(sorted) != targets(mappings) {
+			t.Errorf("Expected %s, got %s", targets(mappings), targets(sorted))
+		}
+		if order := sortOrder(mappings, ""); order != nil {
+			t.Errorf("Expected no reordering, got %v", order)
+		}
+	})
+
+	t.Run("Source orders by source, then by target", func(t *testing.T) {
+		sorted := slices.Clone(mappings)
+		sortMappings(sorted, SortSource)
+		expected := "/home/user/.z,/home/user/.vim/b,/home/user/.vim/c,/home/user/.a"
+		if targets(sorted) != expected {
+			t.Errorf("Expected %s, got %s", expected, targets(sorted))
+		}
+		if order := sortOrder(mappings, SortSource); !slices.Equal(order, []int{3, 1, 2, 0}) {
+			t.Errorf("Expected [3 1 2 0], got %v", order)
+		}
+	})
+
+	t.Run("Error for an unknown order", func(t *testing.T) {
+		if err := validSort("name"); err == nil {
+			t.Error("Expected error for invalid --sort")
+		}
+		if err := validSort(""); err != nil {
+			t.Errorf("Expected empty to sort by target, got: %v", err)
+		}
+	})
+}
+
+func TestForEachMapping(t *testing.T) {
+	t.Run("Flushes buffered output in mapping order", func(t *testing.T) {
+		mappings := []mapping{{targetPath: "/a"}, {targetPath: "/b"}, {targetPath: "/c"}}
+
+		output := captureOutput(t, func() {
+			forEachMapping(mappings, nil, nil, nil, func(m mapping, out *output) {
+				out.printf("start %s\n", m.targetPath)
+				out.printf("error %s\n", m.targetPath)
+				out.printf("end %s\n", m.targetPath)
+			})
+		})
+
+		expected := "start /a\nerror /a\nend /a\nstart /b\nerror /b\nend /b\nstart /c\nerror /c\nend /c\n"
+		if output != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+		}
+	})
+
+	t.Run("Chains run in order while other mappings run beside them", func(t *testing.T) {
+		mappings := []mapping{{targetPath: "/a"}, {targetPath: "/b"}, {targetPath: "/a/c"}, {targetPath: "/d"}}
+		s := &schedule{jobs: 3, chains: [][]int{{0, 2}, {1}, {3}}}
+
+		var mu sync.Mutex
+		var order []string
+		output := captureOutput(t, func() {
+			forEachMapping(mappings, s, nil, nil, func(m mapping, out *output) {
+				mu.Lock()
+				order = append(order, m.targetPath)
+				mu.Unlock()
+				out.printf("linked %s\n", m.targetPath)
+			})
+		})
+
+		if slices.Index(order, "/a") > slices.Index(order, "/a/c") {
+			t.Errorf("Expected /a before /a/c, got %v", order)
+		}
+		if expected := "linked /a\nlinked /b\nlinked /a/c\nlinked /d\n"; output != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+		}
+	})
+
+	t.Run("Shows mappings in sort order and journals them in processing order", func(t *testing.T) {
+		mappings := []mapping{{source: "b", targetPath: "/a"}, {source: "a", targetPath: "/b"}}
+		s := newSchedule(&config.Config{}, mappings, 1, SortSource)
+
+		var actions []string
+		output := captureOutput(t, func() {
+			recorded, _ := forEachMapping(mappings, s, nil, nil, func(m mapping, out *output) {
+				out.record(journal.OpCreateLink, m.targetPath, m.source)
+				out.printf("linked %s\n", m.targetPath)
+			})
+			for _, a := range recorded {
+				actions = append(actions, a.Path)
+			}
+		})
+
+		if expected := "linked /b\nlinked /a\n"; output != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+		}
+		if !slices.Equal(actions, []string{"/a", "/b"}) {
+			t.Errorf("Expected actions for /a then /b, got %v", actions)
+		}
+	})
+}
+
+func TestLinkOutputIsStable(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer func() {
+		if originalDotDir != "" {
+			os.Setenv("DOT_DIR", originalDotDir)
+		} else {
+			os.Unsetenv("DOT_DIR")
+		}
+	}()
+
+	t.Run("Dry-run output is identical across runs", func(t *testing.T) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		setupTestEnvironment(t, dotfilesDir, homeDir)
+
+		var mappings strings.Builder
+		mappings.WriteString("[general]\n")
+		for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+			source := filepath.Join(dotfilesDir, name)
+			if err := os.WriteFile(source, []byte(name), 0644); err != nil {
+				t.Fatalf("Failed to create source: %v", err)
+			}
+			mappings.WriteString(`"` + name + `" = "` + filepath.Join(homeDir, "."+name) + "\"\n")
+		}
+		if err := os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings.String()), 0644); err != nil {
+			t.Fatalf("Failed to write .mappings: %v", err)
+		}
+
+		first := captureOutput(t, func() { Link([]string{"general"}, true) })
+		for i := 0; i < 5; i++ {
+			if output := captureOutput(t, func() { Link([]string{"general"}, true) }); output != first {
+				t.Fatalf("Expected identical output, got:\n%s\nvs:\n%s", first, output)
+			}
+		}
+
+		// Linking several mappings at once shows the same output in the same order
+		for i := 0; i < 5; i++ {
+			output := captureOutput(t, func() {
+				LinkWithOptions([]string{"general"}, LinkOptions{DryRun: true, Jobs: 4})
+			})
+			if output != first {
+				t.Fatalf("Expected output with jobs to match, got:\n%s\nvs:\n%s", first, output)
+			}
+		}
+
+		captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{Jobs: 4}); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+			if err := CheckWithOptions([]string{"general"}, CheckOptions{Jobs: 4}); err != nil {
+				t.Errorf("Expected every link to be correct, got: %v", err)
+			}
+		})
+	})
+	t.Run("Sorted by source, link, check, and list show mappings in source order", func(t *testing.T) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+
+		setupTestEnvironment(t, dotfilesDir, homeDir)
+
+		// The sources run opposite to their targets
+		mappings := "[general]\n"
+		for _, pair := range [][2]string{{"a", ".z"}, {"z", ".a"}} {
+			if err := os.WriteFile(filepath.Join(dotfilesDir, pair[0]), []byte(pair[0]), 0644); err != nil {
+				t.Fatalf("Failed to create source: %v", err)
+			}
+			mappings += `"` + pair[0] + `" = "` + filepath.Join(homeDir, pair[1]) + "\"\n"
+		}
+		if err := os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644); err != nil {
+			t.Fatalf("Failed to write .mappings: %v", err)
+		}
+
+		inSourceOrder := func(t *testing.T, output string) {
+			t.Helper()
+			first, second := strings.Index(output, filepath.Join(homeDir, ".z")), strings.Index(output, filepath.Join(homeDir, ".a"))
+			if first < 0 || second < 0 || first > second {
+				t.Errorf("Expected .z before .a, got:\n%s", output)
+			}
+		}
+
+		inSourceOrder(t, captureOutput(t, func() {
+			LinkWithOptions([]string{"general"}, LinkOptions{DryRun: true, Sort: SortSource})
+		}))
+		inSourceOrder(t, captureOutput(t, func() {
+			CheckWithOptions([]string{"general"}, CheckOptions{Sort: SortSource})
+		}))
+		inSourceOrder(t, captureOutput(t, func() {
+			ListWithOptions([]string{"general"}, ListOptions{Porcelain: true, Sort: SortSource})
+		}))
+
+		if err := LinkWithOptions([]string{"general"}, LinkOptions{DryRun: true, Sort: "name"}); err == nil {
+			t.Error("Expected error for invalid --sort")
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package linker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/dot/internal/table"
+	"github.com/yourusername/dot/internal/term"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// removable reports whether clean removes a target in the given state
+func removable(state linkState) bool {
+	switch state {
+	case stateLinked, stateSourceMissing, stateCopied, stateCopyOutdated:
+		return true
+	}
+	return false
+}
+
+// pickMappings shows every mapping with the state of its link, numbering the ones clean
+// can remove, and returns those picked by the answers read from in
+// The picked mappings are previewed and only returned once the removal is confirmed
+func pickMappings(cache *dirCache, dotfilesDir string, mappings []mapping, in *bufio.Reader) []mapping {
+	var candidates []mapping
+	links := table.New("#", "STATUS", "TARGET", "SOURCE", "PROFILE")
+	links.Truncatable(2, 3)
+	for _, m := range mappings {
+		state, _ := listState(cache, m)
+		number := "-"
+		if removable(state) {
+			candidates = append(candidates, m)
+			number = strconv.Itoa(len(candidates))
+		}
+		source, err := filepath.Rel(dotfilesDir, m.sourcePath)
+		if err != nil {
+			source = m.sourcePath
+		}
+		links.Append(number, state.label(), utils.ContractTarget(m.targetPath), source, m.profile)
+	}
+	width, _ := term.Size(os.Stderr)
+	links.Render(os.Stderr, width)
+
+	if len(candidates) == 0 {
+		fmt.Fprintln(os.Stderr, "\nNo links to remove")
+		return nil
+	}
+
+	var picked []mapping
+	for {
+		fmt.Fprintf(os.Stderr, "\nRemove which links? Numbers or ranges such as 1 3-5, a for all, nothing to cancel: ")
+		answer, _ := in.ReadString('\n')
+		numbers, err := parseSelection(answer, len(candidates))
+		if err != nil {
+			utils.FprintfColor(os.Stderr, "red", "%v\n", err)
+			continue
+		}
+		for _, n := range numbers {
+			picked = append(picked, candidates[n-1])
+		}
+		break
+	}
+	if len(picked) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing selected")
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "\nWill remove:")
+	for _, m := range picked {
+		if m.copied != nil {
+			fmt.Fprintf(os.Stderr, "  %s (copy of %s)\n", m.targetPath, m.sourcePath)
+		} else {
+			fmt.Fprintf(os.Stderr, "  %s -> %s\n", m.targetPath, m.sourcePath)
+		}
+	}
+	if yes, _ := ask(in, "Remove %d link(s)? [y/N] ", len(picked)); !yes {
+		fmt.Fprintln(os.Stderr, "Nothing removed")
+		return nil
+	}
+	return picked
+}
+
+// parseSelection parses an answer such as "1 3-5" or "a" into the numbers it picks out
+// of 1 to n, in ascending order and each once
+func parseSelection(answer string, n int) ([]int, error) {
+	fields := strings.FieldsFunc(answer, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '\t' || r == '\n' || r == '\r'
+	})
+
+	picked := make([]bool, n+1)
+	for _, field := range fields {
+		if field == "a" || field == "all" {
+			for i := 1; i <= n; i++ {
+				picked[i] = true
+			}
+			continue
+		}
+
+		first, last, isRange := strings.Cut(field, "-")
+		from, err := strconv.Atoi(first)
+		to := from
+		if err == nil && isRange {
+			to, err = strconv.Atoi(last)
+		}
+		if err != nil || from < 1 || to > n || from > to {
+			return nil, fmt.Errorf("%q is not a number or range between 1 and %d", field, n)
+		}
+		for i := from; i <= to; i++ {
+			picked[i] = true
+		}
+	}
+
+	var numbers []int
+	for i := 1; i <= n; i++ {
+		if picked[i] {
+			numbers = append(numbers, i)
+		}
+	}
+	return numbers, nil
+}
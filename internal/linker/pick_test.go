@@ -0,0 +1,114 @@
+package linker
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		answer   string
+		expected []int
+	}{
+		{"", nil},
+		{"\n", nil},
+		{"2\n", []int{2}},
+		{"3 1", []int{1, 3}},
+		{"1-3, 2", []int{1, 2, 3}},
+		{"a", []int{1, 2, 3, 4}},
+	}
+	for _, test := range tests {
+		result, err := parseSelection(test.answer, 4)
+		if err != nil {
+			t.Errorf("Expected %q to parse, got %v", test.answer, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("Expected %q to select %v, got %v", test.answer, test.expected, result)
+		}
+	}
+
+	for _, answer := range []string{"0", "5", "3-2", "x", "1-"} {
+		if _, err := parseSelection(answer, 4); err == nil {
+			t.Errorf("Expected %q to be rejected", answer)
+		}
+	}
+}
+
+func TestCleanInteractive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	// .gitconfig and .zshrc are linked, .vimrc is not
+	setup := func(t *testing.T) *fsys.Memory {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"gitconfig\" = \"~/.gitconfig\"\n\"vimrc\" = \"~/.vimrc\"\n\"zshrc\" = \"~/.zshrc\"\n"), 0644)
+		memory.WriteFile("/dotfiles/gitconfig", []byte("git"), 0644)
+		memory.WriteFile("/dotfiles/vimrc", []byte("vim"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+		memory.Symlink("/dotfiles/gitconfig", "/home/user/.gitconfig")
+		memory.Symlink("/dotfiles/zshrc", "/home/user/.zshrc")
+		return memory
+	}
+
+	t.Run("Removes only the picked links", func(t *testing.T) {
+		memory := setup(t)
+
+		output := captureOutput(t, func() {
+			// The first answer is out of range and asked again
+			err := CleanWithOptions([]string{"general"}, CleanOptions{Interactive: true, Input: strings.NewReader("3\n2\ny\n")})
+			if err != nil {
+				t.Fatalf("CleanWithOptions failed: %v", err)
+			}
+		})
+		for _, expected := range []string{"not a number or range between 1 and 2", "Will remove:", "/home/user/.zshrc -> /dotfiles/zshrc"} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected %q in the output, got: %s", expected, output)
+			}
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc"); !os.IsNotExist(err) {
+			t.Errorf("Expected .zshrc to be removed, got %v", err)
+		}
+		if _, err := memory.Readlink("/home/user/.gitconfig"); err != nil {
+			t.Errorf("Expected .gitconfig to stay linked: %v", err)
+		}
+	})
+
+	t.Run("Nothing is removed without confirmation", func(t *testing.T) {
+		memory := setup(t)
+
+		captureOutput(t, func() {
+			if err := CleanWithOptions([]string{"general"}, CleanOptions{Interactive: true, Input: strings.NewReader("a\nn\n")}); err != nil {
+				t.Fatalf("CleanWithOptions failed: %v", err)
+			}
+		})
+		for _, target := range []string{"/home/user/.gitconfig", "/home/user/.zshrc"} {
+			if _, err := memory.Readlink(target); err != nil {
+				t.Errorf("Expected %s to stay linked: %v", target, err)
+			}
+		}
+	})
+}
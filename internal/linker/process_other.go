@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package linker
+
+import "os/exec"
+
+// killProcessGroup leaves cmd as it is; where process groups are not available only the
+// hook's own process is killed when it is cancelled
+func killProcessGroup(cmd *exec.Cmd) {}
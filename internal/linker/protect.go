@@ -0,0 +1,87 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/settings"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// defaultProtected are paths whose loss locks you out of a machine or an identity;
+// they are protected whatever the global config says
+var defaultProtected = []string{
+	"~/.ssh/authorized_keys",
+	"~/.gnupg/private-keys-v1.d",
+}
+
+// protection lists the paths that no command backs up, replaces, or deletes, as a
+// last-ditch safety net against a wrong mapping; nil protects nothing
+type protection []string
+
+// loadProtection returns the default protected paths together with those listed in the
+// global config
+func loadProtection() (protection, error) {
+	cfg, err := settings.Load()
+	if err != nil {
+		return nil, err
+	}
+	return newProtection(cfg.Protected), nil
+}
+
+// newProtection returns the default protected paths together with extra ones, which
+// are targets like those in .mappings
+func newProtection(extra []string) protection {
+	var p protection
+	for _, path := range append(append([]string{}, defaultProtected...), extra...) {
+		p = append(p, filepath.Clean(utils.ExpandTarget(path)))
+	}
+	return p
+}
+
+// covers returns the protected path that changing path would touch: path itself, a
+// directory path lies in, or a path inside the directory path; "" if there is none
+func (p protection) covers(path string) string {
+	path = filepath.Clean(path)
+	for _, protected := range p {
+		if utils.SamePath(path, protected) || within(path, protected) || within(protected, path) {
+			return protected
+		}
+	}
+	return ""
+}
+
+// within reports whether path lies inside the directory dir
+func within(path, dir string) bool {
+	prefix := dir + string(os.PathSeparator)
+	if utils.CaseInsensitive {
+		return strings.HasPrefix(strings.ToLower(path), strings.ToLower(prefix))
+	}
+	return strings.HasPrefix(path, prefix)
+}
+
+// refusal returns why changing target is refused, or "" if it touches no protected path
+func (p protection) refusal(target, change string) string {
+	protected := p.covers(target)
+	switch {
+	case protected == "":
+		return ""
+	case utils.SamePath(protected, target):
+		return fmt.Sprintf("Refusing to %s protected path: %s", change, target)
+	default:
+		return fmt.Sprintf("Refusing to %s %s: it would touch protected path %s", change, target, protected)
+	}
+}
+
+// refuseProtected reports an attempt to change a protected target and returns true,
+// or returns false if changing target touches no protected path
+func refuseProtected(p protection, target, change string, out *output) bool {
+	refusal := p.refusal(target, change)
+	if refusal == "" {
+		return false
+	}
+	out.errorf("%s\n", refusal)
+	return true
+}
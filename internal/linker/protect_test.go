@@ -0,0 +1,163 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/journal"
+)
+
+func TestProtectionCovers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths are POSIX style")
+	}
+
+	originalHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", originalHome)
+	os.Setenv("HOME", "/home/user")
+
+	p := newProtection([]string{"~/.kube/config"})
+	tests := map[string]string{
+		"/home/user/.ssh/authorized_keys":                "/home/user/.ssh/authorized_keys",
+		"/home/user/.ssh":                                "/home/user/.ssh/authorized_keys",
+		"/home/user/.gnupg/private-keys-v1.d/key.key":    "/home/user/.gnupg/private-keys-v1.d",
+		"/home/user/.kube/config":                        "/home/user/.kube/config",
+		"/home/user/.ssh/config":                         "",
+		"/home/user/.ssh/authorized_keys2":               "",
+		"/home/user/.gnupg/private-keys-v1.d-not-really": "",
+	}
+	for path, expected := range tests {
+		if result := p.covers(path); result != expected {
+			t.Errorf("Expected %s to be covered by %q, got %q", path, expected, result)
+		}
+	}
+}
+
+func TestProtectedPaths(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+	}()
+
+	memory := fsys.NewMemory()
+	FS = memory
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	// The global config protects ~/.netrc as well
+	configHome := t.TempDir()
+	os.Setenv("XDG_CONFIG_HOME", configHome)
+	os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+	os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("protected = [\"~/.netrc\"]\n"), 0644)
+
+	memory.MkdirAll("/dotfiles/ssh", 0755)
+	memory.MkdirAll("/home/user/.ssh", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"ssh/authorized_keys\" = \"~/.ssh/authorized_keys\"\n\"netrc\" = \"~/.netrc\"\n\"zshrc\" = \"~/.zshrc\"\n"), 0644)
+	memory.WriteFile("/dotfiles/ssh/authorized_keys", []byte("ssh-ed25519 new"), 0644)
+	memory.WriteFile("/dotfiles/netrc", []byte("machine new"), 0644)
+	memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+	memory.WriteFile("/home/user/.ssh/authorized_keys", []byte("ssh-ed25519 current"), 0644)
+	memory.Symlink("/elsewhere/netrc", "/home/user/.netrc")
+
+	t.Run("Link refuses to replace protected paths", func(t *testing.T) {
+		output := captureOutput(t, func() {
+			err := LinkWithOptions([]string{"general"}, LinkOptions{OnConflict: OnConflictOverwrite})
+			if err == nil || !strings.Contains(err.Error(), "2 mapping(s) failed") {
+				t.Errorf("Expected the refused mappings to fail the run, got %v", err)
+			}
+		})
+		if !strings.Contains(output, "Refusing to replace protected path: /home/user/.ssh/authorized_keys") {
+			t.Errorf("Expected authorized_keys to be refused, got: %s", output)
+		}
+		if !strings.Contains(output, "Refusing to replace protected path: /home/user/.netrc") {
+			t.Errorf("Expected .netrc from the global config to be refused, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/home/user/.ssh/authorized_keys"); string(data) != "ssh-ed25519 current" {
+			t.Errorf("Expected authorized_keys to be untouched, got '%s'", data)
+		}
+		if target, _ := memory.Readlink("/home/user/.zshrc"); target != "/dotfiles/zshrc" {
+			t.Errorf("Expected other mappings to be linked, got %q", target)
+		}
+	})
+
+	t.Run("Clean refuses to remove protected links", func(t *testing.T) {
+		memory.Remove("/home/user/.netrc")
+		memory.Symlink("/dotfiles/netrc", "/home/user/.netrc")
+
+		output := captureOutput(t, func() {
+			err := Clean([]string{"general"})
+			if err == nil || !strings.Contains(err.Error(), "1 mapping(s) failed") {
+				t.Errorf("Expected the refused mapping to fail the run, got %v", err)
+			}
+		})
+		if !strings.Contains(output, "Refusing to remove protected path: /home/user/.netrc") {
+			t.Errorf("Expected .netrc to be refused, got: %s", output)
+		}
+		if _, err := memory.Readlink("/home/user/.netrc"); err != nil {
+			t.Errorf("Expected .netrc link to be kept: %v", err)
+		}
+	})
+
+	t.Run("Restoring backups refuses protected paths", func(t *testing.T) {
+		defer func(dir string) { BackupDir = dir }(BackupDir)
+		BackupDir = ""
+		memory.WriteFile("/home/user/.netrc.bak", []byte("machine old"), 0644)
+
+		output := captureOutput(t, func() {
+			if err := RestoreBackups([]string{"general"}, nil, BackupOptions{Yes: true}); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+		if !strings.Contains(output, "Refusing to restore a backup to protected path: /home/user/.netrc") {
+			t.Errorf("Expected .netrc to be refused, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.netrc.bak"); err != nil {
+			t.Errorf("Expected the backup to be kept: %v", err)
+		}
+	})
+
+	t.Run("Undo refuses protected paths", func(t *testing.T) {
+		originalStateHome := os.Getenv("XDG_STATE_HOME")
+		defer os.Setenv("XDG_STATE_HOME", originalStateHome)
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+		journal.Append(journal.Entry{Command: "link", Actions: []journal.Action{
+			journal.NewAction(journal.OpRestore, "/home/user/.ssh/authorized_keys", "/home/user/.ssh/authorized_keys.bak"),
+		}})
+
+		output := captureOutput(t, func() {
+			if err := Undo(1); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+		if !strings.Contains(output, "Refusing to undo a change to protected path: /home/user/.ssh/authorized_keys") {
+			t.Errorf("Expected authorized_keys to be refused, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/home/user/.ssh/authorized_keys"); string(data) != "ssh-ed25519 current" {
+			t.Errorf("Expected authorized_keys to be untouched, got '%s'", data)
+		}
+	})
+
+	t.Run("Adopt refuses protected paths", func(t *testing.T) {
+		err := Adopt("~/.ssh/authorized_keys", "ssh/keys", "general")
+		if err == nil || !strings.Contains(err.Error(), "protected path /home/user/.ssh/authorized_keys") {
+			t.Errorf("Expected the adoption to be refused, got %v", err)
+		}
+		if _, err := memory.Lstat("/dotfiles/ssh/keys"); !os.IsNotExist(err) {
+			t.Errorf("Expected nothing to be moved, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package linker
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// recursiveSuffix marks a source directory whose files are linked one by one instead of
+// the directory itself, e.g. "config/nvim/**" = "~/.config/nvim/"
+// The target directory stays a real directory, so files that programs generate in it,
+// like undo history or plugin caches, stay out of the repository
+const recursiveSuffix = "/**"
+
+// files returns the files beneath dir, relative to it with slashes, in sorted order
+// A file with ##alternates is listed once by its base name; directories beyond
+// tree.MaxDepth and symlinked directories, unless tree.FollowSymlinks is set, are not
+// descended into but listed like files; what ignore matches, by its path relative to
+// the dotfiles directory where dir is source, is left out
+// Reports false if dir cannot be read
+func (c *dirCache) files(dir, source string, tree config.Tree, ignore ignoreList) ([]string, bool) {
+	return c.walkFiles(dir, source, []string{dir}, tree, ignore)
+}
+
+// walkFiles implements files for dir, given the paths with symlinks followed of the
+// directories from the entry's directory down to dir
+func (c *dirCache) walkFiles(dir, source string, reals []string, tree config.Tree, ignore ignoreList) ([]string, bool) {
+	entries, ok := c.entries(dir)
+	if !ok {
+		return nil, false
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for name, mode := range entries {
+		base, _, _ := strings.Cut(name, "##")
+		if ignore.ignored(path.Join(source, base), mode.IsDir()) {
+			continue
+		}
+		target := filepath.Join(dir, name)
+		if tree.MaxDepth == 0 || len(reals) < tree.MaxDepth {
+			real, descend := "", false
+			switch {
+			case mode.IsDir():
+				real, descend = filepath.Join(reals[len(reals)-1], name), true
+			case mode&fs.ModeSymlink != 0 && tree.FollowSymlinks:
+				real, descend = c.symlinkedDir(target, reals)
+			}
+			if descend {
+				nested, _ := c.walkFiles(target, path.Join(source, name), append(reals[:len(reals):len(reals)], real), tree, ignore)
+				for _, file := range nested {
+					files = append(files, name+"/"+file)
+				}
+				continue
+			}
+		}
+		if !seen[base] {
+			seen[base] = true
+			files = append(files, base)
+		}
+	}
+	sort.Strings(files)
+	return files, true
+}
+
+// symlinkedDir returns the path that the link at path resolves to, if it is a directory
+// to descend into: one that holds none of reals, the directories the link is in, since
+// following a link back up would never end
+func (c *dirCache) symlinkedDir(path string, reals []string) (string, bool) {
+	info, err := c.fs.Stat(path)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	target, err := c.fs.Readlink(path)
+	if err != nil {
+		return "", false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(reals[len(reals)-1], target)
+	}
+	target = filepath.Clean(target)
+	for _, real := range reals {
+		if utils.SamePath(real, target) || within(real, target) {
+			return "", false
+		}
+	}
+	return target, true
+}
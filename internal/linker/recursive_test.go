@@ -0,0 +1,157 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestRecursiveMappings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalHostname := hostname
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		hostname = originalHostname
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+	hostname = func() string { return "laptop" }
+
+	// setup maps the nvim directory recursively
+	setup := func() *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles/nvim/lua/plugins", 0755)
+		memory.MkdirAll("/home/.config/nvim", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"nvim/**\" = \"/home/.config/nvim/\"\n"), 0644)
+		memory.WriteFile("/dotfiles/nvim/init.lua", []byte("init"), 0644)
+		memory.WriteFile("/dotfiles/nvim/lua/plugins/lsp.lua", []byte("lsp"), 0644)
+		memory.WriteFile("/dotfiles/nvim/lua/local.lua##hostname.laptop", []byte("laptop"), 0644)
+		memory.WriteFile("/dotfiles/nvim/lua/work.lua##hostname.desktop", []byte("desktop"), 0644)
+		return memory
+	}
+
+	t.Run("Link creates a link per file", func(t *testing.T) {
+		memory := setup()
+		memory.WriteFile("/home/.config/nvim/undo.log", []byte("generated"), 0644)
+
+		var err error
+		output := captureOutput(t, func() {
+			err = Link([]string{"general"}, false)
+		})
+		if err != nil {
+			t.Fatalf("Link failed: %v\n%s", err, output)
+		}
+
+		expected := map[string]string{
+			"/home/.config/nvim/init.lua":            "/dotfiles/nvim/init.lua",
+			"/home/.config/nvim/lua/plugins/lsp.lua": "/dotfiles/nvim/lua/plugins/lsp.lua",
+			"/home/.config/nvim/lua/local.lua":       "/dotfiles/nvim/lua/local.lua##hostname.laptop",
+		}
+		for target, source := range expected {
+			if link, err := memory.Readlink(target); err != nil || link != source {
+				t.Errorf("Expected %s to link to %s, got %s (%v)", target, source, link, err)
+			}
+		}
+		if _, err := memory.Lstat("/home/.config/nvim/lua/work.lua"); !os.IsNotExist(err) {
+			t.Errorf("Expected no link for an alternate of another host, got %v", err)
+		}
+		if info, err := memory.Lstat("/home/.config/nvim"); err != nil || info.Mode()&os.ModeSymlink != 0 {
+			t.Errorf("Expected the target to stay a directory, got %v", err)
+		}
+		if data, _ := memory.ReadFile("/home/.config/nvim/undo.log"); string(data) != "generated" {
+			t.Errorf("Expected generated files to be left alone, got '%s'", data)
+		}
+
+		output = captureOutput(t, func() {
+			err = Check([]string{"general"})
+		})
+		if err != nil {
+			t.Errorf("Expected every link to be correct, got %v: %s", err, output)
+		}
+	})
+
+	t.Run("Missing directory is reported", func(t *testing.T) {
+		memory := setup()
+		memory.RemoveAll("/dotfiles/nvim")
+
+		output := captureOutput(t, func() {
+			Link([]string{"general"}, false)
+		})
+		if !strings.Contains(output, "/dotfiles/nvim") {
+			t.Errorf("Expected the missing source to be reported, got: %s", output)
+		}
+	})
+	t.Run("max_depth links deeper directories as a whole", func(t *testing.T) {
+		memory := setup()
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"nvim/**\" = { target = \"/home/.config/nvim/\", max_depth = 2 }\n"), 0644)
+
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+
+		if link, _ := memory.Readlink("/home/.config/nvim/lua/plugins"); link != "/dotfiles/nvim/lua/plugins" {
+			t.Errorf("Expected the directory at the limit to be linked, got %q", link)
+		}
+		if link, _ := memory.Readlink("/home/.config/nvim/lua/local.lua"); link != "/dotfiles/nvim/lua/local.lua##hostname.laptop" {
+			t.Errorf("Expected files within the limit to be linked, got %q", link)
+		}
+	})
+
+	t.Run("follow_symlinks descends into linked directories, but not back up", func(t *testing.T) {
+		memory := setup()
+		memory.MkdirAll("/vendor/colors", 0755)
+		memory.WriteFile("/vendor/colors/dark.lua", []byte("dark"), 0644)
+		memory.Symlink("/vendor/colors", "/dotfiles/nvim/colors")
+		memory.Symlink("..", "/dotfiles/nvim/lua/parent")
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"nvim/**\" = { target = \"/home/.config/nvim/\", follow_symlinks = true }\n"), 0644)
+
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+
+		if link, _ := memory.Readlink("/home/.config/nvim/colors/dark.lua"); link != "/dotfiles/nvim/colors/dark.lua" {
+			t.Errorf("Expected the files of the linked directory to be linked, got %q", link)
+		}
+		if link, _ := memory.Readlink("/home/.config/nvim/lua/parent"); link != "/dotfiles/nvim/lua/parent" {
+			t.Errorf("Expected the link back up to be linked as a whole, got %q", link)
+		}
+	})
+	t.Run(".dotignore and ignore leave out matching files", func(t *testing.T) {
+		memory := setup()
+		memory.WriteFile("/dotfiles/.dotignore", []byte("# editor files\n*.swp\n.DS_Store\n"), 0644)
+		memory.WriteFile("/dotfiles/nvim/.init.lua.swp", []byte("swap"), 0644)
+		memory.WriteFile("/dotfiles/nvim/lua/.DS_Store", []byte("finder"), 0644)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"nvim/**\" = { target = \"/home/.config/nvim/\", ignore = [\"/lua/plugins/\"] }\n"), 0644)
+
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+
+		for _, target := range []string{"/home/.config/nvim/.init.lua.swp", "/home/.config/nvim/lua/.DS_Store", "/home/.config/nvim/lua/plugins"} {
+			if _, err := memory.Lstat(target); !os.IsNotExist(err) {
+				t.Errorf("Expected no link for ignored %s, got %v", target, err)
+			}
+		}
+		if link, _ := memory.Readlink("/home/.config/nvim/init.lua"); link != "/dotfiles/nvim/init.lua" {
+			t.Errorf("Expected other files to be linked, got %q", link)
+		}
+	})
+}
@@ -0,0 +1,135 @@
+package linker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/journal"
+)
+
+// Report is the machine-readable record of a link or check run, written with --report
+// so that CI jobs can archive evidence of what was applied, or to stdout with --output json
+type Report struct {
+	Command     string          `json:"command"`
+	Profiles    []string        `json:"profiles"`
+	Options     map[string]bool `json:"options,omitempty"`
+	DotfilesDir string          `json:"dotfiles_dir,omitempty"`
+	Host        string          `json:"host"`
+	OS          string          `json:"os"`
+	Started     time.Time       `json:"started"`
+	DurationMS  int64           `json:"duration_ms"`
+	Phases      []PhaseReport   `json:"phases,omitempty"`
+	Mappings    []MappingReport `json:"mappings"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// PhaseReport is how long a phase of a run took in a Report, see timings
+type PhaseReport struct {
+	Phase      string `json:"phase"`
+	DurationMS int64  `json:"duration_ms"`
+	// LookupsMS is the part of the duration spent reading directories and stat-ing files
+	LookupsMS int64 `json:"lookups_ms"`
+}
+
+// MappingReport is the outcome of one mapping in a Report
+type MappingReport struct {
+	Source     string           `json:"source"`
+	Target     string           `json:"target"`
+	Profile    string           `json:"profile"`
+	Result     string           `json:"result"` // one of the Action constants
+	Messages   []string         `json:"messages,omitempty"`
+	Actions    []journal.Action `json:"actions,omitempty"`
+	DurationMS int64            `json:"duration_ms"`
+	LookupsMS  int64            `json:"lookups_ms,omitempty"`
+}
+
+// newReport starts the report of a run, or returns nil when no report was asked for
+func newReport(enabled bool, command string, profiles []string, options map[string]bool) *Report {
+	if !enabled {
+		return nil
+	}
+	host, _ := os.Hostname()
+	dotfilesDir, _ := dotfiles.GetDotfilesDir()
+	return &Report{
+		Command:     command,
+		Profiles:    profiles,
+		Options:     options,
+		DotfilesDir: dotfilesDir,
+		Host:        host,
+		OS:          runtime.GOOS,
+		Started:     time.Now(),
+		Mappings:    []MappingReport{},
+	}
+}
+
+// addOutput adds the outcome of a mapping processed by link, which spent lookups of its
+// duration reading directories and stat-ing files
+func (r *Report) addOutput(m mapping, out *output, duration, lookups time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.add(m, out.action(), out.messages, out.actions, duration)
+	r.Mappings[len(r.Mappings)-1].LookupsMS = lookups.Milliseconds()
+}
+
+// addTimings adds the phases measured by tm
+func (r *Report) addTimings(tm *timings) {
+	if r == nil || tm == nil {
+		return
+	}
+	for _, p := range tm.phases {
+		r.Phases = append(r.Phases, PhaseReport{Phase: p.name, DurationMS: p.duration.Milliseconds(), LookupsMS: p.lookups.Milliseconds()})
+	}
+}
+
+// add adds the outcome of a mapping
+func (r *Report) add(m mapping, result string, messages []string, actions []journal.Action, duration time.Duration) {
+	if r == nil {
+		return
+	}
+	r.Mappings = append(r.Mappings, MappingReport{
+		Source:     m.source,
+		Target:     m.targetPath,
+		Profile:    m.profile,
+		Result:     result,
+		Messages:   messages,
+		Actions:    actions,
+		DurationMS: duration.Milliseconds(),
+	})
+}
+
+// write finishes the report with the run's error and writes it to path as JSON, and with
+// stdout set to stdout too, for --output json; an empty path writes no file
+// A report that cannot be written is an error only if the run itself succeeded
+func (r *Report) write(path string, stdout bool, runErr error) error {
+	if r == nil {
+		return runErr
+	}
+
+	r.DurationMS = time.Since(r.Started).Milliseconds()
+	if runErr != nil {
+		r.Error = runErr.Error()
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err == nil && stdout {
+		_, err = os.Stdout.Write(append(data, '\n'))
+	}
+	if err == nil && path != "" {
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to write report %s: %w", path, err)
+		if runErr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return runErr
+		}
+		return err
+	}
+	return runErr
+}
@@ -0,0 +1,122 @@
+package linker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer func() {
+		if originalDotDir != "" {
+			os.Setenv("DOT_DIR", originalDotDir)
+		} else {
+			os.Unsetenv("DOT_DIR")
+		}
+	}()
+
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	homeDir := filepath.Join(tempDir, "home")
+	os.Setenv("DOT_DIR", dotfilesDir)
+	setupTestEnvironment(t, dotfilesDir, homeDir)
+
+	mappings := `[general]
+"vim/.vimrc" = "` + filepath.ToSlash(filepath.Join(homeDir, ".vimrc")) + `"
+"missing" = "` + filepath.ToSlash(filepath.Join(homeDir, ".missing")) + `"
+`
+	os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+
+	readReport := func(t *testing.T, path string) Report {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Expected a report, got: %v", err)
+		}
+		var report Report
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("Expected valid JSON, got: %v", err)
+		}
+		return report
+	}
+
+	results := func(report Report) map[string]string {
+		byTarget := make(map[string]string)
+		for _, m := range report.Mappings {
+			byTarget[filepath.Base(m.Target)] = m.Result
+		}
+		return byTarget
+	}
+
+	t.Run("Link reports every mapping", func(t *testing.T) {
+		path := filepath.Join(tempDir, "link.json")
+		captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{Report: path}); err != nil {
+				t.Errorf("Link failed: %v", err)
+			}
+		})
+
+		report := readReport(t, path)
+		if report.Command != "link" || report.DotfilesDir != dotfilesDir {
+			t.Errorf("Expected link in %s, got %s in %s", dotfilesDir, report.Command, report.DotfilesDir)
+		}
+		byTarget := results(report)
+		if byTarget[".vimrc"] != ActionChanged || byTarget[".missing"] != ActionSkipped {
+			t.Errorf("Expected changed and skipped mappings, got %v", byTarget)
+		}
+		for _, m := range report.Mappings {
+			if m.Result == ActionChanged && len(m.Actions) == 0 {
+				t.Errorf("Expected the actions of %s, got none", m.Target)
+			}
+		}
+	})
+
+	t.Run("Link reports its phases", func(t *testing.T) {
+		path := filepath.Join(tempDir, "phases.json")
+		captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{Report: path}); err != nil {
+				t.Errorf("Link failed: %v", err)
+			}
+		})
+
+		var phases []string
+		for _, p := range readReport(t, path).Phases {
+			phases = append(phases, p.Phase)
+		}
+		if strings.Join(phases, ",") != "config,resolve,pre_link,link,hooks" {
+			t.Errorf("Expected config,resolve,pre_link,link,hooks, got %v", phases)
+		}
+	})
+
+	t.Run("Failed run still writes the report", func(t *testing.T) {
+		path := filepath.Join(tempDir, "strict.json")
+		captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{Strict: true, Report: path}); err == nil {
+				t.Error("Expected strict link to fail")
+			}
+		})
+
+		report := readReport(t, path)
+		if report.Error == "" || !report.Options["strict"] {
+			t.Errorf("Expected the error of a strict run, got %+v", report)
+		}
+		if results(report)[".missing"] != ActionFailed {
+			t.Errorf("Expected the missing source to fail, got %v", results(report))
+		}
+	})
+
+	t.Run("Check reports issues", func(t *testing.T) {
+		path := filepath.Join(tempDir, "check.json")
+		captureOutput(t, func() {
+			CheckWithOptions([]string{"general"}, CheckOptions{Report: path})
+		})
+
+		byTarget := results(readReport(t, path))
+		if byTarget[".vimrc"] != ActionOK || byTarget[".missing"] != ActionIssue {
+			t.Errorf("Expected ok and issue, got %v", byTarget)
+		}
+	})
+}
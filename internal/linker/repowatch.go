@@ -0,0 +1,167 @@
+package linker
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// settleDelay is how long the repository has to be quiet before its changes are linked,
+// so that an editor's burst of writes or a git checkout is linked once
+var settleDelay = 300 * time.Millisecond
+
+// watchRepo reports the paths that change in the dotfiles directory dir until ctx is
+// done, in batches separated by quiet periods; tests replace it
+var watchRepo = notifyRepo
+
+// notifyRepo implements watchRepo with filesystem notifications for dir and every
+// directory beneath it except .git, including directories created while watching
+func notifyRepo(ctx context.Context, dir string) (<-chan []string, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watchTree(watcher, dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	changes := make(chan []string)
+	go func() {
+		defer close(changes)
+		defer watcher.Close()
+
+		pending := make(map[string]bool)
+		settled := time.NewTimer(settleDelay)
+		settled.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if inGitDir(dir, event.Name) {
+					continue
+				}
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						watchTree(watcher, event.Name)
+					}
+				}
+				pending[event.Name] = true
+				settled.Reset(settleDelay)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				utils.FprintfColor(os.Stderr, "red", "Error: watching %s: %v\n", dir, err)
+			case <-settled.C:
+				batch := make([]string, 0, len(pending))
+				for path := range pending {
+					batch = append(batch, path)
+				}
+				slices.Sort(batch)
+				clear(pending)
+				select {
+				case changes <- batch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// watchTree adds dir and the directories beneath it, except .git, to watcher
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// inGitDir reports whether path lies in the .git directory of dotfilesDir, whose
+// changes are git's bookkeeping rather than changes to sources
+func inGitDir(dotfilesDir, path string) bool {
+	gitDir := filepath.Join(dotfilesDir, ".git")
+	return utils.SamePath(path, gitDir) || within(path, gitDir)
+}
+
+// changesMappings reports whether any of the changed paths is .mappings, one of its
+// fragments, or the ignoreFile, which may change every mapping
+func changesMappings(dotfilesDir string, changed []string) bool {
+	mappingsPath := filepath.Join(dotfilesDir, ".mappings")
+	ignorePath := filepath.Join(dotfilesDir, ignoreFile)
+	fragmentsDir := filepath.Join(dotfilesDir, config.FragmentsDir)
+	for _, path := range changed {
+		if utils.SamePath(path, mappingsPath) || utils.SamePath(path, ignorePath) {
+			return true
+		}
+		if utils.SamePath(path, fragmentsDir) || within(path, fragmentsDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// affectedBy returns the mappings that a change to the changed paths affects: those whose
+// source, template, encrypted file, or a file their template includes is one of the
+// paths, lies beneath one, or is a directory containing one
+// A template that cannot be parsed affects its mapping, so rendering reports the error
+// A changed alternate, e.g. zshrc##os.darwin, affects the mappings of its plain source,
+// since it may now be the alternate that matches
+func affectedBy(f fsys.FS, dotfilesDir string, mappings []mapping, changed []string) []mapping {
+	var affected []mapping
+	for _, m := range mappings {
+		sources := []string{m.sourcePath, m.template, m.encrypted}
+		if m.template != "" {
+			_, included, err := parseTemplate(f, dotfilesDir, m)
+			if err != nil {
+				affected = append(affected, m)
+				continue
+			}
+			sources = append(sources, included...)
+		}
+		if slices.ContainsFunc(changed, func(path string) bool { return affects(sources, path) }) {
+			affected = append(affected, m)
+		}
+	}
+	return affected
+}
+
+// affects reports whether a change to path affects a mapping with the given sources,
+// see affectedBy
+func affects(sources []string, path string) bool {
+	plain, _, _ := strings.Cut(path, "##")
+	for _, source := range sources {
+		if source == "" {
+			continue
+		}
+		sourcePlain, _, _ := strings.Cut(source, "##")
+		if utils.SamePath(path, source) || utils.SamePath(plain, sourcePlain) || within(path, source) || within(source, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package linker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestAffectedBy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths are POSIX style")
+	}
+
+	mappings := []mapping{
+		{sourcePath: "/dotfiles/zshrc##os.linux", targetPath: "/home/user/.zshrc"},
+		{sourcePath: "/dotfiles/nvim", targetPath: "/home/user/.config/nvim"},
+		{sourcePath: "/data/rendered/gitconfig", template: "/dotfiles/gitconfig.tmpl", targetPath: "/home/user/.gitconfig"},
+		{sourcePath: "/dotfiles/vimrc", targetPath: "/home/user/.vimrc"},
+	}
+	memory := fsys.NewMemory()
+	memory.MkdirAll("/dotfiles/shared", 0755)
+	memory.WriteFile("/dotfiles/gitconfig.tmpl", []byte("{{ template \"shared/user.conf\" . }}"), 0644)
+	memory.WriteFile("/dotfiles/shared/user.conf", []byte("[user]\n"), 0644)
+	targets := func(changed ...string) []string {
+		var targets []string
+		for _, m := range affectedBy(memory, "/dotfiles", mappings, changed) {
+			targets = append(targets, m.targetPath)
+		}
+		return targets
+	}
+
+	for _, tt := range []struct {
+		name     string
+		changed  []string
+		expected []string
+	}{
+		{"A changed source", []string{"/dotfiles/vimrc"}, []string{"/home/user/.vimrc"}},
+		{"A file beneath a directory source", []string{"/dotfiles/nvim/init.lua"}, []string{"/home/user/.config/nvim"}},
+		{"A changed template", []string{"/dotfiles/gitconfig.tmpl"}, []string{"/home/user/.gitconfig"}},
+		{"A file the template includes", []string{"/dotfiles/shared/user.conf"}, []string{"/home/user/.gitconfig"}},
+		{"Another alternate of the source", []string{"/dotfiles/zshrc##os.darwin"}, []string{"/home/user/.zshrc"}},
+		{"A file no mapping uses", []string{"/dotfiles/README.md"}, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := targets(tt.changed...); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestChangesMappings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths are POSIX style")
+	}
+
+	if !changesMappings("/dotfiles", []string{"/dotfiles/zshrc", "/dotfiles/.mappings"}) {
+		t.Error("Expected a change to .mappings to change the mappings")
+	}
+	if !changesMappings("/dotfiles", []string{"/dotfiles/.mappings.d/10-git.toml"}) {
+		t.Error("Expected a change to a fragment to change the mappings")
+	}
+	if !changesMappings("/dotfiles", []string{"/dotfiles/.dotignore"}) {
+		t.Error("Expected a change to .dotignore to change the mappings")
+	}
+	if changesMappings("/dotfiles", []string{"/dotfiles/zshrc"}) {
+		t.Error("Expected a change to a source not to change the mappings")
+	}
+}
+
+func TestNotifyRepo(t *testing.T) {
+	originalDelay := settleDelay
+	defer func() { settleDelay = originalDelay }()
+	settleDelay = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+	os.MkdirAll(filepath.Join(dir, "nvim"), 0755)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := notifyRepo(ctx, dir)
+	if err != nil {
+		t.Fatalf("notifyRepo failed: %v", err)
+	}
+
+	// receive waits for the next batch of changes
+	receive := func() []string {
+		select {
+		case batch := <-changes:
+			return batch
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected a batch of changes")
+		}
+		return nil
+	}
+
+	t.Run("Changes in subdirectories are reported once settled", func(t *testing.T) {
+		os.WriteFile(filepath.Join(dir, ".git", "index"), []byte("index"), 0644)
+		os.WriteFile(filepath.Join(dir, "nvim", "init.lua"), []byte("lua"), 0644)
+
+		batch := receive()
+		if !slices.Contains(batch, filepath.Join(dir, "nvim", "init.lua")) {
+			t.Errorf("Expected init.lua to be reported, got %v", batch)
+		}
+		for _, path := range batch {
+			if strings.Contains(path, ".git") {
+				t.Errorf("Expected changes in .git to be ignored, got %v", batch)
+			}
+		}
+	})
+
+	t.Run("New directories are watched", func(t *testing.T) {
+		os.MkdirAll(filepath.Join(dir, "tmux"), 0755)
+		receive()
+		os.WriteFile(filepath.Join(dir, "tmux", "tmux.conf"), []byte("tmux"), 0644)
+
+		batch := receive()
+		if !slices.Contains(batch, filepath.Join(dir, "tmux", "tmux.conf")) {
+			t.Errorf("Expected tmux.conf to be reported, got %v", batch)
+		}
+	})
+
+	t.Run("The channel closes when the context is done", func(t *testing.T) {
+		cancel()
+		select {
+		case _, ok := <-changes:
+			if ok {
+				t.Error("Expected the channel to be closed")
+			}
+		case <-time.After(5 * time.Second):
+			t.Error("Expected the channel to be closed")
+		}
+	})
+}
+
+func TestWatchLink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalWatchRepo := watchRepo
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		watchRepo = originalWatchRepo
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+
+	memory := fsys.NewMemory()
+	FS = memory
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	memory.MkdirAll("/dotfiles", 0755)
+	memory.MkdirAll("/home/user", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+	memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+	memory.WriteFile("/dotfiles/vimrc", []byte("vim"), 0644)
+
+	// watch runs Watch until the batches of changes were handled
+	watch := func(t *testing.T, batches ...[]string) string {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		watchRepo = func(ctx context.Context, dir string) (<-chan []string, error) {
+			changes := make(chan []string)
+			go func() {
+				for _, batch := range batches {
+					changes <- batch
+				}
+				// Watch handles the last batch before it looks at ctx again
+				cancel()
+			}()
+			return changes, nil
+		}
+		return captureOutput(t, func() {
+			if err := Watch(ctx, []string{"general"}, WatchOptions{Interval: time.Hour, OnReplace: OnReplaceWarn, Link: true}); err != nil {
+				t.Errorf("Watch failed: %v", err)
+			}
+		})
+	}
+
+	t.Run("A changed source links its mapping only", func(t *testing.T) {
+		output := watch(t, []string{"/dotfiles/zshrc"})
+		if !strings.Contains(output, "Created: /home/user/.zshrc -> /dotfiles/zshrc") {
+			t.Errorf("Expected .zshrc to be linked, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.vimrc"); !os.IsNotExist(err) {
+			t.Errorf("Expected .vimrc to be left alone, got %v", err)
+		}
+	})
+
+	t.Run("A changed .mappings links every mapping", func(t *testing.T) {
+		output := watch(t, []string{"/dotfiles/.mappings"})
+		if !strings.Contains(output, "Mappings changed, linking again") || !strings.Contains(output, "Created: /home/user/.vimrc -> /dotfiles/vimrc") {
+			t.Errorf("Expected every mapping to be linked, got: %s", output)
+		}
+	})
+
+	t.Run("Unrelated changes link nothing", func(t *testing.T) {
+		memory.Remove("/home/user/.zshrc")
+		output := watch(t, []string{"/dotfiles/README.md"})
+		if strings.Contains(output, "Created") {
+			t.Errorf("Expected nothing to be linked, got: %s", output)
+		}
+	})
+}
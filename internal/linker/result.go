@@ -0,0 +1,158 @@
+package linker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Actions of a LinkResult, which are also the results of the mappings of a Report
+const (
+	ActionChanged     = "changed"     // link created or replaced what was at the target
+	ActionUnchanged   = "unchanged"   // the target was already correct
+	ActionSkipped     = "skipped"     // the mapping was left alone, e.g. its source is missing
+	ActionFailed      = "failed"      // a step of the mapping failed
+	ActionInterrupted = "interrupted" // a signal stopped the run before the mapping was finished
+	ActionOK          = "ok"          // check found the target correct
+	ActionIssue       = "issue"       // check found something wrong with the target
+)
+
+// LinkResult is the outcome of one mapping of a link, check, or clean run, or for list
+// the state of its link
+type LinkResult struct {
+	Source  string // as .mappings declares it
+	Target  string // absolute target path
+	Profile string // the profile the mapping was selected from
+	// Action is one of the Action constants, or for list one of the states it shows,
+	// e.g. linked or not-linked
+	Action string
+	// Messages are what processing the mapping reported, without color
+	Messages []string
+	// Err is what went wrong when Action is ActionFailed or ActionIssue
+	Err error
+}
+
+// Presenter shows what a run does: the dot command prints it, while programs embedding
+// dot collect the results instead, see Collector
+type Presenter interface {
+	// Messagef shows a message about the run as a whole, such as a warning, in a color
+	// of utils.SprintfColor, or uncolored if color is ""
+	Messagef(color, format string, args ...interface{})
+	// Result shows the outcome of a mapping together with the lines processing it printed
+	Result(r LinkResult, lines []string)
+}
+
+// Output is the presenter of link, check, clean, and list runs
+// The output of hooks and of interactive prompts always goes to the terminal
+var Output Presenter = terminal{}
+
+// terminal is the Presenter of the dot command, which prints to stderr and leaves stdout
+// to the data a command prints
+type terminal struct{}
+
+// Messagef prints a message to stderr
+func (terminal) Messagef(color, format string, args ...interface{}) {
+	if color == "" {
+		fmt.Fprintf(os.Stderr, format, args...)
+		return
+	}
+	utils.FprintfColor(os.Stderr, color, format, args...)
+}
+
+// Result prints the lines a mapping produced to stderr
+func (terminal) Result(_ LinkResult, lines []string) {
+	for _, line := range lines {
+		fmt.Fprint(os.Stderr, line)
+	}
+}
+
+// Collector is a Presenter that keeps the results and messages of a run instead of
+// printing them
+type Collector struct {
+	Results  []LinkResult
+	Messages []string // without color or trailing newline
+}
+
+// Messagef keeps a message
+func (c *Collector) Messagef(_, format string, args ...interface{}) {
+	c.Messages = append(c.Messages, strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+// Result keeps the outcome of a mapping
+func (c *Collector) Result(r LinkResult, _ []string) {
+	c.Results = append(c.Results, r)
+}
+
+// action returns the Action a mapping processed with out ended in
+func (o *output) action() string {
+	switch {
+	case o.interrupted:
+		return ActionInterrupted
+	case o.failed:
+		return ActionFailed
+	case len(o.actions) > 0:
+		return ActionChanged
+	case len(o.messages) > 0:
+		return ActionSkipped
+	}
+	return ActionUnchanged
+}
+
+// result returns the LinkResult of a mapping processed with out
+func (o *output) result(m mapping) LinkResult {
+	var err error
+	if o.failed {
+		err = errors.New(strings.Join(o.errors, "; "))
+	}
+	return newResult(m, o.action(), o.messages, err)
+}
+
+// newResult returns the LinkResult of a mapping
+func newResult(m mapping, action string, messages []string, err error) LinkResult {
+	return LinkResult{Source: m.source, Target: m.targetPath, Profile: m.profile, Action: action, Messages: messages, Err: err}
+}
+
+// settle adds the outcome of a mapping that was not processed by forEachMapping to rep
+// and shows it through Output; message, if any, is what went wrong with the mapping
+func settle(rep *Report, m mapping, action, message string, duration time.Duration) {
+	var messages []string
+	var err error
+	if message != "" {
+		messages = []string{message}
+		err = errors.New(message)
+	}
+	rep.add(m, action, messages, nil, duration)
+	Output.Result(newResult(m, action, messages, err), nil)
+}
+
+// collect runs fn with a Collector as Output and returns the results it showed
+func collect(fn func() error) ([]LinkResult, error) {
+	previous := Output
+	c := &Collector{}
+	Output = c
+	defer func() { Output = previous }()
+	err := fn()
+	return c.Results, err
+}
+
+// LinkResults links the profiles like LinkWithOptions without printing, and returns the
+// outcome of every mapping
+func LinkResults(profiles []string, opts LinkOptions) ([]LinkResult, error) {
+	return collect(func() error { return LinkWithOptions(profiles, opts) })
+}
+
+// CheckResults checks the links of the profiles like Check without printing, and returns
+// the outcome of every mapping; the error reports how many issues were found
+func CheckResults(profiles []string) ([]LinkResult, error) {
+	return collect(func() error { return Check(profiles) })
+}
+
+// CleanResults removes the links of the profiles like CleanWithOptions without printing,
+// and returns the outcome of every mapping
+func CleanResults(profiles []string, opts CleanOptions) ([]LinkResult, error) {
+	return collect(func() error { return CleanWithOptions(profiles, opts) })
+}
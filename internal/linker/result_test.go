@@ -0,0 +1,107 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestResults(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	setup := func() *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("# zsh\n"), 0644)
+		return memory
+	}
+
+	t.Run("Link returns the outcome of every mapping without printing", func(t *testing.T) {
+		memory := setup()
+
+		var results []LinkResult
+		var err error
+		output := captureOutput(t, func() {
+			results, err = LinkResults([]string{"general"}, LinkOptions{})
+		})
+		if err != nil {
+			t.Fatalf("LinkResults failed: %v", err)
+		}
+		if output != "" {
+			t.Errorf("Expected nothing to be printed, got: %s", output)
+		}
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %v", results)
+		}
+		if r := results[0]; r.Target != "/home/user/.vimrc" || r.Action != ActionSkipped || r.Err != nil {
+			t.Errorf("Expected the missing source to be skipped, got %+v", r)
+		}
+		if r := results[1]; r.Source != "zshrc" || r.Target != "/home/user/.zshrc" || r.Action != ActionChanged {
+			t.Errorf("Expected ~/.zshrc to be linked, got %+v", r)
+		}
+		if target, _ := memory.Readlink("/home/user/.zshrc"); target != "/dotfiles/zshrc" {
+			t.Errorf("Expected the link to be created, got %q", target)
+		}
+	})
+
+	t.Run("Check returns issues as errors of their mappings", func(t *testing.T) {
+		setup()
+
+		results, err := CheckResults([]string{"general"})
+		if err == nil {
+			t.Error("Expected the issues to fail the check")
+		}
+		if len(results) != 2 || results[1].Action != ActionIssue || results[1].Err == nil ||
+			!strings.Contains(results[1].Err.Error(), "Missing link: /home/user/.zshrc") {
+			t.Errorf("Expected the missing link as an issue, got %+v", results)
+		}
+	})
+
+	t.Run("List returns the state of every link", func(t *testing.T) {
+		memory := setup()
+		memory.Symlink("/elsewhere/zshrc", "/home/user/.zshrc")
+
+		results, err := ListResults([]string{"general"})
+		if err != nil {
+			t.Fatalf("ListResults failed: %v", err)
+		}
+		if len(results) != 2 || results[1].Action != string(stateWrongLink) || results[1].Messages[0] != "/elsewhere/zshrc" {
+			t.Errorf("Expected the wrong link with where it points, got %+v", results)
+		}
+	})
+
+	t.Run("Clean returns the removed links and restores the presenter", func(t *testing.T) {
+		setup()
+		LinkResults([]string{"general"}, LinkOptions{})
+
+		results, err := CleanResults([]string{"general"}, CleanOptions{})
+		if err != nil {
+			t.Fatalf("CleanResults failed: %v", err)
+		}
+		if len(results) != 2 || results[1].Action != ActionChanged {
+			t.Errorf("Expected the link to be removed, got %+v", results)
+		}
+		if _, ok := Output.(terminal); !ok {
+			t.Errorf("Expected the terminal presenter to be restored, got %T", Output)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package linker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/secrets"
+	"github.com/yourusername/dot/internal/xdg"
+)
+
+// decryptedDir returns where encrypted sources are decrypted to,
+// $XDG_DATA_HOME/dot/decrypted, which only the user can enter
+func decryptedDir() (string, error) {
+	return xdg.Data.Path("decrypted")
+}
+
+// decrypt returns the plaintext of the content of an encrypted file, see secrets.DecryptData
+var decrypt = secrets.DecryptData
+
+// decryptSource returns the plaintext of the encrypted source of a mapping
+func decryptSource(f fsys.FS, m mapping) ([]byte, error) {
+	ciphertext, err := f.ReadFile(m.encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return decrypt(m.encrypted, ciphertext)
+}
+
+// secretStamp returns where the stamp of the plaintext at sourcePath is kept: a hidden
+// file next to it that records what the plaintext was decrypted from, see stampOf
+func secretStamp(sourcePath string) string {
+	return filepath.Join(filepath.Dir(sourcePath), "."+filepath.Base(sourcePath)+".sha256")
+}
+
+// stampOf returns the stamp of a plaintext decrypted from ciphertext: the SHA-256 of
+// each, one per line, so that check can tell a plaintext is up to date without decrypting
+func stampOf(ciphertext, plaintext []byte) []byte {
+	return fmt.Appendf(nil, "%x\n%x\n", sha256.Sum256(ciphertext), sha256.Sum256(plaintext))
+}
+
+// decryptedFrom reports whether the plaintext of a mapping is what its stamp says was
+// decrypted from ciphertext, so it neither changed nor needs to be decrypted again
+func decryptedFrom(f fsys.FS, m mapping, ciphertext []byte) bool {
+	plaintext, err := f.ReadFile(m.sourcePath)
+	if err != nil {
+		return false
+	}
+	stamp, err := f.ReadFile(secretStamp(m.sourcePath))
+	return err == nil && bytes.Equal(stamp, stampOf(ciphertext, plaintext))
+}
+
+// decryptMapping decrypts the encrypted source of a mapping to its source, readable only
+// by the user, and stamps it; a plaintext whose stamp matches the ciphertext is left
+// alone without decrypting
+// It reports whether the source is ready to be linked
+func decryptMapping(cache *dirCache, m mapping, dryRun bool, out *output) bool {
+	ciphertext, err := cache.fs.ReadFile(m.encrypted)
+	if err != nil {
+		out.errorf("Error decrypting %s: %v\n", m.encrypted, err)
+		return false
+	}
+	if decryptedFrom(cache.fs, m, ciphertext) {
+		return true
+	}
+	data, err := decrypt(m.encrypted, ciphertext)
+	if err != nil {
+		out.errorf("Error decrypting %s: %v\n", m.encrypted, err)
+		return false
+	}
+
+	if current, err := cache.fs.ReadFile(m.sourcePath); err != nil || !bytes.Equal(current, data) {
+		if _, err := cache.mkdirAll(filepath.Dir(m.sourcePath), 0700); err != nil {
+			out.errorf("Error creating directory for %s: %v\n", m.sourcePath, err)
+			return false
+		}
+		// Writing over the old plaintext would keep its permissions
+		cache.fs.Remove(m.sourcePath)
+		if err := cache.fs.WriteFile(m.sourcePath, data, 0600); err != nil {
+			out.errorf("Error writing %s: %v\n", m.sourcePath, err)
+			return false
+		}
+		cache.set(m.sourcePath, 0)
+		out.printfColor("green", "%s: %s -> %s\n", verb(dryRun, "Decrypted", "Would decrypt"), m.encrypted, m.sourcePath)
+	}
+	if err := cache.fs.WriteFile(secretStamp(m.sourcePath), stampOf(ciphertext, data), 0600); err != nil {
+		out.errorf("Error writing %s: %v\n", secretStamp(m.sourcePath), err)
+		return false
+	}
+	return true
+}
+
+// staleSecret returns a description of why the decrypted source of a mapping may differ
+// from its encrypted source now, or "" if it is up to date
+// It compares the stamp instead of decrypting, which could prompt for a passphrase
+func staleSecret(cache *dirCache, m mapping) string {
+	ciphertext, err := cache.fs.ReadFile(m.encrypted)
+	if err != nil {
+		return fmt.Sprintf("Error reading %s: %v", m.encrypted, err)
+	}
+	if !decryptedFrom(cache.fs, m, ciphertext) {
+		return fmt.Sprintf("Decrypted secret out of date: %s (run dot link to decrypt %s)", m.sourcePath, m.encrypted)
+	}
+	return ""
+}
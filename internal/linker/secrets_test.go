@@ -0,0 +1,154 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestSecrets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDecrypt := decrypt
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalDataHome := os.Getenv("XDG_DATA_HOME")
+	defer func() {
+		FS = originalFS
+		decrypt = originalDecrypt
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_DATA_HOME", originalDataHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	os.Setenv("XDG_DATA_HOME", "/data")
+	// The fake ciphertext is the plaintext behind a marker
+	fakeDecrypt := func(name string, ciphertext []byte) ([]byte, error) {
+		plaintext, ok := strings.CutPrefix(string(ciphertext), "encrypted:")
+		if !ok {
+			return nil, fmt.Errorf("no identity matched")
+		}
+		return []byte(plaintext), nil
+	}
+	decrypt = fakeDecrypt
+
+	const decrypted = "/data/dot/decrypted/ssh/config"
+
+	setup := func(mappings, ciphertext string) *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles/ssh", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte(mappings), 0644)
+		memory.WriteFile("/dotfiles/ssh/config.age", []byte(ciphertext), 0644)
+		return memory
+	}
+	link := func(t *testing.T) (string, error) {
+		var err error
+		output := captureOutput(t, func() {
+			err = Link([]string{"general"}, false)
+		})
+		return output, err
+	}
+
+	t.Run("Link decrypts the source and links the plaintext", func(t *testing.T) {
+		memory := setup("[general]\n\"ssh/config.age\" = \"~/.ssh/config\"\n", "encrypted:Host *\n")
+
+		output, err := link(t)
+		if err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+		if !strings.Contains(output, "Decrypted: /dotfiles/ssh/config.age -> "+decrypted) {
+			t.Errorf("Expected the source to be decrypted, got: %s", output)
+		}
+		if data, _ := memory.ReadFile(decrypted); string(data) != "Host *\n" {
+			t.Errorf("Expected the plaintext, got '%s'", data)
+		}
+		if info, err := memory.Stat(decrypted); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("Expected the plaintext to be readable only by the user, got %v (%v)", info, err)
+		}
+		if target, _ := memory.Readlink("/home/user/.ssh/config"); target != decrypted {
+			t.Errorf("Expected the link to point to the plaintext, got %s", target)
+		}
+
+		// Linking again neither decrypts the unchanged secret nor touches its plaintext
+		calls := 0
+		decrypt = func(name string, ciphertext []byte) ([]byte, error) {
+			calls++
+			return nil, fmt.Errorf("unexpected decryption")
+		}
+		defer func() { decrypt = fakeDecrypt }()
+		output, _ = link(t)
+		if strings.Contains(output, "Decrypted") || calls != 0 {
+			t.Errorf("Expected no decryption of an unchanged secret, got %d: %s", calls, output)
+		}
+	})
+
+	t.Run("Check reports a plaintext that is out of date without decrypting", func(t *testing.T) {
+		memory := setup("[general]\n\"ssh/config.age\" = \"~/.ssh/config\"\n", "encrypted:Host *\n")
+		if _, err := link(t); err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+		decrypt = func(name string, ciphertext []byte) ([]byte, error) {
+			t.Error("Expected check not to decrypt")
+			return nil, fmt.Errorf("unexpected decryption")
+		}
+		defer func() { decrypt = fakeDecrypt }()
+
+		check := func() (string, error) {
+			var err error
+			output := captureOutput(t, func() {
+				err = Check([]string{"general"})
+			})
+			return output, err
+		}
+		if output, err := check(); err != nil {
+			t.Errorf("Expected the plaintext to be up to date, got %v: %s", err, output)
+		}
+
+		memory.WriteFile("/dotfiles/ssh/config.age", []byte("encrypted:Host github.com\n"), 0644)
+		if output, err := check(); err == nil || !strings.Contains(output, "Decrypted secret out of date: "+decrypted) {
+			t.Errorf("Expected the stale plaintext to be reported, got %v: %s", err, output)
+		}
+
+		memory.WriteFile("/dotfiles/ssh/config.age", []byte("encrypted:Host *\n"), 0644)
+		memory.WriteFile(decrypted, []byte("Host evil\n"), 0600)
+		if output, err := check(); err == nil || !strings.Contains(output, "Decrypted secret out of date: "+decrypted) {
+			t.Errorf("Expected the edited plaintext to be reported, got %v: %s", err, output)
+		}
+	})
+
+	t.Run("A secret this machine cannot decrypt fails the mapping", func(t *testing.T) {
+		memory := setup("[general]\n\"ssh/config.age\" = \"~/.ssh/config\"\n", "garbage")
+
+		output, err := link(t)
+		if err == nil {
+			t.Error("Expected the run to fail")
+		}
+		if !strings.Contains(output, "Error decrypting /dotfiles/ssh/config.age: no identity matched") {
+			t.Errorf("Expected the decryption error, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.ssh/config"); !os.IsNotExist(err) {
+			t.Errorf("Expected no link, got %v", err)
+		}
+	})
+
+	t.Run("Recursive entries drop the extension from the target", func(t *testing.T) {
+		memory := setup("[general]\n\"ssh/**\" = \"~/.ssh\"\n", "encrypted:Host *\n")
+
+		if _, err := link(t); err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+		if target, _ := memory.Readlink("/home/user/.ssh/config"); target != decrypted {
+			t.Errorf("Expected ~/.ssh/config to link the plaintext, got %q", target)
+		}
+	})
+}
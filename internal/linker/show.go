@@ -0,0 +1,233 @@
+package linker
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// ShowOptions configures how Show prints a source
+type ShowOptions struct {
+	// LineNumbers prefixes every line with its number
+	LineNumbers bool
+	// Highlight colors comments, section headers, and keys
+	Highlight bool
+	// Rendered prints what link would write for a template or an encrypted source, rendered
+	// or decrypted now, instead of the output of the last link
+	Rendered bool
+	// Mask hides the values of a decrypted secret, keeping its keys and structure
+	Mask bool
+}
+
+// Show prints the content of the source that a target of the given profiles is linked
+// to, so it can be inspected without following the link
+// name is a target such as ~/.zshrc, a path beneath a linked directory, or a source as
+// .mappings declares it; the source is resolved through alternates like link does
+func Show(profiles []string, name string, opts ShowOptions) error {
+	m, cfg, dotfilesDir, err := lookupMapping(profiles, name)
+	if err != nil {
+		return err
+	}
+	if opts.Rendered && m.template != "" {
+		data, err := renderTemplate(FS, dotfilesDir, m, cfg.Vars)
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", m.template, err)
+		}
+		fmt.Fprintf(os.Stderr, "%s (rendered)\n", m.template)
+		return printSource(os.Stdout, data, opts)
+	}
+	if opts.Rendered && m.encrypted != "" {
+		data, err := decryptSource(FS, m)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", m.encrypted, err)
+		}
+		if opts.Mask {
+			data = maskSecret(data)
+		}
+		fmt.Fprintf(os.Stderr, "%s (decrypted)\n", m.encrypted)
+		return printSource(os.Stdout, data, opts)
+	}
+
+	source := m.sourcePath
+	info, err := FS.Stat(source)
+	if err != nil {
+		return fmt.Errorf("source of %s is missing: %s", name, source)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("source of %s is a directory: %s", name, source)
+	}
+	data, err := FS.ReadFile(source)
+	if err != nil {
+		return err
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return fmt.Errorf("source of %s is a binary file: %s", name, source)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s\n", source)
+	return printSource(os.Stdout, data, opts)
+}
+
+// Which prints the path of the source that a target of the given profiles is linked to,
+// taking name like Show does
+// With dir set, it prints the directory holding the source instead, or the source itself
+// when it is a directory, for shell functions that cd there
+func Which(profiles []string, name string, dir bool) error {
+	source, err := lookupSource(profiles, name)
+	if err != nil {
+		return err
+	}
+	if dir {
+		if info, err := FS.Stat(source); err != nil || !info.IsDir() {
+			source = filepath.Dir(source)
+		}
+	}
+	fmt.Println(source)
+	return nil
+}
+
+// lookupSource returns the source path behind name in the given profiles
+func lookupSource(profiles []string, name string) (string, error) {
+	m, _, _, err := lookupMapping(profiles, name)
+	return m.sourcePath, err
+}
+
+// lookupMapping returns the mapping behind name in the given profiles, see showMapping,
+// along with the configuration and dotfiles directory it was resolved with
+func lookupMapping(profiles []string, name string) (mapping, *config.Config, string, error) {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return mapping{}, nil, "", err
+	}
+
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return mapping{}, nil, "", err
+	}
+
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return mapping{}, nil, "", err
+	}
+	m, err := showMapping(cfg, resolveMappings(newDirCache(FS), dotfilesDir, selected), name)
+	return m, cfg, dotfilesDir, err
+}
+
+// showMapping returns the mapping behind name: the mapping whose target or declared
+// source is name, or, for a file beneath a linked directory that name lies in, one with
+// just the path of that file as its source
+func showMapping(cfg *config.Config, mappings []mapping, name string) (mapping, error) {
+	target := targetPathOf(name)
+
+	var beneath mapping
+	found := false
+	for _, m := range mappings {
+		declared, _ := cfg.MappingsSource(m.profile, m.source)
+		if m.targetPath == target || m.source == name || declared == name {
+			return m, nil
+		}
+		// The deepest linked directory holds the file
+		if strings.HasPrefix(target, m.targetPath+string(filepath.Separator)) && (!found || len(m.targetPath) > len(beneath.targetPath)) {
+			beneath, found = m, true
+		}
+	}
+	if found {
+		rel, _ := filepath.Rel(beneath.targetPath, target)
+		return mapping{sourcePath: filepath.Join(beneath.sourcePath, rel)}, nil
+	}
+	return mapping{}, fmt.Errorf("%s is neither a target nor a source of the selected profiles", name)
+}
+
+// secretMask replaces what maskSecret hides
+const secretMask = "********"
+
+// maskSecret hides the values of a decrypted secret: the value of a key = value or
+// key: value line, and any other line that is not blank, a # // or ; comment, a section
+// header, or only brackets
+func maskSecret(data []byte) []byte {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.Trim(trimmed, "{}[](),") == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, ";") ||
+			strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			continue
+		}
+		if j := strings.IndexAny(line, "=:"); j > 0 && !strings.ContainsAny(strings.TrimPrefix(strings.TrimSpace(line[:j]), "export "), " \t") {
+			value := line[j+1:]
+			lines[i] = line[:j+1] + value[:len(value)-len(strings.TrimLeft(value, " \t"))] + secretMask
+			continue
+		}
+		lines[i] = line[:len(line)-len(strings.TrimLeft(line, " \t"))] + secretMask
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// targetPathOf returns name as an absolute target path, with ~ expanded
+func targetPathOf(name string) string {
+	target := filepath.Clean(utils.ExpandTarget(name))
+	if !filepath.IsAbs(target) {
+		if abs, err := filepath.Abs(target); err == nil {
+			target = abs
+		}
+	}
+	return target
+}
+
+// printSource writes data to w, numbering and highlighting its lines as opts asks
+func printSource(w io.Writer, data []byte, opts ShowOptions) error {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	width := len(fmt.Sprint(len(lines)))
+
+	out := bufio.NewWriter(w)
+	for i, line := range lines {
+		if opts.LineNumbers {
+			number := fmt.Sprintf("%*d ", width, i+1)
+			if opts.Highlight {
+				number = utils.SprintfColor("gray", "%s", number)
+			}
+			out.WriteString(number)
+		}
+		if opts.Highlight {
+			line = highlightLine(line)
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Flush()
+}
+
+// commentPrefixes start a comment line in the shell, vim, ini, and Lua style configs that
+// dotfiles are usually written in
+var commentPrefixes = []string{"#", "//", ";", "\"", "--"}
+
+// highlightLine colors a line of a config file: comments gray, section headers such as
+// [user] blue, and the key of key = value or key: value lines yellow
+func highlightLine(line string) string {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range commentPrefixes {
+		if strings.HasPrefix(trimmed, prefix) {
+			return utils.SprintfColor("gray", "%s", line)
+		}
+	}
+	if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+		return utils.SprintfColor("blue", "%s", line)
+	}
+
+	i := strings.IndexAny(line, "=:")
+	if i <= 0 {
+		return line
+	}
+	key := line[:i]
+	if strings.ContainsAny(strings.TrimSpace(key), " \t\"'") && !strings.HasPrefix(strings.TrimSpace(key), "export ") {
+		return line
+	}
+	return utils.SprintfColor("yellow", "%s", key) + line[i:]
+}
@@ -0,0 +1,203 @@
+package linker
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+func TestShow(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDecrypt := decrypt
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		decrypt = originalDecrypt
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	decrypt = func(name string, ciphertext []byte) ([]byte, error) {
+		return []byte(strings.TrimPrefix(string(ciphertext), "age:")), nil
+	}
+
+	memory := fsys.NewMemory()
+	FS = memory
+	memory.MkdirAll("/dotfiles/nvim/lua", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[vars]\nemail = \"me@example.com\"\n[general]\n\"gitconfig\" = \"~/.gitconfig\"\n\"hgrc.tmpl\" = \"~/.hgrc\"\n\"env.age\" = \"~/.env\"\n[work]\n\"nvim\" = \"~/.config/nvim\"\n\"logo\" = \"~/.logo\"\n"), 0644)
+	memory.WriteFile("/dotfiles/hgrc.tmpl", []byte("[ui]\nusername = {{ .Vars.email }}\n"), 0644)
+	memory.WriteFile("/dotfiles/env.age", []byte("age:# tokens\nexport GITHUB_TOKEN=ghp_secret\n"), 0644)
+	memory.WriteFile("/dotfiles/gitconfig", []byte("[user]\n\tname = me\n"), 0644)
+	memory.WriteFile("/dotfiles/nvim/lua/init.lua", []byte("vim.o.number = true\n"), 0644)
+	memory.WriteFile("/dotfiles/logo", []byte("PNG\x00\x01"), 0644)
+
+	show := func(name string, opts ShowOptions) (string, error) {
+		var err error
+		output := captureOutput(t, func() {
+			err = Show([]string{config.AllProfiles}, name, opts)
+		})
+		return output, err
+	}
+
+	t.Run("Target", func(t *testing.T) {
+		output, err := show("~/.gitconfig", ShowOptions{LineNumbers: true})
+		if err != nil {
+			t.Fatalf("Show failed: %v", err)
+		}
+		if !strings.Contains(output, "1 [user]\n2 \tname = me\n") {
+			t.Errorf("Expected numbered content, got: %s", output)
+		}
+	})
+
+	t.Run("Source", func(t *testing.T) {
+		output, err := show("gitconfig", ShowOptions{})
+		if err != nil {
+			t.Fatalf("Show failed: %v", err)
+		}
+		if !strings.Contains(output, "[user]\n\tname = me\n") {
+			t.Errorf("Expected the content, got: %s", output)
+		}
+	})
+
+	t.Run("File beneath a linked directory", func(t *testing.T) {
+		output, err := show("/home/user/.config/nvim/lua/init.lua", ShowOptions{})
+		if err != nil {
+			t.Fatalf("Show failed: %v", err)
+		}
+		if !strings.Contains(output, "vim.o.number = true") {
+			t.Errorf("Expected the content, got: %s", output)
+		}
+	})
+
+	t.Run("Rendered template and secret", func(t *testing.T) {
+		output, err := show("~/.hgrc", ShowOptions{Rendered: true})
+		if err != nil {
+			t.Fatalf("Show failed: %v", err)
+		}
+		if !strings.Contains(output, "/dotfiles/hgrc.tmpl (rendered)") || !strings.Contains(output, "username = me@example.com\n") {
+			t.Errorf("Expected the rendered template, got: %s", output)
+		}
+
+		output, err = show("~/.env", ShowOptions{Rendered: true})
+		if err != nil {
+			t.Fatalf("Show failed: %v", err)
+		}
+		if !strings.Contains(output, "export GITHUB_TOKEN=ghp_secret\n") {
+			t.Errorf("Expected the decrypted secret, got: %s", output)
+		}
+
+		output, err = show("~/.env", ShowOptions{Rendered: true, Mask: true})
+		if err != nil {
+			t.Fatalf("Show failed: %v", err)
+		}
+		if !strings.Contains(output, "# tokens\nexport GITHUB_TOKEN=********\n") || strings.Contains(output, "ghp_secret") {
+			t.Errorf("Expected the masked secret, got: %s", output)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := map[string]string{
+			"~/.zshrc":       "neither a target nor a source",
+			"~/.config/nvim": "is a directory",
+			"~/.logo":        "is a binary file",
+		}
+		for name, expected := range tests {
+			if _, err := show(name, ShowOptions{}); err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected %s to fail with %q, got %v", name, expected, err)
+			}
+		}
+	})
+}
+
+func TestHighlightLine(t *testing.T) {
+	tests := map[string]string{
+		"# comment":         utils.SprintfColor("gray", "%s", "# comment"),
+		"[user]":            utils.SprintfColor("blue", "%s", "[user]"),
+		"\tname = me":       utils.SprintfColor("yellow", "%s", "\tname ") + "= me",
+		"export EDITOR=vim": utils.SprintfColor("yellow", "%s", "export EDITOR") + "=vim",
+		"echo \"a=b\"":      "echo \"a=b\"",
+	}
+	for line, expected := range tests {
+		if result := highlightLine(line); result != expected {
+			t.Errorf("Expected %q to highlight as %q, got %q", line, expected, result)
+		}
+	}
+
+	var buf bytes.Buffer
+	printSource(&buf, []byte(strings.Repeat("x\n", 10)), ShowOptions{LineNumbers: true})
+	if !strings.HasPrefix(buf.String(), " 1 x\n") || !strings.Contains(buf.String(), "10 x\n") {
+		t.Errorf("Expected aligned line numbers, got %q", buf.String())
+	}
+}
+
+func TestMaskSecret(t *testing.T) {
+	tests := map[string]string{
+		"# comment":               "# comment",
+		"[default]":               "[default]",
+		"\tpassword = hunter2":    "\tpassword = ********",
+		"\"token\": \"abc\",":     "\"token\": ********",
+		"ssh-ed25519 AAAA= me@pc": "********",
+		"}":                       "}",
+	}
+	for line, expected := range tests {
+		if result := string(maskSecret([]byte(line))); result != expected {
+			t.Errorf("Expected %q to mask as %q, got %q", line, expected, result)
+		}
+	}
+}
+
+func TestWhich(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	memory := fsys.NewMemory()
+	FS = memory
+	memory.MkdirAll("/dotfiles/zsh", 0755)
+	memory.MkdirAll("/dotfiles/nvim", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zsh/zshrc\" = \"~/.zshrc\"\n\"nvim\" = \"~/.config/nvim\"\n"), 0644)
+	memory.WriteFile("/dotfiles/zsh/zshrc", []byte("zsh"), 0644)
+
+	tests := []struct {
+		name     string
+		dir      bool
+		expected string
+	}{
+		{"~/.zshrc", false, "/dotfiles/zsh/zshrc\n"},
+		{"~/.zshrc", true, "/dotfiles/zsh\n"},
+		{"~/.config/nvim", true, "/dotfiles/nvim\n"},
+	}
+	for _, test := range tests {
+		output := captureOutput(t, func() {
+			if err := Which([]string{config.AllProfiles}, test.name, test.dir); err != nil {
+				t.Fatalf("Which failed: %v", err)
+			}
+		})
+		if output != test.expected {
+			t.Errorf("Expected %s (dir %v) to print %q, got %q", test.name, test.dir, test.expected, output)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package linker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/table"
+	"github.com/yourusername/dot/internal/term"
+)
+
+// ProfileStatus counts the mappings of a profile by the state of their links
+type ProfileStatus struct {
+	Profile string `json:"profile"`
+	// Linked are symlinks to their source, or copies matching it
+	Linked int `json:"linked"`
+	// Missing have nothing at their target
+	Missing int `json:"missing"`
+	// Broken are links to another path or to a missing source, and copies out of date
+	Broken int `json:"broken"`
+	// Conflicting have a file of their own at their target, including edited copies
+	Conflicting int `json:"conflicting"`
+	// Disabled are left alone on this machine by dot disable
+	Disabled int `json:"disabled"`
+}
+
+// Healthy reports whether every enabled mapping of the profile is linked
+func (s ProfileStatus) Healthy() bool {
+	return s.Missing == 0 && s.Broken == 0 && s.Conflicting == 0
+}
+
+// count adds a mapping in the given state to the status
+func (s *ProfileStatus) count(state linkState) {
+	switch state {
+	case stateLinked, stateCopied:
+		s.Linked++
+	case stateNotLinked:
+		s.Missing++
+	case stateNotSymlink, stateCopyEdited:
+		s.Conflicting++
+	default:
+		s.Broken++
+	}
+}
+
+// ProfileStatuses returns the link health of each of the profiles, in the order they
+// were given with patterns and "all" expanded
+func ProfileStatuses(profiles []string) ([]ProfileStatus, error) {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := cfg.ExpandProfiles(profiles)
+	if err != nil {
+		return nil, err
+	}
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return nil, err
+	}
+	selected, disabled, err := withoutDisabled(cfg, selected)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := newDirCache(FS)
+	mappings := resolveMappings(cache, dotfilesDir, selected)
+	if err := markCopies(cfg, mappings); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]ProfileStatus, len(names))
+	for i, name := range names {
+		statuses[i].Profile = name
+	}
+	// of returns the status a mapping of the profile counts towards
+	of := func(profile string) *ProfileStatus {
+		if i := slices.Index(names, profile); i >= 0 {
+			return &statuses[i]
+		}
+		statuses = append(statuses, ProfileStatus{Profile: profile})
+		names = append(names, profile)
+		return &statuses[len(statuses)-1]
+	}
+	for _, m := range mappings {
+		state, _ := listState(cache, m)
+		of(m.profile).count(state)
+	}
+	for _, m := range disabled {
+		of(m.Profile).Disabled++
+	}
+	return statuses, nil
+}
+
+// StatusOptions controls how Status prints its summary
+type StatusOptions struct {
+	// JSON prints the repository and profile states as a JSON object instead
+	JSON bool
+}
+
+// statusJSON is what Status prints with StatusOptions.JSON
+type statusJSON struct {
+	Repository *dotfiles.RepoStatus `json:"repository"`
+	Profiles   []ProfileStatus      `json:"profiles"`
+}
+
+// Status prints the git state of the dotfiles repository and how many links of each
+// profile are linked, missing, broken, or conflicting
+// A repository git cannot read is reported as a warning, so the links are still shown
+func Status(profiles []string, opts StatusOptions) error {
+	statuses, err := ProfileStatuses(profiles)
+	if err != nil {
+		return err
+	}
+	repo, err := dotfiles.Status()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if opts.JSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(statusJSON{Repository: repo, Profiles: statuses})
+	}
+
+	if repo != nil {
+		printRepoStatus(repo)
+		fmt.Println()
+	}
+
+	summary := table.New("STATUS", "PROFILE", "LINKED", "MISSING", "BROKEN", "CONFLICTING", "DISABLED")
+	for _, s := range statuses {
+		symbol := term.OK
+		if !s.Healthy() {
+			symbol = term.Error
+		}
+		summary.Append(symbol.String(), s.Profile, strconv.Itoa(s.Linked), strconv.Itoa(s.Missing),
+			strconv.Itoa(s.Broken), strconv.Itoa(s.Conflicting), strconv.Itoa(s.Disabled))
+	}
+	return summary.Render(os.Stdout, term.Width())
+}
+
+// printRepoStatus prints the branch of the repository and its uncommitted changes
+func printRepoStatus(repo *dotfiles.RepoStatus) {
+	branch := repo.Branch
+	if branch == "" {
+		branch = "HEAD detached"
+	}
+	var tracking []string
+	if repo.Upstream == "" {
+		tracking = append(tracking, "no upstream")
+	} else {
+		tracking = append(tracking, repo.Upstream)
+		if repo.Ahead > 0 {
+			tracking = append(tracking, fmt.Sprintf("ahead %d", repo.Ahead))
+		}
+		if repo.Behind > 0 {
+			tracking = append(tracking, fmt.Sprintf("behind %d", repo.Behind))
+		}
+		if repo.Ahead == 0 && repo.Behind == 0 {
+			tracking = append(tracking, "up to date")
+		}
+	}
+	fmt.Printf("Repository: %s\n", repo.Dir)
+	fmt.Printf("Branch: %s (%s)\n", branch, strings.Join(tracking, ", "))
+
+	if !repo.Dirty() {
+		fmt.Println("Working tree clean")
+		return
+	}
+	fmt.Printf("Uncommitted changes: %d file(s)\n", len(repo.Changes))
+	for _, change := range repo.Changes {
+		fmt.Printf("  %s %s\n", change.Status, change.Path)
+	}
+}
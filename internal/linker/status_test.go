@@ -0,0 +1,68 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestProfileStatuses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	memory := fsys.NewMemory()
+	FS = memory
+	memory.MkdirAll("/dotfiles", 0755)
+	memory.MkdirAll("/home", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte(`[general]
+"vimrc" = "/home/.vimrc"
+"zshrc" = "/home/.zshrc"
+"bashrc" = "/home/.bashrc"
+"gitconfig" = "/home/.gitconfig"
+
+[work]
+"ssh" = "/home/.ssh_config"
+`), 0644)
+	for _, name := range []string{"vimrc", "zshrc", "bashrc", "gitconfig", "ssh"} {
+		memory.WriteFile("/dotfiles/"+name, []byte(name), 0644)
+	}
+	memory.Symlink("/dotfiles/vimrc", "/home/.vimrc")
+	memory.Symlink("/dotfiles/vimrc", "/home/.zshrc")
+	memory.WriteFile("/home/.bashrc", []byte("local"), 0644)
+
+	statuses, err := ProfileStatuses([]string{"general", "work"})
+	if err != nil {
+		t.Fatalf("ProfileStatuses failed: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 profiles, got %+v", statuses)
+	}
+
+	general := statuses[0]
+	expected := ProfileStatus{Profile: "general", Linked: 1, Missing: 1, Broken: 1, Conflicting: 1}
+	if general != expected {
+		t.Errorf("Expected %+v, got %+v", expected, general)
+	}
+	if general.Healthy() {
+		t.Error("Expected general to be unhealthy")
+	}
+
+	work := statuses[1]
+	if work.Profile != "work" || work.Missing != 1 {
+		t.Errorf("Expected the work mapping to be missing, got %+v", work)
+	}
+}
@@ -0,0 +1,231 @@
+package linker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// AdoptOptions controls how AdoptChanges copies edits back into the repository
+type AdoptOptions struct {
+	// DryRun reports which copies would be adopted without touching the filesystem
+	DryRun bool
+	// Commit commits the adopted sources to the dotfiles repository
+	Commit bool
+}
+
+// AdoptChanges copies the edits made to copied targets back into their sources, so that
+// copy mode is not a one-way street; copies whose source changed as well are skipped,
+// since adopting them would discard the upstream change
+func AdoptChanges(profiles []string, opts AdoptOptions) error {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return err
+	}
+
+	target := FS
+	if opts.DryRun {
+		target = fsys.NewOverlay(FS)
+	}
+
+	cache := newDirCache(target)
+	mappings := resolveMappings(cache, dotfilesDir, selected)
+	if err := markCopies(cfg, mappings); err != nil {
+		return err
+	}
+
+	actions, failed := forEachMapping(mappings, nil, nil, nil, func(m mapping, out *output) {
+		if m.copied != nil {
+			adoptCopy(cache, m, opts.DryRun, out)
+		}
+	})
+	if opts.DryRun {
+		return failed
+	}
+	journal.Record("sync", profiles, actions)
+	manifest.Record(FS, actions)
+
+	var sources []string
+	for _, action := range actions {
+		sources = append(sources, action.Target)
+	}
+	if len(sources) == 0 {
+		if failed == nil {
+			fmt.Fprintln(os.Stderr, "No edited copies to adopt")
+		}
+		return failed
+	}
+	if opts.Commit {
+		return errors.Join(failed, dotfiles.Commit(sources, fmt.Sprintf("Adopt local edits to %d copied file(s)", len(sources))))
+	}
+	return failed
+}
+
+// adoptCopy replaces a mapping's source with its copied target if only the copy was edited
+func adoptCopy(cache *dirCache, m mapping, dryRun bool, out *output) {
+	if _, err := cache.lstat(m.targetPath); err != nil {
+		return
+	}
+
+	state, err := compareCopy(cache, m)
+	if err != nil {
+		out.errorf("Error comparing %s with %s: %v\n", m.targetPath, m.sourcePath, err)
+		return
+	}
+	switch state {
+	case copyDiverged:
+		out.printfColor("yellow", "Skipped (source changed too, merge the edits by hand): %s\n", m.targetPath)
+		return
+	case copyEdited:
+	default:
+		return
+	}
+	if m.team {
+		out.printfColor("yellow", "Skipped (source is in the read-only team repository): %s\n", m.targetPath)
+		return
+	}
+	if m.template != "" {
+		out.printfColor("yellow", "Skipped (source is rendered from %s, edit the template instead): %s\n", m.template, m.targetPath)
+		return
+	}
+	if m.encrypted != "" {
+		out.printfColor("yellow", "Skipped (source is decrypted from %s, encrypt the file with dot encrypt instead): %s\n", m.encrypted, m.targetPath)
+		return
+	}
+
+	if err := cache.fs.RemoveAll(m.sourcePath); err != nil {
+		out.errorf("Error replacing %s: %v\n", m.sourcePath, err)
+		return
+	}
+	err = utils.CopyTreeFS(cache.fs, m.targetPath, m.sourcePath)
+	// Line endings the entry gave the copy do not end up in the repository
+	if err == nil && m.eol != "" {
+		err = utils.ConvertTreeEOLFS(cache.fs, m.sourcePath, "lf")
+	}
+	if err != nil {
+		out.errorf("Error copying %s to %s: %v\n", m.targetPath, m.sourcePath, err)
+		return
+	}
+	cache.forget(m.sourcePath)
+	out.record(journal.OpAdoptCopy, m.targetPath, m.sourcePath)
+	out.printfColor("green", "%s: %s -> %s\n", verb(dryRun, "Adopted edits", "Would adopt edits"), m.targetPath, m.sourcePath)
+}
+
+// pull updates the dotfiles repository for Sync, and push sends it to every remote;
+// tests replace them
+var (
+	pull = dotfiles.Pull
+	push = dotfiles.Push
+)
+
+// SyncOptions controls a Sync run
+type SyncOptions struct {
+	LinkOptions
+	// Push pushes the repository to every remote once the profiles are linked without
+	// failures
+	Push bool
+}
+
+// SyncSummary is what a Sync changed on the machine
+type SyncSummary struct {
+	// Pulled are the files the pull brought into the repository
+	Pulled []dotfiles.Change
+	// Linked are targets that were linked or copied where nothing was before
+	Linked []string
+	// Replaced are targets whose link, copy, or file was replaced, with any file backed up
+	Replaced []string
+}
+
+// Sync pulls the dotfiles repository, links the profiles with the mappings it pulled,
+// and prints a summary of the files the pull brought in and the targets linked, then
+// pushes the repository if asked to
+// A dry run does not pull or push and summarizes what linking would change
+func Sync(profiles []string, opts SyncOptions) error {
+	var pulled []dotfiles.Change
+	if !opts.DryRun {
+		var err error
+		if pulled, err = pull(); err != nil {
+			return err
+		}
+	}
+
+	rep := newLinkReport(true, profiles, opts.LinkOptions)
+	err := runLink(rep, profiles, opts.LinkOptions)
+	// A run that failed before linking anything, e.g. on a broken .mappings, has nothing to summarize
+	if err != nil && len(rep.Mappings) == 0 {
+		return err
+	}
+	printSyncSummary(summarizeSync(pulled, rep), opts.DryRun)
+	if err != nil || !opts.Push || opts.DryRun {
+		return err
+	}
+	return push()
+}
+
+// summarizeSync sorts the targets of a link run into those linked afresh and those that
+// replaced what was at the target
+func summarizeSync(pulled []dotfiles.Change, rep *Report) SyncSummary {
+	summary := SyncSummary{Pulled: pulled}
+	for _, m := range rep.Mappings {
+		created, replaced := false, false
+		for _, action := range m.Actions {
+			switch action.Op {
+			case journal.OpCreateLink, journal.OpCopy:
+				created = true
+			case journal.OpRemoveLink, journal.OpRemoveCopy, journal.OpBackup:
+				replaced = true
+			}
+		}
+		switch {
+		case created && replaced:
+			summary.Replaced = append(summary.Replaced, m.Target)
+		case created:
+			summary.Linked = append(summary.Linked, m.Target)
+		}
+	}
+	return summary
+}
+
+// printSyncSummary prints what a Sync changed, or that it changed nothing
+func printSyncSummary(summary SyncSummary, dryRun bool) {
+	if len(summary.Pulled) == 0 && len(summary.Linked) == 0 && len(summary.Replaced) == 0 {
+		fmt.Println("Already up to date")
+		return
+	}
+
+	if len(summary.Pulled) > 0 {
+		fmt.Printf("Pulled %d file(s):\n", len(summary.Pulled))
+		for _, change := range summary.Pulled {
+			fmt.Printf("  %s %s\n", change.Status, change.Path)
+		}
+	}
+	if len(summary.Linked) > 0 {
+		fmt.Printf("%s %d new target(s):\n", verb(dryRun, "Linked", "Would link"), len(summary.Linked))
+		for _, target := range summary.Linked {
+			fmt.Printf("  %s\n", target)
+		}
+	}
+	if len(summary.Replaced) > 0 {
+		fmt.Printf("%s %d target(s):\n", verb(dryRun, "Replaced", "Would replace"), len(summary.Replaced))
+		for _, target := range summary.Replaced {
+			fmt.Printf("  %s\n", target)
+		}
+	}
+}
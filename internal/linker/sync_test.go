@@ -0,0 +1,169 @@
+package linker
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestSync(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalPull := pull
+	originalPush := push
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		pull = originalPull
+		push = originalPush
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	setup := func() *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+		memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+		memory.WriteFile("/dotfiles/vimrc", []byte("vim"), 0644)
+		return memory
+	}
+
+	t.Run("Pulls, links, and summarizes", func(t *testing.T) {
+		memory := setup()
+		memory.WriteFile("/home/user/.vimrc", []byte("local"), 0644)
+		pulled := false
+		pull = func() ([]dotfiles.Change, error) {
+			pulled = true
+			return []dotfiles.Change{{Status: "A", Path: "zshrc"}}, nil
+		}
+
+		output := captureOutput(t, func() {
+			if err := Sync([]string{"general"}, SyncOptions{}); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+		})
+		if !pulled {
+			t.Error("Expected the repository to be pulled")
+		}
+		for _, expected := range []string{
+			"Pulled 1 file(s):\n  A zshrc\n",
+			"Linked 1 new target(s):\n  /home/user/.zshrc\n",
+			"Replaced 1 target(s):\n  /home/user/.vimrc\n",
+		} {
+			if !strings.Contains(output, expected) {
+				t.Errorf("Expected '%s' in the summary, got: %s", expected, output)
+			}
+		}
+		if link, _ := memory.Readlink("/home/user/.zshrc"); link != "/dotfiles/zshrc" {
+			t.Errorf("Expected .zshrc to be linked, got '%s'", link)
+		}
+	})
+
+	t.Run("Nothing to do is up to date", func(t *testing.T) {
+		pull = func() ([]dotfiles.Change, error) { return []dotfiles.Change{}, nil }
+
+		output := captureOutput(t, func() {
+			if err := Sync([]string{"general"}, SyncOptions{}); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Already up to date") {
+			t.Errorf("Expected up to date, got: %s", output)
+		}
+	})
+
+	t.Run("Dry runs do not pull", func(t *testing.T) {
+		memory := setup()
+		pull = func() ([]dotfiles.Change, error) {
+			t.Error("Expected no pull in a dry run")
+			return nil, nil
+		}
+
+		output := captureOutput(t, func() {
+			if err := Sync([]string{"general"}, SyncOptions{LinkOptions: LinkOptions{DryRun: true}}); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Would link 2 new target(s):") {
+			t.Errorf("Expected a dry run summary, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc"); !os.IsNotExist(err) {
+			t.Errorf("Expected nothing to be linked, got %v", err)
+		}
+	})
+
+	t.Run("A failed pull links nothing", func(t *testing.T) {
+		memory := setup()
+		pull = func() ([]dotfiles.Change, error) { return nil, errors.New("merge conflict") }
+
+		var err error
+		captureOutput(t, func() {
+			err = Sync([]string{"general"}, SyncOptions{})
+		})
+		if err == nil || err.Error() != "merge conflict" {
+			t.Errorf("Expected the pull error, got %v", err)
+		}
+		if _, err := memory.Lstat("/home/user/.zshrc"); !os.IsNotExist(err) {
+			t.Errorf("Expected nothing to be linked, got %v", err)
+		}
+	})
+	t.Run("A run that fails before linking prints no summary", func(t *testing.T) {
+		memory := setup()
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general\n"), 0644)
+		pull = func() ([]dotfiles.Change, error) { return nil, nil }
+
+		var err error
+		output := captureOutput(t, func() {
+			err = Sync([]string{"general"}, SyncOptions{})
+		})
+		if err == nil {
+			t.Error("Expected the broken .mappings to fail the sync")
+		}
+		if strings.Contains(output, "Already up to date") {
+			t.Errorf("Expected no summary, got: %s", output)
+		}
+	})
+
+	t.Run("Pushes once linked without failures", func(t *testing.T) {
+		memory := setup()
+		pull = func() ([]dotfiles.Change, error) { return nil, nil }
+		pushed := 0
+		push = func() error {
+			pushed++
+			return nil
+		}
+
+		captureOutput(t, func() {
+			if err := Sync([]string{"general"}, SyncOptions{Push: true}); err != nil {
+				t.Fatalf("Sync failed: %v", err)
+			}
+		})
+		if pushed != 1 {
+			t.Errorf("Expected 1 push, got %d", pushed)
+		}
+
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general\n"), 0644)
+		captureOutput(t, func() {
+			if err := Sync([]string{"general"}, SyncOptions{Push: true}); err == nil {
+				t.Error("Expected the broken .mappings to fail the sync")
+			}
+		})
+		if pushed != 1 {
+			t.Errorf("Expected no push after a failed link, got %d", pushed)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package linker
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/utils"
+	"github.com/yourusername/dot/internal/xdg"
+)
+
+// templateExt marks a source as a Go template, which link renders into the rendered
+// directory and links the output of instead of the template itself
+const templateExt = ".tmpl"
+
+// renderedDir returns where templates are rendered to, $XDG_DATA_HOME/dot/rendered
+func renderedDir() (string, error) {
+	return xdg.Data.Path("rendered")
+}
+
+// renderedPath returns where the template source, relative to the dotfiles directory, is
+// rendered to beneath dir: the same path without the .tmpl extension
+// Sources leading out of the repository, like those of a team repository checked out
+// elsewhere, keep their place beneath dir with .. replaced
+func renderedPath(dir, source string) string {
+	return outputPath(dir, strings.TrimSuffix(path.Clean(source), templateExt))
+}
+
+// outputPath returns where the output made from a source is kept beneath dir, given the
+// source's path relative to the dotfiles directory with its extension dropped
+func outputPath(dir, rel string) string {
+	for strings.HasPrefix(rel, "../") {
+		rel = "_" + strings.TrimPrefix(rel, "..")
+	}
+	return filepath.Join(dir, filepath.FromSlash(rel))
+}
+
+// templateData is what a template sees, e.g. {{ .Hostname }} or {{ .Vars.email }}
+type templateData struct {
+	Hostname string
+	OS       string // runtime.GOOS, e.g. darwin or linux
+	Arch     string // runtime.GOARCH, e.g. arm64 or amd64
+	User     string
+	Home     string
+	Profile  string // the profile the mapping was selected from
+	Vars     map[string]interface{}
+}
+
+// currentUser returns the name of the user running dot
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// renderTemplate renders the template of a mapping with the variables of this machine
+// and vars from .mappings; a variable the template uses but vars lacks is an error
+func renderTemplate(f fsys.FS, dotfilesDir string, m mapping, vars map[string]interface{}) ([]byte, error) {
+	tmpl, _, err := parseTemplate(f, dotfilesDir, m)
+	if err != nil {
+		return nil, err
+	}
+
+	home, err := utils.TargetHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, templateData{
+		Hostname: hostname(),
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		User:     currentUser(),
+		Home:     home,
+		Profile:  m.profile,
+		Vars:     vars,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parseTemplate parses the template of a mapping with its delimiters, along with the
+// files it includes by their path relative to dotfilesDir, e.g.
+// {{ template "shared/proxy.conf" . }}, each parsed when first included
+// It returns the paths of the included files as well
+func parseTemplate(f fsys.FS, dotfilesDir string, m mapping) (*template.Template, []string, error) {
+	text, err := f.ReadFile(m.template)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := template.New(filepath.Base(m.template)).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"env": os.Getenv})
+	if len(m.delims) == 2 {
+		tmpl.Delims(m.delims[0], m.delims[1])
+	}
+	if _, err := tmpl.Parse(string(text)); err != nil {
+		return nil, nil, err
+	}
+
+	var included []string
+	for name := missingTemplate(tmpl); name != ""; name = missingTemplate(tmpl) {
+		rel := filepath.FromSlash(name)
+		if !filepath.IsLocal(rel) {
+			return nil, nil, fmt.Errorf("cannot include %q: not a path inside the dotfiles directory", name)
+		}
+		path := filepath.Join(dotfilesDir, rel)
+		text, err := f.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot include %q: %w", name, err)
+		}
+		if _, err := tmpl.New(name).Parse(string(text)); err != nil {
+			return nil, nil, err
+		}
+		included = append(included, path)
+	}
+	return tmpl, included, nil
+}
+
+// missingTemplate returns the name of a template that one of the templates of tmpl
+// includes but none defines, or "" if they are all defined
+func missingTemplate(tmpl *template.Template) string {
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		for _, name := range includedNames(t.Tree.Root, nil) {
+			if tmpl.Lookup(name) == nil {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// includedNames appends the names of the templates that node and the nodes beneath it
+// include to names
+func includedNames(node parse.Node, names []string) []string {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return names
+		}
+		for _, child := range n.Nodes {
+			names = includedNames(child, names)
+		}
+	case *parse.IfNode:
+		names = includedNames(n.ElseList, includedNames(n.List, names))
+	case *parse.RangeNode:
+		names = includedNames(n.ElseList, includedNames(n.List, names))
+	case *parse.WithNode:
+		names = includedNames(n.ElseList, includedNames(n.List, names))
+	case *parse.TemplateNode:
+		names = append(names, n.Name)
+	}
+	return names
+}
+
+// renderMapping renders the template of a mapping to its source, leaving output that is
+// already up to date alone; the output gets the permissions of the template
+// It reports whether the source is ready to be linked
+func renderMapping(cache *dirCache, dotfilesDir string, m mapping, vars map[string]interface{}, dryRun bool, out *output) bool {
+	data, err := renderTemplate(cache.fs, dotfilesDir, m, vars)
+	if err != nil {
+		out.errorf("Error rendering %s: %v\n", m.template, err)
+		return false
+	}
+	if current, err := cache.fs.ReadFile(m.sourcePath); err == nil && bytes.Equal(current, data) {
+		return true
+	}
+
+	info, err := cache.fs.Stat(m.template)
+	if err != nil {
+		out.errorf("Error rendering %s: %v\n", m.template, err)
+		return false
+	}
+	if _, err := cache.mkdirAll(filepath.Dir(m.sourcePath), 0755); err != nil {
+		out.errorf("Error creating directory for %s: %v\n", m.sourcePath, err)
+		return false
+	}
+	if err := cache.fs.WriteFile(m.sourcePath, data, info.Mode().Perm()); err != nil {
+		out.errorf("Error writing %s: %v\n", m.sourcePath, err)
+		return false
+	}
+	cache.set(m.sourcePath, 0)
+	out.printfColor("green", "%s: %s -> %s\n", verb(dryRun, "Rendered", "Would render"), m.template, m.sourcePath)
+	return true
+}
+
+// staleTemplate returns a description of why the rendered source of a mapping differs
+// from what its template renders to now, or "" if it is up to date
+func staleTemplate(cache *dirCache, dotfilesDir string, m mapping, vars map[string]interface{}) string {
+	data, err := renderTemplate(cache.fs, dotfilesDir, m, vars)
+	if err != nil {
+		return fmt.Sprintf("Error rendering %s: %v", m.template, err)
+	}
+	if current, err := cache.fs.ReadFile(m.sourcePath); err != nil || !bytes.Equal(current, data) {
+		return fmt.Sprintf("Rendered template out of date: %s (run dot link to render %s)", m.sourcePath, m.template)
+	}
+	return ""
+}
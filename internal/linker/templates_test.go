@@ -0,0 +1,203 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+func TestTemplates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalHostname := hostname
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalDataHome := os.Getenv("XDG_DATA_HOME")
+	defer func() {
+		FS = originalFS
+		hostname = originalHostname
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_DATA_HOME", originalDataHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+	os.Setenv("XDG_DATA_HOME", "/data")
+	hostname = func() string { return "laptop" }
+
+	const rendered = "/data/dot/rendered/git/.gitconfig"
+
+	// setup maps a gitconfig template using a custom variable and the hostname
+	setup := func(template string) *fsys.Memory {
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles/git", 0755)
+		memory.MkdirAll("/home/user", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[vars]\nemail = \"me@example.com\"\n\n[general]\n\"git/.gitconfig.tmpl\" = \"~/.gitconfig\"\n"), 0644)
+		memory.WriteFile("/dotfiles/git/.gitconfig.tmpl", []byte(template), 0644)
+		return memory
+	}
+	link := func(t *testing.T) (string, error) {
+		var err error
+		output := captureOutput(t, func() {
+			err = Link([]string{"general"}, false)
+		})
+		return output, err
+	}
+
+	t.Run("Link renders the template and links the output", func(t *testing.T) {
+		memory := setup("email = {{ .Vars.email }}\nhost = {{ .Hostname }} ({{ .Profile }})\n")
+
+		output, err := link(t)
+		if err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+		if !strings.Contains(output, "Rendered: /dotfiles/git/.gitconfig.tmpl -> "+rendered) {
+			t.Errorf("Expected the template to be rendered, got: %s", output)
+		}
+		if data, _ := memory.ReadFile(rendered); string(data) != "email = me@example.com\nhost = laptop (general)\n" {
+			t.Errorf("Expected the rendered output, got '%s'", data)
+		}
+		if target, _ := memory.Readlink("/home/user/.gitconfig"); target != rendered {
+			t.Errorf("Expected the link to point to the rendered output, got %s", target)
+		}
+
+		// Rendering again leaves the unchanged output alone
+		output, _ = link(t)
+		if strings.Contains(output, "Rendered") {
+			t.Errorf("Expected no rendering of an unchanged template, got: %s", output)
+		}
+	})
+
+	t.Run("Templates include files of the repository by their path", func(t *testing.T) {
+		memory := setup("[user]\n{{ template \"shared/git/user.conf\" . }}")
+		memory.MkdirAll("/dotfiles/shared/git", 0755)
+		memory.WriteFile("/dotfiles/shared/git/user.conf", []byte("\temail = {{ .Vars.email }}\n{{ template \"shared/name.conf\" }}"), 0644)
+		memory.WriteFile("/dotfiles/shared/name.conf", []byte("\tname = me\n"), 0644)
+
+		if output, err := link(t); err != nil {
+			t.Fatalf("Link failed: %v\n%s", err, output)
+		}
+		if data, _ := memory.ReadFile(rendered); string(data) != "[user]\n\temail = me@example.com\n\tname = me\n" {
+			t.Errorf("Expected the included files, got '%s'", data)
+		}
+
+		// A change to an included file alone makes the output out of date
+		memory.WriteFile("/dotfiles/shared/name.conf", []byte("\tname = you\n"), 0644)
+		var err error
+		output := captureOutput(t, func() {
+			err = Check([]string{"general"})
+		})
+		if err == nil || !strings.Contains(output, "Rendered template out of date: "+rendered) {
+			t.Errorf("Expected the stale output to be reported, got %v: %s", err, output)
+		}
+	})
+
+	t.Run("Templates see the home that --home names", func(t *testing.T) {
+		defer func() { utils.TargetHome = "" }()
+		utils.TargetHome = "/image/home"
+		memory := setup("home = {{ .Home }}\n")
+		memory.MkdirAll("/image/home", 0755)
+
+		if output, err := link(t); err != nil {
+			t.Fatalf("Link failed: %v\n%s", err, output)
+		}
+		if data, _ := memory.ReadFile(rendered); string(data) != "home = /image/home\n" {
+			t.Errorf("Expected the target home, got '%s'", data)
+		}
+	})
+
+	t.Run("Templates cannot include files outside the repository", func(t *testing.T) {
+		memory := setup("{{ template \"../etc/passwd\" }}")
+		memory.MkdirAll("/etc", 0755)
+		memory.WriteFile("/etc/passwd", []byte("root"), 0644)
+
+		output, _ := link(t)
+		if !strings.Contains(output, "not a path inside the dotfiles directory") {
+			t.Errorf("Expected the include to be refused, got: %s", output)
+		}
+	})
+
+	t.Run("Templates use the delimiters of their entry", func(t *testing.T) {
+		memory := setup("email = [[ .Vars.email ]]\nalias = {{ not a template }}\n")
+		memory.WriteFile("/dotfiles/.mappings", []byte("template_delims = [\"<%\", \"%>\"]\n\n[vars]\nemail = \"me@example.com\"\n\n[general]\n\"git/.gitconfig.tmpl\" = { target = \"~/.gitconfig\", delims = [\"[[\", \"]]\"] }\n\"zsh/.zshrc.tmpl\" = \"~/.zshrc\"\n"), 0644)
+		memory.MkdirAll("/dotfiles/zsh", 0755)
+		memory.WriteFile("/dotfiles/zsh/.zshrc.tmpl", []byte("export EMAIL=<% .Vars.email %> # {{ }}\n"), 0644)
+
+		if output, err := link(t); err != nil {
+			t.Fatalf("Link failed: %v\n%s", err, output)
+		}
+		if data, _ := memory.ReadFile(rendered); string(data) != "email = me@example.com\nalias = {{ not a template }}\n" {
+			t.Errorf("Expected the entry's delimiters to be used, got '%s'", data)
+		}
+		if data, _ := memory.ReadFile("/data/dot/rendered/zsh/.zshrc"); string(data) != "export EMAIL=me@example.com # {{ }}\n" {
+			t.Errorf("Expected the global delimiters to be used, got '%s'", data)
+		}
+	})
+
+	t.Run("Check reports rendered output that is out of date", func(t *testing.T) {
+		memory := setup("host = {{ .Hostname }}\n")
+		if _, err := link(t); err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+		memory.WriteFile("/dotfiles/git/.gitconfig.tmpl", []byte("host = {{ .Hostname }}\nos = {{ .OS }}\n"), 0644)
+
+		var err error
+		output := captureOutput(t, func() {
+			err = Check([]string{"general"})
+		})
+		if err == nil || !strings.Contains(output, "Rendered template out of date: "+rendered) {
+			t.Errorf("Expected the stale output to be reported, got %v: %s", err, output)
+		}
+	})
+
+	t.Run("Unknown variable fails the mapping", func(t *testing.T) {
+		memory := setup("name = {{ .Vars.name }}\n")
+
+		output, err := link(t)
+		if err == nil {
+			t.Error("Expected the run to fail")
+		}
+		if !strings.Contains(output, "Error rendering /dotfiles/git/.gitconfig.tmpl") {
+			t.Errorf("Expected the rendering error, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/user/.gitconfig"); !os.IsNotExist(err) {
+			t.Errorf("Expected no link, got %v", err)
+		}
+	})
+
+	t.Run("Dry run does not write the output", func(t *testing.T) {
+		memory := setup("host = {{ .Hostname }}\n")
+
+		output := captureOutput(t, func() {
+			if err := Link([]string{"general"}, true); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Would render: /dotfiles/git/.gitconfig.tmpl") {
+			t.Errorf("Expected the rendering to be reported, got: %s", output)
+		}
+		if _, err := memory.Lstat(rendered); !os.IsNotExist(err) {
+			t.Errorf("Expected no rendered output, got %v", err)
+		}
+	})
+}
+
+func TestRenderedPath(t *testing.T) {
+	tests := map[string]string{
+		"git/.gitconfig.tmpl":     "/r/git/.gitconfig",
+		"../team/zsh/.zshrc.tmpl": "/r/_/team/zsh/.zshrc",
+	}
+	for source, expected := range tests {
+		if result := renderedPath("/r", source); result != expected {
+			t.Errorf("Expected %s to render to %s, got %s", source, expected, result)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package linker
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/yourusername/dot/internal/table"
+	"github.com/yourusername/dot/internal/term"
+)
+
+// Phases of a link run measured by timings
+const (
+	phaseConfig  = "config"   // reading .mappings and the global config, selecting mappings
+	phaseResolve = "resolve"  // resolving sources, alternates, targets, and copies
+	phasePreLink = "pre_link" // running the pre_link hooks of profiles
+	phaseLink    = "link"     // checking each target and creating links, copies, and backups
+	phaseHooks   = "hooks"    // running on_change hooks and the post_link hooks of profiles
+)
+
+// slowestMappings is the number of mappings --timings lists
+const slowestMappings = 10
+
+// phaseTiming is how long a phase of a run took
+type phaseTiming struct {
+	name     string
+	duration time.Duration
+	lookups  time.Duration // the part spent reading directories and stat-ing files
+}
+
+// mappingTiming is how long a mapping took
+type mappingTiming struct {
+	target   string
+	duration time.Duration
+	lookups  time.Duration
+}
+
+// timings measures the phases of a run and each of its mappings, to tell why a run is
+// slow, e.g. on a network home directory
+// A nil timings measures nothing
+type timings struct {
+	cache    *dirCache // the cache whose filesystem lookups are counted
+	phase    string    // the current phase, empty between phases
+	started  time.Time
+	lookups  time.Duration // the lookup time of the cache when the phase started
+	phases   []phaseTiming
+	mappings []mappingTiming
+}
+
+// newTimings returns timings for a run, or nil when they are not wanted
+func newTimings(enabled bool) *timings {
+	if !enabled {
+		return nil
+	}
+	return &timings{}
+}
+
+// begin ends the current phase and starts the named one
+func (t *timings) begin(phase string) {
+	if t == nil {
+		return
+	}
+	t.end()
+	t.phase, t.started, t.lookups = phase, time.Now(), t.lookupTime()
+}
+
+// end ends the current phase, if any
+func (t *timings) end() {
+	if t == nil || t.phase == "" {
+		return
+	}
+	t.phases = append(t.phases, phaseTiming{name: t.phase, duration: time.Since(t.started), lookups: t.lookupTime() - t.lookups})
+	t.phase = ""
+}
+
+// watch counts the filesystem lookups of cache from now on
+func (t *timings) watch(cache *dirCache) {
+	if t != nil {
+		t.cache = cache
+	}
+}
+
+// lookupTime returns the lookup time of the watched cache so far
+func (t *timings) lookupTime() time.Duration {
+	if t == nil || t.cache == nil {
+		return 0
+	}
+	return t.cache.lookupTime()
+}
+
+// mapping records how long a mapping took
+func (t *timings) mapping(m mapping, duration, lookups time.Duration) {
+	if t != nil {
+		t.mappings = append(t.mappings, mappingTiming{target: m.targetPath, duration: duration, lookups: lookups})
+	}
+}
+
+// print writes the phases and the slowest mappings to stderr
+func (t *timings) print() {
+	if t == nil {
+		return
+	}
+
+	var total, totalLookups time.Duration
+	phases := table.New("PHASE", "TIME", "LOOKUPS")
+	for _, p := range t.phases {
+		phases.Append(p.name, formatDuration(p.duration), formatDuration(p.lookups))
+		total += p.duration
+		totalLookups += p.lookups
+	}
+	phases.Append("total", formatDuration(total), formatDuration(totalLookups))
+	fmt.Fprintln(os.Stderr)
+	phases.Render(os.Stderr, 0)
+
+	if len(t.mappings) == 0 {
+		return
+	}
+	slowest := slices.Clone(t.mappings)
+	slices.SortStableFunc(slowest, func(a, b mappingTiming) int {
+		return cmp.Compare(b.duration, a.duration)
+	})
+	if len(slowest) > slowestMappings {
+		slowest = slowest[:slowestMappings]
+	}
+
+	mappings := table.New("TARGET", "TIME", "LOOKUPS")
+	mappings.Truncatable(0)
+	for _, m := range slowest {
+		mappings.Append(m.target, formatDuration(m.duration), formatDuration(m.lookups))
+	}
+	fmt.Fprintf(os.Stderr, "\nSlowest of %d mapping(s):\n", len(t.mappings))
+	width, _ := term.Size(os.Stderr)
+	mappings.Render(os.Stderr, width)
+}
+
+// formatDuration rounds d to a precision that is readable but still tells fast steps apart
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	}
+	return d.Round(time.Microsecond).String()
+}
@@ -0,0 +1,67 @@
+package linker
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestTimings(t *testing.T) {
+	t.Run("Nil timings measure nothing", func(t *testing.T) {
+		var tm *timings
+		tm.begin(phaseConfig)
+		tm.watch(newDirCache(fsys.NewMemory()))
+		tm.mapping(mapping{}, time.Second, 0)
+		tm.end()
+		tm.print()
+	})
+
+	t.Run("Phases count the lookups of the watched cache", func(t *testing.T) {
+		memory := fsys.NewMemory()
+		memory.MkdirAll("/home/user", 0755)
+		cache := newDirCache(memory)
+
+		tm := newTimings(true)
+		tm.begin(phaseConfig)
+		tm.begin(phaseResolve)
+		tm.watch(cache)
+		cache.lstat("/home/user/.zshrc")
+		tm.end()
+
+		if len(tm.phases) != 2 || tm.phases[0].name != phaseConfig || tm.phases[1].name != phaseResolve {
+			t.Fatalf("Expected config and resolve phases, got %v", tm.phases)
+		}
+		if tm.phases[1].lookups != cache.lookupTime() || tm.phases[1].lookups > tm.phases[1].duration {
+			t.Errorf("Expected the cache's %v of lookups within the phase's %v, got %v", cache.lookupTime(), tm.phases[1].duration, tm.phases[1].lookups)
+		}
+	})
+
+	t.Run("Print lists the slowest mappings first", func(t *testing.T) {
+		tm := newTimings(true)
+		tm.mapping(mapping{targetPath: "/home/user/.fast"}, time.Millisecond, 0)
+		tm.mapping(mapping{targetPath: "/home/user/.slow"}, time.Second, 900*time.Millisecond)
+
+		output := captureOutput(t, tm.print)
+		if !strings.Contains(output, "Slowest of 2 mapping(s)") {
+			t.Errorf("Expected the mapping count, got: %s", output)
+		}
+		if strings.Index(output, ".slow") > strings.Index(output, ".fast") {
+			t.Errorf("Expected .slow before .fast, got: %s", output)
+		}
+	})
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		1234567 * time.Nanosecond:        "1.23ms",
+		1500 * time.Nanosecond:           "2µs",
+		2*time.Second + time.Microsecond: "2s",
+	}
+	for d, expected := range tests {
+		if result := formatDuration(d); result != expected {
+			t.Errorf("Expected %v to format as %s, got %s", d, expected, result)
+		}
+	}
+}
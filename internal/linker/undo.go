@@ -0,0 +1,177 @@
+package linker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Undo reverts the most recent link or clean runs recorded in the journal
+// Each action is only reverted while the filesystem still looks the way the run left
+// it, so changes made since then are never overwritten
+func Undo(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+
+	entries, err := journal.Load()
+	if err != nil {
+		return err
+	}
+
+	undoable := journal.Undoable(entries)
+	if len(undoable) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	if steps > len(undoable) {
+		return fmt.Errorf("only %d run(s) can be undone", len(undoable))
+	}
+
+	man, err := manifest.Load()
+	if err != nil {
+		return err
+	}
+	protected, err := loadProtection()
+	if err != nil {
+		return err
+	}
+
+	undo := journal.Entry{Command: "undo"}
+	for _, entry := range undoable[:steps] {
+		fmt.Fprintf(os.Stderr, "Undoing %s from %s\n", entry.Command, entry.Time.Local().Format("2006-01-02 15:04:05"))
+
+		for i := len(entry.Actions) - 1; i >= 0; i-- {
+			if action, ok := undoAction(man, protected, entry.Actions[i]); ok {
+				undo.Actions = append(undo.Actions, action)
+			}
+		}
+		undo.Undoes = append(undo.Undoes, entry.ID)
+	}
+
+	if _, err := journal.Append(undo); err != nil {
+		return fmt.Errorf("failed to record undo in journal: %w", err)
+	}
+	manifest.Record(FS, undo.Actions)
+
+	return nil
+}
+
+// undoAction reverts a single journal action and returns the action that reverted it
+// man holds the copies made in place of symlinks, which are only removed while unchanged
+// Actions on a protected path are left alone, except removing a directory left empty
+func undoAction(man *manifest.Manifest, protected protection, action journal.Action) (journal.Action, bool) {
+	if action.Op != journal.OpMkdir {
+		if refusal := protected.refusal(action.Path, "undo a change to"); refusal != "" {
+			utils.FprintfColor(os.Stderr, "red", "%s\n", refusal)
+			return journal.Action{}, false
+		}
+	}
+
+	switch action.Op {
+	case journal.OpCreateLink:
+		if linkTarget, err := FS.Readlink(action.Path); err != nil || !utils.SamePath(linkTarget, action.Target) {
+			fmt.Fprintf(os.Stderr, "Skipped (changed since): %s\n", action.Path)
+			return journal.Action{}, false
+		}
+		if err := FS.Remove(action.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing %s: %v\n", action.Path, err)
+			return journal.Action{}, false
+		}
+		fmt.Fprintf(os.Stderr, "Removed: %s\n", action.Path)
+		return journal.NewAction(journal.OpRemoveLink, action.Path, action.Target), true
+
+	case journal.OpRemoveLink:
+		if _, err := FS.Lstat(action.Path); err == nil {
+			fmt.Fprintf(os.Stderr, "Skipped (changed since): %s\n", action.Path)
+			return journal.Action{}, false
+		}
+		if err := FS.MkdirAll(filepath.Dir(action.Path), 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating directory for %s: %v\n", action.Path, err)
+			return journal.Action{}, false
+		}
+		if err := FS.Symlink(action.Target, action.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating link %s -> %s: %v\n", action.Path, action.Target, err)
+			return journal.Action{}, false
+		}
+		utils.FprintfColor(os.Stderr, "green", "Restored: %s -> %s\n", action.Path, action.Target)
+		return journal.NewAction(journal.OpCreateLink, action.Path, action.Target), true
+
+	case journal.OpBackup:
+		if _, err := FS.Lstat(action.Path); err == nil {
+			fmt.Fprintf(os.Stderr, "Skipped (changed since): %s\n", action.Path)
+			return journal.Action{}, false
+		}
+		if err := utils.MovePathFS(FS, action.Target, action.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error restoring backup %s: %v\n", action.Target, err)
+			return journal.Action{}, false
+		}
+		removeEmptyDirs(FS, action.Target)
+		utils.FprintfColor(os.Stderr, "blue", "Restored backup: %s -> %s\n", action.Target, action.Path)
+		return journal.NewAction(journal.OpRestore, action.Path, action.Target), true
+
+	case journal.OpRestore:
+		if _, err := FS.Lstat(action.Target); err == nil {
+			fmt.Fprintf(os.Stderr, "Skipped (backup exists): %s\n", action.Target)
+			return journal.Action{}, false
+		}
+		if err := utils.MovePathFS(FS, action.Path, action.Target); err != nil {
+			fmt.Fprintf(os.Stderr, "Error moving %s back to %s: %v\n", action.Path, action.Target, err)
+			return journal.Action{}, false
+		}
+		fmt.Fprintf(os.Stderr, "Backed up again: %s -> %s\n", action.Path, action.Target)
+		return journal.NewAction(journal.OpBackup, action.Path, action.Target), true
+
+	case journal.OpMove:
+		// The source the file replaced comes back from its backup, undone next
+		if _, err := FS.Lstat(action.Path); err == nil {
+			fmt.Fprintf(os.Stderr, "Skipped (changed since): %s\n", action.Path)
+			return journal.Action{}, false
+		}
+		if err := utils.MovePathFS(FS, action.Target, action.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error moving %s back to %s: %v\n", action.Target, action.Path, err)
+			return journal.Action{}, false
+		}
+		utils.FprintfColor(os.Stderr, "blue", "Moved back: %s -> %s\n", action.Target, action.Path)
+		return journal.NewAction(journal.OpMove, action.Target, action.Path), true
+
+	case journal.OpCopy:
+		digest, err := manifest.Hash(FS, action.Path)
+		if err != nil || digest != man.Copies[action.Path].Hash {
+			fmt.Fprintf(os.Stderr, "Skipped (changed since): %s\n", action.Path)
+			return journal.Action{}, false
+		}
+		if err := FS.RemoveAll(action.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing %s: %v\n", action.Path, err)
+			return journal.Action{}, false
+		}
+		fmt.Fprintf(os.Stderr, "Removed copy: %s\n", action.Path)
+		return journal.NewAction(journal.OpRemoveCopy, action.Path, action.Target), true
+
+	case journal.OpRemoveCopy:
+		if _, err := FS.Lstat(action.Path); err == nil {
+			fmt.Fprintf(os.Stderr, "Skipped (changed since): %s\n", action.Path)
+			return journal.Action{}, false
+		}
+		if err := utils.CopyTreeFS(FS, action.Target, action.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error copying %s to %s: %v\n", action.Target, action.Path, err)
+			return journal.Action{}, false
+		}
+		utils.FprintfColor(os.Stderr, "green", "Restored copy: %s <- %s\n", action.Path, action.Target)
+		return journal.NewAction(journal.OpCopy, action.Path, action.Target), true
+
+	case journal.OpMkdir:
+		// Only directories left empty are removed
+		if err := FS.Remove(action.Path); err != nil {
+			return journal.Action{}, false
+		}
+		fmt.Fprintf(os.Stderr, "Removed directory: %s\n", action.Path)
+		return journal.NewAction(journal.OpRmdir, action.Path, ""), true
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: cannot undo unknown action %q on %s\n", action.Op, action.Path)
+	return journal.Action{}, false
+}
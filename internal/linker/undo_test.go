@@ -0,0 +1,214 @@
+package linker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/journal"
+)
+
+func TestUndo(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+
+	setup := func(t *testing.T) (string, string) {
+		tempDir := t.TempDir()
+		dotfilesDir := filepath.Join(tempDir, "dotfiles")
+		homeDir := filepath.Join(tempDir, "home")
+		os.Setenv("DOT_DIR", dotfilesDir)
+		os.Setenv("XDG_STATE_HOME", filepath.Join(tempDir, "state"))
+
+		os.MkdirAll(dotfilesDir, 0755)
+		os.MkdirAll(homeDir, 0755)
+		os.WriteFile(filepath.Join(dotfilesDir, "zshrc"), []byte("zsh"), 0644)
+		os.WriteFile(filepath.Join(dotfilesDir, "init.lua"), []byte("lua"), 0644)
+		os.WriteFile(filepath.Join(homeDir, ".zshrc"), []byte("original"), 0644)
+
+		mappings := `[general]
+"zshrc" = "` + filepath.Join(homeDir, ".zshrc") + `"
+"init.lua" = "` + filepath.Join(homeDir, ".config", "nvim", "init.lua") + `"
+`
+		os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte(mappings), 0644)
+		return dotfilesDir, homeDir
+	}
+
+	t.Run("Reverts a link run", func(t *testing.T) {
+		_, homeDir := setup(t)
+
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+
+		output := captureOutput(t, func() {
+			if err := Undo(1); err != nil {
+				t.Fatalf("Undo failed: %v", err)
+			}
+		})
+
+		data, err := os.ReadFile(filepath.Join(homeDir, ".zshrc"))
+		if err != nil || string(data) != "original" {
+			t.Errorf("Expected original file to be restored, got '%s' (%v)", data, err)
+		}
+		if _, err := os.Lstat(filepath.Join(homeDir, ".config")); !os.IsNotExist(err) {
+			t.Errorf("Expected created directories to be removed, got %v", err)
+		}
+		if !strings.Contains(output, "Restored backup:") {
+			t.Errorf("Expected restore message, got: %s", output)
+		}
+	})
+
+	t.Run("Reverts an adopting link run, source included", func(t *testing.T) {
+		dotfilesDir, homeDir := setup(t)
+
+		captureOutput(t, func() {
+			if err := LinkWithOptions([]string{"general"}, LinkOptions{OnConflict: OnConflictAdopt}); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		if data, _ := os.ReadFile(filepath.Join(dotfilesDir, "zshrc")); string(data) != "original" {
+			t.Fatalf("Expected the file to be adopted, got '%s'", data)
+		}
+
+		captureOutput(t, func() {
+			if err := Undo(1); err != nil {
+				t.Fatalf("Undo failed: %v", err)
+			}
+		})
+
+		if data, err := os.ReadFile(filepath.Join(homeDir, ".zshrc")); err != nil || string(data) != "original" {
+			t.Errorf("Expected the adopted file back at its target, got '%s' (%v)", data, err)
+		}
+		if data, err := os.ReadFile(filepath.Join(dotfilesDir, "zshrc")); err != nil || string(data) != "zsh" {
+			t.Errorf("Expected the previous source to be restored, got '%s' (%v)", data, err)
+		}
+	})
+
+	t.Run("Reverts a clean run", func(t *testing.T) {
+		dotfilesDir, homeDir := setup(t)
+
+		captureOutput(t, func() {
+			Link([]string{"general"}, false)
+			Clean([]string{"general"})
+		})
+
+		captureOutput(t, func() {
+			if err := Undo(1); err != nil {
+				t.Fatalf("Undo failed: %v", err)
+			}
+		})
+
+		link, err := os.Readlink(filepath.Join(homeDir, ".zshrc"))
+		if err != nil || link != filepath.Join(dotfilesDir, "zshrc") {
+			t.Errorf("Expected link to be restored, got %s (%v)", link, err)
+		}
+	})
+
+	t.Run("Steps go further back", func(t *testing.T) {
+		_, homeDir := setup(t)
+
+		captureOutput(t, func() {
+			Link([]string{"general"}, false)
+			Clean([]string{"general"})
+			if err := Undo(2); err != nil {
+				t.Fatalf("Undo failed: %v", err)
+			}
+		})
+
+		data, err := os.ReadFile(filepath.Join(homeDir, ".zshrc"))
+		if err != nil || string(data) != "original" {
+			t.Errorf("Expected original file after undoing both runs, got '%s' (%v)", data, err)
+		}
+	})
+
+	t.Run("Undone runs are not undone twice", func(t *testing.T) {
+		setup(t)
+
+		captureOutput(t, func() {
+			Link([]string{"general"}, false)
+			Undo(1)
+		})
+
+		if err := Undo(1); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+			t.Errorf("Expected nothing to undo, got %v", err)
+		}
+	})
+
+	t.Run("Changes made since are kept", func(t *testing.T) {
+		_, homeDir := setup(t)
+
+		captureOutput(t, func() {
+			Link([]string{"general"}, false)
+		})
+		target := filepath.Join(homeDir, ".zshrc")
+		os.Remove(target)
+		os.WriteFile(target, []byte("edited"), 0644)
+
+		output := captureOutput(t, func() {
+			Undo(1)
+		})
+
+		if data, _ := os.ReadFile(target); string(data) != "edited" {
+			t.Errorf("Expected edited file to be kept, got '%s'", data)
+		}
+		if !strings.Contains(output, "Skipped (changed since): "+target) {
+			t.Errorf("Expected skip message, got: %s", output)
+		}
+	})
+
+	t.Run("Dry run is not recorded", func(t *testing.T) {
+		setup(t)
+
+		captureOutput(t, func() {
+			Link([]string{"general"}, true)
+		})
+
+		if err := Undo(1); err == nil {
+			t.Error("Expected nothing to undo after a dry run")
+		}
+	})
+}
+
+func TestLinkRecordsJournal(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+
+	tempDir := t.TempDir()
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+	homeDir := filepath.Join(tempDir, "home")
+	os.Setenv("DOT_DIR", dotfilesDir)
+	os.Setenv("XDG_STATE_HOME", filepath.Join(tempDir, "state"))
+	setupTestEnvironment(t, dotfilesDir, homeDir)
+
+	captureOutput(t, func() {
+		Link([]string{"general", "work"}, false)
+		Link([]string{"general", "work"}, false)
+	})
+
+	entries, err := journal.Load()
+	if err != nil {
+		t.Fatalf("Failed to load journal: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected only the run that changed something to be recorded, got %d entries", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Command != "link" || strings.Join(entry.Profiles, ",") != "general,work" {
+		t.Errorf("Expected link run with profiles general,work, got %s %v", entry.Command, entry.Profiles)
+	}
+	if len(entry.Actions) != 1 || entry.Actions[0].Op != journal.OpCreateLink || entry.Actions[0].Time.IsZero() {
+		t.Errorf("Expected one timestamped create_link action, got %+v", entry.Actions)
+	}
+}
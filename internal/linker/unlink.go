@@ -0,0 +1,126 @@
+package linker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// UnlinkOptions configures an unlink run
+type UnlinkOptions struct {
+	// RestoreBackups moves the backup that link made back into place once the link
+	// is removed
+	RestoreBackups bool
+}
+
+// Unlink removes the links of the mappings that names are the targets or sources of,
+// leaving the other mappings of the profiles alone
+// A name is a target such as ~/.zshrc or a source as .mappings declares it; a name that
+// matches no mapping fails the run before anything is removed
+func Unlink(profiles []string, names []string, opts UnlinkOptions) error {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return err
+	}
+
+	policy, err := loadHookPolicy()
+	if err != nil {
+		return err
+	}
+	protected, err := loadProtection()
+	if err != nil {
+		return err
+	}
+
+	cache := newDirCache(FS)
+	mappings := resolveMappings(cache, dotfilesDir, selected)
+	if err := markCopies(cfg, mappings); err != nil {
+		return err
+	}
+
+	var matched []mapping
+	seen := make(map[string]bool)
+	for _, name := range names {
+		found := matchMappings(cfg, mappings, name)
+		if len(found) == 0 {
+			return fmt.Errorf("%s is neither a target nor a source of the selected profiles", name)
+		}
+		for _, m := range found {
+			if !seen[m.targetPath] {
+				seen[m.targetPath] = true
+				matched = append(matched, m)
+			}
+		}
+	}
+
+	actions, failed := forEachMapping(matched, nil, nil, nil, func(m mapping, out *output) {
+		cleanMapping(cache, m, protected, out)
+		if opts.RestoreBackups && !out.failed {
+			restoreBackupOf(cache, m, protected, out)
+		}
+	})
+	journal.Record("unlink", profiles, actions)
+	manifest.Record(FS, actions)
+
+	// on_remove hooks only run for links and copies that were actually removed
+	return errors.Join(failed, runHooks("on_remove", dotfilesDir, collectHooks(cfg, matched, actions, []string{journal.OpRemoveLink, journal.OpRemoveCopy}, policy, func(e config.Entry) string {
+		return e.OnRemove
+	}), false))
+}
+
+// matchMappings returns the mappings whose target or declared source is name; a source
+// linked file by file matches each of its files
+func matchMappings(cfg *config.Config, mappings []mapping, name string) []mapping {
+	target := targetPathOf(name)
+	var matched []mapping
+	for _, m := range mappings {
+		declared, _ := cfg.MappingsSource(m.profile, m.source)
+		if utils.SamePath(m.targetPath, target) || m.source == name || declared == name {
+			matched = append(matched, m)
+		}
+	}
+	return matched
+}
+
+// restoreBackupOf moves the latest backup that link made of a mapping's target back
+// into place, once nothing is left at the target, unless the target is protected
+func restoreBackupOf(cache *dirCache, m mapping, protected protection, out *output) {
+	if _, err := cache.lstat(m.targetPath); err == nil {
+		return
+	}
+	backups := backupsOf(cache.fs, backupStore(cache.fs), m.targetPath)
+	if len(backups) == 0 {
+		return
+	}
+	backup := backups[0].path
+	if refuseProtected(protected, m.targetPath, "restore a backup to", out) {
+		return
+	}
+
+	if err := utils.RestoreBackupFromFS(cache.fs, m.targetPath, backup); err != nil {
+		out.errorf("Error restoring backup %s: %v\n", backup, err)
+		return
+	}
+	cache.remove(backup)
+	removeEmptyDirs(cache.fs, backup)
+	if info, err := cache.fs.Lstat(m.targetPath); err == nil {
+		cache.set(m.targetPath, info.Mode().Type())
+	}
+	out.record(journal.OpRestore, m.targetPath, backup)
+	out.printfColor("blue", "Restored backup: %s -> %s\n", backup, m.targetPath)
+}
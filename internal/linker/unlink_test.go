@@ -0,0 +1,112 @@
+package linker
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestUnlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("DOT_DIR", "/dotfiles")
+
+	// setup links .vimrc and .zshrc, and leaves a backup of the .zshrc found at its target
+	setup := func(t *testing.T) *fsys.Memory {
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+		memory := fsys.NewMemory()
+		FS = memory
+		memory.MkdirAll("/dotfiles/vim", 0755)
+		memory.MkdirAll("/dotfiles/zsh", 0755)
+		memory.MkdirAll("/home", 0755)
+		memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"vim/.vimrc\" = \"/home/.vimrc\"\n\"zsh/.zshrc\" = \"/home/.zshrc\"\n"), 0644)
+		memory.WriteFile("/dotfiles/vim/.vimrc", []byte("vim"), 0644)
+		memory.WriteFile("/dotfiles/zsh/.zshrc", []byte("zsh"), 0644)
+		memory.WriteFile("/home/.zshrc", []byte("local"), 0644)
+		captureOutput(t, func() {
+			if err := Link([]string{"general"}, false); err != nil {
+				t.Fatalf("Link failed: %v", err)
+			}
+		})
+		return memory
+	}
+
+	t.Run("Removes only the named link", func(t *testing.T) {
+		memory := setup(t)
+
+		output := captureOutput(t, func() {
+			if err := Unlink([]string{"general"}, []string{"/home/.zshrc"}, UnlinkOptions{}); err != nil {
+				t.Errorf("Unlink failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Removed: /home/.zshrc") {
+			t.Errorf("Expected the link to be removed, got: %s", output)
+		}
+		if _, err := memory.Lstat("/home/.zshrc"); !os.IsNotExist(err) {
+			t.Errorf("Expected no .zshrc, got %v", err)
+		}
+		if _, err := memory.Lstat("/home/.zshrc.bak"); err != nil {
+			t.Errorf("Expected the backup to be kept, got %v", err)
+		}
+		if link, _ := memory.Readlink("/home/.vimrc"); link != "/dotfiles/vim/.vimrc" {
+			t.Errorf("Expected .vimrc to stay linked, got %s", link)
+		}
+	})
+
+	t.Run("Restores the backup of a source", func(t *testing.T) {
+		memory := setup(t)
+
+		output := captureOutput(t, func() {
+			if err := Unlink([]string{"general"}, []string{"zsh/.zshrc"}, UnlinkOptions{RestoreBackups: true}); err != nil {
+				t.Errorf("Unlink failed: %v", err)
+			}
+		})
+		if !strings.Contains(output, "Restored backup: /home/.zshrc.bak -> /home/.zshrc") {
+			t.Errorf("Expected the backup to be restored, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/home/.zshrc"); string(data) != "local" {
+			t.Errorf("Expected the original .zshrc, got '%s'", data)
+		}
+
+		// Undo puts the backup aside again and links the source back
+		captureOutput(t, func() {
+			if err := Undo(1); err != nil {
+				t.Errorf("Undo failed: %v", err)
+			}
+		})
+		if link, _ := memory.Readlink("/home/.zshrc"); link != "/dotfiles/zsh/.zshrc" {
+			t.Errorf("Expected .zshrc to be linked again, got '%s'", link)
+		}
+		if data, _ := memory.ReadFile("/home/.zshrc.bak"); string(data) != "local" {
+			t.Errorf("Expected the backup to be back, got '%s'", data)
+		}
+	})
+
+	t.Run("Unknown name removes nothing", func(t *testing.T) {
+		memory := setup(t)
+
+		var err error
+		captureOutput(t, func() {
+			err = Unlink([]string{"general"}, []string{"/home/.vimrc", "/home/.bashrc"}, UnlinkOptions{})
+		})
+		if err == nil || !strings.Contains(err.Error(), "/home/.bashrc is neither a target nor a source") {
+			t.Errorf("Expected the unknown name to fail the run, got %v", err)
+		}
+		if _, err := memory.Lstat("/home/.vimrc"); err != nil {
+			t.Errorf("Expected .vimrc to stay linked, got %v", err)
+		}
+	})
+}
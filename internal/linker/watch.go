@@ -0,0 +1,185 @@
+package linker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/manifest"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// What watch does when a managed link is replaced by a file or directory
+const (
+	OnReplaceWarn   = "warn"   // only warn about it
+	OnReplaceRelink = "relink" // back up the file and link again, as dot link does
+	OnReplaceAdopt  = "adopt"  // move the file into the repository in place of its source and link again
+)
+
+// OnReplacePolicies lists the valid values of WatchOptions.OnReplace
+var OnReplacePolicies = []string{OnReplaceWarn, OnReplaceRelink, OnReplaceAdopt}
+
+// WatchOptions controls how Watch reacts to replaced links
+type WatchOptions struct {
+	// Interval is the time between two looks at the targets
+	Interval time.Duration
+	// OnReplace is one of OnReplacePolicies
+	OnReplace string
+	// Link links the mappings affected by changes to the dotfiles repository as they
+	// are made, and all of them when .mappings changes
+	Link bool
+}
+
+// Watch looks at the targets of the selected profiles every interval until ctx is done,
+// and reports a managed link that an installer or application replaced with a real file
+// Only links that were correct at some point while watching are reported, so a target
+// that was never linked does not warn on every look
+func Watch(ctx context.Context, profiles []string, opts WatchOptions) error {
+	if !validOnReplace(opts.OnReplace) {
+		return fmt.Errorf("invalid --on-replace %q, must be one of: %s", opts.OnReplace, strings.Join(OnReplacePolicies, ", "))
+	}
+	if opts.Interval <= 0 {
+		return fmt.Errorf("invalid --interval %s, must be positive", opts.Interval)
+	}
+
+	linked := make(map[string]bool)
+	count, err := watchTargets(profiles, linked, opts.OnReplace)
+	if err != nil {
+		return err
+	}
+
+	// A nil channel never delivers, so without Link only the targets are watched
+	var changes <-chan []string
+	if opts.Link {
+		dotfilesDir, err := dotfiles.GetDotfilesDir()
+		if err != nil {
+			return err
+		}
+		if changes, err = watchRepo(ctx, dotfilesDir); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dotfilesDir, err)
+		}
+		fmt.Fprintf(os.Stderr, "Watching %s for changes to link\n", dotfilesDir)
+	}
+	fmt.Fprintf(os.Stderr, "Watching %d link(s) every %s, press Ctrl-C to stop\n", count, opts.Interval)
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// The .mappings file may be mid-edit, so a look that fails does not end the watch
+			if _, err := watchTargets(profiles, linked, opts.OnReplace); err != nil {
+				utils.FprintfColor(os.Stderr, "red", "Error: %v\n", err)
+			}
+		case changed, ok := <-changes:
+			if !ok {
+				changes = nil
+				continue
+			}
+			if err := linkChanges(profiles, changed); err != nil {
+				utils.FprintfColor(os.Stderr, "red", "Error: %v\n", err)
+			}
+		}
+	}
+}
+
+// linkChanges links the mappings of the profiles that the changed paths of the dotfiles
+// repository affect, or all of them when .mappings or a fragment changed
+func linkChanges(profiles []string, changed []string) error {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	if changesMappings(dotfilesDir, changed) {
+		fmt.Fprintln(os.Stderr, "Mappings changed, linking again")
+		return LinkWithOptions(profiles, LinkOptions{})
+	}
+	return LinkWithOptions(profiles, LinkOptions{changed: changed})
+}
+
+// validOnReplace reports whether policy is one of OnReplacePolicies
+func validOnReplace(policy string) bool {
+	return slices.Contains(OnReplacePolicies, policy)
+}
+
+// watchTargets takes one look at the targets, handling those that were linked on an
+// earlier look and are now files or directories, and updates linked with the targets
+// that are correct links now
+// Returns the number of correct links
+func watchTargets(profiles []string, linked map[string]bool, onReplace string) (int, error) {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return 0, err
+	}
+
+	cfg, err := config.ParseConfigFS(FS, dotfilesDir)
+	if err != nil {
+		return 0, err
+	}
+
+	selected, err := cfg.Select(profiles)
+	if err != nil {
+		return 0, err
+	}
+	selected, _, err = withoutDisabled(cfg, selected)
+	if err != nil {
+		return 0, err
+	}
+
+	protected, err := loadProtection()
+	if err != nil {
+		return 0, err
+	}
+
+	cache := newDirCache(FS)
+	mappings := resolveMappings(cache, dotfilesDir, selected)
+	wasLinked := make(map[string]bool, len(linked))
+	for target := range linked {
+		wasLinked[target] = true
+		delete(linked, target)
+	}
+
+	// Failures were printed already; watching goes on
+	actions, _ := forEachMapping(mappings, nil, nil, nil, func(m mapping, out *output) {
+		mode, err := cache.lstat(m.targetPath)
+		switch {
+		case err != nil:
+		case mode&os.ModeSymlink != 0:
+			if linkTarget, err := cache.fs.Readlink(m.targetPath); err == nil && utils.SamePath(linkTarget, m.sourcePath) {
+				linked[m.targetPath] = true
+			}
+		case wasLinked[m.targetPath]:
+			replacedLink(cache, m, onReplace, protected, out)
+			if !out.failed && onReplace != OnReplaceWarn {
+				linked[m.targetPath] = true
+			}
+		}
+	})
+	if len(actions) > 0 {
+		journal.Record("watch", profiles, actions)
+		manifest.Record(FS, actions)
+	}
+	return len(linked), nil
+}
+
+// replacedLink warns that a mapping's link was replaced by a file or directory and
+// handles it according to the policy, leaving protected paths alone
+func replacedLink(cache *dirCache, m mapping, onReplace string, protected protection, out *output) {
+	out.printfColor("yellow", "Warning: Managed link replaced by a file: %s (was pointing to %s)\n", m.targetPath, m.sourcePath)
+
+	switch onReplace {
+	case OnReplaceRelink:
+		linkMapping(cache, m, false, &conflictResolver{policy: OnConflictBackup, protected: protected, backups: newBackupDir(FS)}, out)
+	case OnReplaceAdopt:
+		linkMapping(cache, m, false, &conflictResolver{policy: OnConflictAdopt, protected: protected, backups: newBackupDir(FS)}, out)
+	}
+}
@@ -0,0 +1,134 @@
+package linker
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+func TestWatchTargets(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("in-memory paths are POSIX style")
+	}
+
+	originalFS := FS
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		FS = originalFS
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+	}()
+
+	memory := fsys.NewMemory()
+	FS = memory
+	os.Setenv("DOT_DIR", "/dotfiles")
+	os.Setenv("HOME", "/home/user")
+
+	memory.MkdirAll("/dotfiles", 0755)
+	memory.MkdirAll("/home/user", 0755)
+	memory.WriteFile("/dotfiles/.mappings", []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n\"vimrc\" = \"~/.vimrc\"\n"), 0644)
+	memory.WriteFile("/dotfiles/zshrc", []byte("zsh"), 0644)
+	memory.WriteFile("/dotfiles/vimrc", []byte("vim"), 0644)
+	memory.Symlink("/dotfiles/zshrc", "/home/user/.zshrc")
+	memory.WriteFile("/home/user/.vimrc", []byte("never linked"), 0644)
+
+	linked := make(map[string]bool)
+	replace := func(contents string) {
+		memory.Remove("/home/user/.zshrc")
+		memory.WriteFile("/home/user/.zshrc", []byte(contents), 0644)
+	}
+
+	t.Run("Only correct links are watched", func(t *testing.T) {
+		var count int
+		output := captureOutput(t, func() {
+			var err error
+			if count, err = watchTargets([]string{"general"}, linked, OnReplaceWarn); err != nil {
+				t.Fatalf("watchTargets failed: %v", err)
+			}
+		})
+		if count != 1 {
+			t.Errorf("Expected 1 watched link, got %d", count)
+		}
+		if output != "" {
+			t.Errorf("Expected no output, got: %s", output)
+		}
+	})
+
+	t.Run("Warn reports a replaced link once", func(t *testing.T) {
+		replace("installer")
+
+		output := captureOutput(t, func() {
+			watchTargets([]string{"general"}, linked, OnReplaceWarn)
+		})
+		if !strings.Contains(output, "Warning: Managed link replaced by a file: /home/user/.zshrc (was pointing to /dotfiles/zshrc)") {
+			t.Errorf("Expected replaced link warning, got: %s", output)
+		}
+		if strings.Contains(output, ".vimrc") {
+			t.Errorf("Expected a target that was never linked not to be reported, got: %s", output)
+		}
+
+		output = captureOutput(t, func() {
+			watchTargets([]string{"general"}, linked, OnReplaceWarn)
+		})
+		if output != "" {
+			t.Errorf("Expected the warning not to repeat, got: %s", output)
+		}
+	})
+
+	t.Run("Relink backs up the file and links again", func(t *testing.T) {
+		memory.Remove("/home/user/.zshrc")
+		memory.Symlink("/dotfiles/zshrc", "/home/user/.zshrc")
+		watchTargets([]string{"general"}, linked, OnReplaceRelink)
+		replace("installer")
+
+		output := captureOutput(t, func() {
+			watchTargets([]string{"general"}, linked, OnReplaceRelink)
+		})
+		if !strings.Contains(output, "Backed up: /home/user/.zshrc -> /home/user/.zshrc.bak") {
+			t.Errorf("Expected the file to be backed up, got: %s", output)
+		}
+		if target, err := memory.Readlink("/home/user/.zshrc"); err != nil || target != "/dotfiles/zshrc" {
+			t.Errorf("Expected .zshrc to be linked again, got %q (%v)", target, err)
+		}
+	})
+
+	t.Run("Adopt moves the file into the repository", func(t *testing.T) {
+		replace("from the app")
+
+		output := captureOutput(t, func() {
+			watchTargets([]string{"general"}, linked, OnReplaceAdopt)
+		})
+		if !strings.Contains(output, "Adopted: /home/user/.zshrc -> /dotfiles/zshrc") {
+			t.Errorf("Expected the file to be adopted, got: %s", output)
+		}
+		if data, _ := memory.ReadFile("/dotfiles/zshrc"); string(data) != "from the app" {
+			t.Errorf("Expected 'from the app', got '%s'", data)
+		}
+		if target, err := memory.Readlink("/home/user/.zshrc"); err != nil || target != "/dotfiles/zshrc" {
+			t.Errorf("Expected .zshrc to be linked again, got %q (%v)", target, err)
+		}
+	})
+
+	t.Run("Watch rejects unknown policies", func(t *testing.T) {
+		err := Watch(context.Background(), []string{"general"}, WatchOptions{Interval: time.Second, OnReplace: "delete"})
+		if err == nil || !strings.Contains(err.Error(), "invalid --on-replace") {
+			t.Errorf("Expected invalid policy error, got %v", err)
+		}
+	})
+
+	t.Run("Watch stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		captureOutput(t, func() {
+			if err := Watch(ctx, []string{"general"}, WatchOptions{Interval: time.Millisecond, OnReplace: OnReplaceWarn}); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	})
+}
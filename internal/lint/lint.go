@@ -0,0 +1,256 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/dot/internal/dotfiles"
+)
+
+// Severity is how much a finding matters
+type Severity int
+
+const (
+	Warning Severity = iota // worth fixing, but nothing breaks
+	Error                   // breaks a file or is unsafe to link
+)
+
+// String returns the severity as printed in front of a finding
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Finding is a problem with one file of the repository
+type Finding struct {
+	Path     string // relative to the repository
+	Line     int    // the first line the problem is on, or 0 when it concerns the whole file
+	Severity Severity
+	Message  string
+}
+
+// String formats the finding like a compiler message, e.g. zsh/zshrc:3: warning: ...
+func (f Finding) String() string {
+	location := f.Path
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", f.Path, f.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, f.Severity, f.Message)
+}
+
+// shellConfigs are the names of shell startup files, without their leading dot
+var shellConfigs = map[string]bool{
+	"aliases": true, "bash_aliases": true, "bash_logout": true, "bash_profile": true, "bashrc": true,
+	"profile": true, "zlogin": true, "zlogout": true, "zprofile": true, "zshenv": true, "zshrc": true,
+}
+
+// shellExtensions are the extensions of shell scripts and configs
+var shellExtensions = map[string]bool{".sh": true, ".bash": true, ".zsh": true, ".fish": true}
+
+// Run lints the dotfiles repository, or only the given files of it, printing one line per
+// finding to w; it fails if any finding is an error, or with strict any at all
+// Paths are relative to the working directory, as pre-commit passes them
+func Run(w io.Writer, paths []string, strict bool) error {
+	dir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	findings, err := Lint(dir, paths)
+	if err != nil {
+		return err
+	}
+
+	errors, warnings := 0, 0
+	for _, f := range findings {
+		fmt.Fprintln(w, f)
+		if f.Severity == Error {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+
+	if errors > 0 || (strict && warnings > 0) {
+		return fmt.Errorf("%d error(s), %d warning(s)", errors, warnings)
+	}
+	if warnings > 0 {
+		fmt.Fprintf(os.Stderr, "%d warning(s)\n", warnings)
+	} else {
+		fmt.Fprintln(os.Stderr, "No problems found")
+	}
+	return nil
+}
+
+// Lint checks every file of the repository in dir, or only the given paths, and returns
+// the findings sorted by path; the .git directory is skipped
+func Lint(dir string, paths []string) ([]Finding, error) {
+	var findings []Finding
+	check := func(path string, entry fs.DirEntry) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			rel = path
+		}
+		found, err := lintFile(dir, path, filepath.ToSlash(rel), entry)
+		findings = append(findings, found...)
+		return err
+	}
+
+	if len(paths) > 0 {
+		for _, path := range paths {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return nil, err
+			}
+			info, err := os.Lstat(abs)
+			if err != nil {
+				return nil, err
+			}
+			if err := check(abs, fs.FileInfoToDirEntry(info)); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if entry.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			return check(path, entry)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Path < findings[j].Path
+	})
+	return findings, nil
+}
+
+// lintFile checks one file, rel being its path as findings show it
+func lintFile(dir, path, rel string, entry fs.DirEntry) ([]Finding, error) {
+	if entry.Type()&fs.ModeSymlink != 0 {
+		return lintSymlink(dir, path, rel), nil
+	}
+	if !entry.Type().IsRegular() {
+		return nil, nil
+	}
+
+	info, err := entry.Info()
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0002 != 0 {
+		findings = append(findings, Finding{rel, 0, Error, "writable by every user, who could change what gets linked into your home directory"})
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Binary files have no lines to check
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return findings, nil
+	}
+
+	shebang := bytes.HasPrefix(data, []byte("#!"))
+	if shebang && runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+		findings = append(findings, Finding{rel, 0, Warning, "script with a #! line is not executable; run chmod +x"})
+	}
+
+	if line := firstLine(data, func(line []byte) bool { return bytes.HasSuffix(line, []byte("\r")) }); line > 0 && isShell(rel, data) {
+		findings = append(findings, Finding{rel, line, Error, "CRLF line endings, which the shell reads as part of each command"})
+	}
+
+	if !strings.EqualFold(filepath.Ext(rel), ".md") {
+		trailing := func(line []byte) bool {
+			line = bytes.TrimSuffix(line, []byte("\r"))
+			return bytes.HasSuffix(line, []byte(" ")) || bytes.HasSuffix(line, []byte("\t"))
+		}
+		if line := firstLine(data, trailing); line > 0 {
+			findings = append(findings, Finding{rel, line, Warning, fmt.Sprintf("trailing whitespace on %d line(s)", countLines(data, trailing))})
+		}
+	}
+	return findings, nil
+}
+
+// lintSymlink reports a symlink inside the repository whose target does not exist
+func lintSymlink(dir, path, rel string) []Finding {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return []Finding{{rel, 0, Error, fmt.Sprintf("unreadable symlink: %v", err)}}
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	resolved := target
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(path), target)
+	}
+	if inside, err := filepath.Rel(dir, resolved); err == nil && inside != ".." && !strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return []Finding{{rel, 0, Error, fmt.Sprintf("broken symlink to %s, which does not exist in the repository", target)}}
+	}
+	return []Finding{{rel, 0, Error, fmt.Sprintf("broken symlink to %s", target)}}
+}
+
+// isShell reports whether a file is a shell script or shell startup file
+func isShell(rel string, data []byte) bool {
+	name := strings.TrimPrefix(filepath.Base(rel), ".")
+	if shellConfigs[name] || shellExtensions[filepath.Ext(name)] {
+		return true
+	}
+	if !bytes.HasPrefix(data, []byte("#!")) {
+		return false
+	}
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	for _, shell := range []string{"sh", "bash", "zsh", "fish", "dash", "ksh"} {
+		if bytes.HasSuffix(bytes.TrimSpace(first), []byte("/"+shell)) || bytes.HasSuffix(bytes.TrimSpace(first), []byte(" "+shell)) {
+			return true
+		}
+	}
+	return false
+}
+
+// lines splits data into lines without their newlines
+func lines(data []byte) [][]byte {
+	return bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+}
+
+// firstLine returns the number of the first line that matches, or 0 if none does
+func firstLine(data []byte, match func([]byte) bool) int {
+	for i, line := range lines(data) {
+		if match(line) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// countLines returns how many lines match
+func countLines(data []byte, match func([]byte) bool) int {
+	count := 0
+	for _, line := range lines(data) {
+		if match(line) {
+			count++
+		}
+	}
+	return count
+}
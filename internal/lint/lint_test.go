@@ -0,0 +1,93 @@
+package lint
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes and symlinks differ on Windows")
+	}
+
+	dir := t.TempDir()
+	write := func(name, content string, mode os.FileMode) {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), mode)
+		os.Chmod(path, mode)
+	}
+	write("bin/update", "#!/bin/sh\necho update\n", 0644)
+	write("bin/ok", "#!/bin/sh\necho ok\n", 0755)
+	write("zsh/zshrc", "export A=1\r\nexport B=2\r\n", 0644)
+	write("gitconfig", "[user]\n\tname = me \n\temail = me@example.com\t\n", 0644)
+	write("README.md", "line break  \n", 0644)
+	write("notes.txt", "windows\r\n", 0644)
+	write("shared", "x\n", 0666)
+	write(".git/config", "trailing \n", 0644)
+	os.Symlink("missing", filepath.Join(dir, "broken"))
+	os.Symlink("..missing", filepath.Join(dir, "dotted"))
+	os.Symlink("bin/ok", filepath.Join(dir, "working"))
+
+	findings, err := Lint(dir, nil)
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	var result []string
+	for _, f := range findings {
+		result = append(result, f.String())
+	}
+	got := strings.Join(result, "\n")
+
+	expected := []string{
+		"bin/update: warning: script with a #! line is not executable",
+		"broken: error: broken symlink to missing, which does not exist in the repository",
+		"dotted: error: broken symlink to ..missing, which does not exist in the repository",
+		"gitconfig:2: warning: trailing whitespace on 2 line(s)",
+		"shared: error: writable by every user",
+		"zsh/zshrc:1: error: CRLF line endings",
+	}
+	for _, e := range expected {
+		if !strings.Contains(got, e) {
+			t.Errorf("Expected %q in the findings, got:\n%s", e, got)
+		}
+	}
+	if len(findings) != len(expected) {
+		t.Errorf("Expected %d findings, got:\n%s", len(expected), got)
+	}
+
+	t.Run("Only the given paths", func(t *testing.T) {
+		findings, err := Lint(dir, []string{filepath.Join(dir, "gitconfig"), filepath.Join(dir, "bin/ok")})
+		if err != nil {
+			t.Fatalf("Lint failed: %v", err)
+		}
+		if len(findings) != 1 || findings[0].Path != "gitconfig" {
+			t.Errorf("Expected one finding in gitconfig, got %v", findings)
+		}
+	})
+}
+
+func TestRun(t *testing.T) {
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer os.Setenv("DOT_DIR", originalDotDir)
+
+	dir := t.TempDir()
+	os.Setenv("DOT_DIR", dir)
+	os.WriteFile(filepath.Join(dir, "vimrc"), []byte("set number \n"), 0644)
+
+	var buf bytes.Buffer
+	if err := Run(&buf, nil, false); err != nil {
+		t.Errorf("Expected warnings not to fail, got %v", err)
+	}
+	if buf.String() != "vimrc:1: warning: trailing whitespace on 1 line(s)\n" {
+		t.Errorf("Expected the finding, got %q", buf.String())
+	}
+
+	if err := Run(&buf, nil, true); err == nil || err.Error() != "0 error(s), 1 warning(s)" {
+		t.Errorf("Expected strict to fail on warnings, got %v", err)
+	}
+}
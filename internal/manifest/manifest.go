@@ -0,0 +1,219 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/utils"
+	"github.com/yourusername/dot/internal/xdg"
+)
+
+// Copy is a target that holds a copy of its source instead of a symlink to it
+type Copy struct {
+	Source string `json:"source"`
+	// Hash is the digest of the content dot wrote to the target
+	Hash string `json:"hash"`
+}
+
+// Manifest is the state dot keeps about the targets it manages beyond the journal
+type Manifest struct {
+	Copies map[string]Copy `json:"copies,omitempty"`
+	// Linked is when dot last linked or copied each target it manages
+	Linked map[string]time.Time `json:"linked,omitempty"`
+	// Disabled lists the profiles and sources switched off on this machine, in the order
+	// they were disabled
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+// Disable records that name is switched off on this machine; it reports false when name
+// already was
+func (m *Manifest) Disable(name string) bool {
+	if slices.Contains(m.Disabled, name) {
+		return false
+	}
+	m.Disabled = append(m.Disabled, name)
+	return true
+}
+
+// Enable removes name from the disabled profiles and sources; it reports false when name
+// was not disabled
+func (m *Manifest) Enable(name string) bool {
+	i := slices.Index(m.Disabled, name)
+	if i < 0 {
+		return false
+	}
+	m.Disabled = slices.Delete(m.Disabled, i, i+1)
+	return true
+}
+
+// Path returns the location of the manifest file
+// It lives under $XDG_STATE_HOME/dot, falling back to ~/.local/state/dot
+func Path() (string, error) {
+	return xdg.State.Path("manifest.json")
+}
+
+// Load reads the manifest; a missing manifest yields an empty one
+func Load() (*Manifest, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{Copies: make(map[string]Copy), Linked: make(map[string]time.Time)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if m.Copies == nil {
+		m.Copies = make(map[string]Copy)
+	}
+	if m.Linked == nil {
+		m.Linked = make(map[string]time.Time)
+	}
+
+	return m, nil
+}
+
+// Save writes the manifest, creating its directory if needed
+func (m *Manifest) Save() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return nil
+}
+
+// now returns the current time; tests replace it
+var now = time.Now
+
+// Apply updates the copies from the copy, adopt_copy, and remove_copy actions of a run,
+// hashing each new or adopted copy as it is on f, and the link times from the
+// create_link, copy, remove_link, and remove_copy actions
+func (m *Manifest) Apply(f fsys.FS, actions []journal.Action) error {
+	if m.Linked == nil {
+		m.Linked = make(map[string]time.Time)
+	}
+	for _, action := range actions {
+		switch action.Op {
+		case journal.OpCopy, journal.OpAdoptCopy:
+			digest, err := Hash(f, action.Path)
+			if err != nil {
+				return fmt.Errorf("failed to hash %s: %w", action.Path, err)
+			}
+			m.Copies[action.Path] = Copy{Source: action.Target, Hash: digest}
+		case journal.OpRemoveCopy:
+			delete(m.Copies, action.Path)
+		}
+
+		switch action.Op {
+		case journal.OpCreateLink, journal.OpCopy:
+			m.Linked[action.Path] = now().UTC()
+		case journal.OpRemoveLink, journal.OpRemoveCopy:
+			delete(m.Linked, action.Path)
+		}
+	}
+	return nil
+}
+
+// Record applies the copy and link actions of a run to the manifest and saves it
+// Runs without such actions leave the manifest alone, and a manifest that cannot be
+// written only produces a warning, since the run itself already happened
+func Record(f fsys.FS, actions []journal.Action) {
+	changed := false
+	for _, action := range actions {
+		switch action.Op {
+		case journal.OpCopy, journal.OpAdoptCopy, journal.OpRemoveCopy, journal.OpCreateLink, journal.OpRemoveLink:
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	m, err := Load()
+	if err == nil {
+		err = m.Apply(f, actions)
+	}
+	if err == nil {
+		err = m.Save()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update manifest: %v\n", err)
+	}
+}
+
+// Hash returns a digest of the file or directory tree at path on f
+// Directories are hashed by the names and contents of everything below them; symlinks
+// are followed, as copying follows them too
+func Hash(f fsys.FS, path string) (string, error) {
+	return HashEOL(f, path, "")
+}
+
+// HashEOL returns the digest Hash would return after the line endings of the files at
+// path were converted to eol, see utils.ConvertEOL
+func HashEOL(f fsys.FS, path, eol string) (string, error) {
+	h := sha256.New()
+	if err := hashTree(f, h, path, ".", eol); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashTree feeds the entry at path, known as rel within the hashed tree, into h
+func hashTree(f fsys.FS, h hash.Hash, path, rel, eol string) error {
+	info, err := f.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		data, err := f.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data = utils.ConvertEOL(data, eol)
+		fmt.Fprintf(h, "file %s %d\n", rel, len(data))
+		h.Write(data)
+		return nil
+	}
+
+	fmt.Fprintf(h, "dir %s\n", rel)
+	entries, err := f.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := hashTree(f, h, filepath.Join(path, entry.Name()), rel+"/"+entry.Name(), eol); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/journal"
+)
+
+func TestPath(t *testing.T) {
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer os.Setenv("XDG_STATE_HOME", originalStateHome)
+
+	os.Setenv("XDG_STATE_HOME", "/tmp/state")
+
+	path, err := Path()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	expected := filepath.Join("/tmp/state", "dot", "manifest.json")
+	if path != expected {
+		t.Errorf("Expected %s, got %s", expected, path)
+	}
+}
+
+func TestHash(t *testing.T) {
+	f := fsys.NewMemory()
+	f.MkdirAll("/a/sub", 0755)
+	f.WriteFile("/a/sub/file", []byte("content"), 0644)
+	f.MkdirAll("/b/sub", 0755)
+	f.WriteFile("/b/sub/file", []byte("content"), 0644)
+	f.WriteFile("/c", []byte("content"), 0644)
+
+	t.Run("Equal trees hash equal", func(t *testing.T) {
+		a, err := Hash(f, "/a")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		b, _ := Hash(f, "/b")
+		if a != b {
+			t.Errorf("Expected equal hashes, got %s and %s", a, b)
+		}
+	})
+
+	t.Run("Content changes the hash", func(t *testing.T) {
+		before, _ := Hash(f, "/c")
+		f.WriteFile("/c", []byte("changed"), 0644)
+		after, _ := Hash(f, "/c")
+		if before == after {
+			t.Error("Expected the hash to change with the content")
+		}
+	})
+
+	t.Run("Names change the hash", func(t *testing.T) {
+		before, _ := Hash(f, "/b")
+		f.Rename("/b/sub/file", "/b/sub/renamed")
+		after, _ := Hash(f, "/b")
+		if before == after {
+			t.Error("Expected the hash to change with a file name")
+		}
+	})
+
+	t.Run("Missing path is an error", func(t *testing.T) {
+		if _, err := Hash(f, "/missing"); err == nil {
+			t.Error("Expected an error for a missing path")
+		}
+	})
+}
+
+func TestRecord(t *testing.T) {
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer os.Setenv("XDG_STATE_HOME", originalStateHome)
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	f := fsys.NewMemory()
+	f.MkdirAll("/home", 0755)
+	f.WriteFile("/home/.vimrc", []byte("set number"), 0644)
+
+	t.Run("Missing manifest is empty", func(t *testing.T) {
+		m, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(m.Copies) != 0 {
+			t.Errorf("Expected no copies, got %d", len(m.Copies))
+		}
+	})
+
+	t.Run("Copies are recorded with their hash", func(t *testing.T) {
+		Record(f, []journal.Action{journal.NewAction(journal.OpCopy, "/home/.vimrc", "/dotfiles/vimrc")})
+
+		m, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		c, exists := m.Copies["/home/.vimrc"]
+		if !exists {
+			t.Fatal("Expected the copy to be recorded")
+		}
+		if c.Source != "/dotfiles/vimrc" {
+			t.Errorf("Expected source /dotfiles/vimrc, got %s", c.Source)
+		}
+		expected, _ := Hash(f, "/home/.vimrc")
+		if c.Hash != expected {
+			t.Errorf("Expected hash %s, got %s", expected, c.Hash)
+		}
+	})
+
+	t.Run("Removed copies are forgotten", func(t *testing.T) {
+		Record(f, []journal.Action{journal.NewAction(journal.OpRemoveCopy, "/home/.vimrc", "/dotfiles/vimrc")})
+
+		m, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, exists := m.Copies["/home/.vimrc"]; exists {
+			t.Error("Expected the copy to be removed from the manifest")
+		}
+	})
+}
+
+func TestLinked(t *testing.T) {
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	originalNow := now
+	defer func() {
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+		now = originalNow
+	}()
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+	linked := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return linked }
+
+	f := fsys.NewMemory()
+
+	t.Run("Created links are recorded with the time", func(t *testing.T) {
+		Record(f, []journal.Action{journal.NewAction(journal.OpCreateLink, "/home/.zshrc", "/dotfiles/zshrc")})
+
+		m, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if result := m.Linked["/home/.zshrc"]; !result.Equal(linked) {
+			t.Errorf("Expected %v, got %v", linked, result)
+		}
+	})
+
+	t.Run("Removed links are forgotten", func(t *testing.T) {
+		Record(f, []journal.Action{journal.NewAction(journal.OpRemoveLink, "/home/.zshrc", "/dotfiles/zshrc")})
+
+		m, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if _, exists := m.Linked["/home/.zshrc"]; exists {
+			t.Error("Expected the link to be removed from the manifest")
+		}
+	})
+}
+
+func TestDisabled(t *testing.T) {
+	m := &Manifest{}
+	if !m.Disable("gui") || !m.Disable("vim/.vimrc") {
+		t.Fatal("Expected names to be disabled")
+	}
+	if m.Disable("gui") {
+		t.Error("Expected disabling gui twice to report false")
+	}
+	if !m.Enable("gui") {
+		t.Error("Expected gui to be enabled")
+	}
+	if m.Enable("gui") {
+		t.Error("Expected enabling gui twice to report false")
+	}
+	if len(m.Disabled) != 1 || m.Disabled[0] != "vim/.vimrc" {
+		t.Errorf("Expected [vim/.vimrc], got %v", m.Disabled)
+	}
+}
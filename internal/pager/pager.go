@@ -0,0 +1,120 @@
+package pager
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/yourusername/dot/internal/term"
+)
+
+// DefaultCommand is the pager used when $PAGER is not set; -R passes colors through
+const DefaultCommand = "less -R"
+
+// Pager collects what a command writes to stdout and, once the command is done, shows it
+// through the pager if it does not fit on the terminal
+type Pager struct {
+	stdout *os.File
+	writer *os.File
+	height int
+	done   chan []byte
+}
+
+// Command returns the pager command line from $PAGER, or DefaultCommand when it is unset
+// An empty $PAGER or "cat" disables paging and yields ""
+func Command() string {
+	command, set := os.LookupEnv("PAGER")
+	if !set {
+		return DefaultCommand
+	}
+	command = strings.TrimSpace(command)
+	if command == "cat" {
+		return ""
+	}
+	return command
+}
+
+// Start redirects stdout into a buffer when it is a terminal and the pager is installed
+// It returns nil otherwise, in which case output is written directly; Stop accepts nil
+func Start() *Pager {
+	command := Command()
+	if command == "" || !term.IsTerminal(os.Stdout) {
+		return nil
+	}
+	if _, err := exec.LookPath(strings.Fields(command)[0]); err != nil {
+		return nil
+	}
+	_, height := term.Size(os.Stdout)
+	if height <= 0 {
+		return nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil
+	}
+
+	p := &Pager{stdout: os.Stdout, writer: w, height: height, done: make(chan []byte)}
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		r.Close()
+		p.done <- buf.Bytes()
+	}()
+	term.SetTerminal(os.Stdout)
+	os.Stdout = w
+
+	return p
+}
+
+// Stop restores stdout and writes the collected output, through the pager when it is
+// taller than the terminal
+func (p *Pager) Stop() error {
+	if p == nil {
+		return nil
+	}
+
+	p.writer.Close()
+	os.Stdout = p.stdout
+	term.SetTerminal(nil)
+	output := <-p.done
+
+	if !exceeds(output, p.height) {
+		_, err := p.stdout.Write(output)
+		return err
+	}
+
+	if err := run(Command(), output, p.stdout); err != nil {
+		// Output must never be lost because the pager is broken
+		fmt.Fprintf(os.Stderr, "Warning: pager %q failed: %v\n", Command(), err)
+		_, err = p.stdout.Write(output)
+		return err
+	}
+
+	return nil
+}
+
+// exceeds reports whether output has more lines than fit in height rows; one row is
+// kept free for the prompt shown after the output
+func exceeds(output []byte, height int) bool {
+	return bytes.Count(output, []byte("\n")) >= height
+}
+
+// run pipes output into the pager command
+func run(command string, output []byte, stdout *os.File) error {
+	fields := strings.Fields(command)
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	// Like git, let less show colors and quit on short output unless told otherwise
+	if _, set := os.LookupEnv("LESS"); !set {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	return cmd.Run()
+}
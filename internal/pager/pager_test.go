@@ -0,0 +1,73 @@
+package pager
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	originalPager, hadPager := os.LookupEnv("PAGER")
+	defer func() {
+		if hadPager {
+			os.Setenv("PAGER", originalPager)
+		} else {
+			os.Unsetenv("PAGER")
+		}
+	}()
+
+	t.Run("Defaults to less", func(t *testing.T) {
+		os.Unsetenv("PAGER")
+		if command := Command(); command != DefaultCommand {
+			t.Errorf("Expected %s, got %s", DefaultCommand, command)
+		}
+	})
+
+	t.Run("Uses PAGER", func(t *testing.T) {
+		os.Setenv("PAGER", "most")
+		if command := Command(); command != "most" {
+			t.Errorf("Expected most, got %s", command)
+		}
+	})
+
+	t.Run("Empty PAGER or cat disables paging", func(t *testing.T) {
+		for _, value := range []string{"", "cat"} {
+			os.Setenv("PAGER", value)
+			if command := Command(); command != "" {
+				t.Errorf("Expected no pager for PAGER=%q, got %s", value, command)
+			}
+		}
+	})
+}
+
+func TestExceeds(t *testing.T) {
+	output := []byte("one\ntwo\nthree\n")
+	if exceeds(output, 4) {
+		t.Error("Expected 3 lines to fit in 4 rows")
+	}
+	if !exceeds(output, 3) {
+		t.Error("Expected 3 lines not to fit in 3 rows, which leaves no room for the prompt")
+	}
+}
+
+func TestStartWithoutTerminal(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	originalStdout := os.Stdout
+	os.Stdout = file
+	defer func() { os.Stdout = originalStdout }()
+
+	p := Start()
+	if p != nil {
+		t.Error("Expected no pager when stdout is not a terminal")
+	}
+	if os.Stdout != file {
+		t.Error("Expected stdout to be left alone")
+	}
+	if err := p.Stop(); err != nil {
+		t.Errorf("Expected Stop on nil pager to succeed, got: %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package presets
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain keeps the journal written by mutating runs out of the real state directory
+func TestMain(m *testing.M) {
+	stateDir, err := os.MkdirTemp("", "dot-state")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_STATE_HOME", stateDir)
+
+	code := m.Run()
+
+	os.RemoveAll(stateDir)
+	os.Exit(code)
+}
@@ -0,0 +1,138 @@
+package presets
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yourusername/dot/internal/config"
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/journal"
+	"github.com/yourusername/dot/internal/linker"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+//go:embed presets.toml
+var presetsData string
+
+// File is a single file or directory managed by a preset
+type File struct {
+	config.Entry
+	Source string `toml:"source"`
+	Dir    bool   `toml:"dir"`
+}
+
+// Preset describes the canonical configuration locations of a tool
+type Preset struct {
+	Description string `toml:"description"`
+	Files       []File `toml:"files"`
+}
+
+// Load returns all bundled presets keyed by name
+func Load() (map[string]Preset, error) {
+	var presets map[string]Preset
+	if _, err := toml.Decode(presetsData, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse bundled presets: %w", err)
+	}
+	return presets, nil
+}
+
+// Names returns the sorted names of the bundled presets
+func Names(presets map[string]Preset) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Add applies a preset to the dotfiles repository under the given profile
+// Existing files at the target are adopted into the repository, otherwise an empty
+// placeholder is created so the mapping is ready to be filled in and linked
+func Add(name, profile string) error {
+	presets, err := Load()
+	if err != nil {
+		return err
+	}
+
+	preset, exists := presets[name]
+	if !exists {
+		return fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(Names(presets), ", "))
+	}
+
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ParseConfig(dotfilesDir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range preset.Files {
+		file.Source = cfg.RepoSource(profile, file.Source)
+		target := file.TargetFor(runtime.GOOS)
+		if target == "" {
+			fmt.Fprintf(os.Stderr, "Skipped (not used on %s): %s\n", runtime.GOOS, file.Source)
+			continue
+		}
+
+		if _, exists := cfg.Profiles[profile][file.Source]; exists {
+			fmt.Fprintf(os.Stderr, "Skipped (already mapped): %s\n", file.Source)
+			continue
+		}
+
+		if err := addFile(dotfilesDir, profile, file, target); err != nil {
+			fmt.Fprintf(os.Stderr, "Error adding %s: %v\n", file.Source, err)
+		}
+	}
+
+	return nil
+}
+
+// addFile adopts or scaffolds a single preset file and registers its mapping
+func addFile(dotfilesDir, profile string, file File, target string) error {
+	sourcePath := filepath.Join(dotfilesDir, filepath.FromSlash(file.Source))
+	targetPath := utils.ExpandTarget(target)
+
+	_, targetErr := os.Lstat(targetPath)
+	isLink, _ := utils.IsSymlink(targetPath)
+
+	if targetErr == nil && !isLink && !utils.FileExists(sourcePath) {
+		return linker.AdoptEntry(targetPath, file.Source, profile, config.Entry{Target: file.Target, Targets: file.Targets})
+	}
+
+	if !utils.FileExists(sourcePath) {
+		if err := createPlaceholder(sourcePath, file.Dir); err != nil {
+			return err
+		}
+		utils.FprintfColor(os.Stderr, "blue", "Created: %s\n", sourcePath)
+	}
+
+	if err := config.AddEntry(dotfilesDir, profile, file.Source, config.Entry{Target: file.Target, Targets: file.Targets}); err != nil {
+		return err
+	}
+	journal.Record("add", []string{profile}, []journal.Action{journal.NewAction(journal.OpMap, file.Source, target)})
+
+	utils.FprintfColor(os.Stderr, "green", "Mapped: %s -> %s\n", file.Source, target)
+	return nil
+}
+
+// createPlaceholder creates an empty directory or file in the repository
+func createPlaceholder(path string, dir bool) error {
+	if dir {
+		return os.MkdirAll(path, 0755)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, nil, 0644)
+}
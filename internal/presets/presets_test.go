@@ -0,0 +1,142 @@
+package presets
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/yourusername/dot/internal/config"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("Bundled presets are well formed", func(t *testing.T) {
+		presets, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		for _, name := range []string{"nvim", "tmux", "zsh", "git", "alacritty", "ssh"} {
+			if _, exists := presets[name]; !exists {
+				t.Errorf("Expected preset %s to be bundled", name)
+			}
+		}
+
+		for name, preset := range presets {
+			if len(preset.Files) == 0 {
+				t.Errorf("Preset %s has no files", name)
+			}
+			for _, file := range preset.Files {
+				if file.Source == "" {
+					t.Errorf("Preset %s has a file without source", name)
+				}
+				if file.TargetFor("linux") == "" && file.TargetFor("darwin") == "" && file.TargetFor("windows") == "" {
+					t.Errorf("Preset %s file %s has no target", name, file.Source)
+				}
+			}
+		}
+	})
+}
+
+func TestFileTargetFor(t *testing.T) {
+	file := File{Entry: config.Entry{Target: "~/.config/nvim", Targets: map[string]string{"windows": "~/AppData/Local/nvim"}}}
+
+	if file.TargetFor("linux") != "~/.config/nvim" {
+		t.Errorf("Expected default target on linux, got %s", file.TargetFor("linux"))
+	}
+	if file.TargetFor("windows") != "~/AppData/Local/nvim" {
+		t.Errorf("Expected windows override, got %s", file.TargetFor("windows"))
+	}
+}
+
+func TestAdd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("preset targets differ on windows")
+	}
+
+	tempDir := t.TempDir()
+	homeDir := filepath.Join(tempDir, "home")
+	dotfilesDir := filepath.Join(tempDir, "dotfiles")
+
+	originalHome := os.Getenv("HOME")
+	originalDotDir := os.Getenv("DOT_DIR")
+	defer func() {
+		os.Setenv("HOME", originalHome)
+		if originalDotDir != "" {
+			os.Setenv("DOT_DIR", originalDotDir)
+		} else {
+			os.Unsetenv("DOT_DIR")
+		}
+	}()
+	os.Setenv("HOME", homeDir)
+	os.Setenv("DOT_DIR", dotfilesDir)
+
+	if err := os.MkdirAll(dotfilesDir, 0755); err != nil {
+		t.Fatalf("Failed to create dotfiles dir: %v", err)
+	}
+	if err := os.MkdirAll(homeDir, 0755); err != nil {
+		t.Fatalf("Failed to create home dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte("[general]\n"), 0644); err != nil {
+		t.Fatalf("Failed to create .mappings: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(homeDir, ".zshrc"), []byte("existing"), 0644); err != nil {
+		t.Fatalf("Failed to create .zshrc: %v", err)
+	}
+
+	t.Run("Adopts existing files and scaffolds missing ones", func(t *testing.T) {
+		if err := Add("zsh", "general"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dotfilesDir, "zsh", ".zshrc"))
+		if err != nil || string(data) != "existing" {
+			t.Errorf("Expected existing .zshrc to be adopted, got %q (%v)", data, err)
+		}
+		if _, err := os.Stat(filepath.Join(dotfilesDir, "zsh", ".zshenv")); err != nil {
+			t.Errorf("Expected placeholder .zshenv to be created: %v", err)
+		}
+
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Failed to parse config: %v", err)
+		}
+		if cfg.Profiles["general"]["zsh/.zshrc"] != "~/.zshrc" || cfg.Profiles["general"]["zsh/.zshenv"] != "~/.zshenv" {
+			t.Errorf("Expected zsh mappings, got %v", cfg.Profiles["general"])
+		}
+	})
+
+	t.Run("Writes the targets of every OS", func(t *testing.T) {
+		existing := filepath.Join(homeDir, ".config", "alacritty", "alacritty.toml")
+		if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(existing, []byte("existing"), 0644); err != nil {
+			t.Fatalf("Failed to create alacritty.toml: %v", err)
+		}
+		if err := Add("alacritty", "general"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		cfg, err := config.ParseConfig(dotfilesDir)
+		if err != nil {
+			t.Fatalf("Failed to parse config: %v", err)
+		}
+		entry := cfg.Entries["general"]["alacritty/alacritty.toml"]
+		if entry.Target != "~/.config/alacritty/alacritty.toml" || entry.Targets["windows"] != "~/AppData/Roaming/alacritty/alacritty.toml" {
+			t.Errorf("Expected the default and windows targets, got %+v", entry)
+		}
+	})
+
+	t.Run("Second run skips mapped files", func(t *testing.T) {
+		if err := Add("zsh", "general"); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Unknown preset", func(t *testing.T) {
+		if err := Add("nonexistent", "general"); err == nil {
+			t.Error("Expected error for unknown preset")
+		}
+	})
+}
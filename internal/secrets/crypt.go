@@ -0,0 +1,212 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Backend is the tool a secret is encrypted with, named by the extension of its file
+type Backend string
+
+const (
+	// Age encrypts to the recipients of the repository and decrypts with the identities
+	// of this machine, see dot keys
+	Age Backend = "age"
+	// GPG encrypts to GnuPG keys and decrypts with the keyring and agent of this machine
+	GPG Backend = "gpg"
+)
+
+// Ext returns the extension that marks a file encrypted with the backend, e.g. .age
+func (b Backend) Ext() string {
+	return "." + string(b)
+}
+
+// BackendOf returns the backend a file is encrypted with by its extension, .age or .gpg,
+// and false for a file that is not encrypted
+func BackendOf(name string) (Backend, bool) {
+	for _, b := range []Backend{Age, GPG} {
+		if strings.HasSuffix(name, b.Ext()) && len(name) > len(b.Ext()) {
+			return b, true
+		}
+	}
+	return "", false
+}
+
+// runTool runs an external tool with stdin and returns what it printed; install tells
+// where to get the tool when it is not installed
+func runTool(name, install string, stdin []byte, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s not found; install %s", name, install)
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %s", name, message)
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// runAge runs age, the tool of the Age backend
+func runAge(stdin []byte, args ...string) ([]byte, error) {
+	return runTool("age", "age from https://age-encryption.org", stdin, args...)
+}
+
+// runGPG runs gpg, the tool of the GPG backend
+func runGPG(stdin []byte, args ...string) ([]byte, error) {
+	return runTool("gpg", "GnuPG from https://gnupg.org", stdin, args...)
+}
+
+// EncryptData encrypts plaintext with the backend: with age to the recipients of the
+// repository, with gpg to the given key IDs or, without any, to the default key
+func EncryptData(backend Backend, plaintext []byte, gpgRecipients []string) ([]byte, error) {
+	if backend == GPG {
+		args := []string{"--quiet", "--batch", "--yes", "--encrypt"}
+		if len(gpgRecipients) == 0 {
+			args = append(args, "--default-recipient-self")
+		}
+		for _, recipient := range gpgRecipients {
+			args = append(args, "--recipient", recipient)
+		}
+		return runGPG(plaintext, args...)
+	}
+
+	recipients, err := Recipients()
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients in %s; run dot keys generate to create one", RecipientsFile)
+	}
+	path, err := recipientsPath()
+	if err != nil {
+		return nil, err
+	}
+	return runAge(plaintext, "--encrypt", "--recipients-file", path)
+}
+
+// DecryptData decrypts the content of the encrypted file name with the backend its
+// extension names: age with this machine's identities, gpg with its keyring
+func DecryptData(name string, ciphertext []byte) ([]byte, error) {
+	backend, ok := BackendOf(name)
+	if !ok {
+		return nil, fmt.Errorf("%s is not encrypted, expected a %s or %s file", name, Age.Ext(), GPG.Ext())
+	}
+	if backend == GPG {
+		return runGPG(ciphertext, "--quiet", "--decrypt")
+	}
+
+	identities, err := Identities()
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, fmt.Errorf("this machine has no identity to decrypt %s; run dot keys generate or dot keys add --identity", name)
+	}
+	path, err := IdentitiesPath()
+	if err != nil {
+		return nil, err
+	}
+	return runAge(ciphertext, "--decrypt", "--identity", path)
+}
+
+// EncryptOptions controls how Encrypt encrypts files
+type EncryptOptions struct {
+	// GPG encrypts with gpg instead of age
+	GPG bool
+	// Recipients are the gpg key IDs to encrypt to, the default key if empty
+	Recipients []string
+}
+
+// Encrypt writes an encrypted copy of each file next to it, named with the extension of
+// the backend, e.g. ssh/config.age, replacing an earlier copy
+// The plaintext is kept; one inside the dotfiles repository is warned about, as it should
+// not be committed
+func Encrypt(paths []string, opts EncryptOptions) error {
+	backend := Age
+	if opts.GPG {
+		backend = GPG
+	} else if len(opts.Recipients) > 0 {
+		return fmt.Errorf("recipients are only given to gpg; age encrypts to the recipients in %s", RecipientsFile)
+	}
+	dotfilesDir, _ := dotfiles.GetDotfilesDir()
+
+	for _, path := range paths {
+		path = utils.ExpandPath(path)
+		if _, encrypted := BackendOf(path); encrypted {
+			return fmt.Errorf("%s is encrypted already", path)
+		}
+		plaintext, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		ciphertext, err := EncryptData(backend, plaintext, opts.Recipients)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %s: %w", path, err)
+		}
+		encryptedPath := path + backend.Ext()
+		if err := os.WriteFile(encryptedPath, ciphertext, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", encryptedPath, err)
+		}
+		utils.FprintfColor(os.Stderr, "green", "Encrypted: %s -> %s\n", path, encryptedPath)
+
+		if abs, err := filepath.Abs(path); err == nil && dotfilesDir != "" && strings.HasPrefix(abs, dotfilesDir+string(filepath.Separator)) {
+			utils.FprintfColor(os.Stderr, "yellow", "Warning: %s is not encrypted; remove it or add it to .gitignore before committing\n", path)
+		}
+	}
+	return nil
+}
+
+// DecryptOptions controls where Decrypt puts the plaintext
+type DecryptOptions struct {
+	// Stdout prints the plaintext instead of writing it to a file
+	Stdout bool
+}
+
+// Decrypt writes the plaintext of each encrypted file next to it without the extension
+// of its backend, readable only by the user, e.g. to edit it and encrypt it again
+// A file already at that path is never overwritten
+func Decrypt(paths []string, opts DecryptOptions) error {
+	for _, path := range paths {
+		path = utils.ExpandPath(path)
+		backend, encrypted := BackendOf(path)
+		if !encrypted {
+			return fmt.Errorf("%s is not encrypted, expected a %s or %s file", path, Age.Ext(), GPG.Ext())
+		}
+		decryptedPath := strings.TrimSuffix(path, backend.Ext())
+		if !opts.Stdout && utils.FileExists(decryptedPath) {
+			return fmt.Errorf("%s already exists; remove it or use --stdout", decryptedPath)
+		}
+
+		ciphertext, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		plaintext, err := DecryptData(path, ciphertext)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s: %w", path, err)
+		}
+		if opts.Stdout {
+			os.Stdout.Write(plaintext)
+			continue
+		}
+		if err := os.WriteFile(decryptedPath, plaintext, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", decryptedPath, err)
+		}
+		utils.FprintfColor(os.Stderr, "green", "Decrypted: %s -> %s\n", path, filepath.Base(decryptedPath))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeAge "encrypts" by prefixing the input with the recipients file it was given, and
+// "decrypts" by dropping that first line
+const fakeAge = `#!/bin/sh
+case "$1" in
+--encrypt) echo "age to $3"; cat ;;
+--decrypt) tail -n +2 ;;
+esac
+`
+
+func TestBackendOf(t *testing.T) {
+	tests := map[string]Backend{
+		"ssh/config.age": Age,
+		"aws/creds.gpg":  GPG,
+		"ssh/config":     "",
+		".age":           "",
+	}
+	for name, expected := range tests {
+		if backend, _ := BackendOf(name); backend != expected {
+			t.Errorf("Expected BackendOf(%q) to be %q, got %q", name, expected, backend)
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake age is a shell script")
+	}
+
+	originalPath := os.Getenv("PATH")
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		os.Setenv("PATH", originalPath)
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+
+	binDir := t.TempDir()
+	os.WriteFile(filepath.Join(binDir, "age"), []byte(fakeAge), 0755)
+	os.Setenv("PATH", binDir+string(os.PathListSeparator)+originalPath)
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+	dotfilesDir := t.TempDir()
+	os.Setenv("DOT_DIR", dotfilesDir)
+	file := filepath.Join(dotfilesDir, "config")
+	os.WriteFile(file, []byte("Host *\n"), 0644)
+
+	t.Run("Encrypt needs recipients", func(t *testing.T) {
+		err := Encrypt([]string{file}, EncryptOptions{})
+		if err == nil || !strings.Contains(err.Error(), "no recipients") {
+			t.Errorf("Expected a missing recipients error, got %v", err)
+		}
+	})
+
+	t.Run("Encrypt writes the file with the extension of the backend", func(t *testing.T) {
+		AddRecipients([]string{"age1laptop"}, "laptop")
+
+		if err := Encrypt([]string{file}, EncryptOptions{}); err != nil {
+			t.Fatalf("Encrypt failed: %v", err)
+		}
+		data, _ := os.ReadFile(file + ".age")
+		expected := "age to " + filepath.Join(dotfilesDir, RecipientsFile) + "\nHost *\n"
+		if string(data) != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, data)
+		}
+	})
+
+	t.Run("Decrypt needs an identity", func(t *testing.T) {
+		os.Remove(file)
+
+		err := Decrypt([]string{file + ".age"}, DecryptOptions{})
+		if err == nil || !strings.Contains(err.Error(), "no identity") {
+			t.Errorf("Expected a missing identity error, got %v", err)
+		}
+	})
+
+	t.Run("Decrypt writes the plaintext readable only by the user", func(t *testing.T) {
+		appendIdentities([]Identity{{Recipient: "age1laptop", secret: identityPrefix + "1LAPTOP"}})
+
+		if err := Decrypt([]string{file + ".age"}, DecryptOptions{}); err != nil {
+			t.Fatalf("Decrypt failed: %v", err)
+		}
+		if data, _ := os.ReadFile(file); string(data) != "Host *\n" {
+			t.Errorf("Expected the plaintext, got '%s'", data)
+		}
+		if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("Expected mode 0600, got %v (%v)", info, err)
+		}
+	})
+
+	t.Run("Decrypt does not overwrite a file", func(t *testing.T) {
+		err := Decrypt([]string{file + ".age"}, DecryptOptions{})
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected an existing file error, got %v", err)
+		}
+	})
+
+	t.Run("Recipients are only given to gpg", func(t *testing.T) {
+		err := Encrypt([]string{file}, EncryptOptions{Recipients: []string{"me@example.com"}})
+		if err == nil {
+			t.Error("Expected an error for recipients without --gpg")
+		}
+	})
+}
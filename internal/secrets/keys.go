@@ -0,0 +1,374 @@
+package secrets
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yourusername/dot/internal/dotfiles"
+	"github.com/yourusername/dot/internal/table"
+	"github.com/yourusername/dot/internal/term"
+	"github.com/yourusername/dot/internal/utils"
+	"github.com/yourusername/dot/internal/xdg"
+)
+
+// RecipientsFile lists, in the dotfiles repository, the age recipients that secrets are
+// encrypted to, one per line in the format age -R reads
+// A comment line right before a recipient names the machine it belongs to
+const RecipientsFile = ".age-recipients"
+
+// identityPrefix starts the secret key line of an age identity
+const identityPrefix = "AGE-SECRET-KEY-"
+
+// Identity is an age private key held by this machine
+type Identity struct {
+	// Recipient is the public key that secrets are encrypted to for this identity
+	Recipient string
+	// Created is when age-keygen made the identity, "" for imported keys without the comment
+	Created string
+	secret  string
+}
+
+// Recipient is a public key listed in the repository's recipients file
+type Recipient struct {
+	Key  string
+	Name string // the machine or person it belongs to, "" if not named
+}
+
+// IdentitiesPath returns the location of this machine's age identities
+// It lives under $XDG_STATE_HOME/dot, falling back to ~/.local/state/dot, and is only
+// readable by the user
+func IdentitiesPath() (string, error) {
+	return xdg.State.Path("identities.txt")
+}
+
+// ageKeygen runs age-keygen with input on stdin and returns what it printed
+func ageKeygen(input string, args ...string) (string, error) {
+	output, err := runTool("age-keygen", "age from https://age-encryption.org", []byte(input), args...)
+	return string(output), err
+}
+
+// parseIdentities returns the identities in the content of an identity file, taking the
+// recipient and creation time from the comments age-keygen writes before each key
+// Keys without a public key comment get their recipient from age-keygen -y
+func parseIdentities(data string) ([]Identity, error) {
+	var identities []Identity
+	var current Identity
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "# created:"):
+			current.Created = strings.TrimSpace(strings.TrimPrefix(line, "# created:"))
+		case strings.HasPrefix(line, "# public key:"):
+			current.Recipient = strings.TrimSpace(strings.TrimPrefix(line, "# public key:"))
+		case strings.HasPrefix(line, identityPrefix):
+			current.secret = line
+			if current.Recipient == "" {
+				recipient, err := ageKeygen(line+"\n", "-y")
+				if err != nil {
+					return nil, err
+				}
+				current.Recipient = strings.TrimSpace(recipient)
+			}
+			identities = append(identities, current)
+			current = Identity{}
+		}
+	}
+	return identities, scanner.Err()
+}
+
+// Identities returns the age identities of this machine, none if it has no identity file
+func Identities() ([]Identity, error) {
+	path, err := IdentitiesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identities: %w", err)
+	}
+	return parseIdentities(string(data))
+}
+
+// appendIdentities adds identities to this machine's identity file, creating it readable
+// only by the user
+func appendIdentities(identities []Identity) error {
+	path, err := IdentitiesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open identities: %w", err)
+	}
+	defer f.Close()
+	for _, identity := range identities {
+		if identity.Created != "" {
+			fmt.Fprintf(f, "# created: %s\n", identity.Created)
+		}
+		fmt.Fprintf(f, "# public key: %s\n%s\n", identity.Recipient, identity.secret)
+	}
+	return f.Close()
+}
+
+// recipientsPath returns the recipients file of the dotfiles repository, which must exist
+func recipientsPath() (string, error) {
+	dotfilesDir, err := dotfiles.GetDotfilesDir()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dotfilesDir); os.IsNotExist(err) {
+		return "", fmt.Errorf("dotfiles directory %s does not exist", dotfilesDir)
+	}
+	return filepath.Join(dotfilesDir, RecipientsFile), nil
+}
+
+// Recipients returns the recipients listed in the dotfiles repository, none if it has
+// no recipients file
+func Recipients() ([]Recipient, error) {
+	path, err := recipientsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", RecipientsFile, err)
+	}
+
+	var recipients []Recipient
+	name := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			name = ""
+		case strings.HasPrefix(line, "#"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		default:
+			recipients = append(recipients, Recipient{Key: line, Name: name})
+			name = ""
+		}
+	}
+	return recipients, nil
+}
+
+// ValidRecipient reports whether key is an age or SSH public key that age encrypts to
+func ValidRecipient(key string) bool {
+	if strings.HasPrefix(key, "age1") {
+		return !strings.ContainsAny(key, " \t")
+	}
+	return strings.HasPrefix(key, "ssh-ed25519 ") || strings.HasPrefix(key, "ssh-rsa ")
+}
+
+// addRecipient appends key to the repository's recipients file under name; it reports
+// false when the key is listed already
+func addRecipient(key, name string) (bool, error) {
+	if !ValidRecipient(key) {
+		return false, fmt.Errorf("invalid recipient %q, expected an age public key (age1...) or an SSH public key", key)
+	}
+	recipients, err := Recipients()
+	if err != nil {
+		return false, err
+	}
+	if slices.ContainsFunc(recipients, func(r Recipient) bool { return r.Key == key }) {
+		return false, nil
+	}
+
+	path, err := recipientsPath()
+	if err != nil {
+		return false, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", RecipientsFile, err)
+	}
+	defer f.Close()
+	if name != "" {
+		fmt.Fprintf(f, "# %s\n", name)
+	}
+	fmt.Fprintln(f, key)
+	return true, f.Close()
+}
+
+// hostname names this machine's recipient unless a name is given
+func hostname(name string) string {
+	if name != "" {
+		return name
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// register adds the recipient of an identity to the repository, reporting what it did
+func register(identity Identity, name string) error {
+	added, err := addRecipient(identity.Recipient, hostname(name))
+	if err != nil {
+		return err
+	}
+	if added {
+		utils.FprintfColor(os.Stderr, "green", "Added recipient to %s: %s\n", RecipientsFile, identity.Recipient)
+	}
+	return nil
+}
+
+// Generate creates a new age identity for this machine, stores it in the state directory,
+// and adds its recipient to the repository's recipients file under name, the hostname
+// if empty; the recipient is printed to stdout
+func Generate(name string) error {
+	output, err := ageKeygen("")
+	if err != nil {
+		return err
+	}
+	identities, err := parseIdentities(output)
+	if err != nil {
+		return err
+	}
+	if len(identities) != 1 {
+		return fmt.Errorf("age-keygen did not print an identity")
+	}
+	if err := appendIdentities(identities); err != nil {
+		return err
+	}
+
+	path, _ := IdentitiesPath()
+	utils.FprintfColor(os.Stderr, "green", "Generated identity in %s\n", path)
+	fmt.Println(identities[0].Recipient)
+	return register(identities[0], name)
+}
+
+// Import stores the identities in an existing age identity file as this machine's and
+// adds their recipients to the repository under name, the hostname if empty
+// Identities this machine holds already are skipped
+func Import(path, name string) error {
+	data, err := os.ReadFile(utils.ExpandPath(path))
+	if err != nil {
+		return err
+	}
+	found, err := parseIdentities(string(data))
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("no age identity (%s...) in %s", identityPrefix, path)
+	}
+
+	held, err := Identities()
+	if err != nil {
+		return err
+	}
+	var added []Identity
+	for _, identity := range found {
+		if slices.ContainsFunc(held, func(h Identity) bool { return h.Recipient == identity.Recipient }) {
+			fmt.Fprintf(os.Stderr, "Skipped (already held): %s\n", identity.Recipient)
+			continue
+		}
+		added = append(added, identity)
+	}
+	if err := appendIdentities(added); err != nil {
+		return err
+	}
+
+	for _, identity := range found {
+		if slices.Contains(added, identity) {
+			utils.FprintfColor(os.Stderr, "green", "Imported identity: %s\n", identity.Recipient)
+		}
+		if err := register(identity, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddRecipients adds public keys, e.g. of another machine or a teammate, to the
+// repository's recipients file under name
+func AddRecipients(keys []string, name string) error {
+	for _, key := range keys {
+		added, err := addRecipient(strings.TrimSpace(key), name)
+		if err != nil {
+			return err
+		}
+		if added {
+			utils.FprintfColor(os.Stderr, "green", "Added recipient to %s: %s\n", RecipientsFile, key)
+		} else {
+			fmt.Fprintf(os.Stderr, "Skipped (already listed): %s\n", key)
+		}
+	}
+	return nil
+}
+
+// List shows the repository's recipients together with the identities of this machine,
+// marking the recipients this machine can decrypt for and identities not yet listed
+func List() error {
+	identities, err := Identities()
+	if err != nil {
+		return err
+	}
+	recipients, err := Recipients()
+	if err != nil {
+		return err
+	}
+	if len(identities) == 0 && len(recipients) == 0 {
+		fmt.Fprintln(os.Stderr, "No identities or recipients; run dot keys generate to create one")
+		return nil
+	}
+
+	held := func(key string) bool {
+		return slices.ContainsFunc(identities, func(i Identity) bool { return i.Recipient == key })
+	}
+	t := table.New("RECIPIENT", "NAME", "STATUS")
+	t.Truncatable(0)
+	for _, r := range recipients {
+		status := ""
+		if held(r.Key) {
+			status = "this machine"
+		}
+		t.Append(r.Key, r.Name, status)
+	}
+	for _, identity := range identities {
+		if !slices.ContainsFunc(recipients, func(r Recipient) bool { return r.Key == identity.Recipient }) {
+			t.Append(identity.Recipient, "", "this machine, not in "+RecipientsFile)
+		}
+	}
+	return t.Render(os.Stdout, term.Width())
+}
+
+// Export prints the recipients of this machine's identities, or with secret the
+// identities themselves, to move them to another machine with dot keys add --identity
+func Export(secret bool) error {
+	identities, err := Identities()
+	if err != nil {
+		return err
+	}
+	if len(identities) == 0 {
+		return fmt.Errorf("this machine has no identity; run dot keys generate to create one")
+	}
+
+	if secret {
+		utils.FprintfColor(os.Stderr, "yellow", "Warning: the output decrypts every secret encrypted to these identities; keep it private\n")
+	}
+	for _, identity := range identities {
+		if !secret {
+			fmt.Println(identity.Recipient)
+			continue
+		}
+		if identity.Created != "" {
+			fmt.Printf("# created: %s\n", identity.Created)
+		}
+		fmt.Printf("# public key: %s\n%s\n", identity.Recipient, identity.secret)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeKeygen prints a new identity on every run, numbered by the runs so far, and derives
+// the recipient of a key on stdin with -y
+const fakeKeygen = `#!/bin/sh
+if [ "$1" = "-y" ]; then
+	read key
+	echo "age1derived$(echo "$key" | tr -dc '0-9')"
+	exit 0
+fi
+echo run >> "$CALLS"
+n=$(wc -l < "$CALLS" | tr -d ' ')
+echo "# created: 2024-05-01T12:00:00Z"
+echo "# public key: age1generated$n"
+echo "AGE-SECRET-KEY-1GENERATED$n"
+`
+
+func TestKeys(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake age-keygen is a shell script")
+	}
+
+	originalPath := os.Getenv("PATH")
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		os.Setenv("PATH", originalPath)
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+		os.Unsetenv("CALLS")
+	}()
+
+	setup := func(t *testing.T) string {
+		binDir := t.TempDir()
+		os.WriteFile(filepath.Join(binDir, "age-keygen"), []byte(fakeKeygen), 0755)
+		os.Setenv("PATH", binDir+string(os.PathListSeparator)+originalPath)
+		os.Setenv("CALLS", filepath.Join(t.TempDir(), "calls"))
+		os.Setenv("XDG_STATE_HOME", t.TempDir())
+		dotfilesDir := t.TempDir()
+		os.Setenv("DOT_DIR", dotfilesDir)
+		return dotfilesDir
+	}
+
+	t.Run("Generate stores the identity and registers its recipient", func(t *testing.T) {
+		dotfilesDir := setup(t)
+
+		if err := Generate("laptop"); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+
+		path, _ := IdentitiesPath()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Expected the identity file to exist: %v", err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Expected mode 0600, got %o", info.Mode().Perm())
+		}
+		identities, err := Identities()
+		if err != nil || len(identities) != 1 || identities[0].Recipient != "age1generated1" {
+			t.Fatalf("Expected the generated identity, got %v (%v)", identities, err)
+		}
+
+		data, _ := os.ReadFile(filepath.Join(dotfilesDir, RecipientsFile))
+		if string(data) != "# laptop\nage1generated1\n" {
+			t.Errorf("Expected the recipient to be registered, got %q", data)
+		}
+	})
+
+	t.Run("A second identity is appended", func(t *testing.T) {
+		setup(t)
+
+		Generate("laptop")
+		if err := Generate("laptop"); err != nil {
+			t.Fatalf("Generate failed: %v", err)
+		}
+		recipients, _ := Recipients()
+		if len(recipients) != 2 || recipients[1].Key != "age1generated2" || recipients[1].Name != "laptop" {
+			t.Errorf("Expected two recipients, got %v", recipients)
+		}
+	})
+
+	t.Run("Add lists recipients once", func(t *testing.T) {
+		setup(t)
+
+		if err := AddRecipients([]string{"age1desktop", "ssh-ed25519 AAAAC3Nz me@work"}, "desktop"); err != nil {
+			t.Fatalf("AddRecipients failed: %v", err)
+		}
+		if err := AddRecipients([]string{"age1desktop"}, "desktop"); err != nil {
+			t.Fatalf("AddRecipients failed: %v", err)
+		}
+		recipients, _ := Recipients()
+		if len(recipients) != 2 {
+			t.Errorf("Expected two recipients, got %v", recipients)
+		}
+	})
+
+	t.Run("Add rejects what is not a public key", func(t *testing.T) {
+		setup(t)
+
+		err := AddRecipients([]string{"AGE-SECRET-KEY-1OOPS"}, "")
+		if err == nil || !strings.Contains(err.Error(), "invalid recipient") {
+			t.Errorf("Expected an invalid recipient error, got %v", err)
+		}
+	})
+
+	t.Run("Import takes identities without a public key comment", func(t *testing.T) {
+		dotfilesDir := setup(t)
+		file := filepath.Join(t.TempDir(), "key.txt")
+		os.WriteFile(file, []byte("AGE-SECRET-KEY-1KEY7\n"), 0600)
+
+		if err := Import(file, "old laptop"); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		if err := Import(file, "old laptop"); err != nil {
+			t.Fatalf("Import failed: %v", err)
+		}
+		identities, _ := Identities()
+		if len(identities) != 1 || identities[0].Recipient != "age1derived17" {
+			t.Errorf("Expected one imported identity, got %v", identities)
+		}
+		data, _ := os.ReadFile(filepath.Join(dotfilesDir, RecipientsFile))
+		if string(data) != "# old laptop\nage1derived17\n" {
+			t.Errorf("Expected the recipient to be registered once, got %q", data)
+		}
+	})
+
+	t.Run("Export needs an identity", func(t *testing.T) {
+		setup(t)
+
+		if err := Export(false); err == nil {
+			t.Error("Expected an error without identities")
+		}
+	})
+}
+
+func TestValidRecipient(t *testing.T) {
+	tests := map[string]bool{
+		"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p": true,
+		"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAI me@laptop":                true,
+		"age1 with spaces":      false,
+		"AGE-SECRET-KEY-1ABC":   false,
+		"ecdsa-sha2-nistp256 A": false,
+	}
+	for key, expected := range tests {
+		if result := ValidRecipient(key); result != expected {
+			t.Errorf("Expected ValidRecipient(%q) to be %v, got %v", key, expected, result)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yourusername/dot/internal/utils"
+	"github.com/yourusername/dot/internal/xdg"
+)
+
+// Settings is the global configuration of dot, shared by every dotfiles repository
+type Settings struct {
+	// DotfilesDir is the dotfiles repository used when $DOT_DIR is not set, instead of
+	// ~/.dotfiles
+	DotfilesDir string `toml:"dotfiles_dir,omitempty"`
+	// Profiles are the profiles commands use when --profile is not given, instead of general
+	Profiles []string `toml:"profiles,omitempty"`
+	// Color is when output is colored: "auto" on a terminal unless $NO_COLOR is set,
+	// "always", or "never"
+	Color string `toml:"color,omitempty"`
+	// BackupDir is the backup store link moves files out of the way to, beneath a
+	// directory for each run and then their absolute path, instead of backups in the XDG
+	// data directory
+	BackupDir string `toml:"backup_dir,omitempty"`
+	// AdjacentBackups makes link move files out of the way next to their target as
+	// <target>.bak, replacing an earlier backup, instead of to the backup store
+	AdjacentBackups bool `toml:"adjacent_backups,omitempty"`
+	// Strict makes link fail on missing sources, as if --strict was always given
+	Strict bool `toml:"strict,omitempty"`
+	// VerifySignatures makes clone, update, and link refuse a repository whose commit is
+	// not signed by a key git trusts, or tagged by a signed tag
+	VerifySignatures bool `toml:"verify_signatures,omitempty"`
+	// OnConflict is what link does with a file or another link at a target unless
+	// --on-conflict is given: "backup", "skip", "overwrite", "adopt", or "prompt"
+	OnConflict string `toml:"on_conflict,omitempty"`
+	// Protected lists paths that link and clean never back up, replace, or delete, in
+	// addition to ~/.ssh/authorized_keys and ~/.gnupg/private-keys-v1.d
+	Protected []string `toml:"protected,omitempty"`
+	// Remotes maps remote names to URLs that the dotfiles repository is pushed to
+	Remotes map[string]string `toml:"remotes,omitempty"`
+	// AllowedRemotes restricts the URLs repositories are cloned from, pulled from, and
+	// pushed to, to those matching one of these patterns, where * matches any text, e.g.
+	// "git@github.corp:*"; empty allows every URL
+	AllowedRemotes []string `toml:"allowed_remotes,omitempty"`
+	// Hooks are the defaults for running the hooks of mappings
+	Hooks Hooks `toml:"hooks,omitempty"`
+	// Network controls the git operations that talk to remotes
+	Network Network `toml:"network,omitempty"`
+	// Team is a shared dotfiles repository that the personal one overrides
+	Team Team `toml:"team,omitempty"`
+}
+
+// Team is a read-only dotfiles repository shared by a team, e.g. an organization's
+// baseline configs; its profiles are merged beneath the personal repository's, which
+// override them
+type Team struct {
+	// URL is where the team repository is cloned from by dot clone and dot update
+	URL string `toml:"url,omitempty"`
+	// Dir is where the team repository is checked out, by default under
+	// $XDG_DATA_HOME/dot/team
+	Dir string `toml:"dir,omitempty"`
+}
+
+// Enabled reports whether a team repository is configured
+func (t Team) Enabled() bool {
+	return t.URL != "" || t.Dir != ""
+}
+
+// Directory returns the absolute path the team repository is checked out at
+func (t Team) Directory() (string, error) {
+	if t.Dir != "" {
+		return filepath.Abs(utils.ExpandPath(t.Dir))
+	}
+	return xdg.Data.Path("team")
+}
+
+// Hooks controls how the on_change and on_remove hooks of mappings are run
+type Hooks struct {
+	// Timeout stops a hook that runs longer, e.g. "30s"; "0" lets hooks run as long as they take
+	Timeout string `toml:"timeout,omitempty"`
+	// OnFailure is "warn" to report a failing hook and carry on, or "abort" to fail the run
+	OnFailure string `toml:"on_failure,omitempty"`
+	// Output is "stream" to show hook output as it is written, or "capture" to show it
+	// only when the hook fails
+	Output string `toml:"output,omitempty"`
+}
+
+// Network controls the git operations that talk to remotes: clone, pull, and push
+type Network struct {
+	// Timeout stops an operation that runs longer, e.g. "5m"; "0" waits as long as it takes
+	Timeout string `toml:"timeout,omitempty"`
+	// Retries is how often an operation that failed with a network error is tried again
+	Retries *int `toml:"retries,omitempty"`
+	// SSHKey is the private key git uses for SSH remotes, e.g. "~/.ssh/id_work"
+	SSHKey string `toml:"ssh_key,omitempty"`
+	// SSHCommand replaces the ssh command git runs for SSH remotes, like GIT_SSH_COMMAND
+	SSHCommand string `toml:"ssh_command,omitempty"`
+}
+
+// Validate checks that the network options hold valid values; unset options are left to defaults
+func (n Network) Validate() error {
+	if n.Timeout != "" {
+		if d, err := time.ParseDuration(n.Timeout); err != nil || d < 0 {
+			return fmt.Errorf("invalid timeout %q, expected a duration like \"5m\"", n.Timeout)
+		}
+	}
+	if n.Retries != nil && *n.Retries < 0 {
+		return fmt.Errorf("invalid retries %d, expected 0 or more", *n.Retries)
+	}
+	if n.SSHKey != "" && n.SSHCommand != "" {
+		return fmt.Errorf("ssh_key and ssh_command cannot both be set")
+	}
+	return nil
+}
+
+// Validate checks that the hook options hold known values; empty options are left to defaults
+func (h Hooks) Validate() error {
+	if h.Timeout != "" {
+		if d, err := time.ParseDuration(h.Timeout); err != nil || d < 0 {
+			return fmt.Errorf("invalid hook timeout %q, expected a duration like \"30s\"", h.Timeout)
+		}
+	}
+	switch h.OnFailure {
+	case "", "warn", "abort":
+	default:
+		return fmt.Errorf("invalid hook on_failure %q, expected \"warn\" or \"abort\"", h.OnFailure)
+	}
+	switch h.Output {
+	case "", "stream", "capture":
+	default:
+		return fmt.Errorf("invalid hook output %q, expected \"stream\" or \"capture\"", h.Output)
+	}
+	return nil
+}
+
+// RemoteAllowed reports whether url matches one of AllowedRemotes, or whether that is empty
+func (s *Settings) RemoteAllowed(url string) bool {
+	if len(s.AllowedRemotes) == 0 {
+		return true
+	}
+	for _, pattern := range s.AllowedRemotes {
+		if matchWildcard(pattern, url) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWildcard reports whether s matches pattern in full, where * in pattern matches
+// any text, including slashes, and every other character only itself
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	last := parts[len(parts)-1]
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
+
+// EnvVar names the variable that points dot at an alternate global config file
+const EnvVar = "DOT_CONFIG"
+
+// Path returns the location of the global config file
+// $DOT_CONFIG names it when set; otherwise it lives under $XDG_CONFIG_HOME/dot, falling
+// back to ~/.config/dot
+func Path() (string, error) {
+	if path := os.Getenv(EnvVar); path != "" {
+		return filepath.Abs(utils.ExpandPath(path))
+	}
+	return xdg.Config.Path("config.toml")
+}
+
+// Load reads the global config file
+// A missing file at the default location yields empty settings, while one that
+// $DOT_CONFIG names must exist
+func Load() (*Settings, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &Settings{}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if os.Getenv(EnvVar) != "" {
+			return nil, fmt.Errorf("config file %s does not exist", path)
+		}
+		return settings, nil
+	}
+
+	md, err := toml.DecodeFile(path, settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown option %q in %s", undecoded[0].String(), path)
+	}
+	switch settings.OnConflict {
+	case "", "backup", "skip", "overwrite", "adopt", "prompt":
+	default:
+		return nil, fmt.Errorf("%s: invalid on_conflict %q, expected \"backup\", \"skip\", \"overwrite\", \"adopt\", or \"prompt\"", path, settings.OnConflict)
+	}
+	switch settings.Color {
+	case "", "auto", "always", "never":
+	default:
+		return nil, fmt.Errorf("%s: invalid color %q, expected \"auto\", \"always\", or \"never\"", path, settings.Color)
+	}
+	if err := settings.Hooks.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: [hooks] %w", path, err)
+	}
+	if err := settings.Network.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: [network] %w", path, err)
+	}
+
+	return settings, nil
+}
+
+// Save writes the settings to the global config file, creating its directory if needed
+func Save(settings *Settings) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := toml.NewEncoder(file).Encode(settings); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+
+	t.Run("Uses XDG_CONFIG_HOME", func(t *testing.T) {
+		os.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+
+		path, err := Path()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := filepath.Join("/tmp/config", "dot", "config.toml")
+		if path != expected {
+			t.Errorf("Expected %s, got %s", expected, path)
+		}
+	})
+
+	t.Run("DOT_CONFIG overrides the location", func(t *testing.T) {
+		os.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+		os.Setenv("DOT_CONFIG", "/tmp/ci/dot.toml")
+		defer os.Unsetenv("DOT_CONFIG")
+
+		path, err := Path()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected, _ := filepath.Abs(filepath.FromSlash("/tmp/ci/dot.toml"))
+		if path != expected {
+			t.Errorf("Expected %s, got %s", expected, path)
+		}
+	})
+
+	t.Run("Falls back to ~/.config", func(t *testing.T) {
+		os.Setenv("XDG_CONFIG_HOME", "")
+		homeDir, _ := os.UserHomeDir()
+
+		path, err := Path()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := filepath.Join(homeDir, ".config", "dot", "config.toml")
+		if path != expected {
+			t.Errorf("Expected %s, got %s", expected, path)
+		}
+	})
+}
+
+func TestLoadAndSave(t *testing.T) {
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+
+	t.Run("Missing file yields empty settings", func(t *testing.T) {
+		os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		settings, err := Load()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if len(settings.Remotes) != 0 {
+			t.Errorf("Expected no remotes, got %v", settings.Remotes)
+		}
+	})
+
+	t.Run("Missing file named by DOT_CONFIG is an error", func(t *testing.T) {
+		os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+		os.Setenv(EnvVar, filepath.Join(t.TempDir(), "typo.toml"))
+		defer os.Unsetenv(EnvVar)
+
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "does not exist") {
+			t.Errorf("Expected an error for the missing file, got: %v", err)
+		}
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		if err := Save(&Settings{Remotes: map[string]string{"mirror": "git@example.com:me/dotfiles.git"}}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		settings, err := Load()
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if settings.Remotes["mirror"] != "git@example.com:me/dotfiles.git" {
+			t.Errorf("Expected mirror remote, got %v", settings.Remotes)
+		}
+	})
+
+	t.Run("Unknown options are rejected", func(t *testing.T) {
+		configHome := t.TempDir()
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("colour = true\n"), 0644)
+
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "unknown option") {
+			t.Errorf("Expected unknown option error, got %v", err)
+		}
+	})
+
+	t.Run("Invalid hook options are rejected", func(t *testing.T) {
+		configHome := t.TempDir()
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("[hooks]\ntimeout = \"soon\"\n"), 0644)
+
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "invalid hook timeout") {
+			t.Errorf("Expected invalid timeout error, got %v", err)
+		}
+	})
+
+	t.Run("Invalid conflict policies are rejected", func(t *testing.T) {
+		configHome := t.TempDir()
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("on_conflict = \"replace\"\n"), 0644)
+
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "invalid on_conflict") {
+			t.Errorf("Expected invalid on_conflict error, got %v", err)
+		}
+	})
+
+	t.Run("Defaults are read", func(t *testing.T) {
+		configHome := t.TempDir()
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("dotfiles_dir = \"~/src/dotfiles\"\nprofiles = [\"work\", \"laptop\"]\ncolor = \"never\"\nbackup_dir = \"~/.local/share/dot/backups\"\nadjacent_backups = true\n"), 0644)
+
+		settings, err := Load()
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if settings.DotfilesDir != "~/src/dotfiles" {
+			t.Errorf("Expected '~/src/dotfiles', got '%s'", settings.DotfilesDir)
+		}
+		if len(settings.Profiles) != 2 || settings.Profiles[0] != "work" || settings.Profiles[1] != "laptop" {
+			t.Errorf("Expected [work laptop], got %v", settings.Profiles)
+		}
+		if settings.Color != "never" {
+			t.Errorf("Expected 'never', got '%s'", settings.Color)
+		}
+		if settings.BackupDir != "~/.local/share/dot/backups" {
+			t.Errorf("Expected '~/.local/share/dot/backups', got '%s'", settings.BackupDir)
+		}
+		if !settings.AdjacentBackups {
+			t.Error("Expected adjacent backups")
+		}
+	})
+
+	t.Run("Invalid colors are rejected", func(t *testing.T) {
+		configHome := t.TempDir()
+		os.Setenv("XDG_CONFIG_HOME", configHome)
+		os.MkdirAll(filepath.Join(configHome, "dot"), 0755)
+		os.WriteFile(filepath.Join(configHome, "dot", "config.toml"), []byte("color = \"yes\"\n"), 0644)
+
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "invalid color") {
+			t.Errorf("Expected invalid color error, got %v", err)
+		}
+	})
+}
+
+func TestHooksValidate(t *testing.T) {
+	valid := []Hooks{
+		{},
+		{Timeout: "30s", OnFailure: "abort", Output: "capture"},
+		{Timeout: "0", OnFailure: "warn", Output: "stream"},
+	}
+	for _, hooks := range valid {
+		if err := hooks.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", hooks, err)
+		}
+	}
+
+	invalid := []Hooks{
+		{Timeout: "-1s"},
+		{OnFailure: "ignore"},
+		{Output: "quiet"},
+	}
+	for _, hooks := range invalid {
+		if err := hooks.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", hooks)
+		}
+	}
+}
+
+func TestNetworkValidate(t *testing.T) {
+	zero, negative := 0, -1
+
+	valid := []Network{
+		{},
+		{Timeout: "5m", Retries: &zero},
+		{Timeout: "0"},
+	}
+	for _, network := range valid {
+		if err := network.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", network, err)
+		}
+	}
+
+	invalid := []Network{
+		{Timeout: "later"},
+		{Timeout: "-1m"},
+		{Retries: &negative},
+	}
+	for _, network := range invalid {
+		if err := network.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", network)
+		}
+	}
+}
+
+func TestRemoteAllowed(t *testing.T) {
+	s := &Settings{AllowedRemotes: []string{"git@github.corp:*", "https://github.corp/team/*.git"}}
+	tests := map[string]bool{
+		"git@github.corp:me/dotfiles.git":        true,
+		"https://github.corp/team/dotfiles.git":  true,
+		"https://github.corp/team/dotfiles":      false,
+		"git@github.com:me/dotfiles.git":         false,
+		"https://evil.example/git@github.corp:x": false,
+	}
+	for url, expected := range tests {
+		if result := s.RemoteAllowed(url); result != expected {
+			t.Errorf("Expected RemoteAllowed(%q) to be %v, got %v", url, expected, result)
+		}
+	}
+
+	if !(&Settings{}).RemoteAllowed("https://anything.example/repo.git") {
+		t.Error("Expected every URL to be allowed without an allowlist")
+	}
+}
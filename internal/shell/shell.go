@@ -0,0 +1,48 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// posix defines the shell functions for bash and zsh
+const posix = `# dot shell integration; add to your shell config with
+#   eval "$(dot shell-integration)"
+
+# dotcd <target> changes to the directory in the dotfiles repository that holds the
+# source of a target, e.g. dotcd ~/.config/nvim
+dotcd() {
+	local dir
+	dir="$(command dot which --dir "$@")" || return
+	cd "$dir"
+}
+`
+
+// fish defines the shell functions for fish
+const fish = `# dot shell integration; add to your fish config with
+#   dot shell-integration --shell fish | source
+
+# dotcd <target> changes to the directory in the dotfiles repository that holds the
+# source of a target, e.g. dotcd ~/.config/nvim
+function dotcd
+	set -l dir (command dot which --dir $argv); or return
+	cd $dir
+end
+`
+
+// Integration returns the shell functions for the named shell, or for the login shell
+// in $SHELL when name is empty
+func Integration(name string) (string, error) {
+	if name == "" {
+		name = filepath.Base(os.Getenv("SHELL"))
+	}
+
+	switch name {
+	case "bash", "zsh", "sh":
+		return posix, nil
+	case "fish":
+		return fish, nil
+	}
+	return "", fmt.Errorf("unsupported shell %q; use bash, zsh, or fish", name)
+}
@@ -0,0 +1,40 @@
+package shell
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIntegration(t *testing.T) {
+	originalShell := os.Getenv("SHELL")
+	defer os.Setenv("SHELL", originalShell)
+
+	t.Run("Shell from $SHELL", func(t *testing.T) {
+		os.Setenv("SHELL", "/usr/bin/fish")
+		script, err := Integration("")
+		if err != nil {
+			t.Fatalf("Integration failed: %v", err)
+		}
+		if !strings.Contains(script, "function dotcd") {
+			t.Errorf("Expected the fish function, got: %s", script)
+		}
+	})
+
+	t.Run("Named shell", func(t *testing.T) {
+		os.Setenv("SHELL", "/usr/bin/fish")
+		script, err := Integration("zsh")
+		if err != nil {
+			t.Fatalf("Integration failed: %v", err)
+		}
+		if !strings.Contains(script, "dotcd() {") || !strings.Contains(script, "dot which --dir") {
+			t.Errorf("Expected the POSIX function, got: %s", script)
+		}
+	})
+
+	t.Run("Unsupported shell", func(t *testing.T) {
+		if _, err := Integration("tcsh"); err == nil {
+			t.Error("Expected an error for tcsh")
+		}
+	})
+}
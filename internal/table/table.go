@@ -0,0 +1,183 @@
+package table
+
+import (
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/dot/internal/term"
+)
+
+// gap is the space between two columns
+const gap = "  "
+
+// minWidth is the narrowest a truncatable column is shortened to
+const minWidth = 12
+
+// Table renders rows as aligned columns, shortening the truncatable columns when the
+// table is wider than the terminal
+type Table struct {
+	header      []string
+	rows        [][]string
+	truncatable map[int]bool
+}
+
+// New returns a table with the given column headers
+func New(header ...string) *Table {
+	return &Table{header: header, truncatable: make(map[int]bool)}
+}
+
+// Truncatable marks columns whose cells may be shortened to fit the terminal width; a
+// shortened cell keeps its end, which is the most telling part of a path
+func (t *Table) Truncatable(columns ...int) {
+	for _, column := range columns {
+		t.truncatable[column] = true
+	}
+}
+
+// Append adds a row; missing cells are rendered empty
+func (t *Table) Append(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// Len returns the number of rows
+func (t *Table) Len() int {
+	return len(t.rows)
+}
+
+// Render writes the table to w, fitting it into width columns when width is positive
+func (t *Table) Render(w io.Writer, width int) error {
+	rows := append([][]string{t.header}, t.rows...)
+	widths := t.widths(rows)
+	if width > 0 {
+		t.fit(widths, width)
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		line := make([]string, 0, len(widths))
+		for i := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = truncate(row[i], widths[i])
+			}
+			// The last column is not padded, so lines carry no trailing spaces
+			if i < len(widths)-1 {
+				cell += strings.Repeat(" ", widths[i]-Width(cell))
+			}
+			line = append(line, cell)
+		}
+		b.WriteString(strings.TrimRight(strings.Join(line, gap), " ") + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// widths returns the display width of the widest cell of every column
+func (t *Table) widths(rows [][]string) []int {
+	var widths []int
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+			if cellWidth := Width(cell); cellWidth > widths[i] {
+				widths[i] = cellWidth
+			}
+		}
+	}
+	return widths
+}
+
+// fit shortens the widest truncatable columns, one cell at a time, until the table fits
+// in width or no column can be shortened any further
+func (t *Table) fit(widths []int, width int) {
+	total := len(gap) * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+
+	for total > width {
+		widest := -1
+		for i, w := range widths {
+			if t.truncatable[i] && w > minWidth && (widest < 0 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// truncate shortens cell to width by replacing its start with an ellipsis
+func truncate(cell string, width int) string {
+	if Width(cell) <= width {
+		return cell
+	}
+
+	ellipsis := term.Ellipsis()
+	runes := []rune(cell)
+	kept := Width(ellipsis)
+	start := len(runes)
+	for start > 0 {
+		w := runeWidth(runes[start-1])
+		if kept+w > width {
+			break
+		}
+		kept += w
+		start--
+	}
+	return ellipsis + string(runes[start:])
+}
+
+// Width returns the number of terminal columns s occupies
+func Width(s string) int {
+	runes := []rune(s)
+	width := 0
+	for i, r := range runes {
+		// An emoji variation selector makes the preceding symbol double width
+		if i+1 < len(runes) && runes[i+1] == '\uFE0F' {
+			width += 2
+			continue
+		}
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth returns the number of terminal columns r occupies
+func runeWidth(r rune) int {
+	switch {
+	case r == '\u200D', r >= '\uFE00' && r <= '\uFE0F', unicode.Is(unicode.Mn, r):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// isWide reports whether r is an emoji or East Asian wide character
+func isWide(r rune) bool {
+	switch {
+	case r >= 0x1100 && r <= 0x115F, // Hangul Jamo
+		r >= 0x2E80 && r <= 0xA4CF, // CJK
+		r >= 0xAC00 && r <= 0xD7A3, // Hangul syllables
+		r >= 0xF900 && r <= 0xFAFF, // CJK compatibility ideographs
+		r >= 0xFF00 && r <= 0xFF60, // fullwidth forms
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1FAFF, // emoji
+		r >= 0x20000 && r <= 0x3FFFD:
+		return true
+	}
+
+	// Symbols from the dingbat and miscellaneous blocks that render as emoji
+	switch r {
+	case '✅', '❌', '❓', '❔', '❕', '❗', '➕', '➖', '➗', '⌛', '⏳', '⚡', '⭐':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package table
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/dot/internal/term"
+)
+
+func TestRender(t *testing.T) {
+	render := func(tbl *Table, width int) string {
+		var buf bytes.Buffer
+		if err := tbl.Render(&buf, width); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	t.Run("Aligns columns", func(t *testing.T) {
+		tbl := New("NAME", "VALUE")
+		tbl.Append("a", "1")
+		tbl.Append("longer", "2")
+
+		expected := "NAME    VALUE\na       1\nlonger  2\n"
+		if output := render(tbl, 0); output != expected {
+			t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+		}
+	})
+
+	t.Run("Aligns emoji by display width", func(t *testing.T) {
+		tbl := New("STATUS", "TARGET")
+		tbl.Append("✅ linked", "~/.zshrc")
+		tbl.Append("⚠️ source missing", "~/.vimrc")
+		tbl.Append("x", "~/.bashrc")
+
+		lines := strings.Split(strings.TrimSuffix(render(tbl, 0), "\n"), "\n")
+		for _, line := range lines[1:] {
+			column := Width(line[:strings.Index(line, "~")])
+			if column != Width("⚠️ source missing")+len(gap) {
+				t.Errorf("Expected second column at the same position, got %d in %q", column, line)
+			}
+		}
+	})
+
+	t.Run("No trailing spaces", func(t *testing.T) {
+		tbl := New("A", "B")
+		tbl.Append("value")
+
+		for _, line := range strings.Split(render(tbl, 0), "\n") {
+			if strings.HasSuffix(line, " ") {
+				t.Errorf("Expected no trailing spaces, got %q", line)
+			}
+		}
+	})
+
+	t.Run("Truncates to fit the width", func(t *testing.T) {
+		tbl := New("STATUS", "TARGET")
+		tbl.Truncatable(1)
+		tbl.Append("ok", "~/.config/some/deeply/nested/directory/init.lua")
+
+		output := render(tbl, 30)
+		for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+			if Width(line) > 30 {
+				t.Errorf("Expected line to fit in 30 columns, got %d: %q", Width(line), line)
+			}
+		}
+		if !strings.Contains(output, term.Ellipsis()) || !strings.HasSuffix(output, "directory/init.lua\n") {
+			t.Errorf("Expected truncated cell to keep its end, got:\n%s", output)
+		}
+	})
+
+	t.Run("Keeps columns that are not truncatable", func(t *testing.T) {
+		tbl := New("STATUS", "TARGET")
+		tbl.Append("ok", "~/.config/some/deeply/nested/directory/init.lua")
+
+		if output := render(tbl, 30); strings.Contains(output, term.Ellipsis()) {
+			t.Errorf("Expected no truncation, got:\n%s", output)
+		}
+	})
+}
+
+func TestWidth(t *testing.T) {
+	cases := map[string]int{
+		"abc":  3,
+		"✅":    2,
+		"⚠️":   2,
+		"❌ no": 5,
+		"日本":   4,
+		"é":   1,
+	}
+	for s, expected := range cases {
+		if width := Width(s); width != expected {
+			t.Errorf("Expected width %d for %q, got %d", expected, s, width)
+		}
+	}
+}
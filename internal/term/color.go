@@ -0,0 +1,33 @@
+package term
+
+import "os"
+
+// When output is colored, see ColorMode
+const (
+	ColorAuto   = "auto"   // on a terminal, unless $NO_COLOR is set
+	ColorAlways = "always" // even when output goes to a file or pipe
+	ColorNever  = "never"  // never
+)
+
+// ColorMode is when output is colored, one of the Color constants
+// The CLI sets it from the color option of the global config
+var ColorMode = ColorAuto
+
+// Colored reports whether output written to f is colored
+// Output to stdout is colored when the terminal it is eventually shown on is, as while
+// it goes through the pager
+func Colored(f *os.File) bool {
+	switch ColorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if f == os.Stdout && terminal != nil {
+		f = terminal
+	}
+	return IsTerminal(f)
+}
@@ -0,0 +1,48 @@
+package term
+
+import (
+	"os"
+	"testing"
+)
+
+func TestColored(t *testing.T) {
+	originalMode := ColorMode
+	originalNoColor, noColorSet := os.LookupEnv("NO_COLOR")
+	defer func() {
+		ColorMode = originalMode
+		if noColorSet {
+			os.Setenv("NO_COLOR", originalNoColor)
+		} else {
+			os.Unsetenv("NO_COLOR")
+		}
+	}()
+
+	file, err := os.CreateTemp(t.TempDir(), "output")
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	defer file.Close()
+
+	t.Run("Auto does not color files", func(t *testing.T) {
+		ColorMode = ColorAuto
+		if Colored(file) {
+			t.Error("Expected no color for a file")
+		}
+	})
+
+	t.Run("Always colors files", func(t *testing.T) {
+		ColorMode = ColorAlways
+		os.Setenv("NO_COLOR", "1")
+		if !Colored(file) {
+			t.Error("Expected color for a file")
+		}
+	})
+
+	t.Run("Never does not color", func(t *testing.T) {
+		ColorMode = ColorNever
+		os.Unsetenv("NO_COLOR")
+		if Colored(file) {
+			t.Error("Expected no color")
+		}
+	})
+}
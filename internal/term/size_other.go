@@ -0,0 +1,11 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package term
+
+import "os"
+
+// size returns the columns and rows of the terminal, or 0, 0 if unknown
+// Only $COLUMNS and $LINES are consulted on this platform
+func size(_ *os.File) (int, int) {
+	return envSize()
+}
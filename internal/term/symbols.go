@@ -0,0 +1,50 @@
+package term
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// ASCII replaces emoji and other non-ASCII markers in output with plain text
+// The CLI sets it from --ascii and the locale
+var ASCII bool
+
+// Symbol is a status marker printed in front of a line
+type Symbol int
+
+const (
+	OK Symbol = iota
+	Error
+	Warning
+	Added
+)
+
+// String returns the marker as emoji, or as a plain text marker in ASCII mode
+func (s Symbol) String() string {
+	if ASCII {
+		return [...]string{"OK", "ERR", "WARN", "+"}[s]
+	}
+	return [...]string{"✅", "❌", "⚠️", "➕"}[s]
+}
+
+// Ellipsis returns the marker for shortened text
+func Ellipsis() string {
+	if ASCII {
+		return "..."
+	}
+	return "…"
+}
+
+// UTF8Locale reports whether the locale, taken from $LC_ALL, $LC_CTYPE, or $LANG like
+// the C library does, uses UTF-8
+// Without any locale set only macOS defaults to UTF-8; elsewhere that is the C locale
+func UTF8Locale() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			locale = strings.ToLower(locale)
+			return strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+		}
+	}
+	return runtime.GOOS == "darwin"
+}
@@ -0,0 +1,73 @@
+package term
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestSymbol(t *testing.T) {
+	defer func() { ASCII = false }()
+
+	ASCII = false
+	if OK.String() != "✅" {
+		t.Errorf("Expected emoji marker, got %s", OK)
+	}
+
+	ASCII = true
+	for symbol, expected := range map[Symbol]string{OK: "OK", Error: "ERR", Warning: "WARN", Added: "+"} {
+		if symbol.String() != expected {
+			t.Errorf("Expected %s, got %s", expected, symbol)
+		}
+	}
+	if Ellipsis() != "..." {
+		t.Errorf("Expected ..., got %s", Ellipsis())
+	}
+}
+
+func TestUTF8Locale(t *testing.T) {
+	names := []string{"LC_ALL", "LC_CTYPE", "LANG"}
+	original := make(map[string]string)
+	for _, name := range names {
+		original[name] = os.Getenv(name)
+	}
+	defer func() {
+		for name, value := range original {
+			os.Setenv(name, value)
+		}
+	}()
+
+	set := func(lcAll, lcCtype, lang string) {
+		os.Setenv("LC_ALL", lcAll)
+		os.Setenv("LC_CTYPE", lcCtype)
+		os.Setenv("LANG", lang)
+	}
+
+	t.Run("UTF-8 locale", func(t *testing.T) {
+		set("", "", "en_US.UTF-8")
+		if !UTF8Locale() {
+			t.Error("Expected en_US.UTF-8 to be UTF-8")
+		}
+	})
+
+	t.Run("LC_ALL overrides LANG", func(t *testing.T) {
+		set("C", "", "en_US.UTF-8")
+		if UTF8Locale() {
+			t.Error("Expected LC_ALL=C to win over LANG")
+		}
+	})
+
+	t.Run("Latin-1 locale", func(t *testing.T) {
+		set("", "de_DE.ISO-8859-1", "")
+		if UTF8Locale() {
+			t.Error("Expected de_DE.ISO-8859-1 not to be UTF-8")
+		}
+	})
+
+	t.Run("No locale", func(t *testing.T) {
+		set("", "", "")
+		if UTF8Locale() != (runtime.GOOS == "darwin") {
+			t.Errorf("Expected UTF-8 only on macOS without a locale")
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package term
+
+import (
+	"os"
+	"strconv"
+)
+
+// terminal is the terminal that output is shown on while os.Stdout is redirected
+var terminal *os.File
+
+// SetTerminal records the terminal that output is eventually shown on while os.Stdout
+// is redirected, as the pager does; nil clears it
+func SetTerminal(f *os.File) {
+	terminal = f
+}
+
+// IsTerminal reports whether f is a terminal rather than a file or pipe
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Size returns the columns and rows of the terminal f is attached to, or 0, 0 if f is
+// not a terminal or its size is unknown
+func Size(f *os.File) (int, int) {
+	if !IsTerminal(f) {
+		return 0, 0
+	}
+	return size(f)
+}
+
+// Width returns the number of columns of the terminal stdout is shown on, or 0 when
+// stdout does not end up on a terminal
+func Width() int {
+	f := os.Stdout
+	if terminal != nil {
+		f = terminal
+	}
+	width, _ := Size(f)
+	return width
+}
+
+// envSize returns the terminal size from $COLUMNS and $LINES, using 0 for unset values
+func envSize() (int, int) {
+	return envInt("COLUMNS"), envInt("LINES")
+}
+
+// envInt returns the non-negative integer in the environment variable name, or 0
+func envInt(name string) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value < 0 {
+		return 0
+	}
+	return value
+}
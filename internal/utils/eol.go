@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"bytes"
+	"path/filepath"
+	"runtime"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+// ValidEOL reports whether eol is a line ending setting that ConvertEOL understands,
+// "" meaning the line endings are kept
+func ValidEOL(eol string) bool {
+	switch eol {
+	case "", "lf", "crlf", "native":
+		return true
+	}
+	return false
+}
+
+// ConvertEOL returns data with its line endings converted to eol: "lf", "crlf", or
+// "native" for those of the OS; "" and binary data, which holds a NUL byte, are returned
+// as they are
+func ConvertEOL(data []byte, eol string) []byte {
+	if eol == "native" {
+		eol = "lf"
+		if runtime.GOOS == "windows" {
+			eol = "crlf"
+		}
+	}
+	if eol == "" || bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		return data
+	}
+
+	lf := bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if eol == "crlf" {
+		return bytes.ReplaceAll(lf, []byte("\n"), []byte("\r\n"))
+	}
+	return lf
+}
+
+// ConvertTreeEOLFS converts the line endings of the file at path, or of every file below
+// the directory at path, to eol in place
+func ConvertTreeEOLFS(f fsys.FS, path, eol string) error {
+	if eol == "" {
+		return nil
+	}
+
+	stat, err := f.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	if stat.IsDir() {
+		entries, err := f.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := ConvertTreeEOLFS(f, filepath.Join(path, entry.Name()), eol); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !stat.Mode().IsRegular() {
+		return nil
+	}
+
+	data, err := f.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if converted := ConvertEOL(data, eol); !bytes.Equal(converted, data) {
+		return f.WriteFile(path, converted, stat.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/dot/internal/fsys"
+)
+
+// BackupFileFS is BackupFile on the given filesystem
+func BackupFileFS(f fsys.FS, path string) error {
+	return BackupFileToFS(f, path, path+".bak")
+}
+
+// BackupFileToFS moves path to backupPath, replacing an earlier backup there and
+// creating the directories backupPath needs
+func BackupFileToFS(f fsys.FS, path, backupPath string) error {
+	// Remove existing backup if it exists
+	if _, err := f.Lstat(backupPath); err == nil {
+		if err := f.RemoveAll(backupPath); err != nil {
+			return fmt.Errorf("failed to remove existing backup %s: %w", backupPath, err)
+		}
+	}
+
+	// Create backup by moving, which copies when backupPath is on another filesystem
+	if err := MovePathFS(f, path, backupPath); err != nil {
+		return fmt.Errorf("failed to create backup %s: %w", backupPath, err)
+	}
+
+	return nil
+}
+
+// RestoreBackupFS is RestoreBackup on the given filesystem
+func RestoreBackupFS(f fsys.FS, path string) error {
+	return RestoreBackupFromFS(f, path, path+".bak")
+}
+
+// RestoreBackupFromFS moves backupPath back to path, which must not exist
+func RestoreBackupFromFS(f fsys.FS, path, backupPath string) error {
+	if _, err := f.Lstat(path); err == nil {
+		return fmt.Errorf("cannot restore backup %s: %s exists", backupPath, path)
+	}
+	if err := MovePathFS(f, backupPath, path); err != nil {
+		return fmt.Errorf("failed to restore backup %s: %w", backupPath, err)
+	}
+
+	return nil
+}
+
+// IsSymlinkFS is IsSymlink on the given filesystem
+func IsSymlinkFS(f fsys.FS, path string) (bool, error) {
+	stat, err := f.Lstat(path)
+	if err != nil {
+		return false, err
+	}
+
+	return stat.Mode()&os.ModeSymlink != 0, nil
+}
+
+// ReadSymlinkFS is ReadSymlink on the given filesystem
+func ReadSymlinkFS(f fsys.FS, path string) (string, error) {
+	isLink, err := IsSymlinkFS(f, path)
+	if err != nil {
+		return "", err
+	}
+
+	if !isLink {
+		return "", fmt.Errorf("%s is not a symbolic link", path)
+	}
+
+	return f.Readlink(path)
+}
+
+// FileExistsFS is FileExists on the given filesystem
+func FileExistsFS(f fsys.FS, path string) bool {
+	_, err := f.Stat(path)
+	return err == nil
+}
+
+// CopyFileFS is CopyFile on the given filesystem
+func CopyFileFS(f fsys.FS, src, dst string) error {
+	stat, err := f.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if err := f.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dst, err)
+	}
+
+	if stat.Mode()&os.ModeSymlink != 0 {
+		linkTarget, err := f.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return f.Symlink(linkTarget, dst)
+	}
+
+	if !stat.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", src)
+	}
+
+	data, err := f.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	return f.WriteFile(dst, data, stat.Mode().Perm())
+}
+
+// CopyTreeFS is CopyTree on the given filesystem
+func CopyTreeFS(f fsys.FS, src, dst string) error {
+	stat, err := f.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if !stat.IsDir() {
+		return CopyFileFS(f, src, dst)
+	}
+
+	if err := f.MkdirAll(dst, stat.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := f.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := CopyTreeFS(f, filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MovePathFS is MovePath on the given filesystem
+func MovePathFS(f fsys.FS, src, dst string) error {
+	if err := f.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dst, err)
+	}
+
+	if err := f.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	if err := CopyTreeFS(f, src, dst); err != nil {
+		f.RemoveAll(dst)
+		return fmt.Errorf("failed to move %s to %s: %w", src, dst, err)
+	}
+
+	return f.RemoveAll(src)
+}
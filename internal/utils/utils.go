@@ -4,16 +4,63 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+
+	"github.com/yourusername/dot/internal/fsys"
+	"github.com/yourusername/dot/internal/term"
+	"github.com/yourusername/dot/internal/wsl"
 )
 
+// CaseInsensitive reports whether paths that differ only in case refer to the same file,
+// as they do by default on Windows and macOS
+var CaseInsensitive = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// TargetHome is the directory ~ stands for in mapping targets, set by --home for runs
+// that fill a home directory other than the user's, such as an OS image or another
+// user's home; "" is the user's home directory
+var TargetHome string
+
 // ExpandPath expands ~ to the user's home directory
+// On Windows ~ is %USERPROFILE%, ~\ is accepted as well as ~/, %VAR% references such as
+// %APPDATA% are expanded, and forward slashes become backslashes
+// A leading $WINHOME is the Windows home directory, for WSL configs that reach into it
 func ExpandPath(path string) string {
+	return expandPath(path, os.UserHomeDir)
+}
+
+// ExpandTarget expands a mapping target like ExpandPath, with ~ standing for TargetHome
+// when it is set
+func ExpandTarget(path string) string {
+	return expandPath(path, TargetHomeDir)
+}
+
+// TargetHomeDir returns the directory ~ stands for in mapping targets
+func TargetHomeDir() (string, error) {
+	if TargetHome != "" {
+		return TargetHome, nil
+	}
+	return os.UserHomeDir()
+}
+
+// expandPath implements ExpandPath with ~ standing for the directory returned by home
+func expandPath(path string, home func() (string, error)) string {
+	if runtime.GOOS == "windows" {
+		path = filepath.FromSlash(expandWindowsEnv(path))
+	}
+
+	if rest, found := cutHomeVar(path); found {
+		if home, err := wsl.WindowsHome(); err == nil {
+			return filepath.Join(home, rest)
+		}
+		return path
+	}
+
 	if !strings.HasPrefix(path, "~") {
 		return path
 	}
 
-	homeDir, err := os.UserHomeDir()
+	homeDir, err := home()
 	if err != nil {
 		// If we can't get home directory, return path as-is
 		return path
@@ -23,61 +70,117 @@ func ExpandPath(path string) string {
 		return homeDir
 	}
 
-	if strings.HasPrefix(path, "~/") {
+	if strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
 		return filepath.Join(homeDir, path[2:])
 	}
 
 	return path
 }
 
-// BackupFile creates a backup of a file or directory by adding .bak suffix
-// Overwrites existing .bak file if present
-func BackupFile(path string) error {
-	backupPath := path + ".bak"
-
-	// Remove existing backup if it exists
-	if _, err := os.Stat(backupPath); err == nil {
-		if err := os.RemoveAll(backupPath); err != nil {
-			return fmt.Errorf("failed to remove existing backup %s: %w", backupPath, err)
+// cutHomeVar returns the rest of a path starting with $WINHOME or ${WINHOME}
+func cutHomeVar(path string) (string, bool) {
+	for _, prefix := range []string{"$" + wsl.HomeVar, "${" + wsl.HomeVar + "}"} {
+		if rest, found := strings.CutPrefix(path, prefix); found && (rest == "" || os.IsPathSeparator(rest[0])) {
+			return rest, true
 		}
 	}
+	return "", false
+}
 
-	// Create backup by renaming
-	if err := os.Rename(path, backupPath); err != nil {
-		return fmt.Errorf("failed to create backup %s: %w", backupPath, err)
-	}
+// expandWindowsEnv replaces %NAME% references with the value of the environment variable
+// Unknown variables are left as they are, like cmd does
+func expandWindowsEnv(path string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(path, "%")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(path[start+1:], "%")
+		if end < 0 {
+			break
+		}
+		end += start + 1
 
-	return nil
+		name := path[start+1 : end]
+		if value, set := os.LookupEnv(name); set && name != "" {
+			b.WriteString(path[:start] + value)
+			path = path[end+1:]
+		} else {
+			// Keep the first % and look for a reference starting at the second one
+			b.WriteString(path[:end])
+			path = path[end:]
+		}
+	}
+	b.WriteString(path)
+	return b.String()
 }
 
-// IsSymlink checks if a path is a symbolic link
-func IsSymlink(path string) (bool, error) {
-	stat, err := os.Lstat(path)
-	if err != nil {
-		return false, err
+// SamePath reports whether two paths refer to the same location, ignoring redundant
+// separators and, where the filesystem does, case
+func SamePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if CaseInsensitive {
+		return strings.EqualFold(a, b)
 	}
+	return a == b
+}
 
-	return stat.Mode()&os.ModeSymlink != 0, nil
+// ContractPath replaces the user's home directory prefix with ~
+func ContractPath(path string) string {
+	return contractPath(path, os.UserHomeDir)
 }
 
-// ReadSymlink safely reads a symbolic link target
-func ReadSymlink(path string) (string, error) {
-	isLink, err := IsSymlink(path)
+// ContractTarget replaces the prefix of a target path that ~ stands for in mapping
+// targets with ~, the reverse of ExpandTarget
+func ContractTarget(path string) string {
+	return contractPath(path, TargetHomeDir)
+}
+
+// contractPath implements ContractPath with ~ standing for the directory returned by home
+func contractPath(path string, home func() (string, error)) string {
+	homeDir, err := home()
 	if err != nil {
-		return "", err
+		return path
+	}
+
+	if path == homeDir {
+		return "~"
 	}
 
-	if !isLink {
-		return "", fmt.Errorf("%s is not a symbolic link", path)
+	// A name that merely starts with dots, like ~/..data, is still beneath the home directory
+	if rel, err := filepath.Rel(homeDir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel) {
+		return "~/" + filepath.ToSlash(rel)
 	}
 
-	return os.Readlink(path)
+	return path
+}
+
+// BackupFile creates a backup of a file or directory by adding .bak suffix
+// Overwrites existing .bak file if present
+func BackupFile(path string) error {
+	return BackupFileFS(fsys.OS{}, path)
+}
+
+// RestoreBackup moves the .bak backup of path, as made by BackupFile, back into place
+// Fails if something is at path already
+func RestoreBackup(path string) error {
+	return RestoreBackupFS(fsys.OS{}, path)
+}
+
+// IsSymlink checks if a path is a symbolic link
+func IsSymlink(path string) (bool, error) {
+	return IsSymlinkFS(fsys.OS{}, path)
+}
+
+// ReadSymlink safely reads a symbolic link target
+func ReadSymlink(path string) (string, error) {
+	return ReadSymlinkFS(fsys.OS{}, path)
 }
 
 // FileExists checks if a file or directory exists
 func FileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
+	return FileExistsFS(fsys.OS{}, path)
 }
 
 // LogInfo writes an informational message to stdout
@@ -110,44 +213,30 @@ const (
 
 // PrintLn prints text with color
 func PrintLn(text string, colorChoice string) {
-	switch colorChoice {
-	case "red":
-		fmt.Println(Red + text + Reset)
-	case "green":
-		fmt.Println(Green + text + Reset)
-	case "yellow":
-		fmt.Println(Yellow + text + Reset)
-	case "blue":
-		fmt.Println(Blue + text + Reset)
-	case "gray":
-		fmt.Println(Gray + text + Reset)
-	default:
-		fmt.Println(White + text + Reset)
-	}
+	fmt.Println(colorize(os.Stdout, colorChoice, text))
 }
 
 // PrintfColor prints formatted text with color
 func PrintfColor(colorChoice string, format string, args ...interface{}) {
-	var color string
-	switch colorChoice {
-	case "red":
-		color = Red
-	case "green":
-		color = Green
-	case "yellow":
-		color = Yellow
-	case "blue":
-		color = Blue
-	case "gray":
-		color = Gray
-	default:
-		color = White
-	}
-	fmt.Printf(color+format+Reset, args...)
+	fmt.Print(SprintfColor(colorChoice, format, args...))
+}
+
+// SprintfColor returns formatted text wrapped in color codes, or plain when stdout is
+// not colored, see term.Colored
+func SprintfColor(colorChoice string, format string, args ...interface{}) string {
+	return colorize(os.Stdout, colorChoice, fmt.Sprintf(format, args...))
 }
 
 // FprintfColor prints formatted text with color to a specific writer
 func FprintfColor(writer *os.File, colorChoice string, format string, args ...interface{}) {
+	fmt.Fprint(writer, colorize(writer, colorChoice, fmt.Sprintf(format, args...)))
+}
+
+// colorize wraps text in the codes of the named color when output to f is colored
+func colorize(f *os.File, colorChoice, text string) string {
+	if !term.Colored(f) {
+		return text
+	}
 	var color string
 	switch colorChoice {
 	case "red":
@@ -163,5 +252,22 @@ func FprintfColor(writer *os.File, colorChoice string, format string, args ...in
 	default:
 		color = White
 	}
-	fmt.Fprintf(writer, color+format+Reset, args...)
+	return color + text + Reset
+}
+
+// CopyFile copies a regular file or symbolic link from src to dst
+// Parent directories of dst are created as needed and file permissions are preserved
+func CopyFile(src, dst string) error {
+	return CopyFileFS(fsys.OS{}, src, dst)
+}
+
+// CopyTree recursively copies a file, symbolic link, or directory from src to dst
+func CopyTree(src, dst string) error {
+	return CopyTreeFS(fsys.OS{}, src, dst)
+}
+
+// MovePath moves a file or directory, copying and removing the original
+// when a rename is not possible (e.g. across filesystems)
+func MovePath(src, dst string) error {
+	return MovePathFS(fsys.OS{}, src, dst)
 }
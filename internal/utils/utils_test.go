@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -205,6 +206,44 @@ func TestBackupFile(t *testing.T) {
 	})
 }
 
+func TestRestoreBackup(t *testing.T) {
+	t.Run("Restore backup", func(t *testing.T) {
+		tempDir := t.TempDir()
+		testFile := filepath.Join(tempDir, "test.txt")
+		os.WriteFile(testFile+".bak", []byte("original"), 0644)
+
+		if err := RestoreBackup(testFile); err != nil {
+			t.Errorf("RestoreBackup failed: %v", err)
+		}
+		if FileExists(testFile + ".bak") {
+			t.Error("Backup file should not exist after restoring it")
+		}
+		if content, _ := os.ReadFile(testFile); string(content) != "original" {
+			t.Errorf("Restored content = %q, want %q", string(content), "original")
+		}
+	})
+
+	t.Run("Refuse to replace an existing file", func(t *testing.T) {
+		tempDir := t.TempDir()
+		testFile := filepath.Join(tempDir, "test.txt")
+		os.WriteFile(testFile, []byte("current"), 0644)
+		os.WriteFile(testFile+".bak", []byte("original"), 0644)
+
+		if err := RestoreBackup(testFile); err == nil {
+			t.Error("Expected error when the file exists")
+		}
+		if content, _ := os.ReadFile(testFile); string(content) != "current" {
+			t.Errorf("Content = %q, want %q", string(content), "current")
+		}
+	})
+
+	t.Run("Fail without backup", func(t *testing.T) {
+		if err := RestoreBackup(filepath.Join(t.TempDir(), "test.txt")); err == nil {
+			t.Error("Expected error when there is no backup")
+		}
+	})
+}
+
 func TestIsSymlink(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -433,3 +472,217 @@ func TestLogFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestContractPath(t *testing.T) {
+	originalHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", originalHome)
+	os.Setenv("HOME", "/test/home")
+
+	tests := map[string]string{
+		"/test/home":              "~",
+		"/test/home/.vimrc":       "~/.vimrc",
+		"/test/home/.config/nvim": "~/.config/nvim",
+		"/test/homework/file":     "/test/homework/file",
+		"/test/home/..data":       "~/..data",
+		"/test/other":             "/test/other",
+		"/etc/hosts":              "/etc/hosts",
+	}
+
+	for input, expected := range tests {
+		if result := ContractPath(input); result != expected {
+			t.Errorf("ContractPath(%q) = %q, want %q", input, result, expected)
+		}
+	}
+}
+
+func TestTargetHome(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths are POSIX style")
+	}
+
+	originalHome := os.Getenv("HOME")
+	defer func() {
+		os.Setenv("HOME", originalHome)
+		TargetHome = ""
+	}()
+	os.Setenv("HOME", "/test/home")
+	TargetHome = "/image/root/home/me"
+
+	t.Run("Targets expand below the target home", func(t *testing.T) {
+		if result := ExpandTarget("~/.zshrc"); result != "/image/root/home/me/.zshrc" {
+			t.Errorf("Expected /image/root/home/me/.zshrc, got %s", result)
+		}
+		if result := ContractTarget("/image/root/home/me/.config/nvim"); result != "~/.config/nvim" {
+			t.Errorf("Expected ~/.config/nvim, got %s", result)
+		}
+	})
+
+	t.Run("Other paths keep the user's home", func(t *testing.T) {
+		if result := ExpandPath("~/.ssh/id_work"); result != "/test/home/.ssh/id_work" {
+			t.Errorf("Expected /test/home/.ssh/id_work, got %s", result)
+		}
+		if result := ContractPath("/image/root/home/me/.zshrc"); result != "/image/root/home/me/.zshrc" {
+			t.Errorf("Expected the path unchanged, got %s", result)
+		}
+	})
+
+	t.Run("No target home means the user's home", func(t *testing.T) {
+		TargetHome = ""
+		if result := ExpandTarget("~/.zshrc"); result != "/test/home/.zshrc" {
+			t.Errorf("Expected /test/home/.zshrc, got %s", result)
+		}
+	})
+}
+
+func TestExpandWindowsEnv(t *testing.T) {
+	os.Setenv("DOT_TEST_APPDATA", `C:\Users\me\AppData\Roaming`)
+	defer os.Unsetenv("DOT_TEST_APPDATA")
+
+	tests := map[string]string{
+		`%DOT_TEST_APPDATA%\Code\User`: `C:\Users\me\AppData\Roaming\Code\User`,
+		`%DOT_TEST_UNSET%\file`:        `%DOT_TEST_UNSET%\file`,
+		`100%%DOT_TEST_APPDATA%`:       `100%C:\Users\me\AppData\Roaming`,
+		`%%`:                           `%%`,
+		`no references`:                `no references`,
+		`50% off %DOT_TEST_APPDATA%\x`: `50% off C:\Users\me\AppData\Roaming\x`,
+	}
+
+	for input, expected := range tests {
+		if result := expandWindowsEnv(input); result != expected {
+			t.Errorf("expandWindowsEnv(%q) = %q, want %q", input, result, expected)
+		}
+	}
+}
+
+func TestExpandWindowsHome(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("paths are POSIX style")
+	}
+
+	originalWinHome, wasSet := os.LookupEnv("WINHOME")
+	defer func() {
+		if wasSet {
+			os.Setenv("WINHOME", originalWinHome)
+		} else {
+			os.Unsetenv("WINHOME")
+		}
+	}()
+	os.Setenv("WINHOME", "/mnt/c/Users/me")
+
+	tests := map[string]string{
+		"$WINHOME":                      "/mnt/c/Users/me",
+		"$WINHOME/AppData/Roaming/Code": "/mnt/c/Users/me/AppData/Roaming/Code",
+		"${WINHOME}/.gitconfig":         "/mnt/c/Users/me/.gitconfig",
+		"$WINHOMEDIR/file":              "$WINHOMEDIR/file",
+		"/etc/$WINHOME":                 "/etc/$WINHOME",
+	}
+
+	for input, expected := range tests {
+		if result := ExpandPath(input); result != expected {
+			t.Errorf("ExpandPath(%q) = %q, want %q", input, result, expected)
+		}
+	}
+}
+
+func TestSamePath(t *testing.T) {
+	original := CaseInsensitive
+	defer func() { CaseInsensitive = original }()
+
+	t.Run("Ignores redundant separators", func(t *testing.T) {
+		if !SamePath("/home/user//.vimrc", "/home/user/.vimrc/") {
+			t.Error("Expected paths to be the same")
+		}
+	})
+
+	t.Run("Case-sensitive filesystem", func(t *testing.T) {
+		CaseInsensitive = false
+		if SamePath("/Users/me/.vimrc", "/users/me/.vimrc") {
+			t.Error("Expected paths differing in case to differ")
+		}
+	})
+
+	t.Run("Case-insensitive filesystem", func(t *testing.T) {
+		CaseInsensitive = true
+		if !SamePath("/Users/me/.vimrc", "/users/me/.vimrc") {
+			t.Error("Expected paths differing in case to be the same")
+		}
+	})
+}
+
+func TestMovePath(t *testing.T) {
+	t.Run("Move directory tree", func(t *testing.T) {
+		tempDir := t.TempDir()
+		src := filepath.Join(tempDir, "src")
+		dst := filepath.Join(tempDir, "nested", "dst")
+
+		if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+			t.Fatalf("Failed to create source tree: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(src, "sub", "file"), []byte("content"), 0600); err != nil {
+			t.Fatalf("Failed to create source file: %v", err)
+		}
+
+		if err := MovePath(src, dst); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if FileExists(src) {
+			t.Error("Expected source to be removed")
+		}
+		data, err := os.ReadFile(filepath.Join(dst, "sub", "file"))
+		if err != nil || string(data) != "content" {
+			t.Errorf("Expected moved file content, got %q (%v)", data, err)
+		}
+	})
+}
+
+func TestCopyTree(t *testing.T) {
+	t.Run("Preserves permissions and symlinks", func(t *testing.T) {
+		tempDir := t.TempDir()
+		src := filepath.Join(tempDir, "src")
+		dst := filepath.Join(tempDir, "dst")
+
+		if err := os.MkdirAll(src, 0755); err != nil {
+			t.Fatalf("Failed to create source dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(src, "script"), []byte("#!/bin/sh"), 0755); err != nil {
+			t.Fatalf("Failed to create script: %v", err)
+		}
+		if err := os.Symlink("script", filepath.Join(src, "link")); err != nil {
+			t.Fatalf("Failed to create symlink: %v", err)
+		}
+
+		if err := CopyTree(src, dst); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		stat, err := os.Stat(filepath.Join(dst, "script"))
+		if err != nil || stat.Mode().Perm() != 0755 {
+			t.Errorf("Expected executable copy, got %v (%v)", stat, err)
+		}
+		linkTarget, err := os.Readlink(filepath.Join(dst, "link"))
+		if err != nil || linkTarget != "script" {
+			t.Errorf("Expected symlink to be copied, got %s (%v)", linkTarget, err)
+		}
+	})
+}
+
+func TestConvertEOL(t *testing.T) {
+	tests := []struct {
+		data, eol, expected string
+	}{
+		{"a\r\nb\n", "lf", "a\nb\n"},
+		{"a\r\nb\n", "crlf", "a\r\nb\r\n"},
+		{"a\r\nb\n", "", "a\r\nb\n"},
+		{"a\x00\nb\n", "crlf", "a\x00\nb\n"},
+	}
+	for _, test := range tests {
+		if result := string(ConvertEOL([]byte(test.data), test.eol)); result != test.expected {
+			t.Errorf("Expected %q as %s to be %q, got %q", test.data, test.eol, test.expected, result)
+		}
+	}
+
+	if !ValidEOL("native") || ValidEOL("cr") {
+		t.Error("Expected native to be valid and cr not")
+	}
+}
@@ -0,0 +1,69 @@
+package wsl
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Profile is the profile included automatically when running under WSL
+const Profile = "wsl"
+
+// HomeVar is the variable holding the Windows home directory as a Linux path, like
+// /mnt/c/Users/name; setting it in the environment overrides the detected directory
+const HomeVar = "WINHOME"
+
+// Detect reports whether dot runs under the Windows Subsystem for Linux
+// The result is computed once, since it cannot change while dot runs
+var Detect = sync.OnceValue(func() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if os.Getenv("WSL_DISTRO_NAME") != "" || os.Getenv("WSL_INTEROP") != "" {
+		return true
+	}
+	version, err := os.ReadFile("/proc/version")
+	return err == nil && isWSLKernel(string(version))
+})
+
+// isWSLKernel reports whether a /proc/version line is that of a WSL kernel, which
+// WSL 1 and WSL 2 both mark with "microsoft"
+func isWSLKernel(version string) bool {
+	version = strings.ToLower(version)
+	return strings.Contains(version, "microsoft") || strings.Contains(version, "wsl")
+}
+
+// WindowsHome returns the Windows home directory as a path inside WSL
+// $WINHOME wins when set; otherwise Windows is asked for %USERPROFILE%, which is
+// translated with wslpath
+func WindowsHome() (string, error) {
+	if home := os.Getenv(HomeVar); home != "" {
+		return home, nil
+	}
+	if !Detect() {
+		return "", fmt.Errorf("the Windows home directory is only known under WSL; set $%s", HomeVar)
+	}
+	return windowsHome()
+}
+
+// windowsHome asks Windows for the home directory, once, since starting cmd.exe is slow
+var windowsHome = sync.OnceValues(func() (string, error) {
+	out, err := exec.Command("cmd.exe", "/C", "echo %USERPROFILE%").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to query %%USERPROFILE%% from Windows: %w", err)
+	}
+	profile := strings.TrimSpace(string(out))
+	if profile == "" || profile == "%USERPROFILE%" {
+		return "", fmt.Errorf("%%USERPROFILE%% is not set in Windows")
+	}
+
+	out, err = exec.Command("wslpath", "-u", profile).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to translate %s with wslpath: %w", profile, err)
+	}
+	return filepath.Clean(strings.TrimSpace(string(out))), nil
+})
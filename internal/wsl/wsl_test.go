@@ -0,0 +1,34 @@
+package wsl
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIsWSLKernel(t *testing.T) {
+	tests := map[string]bool{
+		"Linux version 5.15.153.1-microsoft-standard-WSL2 (root@941d701f84f1) (gcc (GCC) 11.2.0)": true,
+		"Linux version 4.4.0-19041-Microsoft (Microsoft@Microsoft.com) (gcc version 5.4.0)":       true,
+		"Linux version 6.8.0-45-generic (buildd@lcy02-amd64-115) (x86_64-linux-gnu-gcc-13)":       false,
+	}
+
+	for version, expected := range tests {
+		if result := isWSLKernel(version); result != expected {
+			t.Errorf("isWSLKernel(%q) = %v, want %v", version, result, expected)
+		}
+	}
+}
+
+func TestWindowsHomeFromEnvironment(t *testing.T) {
+	originalHome := os.Getenv(HomeVar)
+	defer os.Setenv(HomeVar, originalHome)
+	os.Setenv(HomeVar, "/mnt/c/Users/me")
+
+	home, err := WindowsHome()
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if home != "/mnt/c/Users/me" {
+		t.Errorf("Expected /mnt/c/Users/me, got %s", home)
+	}
+}
@@ -0,0 +1,106 @@
+package xdg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/yourusername/dot/internal/utils"
+)
+
+// Dir is one of the XDG base directories that dot keeps its own files in
+type Dir struct {
+	env      string // the variable that relocates the directory
+	fallback string // the location relative to the home directory when env is unset
+}
+
+var (
+	// Config holds settings the user edits, like config.toml
+	Config = Dir{env: "XDG_CONFIG_HOME", fallback: ".config"}
+	// State holds what dot records about past runs, like the journal and manifest
+	State = Dir{env: "XDG_STATE_HOME", fallback: filepath.Join(".local", "state")}
+	// Data holds files dot stores on the user's behalf
+	Data = Dir{env: "XDG_DATA_HOME", fallback: filepath.Join(".local", "share")}
+)
+
+// Home returns the base directory, from its variable or the default under the home directory
+// Relative values are ignored, as the XDG Base Directory specification requires
+func (d Dir) Home() (string, error) {
+	if home := os.Getenv(d.env); filepath.IsAbs(home) {
+		return home, nil
+	}
+	return d.defaultHome()
+}
+
+// defaultHome returns the base directory used when its variable is not set
+func (d Dir) defaultHome() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, d.fallback), nil
+}
+
+// Path returns the location of dot's file name in the directory
+func (d Dir) Path(name string) (string, error) {
+	home, err := d.Home()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "dot", name), nil
+}
+
+// file is one of dot's own files or directories
+type file struct {
+	dir  Dir
+	name string
+}
+
+// files are the files and directories Migrate moves, directories as a whole
+var files = []file{
+	{Config, "config.toml"},
+	{State, "history.jsonl"},
+	{State, "manifest.json"},
+	{State, "identities.txt"},
+	{Data, "backups"},
+	{Data, "rendered"},
+	{Data, "decrypted"},
+	{Data, "team"},
+}
+
+// Move is a file or directory that Migrate moved
+type Move struct {
+	From string
+	To   string
+}
+
+// Migrate moves dot's files written before their base directory was relocated, from
+// the default location to the one its variable now names, and returns what it moved
+// A file or directory already present at the new location is never overwritten
+func Migrate() ([]Move, error) {
+	var moves []Move
+	for _, f := range files {
+		current, err := f.dir.Path(f.name)
+		if err != nil {
+			return moves, err
+		}
+		home, err := f.dir.defaultHome()
+		if err != nil {
+			return moves, err
+		}
+		legacy := filepath.Join(home, "dot", f.name)
+
+		if utils.SamePath(legacy, current) || !utils.FileExists(legacy) || utils.FileExists(current) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(current), 0755); err != nil {
+			return moves, fmt.Errorf("failed to create %s: %w", filepath.Dir(current), err)
+		}
+		if err := utils.MovePath(legacy, current); err != nil {
+			return moves, fmt.Errorf("failed to move %s to %s: %w", legacy, current, err)
+		}
+		fmt.Fprintf(os.Stderr, "Moved %s to %s\n", legacy, current)
+		moves = append(moves, Move{From: legacy, To: current})
+	}
+	return moves, nil
+}
@@ -0,0 +1,138 @@
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	defer func() {
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+	}()
+	os.Setenv("HOME", "/home/user")
+
+	t.Run("Uses the variable", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", "/tmp/state")
+		path, err := State.Path("history.jsonl")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := filepath.Join("/tmp/state", "dot", "history.jsonl")
+		if path != expected {
+			t.Errorf("Expected %s, got %s", expected, path)
+		}
+	})
+
+	t.Run("Falls back to the default", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", "")
+		path, err := State.Path("history.jsonl")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := filepath.Join("/home/user", ".local", "state", "dot", "history.jsonl")
+		if path != expected {
+			t.Errorf("Expected %s, got %s", expected, path)
+		}
+	})
+
+	t.Run("Ignores relative values", func(t *testing.T) {
+		os.Setenv("XDG_STATE_HOME", "state")
+		path, err := State.Path("history.jsonl")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		expected := filepath.Join("/home/user", ".local", "state", "dot", "history.jsonl")
+		if path != expected {
+			t.Errorf("Expected %s, got %s", expected, path)
+		}
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	originalHome := os.Getenv("HOME")
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	originalDataHome := os.Getenv("XDG_DATA_HOME")
+	defer func() {
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+		os.Setenv("XDG_DATA_HOME", originalDataHome)
+	}()
+
+	homeDir := t.TempDir()
+	os.Setenv("HOME", homeDir)
+	os.Setenv("XDG_CONFIG_HOME", "")
+
+	legacyDir := filepath.Join(homeDir, ".local", "state", "dot")
+	os.MkdirAll(legacyDir, 0755)
+	os.WriteFile(filepath.Join(legacyDir, "history.jsonl"), []byte("old journal"), 0644)
+	os.WriteFile(filepath.Join(legacyDir, "manifest.json"), []byte("old manifest"), 0644)
+
+	stateHome := filepath.Join(homeDir, "state")
+	os.MkdirAll(filepath.Join(stateHome, "dot"), 0755)
+	os.WriteFile(filepath.Join(stateHome, "dot", "manifest.json"), []byte("new manifest"), 0644)
+	os.Setenv("XDG_STATE_HOME", stateHome)
+
+	legacyData := filepath.Join(homeDir, ".local", "share", "dot")
+	os.MkdirAll(filepath.Join(legacyData, "backups", "20240102-150405"), 0755)
+	os.WriteFile(filepath.Join(legacyData, "backups", "20240102-150405", "zshrc"), []byte("old backup"), 0644)
+	dataHome := filepath.Join(homeDir, "data")
+	os.Setenv("XDG_DATA_HOME", dataHome)
+
+	moves, err := Migrate()
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	t.Run("Moves files to the relocated directory", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join(stateHome, "dot", "history.jsonl"))
+		if err != nil {
+			t.Fatalf("Expected migrated journal: %v", err)
+		}
+		if string(data) != "old journal" {
+			t.Errorf("Expected 'old journal', got '%s'", data)
+		}
+		if _, err := os.Stat(filepath.Join(legacyDir, "history.jsonl")); !os.IsNotExist(err) {
+			t.Error("Expected the legacy journal to be gone")
+		}
+	})
+
+	t.Run("Keeps files already at the new location", func(t *testing.T) {
+		data, _ := os.ReadFile(filepath.Join(stateHome, "dot", "manifest.json"))
+		if string(data) != "new manifest" {
+			t.Errorf("Expected 'new manifest', got '%s'", data)
+		}
+		if _, err := os.Stat(filepath.Join(legacyDir, "manifest.json")); err != nil {
+			t.Error("Expected the legacy manifest to be left alone")
+		}
+	})
+
+	t.Run("Moves directories as a whole", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join(dataHome, "dot", "backups", "20240102-150405", "zshrc"))
+		if err != nil {
+			t.Fatalf("Expected migrated backups: %v", err)
+		}
+		if string(data) != "old backup" {
+			t.Errorf("Expected 'old backup', got '%s'", data)
+		}
+		if _, err := os.Stat(filepath.Join(legacyData, "backups")); !os.IsNotExist(err) {
+			t.Error("Expected the legacy backups to be gone")
+		}
+	})
+
+	t.Run("Returns what was moved", func(t *testing.T) {
+		expected := []Move{
+			{From: filepath.Join(legacyDir, "history.jsonl"), To: filepath.Join(stateHome, "dot", "history.jsonl")},
+			{From: filepath.Join(legacyData, "backups"), To: filepath.Join(dataHome, "dot", "backups")},
+		}
+		if !slices.Equal(moves, expected) {
+			t.Errorf("Expected %v, got %v", expected, moves)
+		}
+	})
+}
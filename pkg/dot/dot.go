@@ -0,0 +1,59 @@
+// Package dot links, checks, cleans, and lists dotfiles like the dot command does, for
+// Go programs that embed it
+// The dotfiles repository is found like the command finds it, from $DOT_DIR or the
+// global config, and nothing is printed; every function returns the outcome of each
+// mapping instead; hooks still write their output to stderr
+// Runs change package state of dot and must not overlap
+package dot
+
+import "github.com/yourusername/dot/internal/linker"
+
+// Result is the outcome of one mapping of a run, or for List the state of its link
+type Result = linker.LinkResult
+
+// Actions of a Result
+const (
+	ActionChanged     = linker.ActionChanged
+	ActionUnchanged   = linker.ActionUnchanged
+	ActionSkipped     = linker.ActionSkipped
+	ActionFailed      = linker.ActionFailed
+	ActionInterrupted = linker.ActionInterrupted
+	ActionOK          = linker.ActionOK
+	ActionIssue       = linker.ActionIssue
+)
+
+// LinkOptions controls how Link processes the mappings
+type LinkOptions = linker.LinkOptions
+
+// CleanOptions controls how Clean processes the mappings
+type CleanOptions = linker.CleanOptions
+
+// Link links the mappings of the profiles, [general] if none are given
+func Link(profiles []string, opts LinkOptions) ([]Result, error) {
+	return linker.LinkResults(orGeneral(profiles), opts)
+}
+
+// Check checks the links of the profiles, [general] if none are given; the error
+// reports how many issues were found, which are the results with ActionIssue
+func Check(profiles []string) ([]Result, error) {
+	return linker.CheckResults(orGeneral(profiles))
+}
+
+// Clean removes the links of the profiles, [general] if none are given
+func Clean(profiles []string, opts CleanOptions) ([]Result, error) {
+	return linker.CleanResults(orGeneral(profiles), opts)
+}
+
+// List returns the state of the link of every mapping of the profiles, [general] if
+// none are given; the action of a result is a state like linked or not-linked
+func List(profiles []string) ([]Result, error) {
+	return linker.ListResults(orGeneral(profiles))
+}
+
+// orGeneral returns profiles, or [general] if there are none
+func orGeneral(profiles []string) []string {
+	if len(profiles) == 0 {
+		return []string{"general"}
+	}
+	return profiles
+}
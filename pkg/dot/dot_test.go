@@ -0,0 +1,48 @@
+package dot
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestLinkAndList(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+
+	originalDotDir := os.Getenv("DOT_DIR")
+	originalHome := os.Getenv("HOME")
+	originalStateHome := os.Getenv("XDG_STATE_HOME")
+	originalConfigHome := os.Getenv("XDG_CONFIG_HOME")
+	defer func() {
+		os.Setenv("DOT_DIR", originalDotDir)
+		os.Setenv("HOME", originalHome)
+		os.Setenv("XDG_STATE_HOME", originalStateHome)
+		os.Setenv("XDG_CONFIG_HOME", originalConfigHome)
+	}()
+	dotfilesDir, home := t.TempDir(), t.TempDir()
+	os.Setenv("DOT_DIR", dotfilesDir)
+	os.Setenv("HOME", home)
+	os.Setenv("XDG_STATE_HOME", t.TempDir())
+	os.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	os.WriteFile(filepath.Join(dotfilesDir, ".mappings"), []byte("[general]\n\"zshrc\" = \"~/.zshrc\"\n"), 0644)
+	os.WriteFile(filepath.Join(dotfilesDir, "zshrc"), []byte("# zsh\n"), 0644)
+
+	results, err := Link(nil, LinkOptions{})
+	if err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Action != ActionChanged || results[0].Target != filepath.Join(home, ".zshrc") {
+		t.Errorf("Expected ~/.zshrc to be linked, got %+v", results)
+	}
+
+	results, err = List(nil)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Action != "linked" {
+		t.Errorf("Expected ~/.zshrc to be listed as linked, got %+v", results)
+	}
+}